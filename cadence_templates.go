@@ -0,0 +1,9 @@
+package main
+
+import "embed"
+
+// Cadence transaction and script templates are embedded in the binary, they
+// can be overridden at runtime from FLOW_PDS_CADENCE_TEMPLATE_DIR.
+//
+//go:embed cadence-transactions cadence-scripts
+var cadenceTemplates embed.FS
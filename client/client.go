@@ -0,0 +1,170 @@
+// Package client is a Go client for the Flow PDS API.
+//
+// The types and operations in client_gen.go are generated from the API
+// definition (./reference in the flow-pds repository), do not edit them by hand.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the Flow PDS API at BaseURL, e.g. "http://localhost:3000/v1".
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Bearer token for the admin endpoints, optional
+	AdminToken string
+	// API key of an issuer, sent with the other requests, optional
+	APIKey string
+	// Address and hex encoded shared secret of an issuer to sign the other
+	// requests with instead of sending an API key, optional
+	SigningIssuer string
+	SigningSecret string
+}
+
+// New returns a client for the API at 'baseURL' using http.DefaultClient.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is returned for any non 2xx response. Code and Detail are read from
+// the problem details (RFC 7807) of the response.
+type Error struct {
+	StatusCode int
+	Code       string // Machine readable, e.g. "insufficient_escrow"
+	Detail     string
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context sending 'key' as the Idempotency-Key
+// of requests made with it, so retrying e.g. CreateDistribution with the same
+// key and input returns the distribution created first.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}, admin bool) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok && key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if admin && c.AdminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.AdminToken)
+	} else if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	} else if !admin && c.SigningSecret != "" {
+		if err := c.sign(req, b); err != nil {
+			return err
+		}
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		e := &Error{StatusCode: res.StatusCode, Body: strings.TrimSpace(string(resBody))}
+		var problem struct {
+			Code   string `json:"code"`
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(resBody, &problem) == nil {
+			e.Code, e.Detail = problem.Code, problem.Detail
+		}
+		return e
+	}
+
+	if out == nil || len(resBody) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resBody, out)
+}
+
+// sign sets the headers of a request signed with SigningSecret: the hex
+// encoded HMAC-SHA256 of the timestamp, nonce, method and request URI (path
+// and query) joined by "." followed by a "." and the body.
+func (c *Client) sign(req *http.Request, body []byte) error {
+	key, err := hex.DecodeString(c.SigningSecret)
+	if err != nil {
+		return fmt.Errorf("invalid signing secret: %w", err)
+	}
+
+	n := make([]byte, 16)
+	if _, err := rand.Read(n); err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(n)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, req.Method, req.URL.RequestURI()}, ".")))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	req.Header.Set("X-PDS-Issuer", c.SigningIssuer)
+	req.Header.Set("X-PDS-Timestamp", timestamp)
+	req.Header.Set("X-PDS-Nonce", nonce)
+	req.Header.Set("X-PDS-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	return nil
+}
@@ -0,0 +1,2188 @@
+// Code generated by tools/clientgen from the Flow PDS API definition. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// APIKey API key of an issuer. The key itself is only returned when created.
+type APIKey struct {
+	Id     string      `json:"id,omitempty"`
+	Issuer FlowAddress `json:"issuer,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	// Start of the key, identifies it in listings
+	Prefix    string     `json:"prefix,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Collectible contracts distributions created or updated with the key may use, any the issuer may use if empty
+	AllowedCollectibleContracts []ContractReference `json:"allowedCollectibleContracts,omitempty"`
+	// Only returned when created
+	Key string `json:"key,omitempty"`
+}
+
+// BucketCreate A bucket from which to pick collectibles into a pack.
+type BucketCreate struct {
+	// Optional, overrides the collectibleReference of the pack template. Packs can hold collectibles of several contracts, which must have different names.
+	CollectibleReference  *ContractReference `json:"collectibleReference,omitempty"`
+	CollectibleCount      int64              `json:"collectibleCount"`
+	CollectibleCollection []int64            `json:"collectibleCollection"`
+	// Optional. Collectibles of the collection by tier (e.g. rarity), the tier of each slot is disclosed from teaseNotBefore of the pack template.
+	CollectibleTiers map[string]interface{} `json:"collectibleTiers,omitempty"`
+	// Optional. Slots of the bucket are filled by tier in proportion to these weights (e.g. {"common": 90, "rare": 10}), requires collectibleTiers for all collectibles.
+	TierWeights map[string]interface{} `json:"tierWeights,omitempty"`
+	// Optional. Number of slots of each pack guaranteed to a tier (e.g. {"rare": 1}), the other slots are filled by tierWeights.
+	GuaranteedTiers map[string]interface{} `json:"guaranteedTiers,omitempty"`
+}
+
+type BucketGet struct {
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	CollectibleCount     int64              `json:"collectibleCount,omitempty"`
+	// Optional. Collectibles of the collection by tier (e.g. rarity), the tier of each slot is disclosed from teaseNotBefore of the pack template.
+	CollectibleTiers map[string]interface{} `json:"collectibleTiers,omitempty"`
+	// Optional. Slots of the bucket are filled by tier in proportion to these weights (e.g. {"common": 90, "rare": 10}), requires collectibleTiers for all collectibles.
+	TierWeights map[string]interface{} `json:"tierWeights,omitempty"`
+	// Optional. Number of slots of each pack guaranteed to a tier (e.g. {"rare": 1}), the other slots are filled by tierWeights.
+	GuaranteedTiers map[string]interface{} `json:"guaranteedTiers,omitempty"`
+}
+
+// CollectibleIDReservation Collectible IDs reserved for a pack, to be minted when the pack is opened.
+type CollectibleIDReservation struct {
+	PackID               string             `json:"packID,omitempty"`
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	CollectibleIDs       []int64            `json:"collectibleIDs,omitempty"`
+	CreatedAt            *time.Time         `json:"createdAt,omitempty"`
+}
+
+// CollectibleOwnershipCheck Report of checking that the issuer owns all collectibles of a distribution before it is set up, listing each collectible missing from the collection of the issuer.
+type CollectibleOwnershipCheck struct {
+	DistID    string      `json:"distID,omitempty"`
+	CheckedAt *time.Time  `json:"checkedAt,omitempty"`
+	Issuer    FlowAddress `json:"issuer,omitempty"`
+	// False if any collectible is missing, the distribution is then aborted
+	Passed       bool                                   `json:"passed,omitempty"`
+	CheckedCount int64                                  `json:"checkedCount,omitempty"`
+	MissingCount int64                                  `json:"missingCount,omitempty"`
+	Missing      []CollectibleOwnershipCheckMissingItem `json:"missing,omitempty"`
+}
+
+type CollectibleOwnershipCheckMissingItem struct {
+	BucketIndex int64  `json:"bucketIndex,omitempty"`
+	FlowID      int64  `json:"flowID,omitempty"`
+	Collectible string `json:"collectible,omitempty"`
+}
+
+// Collection Groups related distributions of an issuer, e.g. the drops of a season. Distributions of the collection use its policies where they leave them out.
+type Collection struct {
+	CollectionID         string             `json:"collectionID,omitempty"`
+	Issuer               FlowAddress        `json:"issuer,omitempty"`
+	Name                 string             `json:"name,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	CreatedAt            *time.Time         `json:"createdAt,omitempty"`
+	UpdatedAt            *time.Time         `json:"updatedAt,omitempty"`
+	PackReference        *ContractReference `json:"packReference,omitempty"`
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	AccessAPIHost        string             `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL     string             `json:"revealWebhookURL,omitempty"`
+	Stats                *CollectionStats   `json:"stats,omitempty"`
+}
+
+// CollectionStats Roll-up of the distributions of a collection.
+type CollectionStats struct {
+	DistributionCount    int64                  `json:"distributionCount,omitempty"`
+	DistributionsByState map[string]interface{} `json:"distributionsByState,omitempty"`
+	PackCount            int64                  `json:"packCount,omitempty"`
+	// Minted, not revealed
+	SealedCount int64 `json:"sealedCount,omitempty"`
+	// Revealed, not opened
+	RevealedCount int64 `json:"revealedCount,omitempty"`
+	OpenedCount   int64 `json:"openedCount,omitempty"`
+}
+
+// CompletionReport Summary of a distribution stored when it was closed, after all packs were opened or the reveal window expired.
+type CompletionReport struct {
+	DistID    string     `json:"distID,omitempty"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+	Reason    string     `json:"reason,omitempty"` // One of: all-opened, reveal-window-expired
+	PackCount int64      `json:"packCount,omitempty"`
+	// Packs never revealed
+	SealedCount int64 `json:"sealedCount,omitempty"`
+	// Packs revealed but never opened
+	RevealedCount int64 `json:"revealedCount,omitempty"`
+	OpenedCount   int64 `json:"openedCount,omitempty"`
+	// Packs revoked before being opened
+	RevokedCount int64 `json:"revokedCount,omitempty"`
+	// Collectibles of unopened packs left in escrow
+	UnopenedCollectibleCount int64 `json:"unopenedCollectibleCount,omitempty"`
+	TransactionCount         int64 `json:"transactionCount,omitempty"`
+	// Failed or dead-letter transactions
+	FailedTransactionCount int64 `json:"failedTransactionCount,omitempty"`
+	// Transaction destroying the capabilities the issuer shared with the PDS
+	CloseTransactionID string `json:"closeTransactionID,omitempty"`
+}
+
+// ContractReference Way of referencing a contract on Flow.
+type ContractReference struct {
+	Name    string      `json:"name"`
+	Address FlowAddress `json:"address"`
+}
+
+type CreateApiKeyRequest struct {
+	// Identifies the key in listings
+	Name string `json:"name"`
+	// Optional, collectible contracts distributions created or updated with the key may use. They have to be allowed for the issuer, any the issuer may use if empty.
+	AllowedCollectibleContracts []ContractReference `json:"allowedCollectibleContracts,omitempty"`
+}
+
+type CreateCollectionRequest struct {
+	Issuer               FlowAddress        `json:"issuer"`
+	Name                 string             `json:"name"`
+	Description          string             `json:"description,omitempty"`
+	PackReference        *ContractReference `json:"packReference,omitempty"`
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	// Must be allowed by the service configuration
+	AccessAPIHost    string `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string `json:"revealWebhookURL,omitempty"`
+}
+
+type CreateDistributionRequest struct {
+	DistFlowID   int64              `json:"distFlowID"`
+	Issuer       FlowAddress        `json:"issuer"`
+	PackTemplate PackTemplateCreate `json:"packTemplate"`
+	// Optional Access API host to use for this distribution, must be allowed by the service configuration
+	AccessAPIHost string `json:"accessAPIHost,omitempty"`
+	// Optional URL receiving the pack.teased and pack.revealed events of the two-stage reveal
+	RevealWebhookURL string                `json:"revealWebhookURL,omitempty"`
+	Metadata         *DistributionMetadata `json:"metadata,omitempty"`
+	// Optional collection of the issuer to add the distribution to. Pack and collectible references (left empty), accessAPIHost and revealWebhookURL left out are taken from the collection.
+	CollectionID string `json:"collectionID,omitempty"`
+	// Optional template of the issuer to create the distribution from. packTemplate.buckets give the collectibles of the buckets of the template in the same order, their collectible references and counts as well as the pack reference, packCount, accessAPIHost, revealWebhookURL and collectionID left out are taken from the template.
+	TemplateID string `json:"templateID,omitempty"`
+	// Optional time to start the distribution at, must be in the future. The distribution is resolved right away and stays in the scheduled state until then, it is set up and starts settling once the time has passed.
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+	// Optional, resolve the packs from a seed anchored on-chain with a commit-reveal scheme: the PDS commits to a secret when setting up the distribution, derives the seed from it and a later block once that is sealed and reveals the secret once settled.
+	SeedCommitReveal bool `json:"seedCommitReveal,omitempty"`
+	// Optional, version of the commitment hash scheme of the packs, defaults to the current version. Use an older version for pack contracts verifying reveals with an older scheme.
+	CommitmentHashVersion int64 `json:"commitmentHashVersion,omitempty"`
+}
+
+type CreateDistributionTemplateRequest struct {
+	Issuer        FlowAddress        `json:"issuer"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	PackReference *ContractReference `json:"packReference,omitempty"`
+	PackCount     int64              `json:"packCount,omitempty"`
+	Buckets       []TemplateBucket   `json:"buckets"`
+	// Default collectible contract of the buckets
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	// Must be allowed by the service configuration
+	AccessAPIHost    string `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string `json:"revealWebhookURL,omitempty"`
+	CollectionID     string `json:"collectionID,omitempty"`
+}
+
+type CreateGiftIntentsRequest struct {
+	Gifts []CreateGiftIntentsRequestGiftsItem `json:"gifts"`
+	// Optional, pending intents expire at this time
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Optional, a reminder webhook is sent at this time if the pack has not been transferred yet, requires webhookURL
+	RemindAt *time.Time `json:"remindAt,omitempty"`
+	// Optional, receives a POST with a JSON body when the transfer is observed (gift.transferred), a reminder is due (gift.reminder) or the intent expires (gift.expired)
+	WebhookURL string `json:"webhookURL,omitempty"`
+}
+
+type CreateGiftIntentsRequestGiftsItem struct {
+	PackFlowID int64       `json:"packFlowID"`
+	Recipient  FlowAddress `json:"recipient"`
+}
+
+type CreateIssuerRequest struct {
+	Address                     FlowAddress         `json:"address"`
+	Name                        string              `json:"name"`
+	AllowedCollectibleContracts []ContractReference `json:"allowedCollectibleContracts,omitempty"`
+}
+
+type CreateIssuerWebhookRequest struct {
+	Url string `json:"url"`
+}
+
+type DistributionClone struct {
+	// Onchain ID of the new distribution, created by the issuer
+	DistFlowID int64 `json:"distFlowID"`
+}
+
+// DistributionCosts FLOW paid in transaction fees for the transactions sent on behalf of a distribution.
+type DistributionCosts struct {
+	DistID string `json:"distID,omitempty"`
+	// Total fees in FLOW
+	Fees string `json:"fees,omitempty"`
+	// Number of transaction sends, including retries
+	Attempts int64 `json:"attempts,omitempty"`
+	// Fees per Cadence template (e.g. settle, mint)
+	ByName []DistributionCostsByNameItem `json:"byName,omitempty"`
+}
+
+type DistributionCostsByNameItem struct {
+	Name     string `json:"name,omitempty"`
+	Fees     string `json:"fees,omitempty"`
+	Attempts int64  `json:"attempts,omitempty"`
+}
+
+type DistributionCreateOk struct {
+	DistID     string `json:"distID,omitempty"`
+	DistFlowID int64  `json:"distFlowID,omitempty"`
+}
+
+// DistributionETA Estimate of when the settlement and minting of a distribution complete.
+type DistributionETA struct {
+	DistID string `json:"distID,omitempty"`
+	State  string `json:"state,omitempty"` // One of: init, invalid, resolved, scheduled, setup, settling, settled, minting, complete, closed, cancelled, stalled, draft
+	// Collectibles settled into escrow
+	SettledCount int64 `json:"settledCount,omitempty"`
+	// Collectibles to settle, 0 until settling starts
+	SettleTotal int64 `json:"settleTotal,omitempty"`
+	// Packs minted, in any state after minting
+	MintedCount int64 `json:"mintedCount,omitempty"`
+	PackCount   int64 `json:"packCount,omitempty"`
+	// Settle transactions left, of the current batch size
+	SettleBatches int64 `json:"settleBatches,omitempty"`
+	// Mint transactions left, of the current batch size
+	MintBatches int64 `json:"mintBatches,omitempty"`
+	// Average milliseconds from sending a batch transaction to its result
+	SealLatencyMs int64 `json:"sealLatencyMs,omitempty"`
+	// True if the seal latency is of the latest sealed batches of the distribution, false if it is the configured estimate
+	SealLatencyObserved bool `json:"sealLatencyObserved,omitempty"`
+	// Left out unless settling
+	EstimatedSettledAt *time.Time `json:"estimatedSettledAt,omitempty"`
+	// The distribution completes once minted, left out unless settling or minting
+	EstimatedMintedAt *time.Time `json:"estimatedMintedAt,omitempty"`
+}
+
+// DistributionExportPack A pack in the export of a distribution.
+type DistributionExportPack struct {
+	PackID string `json:"packID,omitempty"`
+	// PackNFT ID, null if never minted
+	FlowID         int64  `json:"flowID,omitempty"`
+	State          string `json:"state,omitempty"`
+	CommitmentHash string `json:"commitmentHash,omitempty"`
+	// Salt of the commitment hash, only if requested
+	Salt string `json:"salt,omitempty"`
+	// Collectibles of the pack
+	Collectibles []string    `json:"collectibles,omitempty"`
+	Owner        FlowAddress `json:"owner,omitempty"`
+}
+
+type DistributionGet struct {
+	DistID           string                `json:"distID,omitempty"`
+	DistFlowID       int64                 `json:"distFlowID,omitempty"`
+	CreatedAt        *time.Time            `json:"createdAt,omitempty"`
+	UpdatedAt        *time.Time            `json:"updatedAt,omitempty"`
+	Issuer           FlowAddress           `json:"issuer,omitempty"`
+	State            string                `json:"state,omitempty"` // One of: init, resolved, scheduled, settling, settled, complete, closed, cancelled, stalled, draft
+	PackTemplate     *PackTemplateGet      `json:"packTemplate,omitempty"`
+	AccessAPIHost    string                `json:"accessAPIHost,omitempty"`
+	IssuerBranding   *IssuerBranding       `json:"issuerBranding,omitempty"`
+	Metadata         *DistributionMetadata `json:"metadata,omitempty"`
+	RevealWebhookURL string                `json:"revealWebhookURL,omitempty"`
+	TeasedAt         *time.Time            `json:"teasedAt,omitempty"`
+	CollectionID     string                `json:"collectionID,omitempty"`
+	// Template the distribution was created from
+	TemplateID string `json:"templateID,omitempty"`
+	// Set once archived
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	// Set while paused, no new settle or mint batches are sent
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	// Set while stalled, the state the distribution timed out in
+	StalledState string `json:"stalledState,omitempty"`
+	// The distribution is scheduled until this time
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+	// Set if the packs are resolved from a seed anchored on-chain with a commit-reveal scheme
+	SeedCommitReveal bool `json:"seedCommitReveal,omitempty"`
+	// Height of the block the seed is derived from along with the committed secret, set once set up
+	SeedAnchorHeight int64 `json:"seedAnchorHeight,omitempty"`
+	// ID of the anchor block, set once the packs are resolved from the anchored seed
+	SeedAnchorBlockID string `json:"seedAnchorBlockID,omitempty"`
+	// Version of the commitment hash scheme of the packs
+	CommitmentHashVersion int64 `json:"commitmentHashVersion,omitempty"`
+}
+
+type DistributionList struct {
+	DistID       string                `json:"distID,omitempty"`
+	DistFlowID   int64                 `json:"distFlowID,omitempty"`
+	CreatedAt    *time.Time            `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time            `json:"updatedAt,omitempty"`
+	Issuer       FlowAddress           `json:"issuer,omitempty"`
+	CollectionID string                `json:"collectionID,omitempty"`
+	Metadata     *DistributionMetadata `json:"metadata,omitempty"`
+	// Set once archived
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	// Set while paused, no new settle or mint batches are sent
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+	// The distribution is scheduled until this time
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+	State             string     `json:"state,omitempty"` // One of: init, resolved, scheduled, settling, settled, complete, closed, cancelled, stalled, draft
+}
+
+// DistributionMetadata Optional display metadata of a distribution, e.g. to render the drop on a marketplace.
+type DistributionMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Absolute https, http or ipfs URI
+	ImageURI string `json:"imageURI,omitempty"`
+	// Set if the packs are minted with the metadata, see FLOW_PDS_PACK_METADATA_ON_CHAIN
+	OnChain bool `json:"onChain,omitempty"`
+}
+
+// DistributionProgress Progress of the settlement and minting of a distribution, the data of each distribution event.
+type DistributionProgress struct {
+	State string `json:"state,omitempty"` // One of: init, invalid, resolved, scheduled, setup, settling, settled, minting, complete, closed, cancelled, stalled, draft
+	// Collectibles settled into escrow
+	SettledCount int64 `json:"settledCount,omitempty"`
+	// Collectibles to settle, 0 until settling starts
+	SettleTotal int64 `json:"settleTotal,omitempty"`
+	// Packs minted, in any state after minting
+	MintedCount int64 `json:"mintedCount,omitempty"`
+	PackCount   int64 `json:"packCount,omitempty"`
+}
+
+// DistributionResolution Seed the packs of a distribution were resolved from and whether the packs stored match the packs the seed resolves to.
+type DistributionResolution struct {
+	DistID string `json:"distID"`
+	// Seed of the random number generator which shuffled the collectibles into packs, a signed 64-bit integer
+	Seed string `json:"seed"`
+	// Version of the algorithm distributing collectibles into packs
+	AlgorithmVersion int64 `json:"algorithmVersion"`
+	// Packs of the distribution
+	PackCount int64 `json:"packCount"`
+	// Packs holding the collectibles of a pack resolved from the seed
+	MatchingCount int64 `json:"matchingCount"`
+	// Set if every pack matches a pack resolved from the seed, and for commit-reveal distributions the seed derives from the secret and anchor block
+	Verified bool `json:"verified"`
+	// Hex encoded secret committed to on-chain, commit-reveal distributions only
+	SeedSecret string `json:"seedSecret,omitempty"`
+	// Height of the anchor block, commit-reveal distributions only
+	SeedAnchorHeight int64 `json:"seedAnchorHeight,omitempty"`
+	// ID of the anchor block, the seed is the first 8 bytes (big endian) of SHA2-256(secret || anchor block ID), commit-reveal distributions only
+	SeedAnchorBlockID string `json:"seedAnchorBlockID,omitempty"`
+}
+
+// DistributionRetry Result of retrying the settlement or minting of a stuck or stalled distribution.
+type DistributionRetry struct {
+	State string `json:"state,omitempty"` // One of: setup, settling, settled, minting
+	// Events are handled up to this block height
+	CheckpointBlock int64 `json:"checkpointBlock,omitempty"`
+	// Dead-letter transactions replaced by the new batches
+	CancelledTransactions int64 `json:"cancelledTransactions,omitempty"`
+	// Collectibles (settling) or packs (minting) queued again
+	RequeuedCount int64 `json:"requeuedCount,omitempty"`
+	// New settle or mint transactions
+	QueuedTransactions int64 `json:"queuedTransactions,omitempty"`
+	// Set if the distribution was stalled, it is set back to the state it timed out in
+	Unstalled bool `json:"unstalled,omitempty"`
+}
+
+// DistributionSummary Overview of a distribution for dashboards: packs per state, slot fill rates, progress, transaction errors and timing.
+type DistributionSummary struct {
+	DistID string `json:"distID,omitempty"`
+	State  string `json:"state,omitempty"` // One of: init, invalid, resolved, scheduled, setup, settling, settled, minting, complete, closed, cancelled, stalled, draft
+	// Collectibles settled into escrow
+	SettledCount int64 `json:"settledCount,omitempty"`
+	// Collectibles to settle, 0 until settling starts
+	SettleTotal int64 `json:"settleTotal,omitempty"`
+	// Percent of the collectibles to settle which are settled
+	SettledPercent float64 `json:"settledPercent,omitempty"`
+	// Packs minted, in any state after minting
+	MintedCount int64 `json:"mintedCount,omitempty"`
+	PackCount   int64 `json:"packCount,omitempty"`
+	// Percent of the packs which are minted
+	MintedPercent float64                `json:"mintedPercent,omitempty"`
+	PacksByState  map[string]interface{} `json:"packsByState,omitempty"`
+	// One per bucket of the pack template
+	Slots               []DistributionSummarySlotsItem `json:"slots,omitempty"`
+	TransactionsByState map[string]interface{}         `json:"transactionsByState,omitempty"`
+	// Failed and dead-letter transactions
+	ErrorCount        int64      `json:"errorCount,omitempty"`
+	CreatedAt         *time.Time `json:"createdAt,omitempty"`
+	SettlingStartedAt *time.Time `json:"settlingStartedAt,omitempty"`
+	// Set once all collectibles are settled
+	SettledAt        *time.Time `json:"settledAt,omitempty"`
+	MintingStartedAt *time.Time `json:"mintingStartedAt,omitempty"`
+	// Set when minting completes
+	MintedAt *time.Time `json:"mintedAt,omitempty"`
+}
+
+type DistributionSummarySlotsItem struct {
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	// Slots of the bucket in all packs
+	SlotCount int64 `json:"slotCount,omitempty"`
+	// Collectibles of the bucket to fill the slots with
+	AvailableCount int64 `json:"availableCount,omitempty"`
+	// Percent of the slots the collectibles of the bucket can fill
+	FillRate float64 `json:"fillRate,omitempty"`
+}
+
+// DistributionTemplate Reusable drop format of an issuer. Distributions created from the template (templateID) only give the collectibles of each bucket, the template fills in what they leave out.
+type DistributionTemplate struct {
+	TemplateID    string             `json:"templateID,omitempty"`
+	Issuer        FlowAddress        `json:"issuer,omitempty"`
+	Name          string             `json:"name,omitempty"`
+	Description   string             `json:"description,omitempty"`
+	CreatedAt     *time.Time         `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time         `json:"updatedAt,omitempty"`
+	PackReference *ContractReference `json:"packReference,omitempty"`
+	// Used by distributions which leave out packCount
+	PackCount int64 `json:"packCount,omitempty"`
+	// Buckets of the distributions, in order
+	Buckets          []TemplateBucket `json:"buckets,omitempty"`
+	AccessAPIHost    string           `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string           `json:"revealWebhookURL,omitempty"`
+	CollectionID     string           `json:"collectionID,omitempty"`
+}
+
+// DistributionUpdate Changes to a distribution which has not started settling, fields left out are not changed.
+type DistributionUpdate struct {
+	PackTemplate     *DistributionUpdatePackTemplate `json:"packTemplate,omitempty"`
+	AccessAPIHost    string                          `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string                          `json:"revealWebhookURL,omitempty"`
+	Metadata         *DistributionMetadata           `json:"metadata,omitempty"`
+	// Must be in the future, reschedules the distribution
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+}
+
+type DistributionUpdatePackTemplate struct {
+	PackReference        *ContractReference `json:"packReference,omitempty"`
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	PackCount            int64              `json:"packCount,omitempty"`
+	// Replaces all buckets of the pack template, collectibleReference is the default of these buckets.
+	Buckets         []BucketCreate `json:"buckets,omitempty"`
+	RevealNotBefore *time.Time     `json:"revealNotBefore,omitempty"`
+	TeaseNotBefore  *time.Time     `json:"teaseNotBefore,omitempty"`
+}
+
+// EscrowSurplus Collectibles of a distribution left in the PDS escrow which no pack needs.
+type EscrowSurplus struct {
+	DistID string `json:"distID"`
+	// Surplus collectibles ordered by contract and ID, as A.<address>.<contract>.<id>
+	Collectibles []string `json:"collectibles"`
+	// Return transactions queued, only set when returning the surplus
+	QueuedTransactions int64 `json:"queuedTransactions"`
+}
+
+// FlowAddress An accounts address on Flow.
+type FlowAddress string
+
+// FungibleTokenAmount An amount of a fungible token (e.g. FLOW) included in each pack, escrowed from the issuer during settlement and deposited to the owner when the pack is opened.
+type FungibleTokenAmount struct {
+	TokenReference ContractReference `json:"tokenReference"`
+	// Amount per pack, as a UFix64 decimal string.
+	Amount string `json:"amount"`
+	// Identifier of the public path of the token receiver of pack owners and the issuer.
+	ReceiverPath string `json:"receiverPath"`
+}
+
+// GiftIntent An intent to transfer (gift) a minted pack to a recipient. The state changes to transferred once a deposit of the pack to the recipient is observed onchain.
+type GiftIntent struct {
+	GiftIntentID  string      `json:"giftIntentID,omitempty"`
+	DistID        string      `json:"distID,omitempty"`
+	CreatedAt     *time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time  `json:"updatedAt,omitempty"`
+	PackFlowID    int64       `json:"packFlowID,omitempty"`
+	Recipient     FlowAddress `json:"recipient,omitempty"`
+	State         string      `json:"state,omitempty"` // One of: pending, transferred, expired
+	ExpiresAt     *time.Time  `json:"expiresAt,omitempty"`
+	RemindAt      *time.Time  `json:"remindAt,omitempty"`
+	WebhookURL    string      `json:"webhookURL,omitempty"`
+	TransferredAt *time.Time  `json:"transferredAt,omitempty"`
+	// ID of the transaction which transferred the pack, empty if the recipient already owned the pack when the intent was registered
+	TransferTransactionID string `json:"transferTransactionID,omitempty"`
+}
+
+// GraphQLRequest A GraphQL query, see GET /graphql/schema for the schema.
+type GraphQLRequest struct {
+	Query string `json:"query"`
+	// Operation to execute if the query has several
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse Result of a GraphQL query. Fields which failed to resolve are null in data and listed in errors; data is left out if the query could not be executed.
+type GraphQLResponse struct {
+	Data   map[string]interface{}      `json:"data,omitempty"`
+	Errors []GraphQLResponseErrorsItem `json:"errors,omitempty"`
+}
+
+type GraphQLResponseErrorsItem struct {
+	Message string `json:"message"`
+	// Response keys and list indexes leading to the field
+	Path []interface{} `json:"path,omitempty"`
+}
+
+// IssuerBranding Display metadata of an issuer, included in the distributions and packs of the issuer.
+type IssuerBranding struct {
+	Issuer      FlowAddress `json:"issuer"`
+	DisplayName string      `json:"displayName"`
+	// Absolute https, http or ipfs URI
+	LogoURI string `json:"logoURI,omitempty"`
+	// Absolute https, http or mailto URL
+	SupportURL string    `json:"supportURL,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// IssuerCallback A verified callback of an issuer system to the PDS.
+type IssuerCallback struct {
+	Id     string      `json:"id,omitempty"`
+	Issuer FlowAddress `json:"issuer,omitempty"`
+	// ID chosen by the issuer, a callback ID is accepted only once
+	CallbackID string `json:"callbackID,omitempty"`
+	// Event of the callback, for example payment.confirmed (requires packID)
+	Event  string `json:"event,omitempty"`
+	PackID string `json:"packID,omitempty"`
+	// Optional, stored as sent by the issuer
+	Data      map[string]interface{} `json:"data,omitempty"`
+	SignedAt  *time.Time             `json:"signedAt,omitempty"`
+	CreatedAt *time.Time             `json:"createdAt,omitempty"`
+}
+
+// IssuerCallbackSecret Shared secret an issuer signs its callbacks with, only returned when generated.
+type IssuerCallbackSecret struct {
+	Issuer FlowAddress `json:"issuer,omitempty"`
+	// Hex encoded HMAC-SHA256 key
+	Secret    string     `json:"secret,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// IssuerRegistration Issuer organization registered with the service.
+type IssuerRegistration struct {
+	Address FlowAddress `json:"address,omitempty"`
+	Name    string      `json:"name,omitempty"`
+	// Collectible contracts distributions of the issuer may use, any if empty
+	AllowedCollectibleContracts []ContractReference `json:"allowedCollectibleContracts,omitempty"`
+	CreatedAt                   *time.Time          `json:"createdAt,omitempty"`
+	UpdatedAt                   *time.Time          `json:"updatedAt,omitempty"`
+}
+
+// IssuerWebhook Webhook receiving the state changes of the distributions and packs of an issuer. The secret is only returned when created.
+type IssuerWebhook struct {
+	Id     string      `json:"id,omitempty"`
+	Issuer FlowAddress `json:"issuer,omitempty"`
+	Url    string      `json:"url,omitempty"`
+	// API key the webhook was registered with, it stops receiving events once the key is revoked
+	ApiKeyID  string     `json:"apiKeyID,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Only returned when created. Hex encoded HMAC-SHA256 key of the X-PDS-Signature header of deliveries, signed like issuer callbacks.
+	Secret string `json:"secret,omitempty"`
+}
+
+// KeyRotation A rotate-and-freeze operation: sending was frozen, the admin keys revoked with the recovery key and the standby keys switched to.
+type KeyRotation struct {
+	KeyRotationID string    `json:"keyRotationID"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	// Sending stays frozen if the rotation failed
+	State             string  `json:"state"` // One of: revoking, complete, failed
+	Reason            string  `json:"reason,omitempty"`
+	RevokedKeyIndexes []int64 `json:"revokedKeyIndexes"`
+	StandbyKeyIndexes []int64 `json:"standbyKeyIndexes"`
+	// Flow ID of the revoke transaction
+	FlowTransactionID string `json:"flowTransactionID,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// OwnedCollectibles IDs of the collectibles of a contract held by an account.
+type OwnedCollectibles struct {
+	Address              FlowAddress        `json:"address,omitempty"`
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	CollectibleIDs       []int64            `json:"collectibleIDs,omitempty"`
+}
+
+// OwnedPack Public fields of a pack believed to be held by an account, as tracked from the Withdraw and Deposit events of the PackNFT contract
+type OwnedPack struct {
+	PackID         string             `json:"packID,omitempty"`
+	DistID         string             `json:"distID,omitempty"`
+	PackReference  *ContractReference `json:"packReference,omitempty"`
+	FlowID         int64              `json:"flowID,omitempty"`
+	State          string             `json:"state,omitempty"`
+	CommitmentHash string             `json:"commitmentHash,omitempty"`
+}
+
+// OwnershipVerification Onchain pack ownership verification of a distribution, with a report of packs whose onchain owner does not match the owner in database.
+type OwnershipVerification struct {
+	VerificationID   string                                   `json:"verificationID,omitempty"`
+	DistID           string                                   `json:"distID,omitempty"`
+	CreatedAt        *time.Time                               `json:"createdAt,omitempty"`
+	UpdatedAt        *time.Time                               `json:"updatedAt,omitempty"`
+	State            string                                   `json:"state,omitempty"` // One of: init, running, complete
+	CheckedCount     int64                                    `json:"checkedCount,omitempty"`
+	DiscrepancyCount int64                                    `json:"discrepancyCount,omitempty"`
+	Discrepancies    []OwnershipVerificationDiscrepanciesItem `json:"discrepancies,omitempty"`
+}
+
+type OwnershipVerificationDiscrepanciesItem struct {
+	PackID        string      `json:"packID,omitempty"`
+	PackFlowID    int64       `json:"packFlowID,omitempty"`
+	ExpectedOwner FlowAddress `json:"expectedOwner,omitempty"`
+	Reason        string      `json:"reason,omitempty"` // One of: unknown-owner, not-owned
+}
+
+// Pack A public representation of a Pack
+type Pack struct {
+	PackID         string `json:"packID,omitempty"`
+	DistID         string `json:"distID,omitempty"`
+	FlowID         int64  `json:"flowID,omitempty"`
+	State          string `json:"state,omitempty"`
+	CommitmentHash string `json:"commitmentHash,omitempty"`
+	// Set if the pack was revoked
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Set on a replacement pack, ID of the pack it replaces
+	ReplacesPackID string          `json:"replacesPackID,omitempty"`
+	IssuerBranding *IssuerBranding `json:"issuerBranding,omitempty"`
+	// Tier of each slot of the pack, once the distribution is teased. Empty for collectibles without a tier.
+	Teaser []string `json:"teaser,omitempty"`
+	// Collectibles of the pack, once revealed.
+	Collectibles []string `json:"collectibles,omitempty"`
+}
+
+// PackList Public fields of a pack of a distribution
+type PackList struct {
+	PackID         string `json:"packID,omitempty"`
+	FlowID         int64  `json:"flowID,omitempty"`
+	State          string `json:"state,omitempty"`
+	CommitmentHash string `json:"commitmentHash,omitempty"`
+}
+
+// PackProof How the commitment hash of a revealed pack is computed from its salt and contents, to verify the revealed contents match the hash committed on-chain when the pack was minted.
+type PackProof struct {
+	PackID string `json:"packID"`
+	// ID of the PackNFT
+	FlowID        int64             `json:"flowID,omitempty"`
+	PackReference ContractReference `json:"packReference"`
+	// Hex encoded commitment hash stored in the PackNFT at mint
+	CommitmentHash string `json:"commitmentHash"`
+	// Version of the commitment hash scheme of the distribution
+	CommitmentHashVersion int64 `json:"commitmentHashVersion"`
+	// Hash algorithm of the scheme, e.g. SHA2-256
+	Algorithm string `json:"algorithm"`
+	// Hex encoded salt of the pack
+	Salt string `json:"salt"`
+	// Collectibles of the pack in slot order, as A.<address>.<contract>.<id>
+	Collectibles []string `json:"collectibles"`
+	// Input of the hash algorithm
+	Preimage string `json:"preimage"`
+	// Hex encoded hash of the preimage
+	ComputedHash string `json:"computedHash"`
+	// Set if the computed hash is the commitment hash
+	Verified bool `json:"verified"`
+}
+
+type PackReplace struct {
+	// Why the pack is replaced, not public
+	Reason string `json:"reason,omitempty"`
+}
+
+// PackReplacement A replacement pack minted for a damaged one.
+type PackReplacement struct {
+	DistID            string `json:"distID"`
+	OriginalPackID    string `json:"originalPackID"`
+	ReplacementPackID string `json:"replacementPackID"`
+	Reason            string `json:"reason"`
+	// Latest transaction minting the replacement
+	MintTransactionID string `json:"mintTransactionID"`
+	// Set once the replacement is minted, or failed to mint
+	Complete    bool       `json:"complete"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// PackReveal Packs of a distribution to reveal (and open) on behalf of the issuer.
+type PackReveal struct {
+	// Offchain IDs of the packs, sealed packs to reveal them, or revealed packs to open them.
+	PackIDs []string `json:"packIDs"`
+	// Also open the packs, to the believed owner of each pack. Defaults to false.
+	Open bool `json:"open,omitempty"`
+}
+
+// PackRevealResult Transaction stored for a pack of a bulk reveal.
+type PackRevealResult struct {
+	PackID        string `json:"packID,omitempty"`
+	TransactionID string `json:"transactionID,omitempty"`
+	// reveal for a sealed pack (opened too if requested), open for a revealed pack
+	Action string `json:"action,omitempty"` // One of: reveal, open
+}
+
+// PackRevocation A revoked pack.
+type PackRevocation struct {
+	PackID string `json:"packID"`
+	// ID of the PackNFT
+	FlowID    int64     `json:"flowID"`
+	RevokedAt time.Time `json:"revokedAt"`
+	Reason    string    `json:"reason"`
+	// Transaction freezing the PackNFT onchain, if any
+	TransactionID string `json:"transactionID,omitempty"`
+}
+
+type PackRevoke struct {
+	// Why the pack is revoked, not public
+	Reason string `json:"reason,omitempty"`
+}
+
+// PackTemplateCreate A template from which to generate packs.
+type PackTemplateCreate struct {
+	PackReference        ContractReference `json:"packReference"`
+	CollectibleReference ContractReference `json:"collectibleReference"`
+	PackCount            int64             `json:"packCount"`
+	Buckets              []BucketCreate    `json:"buckets"`
+	// Optional. Set if the pack contract does not allow revealing packs before this time, reveal requests received earlier are processed once it passes.
+	RevealNotBefore *time.Time `json:"revealNotBefore,omitempty"`
+	// Optional. Discloses the tier of each slot of the packs from this time on, must be before revealNotBefore.
+	TeaseNotBefore *time.Time `json:"teaseNotBefore,omitempty"`
+	// Optional, a fungible token amount included in each pack.
+	FungibleToken *FungibleTokenAmount `json:"fungibleToken,omitempty"`
+}
+
+type PackTemplateGet struct {
+	PackReference *ContractReference `json:"packReference,omitempty"`
+	PackCount     int64              `json:"packCount,omitempty"`
+	Buckets       []BucketGet        `json:"buckets,omitempty"`
+	// Optional. Set if the pack contract does not allow revealing packs before this time, reveal requests received earlier are processed once it passes.
+	RevealNotBefore *time.Time `json:"revealNotBefore,omitempty"`
+	// Optional. Discloses the tier of each slot of the packs from this time on, must be before revealNotBefore.
+	TeaseNotBefore *time.Time `json:"teaseNotBefore,omitempty"`
+	// Optional, a fungible token amount included in each pack.
+	FungibleToken *FungibleTokenAmount `json:"fungibleToken,omitempty"`
+}
+
+// PublicStats Aggregate numbers over the distributions of opted in issuers. Counts are left out while too few issuers have opted in.
+type PublicStats struct {
+	// Packs minted, rounded down
+	PacksMinted int64 `json:"packsMinted,omitempty"`
+	// Distributions completed, rounded down
+	DistributionsCompleted int64     `json:"distributionsCompleted,omitempty"`
+	UpdatedAt              time.Time `json:"updatedAt"`
+}
+
+type ReceiveIssuerCallbackRequest struct {
+	CallbackID string                 `json:"callbackID"`
+	Event      string                 `json:"event"`
+	PackID     string                 `json:"packID,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type ReserveCollectibleIdsRequest struct {
+	CollectibleReference ContractReference `json:"collectibleReference"`
+	Count                int64             `json:"count"`
+}
+
+type RevealSchedule struct {
+	// When the packs of the distribution can be revealed, reveal and open requests received earlier are processed once it passes. Must be in the future and after teaseNotBefore, if set. Left out (or null) opens the reveals at once.
+	RevealNotBefore *time.Time `json:"revealNotBefore,omitempty"`
+}
+
+type RotateAndFreezeRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type SetDistCapRequest struct {
+	Issuer FlowAddress `json:"issuer,omitempty"`
+}
+
+type SetIssuerBrandingRequest struct {
+	DisplayName string `json:"displayName"`
+	LogoURI     string `json:"logoURI,omitempty"`
+	SupportURL  string `json:"supportURL,omitempty"`
+}
+
+type SetPublicStatsOptInRequest struct {
+	OptIn bool `json:"optIn"`
+}
+
+// TemplateBucket Structure of a bucket of a distribution template, without its collectibles.
+type TemplateBucket struct {
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	// How many collectibles of the bucket go in each pack
+	CollectibleCount int64 `json:"collectibleCount"`
+}
+
+// Transaction A Flow transaction sent by the PDS.
+type Transaction struct {
+	TransactionID string     `json:"transactionID,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	// Cadence template the transaction was built from
+	Name  string `json:"name,omitempty"`
+	State string `json:"state,omitempty"` // One of: init, retry, sent, failed, complete, dead-letter, cancelled
+	// Send lane of the transaction
+	Priority string `json:"priority,omitempty"` // One of: user-facing, settlement, minting
+	// Version of the stored transaction format of the PDS which created it
+	JobVersion int64 `json:"jobVersion,omitempty"`
+	// Error of the latest attempt
+	Error      string `json:"error,omitempty"`
+	RetryCount int64  `json:"retryCount,omitempty"`
+	// Flow ID of the latest sent transaction
+	FlowTransactionID string `json:"flowTransactionID,omitempty"`
+	DistID            string `json:"distID,omitempty"`
+	PackID            string `json:"packID,omitempty"`
+	// JSON-Cadence encoded arguments
+	Arguments []map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// TransactionAttempt A single send of a transaction by the PDS, as recorded in the audit log.
+type TransactionAttempt struct {
+	AttemptID string `json:"attemptID,omitempty"`
+	// Offchain ID of the transaction
+	TransactionID string     `json:"transactionID,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	// 1 for the first send of the transaction
+	Attempt int64 `json:"attempt,omitempty"`
+	// Cadence template the transaction was built from
+	Name string `json:"name,omitempty"`
+	// Flow ID of the sent transaction
+	FlowTransactionID string `json:"flowTransactionID,omitempty"`
+	// Hex encoded SHA-256 hash of the Cadence script
+	ScriptHash string `json:"scriptHash,omitempty"`
+	// JSON-Cadence encoded arguments
+	Arguments            []map[string]interface{} `json:"arguments,omitempty"`
+	ProposerKeyIndex     int64                    `json:"proposerKeyIndex,omitempty"`
+	ReferenceBlockHeight int64                    `json:"referenceBlockHeight,omitempty"`
+	// Hex encoded RLP of the signed transaction
+	Rlp string `json:"rlp,omitempty"`
+	// sent until the outcome is known, retry if the transaction was sent again
+	State string `json:"state,omitempty"` // One of: sent, retry, failed, complete
+	Error string `json:"error,omitempty"`
+}
+
+type UpdateDistributionTemplateRequest struct {
+	Issuer        FlowAddress        `json:"issuer,omitempty"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	PackReference *ContractReference `json:"packReference,omitempty"`
+	PackCount     int64              `json:"packCount,omitempty"`
+	Buckets       []TemplateBucket   `json:"buckets"`
+	// Default collectible contract of the buckets
+	CollectibleReference *ContractReference `json:"collectibleReference,omitempty"`
+	// Must be allowed by the service configuration
+	AccessAPIHost    string `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string `json:"revealWebhookURL,omitempty"`
+	CollectionID     string `json:"collectionID,omitempty"`
+}
+
+type UpdateIssuerRequest struct {
+	Name                        string              `json:"name"`
+	AllowedCollectibleContracts []ContractReference `json:"allowedCollectibleContracts,omitempty"`
+}
+
+// HealthReady Health check
+//
+// Simple health check, will always respond with 200 OK.
+//
+// GET /health/ready
+func (c *Client) HealthReady(ctx context.Context) error {
+	path := "/health/ready"
+	query := url.Values{}
+	return c.do(ctx, http.MethodGet, path, query, nil, nil, false)
+}
+
+// GetPublicStats Get public stats
+//
+// Aggregate numbers for public status pages, counts only opted in issuers. Does not require authentication.
+//
+// GET /stats
+func (c *Client) GetPublicStats(ctx context.Context) (PublicStats, error) {
+	path := "/stats"
+	query := url.Values{}
+	var res PublicStats
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetSystemConfig Get configuration
+//
+// Returns the effective configuration of the running instance with secrets redacted.
+//
+// GET /system/config
+func (c *Client) GetSystemConfig(ctx context.Context) (map[string]interface{}, error) {
+	path := "/system/config"
+	query := url.Values{}
+	var res map[string]interface{}
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// ListDeadLetterTransactionsParams are the optional query parameters of ListDeadLetterTransactions.
+type ListDeadLetterTransactionsParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListDeadLetterTransactions List dead-letter transactions
+//
+// Lists transactions which ran out of attempts, most recently updated first. A distribution waiting for a dead-letter transaction does not progress until it is requeued.
+//
+// GET /transactions/dead-letter
+func (c *Client) ListDeadLetterTransactions(ctx context.Context, params *ListDeadLetterTransactionsParams) ([]Transaction, error) {
+	path := "/transactions/dead-letter"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []Transaction
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// RequeueTransaction Requeue transaction
+//
+// Resets a dead-letter transaction to be rebuilt and sent again, its attempts start over.
+//
+// POST /transactions/{transactionId}/requeue
+func (c *Client) RequeueTransaction(ctx context.Context, transactionId string) (Transaction, error) {
+	path := "/transactions/" + url.PathEscape(string(transactionId)) + "/requeue"
+	query := url.Values{}
+	var res Transaction
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// RotateAndFreeze Rotate keys and freeze
+//
+// For a suspected key compromise. Freezes sending, revokes the admin keys onchain using the recovery key and switches to the standby keys. Sending is resumed once the revoke transaction is sealed, if the rotation fails sending stays frozen.
+//
+// POST /keys/rotate-and-freeze
+func (c *Client) RotateAndFreeze(ctx context.Context, body RotateAndFreezeRequest) (KeyRotation, error) {
+	path := "/keys/rotate-and-freeze"
+	query := url.Values{}
+	var res KeyRotation
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// FreezeSending Freeze sending
+//
+// Stops sending any transactions until unfrozen. Transactions keep being queued.
+//
+// POST /sending/freeze
+func (c *Client) FreezeSending(ctx context.Context) error {
+	path := "/sending/freeze"
+	query := url.Values{}
+	return c.do(ctx, http.MethodPost, path, query, nil, nil, true)
+}
+
+// UnfreezeSending Unfreeze sending
+//
+// Resumes sending transactions.
+//
+// POST /sending/unfreeze
+func (c *Client) UnfreezeSending(ctx context.Context) error {
+	path := "/sending/unfreeze"
+	query := url.Values{}
+	return c.do(ctx, http.MethodPost, path, query, nil, nil, true)
+}
+
+// SetDistCap Set distribution capability
+//
+// Share the create distribution capability to issuer.
+//
+// POST /set-dist-cap
+func (c *Client) SetDistCap(ctx context.Context, body SetDistCapRequest) (string, error) {
+	path := "/set-dist-cap"
+	query := url.Values{}
+	var res string
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListOwnedCollectiblesParams are the optional query parameters of ListOwnedCollectibles.
+type ListOwnedCollectiblesParams struct {
+	// Optional if collectible contracts are configured for the network
+	ContractAddress *FlowAddress
+	Limit           *int64
+	Offset          *int64
+}
+
+// ListOwnedCollectibles List owned collectibles
+//
+// Runs a script returning the IDs of the collectibles of a contract held by an account (e.g. a treasury account), useful for building the buckets of a distribution. The list is empty if the account has no public collection.
+//
+// GET /accounts/{address}/collectibles
+func (c *Client) ListOwnedCollectibles(ctx context.Context, address FlowAddress, contractName string, params *ListOwnedCollectiblesParams) (OwnedCollectibles, error) {
+	path := "/accounts/" + url.PathEscape(string(address)) + "/collectibles"
+	query := url.Values{}
+	query.Set("contractName", string(contractName))
+	if params != nil {
+		if params.ContractAddress != nil {
+			query.Set("contractAddress", string(*params.ContractAddress))
+		}
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res OwnedCollectibles
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ListOwnerPacksParams are the optional query parameters of ListOwnerPacks.
+type ListOwnerPacksParams struct {
+	Limit  *int64
+	Offset *int64
+	// Comma separated pack states, "minted" for all states after minting, "unopened" for minted packs not yet opened, "mint-failed" for packs which failed to mint too many times
+	State *string
+}
+
+// ListOwnerPacks List owned packs
+//
+// Lists the packs of any distribution held by an account in order of creation, e.g. to show the unopened packs of a user with ?state=unopened. Ownership is tracked from the Withdraw and Deposit events of the circulating PackNFT contracts, so transfers show up once the PDS has polled their blocks. The total number of packs in the given states is returned in the X-Total-Count header.
+//
+// GET /owners/{address}/packs
+func (c *Client) ListOwnerPacks(ctx context.Context, address FlowAddress, params *ListOwnerPacksParams) ([]OwnedPack, error) {
+	path := "/owners/" + url.PathEscape(string(address)) + "/packs"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+		if params.State != nil {
+			query.Set("state", string(*params.State))
+		}
+	}
+	var res []OwnedPack
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// CreateIssuer Register issuer
+//
+// Registers an issuer organization. Distributions of the issuer may only use the allowed collectible contracts, any if none are given.
+//
+// POST /issuers
+func (c *Client) CreateIssuer(ctx context.Context, body CreateIssuerRequest) (IssuerRegistration, error) {
+	path := "/issuers"
+	query := url.Values{}
+	var res IssuerRegistration
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// ListIssuersParams are the optional query parameters of ListIssuers.
+type ListIssuersParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListIssuers List issuers
+//
+// Lists registered issuers in the order they were registered.
+//
+// GET /issuers
+func (c *Client) ListIssuers(ctx context.Context, params *ListIssuersParams) ([]IssuerRegistration, error) {
+	path := "/issuers"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []IssuerRegistration
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// GetIssuer Get issuer
+//
+// Returns a registered issuer.
+//
+// GET /issuers/{address}
+func (c *Client) GetIssuer(ctx context.Context, address FlowAddress) (IssuerRegistration, error) {
+	path := "/issuers/" + url.PathEscape(string(address))
+	query := url.Values{}
+	var res IssuerRegistration
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// UpdateIssuer Update issuer
+//
+// Replaces the name and allowed collectible contracts of a registered issuer. Existing distributions are not affected.
+//
+// PUT /issuers/{address}
+func (c *Client) UpdateIssuer(ctx context.Context, address FlowAddress, body UpdateIssuerRequest) (IssuerRegistration, error) {
+	path := "/issuers/" + url.PathEscape(string(address))
+	query := url.Values{}
+	var res IssuerRegistration
+	err := c.do(ctx, http.MethodPut, path, query, body, &res, true)
+	return res, err
+}
+
+// SetIssuerBranding Set issuer branding
+//
+// Sets the display name, logo and support URL of an issuer, replacing any earlier branding. Included in the distributions and packs of the issuer.
+//
+// PUT /issuers/{address}/branding
+func (c *Client) SetIssuerBranding(ctx context.Context, address FlowAddress, body SetIssuerBrandingRequest) (IssuerBranding, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/branding"
+	query := url.Values{}
+	var res IssuerBranding
+	err := c.do(ctx, http.MethodPut, path, query, body, &res, false)
+	return res, err
+}
+
+// GetIssuerBranding Get issuer branding
+//
+// Returns the branding of an issuer.
+//
+// GET /issuers/{address}/branding
+func (c *Client) GetIssuerBranding(ctx context.Context, address FlowAddress) (IssuerBranding, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/branding"
+	query := url.Values{}
+	var res IssuerBranding
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// RotateIssuerCallbackSecret Rotate issuer callback secret
+//
+// Generates a new shared secret the issuer signs its callbacks with, replacing any earlier one. The secret is only returned here.
+//
+// POST /issuers/{address}/callback-secret
+func (c *Client) RotateIssuerCallbackSecret(ctx context.Context, address FlowAddress) (IssuerCallbackSecret, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/callback-secret"
+	query := url.Values{}
+	var res IssuerCallbackSecret
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// ReceiveIssuerCallback Receive issuer callback
+//
+// Receives a callback of an issuer system, for example confirming an off-chain payment of a pack. The callback is verified against the callback secret of the issuer and stored, each callback ID is accepted once.
+//
+// POST /issuers/{address}/callbacks
+func (c *Client) ReceiveIssuerCallback(ctx context.Context, address FlowAddress, body ReceiveIssuerCallbackRequest) (IssuerCallback, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/callbacks"
+	query := url.Values{}
+	var res IssuerCallback
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListIssuerCallbacksParams are the optional query parameters of ListIssuerCallbacks.
+type ListIssuerCallbacksParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListIssuerCallbacks List issuer callbacks
+//
+// Lists the received callbacks of an issuer, most recent first.
+//
+// GET /issuers/{address}/callbacks
+func (c *Client) ListIssuerCallbacks(ctx context.Context, address FlowAddress, params *ListIssuerCallbacksParams) ([]IssuerCallback, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/callbacks"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []IssuerCallback
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// SetPublicStatsOptIn Set public stats opt-in
+//
+// Opts an issuer in or out of the public stats, issuers are opted out by default.
+//
+// PUT /issuers/{address}/public-stats
+func (c *Client) SetPublicStatsOptIn(ctx context.Context, address FlowAddress, body SetPublicStatsOptInRequest) error {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/public-stats"
+	query := url.Values{}
+	return c.do(ctx, http.MethodPut, path, query, body, nil, false)
+}
+
+// CreateApiKey Create API key
+//
+// Creates an API key for the issuer. The key is only returned here, the service stores its hash.
+//
+// POST /issuers/{address}/api-keys
+func (c *Client) CreateApiKey(ctx context.Context, address FlowAddress, body CreateApiKeyRequest) (APIKey, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/api-keys"
+	query := url.Values{}
+	var res APIKey
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// ListApiKeys List API keys
+//
+// Lists the API keys of the issuer, including revoked ones.
+//
+// GET /issuers/{address}/api-keys
+func (c *Client) ListApiKeys(ctx context.Context, address FlowAddress) ([]APIKey, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/api-keys"
+	query := url.Values{}
+	var res []APIKey
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// RevokeApiKey Revoke API key
+//
+// Revokes an API key of the issuer, requests with it are rejected from then on.
+//
+// POST /issuers/{address}/api-keys/{apiKeyId}/revoke
+func (c *Client) RevokeApiKey(ctx context.Context, address FlowAddress, apiKeyId string) (APIKey, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/api-keys/" + url.PathEscape(string(apiKeyId)) + "/revoke"
+	query := url.Values{}
+	var res APIKey
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// CreateIssuerWebhook Register webhook
+//
+// Registers a URL to receive the webhook events of the issuer: distribution.<state> on each state change of a distribution and pack.revealed, pack.opened and pack.revoked. The secret signing the deliveries is only returned here. Webhook endpoints always require authentication, even without FLOW_PDS_API_KEYS_REQUIRED. Deliveries to private, loopback and link-local addresses fail unless FLOW_PDS_ISSUER_WEBHOOK_ALLOW_PRIVATE is set.
+//
+// POST /issuers/{address}/webhooks
+func (c *Client) CreateIssuerWebhook(ctx context.Context, address FlowAddress, body CreateIssuerWebhookRequest) (IssuerWebhook, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/webhooks"
+	query := url.Values{}
+	var res IssuerWebhook
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListIssuerWebhooks List webhooks
+//
+// Lists the webhooks of the issuer.
+//
+// GET /issuers/{address}/webhooks
+func (c *Client) ListIssuerWebhooks(ctx context.Context, address FlowAddress) ([]IssuerWebhook, error) {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/webhooks"
+	query := url.Values{}
+	var res []IssuerWebhook
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// DeleteIssuerWebhook Delete webhook
+//
+// Deletes a webhook of the issuer, its queued deliveries are dropped.
+//
+// DELETE /issuers/{address}/webhooks/{webhookId}
+func (c *Client) DeleteIssuerWebhook(ctx context.Context, address FlowAddress, webhookId string) error {
+	path := "/issuers/" + url.PathEscape(string(address)) + "/webhooks/" + url.PathEscape(string(webhookId))
+	query := url.Values{}
+	return c.do(ctx, http.MethodDelete, path, query, nil, nil, false)
+}
+
+// GetPackById Get Pack
+//
+// Returns the public details of a pack.
+//
+// GET /packs/{packId}
+func (c *Client) GetPackById(ctx context.Context, packId string) (Pack, error) {
+	path := "/packs/" + url.PathEscape(string(packId))
+	query := url.Values{}
+	var res Pack
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetPackByCommitmentHash Get Pack by commitment hash
+//
+// Resolves a pack by its onchain commitment hash, e.g. to answer what is in a given pack. Returns the distribution and state of the pack and its collectibles once revealed.
+//
+// GET /packs/by-commitment-hash/{commitmentHash}
+func (c *Client) GetPackByCommitmentHash(ctx context.Context, commitmentHash string) (Pack, error) {
+	path := "/packs/by-commitment-hash/" + url.PathEscape(string(commitmentHash))
+	query := url.Values{}
+	var res Pack
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetPackByFlowIdParams are the optional query parameters of GetPackByFlowId.
+type GetPackByFlowIdParams struct {
+	// Pack NFT contract, e.g. A.0ae53cb6e3f42a79.PackNFT
+	PackReference *string
+}
+
+// GetPackByFlowId Get Pack by Flow ID
+//
+// Resolves a pack by its PackNFT ID, e.g. to answer what is in a given pack. Returns the distribution and state of the pack and its collectibles once revealed. PackNFT IDs are unique per contract, packReference is required if packs of more than one contract have the ID.
+//
+// GET /packs/by-flow-id/{flowId}
+func (c *Client) GetPackByFlowId(ctx context.Context, flowId int64, params *GetPackByFlowIdParams) (Pack, error) {
+	path := "/packs/by-flow-id/" + url.PathEscape(strconv.FormatInt(int64(flowId), 10))
+	query := url.Values{}
+	if params != nil {
+		if params.PackReference != nil {
+			query.Set("packReference", string(*params.PackReference))
+		}
+	}
+	var res Pack
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetPackProof Get Pack Proof
+//
+// Returns the salt of a revealed pack and how its commitment hash is computed, to verify its contents independently.
+//
+// GET /packs/{packId}/proof
+func (c *Client) GetPackProof(ctx context.Context, packId string) (PackProof, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/proof"
+	query := url.Values{}
+	var res PackProof
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ReserveCollectibleIds Reserve collectible IDs
+//
+// Reserves unique IDs of a collectible contract for a pack whose collectibles are minted when it is opened. IDs are allocated from a persisted counter per contract so concurrent opens never get the same IDs. Reserving again for the same pack and contract returns the earlier reservation.
+//
+// POST /packs/{packId}/collectible-ids
+func (c *Client) ReserveCollectibleIds(ctx context.Context, packId string, body ReserveCollectibleIdsRequest) (CollectibleIDReservation, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/collectible-ids"
+	query := url.Values{}
+	var res CollectibleIDReservation
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// ListCollectibleIdReservations List collectible ID reservations
+//
+// Lists the collectible IDs reserved for a pack.
+//
+// GET /packs/{packId}/collectible-ids
+func (c *Client) ListCollectibleIdReservations(ctx context.Context, packId string) ([]CollectibleIDReservation, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/collectible-ids"
+	query := url.Values{}
+	var res []CollectibleIDReservation
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// ForceRevealPackParams are the optional query parameters of ForceRevealPack.
+type ForceRevealPackParams struct {
+	// Also open the pack once revealed, like a reveal request asking to open
+	OpenRequest *bool
+}
+
+// ForceRevealPack Force pack reveal
+//
+// Stores a new reveal transaction for a pack whose reveal request was handled but whose reveal transaction failed. The pack has to be in reveal-request-handled state and sealed onchain, with a known owner and no reveal transaction in flight.
+//
+// POST /packs/{packId}/force-reveal
+func (c *Client) ForceRevealPack(ctx context.Context, packId string, params *ForceRevealPackParams) (Transaction, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/force-reveal"
+	query := url.Values{}
+	if params != nil {
+		if params.OpenRequest != nil {
+			query.Set("openRequest", strconv.FormatBool(bool(*params.OpenRequest)))
+		}
+	}
+	var res Transaction
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// ForceOpenPack Force pack open
+//
+// Stores a new open transaction for a pack whose open request was handled but whose open transaction failed. The pack has to be in open-request-handled state and revealed onchain, with a known owner and no open transaction in flight.
+//
+// POST /packs/{packId}/force-open
+func (c *Client) ForceOpenPack(ctx context.Context, packId string) (Transaction, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/force-open"
+	query := url.Values{}
+	var res Transaction
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// RevokePack Revoke pack
+//
+// Revokes a minted, unopened pack (sealed or revealed), e.g. one sold fraudulently. Reveal and open requests of the pack are ignored from then on. With FLOW_PDS_PACK_REVOCATION_ON_CHAIN a transaction freezing the PackNFT onchain is queued, unless the distribution is closed.
+//
+// POST /packs/{packId}/revoke
+func (c *Client) RevokePack(ctx context.Context, packId string, body PackRevoke) (PackRevocation, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/revoke"
+	query := url.Values{}
+	var res PackRevocation
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// ReplacePack Replace pack
+//
+// Mints a replacement for a revoked pack, or a pack whose reveal transactions failed (it is revoked first), of a complete distribution. Each slot gets a collectible of the same bucket and tier from the reserve of the distribution, collectibles of its buckets held in escrow which no pack uses. The replacement is minted to the issuer.
+//
+// POST /packs/{packId}/replace
+func (c *Client) ReplacePack(ctx context.Context, packId string, body PackReplace) (PackReplacement, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/replace"
+	query := url.Values{}
+	var res PackReplacement
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, true)
+	return res, err
+}
+
+// GetPackReplacement Get pack replacement
+//
+// Returns the replacement of a pack and whether it is minted.
+//
+// GET /packs/{packId}/replacement
+func (c *Client) GetPackReplacement(ctx context.Context, packId string) (PackReplacement, error) {
+	path := "/packs/" + url.PathEscape(string(packId)) + "/replacement"
+	query := url.Values{}
+	var res PackReplacement
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// CreateCollection Create Collection
+//
+// Create a collection grouping related distributions of an issuer (e.g. a season). The optional policies are used by distributions of the collection which leave them out.
+//
+// POST /collections
+func (c *Client) CreateCollection(ctx context.Context, body CreateCollectionRequest) (Collection, error) {
+	path := "/collections"
+	query := url.Values{}
+	var res Collection
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListCollectionsParams are the optional query parameters of ListCollections.
+type ListCollectionsParams struct {
+	// Only list the collections of this issuer
+	Issuer *FlowAddress
+	Limit  *int64
+	Offset *int64
+}
+
+// ListCollections List collections
+//
+// List collections, most recent first.
+//
+// GET /collections
+func (c *Client) ListCollections(ctx context.Context, params *ListCollectionsParams) ([]Collection, error) {
+	path := "/collections"
+	query := url.Values{}
+	if params != nil {
+		if params.Issuer != nil {
+			query.Set("issuer", string(*params.Issuer))
+		}
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []Collection
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetCollectionById Get Collection
+//
+// Returns a collection with the roll-up stats of its distributions and their packs.
+//
+// GET /collections/{collectionId}
+func (c *Client) GetCollectionById(ctx context.Context, collectionId string) (Collection, error) {
+	path := "/collections/" + url.PathEscape(string(collectionId))
+	query := url.Values{}
+	var res Collection
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ListCollectionDistributionsParams are the optional query parameters of ListCollectionDistributions.
+type ListCollectionDistributionsParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListCollectionDistributions List collection distributions
+//
+// List the distributions of a collection, most recent first.
+//
+// GET /collections/{collectionId}/distributions
+func (c *Client) ListCollectionDistributions(ctx context.Context, collectionId string, params *ListCollectionDistributionsParams) ([]DistributionList, error) {
+	path := "/collections/" + url.PathEscape(string(collectionId)) + "/distributions"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []DistributionList
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// CreateDistributionTemplate Create Distribution Template
+//
+// Create a reusable drop format of an issuer: the pack contract, pack count, bucket structure and settings of its distributions. Distributions created with its templateID only give the collectibles of each bucket.
+//
+// POST /distribution-templates
+func (c *Client) CreateDistributionTemplate(ctx context.Context, body CreateDistributionTemplateRequest) (DistributionTemplate, error) {
+	path := "/distribution-templates"
+	query := url.Values{}
+	var res DistributionTemplate
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListDistributionTemplatesParams are the optional query parameters of ListDistributionTemplates.
+type ListDistributionTemplatesParams struct {
+	// Only list the templates of this issuer
+	Issuer *FlowAddress
+	Limit  *int64
+	Offset *int64
+}
+
+// ListDistributionTemplates List distribution templates
+//
+// List distribution templates, most recent first.
+//
+// GET /distribution-templates
+func (c *Client) ListDistributionTemplates(ctx context.Context, params *ListDistributionTemplatesParams) ([]DistributionTemplate, error) {
+	path := "/distribution-templates"
+	query := url.Values{}
+	if params != nil {
+		if params.Issuer != nil {
+			query.Set("issuer", string(*params.Issuer))
+		}
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []DistributionTemplate
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetDistributionTemplateById Get Distribution Template
+//
+// GET /distribution-templates/{templateId}
+func (c *Client) GetDistributionTemplateById(ctx context.Context, templateId string) (DistributionTemplate, error) {
+	path := "/distribution-templates/" + url.PathEscape(string(templateId))
+	query := url.Values{}
+	var res DistributionTemplate
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// UpdateDistributionTemplate Update Distribution Template
+//
+// Replaces a distribution template, its issuer can not change. Distributions already created from it are not changed.
+//
+// PUT /distribution-templates/{templateId}
+func (c *Client) UpdateDistributionTemplate(ctx context.Context, templateId string, body UpdateDistributionTemplateRequest) (DistributionTemplate, error) {
+	path := "/distribution-templates/" + url.PathEscape(string(templateId))
+	query := url.Values{}
+	var res DistributionTemplate
+	err := c.do(ctx, http.MethodPut, path, query, body, &res, false)
+	return res, err
+}
+
+// DeleteDistributionTemplate Delete Distribution Template
+//
+// Deletes a distribution template, distributions already created from it are kept.
+//
+// DELETE /distribution-templates/{templateId}
+func (c *Client) DeleteDistributionTemplate(ctx context.Context, templateId string) error {
+	path := "/distribution-templates/" + url.PathEscape(string(templateId))
+	query := url.Values{}
+	return c.do(ctx, http.MethodDelete, path, query, nil, nil, false)
+}
+
+// CreateDistribution Create Distribution
+//
+// Create a distribution. If template is valid, a distribution is created in database and both the offchain (distID) and the onchain (distFlowID) IDs are returned. All the related tasks are started asynchronously (settling and minting).
+//
+// POST /distributions
+func (c *Client) CreateDistribution(ctx context.Context, body CreateDistributionRequest) (DistributionCreateOk, error) {
+	path := "/distributions"
+	query := url.Values{}
+	var res DistributionCreateOk
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListDistributionsParams are the optional query parameters of ListDistributions.
+type ListDistributionsParams struct {
+	Limit  *int64
+	Offset *int64
+	// Comma separated states, e.g. "settling,minting"
+	State        *string
+	Issuer       *FlowAddress
+	CreatedAfter *time.Time
+	Sort         *string
+	// List only archived distributions instead of leaving them out
+	Archived *bool
+}
+
+// ListDistributions List distributions
+//
+// Lists distributions matching the filters, newest first unless sorted otherwise. The total number of matching distributions is returned in the X-Total-Count header.
+//
+// GET /distributions
+func (c *Client) ListDistributions(ctx context.Context, params *ListDistributionsParams) ([]DistributionList, error) {
+	path := "/distributions"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+		if params.State != nil {
+			query.Set("state", string(*params.State))
+		}
+		if params.Issuer != nil {
+			query.Set("issuer", string(*params.Issuer))
+		}
+		if params.CreatedAfter != nil {
+			query.Set("createdAfter", (*params.CreatedAfter).Format(time.RFC3339))
+		}
+		if params.Sort != nil {
+			query.Set("sort", string(*params.Sort))
+		}
+		if params.Archived != nil {
+			query.Set("archived", strconv.FormatBool(bool(*params.Archived)))
+		}
+	}
+	var res []DistributionList
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// CreateDistributions Create distributions in bulk
+//
+// Create several distributions at once, either all of them or none. All distributions are validated before any is stored, an error names the index of the failing distribution (or the row of the CSV). Idempotency keys are not supported.
+//
+// POST /distributions/bulk
+func (c *Client) CreateDistributions(ctx context.Context, body []CreateDistributionRequest) ([]DistributionCreateOk, error) {
+	path := "/distributions/bulk"
+	query := url.Values{}
+	var res []DistributionCreateOk
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// GetDistributionById Get Distribution
+//
+// Returns the details for a distribution.
+//
+// GET /distributions/{distributionId}
+func (c *Client) GetDistributionById(ctx context.Context, distributionId string) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId))
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// UpdateDistribution Update distribution
+//
+// Fixes the bucket definitions, pack count or other settings of a distribution which has not started settling, instead of creating a new one. The distribution is resolved again with new packs. Resolved or scheduled distributions not yet set up and aborted (invalid) distributions which never started settling can be updated, aborted ones are started again and their state onchain set back to initialized.
+//
+// PATCH /distributions/{distributionId}
+func (c *Client) UpdateDistribution(ctx context.Context, distributionId string, body DistributionUpdate) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId))
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPatch, path, query, body, &res, false)
+	return res, err
+}
+
+// CancelDistributionParams are the optional query parameters of CancelDistribution.
+type CancelDistributionParams struct {
+	// Return the escrowed collectibles of the cancelled packs to the issuer
+	ReturnEscrow *bool
+}
+
+// CancelDistribution Cancel distribution
+//
+// Cancels a distribution, same as aborting it.
+//
+// DELETE /distributions/{distributionId}
+func (c *Client) CancelDistribution(ctx context.Context, distributionId string, params *CancelDistributionParams) error {
+	path := "/distributions/" + url.PathEscape(string(distributionId))
+	query := url.Values{}
+	if params != nil {
+		if params.ReturnEscrow != nil {
+			query.Set("returnEscrow", strconv.FormatBool(bool(*params.ReturnEscrow)))
+		}
+	}
+	return c.do(ctx, http.MethodDelete, path, query, nil, nil, false)
+}
+
+// ListDistributionPacksParams are the optional query parameters of ListDistributionPacks.
+type ListDistributionPacksParams struct {
+	Limit  *int64
+	Offset *int64
+	// Comma separated pack states, "minted" for all states after minting, "unopened" for minted packs not yet opened, "mint-failed" for packs which failed to mint too many times
+	State *string
+}
+
+// ListDistributionPacks List distribution packs
+//
+// Lists the packs of a distribution in order of creation, e.g. to reconcile a drop. The total number of packs in the given states is returned in the X-Total-Count header.
+//
+// GET /distributions/{distributionId}/packs
+func (c *Client) ListDistributionPacks(ctx context.Context, distributionId string, params *ListDistributionPacksParams) ([]PackList, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/packs"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+		if params.State != nil {
+			query.Set("state", string(*params.State))
+		}
+	}
+	var res []PackList
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// RevealPacks Reveal packs
+//
+// Reveals (and opens) a list of packs of a distribution on behalf of the issuer, e.g. for a promotional mass reveal, as if their owners had requested it. The transactions are sent in the user-facing lane like those of reveal requests. All packs have to be sealed (or revealed, to open them) or none is revealed. Reveals are rejected before the revealNotBefore of the distribution.
+//
+// POST /distributions/{distributionId}/packs/reveal
+func (c *Client) RevealPacks(ctx context.Context, distributionId string, body PackReveal) ([]PackRevealResult, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/packs/reveal"
+	query := url.Values{}
+	var res []PackRevealResult
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// AbortDistributionParams are the optional query parameters of AbortDistribution.
+type AbortDistributionParams struct {
+	// Return the escrowed collectibles of the cancelled packs to the issuer
+	ReturnEscrow *bool
+}
+
+// AbortDistribution Abort distribution
+//
+// Forcibly abort the process, which will put the Distribution into the Invalid state. Settle and mint transactions not yet sent are cancelled. Once the sent ones have finished, the packs which were never minted are set to the cancelled state and, if requested, their escrowed collectibles are returned to the issuer. A distribution which started settling is set to the cancelled state once the return transactions have finished.
+//
+// POST /distributions/{distributionId}/abort
+func (c *Client) AbortDistribution(ctx context.Context, distributionId string, params *AbortDistributionParams) error {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/abort"
+	query := url.Values{}
+	if params != nil {
+		if params.ReturnEscrow != nil {
+			query.Set("returnEscrow", strconv.FormatBool(bool(*params.ReturnEscrow)))
+		}
+	}
+	return c.do(ctx, http.MethodPost, path, query, nil, nil, false)
+}
+
+// RetryDistribution Retry distribution
+//
+// Re-kicks the settlement or minting of a distribution stuck in the settling or minting state, e.g. after an Access API outage. Events are handled from the stored checkpoint up to the latest sealed block, then the collectibles or packs not yet settled or minted are queued again in new batches, replacing dead-letter transactions. Refused while settle or mint transactions are still in flight. A stalled distribution is set back to the state it timed out in and its held transactions are released first.
+//
+// POST /distributions/{distributionId}/retry
+func (c *Client) RetryDistribution(ctx context.Context, distributionId string) (DistributionRetry, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/retry"
+	query := url.Values{}
+	var res DistributionRetry
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, false)
+	return res, err
+}
+
+// PauseDistribution Pause distribution
+//
+// Stops sending new settle and mint batches of a distribution which is not yet complete, e.g. to halt a drop after discovering a content mistake. Transactions already sent finish and their events are still handled, the others are held until resumed. A paused distribution does not start settling or minting, pausedAt is set while paused.
+//
+// POST /distributions/{distributionId}/pause
+func (c *Client) PauseDistribution(ctx context.Context, distributionId string) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/pause"
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, false)
+	return res, err
+}
+
+// ResumeDistribution Resume distribution
+//
+// Resumes a paused distribution, its held transactions are sent and settlement and minting continue.
+//
+// POST /distributions/{distributionId}/resume
+func (c *Client) ResumeDistribution(ctx context.Context, distributionId string) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/resume"
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, false)
+	return res, err
+}
+
+// ArchiveDistribution Archive distribution
+//
+// Archives a complete or closed distribution whose packs do not change anymore: all packs are opened or empty, or also sealed once the distribution is closed. Its packs are moved to an archive table and it is left out of GET /distributions unless ?archived=true. The distribution and its packs can still be read.
+//
+// POST /distributions/{distributionId}/archive
+func (c *Client) ArchiveDistribution(ctx context.Context, distributionId string) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/archive"
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, false)
+	return res, err
+}
+
+// CloneDistribution Clone distribution
+//
+// Creates a draft distribution with the bucket structure (collectible contracts, counts and tier weights), pack count, pack contract and settings of the distribution, but no collectibles, for a recurring drop. Reveal times and the settlement start time are left out. PATCH the draft with the collectibleCollection (and collectibleTiers) of each bucket to resolve it, bucket fields left out are filled in from the draft.
+//
+// POST /distributions/{distributionId}/clone
+func (c *Client) CloneDistribution(ctx context.Context, distributionId string, body DistributionClone) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/clone"
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ScheduleReveals Schedule reveals
+//
+// Sets when the packs of a distribution can be revealed (revealNotBefore of the pack template), in any state until the distribution is closed, e.g. to open packs at an announced time after minting. Reveal and open requests received earlier are queued and processed once it passes. Requests already queued are moved to the new time, leaving revealNotBefore out (or null) opens the reveals and sends them at once.
+//
+// PUT /distributions/{distributionId}/reveal-schedule
+func (c *Client) ScheduleReveals(ctx context.Context, distributionId string, body RevealSchedule) (DistributionGet, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/reveal-schedule"
+	query := url.Values{}
+	var res DistributionGet
+	err := c.do(ctx, http.MethodPut, path, query, body, &res, false)
+	return res, err
+}
+
+// GetCollectibleOwnershipCheck Get collectible ownership check
+//
+// Returns the report of checking, when the distribution was set up, that its issuer owns all collectibles of its buckets. A distribution with missing collectibles is aborted before anything is escrowed, see FLOW_PDS_COLLECTIBLE_OWNERSHIP_CHECK.
+//
+// GET /distributions/{distributionId}/collectible-ownership
+func (c *Client) GetCollectibleOwnershipCheck(ctx context.Context, distributionId string) (CollectibleOwnershipCheck, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/collectible-ownership"
+	query := url.Values{}
+	var res CollectibleOwnershipCheck
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// StartOwnershipVerification Start ownership verification
+//
+// Start verifying the onchain ownership of all minted packs in a complete distribution against the owners tracked from pack transfer events. The verification runs asynchronously.
+//
+// POST /distributions/{distributionId}/ownership-verifications
+func (c *Client) StartOwnershipVerification(ctx context.Context, distributionId string) (OwnershipVerification, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/ownership-verifications"
+	query := url.Values{}
+	var res OwnershipVerification
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetOwnershipVerification Get ownership verification
+//
+// Returns the state and discrepancy report of an ownership verification.
+//
+// GET /distributions/{distributionId}/ownership-verifications/{verificationId}
+func (c *Client) GetOwnershipVerification(ctx context.Context, distributionId string, verificationId string) (OwnershipVerification, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/ownership-verifications/" + url.PathEscape(string(verificationId))
+	query := url.Values{}
+	var res OwnershipVerification
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetEscrowSurplus Get escrow surplus
+//
+// Reconciles the PDS escrow with the packs of a complete, closed or cancelled distribution. Collectibles of its buckets held in escrow are surplus unless a pack of the distribution, or of another distribution of the same issuer, still needs them (init, sealed, revealed or open requested). These are e.g. the collectibles of packs which failed to mint or were cancelled, or collectibles escrowed on top of the resolution.
+//
+// GET /distributions/{distributionId}/escrow-surplus
+func (c *Client) GetEscrowSurplus(ctx context.Context, distributionId string) (EscrowSurplus, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/escrow-surplus"
+	query := url.Values{}
+	var res EscrowSurplus
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ReturnEscrowSurplus Return escrow surplus
+//
+// Queues transactions returning the escrow surplus of a distribution to its issuer, in batches of the settlement batch size. Refused while return transactions of the distribution are still in flight.
+//
+// POST /distributions/{distributionId}/escrow-surplus/return
+func (c *Client) ReturnEscrowSurplus(ctx context.Context, distributionId string) (EscrowSurplus, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/escrow-surplus/return"
+	query := url.Values{}
+	var res EscrowSurplus
+	err := c.do(ctx, http.MethodPost, path, query, nil, &res, true)
+	return res, err
+}
+
+// GetCompletionReport Get completion report
+//
+// Returns the completion report of a closed distribution, stored when the distribution was torn down.
+//
+// GET /distributions/{distributionId}/report
+func (c *Client) GetCompletionReport(ctx context.Context, distributionId string) (CompletionReport, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/report"
+	query := url.Values{}
+	var res CompletionReport
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ExportDistributionParams are the optional query parameters of ExportDistribution.
+type ExportDistributionParams struct {
+	Format *string
+	// Include the salts of the commitment hashes of revealed packs
+	IncludeSalt *bool
+}
+
+// ExportDistribution Export distribution
+//
+// Returns a manifest of the packs of a complete or closed distribution for customer support and audits: pack ID, Flow ID, state, commitment hash, optionally the salt, the collectibles and the owner if known. The collectibles and salts of packs not yet revealed are left out, the export is restricted to the issuer like the mutating endpoints.
+//
+// GET /distributions/{distributionId}/export
+func (c *Client) ExportDistribution(ctx context.Context, distributionId string, params *ExportDistributionParams) ([]DistributionExportPack, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/export"
+	query := url.Values{}
+	if params != nil {
+		if params.Format != nil {
+			query.Set("format", string(*params.Format))
+		}
+		if params.IncludeSalt != nil {
+			query.Set("includeSalt", strconv.FormatBool(bool(*params.IncludeSalt)))
+		}
+	}
+	var res []DistributionExportPack
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetDistributionCosts Get distribution costs
+//
+// Returns the transaction fees paid for settling, minting and any other transaction of the distribution, in total and per transaction template. Fees are known once a transaction is executed.
+//
+// GET /distributions/{distributionId}/costs
+func (c *Client) GetDistributionCosts(ctx context.Context, distributionId string) (DistributionCosts, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/costs"
+	query := url.Values{}
+	var res DistributionCosts
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetDistributionSummary Get distribution summary
+//
+// Returns an overview of a distribution for dashboards: the number of packs per state, how well each bucket fills its pack slots, settlement and minting progress in percent, the number of transactions per state including failed and dead-letter ones, and when settling and minting started and finished.
+//
+// GET /distributions/{distributionId}/summary
+func (c *Client) GetDistributionSummary(ctx context.Context, distributionId string) (DistributionSummary, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/summary"
+	query := url.Values{}
+	var res DistributionSummary
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetDistributionEta Estimate distribution completion
+//
+// Returns an estimate of when the settlement and minting of a settling or minting distribution complete, from the batches left at the current batch sizes, the send rate and the seal latency of its latest sealed batches. The estimate is updated as batches seal. It assumes the distribution has the send rate to itself.
+//
+// GET /distributions/{distributionId}/eta
+func (c *Client) GetDistributionEta(ctx context.Context, distributionId string) (DistributionETA, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/eta"
+	query := url.Values{}
+	var res DistributionETA
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetDistributionResolution Verify distribution resolution
+//
+// Discloses the seed and algorithm version the collectibles of a closed or cancelled distribution, or of a complete distribution with all of its packs revealed or opened, were shuffled into packs with, and verifies the packs stored against the packs the seed resolves to. Fails with a 'distribution_state' problem in other states or while packs of a complete distribution are still sealed, or if the distribution was resolved before seeds were recorded.
+//
+// GET /distributions/{distributionId}/resolution
+func (c *Client) GetDistributionResolution(ctx context.Context, distributionId string) (DistributionResolution, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/resolution"
+	query := url.Values{}
+	var res DistributionResolution
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// ListTransactionAuditParams are the optional query parameters of ListTransactionAudit.
+type ListTransactionAuditParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListTransactionAudit List transaction audit log
+//
+// Lists every transaction sent on behalf of the distribution, one entry per send attempt, oldest first. Entries hold the script hash, Cadence arguments, proposer key index and the signed transaction (RLP) as sent, and the final status of the attempt.
+//
+// GET /distributions/{distributionId}/transactions
+func (c *Client) ListTransactionAudit(ctx context.Context, distributionId string, params *ListTransactionAuditParams) ([]TransactionAttempt, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/transactions"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []TransactionAttempt
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, true)
+	return res, err
+}
+
+// CreateGiftIntents Create gift intents
+//
+// Register intended recipients for minted packs (e.g. a gift campaign). A pack can have only one pending gift intent at a time.
+//
+// POST /distributions/{distributionId}/gift-intents
+func (c *Client) CreateGiftIntents(ctx context.Context, distributionId string, body CreateGiftIntentsRequest) ([]GiftIntent, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/gift-intents"
+	query := url.Values{}
+	var res []GiftIntent
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// ListGiftIntentsParams are the optional query parameters of ListGiftIntents.
+type ListGiftIntentsParams struct {
+	Limit  *int64
+	Offset *int64
+}
+
+// ListGiftIntents List gift intents
+//
+// Lists the gift intents of a distribution, newest first.
+//
+// GET /distributions/{distributionId}/gift-intents
+func (c *Client) ListGiftIntents(ctx context.Context, distributionId string, params *ListGiftIntentsParams) ([]GiftIntent, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/gift-intents"
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			query.Set("limit", strconv.FormatInt(int64(*params.Limit), 10))
+		}
+		if params.Offset != nil {
+			query.Set("offset", strconv.FormatInt(int64(*params.Offset), 10))
+		}
+	}
+	var res []GiftIntent
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetGiftIntent Get gift intent
+//
+// Returns a gift intent including whether the transfer to the recipient has been observed.
+//
+// GET /distributions/{distributionId}/gift-intents/{giftIntentId}
+func (c *Client) GetGiftIntent(ctx context.Context, distributionId string, giftIntentId string) (GiftIntent, error) {
+	path := "/distributions/" + url.PathEscape(string(distributionId)) + "/gift-intents/" + url.PathEscape(string(giftIntentId))
+	query := url.Values{}
+	var res GiftIntent
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GraphqlQuery GraphQL query
+//
+// Read-only GraphQL API over distributions, buckets, packs and collectibles, so related data can be fetched in one request. Queries can also be sent with GET and the query, operationName and variables parameters. Mutations, subscriptions and introspection are not supported. Distributions of other issuers are not visible when FLOW_PDS_ISSUER_ISOLATION is set.
+//
+// POST /graphql
+func (c *Client) GraphqlQuery(ctx context.Context, body GraphQLRequest) (GraphQLResponse, error) {
+	path := "/graphql"
+	query := url.Values{}
+	var res GraphQLResponse
+	err := c.do(ctx, http.MethodPost, path, query, body, &res, false)
+	return res, err
+}
+
+// GraphqlQueryGetParams are the optional query parameters of GraphqlQueryGet.
+type GraphqlQueryGetParams struct {
+	// Operation of the document to run
+	OperationName *string
+	// JSON encoded variables of the operation
+	Variables *string
+}
+
+// GraphqlQueryGet GraphQL query (GET)
+//
+// Same as graphql-query, with the query in the query string.
+//
+// GET /graphql
+func (c *Client) GraphqlQueryGet(ctx context.Context, queryParam string, params *GraphqlQueryGetParams) (GraphQLResponse, error) {
+	path := "/graphql"
+	query := url.Values{}
+	query.Set("query", string(queryParam))
+	if params != nil {
+		if params.OperationName != nil {
+			query.Set("operationName", string(*params.OperationName))
+		}
+		if params.Variables != nil {
+			query.Set("variables", string(*params.Variables))
+		}
+	}
+	var res GraphQLResponse
+	err := c.do(ctx, http.MethodGet, path, query, nil, &res, false)
+	return res, err
+}
+
+// GetGraphqlSchema Get GraphQL schema
+//
+// Returns the schema of the GraphQL API in the schema definition language.
+//
+// GET /graphql/schema
+func (c *Client) GetGraphqlSchema(ctx context.Context) error {
+	path := "/graphql/schema"
+	query := url.Values{}
+	return c.do(ctx, http.MethodGet, path, query, nil, nil, false)
+}
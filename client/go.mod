@@ -0,0 +1,3 @@
+module github.com/flow-hydraulics/flow-pds/client
+
+go 1.17
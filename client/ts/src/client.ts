@@ -0,0 +1,1830 @@
+// Code generated by tools/clientgen from the Flow PDS API definition. DO NOT EDIT.
+
+/** API key of an issuer. The key itself is only returned when created. */
+export interface APIKey {
+  id?: string;
+  issuer?: FlowAddress;
+  name?: string;
+  /** Start of the key, identifies it in listings */
+  prefix?: string;
+  createdAt?: string;
+  revokedAt?: string;
+  /** Collectible contracts distributions created or updated with the key may use, any the issuer may use if empty */
+  allowedCollectibleContracts?: ContractReference[];
+  /** Only returned when created */
+  key?: string;
+}
+
+/** A bucket from which to pick collectibles into a pack. */
+export interface BucketCreate {
+  /** Optional, overrides the collectibleReference of the pack template. Packs can hold collectibles of several contracts, which must have different names. */
+  collectibleReference?: ContractReference;
+  collectibleCount: number;
+  collectibleCollection: number[];
+  /** Optional. Collectibles of the collection by tier (e.g. rarity), the tier of each slot is disclosed from teaseNotBefore of the pack template. */
+  collectibleTiers?: Record<string, unknown>;
+  /** Optional. Slots of the bucket are filled by tier in proportion to these weights (e.g. {"common": 90, "rare": 10}), requires collectibleTiers for all collectibles. */
+  tierWeights?: Record<string, unknown>;
+  /** Optional. Number of slots of each pack guaranteed to a tier (e.g. {"rare": 1}), the other slots are filled by tierWeights. */
+  guaranteedTiers?: Record<string, unknown>;
+}
+
+export interface BucketGet {
+  collectibleReference?: ContractReference;
+  collectibleCount?: number;
+  /** Optional. Collectibles of the collection by tier (e.g. rarity), the tier of each slot is disclosed from teaseNotBefore of the pack template. */
+  collectibleTiers?: Record<string, unknown>;
+  /** Optional. Slots of the bucket are filled by tier in proportion to these weights (e.g. {"common": 90, "rare": 10}), requires collectibleTiers for all collectibles. */
+  tierWeights?: Record<string, unknown>;
+  /** Optional. Number of slots of each pack guaranteed to a tier (e.g. {"rare": 1}), the other slots are filled by tierWeights. */
+  guaranteedTiers?: Record<string, unknown>;
+}
+
+/** Collectible IDs reserved for a pack, to be minted when the pack is opened. */
+export interface CollectibleIDReservation {
+  packID?: string;
+  collectibleReference?: ContractReference;
+  collectibleIDs?: number[];
+  createdAt?: string;
+}
+
+/** Report of checking that the issuer owns all collectibles of a distribution before it is set up, listing each collectible missing from the collection of the issuer. */
+export interface CollectibleOwnershipCheck {
+  distID?: string;
+  checkedAt?: string;
+  issuer?: FlowAddress;
+  /** False if any collectible is missing, the distribution is then aborted */
+  passed?: boolean;
+  checkedCount?: number;
+  missingCount?: number;
+  missing?: CollectibleOwnershipCheckMissingItem[];
+}
+
+export interface CollectibleOwnershipCheckMissingItem {
+  bucketIndex?: number;
+  flowID?: number;
+  collectible?: string;
+}
+
+/** Groups related distributions of an issuer, e.g. the drops of a season. Distributions of the collection use its policies where they leave them out. */
+export interface Collection {
+  collectionID?: string;
+  issuer?: FlowAddress;
+  name?: string;
+  description?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  packReference?: ContractReference;
+  collectibleReference?: ContractReference;
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+  stats?: CollectionStats;
+}
+
+/** Roll-up of the distributions of a collection. */
+export interface CollectionStats {
+  distributionCount?: number;
+  distributionsByState?: Record<string, unknown>;
+  packCount?: number;
+  /** Minted, not revealed */
+  sealedCount?: number;
+  /** Revealed, not opened */
+  revealedCount?: number;
+  openedCount?: number;
+}
+
+/** Summary of a distribution stored when it was closed, after all packs were opened or the reveal window expired. */
+export interface CompletionReport {
+  distID?: string;
+  closedAt?: string;
+  reason?: 'all-opened' | 'reveal-window-expired';
+  packCount?: number;
+  /** Packs never revealed */
+  sealedCount?: number;
+  /** Packs revealed but never opened */
+  revealedCount?: number;
+  openedCount?: number;
+  /** Packs revoked before being opened */
+  revokedCount?: number;
+  /** Collectibles of unopened packs left in escrow */
+  unopenedCollectibleCount?: number;
+  transactionCount?: number;
+  /** Failed or dead-letter transactions */
+  failedTransactionCount?: number;
+  /** Transaction destroying the capabilities the issuer shared with the PDS */
+  closeTransactionID?: string;
+}
+
+/** Way of referencing a contract on Flow. */
+export interface ContractReference {
+  name: string;
+  address: FlowAddress;
+}
+
+export interface CreateApiKeyRequest {
+  /** Identifies the key in listings */
+  name: string;
+  /** Optional, collectible contracts distributions created or updated with the key may use. They have to be allowed for the issuer, any the issuer may use if empty. */
+  allowedCollectibleContracts?: ContractReference[];
+}
+
+export interface CreateCollectionRequest {
+  issuer: FlowAddress;
+  name: string;
+  description?: string;
+  packReference?: ContractReference;
+  collectibleReference?: ContractReference;
+  /** Must be allowed by the service configuration */
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+}
+
+export interface CreateDistributionRequest {
+  distFlowID: number;
+  issuer: FlowAddress;
+  packTemplate: PackTemplateCreate;
+  /** Optional Access API host to use for this distribution, must be allowed by the service configuration */
+  accessAPIHost?: string;
+  /** Optional URL receiving the pack.teased and pack.revealed events of the two-stage reveal */
+  revealWebhookURL?: string;
+  metadata?: DistributionMetadata;
+  /** Optional collection of the issuer to add the distribution to. Pack and collectible references (left empty), accessAPIHost and revealWebhookURL left out are taken from the collection. */
+  collectionID?: string;
+  /** Optional template of the issuer to create the distribution from. packTemplate.buckets give the collectibles of the buckets of the template in the same order, their collectible references and counts as well as the pack reference, packCount, accessAPIHost, revealWebhookURL and collectionID left out are taken from the template. */
+  templateID?: string;
+  /** Optional time to start the distribution at, must be in the future. The distribution is resolved right away and stays in the scheduled state until then, it is set up and starts settling once the time has passed. */
+  settlementStartAt?: string;
+  /** Optional, resolve the packs from a seed anchored on-chain with a commit-reveal scheme: the PDS commits to a secret when setting up the distribution, derives the seed from it and a later block once that is sealed and reveals the secret once settled. */
+  seedCommitReveal?: boolean;
+  /** Optional, version of the commitment hash scheme of the packs, defaults to the current version. Use an older version for pack contracts verifying reveals with an older scheme. */
+  commitmentHashVersion?: number;
+}
+
+export interface CreateDistributionTemplateRequest {
+  issuer: FlowAddress;
+  name: string;
+  description?: string;
+  packReference?: ContractReference;
+  packCount?: number;
+  buckets: TemplateBucket[];
+  /** Default collectible contract of the buckets */
+  collectibleReference?: ContractReference;
+  /** Must be allowed by the service configuration */
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+  collectionID?: string;
+}
+
+export interface CreateGiftIntentsRequest {
+  gifts: CreateGiftIntentsRequestGiftsItem[];
+  /** Optional, pending intents expire at this time */
+  expiresAt?: string;
+  /** Optional, a reminder webhook is sent at this time if the pack has not been transferred yet, requires webhookURL */
+  remindAt?: string;
+  /** Optional, receives a POST with a JSON body when the transfer is observed (gift.transferred), a reminder is due (gift.reminder) or the intent expires (gift.expired) */
+  webhookURL?: string;
+}
+
+export interface CreateGiftIntentsRequestGiftsItem {
+  packFlowID: number;
+  recipient: FlowAddress;
+}
+
+export interface CreateIssuerRequest {
+  address: FlowAddress;
+  name: string;
+  allowedCollectibleContracts?: ContractReference[];
+}
+
+export interface CreateIssuerWebhookRequest {
+  url: string;
+}
+
+export interface DistributionClone {
+  /** Onchain ID of the new distribution, created by the issuer */
+  distFlowID: number;
+}
+
+/** FLOW paid in transaction fees for the transactions sent on behalf of a distribution. */
+export interface DistributionCosts {
+  distID?: string;
+  /** Total fees in FLOW */
+  fees?: string;
+  /** Number of transaction sends, including retries */
+  attempts?: number;
+  /** Fees per Cadence template (e.g. settle, mint) */
+  byName?: DistributionCostsByNameItem[];
+}
+
+export interface DistributionCostsByNameItem {
+  name?: string;
+  fees?: string;
+  attempts?: number;
+}
+
+export interface DistributionCreateOk {
+  distID?: string;
+  distFlowID?: number;
+}
+
+/** Estimate of when the settlement and minting of a distribution complete. */
+export interface DistributionETA {
+  distID?: string;
+  state?: 'init' | 'invalid' | 'resolved' | 'scheduled' | 'setup' | 'settling' | 'settled' | 'minting' | 'complete' | 'closed' | 'cancelled' | 'stalled' | 'draft';
+  /** Collectibles settled into escrow */
+  settledCount?: number;
+  /** Collectibles to settle, 0 until settling starts */
+  settleTotal?: number;
+  /** Packs minted, in any state after minting */
+  mintedCount?: number;
+  packCount?: number;
+  /** Settle transactions left, of the current batch size */
+  settleBatches?: number;
+  /** Mint transactions left, of the current batch size */
+  mintBatches?: number;
+  /** Average milliseconds from sending a batch transaction to its result */
+  sealLatencyMs?: number;
+  /** True if the seal latency is of the latest sealed batches of the distribution, false if it is the configured estimate */
+  sealLatencyObserved?: boolean;
+  /** Left out unless settling */
+  estimatedSettledAt?: string;
+  /** The distribution completes once minted, left out unless settling or minting */
+  estimatedMintedAt?: string;
+}
+
+/** A pack in the export of a distribution. */
+export interface DistributionExportPack {
+  packID?: string;
+  /** PackNFT ID, null if never minted */
+  flowID?: number;
+  state?: string;
+  commitmentHash?: string;
+  /** Salt of the commitment hash, only if requested */
+  salt?: string;
+  /** Collectibles of the pack */
+  collectibles?: string[];
+  owner?: FlowAddress;
+}
+
+export interface DistributionGet {
+  distID?: string;
+  distFlowID?: number;
+  createdAt?: string;
+  updatedAt?: string;
+  issuer?: FlowAddress;
+  state?: 'init' | 'resolved' | 'scheduled' | 'settling' | 'settled' | 'complete' | 'closed' | 'cancelled' | 'stalled' | 'draft';
+  packTemplate?: PackTemplateGet;
+  accessAPIHost?: string;
+  issuerBranding?: IssuerBranding;
+  metadata?: DistributionMetadata;
+  revealWebhookURL?: string;
+  teasedAt?: string;
+  collectionID?: string;
+  /** Template the distribution was created from */
+  templateID?: string;
+  /** Set once archived */
+  archivedAt?: string;
+  /** Set while paused, no new settle or mint batches are sent */
+  pausedAt?: string;
+  /** Set while stalled, the state the distribution timed out in */
+  stalledState?: string;
+  /** The distribution is scheduled until this time */
+  settlementStartAt?: string;
+  /** Set if the packs are resolved from a seed anchored on-chain with a commit-reveal scheme */
+  seedCommitReveal?: boolean;
+  /** Height of the block the seed is derived from along with the committed secret, set once set up */
+  seedAnchorHeight?: number;
+  /** ID of the anchor block, set once the packs are resolved from the anchored seed */
+  seedAnchorBlockID?: string;
+  /** Version of the commitment hash scheme of the packs */
+  commitmentHashVersion?: number;
+}
+
+export interface DistributionList {
+  distID?: string;
+  distFlowID?: number;
+  createdAt?: string;
+  updatedAt?: string;
+  issuer?: FlowAddress;
+  collectionID?: string;
+  metadata?: DistributionMetadata;
+  /** Set once archived */
+  archivedAt?: string;
+  /** Set while paused, no new settle or mint batches are sent */
+  pausedAt?: string;
+  /** The distribution is scheduled until this time */
+  settlementStartAt?: string;
+  state?: 'init' | 'resolved' | 'scheduled' | 'settling' | 'settled' | 'complete' | 'closed' | 'cancelled' | 'stalled' | 'draft';
+}
+
+/** Optional display metadata of a distribution, e.g. to render the drop on a marketplace. */
+export interface DistributionMetadata {
+  title?: string;
+  description?: string;
+  /** Absolute https, http or ipfs URI */
+  imageURI?: string;
+  /** Set if the packs are minted with the metadata, see FLOW_PDS_PACK_METADATA_ON_CHAIN */
+  onChain?: boolean;
+}
+
+/** Progress of the settlement and minting of a distribution, the data of each distribution event. */
+export interface DistributionProgress {
+  state?: 'init' | 'invalid' | 'resolved' | 'scheduled' | 'setup' | 'settling' | 'settled' | 'minting' | 'complete' | 'closed' | 'cancelled' | 'stalled' | 'draft';
+  /** Collectibles settled into escrow */
+  settledCount?: number;
+  /** Collectibles to settle, 0 until settling starts */
+  settleTotal?: number;
+  /** Packs minted, in any state after minting */
+  mintedCount?: number;
+  packCount?: number;
+}
+
+/** Seed the packs of a distribution were resolved from and whether the packs stored match the packs the seed resolves to. */
+export interface DistributionResolution {
+  distID: string;
+  /** Seed of the random number generator which shuffled the collectibles into packs, a signed 64-bit integer */
+  seed: string;
+  /** Version of the algorithm distributing collectibles into packs */
+  algorithmVersion: number;
+  /** Packs of the distribution */
+  packCount: number;
+  /** Packs holding the collectibles of a pack resolved from the seed */
+  matchingCount: number;
+  /** Set if every pack matches a pack resolved from the seed, and for commit-reveal distributions the seed derives from the secret and anchor block */
+  verified: boolean;
+  /** Hex encoded secret committed to on-chain, commit-reveal distributions only */
+  seedSecret?: string;
+  /** Height of the anchor block, commit-reveal distributions only */
+  seedAnchorHeight?: number;
+  /** ID of the anchor block, the seed is the first 8 bytes (big endian) of SHA2-256(secret || anchor block ID), commit-reveal distributions only */
+  seedAnchorBlockID?: string;
+}
+
+/** Result of retrying the settlement or minting of a stuck or stalled distribution. */
+export interface DistributionRetry {
+  state?: 'setup' | 'settling' | 'settled' | 'minting';
+  /** Events are handled up to this block height */
+  checkpointBlock?: number;
+  /** Dead-letter transactions replaced by the new batches */
+  cancelledTransactions?: number;
+  /** Collectibles (settling) or packs (minting) queued again */
+  requeuedCount?: number;
+  /** New settle or mint transactions */
+  queuedTransactions?: number;
+  /** Set if the distribution was stalled, it is set back to the state it timed out in */
+  unstalled?: boolean;
+}
+
+/** Overview of a distribution for dashboards: packs per state, slot fill rates, progress, transaction errors and timing. */
+export interface DistributionSummary {
+  distID?: string;
+  state?: 'init' | 'invalid' | 'resolved' | 'scheduled' | 'setup' | 'settling' | 'settled' | 'minting' | 'complete' | 'closed' | 'cancelled' | 'stalled' | 'draft';
+  /** Collectibles settled into escrow */
+  settledCount?: number;
+  /** Collectibles to settle, 0 until settling starts */
+  settleTotal?: number;
+  /** Percent of the collectibles to settle which are settled */
+  settledPercent?: number;
+  /** Packs minted, in any state after minting */
+  mintedCount?: number;
+  packCount?: number;
+  /** Percent of the packs which are minted */
+  mintedPercent?: number;
+  packsByState?: Record<string, unknown>;
+  /** One per bucket of the pack template */
+  slots?: DistributionSummarySlotsItem[];
+  transactionsByState?: Record<string, unknown>;
+  /** Failed and dead-letter transactions */
+  errorCount?: number;
+  createdAt?: string;
+  settlingStartedAt?: string;
+  /** Set once all collectibles are settled */
+  settledAt?: string;
+  mintingStartedAt?: string;
+  /** Set when minting completes */
+  mintedAt?: string;
+}
+
+export interface DistributionSummarySlotsItem {
+  collectibleReference?: ContractReference;
+  /** Slots of the bucket in all packs */
+  slotCount?: number;
+  /** Collectibles of the bucket to fill the slots with */
+  availableCount?: number;
+  /** Percent of the slots the collectibles of the bucket can fill */
+  fillRate?: number;
+}
+
+/** Reusable drop format of an issuer. Distributions created from the template (templateID) only give the collectibles of each bucket, the template fills in what they leave out. */
+export interface DistributionTemplate {
+  templateID?: string;
+  issuer?: FlowAddress;
+  name?: string;
+  description?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  packReference?: ContractReference;
+  /** Used by distributions which leave out packCount */
+  packCount?: number;
+  /** Buckets of the distributions, in order */
+  buckets?: TemplateBucket[];
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+  collectionID?: string;
+}
+
+/** Changes to a distribution which has not started settling, fields left out are not changed. */
+export interface DistributionUpdate {
+  packTemplate?: DistributionUpdatePackTemplate;
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+  metadata?: DistributionMetadata;
+  /** Must be in the future, reschedules the distribution */
+  settlementStartAt?: string;
+}
+
+export interface DistributionUpdatePackTemplate {
+  packReference?: ContractReference;
+  collectibleReference?: ContractReference;
+  packCount?: number;
+  /** Replaces all buckets of the pack template, collectibleReference is the default of these buckets. */
+  buckets?: BucketCreate[];
+  revealNotBefore?: string;
+  teaseNotBefore?: string;
+}
+
+/** Collectibles of a distribution left in the PDS escrow which no pack needs. */
+export interface EscrowSurplus {
+  distID: string;
+  /** Surplus collectibles ordered by contract and ID, as A.<address>.<contract>.<id> */
+  collectibles: string[];
+  /** Return transactions queued, only set when returning the surplus */
+  queuedTransactions: number;
+}
+
+/** An accounts address on Flow. */
+export type FlowAddress = string;
+
+/** An amount of a fungible token (e.g. FLOW) included in each pack, escrowed from the issuer during settlement and deposited to the owner when the pack is opened. */
+export interface FungibleTokenAmount {
+  tokenReference: ContractReference;
+  /** Amount per pack, as a UFix64 decimal string. */
+  amount: string;
+  /** Identifier of the public path of the token receiver of pack owners and the issuer. */
+  receiverPath: string;
+}
+
+/** An intent to transfer (gift) a minted pack to a recipient. The state changes to transferred once a deposit of the pack to the recipient is observed onchain. */
+export interface GiftIntent {
+  giftIntentID?: string;
+  distID?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  packFlowID?: number;
+  recipient?: FlowAddress;
+  state?: 'pending' | 'transferred' | 'expired';
+  expiresAt?: string;
+  remindAt?: string;
+  webhookURL?: string;
+  transferredAt?: string;
+  /** ID of the transaction which transferred the pack, empty if the recipient already owned the pack when the intent was registered */
+  transferTransactionID?: string;
+}
+
+/** A GraphQL query, see GET /graphql/schema for the schema. */
+export interface GraphQLRequest {
+  query: string;
+  /** Operation to execute if the query has several */
+  operationName?: string;
+  variables?: Record<string, unknown>;
+}
+
+/** Result of a GraphQL query. Fields which failed to resolve are null in data and listed in errors; data is left out if the query could not be executed. */
+export interface GraphQLResponse {
+  data?: Record<string, unknown>;
+  errors?: GraphQLResponseErrorsItem[];
+}
+
+export interface GraphQLResponseErrorsItem {
+  message: string;
+  /** Response keys and list indexes leading to the field */
+  path?: unknown[];
+}
+
+/** Display metadata of an issuer, included in the distributions and packs of the issuer. */
+export interface IssuerBranding {
+  issuer: FlowAddress;
+  displayName: string;
+  /** Absolute https, http or ipfs URI */
+  logoURI?: string;
+  /** Absolute https, http or mailto URL */
+  supportURL?: string;
+  updatedAt: string;
+}
+
+/** A verified callback of an issuer system to the PDS. */
+export interface IssuerCallback {
+  id?: string;
+  issuer?: FlowAddress;
+  /** ID chosen by the issuer, a callback ID is accepted only once */
+  callbackID?: string;
+  /** Event of the callback, for example payment.confirmed (requires packID) */
+  event?: string;
+  packID?: string;
+  /** Optional, stored as sent by the issuer */
+  data?: Record<string, unknown>;
+  signedAt?: string;
+  createdAt?: string;
+}
+
+/** Shared secret an issuer signs its callbacks with, only returned when generated. */
+export interface IssuerCallbackSecret {
+  issuer?: FlowAddress;
+  /** Hex encoded HMAC-SHA256 key */
+  secret?: string;
+  updatedAt?: string;
+}
+
+/** Issuer organization registered with the service. */
+export interface IssuerRegistration {
+  address?: FlowAddress;
+  name?: string;
+  /** Collectible contracts distributions of the issuer may use, any if empty */
+  allowedCollectibleContracts?: ContractReference[];
+  createdAt?: string;
+  updatedAt?: string;
+}
+
+/** Webhook receiving the state changes of the distributions and packs of an issuer. The secret is only returned when created. */
+export interface IssuerWebhook {
+  id?: string;
+  issuer?: FlowAddress;
+  url?: string;
+  /** API key the webhook was registered with, it stops receiving events once the key is revoked */
+  apiKeyID?: string;
+  createdAt?: string;
+  /** Only returned when created. Hex encoded HMAC-SHA256 key of the X-PDS-Signature header of deliveries, signed like issuer callbacks. */
+  secret?: string;
+}
+
+/** A rotate-and-freeze operation: sending was frozen, the admin keys revoked with the recovery key and the standby keys switched to. */
+export interface KeyRotation {
+  keyRotationID: string;
+  createdAt: string;
+  updatedAt: string;
+  /** Sending stays frozen if the rotation failed */
+  state: 'revoking' | 'complete' | 'failed';
+  reason?: string;
+  revokedKeyIndexes: number[];
+  standbyKeyIndexes: number[];
+  /** Flow ID of the revoke transaction */
+  flowTransactionID?: string;
+  error?: string;
+}
+
+/** IDs of the collectibles of a contract held by an account. */
+export interface OwnedCollectibles {
+  address?: FlowAddress;
+  collectibleReference?: ContractReference;
+  collectibleIDs?: number[];
+}
+
+/** Public fields of a pack believed to be held by an account, as tracked from the Withdraw and Deposit events of the PackNFT contract */
+export interface OwnedPack {
+  packID?: string;
+  distID?: string;
+  packReference?: ContractReference;
+  flowID?: number;
+  state?: string;
+  commitmentHash?: string;
+}
+
+/** Onchain pack ownership verification of a distribution, with a report of packs whose onchain owner does not match the owner in database. */
+export interface OwnershipVerification {
+  verificationID?: string;
+  distID?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  state?: 'init' | 'running' | 'complete';
+  checkedCount?: number;
+  discrepancyCount?: number;
+  discrepancies?: OwnershipVerificationDiscrepanciesItem[];
+}
+
+export interface OwnershipVerificationDiscrepanciesItem {
+  packID?: string;
+  packFlowID?: number;
+  expectedOwner?: FlowAddress;
+  reason?: 'unknown-owner' | 'not-owned';
+}
+
+/** A public representation of a Pack */
+export interface Pack {
+  packID?: string;
+  distID?: string;
+  flowID?: number;
+  state?: string;
+  commitmentHash?: string;
+  /** Set if the pack was revoked */
+  revokedAt?: string;
+  /** Set on a replacement pack, ID of the pack it replaces */
+  replacesPackID?: string;
+  issuerBranding?: IssuerBranding;
+  /** Tier of each slot of the pack, once the distribution is teased. Empty for collectibles without a tier. */
+  teaser?: string[];
+  /** Collectibles of the pack, once revealed. */
+  collectibles?: string[];
+}
+
+/** Public fields of a pack of a distribution */
+export interface PackList {
+  packID?: string;
+  flowID?: number;
+  state?: string;
+  commitmentHash?: string;
+}
+
+/** How the commitment hash of a revealed pack is computed from its salt and contents, to verify the revealed contents match the hash committed on-chain when the pack was minted. */
+export interface PackProof {
+  packID: string;
+  /** ID of the PackNFT */
+  flowID?: number;
+  packReference: ContractReference;
+  /** Hex encoded commitment hash stored in the PackNFT at mint */
+  commitmentHash: string;
+  /** Version of the commitment hash scheme of the distribution */
+  commitmentHashVersion: number;
+  /** Hash algorithm of the scheme, e.g. SHA2-256 */
+  algorithm: string;
+  /** Hex encoded salt of the pack */
+  salt: string;
+  /** Collectibles of the pack in slot order, as A.<address>.<contract>.<id> */
+  collectibles: string[];
+  /** Input of the hash algorithm */
+  preimage: string;
+  /** Hex encoded hash of the preimage */
+  computedHash: string;
+  /** Set if the computed hash is the commitment hash */
+  verified: boolean;
+}
+
+export interface PackReplace {
+  /** Why the pack is replaced, not public */
+  reason?: string;
+}
+
+/** A replacement pack minted for a damaged one. */
+export interface PackReplacement {
+  distID: string;
+  originalPackID: string;
+  replacementPackID: string;
+  reason: string;
+  /** Latest transaction minting the replacement */
+  mintTransactionID: string;
+  /** Set once the replacement is minted, or failed to mint */
+  complete: boolean;
+  createdAt: string;
+  completedAt?: string;
+}
+
+/** Packs of a distribution to reveal (and open) on behalf of the issuer. */
+export interface PackReveal {
+  /** Offchain IDs of the packs, sealed packs to reveal them, or revealed packs to open them. */
+  packIDs: string[];
+  /** Also open the packs, to the believed owner of each pack. Defaults to false. */
+  open?: boolean;
+}
+
+/** Transaction stored for a pack of a bulk reveal. */
+export interface PackRevealResult {
+  packID?: string;
+  transactionID?: string;
+  /** reveal for a sealed pack (opened too if requested), open for a revealed pack */
+  action?: 'reveal' | 'open';
+}
+
+/** A revoked pack. */
+export interface PackRevocation {
+  packID: string;
+  /** ID of the PackNFT */
+  flowID: number;
+  revokedAt: string;
+  reason: string;
+  /** Transaction freezing the PackNFT onchain, if any */
+  transactionID?: string;
+}
+
+export interface PackRevoke {
+  /** Why the pack is revoked, not public */
+  reason?: string;
+}
+
+/** A template from which to generate packs. */
+export interface PackTemplateCreate {
+  packReference: ContractReference;
+  collectibleReference: ContractReference;
+  packCount: number;
+  buckets: BucketCreate[];
+  /** Optional. Set if the pack contract does not allow revealing packs before this time, reveal requests received earlier are processed once it passes. */
+  revealNotBefore?: string;
+  /** Optional. Discloses the tier of each slot of the packs from this time on, must be before revealNotBefore. */
+  teaseNotBefore?: string;
+  /** Optional, a fungible token amount included in each pack. */
+  fungibleToken?: FungibleTokenAmount;
+}
+
+export interface PackTemplateGet {
+  packReference?: ContractReference;
+  packCount?: number;
+  buckets?: BucketGet[];
+  /** Optional. Set if the pack contract does not allow revealing packs before this time, reveal requests received earlier are processed once it passes. */
+  revealNotBefore?: string;
+  /** Optional. Discloses the tier of each slot of the packs from this time on, must be before revealNotBefore. */
+  teaseNotBefore?: string;
+  /** Optional, a fungible token amount included in each pack. */
+  fungibleToken?: FungibleTokenAmount;
+}
+
+/** Aggregate numbers over the distributions of opted in issuers. Counts are left out while too few issuers have opted in. */
+export interface PublicStats {
+  /** Packs minted, rounded down */
+  packsMinted?: number;
+  /** Distributions completed, rounded down */
+  distributionsCompleted?: number;
+  updatedAt: string;
+}
+
+export interface ReceiveIssuerCallbackRequest {
+  callbackID: string;
+  event: string;
+  packID?: string;
+  data?: Record<string, unknown>;
+}
+
+export interface ReserveCollectibleIdsRequest {
+  collectibleReference: ContractReference;
+  count: number;
+}
+
+export interface RevealSchedule {
+  /** When the packs of the distribution can be revealed, reveal and open requests received earlier are processed once it passes. Must be in the future and after teaseNotBefore, if set. Left out (or null) opens the reveals at once. */
+  revealNotBefore?: string;
+}
+
+export interface RotateAndFreezeRequest {
+  reason?: string;
+}
+
+export interface SetDistCapRequest {
+  issuer?: FlowAddress;
+}
+
+export interface SetIssuerBrandingRequest {
+  displayName: string;
+  logoURI?: string;
+  supportURL?: string;
+}
+
+export interface SetPublicStatsOptInRequest {
+  optIn: boolean;
+}
+
+/** Structure of a bucket of a distribution template, without its collectibles. */
+export interface TemplateBucket {
+  collectibleReference?: ContractReference;
+  /** How many collectibles of the bucket go in each pack */
+  collectibleCount: number;
+}
+
+/** A Flow transaction sent by the PDS. */
+export interface Transaction {
+  transactionID?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  /** Cadence template the transaction was built from */
+  name?: string;
+  state?: 'init' | 'retry' | 'sent' | 'failed' | 'complete' | 'dead-letter' | 'cancelled';
+  /** Send lane of the transaction */
+  priority?: 'user-facing' | 'settlement' | 'minting';
+  /** Version of the stored transaction format of the PDS which created it */
+  jobVersion?: number;
+  /** Error of the latest attempt */
+  error?: string;
+  retryCount?: number;
+  /** Flow ID of the latest sent transaction */
+  flowTransactionID?: string;
+  distID?: string;
+  packID?: string;
+  /** JSON-Cadence encoded arguments */
+  arguments?: Array<Record<string, unknown>>;
+}
+
+/** A single send of a transaction by the PDS, as recorded in the audit log. */
+export interface TransactionAttempt {
+  attemptID?: string;
+  /** Offchain ID of the transaction */
+  transactionID?: string;
+  createdAt?: string;
+  updatedAt?: string;
+  /** 1 for the first send of the transaction */
+  attempt?: number;
+  /** Cadence template the transaction was built from */
+  name?: string;
+  /** Flow ID of the sent transaction */
+  flowTransactionID?: string;
+  /** Hex encoded SHA-256 hash of the Cadence script */
+  scriptHash?: string;
+  /** JSON-Cadence encoded arguments */
+  arguments?: Array<Record<string, unknown>>;
+  proposerKeyIndex?: number;
+  referenceBlockHeight?: number;
+  /** Hex encoded RLP of the signed transaction */
+  rlp?: string;
+  /** sent until the outcome is known, retry if the transaction was sent again */
+  state?: 'sent' | 'retry' | 'failed' | 'complete';
+  error?: string;
+}
+
+export interface UpdateDistributionTemplateRequest {
+  issuer?: FlowAddress;
+  name: string;
+  description?: string;
+  packReference?: ContractReference;
+  packCount?: number;
+  buckets: TemplateBucket[];
+  /** Default collectible contract of the buckets */
+  collectibleReference?: ContractReference;
+  /** Must be allowed by the service configuration */
+  accessAPIHost?: string;
+  revealWebhookURL?: string;
+  collectionID?: string;
+}
+
+export interface UpdateIssuerRequest {
+  name: string;
+  allowedCollectibleContracts?: ContractReference[];
+}
+
+export interface ClientOptions {
+  /** Bearer token for the admin endpoints */
+  adminToken?: string;
+  /** API key of an issuer, sent with the other requests */
+  apiKey?: string;
+  /** Defaults to the global fetch */
+  fetch?: typeof fetch;
+  /** Extra headers sent with every request */
+  headers?: Record<string, string>;
+}
+
+/** Error for any non 2xx response */
+export class ApiError extends Error {
+  /** Machine readable code of the problem details (RFC 7807), e.g. 'insufficient_escrow' */
+  readonly code?: string;
+  readonly detail?: string;
+
+  constructor(readonly status: number, readonly body: string) {
+    super(`request failed with status ${status}: ${body}`);
+    this.name = 'ApiError';
+    try {
+      const problem = JSON.parse(body);
+      this.code = problem.code;
+      this.detail = problem.detail;
+    } catch {
+      // Not problem details
+    }
+  }
+}
+
+class Api {
+  private readonly baseUrl: string;
+
+  constructor(baseUrl: string, private readonly options: ClientOptions) {
+    this.baseUrl = baseUrl.replace(/\/+$/, '');
+  }
+
+  async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, string | number | boolean | undefined>,
+    body: unknown,
+    admin: boolean,
+  ): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query)) {
+      if (value !== undefined) {
+        params.set(key, String(value));
+      }
+    }
+    const qs = params.toString();
+
+    const headers: Record<string, string> = { Accept: 'application/json, application/problem+json', ...this.options.headers };
+    if (body !== undefined) {
+      headers['Content-Type'] = 'application/json';
+    }
+    if (admin && this.options.adminToken) {
+      headers.Authorization = `Bearer ${this.options.adminToken}`;
+    } else if (this.options.apiKey) {
+      headers.Authorization = `Bearer ${this.options.apiKey}`;
+    }
+
+    const doFetch = this.options.fetch ?? fetch;
+    const res = await doFetch(this.baseUrl + path + (qs ? '?' + qs : ''), {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    const text = await res.text();
+    if (!res.ok) {
+      throw new ApiError(res.status, text);
+    }
+
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+}
+
+export class Client {
+  private readonly api: Api;
+
+  constructor(baseUrl: string, options: ClientOptions = {}) {
+    this.api = new Api(baseUrl, options);
+  }
+
+  /**
+   * Health check
+   *
+   * Simple health check, will always respond with 200 OK
+   *
+   * GET /health/ready
+   */
+  healthReady(): Promise<void> {
+    return this.api.request<void>("GET", `/health/ready`, {}, undefined, false);
+  }
+
+  /**
+   * Get public stats
+   *
+   * Aggregate numbers for public status pages, counts only opted in issuers. Does not require authentication.
+   *
+   * GET /stats
+   */
+  getPublicStats(): Promise<PublicStats> {
+    return this.api.request<PublicStats>("GET", `/stats`, {}, undefined, false);
+  }
+
+  /**
+   * Get configuration
+   *
+   * Returns the effective configuration of the running instance with secrets redacted.
+   *
+   * GET /system/config
+   */
+  getSystemConfig(): Promise<Record<string, unknown>> {
+    return this.api.request<Record<string, unknown>>("GET", `/system/config`, {}, undefined, true);
+  }
+
+  /**
+   * List dead-letter transactions
+   *
+   * Lists transactions which ran out of attempts, most recently updated first. A distribution waiting for a dead-letter transaction does not progress until it is requeued.
+   *
+   * GET /transactions/dead-letter
+   */
+  listDeadLetterTransactions(params: { limit?: number; offset?: number } = {}): Promise<Transaction[]> {
+    return this.api.request<Transaction[]>("GET", `/transactions/dead-letter`, params, undefined, true);
+  }
+
+  /**
+   * Requeue transaction
+   *
+   * Resets a dead-letter transaction to be rebuilt and sent again, its attempts start over.
+   *
+   * POST /transactions/{transactionId}/requeue
+   */
+  requeueTransaction(transactionId: string): Promise<Transaction> {
+    return this.api.request<Transaction>("POST", `/transactions/${encodeURIComponent(String(transactionId))}/requeue`, {}, undefined, true);
+  }
+
+  /**
+   * Rotate keys and freeze
+   *
+   * For a suspected key compromise. Freezes sending, revokes the admin keys onchain using the recovery key and switches to the standby keys. Sending is resumed once the revoke transaction is sealed, if the rotation fails sending stays frozen.
+   *
+   * POST /keys/rotate-and-freeze
+   */
+  rotateAndFreeze(body: RotateAndFreezeRequest): Promise<KeyRotation> {
+    return this.api.request<KeyRotation>("POST", `/keys/rotate-and-freeze`, {}, body, true);
+  }
+
+  /**
+   * Freeze sending
+   *
+   * Stops sending any transactions until unfrozen. Transactions keep being queued.
+   *
+   * POST /sending/freeze
+   */
+  freezeSending(): Promise<void> {
+    return this.api.request<void>("POST", `/sending/freeze`, {}, undefined, true);
+  }
+
+  /**
+   * Unfreeze sending
+   *
+   * Resumes sending transactions.
+   *
+   * POST /sending/unfreeze
+   */
+  unfreezeSending(): Promise<void> {
+    return this.api.request<void>("POST", `/sending/unfreeze`, {}, undefined, true);
+  }
+
+  /**
+   * Set distribution capability
+   *
+   * Share the create distribution capability to issuer
+   *
+   * POST /set-dist-cap
+   */
+  setDistCap(body: SetDistCapRequest): Promise<string> {
+    return this.api.request<string>("POST", `/set-dist-cap`, {}, body, false);
+  }
+
+  /**
+   * List owned collectibles
+   *
+   * Runs a script returning the IDs of the collectibles of a contract held by an account (e.g. a treasury account), useful for building the buckets of a distribution. The list is empty if the account has no public collection.
+   *
+   * GET /accounts/{address}/collectibles
+   */
+  listOwnedCollectibles(address: FlowAddress, params: { contractName: string; contractAddress?: FlowAddress; limit?: number; offset?: number }): Promise<OwnedCollectibles> {
+    return this.api.request<OwnedCollectibles>("GET", `/accounts/${encodeURIComponent(String(address))}/collectibles`, params, undefined, false);
+  }
+
+  /**
+   * List owned packs
+   *
+   * Lists the packs of any distribution held by an account in order of creation, e.g. to show the unopened packs of a user with ?state=unopened. Ownership is tracked from the Withdraw and Deposit events of the circulating PackNFT contracts, so transfers show up once the PDS has polled their blocks. The total number of packs in the given states is returned in the X-Total-Count header.
+   *
+   * GET /owners/{address}/packs
+   */
+  listOwnerPacks(address: FlowAddress, params: { limit?: number; offset?: number; state?: string } = {}): Promise<OwnedPack[]> {
+    return this.api.request<OwnedPack[]>("GET", `/owners/${encodeURIComponent(String(address))}/packs`, params, undefined, false);
+  }
+
+  /**
+   * Register issuer
+   *
+   * Registers an issuer organization. Distributions of the issuer may only use the allowed collectible contracts, any if none are given.
+   *
+   * POST /issuers
+   */
+  createIssuer(body: CreateIssuerRequest): Promise<IssuerRegistration> {
+    return this.api.request<IssuerRegistration>("POST", `/issuers`, {}, body, true);
+  }
+
+  /**
+   * List issuers
+   *
+   * Lists registered issuers in the order they were registered.
+   *
+   * GET /issuers
+   */
+  listIssuers(params: { limit?: number; offset?: number } = {}): Promise<IssuerRegistration[]> {
+    return this.api.request<IssuerRegistration[]>("GET", `/issuers`, params, undefined, true);
+  }
+
+  /**
+   * Get issuer
+   *
+   * Returns a registered issuer.
+   *
+   * GET /issuers/{address}
+   */
+  getIssuer(address: FlowAddress): Promise<IssuerRegistration> {
+    return this.api.request<IssuerRegistration>("GET", `/issuers/${encodeURIComponent(String(address))}`, {}, undefined, true);
+  }
+
+  /**
+   * Update issuer
+   *
+   * Replaces the name and allowed collectible contracts of a registered issuer. Existing distributions are not affected.
+   *
+   * PUT /issuers/{address}
+   */
+  updateIssuer(address: FlowAddress, body: UpdateIssuerRequest): Promise<IssuerRegistration> {
+    return this.api.request<IssuerRegistration>("PUT", `/issuers/${encodeURIComponent(String(address))}`, {}, body, true);
+  }
+
+  /**
+   * Set issuer branding
+   *
+   * Sets the display name, logo and support URL of an issuer, replacing any earlier branding. Included in the distributions and packs of the issuer.
+   *
+   * PUT /issuers/{address}/branding
+   */
+  setIssuerBranding(address: FlowAddress, body: SetIssuerBrandingRequest): Promise<IssuerBranding> {
+    return this.api.request<IssuerBranding>("PUT", `/issuers/${encodeURIComponent(String(address))}/branding`, {}, body, false);
+  }
+
+  /**
+   * Get issuer branding
+   *
+   * Returns the branding of an issuer.
+   *
+   * GET /issuers/{address}/branding
+   */
+  getIssuerBranding(address: FlowAddress): Promise<IssuerBranding> {
+    return this.api.request<IssuerBranding>("GET", `/issuers/${encodeURIComponent(String(address))}/branding`, {}, undefined, false);
+  }
+
+  /**
+   * Rotate issuer callback secret
+   *
+   * Generates a new shared secret the issuer signs its callbacks with, replacing any earlier one. The secret is only returned here.
+   *
+   * POST /issuers/{address}/callback-secret
+   */
+  rotateIssuerCallbackSecret(address: FlowAddress): Promise<IssuerCallbackSecret> {
+    return this.api.request<IssuerCallbackSecret>("POST", `/issuers/${encodeURIComponent(String(address))}/callback-secret`, {}, undefined, true);
+  }
+
+  /**
+   * Receive issuer callback
+   *
+   * Receives a callback of an issuer system, for example confirming an off-chain payment of a pack. The callback is verified against the callback secret of the issuer and stored, each callback ID is accepted once.
+   *
+   * POST /issuers/{address}/callbacks
+   */
+  receiveIssuerCallback(address: FlowAddress, body: ReceiveIssuerCallbackRequest): Promise<IssuerCallback> {
+    return this.api.request<IssuerCallback>("POST", `/issuers/${encodeURIComponent(String(address))}/callbacks`, {}, body, false);
+  }
+
+  /**
+   * List issuer callbacks
+   *
+   * Lists the received callbacks of an issuer, most recent first.
+   *
+   * GET /issuers/{address}/callbacks
+   */
+  listIssuerCallbacks(address: FlowAddress, params: { limit?: number; offset?: number } = {}): Promise<IssuerCallback[]> {
+    return this.api.request<IssuerCallback[]>("GET", `/issuers/${encodeURIComponent(String(address))}/callbacks`, params, undefined, true);
+  }
+
+  /**
+   * Set public stats opt-in
+   *
+   * Opts an issuer in or out of the public stats, issuers are opted out by default.
+   *
+   * PUT /issuers/{address}/public-stats
+   */
+  setPublicStatsOptIn(address: FlowAddress, body: SetPublicStatsOptInRequest): Promise<void> {
+    return this.api.request<void>("PUT", `/issuers/${encodeURIComponent(String(address))}/public-stats`, {}, body, false);
+  }
+
+  /**
+   * Create API key
+   *
+   * Creates an API key for the issuer. The key is only returned here, the service stores its hash.
+   *
+   * POST /issuers/{address}/api-keys
+   */
+  createApiKey(address: FlowAddress, body: CreateApiKeyRequest): Promise<APIKey> {
+    return this.api.request<APIKey>("POST", `/issuers/${encodeURIComponent(String(address))}/api-keys`, {}, body, true);
+  }
+
+  /**
+   * List API keys
+   *
+   * Lists the API keys of the issuer, including revoked ones.
+   *
+   * GET /issuers/{address}/api-keys
+   */
+  listApiKeys(address: FlowAddress): Promise<APIKey[]> {
+    return this.api.request<APIKey[]>("GET", `/issuers/${encodeURIComponent(String(address))}/api-keys`, {}, undefined, true);
+  }
+
+  /**
+   * Revoke API key
+   *
+   * Revokes an API key of the issuer, requests with it are rejected from then on.
+   *
+   * POST /issuers/{address}/api-keys/{apiKeyId}/revoke
+   */
+  revokeApiKey(address: FlowAddress, apiKeyId: string): Promise<APIKey> {
+    return this.api.request<APIKey>("POST", `/issuers/${encodeURIComponent(String(address))}/api-keys/${encodeURIComponent(String(apiKeyId))}/revoke`, {}, undefined, true);
+  }
+
+  /**
+   * Register webhook
+   *
+   * Registers a URL to receive the webhook events of the issuer: distribution.<state> on each state change of a distribution and pack.revealed, pack.opened and pack.revoked. The secret signing the deliveries is only returned here. Webhook endpoints always require authentication, even without FLOW_PDS_API_KEYS_REQUIRED. Deliveries to private, loopback and link-local addresses fail unless FLOW_PDS_ISSUER_WEBHOOK_ALLOW_PRIVATE is set.
+   *
+   * POST /issuers/{address}/webhooks
+   */
+  createIssuerWebhook(address: FlowAddress, body: CreateIssuerWebhookRequest): Promise<IssuerWebhook> {
+    return this.api.request<IssuerWebhook>("POST", `/issuers/${encodeURIComponent(String(address))}/webhooks`, {}, body, false);
+  }
+
+  /**
+   * List webhooks
+   *
+   * Lists the webhooks of the issuer.
+   *
+   * GET /issuers/{address}/webhooks
+   */
+  listIssuerWebhooks(address: FlowAddress): Promise<IssuerWebhook[]> {
+    return this.api.request<IssuerWebhook[]>("GET", `/issuers/${encodeURIComponent(String(address))}/webhooks`, {}, undefined, false);
+  }
+
+  /**
+   * Delete webhook
+   *
+   * Deletes a webhook of the issuer, its queued deliveries are dropped.
+   *
+   * DELETE /issuers/{address}/webhooks/{webhookId}
+   */
+  deleteIssuerWebhook(address: FlowAddress, webhookId: string): Promise<void> {
+    return this.api.request<void>("DELETE", `/issuers/${encodeURIComponent(String(address))}/webhooks/${encodeURIComponent(String(webhookId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Get Pack
+   *
+   * Returns the public details of a pack.
+   *
+   * GET /packs/{packId}
+   */
+  getPackById(packId: string): Promise<Pack> {
+    return this.api.request<Pack>("GET", `/packs/${encodeURIComponent(String(packId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Get Pack by commitment hash
+   *
+   * Resolves a pack by its onchain commitment hash, e.g. to answer what is in a given pack. Returns the distribution and state of the pack and its collectibles once revealed.
+   *
+   * GET /packs/by-commitment-hash/{commitmentHash}
+   */
+  getPackByCommitmentHash(commitmentHash: string): Promise<Pack> {
+    return this.api.request<Pack>("GET", `/packs/by-commitment-hash/${encodeURIComponent(String(commitmentHash))}`, {}, undefined, false);
+  }
+
+  /**
+   * Get Pack by Flow ID
+   *
+   * Resolves a pack by its PackNFT ID, e.g. to answer what is in a given pack. Returns the distribution and state of the pack and its collectibles once revealed. PackNFT IDs are unique per contract, packReference is required if packs of more than one contract have the ID.
+   *
+   * GET /packs/by-flow-id/{flowId}
+   */
+  getPackByFlowId(flowId: number, params: { packReference?: string } = {}): Promise<Pack> {
+    return this.api.request<Pack>("GET", `/packs/by-flow-id/${encodeURIComponent(String(flowId))}`, params, undefined, false);
+  }
+
+  /**
+   * Get Pack Proof
+   *
+   * Returns the salt of a revealed pack and how its commitment hash is computed, to verify its contents independently.
+   *
+   * GET /packs/{packId}/proof
+   */
+  getPackProof(packId: string): Promise<PackProof> {
+    return this.api.request<PackProof>("GET", `/packs/${encodeURIComponent(String(packId))}/proof`, {}, undefined, false);
+  }
+
+  /**
+   * Reserve collectible IDs
+   *
+   * Reserves unique IDs of a collectible contract for a pack whose collectibles are minted when it is opened. IDs are allocated from a persisted counter per contract so concurrent opens never get the same IDs. Reserving again for the same pack and contract returns the earlier reservation.
+   *
+   * POST /packs/{packId}/collectible-ids
+   */
+  reserveCollectibleIds(packId: string, body: ReserveCollectibleIdsRequest): Promise<CollectibleIDReservation> {
+    return this.api.request<CollectibleIDReservation>("POST", `/packs/${encodeURIComponent(String(packId))}/collectible-ids`, {}, body, true);
+  }
+
+  /**
+   * List collectible ID reservations
+   *
+   * Lists the collectible IDs reserved for a pack.
+   *
+   * GET /packs/{packId}/collectible-ids
+   */
+  listCollectibleIdReservations(packId: string): Promise<CollectibleIDReservation[]> {
+    return this.api.request<CollectibleIDReservation[]>("GET", `/packs/${encodeURIComponent(String(packId))}/collectible-ids`, {}, undefined, true);
+  }
+
+  /**
+   * Force pack reveal
+   *
+   * Stores a new reveal transaction for a pack whose reveal request was handled but whose reveal transaction failed. The pack has to be in reveal-request-handled state and sealed onchain, with a known owner and no reveal transaction in flight.
+   *
+   * POST /packs/{packId}/force-reveal
+   */
+  forceRevealPack(packId: string, params: { openRequest?: boolean } = {}): Promise<Transaction> {
+    return this.api.request<Transaction>("POST", `/packs/${encodeURIComponent(String(packId))}/force-reveal`, params, undefined, true);
+  }
+
+  /**
+   * Force pack open
+   *
+   * Stores a new open transaction for a pack whose open request was handled but whose open transaction failed. The pack has to be in open-request-handled state and revealed onchain, with a known owner and no open transaction in flight.
+   *
+   * POST /packs/{packId}/force-open
+   */
+  forceOpenPack(packId: string): Promise<Transaction> {
+    return this.api.request<Transaction>("POST", `/packs/${encodeURIComponent(String(packId))}/force-open`, {}, undefined, true);
+  }
+
+  /**
+   * Revoke pack
+   *
+   * Revokes a minted, unopened pack (sealed or revealed), e.g. one sold fraudulently. Reveal and open requests of the pack are ignored from then on. With FLOW_PDS_PACK_REVOCATION_ON_CHAIN a transaction freezing the PackNFT onchain is queued, unless the distribution is closed.
+   *
+   * POST /packs/{packId}/revoke
+   */
+  revokePack(packId: string, body: PackRevoke): Promise<PackRevocation> {
+    return this.api.request<PackRevocation>("POST", `/packs/${encodeURIComponent(String(packId))}/revoke`, {}, body, true);
+  }
+
+  /**
+   * Replace pack
+   *
+   * Mints a replacement for a revoked pack, or a pack whose reveal transactions failed (it is revoked first), of a complete distribution. Each slot gets a collectible of the same bucket and tier from the reserve of the distribution, collectibles of its buckets held in escrow which no pack uses. The replacement is minted to the issuer.
+   *
+   * POST /packs/{packId}/replace
+   */
+  replacePack(packId: string, body: PackReplace): Promise<PackReplacement> {
+    return this.api.request<PackReplacement>("POST", `/packs/${encodeURIComponent(String(packId))}/replace`, {}, body, true);
+  }
+
+  /**
+   * Get pack replacement
+   *
+   * Returns the replacement of a pack and whether it is minted.
+   *
+   * GET /packs/{packId}/replacement
+   */
+  getPackReplacement(packId: string): Promise<PackReplacement> {
+    return this.api.request<PackReplacement>("GET", `/packs/${encodeURIComponent(String(packId))}/replacement`, {}, undefined, true);
+  }
+
+  /**
+   * Create Collection
+   *
+   * Create a collection grouping related distributions of an issuer (e.g. a season). The optional policies are used by distributions of the collection which leave them out.
+   *
+   * POST /collections
+   */
+  createCollection(body: CreateCollectionRequest): Promise<Collection> {
+    return this.api.request<Collection>("POST", `/collections`, {}, body, false);
+  }
+
+  /**
+   * List collections
+   *
+   * List collections, most recent first.
+   *
+   * GET /collections
+   */
+  listCollections(params: { issuer?: FlowAddress; limit?: number; offset?: number } = {}): Promise<Collection[]> {
+    return this.api.request<Collection[]>("GET", `/collections`, params, undefined, false);
+  }
+
+  /**
+   * Get Collection
+   *
+   * Returns a collection with the roll-up stats of its distributions and their packs.
+   *
+   * GET /collections/{collectionId}
+   */
+  getCollectionById(collectionId: string): Promise<Collection> {
+    return this.api.request<Collection>("GET", `/collections/${encodeURIComponent(String(collectionId))}`, {}, undefined, false);
+  }
+
+  /**
+   * List collection distributions
+   *
+   * List the distributions of a collection, most recent first.
+   *
+   * GET /collections/{collectionId}/distributions
+   */
+  listCollectionDistributions(collectionId: string, params: { limit?: number; offset?: number } = {}): Promise<DistributionList[]> {
+    return this.api.request<DistributionList[]>("GET", `/collections/${encodeURIComponent(String(collectionId))}/distributions`, params, undefined, false);
+  }
+
+  /**
+   * Create Distribution Template
+   *
+   * Create a reusable drop format of an issuer: the pack contract, pack count, bucket structure and settings of its distributions. Distributions created with its templateID only give the collectibles of each bucket.
+   *
+   * POST /distribution-templates
+   */
+  createDistributionTemplate(body: CreateDistributionTemplateRequest): Promise<DistributionTemplate> {
+    return this.api.request<DistributionTemplate>("POST", `/distribution-templates`, {}, body, false);
+  }
+
+  /**
+   * List distribution templates
+   *
+   * List distribution templates, most recent first.
+   *
+   * GET /distribution-templates
+   */
+  listDistributionTemplates(params: { issuer?: FlowAddress; limit?: number; offset?: number } = {}): Promise<DistributionTemplate[]> {
+    return this.api.request<DistributionTemplate[]>("GET", `/distribution-templates`, params, undefined, false);
+  }
+
+  /**
+   * Get Distribution Template
+   *
+   * GET /distribution-templates/{templateId}
+   */
+  getDistributionTemplateById(templateId: string): Promise<DistributionTemplate> {
+    return this.api.request<DistributionTemplate>("GET", `/distribution-templates/${encodeURIComponent(String(templateId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Update Distribution Template
+   *
+   * Replaces a distribution template, its issuer can not change. Distributions already created from it are not changed.
+   *
+   * PUT /distribution-templates/{templateId}
+   */
+  updateDistributionTemplate(templateId: string, body: UpdateDistributionTemplateRequest): Promise<DistributionTemplate> {
+    return this.api.request<DistributionTemplate>("PUT", `/distribution-templates/${encodeURIComponent(String(templateId))}`, {}, body, false);
+  }
+
+  /**
+   * Delete Distribution Template
+   *
+   * Deletes a distribution template, distributions already created from it are kept.
+   *
+   * DELETE /distribution-templates/{templateId}
+   */
+  deleteDistributionTemplate(templateId: string): Promise<void> {
+    return this.api.request<void>("DELETE", `/distribution-templates/${encodeURIComponent(String(templateId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Create Distribution
+   *
+   * Create a distribution. If template is valid, a distribution is created in database and both the offchain (distID) and the onchain (distFlowID) IDs are returned. All the related tasks are started asynchronously (settling and minting).
+   *
+   * POST /distributions
+   */
+  createDistribution(body: CreateDistributionRequest): Promise<DistributionCreateOk> {
+    return this.api.request<DistributionCreateOk>("POST", `/distributions`, {}, body, false);
+  }
+
+  /**
+   * List distributions
+   *
+   * Lists distributions matching the filters, newest first unless sorted otherwise. The total number of matching distributions is returned in the X-Total-Count header.
+   *
+   * GET /distributions
+   */
+  listDistributions(params: { limit?: number; offset?: number; state?: string; issuer?: FlowAddress; createdAfter?: string; sort?: '-createdAt' | 'createdAt' | '-updatedAt' | 'updatedAt'; archived?: boolean } = {}): Promise<DistributionList[]> {
+    return this.api.request<DistributionList[]>("GET", `/distributions`, params, undefined, false);
+  }
+
+  /**
+   * Create distributions in bulk
+   *
+   * Create several distributions at once, either all of them or none. All distributions are validated before any is stored, an error names the index of the failing distribution (or the row of the CSV). Idempotency keys are not supported.
+   *
+   * POST /distributions/bulk
+   */
+  createDistributions(body: CreateDistributionRequest[]): Promise<DistributionCreateOk[]> {
+    return this.api.request<DistributionCreateOk[]>("POST", `/distributions/bulk`, {}, body, false);
+  }
+
+  /**
+   * Get Distribution
+   *
+   * Returns the details for a distribution.
+   *
+   * GET /distributions/{distributionId}
+   */
+  getDistributionById(distributionId: string): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("GET", `/distributions/${encodeURIComponent(String(distributionId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Update distribution
+   *
+   * Fixes the bucket definitions, pack count or other settings of a distribution which has not started settling, instead of creating a new one. The distribution is resolved again with new packs. Resolved or scheduled distributions not yet set up and aborted (invalid) distributions which never started settling can be updated, aborted ones are started again and their state onchain set back to initialized.
+   *
+   * PATCH /distributions/{distributionId}
+   */
+  updateDistribution(distributionId: string, body: DistributionUpdate): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("PATCH", `/distributions/${encodeURIComponent(String(distributionId))}`, {}, body, false);
+  }
+
+  /**
+   * Cancel distribution
+   *
+   * Cancels a distribution, same as aborting it.
+   *
+   * DELETE /distributions/{distributionId}
+   */
+  cancelDistribution(distributionId: string, params: { returnEscrow?: boolean } = {}): Promise<void> {
+    return this.api.request<void>("DELETE", `/distributions/${encodeURIComponent(String(distributionId))}`, params, undefined, false);
+  }
+
+  /**
+   * List distribution packs
+   *
+   * Lists the packs of a distribution in order of creation, e.g. to reconcile a drop. The total number of packs in the given states is returned in the X-Total-Count header.
+   *
+   * GET /distributions/{distributionId}/packs
+   */
+  listDistributionPacks(distributionId: string, params: { limit?: number; offset?: number; state?: string } = {}): Promise<PackList[]> {
+    return this.api.request<PackList[]>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/packs`, params, undefined, false);
+  }
+
+  /**
+   * Reveal packs
+   *
+   * Reveals (and opens) a list of packs of a distribution on behalf of the issuer, e.g. for a promotional mass reveal, as if their owners had requested it. The transactions are sent in the user-facing lane like those of reveal requests. All packs have to be sealed (or revealed, to open them) or none is revealed. Reveals are rejected before the revealNotBefore of the distribution.
+   *
+   * POST /distributions/{distributionId}/packs/reveal
+   */
+  revealPacks(distributionId: string, body: PackReveal): Promise<PackRevealResult[]> {
+    return this.api.request<PackRevealResult[]>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/packs/reveal`, {}, body, false);
+  }
+
+  /**
+   * Abort distribution
+   *
+   * Forcibly abort the process, which will put the Distribution into the Invalid state. Settle and mint transactions not yet sent are cancelled. Once the sent ones have finished, the packs which were never minted are set to the cancelled state and, if requested, their escrowed collectibles are returned to the issuer. A distribution which started settling is set to the cancelled state once the return transactions have finished.
+   *
+   * POST /distributions/{distributionId}/abort
+   */
+  abortDistribution(distributionId: string, params: { returnEscrow?: boolean } = {}): Promise<void> {
+    return this.api.request<void>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/abort`, params, undefined, false);
+  }
+
+  /**
+   * Retry distribution
+   *
+   * Re-kicks the settlement or minting of a distribution stuck in the settling or minting state, e.g. after an Access API outage. Events are handled from the stored checkpoint up to the latest sealed block, then the collectibles or packs not yet settled or minted are queued again in new batches, replacing dead-letter transactions. Refused while settle or mint transactions are still in flight. A stalled distribution is set back to the state it timed out in and its held transactions are released first.
+   *
+   * POST /distributions/{distributionId}/retry
+   */
+  retryDistribution(distributionId: string): Promise<DistributionRetry> {
+    return this.api.request<DistributionRetry>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/retry`, {}, undefined, false);
+  }
+
+  /**
+   * Pause distribution
+   *
+   * Stops sending new settle and mint batches of a distribution which is not yet complete, e.g. to halt a drop after discovering a content mistake. Transactions already sent finish and their events are still handled, the others are held until resumed. A paused distribution does not start settling or minting, pausedAt is set while paused.
+   *
+   * POST /distributions/{distributionId}/pause
+   */
+  pauseDistribution(distributionId: string): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/pause`, {}, undefined, false);
+  }
+
+  /**
+   * Resume distribution
+   *
+   * Resumes a paused distribution, its held transactions are sent and settlement and minting continue.
+   *
+   * POST /distributions/{distributionId}/resume
+   */
+  resumeDistribution(distributionId: string): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/resume`, {}, undefined, false);
+  }
+
+  /**
+   * Archive distribution
+   *
+   * Archives a complete or closed distribution whose packs do not change anymore: all packs are opened or empty, or also sealed once the distribution is closed. Its packs are moved to an archive table and it is left out of GET /distributions unless ?archived=true. The distribution and its packs can still be read.
+   *
+   * POST /distributions/{distributionId}/archive
+   */
+  archiveDistribution(distributionId: string): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/archive`, {}, undefined, false);
+  }
+
+  /**
+   * Clone distribution
+   *
+   * Creates a draft distribution with the bucket structure (collectible contracts, counts and tier weights), pack count, pack contract and settings of the distribution, but no collectibles, for a recurring drop. Reveal times and the settlement start time are left out. PATCH the draft with the collectibleCollection (and collectibleTiers) of each bucket to resolve it, bucket fields left out are filled in from the draft.
+   *
+   * POST /distributions/{distributionId}/clone
+   */
+  cloneDistribution(distributionId: string, body: DistributionClone): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/clone`, {}, body, false);
+  }
+
+  /**
+   * Schedule reveals
+   *
+   * Sets when the packs of a distribution can be revealed (revealNotBefore of the pack template), in any state until the distribution is closed, e.g. to open packs at an announced time after minting. Reveal and open requests received earlier are queued and processed once it passes. Requests already queued are moved to the new time, leaving revealNotBefore out (or null) opens the reveals and sends them at once.
+   *
+   * PUT /distributions/{distributionId}/reveal-schedule
+   */
+  scheduleReveals(distributionId: string, body: RevealSchedule): Promise<DistributionGet> {
+    return this.api.request<DistributionGet>("PUT", `/distributions/${encodeURIComponent(String(distributionId))}/reveal-schedule`, {}, body, false);
+  }
+
+  /**
+   * Get collectible ownership check
+   *
+   * Returns the report of checking, when the distribution was set up, that its issuer owns all collectibles of its buckets. A distribution with missing collectibles is aborted before anything is escrowed, see FLOW_PDS_COLLECTIBLE_OWNERSHIP_CHECK.
+   *
+   * GET /distributions/{distributionId}/collectible-ownership
+   */
+  getCollectibleOwnershipCheck(distributionId: string): Promise<CollectibleOwnershipCheck> {
+    return this.api.request<CollectibleOwnershipCheck>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/collectible-ownership`, {}, undefined, false);
+  }
+
+  /**
+   * Start ownership verification
+   *
+   * Start verifying the onchain ownership of all minted packs in a complete distribution against the owners tracked from pack transfer events. The verification runs asynchronously.
+   *
+   * POST /distributions/{distributionId}/ownership-verifications
+   */
+  startOwnershipVerification(distributionId: string): Promise<OwnershipVerification> {
+    return this.api.request<OwnershipVerification>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/ownership-verifications`, {}, undefined, false);
+  }
+
+  /**
+   * Get ownership verification
+   *
+   * Returns the state and discrepancy report of an ownership verification.
+   *
+   * GET /distributions/{distributionId}/ownership-verifications/{verificationId}
+   */
+  getOwnershipVerification(distributionId: string, verificationId: string): Promise<OwnershipVerification> {
+    return this.api.request<OwnershipVerification>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/ownership-verifications/${encodeURIComponent(String(verificationId))}`, {}, undefined, false);
+  }
+
+  /**
+   * Get escrow surplus
+   *
+   * Reconciles the PDS escrow with the packs of a complete, closed or cancelled distribution. Collectibles of its buckets held in escrow are surplus unless a pack of the distribution, or of another distribution of the same issuer, still needs them (init, sealed, revealed or open requested). These are e.g. the collectibles of packs which failed to mint or were cancelled, or collectibles escrowed on top of the resolution.
+   *
+   * GET /distributions/{distributionId}/escrow-surplus
+   */
+  getEscrowSurplus(distributionId: string): Promise<EscrowSurplus> {
+    return this.api.request<EscrowSurplus>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/escrow-surplus`, {}, undefined, false);
+  }
+
+  /**
+   * Return escrow surplus
+   *
+   * Queues transactions returning the escrow surplus of a distribution to its issuer, in batches of the settlement batch size. Refused while return transactions of the distribution are still in flight.
+   *
+   * POST /distributions/{distributionId}/escrow-surplus/return
+   */
+  returnEscrowSurplus(distributionId: string): Promise<EscrowSurplus> {
+    return this.api.request<EscrowSurplus>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/escrow-surplus/return`, {}, undefined, true);
+  }
+
+  /**
+   * Get completion report
+   *
+   * Returns the completion report of a closed distribution, stored when the distribution was torn down.
+   *
+   * GET /distributions/{distributionId}/report
+   */
+  getCompletionReport(distributionId: string): Promise<CompletionReport> {
+    return this.api.request<CompletionReport>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/report`, {}, undefined, false);
+  }
+
+  /**
+   * Export distribution
+   *
+   * Returns a manifest of the packs of a complete or closed distribution for customer support and audits: pack ID, Flow ID, state, commitment hash, optionally the salt, the collectibles and the owner if known. The collectibles and salts of packs not yet revealed are left out, the export is restricted to the issuer like the mutating endpoints.
+   *
+   * GET /distributions/{distributionId}/export
+   */
+  exportDistribution(distributionId: string, params: { format?: 'json' | 'csv'; includeSalt?: boolean } = {}): Promise<DistributionExportPack[]> {
+    return this.api.request<DistributionExportPack[]>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/export`, params, undefined, false);
+  }
+
+  /**
+   * Get distribution costs
+   *
+   * Returns the transaction fees paid for settling, minting and any other transaction of the distribution, in total and per transaction template. Fees are known once a transaction is executed.
+   *
+   * GET /distributions/{distributionId}/costs
+   */
+  getDistributionCosts(distributionId: string): Promise<DistributionCosts> {
+    return this.api.request<DistributionCosts>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/costs`, {}, undefined, false);
+  }
+
+  /**
+   * Get distribution summary
+   *
+   * Returns an overview of a distribution for dashboards: the number of packs per state, how well each bucket fills its pack slots, settlement and minting progress in percent, the number of transactions per state including failed and dead-letter ones, and when settling and minting started and finished.
+   *
+   * GET /distributions/{distributionId}/summary
+   */
+  getDistributionSummary(distributionId: string): Promise<DistributionSummary> {
+    return this.api.request<DistributionSummary>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/summary`, {}, undefined, false);
+  }
+
+  /**
+   * Estimate distribution completion
+   *
+   * Returns an estimate of when the settlement and minting of a settling or minting distribution complete, from the batches left at the current batch sizes, the send rate and the seal latency of its latest sealed batches. The estimate is updated as batches seal. It assumes the distribution has the send rate to itself.
+   *
+   * GET /distributions/{distributionId}/eta
+   */
+  getDistributionEta(distributionId: string): Promise<DistributionETA> {
+    return this.api.request<DistributionETA>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/eta`, {}, undefined, false);
+  }
+
+  /**
+   * Verify distribution resolution
+   *
+   * Discloses the seed and algorithm version the collectibles of a closed or cancelled distribution, or of a complete distribution with all of its packs revealed or opened, were shuffled into packs with, and verifies the packs stored against the packs the seed resolves to. Fails with a 'distribution_state' problem in other states or while packs of a complete distribution are still sealed, or if the distribution was resolved before seeds were recorded.
+   *
+   * GET /distributions/{distributionId}/resolution
+   */
+  getDistributionResolution(distributionId: string): Promise<DistributionResolution> {
+    return this.api.request<DistributionResolution>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/resolution`, {}, undefined, false);
+  }
+
+  /**
+   * List transaction audit log
+   *
+   * Lists every transaction sent on behalf of the distribution, one entry per send attempt, oldest first. Entries hold the script hash, Cadence arguments, proposer key index and the signed transaction (RLP) as sent, and the final status of the attempt.
+   *
+   * GET /distributions/{distributionId}/transactions
+   */
+  listTransactionAudit(distributionId: string, params: { limit?: number; offset?: number } = {}): Promise<TransactionAttempt[]> {
+    return this.api.request<TransactionAttempt[]>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/transactions`, params, undefined, true);
+  }
+
+  /**
+   * Create gift intents
+   *
+   * Register intended recipients for minted packs (e.g. a gift campaign). A pack can have only one pending gift intent at a time.
+   *
+   * POST /distributions/{distributionId}/gift-intents
+   */
+  createGiftIntents(distributionId: string, body: CreateGiftIntentsRequest): Promise<GiftIntent[]> {
+    return this.api.request<GiftIntent[]>("POST", `/distributions/${encodeURIComponent(String(distributionId))}/gift-intents`, {}, body, false);
+  }
+
+  /**
+   * List gift intents
+   *
+   * Lists the gift intents of a distribution, newest first.
+   *
+   * GET /distributions/{distributionId}/gift-intents
+   */
+  listGiftIntents(distributionId: string, params: { limit?: number; offset?: number } = {}): Promise<GiftIntent[]> {
+    return this.api.request<GiftIntent[]>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/gift-intents`, params, undefined, false);
+  }
+
+  /**
+   * Get gift intent
+   *
+   * Returns a gift intent including whether the transfer to the recipient has been observed.
+   *
+   * GET /distributions/{distributionId}/gift-intents/{giftIntentId}
+   */
+  getGiftIntent(distributionId: string, giftIntentId: string): Promise<GiftIntent> {
+    return this.api.request<GiftIntent>("GET", `/distributions/${encodeURIComponent(String(distributionId))}/gift-intents/${encodeURIComponent(String(giftIntentId))}`, {}, undefined, false);
+  }
+
+  /**
+   * GraphQL query
+   *
+   * Read-only GraphQL API over distributions, buckets, packs and collectibles, so related data can be fetched in one request. Queries can also be sent with GET and the query, operationName and variables parameters. Mutations, subscriptions and introspection are not supported. Distributions of other issuers are not visible when FLOW_PDS_ISSUER_ISOLATION is set.
+   *
+   * POST /graphql
+   */
+  graphqlQuery(body: GraphQLRequest): Promise<GraphQLResponse> {
+    return this.api.request<GraphQLResponse>("POST", `/graphql`, {}, body, false);
+  }
+
+  /**
+   * GraphQL query (GET)
+   *
+   * Same as graphql-query, with the query in the query string.
+   *
+   * GET /graphql
+   */
+  graphqlQueryGet(params: { query: string; operationName?: string; variables?: string }): Promise<GraphQLResponse> {
+    return this.api.request<GraphQLResponse>("GET", `/graphql`, params, undefined, false);
+  }
+
+  /**
+   * Get GraphQL schema
+   *
+   * Returns the schema of the GraphQL API in the schema definition language.
+   *
+   * GET /graphql/schema
+   */
+  getGraphqlSchema(): Promise<void> {
+    return this.api.request<void>("GET", `/graphql/schema`, {}, undefined, false);
+  }
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
 	"math"
 	"os"
 	"strings"
@@ -394,3 +398,740 @@ func TestE2E(t *testing.T) {
 
 	assert.Equal(t, uint8(2), distStateR.ToGoValue().(uint8), "Expected distribution to be in state 2 (complete)")
 }
+
+func TestE2EMultiCollection(t *testing.T) {
+	cfg := getTestCfg(t, nil)
+	a, cleanup := getTestApp(cfg, true)
+	defer cleanup()
+
+	g := gwtf.NewGoWithTheFlow([]string{"./flow.json"}, "emulator", false, 0)
+
+	flowClient, err := client.New("localhost:3569", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddress(g.Account("issuer").Address())
+	badge := app.AddressLocation{Name: "ExampleBadge", Address: issuer}
+
+	t.Log("Setting up collections of both collectible contracts and the PackIssuer")
+
+	setupE2ECollection(t, g, "owner", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "issuer", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "owner", badge.Name, "BadgeCollectionProvider")
+	setupE2ECollection(t, g, "issuer", badge.Name, "BadgeCollectionProvider")
+	setupE2EIssuer(t, g, a, issuer)
+
+	noPacks := 2
+	nfts := mintE2ECollectibles(t, g, flowClient, "ExampleNFT", noPacks)
+	badges := mintE2ECollectibles(t, g, flowClient, badge.Name, noPacks)
+
+	t.Log("Issuer creates the distribution onchain, sharing a withdraw capability per contract")
+
+	providerPaths := cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.NewString(badge.String()), Value: cadence.Path{Domain: "private", Identifier: "BadgeCollectionProvider"}},
+	})
+
+	distribution := app.Distribution{
+		Issuer: issuer,
+		PackTemplate: app.PackTemplate{
+			PackReference: app.AddressLocation{Name: "PackNFT", Address: issuer},
+			PackCount:     uint(noPacks),
+			Buckets: []app.Bucket{
+				{
+					CollectibleReference:  app.AddressLocation{Name: "ExampleNFT", Address: issuer},
+					CollectibleCount:      1,
+					CollectibleCollection: nfts,
+				},
+				{
+					CollectibleReference:  badge,
+					CollectibleCount:      1,
+					CollectibleCollection: badges,
+				},
+			},
+		},
+	}
+
+	createE2EDistribution(t, g, a, &distribution,
+		"./cadence-transactions/pds/create_multi_collection_distribution.cdc",
+		cadence.Path{Domain: "private", Identifier: "NFTCollectionProvider"},
+		providerPaths,
+		cadence.NewString("MultiCollectionDistTitle"),
+		cadence.NewDictionary(nil),
+	)
+
+	pack := distribution.Packs[0]
+	if len(pack.Collectibles) != 2 {
+		t.Fatalf("expected pack to contain 2 collectibles, got %d", len(pack.Collectibles))
+	}
+
+	t.Log("Owner opens a pack holding collectibles of both contracts")
+
+	openE2EPack(t, g, a, pack)
+
+	for _, c := range pack.Collectibles {
+		waitForE2ECollectible(t, g, flowClient, "owner", c)
+	}
+}
+
+func TestE2EFungibleTokens(t *testing.T) {
+	cfg := getTestCfg(t, nil)
+	a, cleanup := getTestApp(cfg, true)
+	defer cleanup()
+
+	g := gwtf.NewGoWithTheFlow([]string{"./flow.json"}, "emulator", false, 0)
+
+	flowClient, err := client.New("localhost:3569", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddress(g.Account("issuer").Address())
+	// FlowToken of the emulator
+	flowToken := app.AddressLocation{Name: "FlowToken", Address: common.FlowAddressFromString("0ae53cb6e3f42a79")}
+
+	t.Log("Setting up the collections, the PackIssuer and the withdraw capability of the issuer vault")
+
+	setupE2ECollection(t, g, "owner", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "issuer", "ExampleNFT", "NFTCollectionProvider")
+	setupE2EIssuer(t, g, a, issuer)
+
+	linkProvider := "./cadence-transactions/fungibleToken/link_provider.cdc"
+	linkProviderCode := util.ParseCadenceTemplate(linkProvider)
+	_, err = g.
+		TransactionFromFile(linkProvider, linkProviderCode).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "storage", Identifier: "flowTokenVault"}).
+		Argument(cadence.Path{Domain: "private", Identifier: "flowTokenProvider"}).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noPacks := 2
+	amount, err := app.FungibleTokenAmountFromString("1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("Issuer creates the distribution onchain, sharing a withdraw capability of its vault")
+
+	providerPaths := cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.NewString(flowToken.String()), Value: cadence.Path{Domain: "private", Identifier: "flowTokenProvider"}},
+	})
+
+	distribution := app.Distribution{
+		Issuer: issuer,
+		PackTemplate: app.PackTemplate{
+			PackReference: app.AddressLocation{Name: "PackNFT", Address: issuer},
+			PackCount:     uint(noPacks),
+			Buckets: []app.Bucket{
+				{
+					CollectibleReference:  app.AddressLocation{Name: "ExampleNFT", Address: issuer},
+					CollectibleCount:      1,
+					CollectibleCollection: mintE2ECollectibles(t, g, flowClient, "ExampleNFT", noPacks),
+				},
+			},
+			FungibleToken:             app.FungibleTokenAmount{ContractReference: flowToken, Amount: amount},
+			FungibleTokenReceiverPath: "flowTokenReceiver",
+		},
+	}
+
+	createE2EDistribution(t, g, a, &distribution,
+		"./cadence-transactions/pds/create_distribution_with_fungible_tokens.cdc",
+		cadence.Path{Domain: "private", Identifier: "NFTCollectionProvider"},
+		cadence.NewDictionary(nil),
+		providerPaths,
+		cadence.NewString("FungibleTokenDistTitle"),
+		cadence.NewDictionary(nil),
+	)
+
+	t.Log("Owner opens a pack holding a collectible and FLOW")
+
+	owner, err := flowClient.GetAccount(context.Background(), g.Account("owner").Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pack := distribution.Packs[0]
+	openE2EPack(t, g, a, pack)
+
+	waitForE2ECollectible(t, g, flowClient, "owner", pack.Collectibles[0])
+
+	ownerAfter, err := flowClient.GetAccount(context.Background(), g.Account("owner").Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, owner.Balance+amount, ownerAfter.Balance, "Expected the owner to receive the FLOW of the pack")
+}
+
+func TestE2ESeedCommitReveal(t *testing.T) {
+	cfg := getTestCfg(t, nil)
+	a, cleanup := getTestApp(cfg, true)
+	defer cleanup()
+
+	g := gwtf.NewGoWithTheFlow([]string{"./flow.json"}, "emulator", false, 0)
+
+	flowClient, err := client.New("localhost:3569", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddress(g.Account("issuer").Address())
+
+	setupE2ECollection(t, g, "owner", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "issuer", "ExampleNFT", "NFTCollectionProvider")
+	setupE2EIssuer(t, g, a, issuer)
+
+	noPacks := 2
+
+	t.Log("Issuer creates a commit-reveal distribution, the PDS commits to its seed")
+
+	distribution := app.Distribution{
+		Issuer:           issuer,
+		SeedCommitReveal: true,
+		PackTemplate: app.PackTemplate{
+			PackReference: app.AddressLocation{Name: "PackNFT", Address: issuer},
+			PackCount:     uint(noPacks),
+			Buckets: []app.Bucket{
+				{
+					CollectibleReference:  app.AddressLocation{Name: "ExampleNFT", Address: issuer},
+					CollectibleCount:      1,
+					CollectibleCollection: mintE2ECollectibles(t, g, flowClient, "ExampleNFT", noPacks),
+				},
+			},
+		},
+	}
+
+	createE2EDistribution(t, g, a, &distribution,
+		"./cadence-transactions/pds/create_distribution.cdc",
+		cadence.Path{Domain: "private", Identifier: "NFTCollectionProvider"},
+		cadence.NewString("SeedCommitRevealDistTitle"),
+		cadence.NewDictionary(nil),
+	)
+
+	getSeedCommitment := "./cadence-scripts/pds/get_seed_commitment.cdc"
+	getSeedCommitmentCode := util.ParseCadenceTemplate(getSeedCommitment)
+	commitment, err := g.
+		ScriptFromFile(getSeedCommitment, getSeedCommitmentCode).
+		UInt64Argument(uint64(distribution.FlowID.Int64)).
+		RunReturns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secretHash := sha256.Sum256(distribution.SeedSecret)
+	assert.Equal(t, hex.EncodeToString(secretHash[:]), commitment.ToGoValue(), "Expected the commitment to the seed secret on-chain")
+
+	t.Log("Packs are resolved from the seed derived from the secret and the anchor block")
+
+	anchorBlock, err := flowClient.GetBlockByHeight(context.Background(), distribution.SeedAnchorHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, anchorBlock.ID.Hex(), distribution.SeedAnchorBlockID)
+
+	seedHash := sha256.Sum256(append(append([]byte{}, distribution.SeedSecret...), anchorBlock.ID[:]...))
+	assert.Equal(t, int64(binary.BigEndian.Uint64(seedHash[:8])), distribution.ResolveSeed)
+
+	t.Log("Wait for the PDS to reveal the secret on-chain")
+
+	revealed := fmt.Sprintf("A.%s.PDS.DistributionSeedRevealed", g.Account("pds").Address().Hex())
+	for i := 0; ; i++ {
+		latest, err := flowClient.GetLatestBlockHeader(context.Background(), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks, err := flowClient.GetEventsForHeightRange(context.Background(), client.EventRangeQuery{
+			Type:        revealed,
+			StartHeight: distribution.SeedAnchorHeight,
+			EndHeight:   latest.Height,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range blocks {
+			for _, e := range b.Events {
+				if e.Value.Fields[0].ToGoValue() == uint64(distribution.FlowID.Int64) {
+					assert.Equal(t, hex.EncodeToString(distribution.SeedSecret), e.Value.Fields[1].ToGoValue())
+					return
+				}
+			}
+		}
+		if i == 30 {
+			t.Fatal("Expected the PDS to reveal the seed secret")
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func TestE2ERevokePack(t *testing.T) {
+	cfg := getTestCfg(t, nil)
+	cfg.PackRevocationOnChain = true
+	a, cleanup := getTestApp(cfg, true)
+	defer cleanup()
+
+	g := gwtf.NewGoWithTheFlow([]string{"./flow.json"}, "emulator", false, 0)
+
+	flowClient, err := client.New("localhost:3569", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddress(g.Account("issuer").Address())
+
+	t.Log("Setting up the collections, the PackIssuer and the revoke operator of PackNFT")
+
+	setupE2ECollection(t, g, "owner", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "issuer", "ExampleNFT", "NFTCollectionProvider")
+	setupE2EIssuer(t, g, a, issuer)
+
+	linkRevokeOperator := "./cadence-transactions/packNFT/link_revoke_operator.cdc"
+	linkRevokeOperatorCode := util.ParseCadenceTemplate(linkRevokeOperator)
+	_, err = g.
+		TransactionFromFile(linkRevokeOperator, linkRevokeOperatorCode).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "storage", Identifier: "PackNFTRevokeOperator"}).
+		Argument(cadence.Path{Domain: "private", Identifier: "PackNFTRevokeOperator"}).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noPacks := 2
+
+	t.Log("Issuer creates the distribution onchain, sharing the revoke operator")
+
+	distribution := app.Distribution{
+		Issuer: issuer,
+		PackTemplate: app.PackTemplate{
+			PackReference: app.AddressLocation{Name: "PackNFT", Address: issuer},
+			PackCount:     uint(noPacks),
+			Buckets: []app.Bucket{
+				{
+					CollectibleReference:  app.AddressLocation{Name: "ExampleNFT", Address: issuer},
+					CollectibleCount:      1,
+					CollectibleCollection: mintE2ECollectibles(t, g, flowClient, "ExampleNFT", noPacks),
+				},
+			},
+		},
+	}
+
+	createE2EDistribution(t, g, a, &distribution,
+		"./cadence-transactions/pds/create_distribution_with_operators.cdc",
+		cadence.Path{Domain: "private", Identifier: "NFTCollectionProvider"},
+		cadence.NewOptional(cadence.Path{Domain: "private", Identifier: "PackNFTRevokeOperator"}),
+		cadence.NewOptional(nil),
+		cadence.NewString("RevocableDistTitle"),
+		cadence.NewDictionary(nil),
+	)
+
+	t.Log("Admin revokes a pack, the PDS freezes it onchain")
+
+	pack := distribution.Packs[0]
+	revocation, err := a.RevokePack(context.Background(), pack.ID, "sold fraudulently")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revocation.TransactionID == nil {
+		t.Fatal("expected a transaction revoking the pack onchain")
+	}
+
+	packStatus := "./cadence-scripts/packNFT/pack_status.cdc"
+	packStatusCode, err := flow_helpers.ParseCadenceTemplate(
+		packStatus,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    pack.ContractReference.Name,
+			PackNFTAddress: pack.ContractReference.Address.String(),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; ; i++ {
+		status, err := flowClient.ExecuteScriptAtLatestBlock(context.Background(), packStatusCode, []cadence.Value{cadence.UInt64(pack.FlowID.Int64)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// PackNFT.Status.Revoked
+		if status == cadence.UInt8(3) {
+			break
+		}
+		if i == 30 {
+			t.Fatalf("expected the pack to be revoked onchain, status is %v", status)
+		}
+		time.Sleep(time.Second)
+	}
+
+	t.Log("Owner can not request to reveal the revoked pack")
+
+	packID := cadence.UInt64(pack.FlowID.Int64)
+
+	transferPackNFT := "./cadence-transactions/packNFT/transfer_packNFT.cdc"
+	transferPackNFTCode := util.ParseCadenceTemplate(transferPackNFT)
+	_, err = g.
+		TransactionFromFile(transferPackNFT, transferPackNFTCode).
+		SignProposeAndPayAs("issuer").
+		AccountArgument("owner").
+		Argument(packID).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revealRequest := "./cadence-transactions/packNFT/reveal_request.cdc"
+	revealRequestCode := util.ParseCadenceTemplate(revealRequest)
+	_, err = g.
+		TransactionFromFile(revealRequest, revealRequestCode).
+		SignProposeAndPayAs("owner").
+		Argument(packID).
+		BooleanArgument(true).
+		RunE()
+	assert.Error(t, err)
+}
+
+func TestE2EPackMetadata(t *testing.T) {
+	cfg := getTestCfg(t, nil)
+	cfg.PackMetadataOnChain = true
+	a, cleanup := getTestApp(cfg, true)
+	defer cleanup()
+
+	g := gwtf.NewGoWithTheFlow([]string{"./flow.json"}, "emulator", false, 0)
+
+	flowClient, err := client.New("localhost:3569", grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddress(g.Account("issuer").Address())
+
+	t.Log("Setting up the collections, the PackIssuer and the metadata operator of PackNFT")
+
+	setupE2ECollection(t, g, "owner", "ExampleNFT", "NFTCollectionProvider")
+	setupE2ECollection(t, g, "issuer", "ExampleNFT", "NFTCollectionProvider")
+	setupE2EIssuer(t, g, a, issuer)
+
+	linkMetadataOperator := "./cadence-transactions/packNFT/link_metadata_operator.cdc"
+	linkMetadataOperatorCode := util.ParseCadenceTemplate(linkMetadataOperator)
+	_, err = g.
+		TransactionFromFile(linkMetadataOperator, linkMetadataOperatorCode).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "storage", Identifier: "PackNFTMetadataOperator"}).
+		Argument(cadence.Path{Domain: "private", Identifier: "PackNFTMetadataOperator"}).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noPacks := 2
+
+	t.Log("Issuer creates the distribution onchain, sharing the metadata operator")
+
+	distribution := app.Distribution{
+		Issuer: issuer,
+		Metadata: app.DistributionMetadata{
+			Title:       "Pack title",
+			Description: "Pack description",
+			ImageURI:    "ipfs://pack",
+		},
+		PackTemplate: app.PackTemplate{
+			PackReference: app.AddressLocation{Name: "PackNFT", Address: issuer},
+			PackCount:     uint(noPacks),
+			Buckets: []app.Bucket{
+				{
+					CollectibleReference:  app.AddressLocation{Name: "ExampleNFT", Address: issuer},
+					CollectibleCount:      1,
+					CollectibleCollection: mintE2ECollectibles(t, g, flowClient, "ExampleNFT", noPacks),
+				},
+			},
+		},
+	}
+
+	createE2EDistribution(t, g, a, &distribution,
+		"./cadence-transactions/pds/create_distribution_with_operators.cdc",
+		cadence.Path{Domain: "private", Identifier: "NFTCollectionProvider"},
+		cadence.NewOptional(nil),
+		cadence.NewOptional(cadence.Path{Domain: "private", Identifier: "PackNFTMetadataOperator"}),
+		cadence.NewString("MetadataDistTitle"),
+		cadence.NewDictionary(nil),
+	)
+
+	if !distribution.Metadata.OnChain {
+		t.Fatal("expected the packs to be minted with the metadata")
+	}
+
+	pack := distribution.Packs[0]
+	packMetadata := "./cadence-scripts/packNFT/pack_metadata.cdc"
+	packMetadataCode, err := flow_helpers.ParseCadenceTemplate(
+		packMetadata,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    pack.ContractReference.Name,
+			PackNFTAddress: pack.ContractReference.Address.String(),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := flowClient.ExecuteScriptAtLatestBlock(context.Background(), packMetadataCode, []cadence.Value{cadence.UInt64(pack.FlowID.Int64)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[interface{}]interface{}{
+		"name":        "Pack title",
+		"description": "Pack description",
+		"thumbnail":   "ipfs://pack",
+	}, metadata.ToGoValue())
+}
+
+// setupE2ECollection sets up a collection of the collectible contract
+// 'contract' (deployed by the issuer) for 'account', linking its withdraw
+// capability to 'providerPath'. It can be run again.
+func setupE2ECollection(t *testing.T, g *gwtf.GoWithTheFlow, account, contract, providerPath string) {
+	setupScript := "./cadence-transactions/collectibleNFT/setup_collection_and_link_provider.cdc"
+	setupCode, err := flow_helpers.ParseCadenceTemplate(
+		setupScript,
+		&flow_helpers.CadenceTemplateVars{
+			CollectibleNFTName:    contract,
+			CollectibleNFTAddress: g.Account("issuer").Address().String(),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.
+		TransactionFromFile(setupScript, setupCode).
+		SignProposeAndPayAs(account).
+		Argument(cadence.Path{Domain: "private", Identifier: providerPath}).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupE2EIssuer creates the PackIssuer of 'issuer', the PackNFT collections
+// of the issuer and owner and shares the DistCap with the PackIssuer.
+func setupE2EIssuer(t *testing.T, g *gwtf.GoWithTheFlow, a *app.App, issuer common.FlowAddress) {
+	createPackIssuer := "./cadence-transactions/pds/create_new_pack_issuer.cdc"
+	createPackIssuerCode := util.ParseCadenceTemplate(createPackIssuer)
+	_, err := g.
+		TransactionFromFile(createPackIssuer, createPackIssuerCode).
+		SignProposeAndPayAs("issuer").
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createPackNFTCollection := "./cadence-transactions/packNFT/create_new_packNFT_collection.cdc"
+	createPackNFTCollectionCode := util.ParseCadenceTemplate(createPackNFTCollection)
+	for _, account := range []string{"issuer", "owner"} {
+		_, err = g.
+			TransactionFromFile(createPackNFTCollection, createPackNFTCollectionCode).
+			SignProposeAndPayAs(account).
+			RunE()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := a.SetDistCap(context.Background(), issuer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mintE2ECollectibles mints 'count' collectibles of 'contract' to the issuer
+// and returns their IDs.
+func mintE2ECollectibles(t *testing.T, g *gwtf.GoWithTheFlow, flowClient *client.Client, contract string, count int) common.FlowIDList {
+	vars := &flow_helpers.CadenceTemplateVars{
+		CollectibleNFTName:    contract,
+		CollectibleNFTAddress: g.Account("issuer").Address().String(),
+	}
+
+	before := listE2ECollectibles(t, g, flowClient, "issuer", vars)
+
+	mintScript := "./cadence-transactions/collectibleNFT/mint.cdc"
+	mintCode, err := flow_helpers.ParseCadenceTemplate(mintScript, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.
+		TransactionFromFile(mintScript, mintCode).
+		SignProposeAndPayAs("issuer").
+		AccountArgument("issuer").
+		IntArgument(count).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res common.FlowIDList
+	for _, id := range listE2ECollectibles(t, g, flowClient, "issuer", vars) {
+		if _, ok := before.Contains(id); !ok {
+			res = append(res, id)
+		}
+	}
+
+	if len(res) != count {
+		t.Fatalf("expected %d new %s collectibles, got %d", count, contract, len(res))
+	}
+
+	return res
+}
+
+// listE2ECollectibles returns the IDs of the collectibles of the contract of
+// 'vars' owned by 'account'.
+func listE2ECollectibles(t *testing.T, g *gwtf.GoWithTheFlow, flowClient *client.Client, account string, vars *flow_helpers.CadenceTemplateVars) common.FlowIDList {
+	idsScript, err := flow_helpers.ParseCadenceTemplate("./cadence-scripts/collectibleNFT/owned_collectible_ids.cdc", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := flowClient.ExecuteScriptAtLatestBlock(context.Background(), idsScript, []cadence.Value{
+		cadence.NewAddress(g.Account(account).Address()),
+		cadence.NewUInt64(0),
+		cadence.NewUInt64(math.MaxUint32),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := common.FlowIDListFromCadence(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+// createE2EDistribution creates 'distribution' onchain with the issuer
+// transaction 'createScript' and its 'arguments', then in the PDS. Returns
+// once the distribution is complete, with 'distribution' reloaded.
+func createE2EDistribution(t *testing.T, g *gwtf.GoWithTheFlow, a *app.App, distribution *app.Distribution, createScript string, arguments ...cadence.Value) {
+	pdsDistId := "./cadence-scripts/pds/get_next_dist_id.cdc"
+	pdsDistIdCode := util.ParseCadenceTemplate(pdsDistId)
+	currentDistId, err := g.ScriptFromFile(pdsDistId, pdsDistIdCode).RunReturns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createCode, err := flow_helpers.ParseCadenceTemplate(
+		createScript,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    distribution.PackTemplate.PackReference.Name,
+			PackNFTAddress: distribution.PackTemplate.PackReference.Address.String(),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := g.TransactionFromFile(createScript, createCode).SignProposeAndPayAs("issuer")
+	for _, arg := range arguments {
+		tx = tx.Argument(arg)
+	}
+	if _, err := tx.RunE(); err != nil {
+		t.Fatal(err)
+	}
+
+	distribution.State = common.DistributionStateInit
+	distribution.FlowID, err = common.FlowIDFromCadence(currentDistId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.CreateDistribution(context.Background(), distribution); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("Wait for the distribution to complete")
+
+	for {
+		state, err := a.GetDistributionState(context.Background(), distribution.ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "database is locked") {
+				continue
+			}
+			t.Fatal(err)
+		}
+		if state == common.DistributionStateComplete {
+			d, err := a.GetDistribution(context.Background(), distribution.ID)
+			if err != nil {
+				if strings.Contains(err.Error(), "database is locked") {
+					continue
+				}
+				t.Fatal(err)
+			}
+			*distribution = *d
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// openE2EPack transfers 'pack' to the owner, who requests to reveal and open
+// it. Returns once the PDS has opened it.
+func openE2EPack(t *testing.T, g *gwtf.GoWithTheFlow, a *app.App, pack app.Pack) {
+	packID := cadence.UInt64(pack.FlowID.Int64)
+
+	transferPackNFT := "./cadence-transactions/packNFT/transfer_packNFT.cdc"
+	transferPackNFTCode := util.ParseCadenceTemplate(transferPackNFT)
+	_, err := g.
+		TransactionFromFile(transferPackNFT, transferPackNFTCode).
+		SignProposeAndPayAs("issuer").
+		AccountArgument("owner").
+		Argument(packID).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revealRequest := "./cadence-transactions/packNFT/reveal_request.cdc"
+	revealRequestCode := util.ParseCadenceTemplate(revealRequest)
+	_, err = g.
+		TransactionFromFile(revealRequest, revealRequestCode).
+		SignProposeAndPayAs("owner").
+		Argument(packID).
+		BooleanArgument(true).
+		RunE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		p, err := a.GetPack(context.Background(), pack.ID)
+		if err != nil {
+			if strings.Contains(err.Error(), "database is locked") {
+				continue
+			}
+			t.Fatal(err)
+		}
+		if p.State == common.PackStateOpened {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// waitForE2ECollectible waits for collectible 'c' to be deposited to
+// 'account', the open transaction may not be sealed yet when the pack is
+// set opened.
+func waitForE2ECollectible(t *testing.T, g *gwtf.GoWithTheFlow, flowClient *client.Client, account string, c app.Collectible) {
+	vars := &flow_helpers.CadenceTemplateVars{
+		CollectibleNFTName:    c.ContractReference.Name,
+		CollectibleNFTAddress: c.ContractReference.Address.String(),
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, ok := listE2ECollectibles(t, g, flowClient, account, vars).Contains(c.FlowID); ok {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+
+	t.Errorf("expected %s to have collectible NFT: %s", account, c)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -150,6 +152,54 @@ func TestCreateDistWithCap(t *testing.T) {
 	assert.Equal(t, stringifiedKeyPair, metadata)
 }
 
+func TestPDSCommitAndRevealSeed(t *testing.T) {
+	g := gwtf.NewGoWithTheFlow(util.FlowJSON, os.Getenv("NETWORK"), false, 3)
+	nextDistId, err := pds.GetNextDistID(g)
+	assert.NoError(t, err)
+	distId := nextDistId - 1
+
+	secret := "2f1e3c4d5b6a79880716253443526170"
+	secretBytes, err := hex.DecodeString(secret)
+	assert.NoError(t, err)
+	hash := sha256.Sum256(secretBytes)
+	commitment := hex.EncodeToString(hash[:])
+
+	height, err := util.GetCurrentBlockHeight(g)
+	assert.NoError(t, err)
+	anchorHeight := height + 10
+
+	events, err := pds.PDSCommitSeed(g, distId, commitment, anchorHeight)
+	assert.NoError(t, err)
+	util.NewExpectedPDSEvent("DistributionSeedCommitted").
+		AddField("DistId", strconv.Itoa(int(distId))).
+		AddField("commitment", commitment).
+		AddField("anchorHeight", strconv.Itoa(int(anchorHeight))).
+		AssertEqual(t, events[0])
+
+	actual, err := pds.GetSeedCommitment(g, distId)
+	assert.NoError(t, err)
+	assert.Equal(t, commitment, actual)
+
+	// A seed can only be committed once
+	_, err = pds.PDSCommitSeed(g, distId, commitment, anchorHeight)
+	assert.Error(t, err)
+
+	_, err = pds.PDSRevealSeed(g, distId, "00"+secret[2:])
+	assert.Error(t, err)
+
+	events, err = pds.PDSRevealSeed(g, distId, secret)
+	assert.NoError(t, err)
+	util.NewExpectedPDSEvent("DistributionSeedRevealed").
+		AddField("DistId", strconv.Itoa(int(distId))).
+		AddField("secret", secret).
+		AddField("anchorHeight", strconv.Itoa(int(anchorHeight))).
+		AssertEqual(t, events[0])
+
+	// No seed committed for the next distribution
+	_, err = pds.PDSRevealSeed(g, nextDistId, secret)
+	assert.Error(t, err)
+}
+
 func TestPDSEscrowNFTs(t *testing.T) {
 	// This just tests to transfer all issuer example NFTs into escrow
 	g := gwtf.NewGoWithTheFlow(util.FlowJSON, os.Getenv("NETWORK"), false, 3)
@@ -734,3 +784,111 @@ func TestPublicVerify(t *testing.T) {
 	_, err = packnft.Verify(g, currentPack, notNfts)
 	assert.Error(t, err)
 }
+
+func TestPDSRevokePackNFT(t *testing.T) {
+	g := gwtf.NewGoWithTheFlow(util.FlowJSON, os.Getenv("NETWORK"), false, 3)
+	addr := g.Account("issuer").Address().String()
+	metadata := cadence.NewDictionary([]cadence.KeyValuePair{})
+
+	// The distributions created so far did not share a revoke operator
+	nextDistId, err := pds.GetNextDistID(g)
+	assert.NoError(t, err)
+	canRevoke, err := pds.CanRevoke(g, nextDistId-1)
+	assert.NoError(t, err)
+	assert.False(t, canRevoke)
+
+	_, err = pds.PDSRevokePackNFT(g, nextDistId-1, 1)
+	assert.Error(t, err)
+
+	err = packnft.LinkRevokeOperator(g, "PackNFTRevokeOperator")
+	assert.NoError(t, err)
+
+	_, err = pds.CreateDistributionWithOperators(g, "NFTCollectionProvider", "PackNFTRevokeOperator", "", "RevocableDistTitle", metadata)
+	assert.NoError(t, err)
+	distId := nextDistId
+
+	canRevoke, err = pds.CanRevoke(g, distId)
+	assert.NoError(t, err)
+	assert.True(t, canRevoke)
+
+	salt := "r24dfdf9911df152"
+	hash, err := util.GetHash(g, salt+",A."+addr+".ExampleNFT.2,A."+addr+".ExampleNFT.4")
+	assert.NoError(t, err)
+	_, err = pds.PDSMintPackNFT(g, distId, hash, "issuer", "pds")
+	assert.NoError(t, err)
+	packId, err := packnft.GetTotalPacks(g)
+	assert.NoError(t, err)
+
+	events, err := pds.PDSRevokePackNFT(g, distId, packId)
+	assert.NoError(t, err)
+	util.NewExpectedPackNFTEvent("Revoked").
+		AddField("id", strconv.Itoa(int(packId))).
+		AssertEqual(t, events[0])
+
+	status, err := packnft.GetPackStatus(g, packId)
+	assert.NoError(t, err)
+	assert.Equal(t, "Revoked", status)
+
+	_, err = pds.PDSRevokePackNFT(g, distId, packId)
+	assert.Error(t, err)
+
+	// A revoked pack can not be revealed anymore, even publicly
+	addrBytes := cadence.BytesToAddress(g.Account("issuer").Address().Bytes())
+	_, err = packnft.PublicRevealPackNFT(
+		g, packId,
+		cadence.NewArray([]cadence.Value{addrBytes, addrBytes}),
+		cadence.NewArray([]cadence.Value{cadence.NewString("ExampleNFT"), cadence.NewString("ExampleNFT")}),
+		cadence.NewArray([]cadence.Value{cadence.UInt64(2), cadence.UInt64(4)}),
+		salt, "pds",
+	)
+	assert.Error(t, err)
+}
+
+func TestPDSMintPackNFTWithMetadata(t *testing.T) {
+	g := gwtf.NewGoWithTheFlow(util.FlowJSON, os.Getenv("NETWORK"), false, 3)
+	metadata := cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.NewString("name"), Value: cadence.NewString("Pack name")},
+		{Key: cadence.NewString("description"), Value: cadence.NewString("Pack description")},
+		{Key: cadence.NewString("thumbnail"), Value: cadence.NewString("ipfs://pack")},
+	})
+
+	// The last distribution only shared a revoke operator
+	nextDistId, err := pds.GetNextDistID(g)
+	assert.NoError(t, err)
+	canMint, err := pds.CanMintWithMetadata(g, nextDistId-1)
+	assert.NoError(t, err)
+	assert.False(t, canMint)
+
+	_, err = pds.PDSMintPackNFTWithMetadata(g, nextDistId-1, "hash", "issuer", metadata)
+	assert.Error(t, err)
+
+	err = packnft.LinkMetadataOperator(g, "PackNFTMetadataOperator")
+	assert.NoError(t, err)
+
+	_, err = pds.CreateDistributionWithOperators(g, "NFTCollectionProvider", "", "PackNFTMetadataOperator", "MetadataDistTitle", metadata)
+	assert.NoError(t, err)
+	distId := nextDistId
+
+	canMint, err = pds.CanMintWithMetadata(g, distId)
+	assert.NoError(t, err)
+	assert.True(t, canMint)
+
+	events, err := pds.PDSMintPackNFTWithMetadata(g, distId, "hash", "issuer", metadata)
+	assert.NoError(t, err)
+	packId, err := packnft.GetTotalPacks(g)
+	assert.NoError(t, err)
+	util.NewExpectedPackNFTEvent("Mint").
+		AddField("id", strconv.Itoa(int(packId))).
+		AddField("commitHash", "hash").
+		AddField("distId", strconv.Itoa(int(distId))).
+		AssertEqual(t, events[0])
+
+	actual, err := packnft.GetPackMetadata(g, packId)
+	assert.NoError(t, err)
+	assert.Equal(t, cadence.NewOptional(metadata).ToGoValue(), actual.ToGoValue())
+
+	// Packs minted without metadata have none
+	actual, err = packnft.GetPackMetadata(g, packId-1)
+	assert.NoError(t, err)
+	assert.Nil(t, actual.ToGoValue())
+}
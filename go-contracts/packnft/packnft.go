@@ -32,10 +32,22 @@ func GetPackStatus(
 		status = "Revealed"
 	case 2:
 		status = "Opened"
+	case 3:
+		status = "Revoked"
 	}
 	return
 }
 
+func GetPackMetadata(
+	g *gwtf.GoWithTheFlow,
+	id uint64,
+) (metadata cadence.Value, err error) {
+	script := "../cadence-scripts/packNFT/pack_metadata.cdc"
+	code := util.ParseCadenceTemplate(script)
+	metadata, err = g.ScriptFromFile(script, code).UInt64Argument(id).RunReturns()
+	return
+}
+
 func GetTotalPacks(
 	g *gwtf.GoWithTheFlow,
 ) (total uint64, err error) {
@@ -111,3 +123,37 @@ func PublicRevealPackNFT(
 	events = util.ParseTestEvents(e)
 	return
 }
+
+// LinkRevokeOperator saves a revoke operator of PackNFT for the issuer and
+// links it to 'privPath'
+func LinkRevokeOperator(
+	g *gwtf.GoWithTheFlow,
+	privPath string,
+) (err error) {
+	txScript := "../cadence-transactions/packNFT/link_revoke_operator.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	_, err = g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "storage", Identifier: privPath}).
+		Argument(cadence.Path{Domain: "private", Identifier: privPath}).
+		RunE()
+	return
+}
+
+// LinkMetadataOperator saves a metadata operator of PackNFT for the issuer
+// and links it to 'privPath'
+func LinkMetadataOperator(
+	g *gwtf.GoWithTheFlow,
+	privPath string,
+) (err error) {
+	txScript := "../cadence-transactions/packNFT/link_metadata_operator.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	_, err = g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "storage", Identifier: privPath}).
+		Argument(cadence.Path{Domain: "private", Identifier: privPath}).
+		RunE()
+	return
+}
@@ -59,6 +59,31 @@ func CreateDistribution(
 	return
 }
 
+// CreateDistributionWithOperators creates a distribution sharing the revoke
+// and metadata operators linked to the given private paths, none if empty.
+func CreateDistributionWithOperators(
+	g *gwtf.GoWithTheFlow,
+	privPath string,
+	revokeOperatorPath string,
+	metadataOperatorPath string,
+	title string,
+	metadata cadence.Value,
+) (events []*gwtf.FormatedEvent, err error) {
+	createDist := "../cadence-transactions/pds/create_distribution_with_operators.cdc"
+	createDistCode := util.ParseCadenceTemplate(createDist)
+	e, err := g.
+		TransactionFromFile(createDist, createDistCode).
+		SignProposeAndPayAs("issuer").
+		Argument(cadence.Path{Domain: "private", Identifier: privPath}).
+		Argument(optionalPrivatePath(revokeOperatorPath)).
+		Argument(optionalPrivatePath(metadataOperatorPath)).
+		StringArgument(title).
+		Argument(metadata).
+		RunE()
+	events = util.ParseTestEvents(e)
+	return
+}
+
 func GetNextDistID(
 	g *gwtf.GoWithTheFlow,
 ) (distId uint64, err error) {
@@ -110,6 +135,86 @@ func GetDistMetadata(
 	return
 }
 
+func GetSeedCommitment(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+) (commitment string, err error) {
+	script := "../cadence-scripts/pds/get_seed_commitment.cdc"
+	code := util.ParseCadenceTemplate(script)
+	r, err := g.ScriptFromFile(script, code).UInt64Argument(distId).RunReturns()
+	if err != nil {
+		return
+	}
+	if c, ok := r.ToGoValue().(string); ok {
+		commitment = c
+	}
+	return
+}
+
+func PDSCommitSeed(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+	commitment string,
+	anchorHeight uint64,
+) (events []*gwtf.FormatedEvent, err error) {
+	txScript := "../cadence-transactions/pds/commit_seed.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	e, err := g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("pds").
+		UInt64Argument(distId).
+		StringArgument(commitment).
+		UInt64Argument(anchorHeight).
+		RunE()
+	events = util.ParseTestEvents(e)
+	return
+}
+
+func PDSRevealSeed(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+	secret string,
+) (events []*gwtf.FormatedEvent, err error) {
+	txScript := "../cadence-transactions/pds/reveal_seed.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	e, err := g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("pds").
+		UInt64Argument(distId).
+		StringArgument(secret).
+		RunE()
+	events = util.ParseTestEvents(e)
+	return
+}
+
+func CanRevoke(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+) (canRevoke bool, err error) {
+	script := "../cadence-scripts/pds/can_revoke.cdc"
+	code := util.ParseCadenceTemplate(script)
+	r, err := g.ScriptFromFile(script, code).UInt64Argument(distId).RunReturns()
+	if err != nil {
+		return
+	}
+	canRevoke = r.ToGoValue().(bool)
+	return
+}
+
+func CanMintWithMetadata(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+) (canMint bool, err error) {
+	script := "../cadence-scripts/pds/can_mint_with_metadata.cdc"
+	code := util.ParseCadenceTemplate(script)
+	r, err := g.ScriptFromFile(script, code).UInt64Argument(distId).RunReturns()
+	if err != nil {
+		return
+	}
+	canMint = r.ToGoValue().(bool)
+	return
+}
+
 func PDSWithdrawNFT(
 	g *gwtf.GoWithTheFlow,
 	distId uint64,
@@ -123,6 +228,7 @@ func PDSWithdrawNFT(
 		SignProposeAndPayAs("pds").
 		UInt64Argument(distId).
 		Argument(nftIds).
+		StringArgument(util.ExampleNFTIdentifier()).
 		RunE()
 	events = util.ParseTestEvents(e)
 	return
@@ -151,6 +257,27 @@ func PDSMintPackNFT(
 	return
 }
 
+func PDSMintPackNFTWithMetadata(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+	commitHash string,
+	issuer string,
+	metadata cadence.Value,
+) (events []*gwtf.FormatedEvent, err error) {
+	txScript := "../cadence-transactions/pds/mint_packNFT_with_metadata.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	e, err := g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("pds").
+		UInt64Argument(distId).
+		Argument(cadence.NewArray([]cadence.Value{cadence.String(commitHash)})).
+		AccountArgument(issuer).
+		Argument(metadata).
+		RunE()
+	events = util.ParseTestEvents(e)
+	return
+}
+
 func PDSUpdateDistState(
 	g *gwtf.GoWithTheFlow,
 	distId uint64,
@@ -178,6 +305,23 @@ func PDSUpdateDistState(
 	return
 }
 
+func PDSRevokePackNFT(
+	g *gwtf.GoWithTheFlow,
+	distId uint64,
+	packId uint64,
+) (events []*gwtf.FormatedEvent, err error) {
+	txScript := "../cadence-transactions/pds/revoke_packNFT.cdc"
+	code := util.ParseCadenceTemplate(txScript)
+	e, err := g.
+		TransactionFromFile(txScript, code).
+		SignProposeAndPayAs("pds").
+		UInt64Argument(distId).
+		UInt64Argument(packId).
+		RunE()
+	events = util.ParseTestEvents(e)
+	return
+}
+
 func PDSRevealPackNFT(
 	g *gwtf.GoWithTheFlow,
 	distId uint64,
@@ -201,10 +345,13 @@ func PDSRevealPackNFT(
 		Argument(nftContractAddrs).
 		Argument(nftContractNames).
 		Argument(nftIds).
+		Argument(exampleNFTCollections(nftIds)).
 		StringArgument(salt).
 		AccountArgument(owner).
 		BooleanArgument(openReq).
-		Argument(cadence.Path{Domain: "private", Identifier: privPath}).
+		Argument(exampleNFTProviderPaths(privPath)).
+		Argument(cadence.NewDictionary([]cadence.KeyValuePair{})). // No fungible tokens in the packs
+		Argument(cadence.NewDictionary([]cadence.KeyValuePair{})).
 		RunE()
 	events = util.ParseTestEvents(e)
 	return
@@ -231,9 +378,39 @@ func PDSOpenPackNFT(
 		Argument(nftContractAddrs).
 		Argument(nftContractNames).
 		Argument(nftIds).
+		Argument(exampleNFTCollections(nftIds)).
 		AccountArgument(owner).
-		Argument(cadence.Path{Domain: "private", Identifier: privPath}).
+		Argument(exampleNFTProviderPaths(privPath)).
+		Argument(cadence.NewDictionary([]cadence.KeyValuePair{})). // No fungible tokens in the packs
+		Argument(cadence.NewDictionary([]cadence.KeyValuePair{})).
 		RunE()
 	events = util.ParseTestEvents(e)
 	return
 }
+
+// exampleNFTCollections returns the collection of each of 'nftIds', all ExampleNFT
+func exampleNFTCollections(nftIds cadence.Value) cadence.Value {
+	ids, _ := nftIds.(cadence.Array)
+	collections := make([]cadence.Value, len(ids.Values))
+	for i := range collections {
+		collections[i] = cadence.String(util.ExampleNFTIdentifier())
+	}
+	return cadence.NewArray(collections)
+}
+
+// exampleNFTProviderPaths returns 'privPath' as the provider path of ExampleNFT
+func exampleNFTProviderPaths(privPath string) cadence.Value {
+	return cadence.NewDictionary([]cadence.KeyValuePair{{
+		Key:   cadence.String(util.ExampleNFTIdentifier()),
+		Value: cadence.Path{Domain: "private", Identifier: privPath},
+	}})
+}
+
+// optionalPrivatePath returns 'identifier' as an optional private path, nil if
+// it is empty
+func optionalPrivatePath(identifier string) cadence.Value {
+	if identifier == "" {
+		return cadence.NewOptional(nil)
+	}
+	return cadence.NewOptional(cadence.Path{Domain: "private", Identifier: identifier})
+}
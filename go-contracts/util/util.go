@@ -25,6 +25,7 @@ var FlowJSON []string = []string{flowPath}
 
 type Addresses struct {
 	NonFungibleToken      string
+	FungibleToken         string
 	ExampleNFT            string
 	PackNFT               string
 	IPackNFT              string
@@ -33,6 +34,15 @@ type Addresses struct {
 	PackNFTAddress        string
 	CollectibleNFTName    string
 	CollectibleNFTAddress string
+	CollectibleNFTs       []CollectibleNFT
+	TokenName             string
+	TokenAddress          string
+}
+
+type CollectibleNFT struct {
+	Name       string
+	Address    string
+	Identifier string
 }
 
 type TestEvent struct {
@@ -57,6 +67,7 @@ func ParseCadenceTemplate(templatePath string) []byte {
 	// addresses = Addresses{"f8d6e0586b0a20c7", "01cf0e2f2f715450", "01cf0e2f2f715450", "f3fcd2c1a78f5eee", "f3fcd2c1a78f5eee"}
 	addresses = Addresses{
 		NonFungibleToken:      os.Getenv("NON_FUNGIBLE_TOKEN_ADDRESS"),
+		FungibleToken:         os.Getenv("FUNGIBLE_TOKEN_ADDRESS"),
 		ExampleNFT:            os.Getenv("EXAMPLE_NFT_ADDRESS"),
 		PackNFT:               os.Getenv("PACKNFT_ADDRESS"),
 		IPackNFT:              os.Getenv("PDS_ADDRESS"),
@@ -65,6 +76,9 @@ func ParseCadenceTemplate(templatePath string) []byte {
 		PackNFTAddress:        os.Getenv("PACKNFT_ADDRESS"),
 		CollectibleNFTName:    "ExampleNFT",
 		CollectibleNFTAddress: os.Getenv("EXAMPLE_NFT_ADDRESS"),
+		CollectibleNFTs: []CollectibleNFT{
+			{Name: "ExampleNFT", Address: os.Getenv("EXAMPLE_NFT_ADDRESS"), Identifier: ExampleNFTIdentifier()},
+		},
 	}
 
 	buf := &bytes.Buffer{}
@@ -76,6 +90,12 @@ func ParseCadenceTemplate(templatePath string) []byte {
 	return buf.Bytes()
 }
 
+// ExampleNFTIdentifier returns the contract identifier of ExampleNFT, which
+// keys the collections of multi-collection transactions
+func ExampleNFTIdentifier() string {
+	return fmt.Sprintf("A.%s.ExampleNFT", os.Getenv("EXAMPLE_NFT_ADDRESS"))
+}
+
 func ParseTestEvents(events []flow.Event) (formatedEvents []*gwtf.FormatedEvent) {
 	for _, e := range events {
 		formatedEvents = append(formatedEvents, gwtf.ParseEvent(e, uint64(0), time.Now(), nil))
@@ -176,6 +196,17 @@ func GetVersion(g *gwtf.GoWithTheFlow) (result string, err error) {
 	return
 }
 
+func GetCurrentBlockHeight(g *gwtf.GoWithTheFlow) (height uint64, err error) {
+	filename := "../cadence-scripts/pds/get_current_block_height.cdc"
+	script := ParseCadenceTemplate(filename)
+	r, err := g.ScriptFromFile(filename, script).RunReturns()
+	if err != nil {
+		return
+	}
+	height = r.ToGoValue().(uint64)
+	return
+}
+
 func ConvertCadenceByteArray(a cadence.Value) (b []uint8) {
 	// type assertion of interface
 	i := a.ToGoValue().([]interface{})
@@ -7,6 +7,7 @@ replace github.com/bjartek/go-with-the-flow/v2 => github.com/flow-hydraulics/go-
 require (
 	github.com/bjartek/go-with-the-flow/v2 v2.1.6
 	github.com/caarlos0/env/v6 v6.7.1
+	github.com/felixge/httpsnoop v1.0.1
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
@@ -14,11 +15,13 @@ require (
 	github.com/onflow/cadence v0.18.1-0.20210621144040-64e6b6fb2337
 	github.com/onflow/flow-go v0.18.4
 	github.com/onflow/flow-go-sdk v0.20.1-0.20210623043139-533a95abf071
+	github.com/prometheus/client_golang v1.7.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
 	github.com/trailofbits/go-mutexasserts v0.0.0-20200708152505-19999e7d3cef
-	go.uber.org/ratelimit v0.2.0
 	google.golang.org/grpc v1.38.0
+	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 	gorm.io/datatypes v1.0.2
 	gorm.io/driver/mysql v1.1.2
 	gorm.io/driver/postgres v1.1.0
@@ -46,7 +49,6 @@ require (
 	github.com/dustin/go-humanize v1.0.0 // indirect
 	github.com/enescakir/emoji v1.0.0 // indirect
 	github.com/ethereum/go-ethereum v1.9.13 // indirect
-	github.com/felixge/httpsnoop v1.0.1 // indirect
 	github.com/fxamacker/cbor/v2 v2.2.1-0.20210510192846-c3f3c69e7bc8 // indirect
 	github.com/go-sql-driver/mysql v1.6.0 // indirect
 	github.com/go-test/deep v1.0.5 // indirect
@@ -87,7 +89,6 @@ require (
 	github.com/opentracing/opentracing-go v1.2.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/prometheus/client_golang v1.7.1 // indirect
 	github.com/prometheus/client_model v0.2.0 // indirect
 	github.com/prometheus/common v0.14.0 // indirect
 	github.com/prometheus/procfs v0.1.3 // indirect
@@ -112,6 +113,4 @@ require (
 	google.golang.org/api v0.31.0 // indirect
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/genproto v0.0.0-20200831141814-d751682dd103 // indirect
-	google.golang.org/protobuf v1.26.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
 )
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 
 	"os"
+	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/app"
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/grpc"
 	"github.com/flow-hydraulics/flow-pds/service/http"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
 	"github.com/flow-hydraulics/flow-pds/service/transactions"
-	"github.com/onflow/flow-go-sdk/client"
 	log "github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
 )
 
 const version = "0.4.0"
@@ -23,26 +27,6 @@ var (
 	buildTime string // when the executable was built
 )
 
-func init() {
-	lvl, ok := os.LookupEnv("FLOW_PDS_LOG_LEVEL")
-	if !ok {
-		// LOG_LEVEL not set, default to info
-		lvl = "info"
-	}
-
-	ll, err := log.ParseLevel(lvl)
-	if err != nil {
-		ll = log.DebugLevel
-	}
-
-	log.SetFormatter(&log.TextFormatter{
-		DisableColors: true,
-		FullTimestamp: true,
-	})
-
-	log.SetLevel(ll)
-}
-
 func main() {
 	var (
 		printVersion bool
@@ -69,6 +53,14 @@ func main() {
 		panic(err)
 	}
 
+	if err := logging.Setup(cfg); err != nil {
+		panic(err)
+	}
+
+	metrics.Setup(cfg)
+
+	flow_helpers.SetupCadenceTemplates(cadenceTemplates, cfg.CadenceTemplateDir)
+
 	if err := runServer(cfg); err != nil {
 		panic(err)
 	}
@@ -84,8 +76,7 @@ func runServer(cfg *config.Config) error {
 	log.Infof("Starting server (v%s)...", version)
 
 	// Flow client
-	// TODO: WithInsecure()?
-	flowClient, err := client.New(cfg.AccessAPIHost, grpc.WithInsecure())
+	flowClient, err := flow_helpers.NewAccessAPIClient(cfg, common.RealClock{})
 	if err != nil {
 		return err
 	}
@@ -116,12 +107,49 @@ func runServer(cfg *config.Config) error {
 		return err
 	}
 
-	defer app.Close()
+	// gRPC server, optional
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != 0 {
+		// Avoid a nil *JWTVerifier in a non-nil interface
+		var tokens grpc.TokenVerifier
+		if jwt := http.NewJWTVerifier(cfg); jwt != nil {
+			tokens = jwt
+		}
+
+		grpcServer = grpc.NewServer(cfg, app, tokens)
+		if err := grpcServer.ListenAndServe(); err != nil {
+			app.Close()
+			return err
+		}
+	}
 
 	// HTTP server
 	server := http.NewServer(cfg, app)
 
 	server.ListenAndServe()
 
+	sig := http.WaitForSignal()
+
+	log.Infof("Got signal: %s. Shutting down...", sig)
+
+	// Stop accepting requests first, then let the poller finish its running
+	// step, all within the grace period
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGracePeriod)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorf("Error in server shutdown: %s", err)
+	}
+
+	if grpcServer != nil {
+		deadline, _ := ctx.Deadline()
+		grpcServer.Stop(time.Until(deadline))
+	}
+
+	app.Shutdown(ctx)
+
+	log.Info("Shutdown complete")
+
 	return nil
 }
+
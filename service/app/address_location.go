@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 )
@@ -19,3 +20,16 @@ func (al AddressLocation) String() string {
 func (al AddressLocation) ProviderPath() string {
 	return fmt.Sprintf("%s_%s_ProviderPath", al.Name, al.Address)
 }
+
+// AddressLocationFromString returns a reference to a contract from its string
+// representation, e.g. 'A.0ae53cb6e3f42a79.PackNFT'.
+func AddressLocationFromString(s string) (AddressLocation, error) {
+	split := strings.Split(s, ".")
+	if len(split) != 3 || split[0] != "A" || split[1] == "" || split[2] == "" {
+		return AddressLocation{}, fmt.Errorf("invalid contract reference '%s', expected 'A.<address>.<name>'", s)
+	}
+	return AddressLocation{
+		Name:    split[2],
+		Address: common.FlowAddressFromString(split[1]),
+	}, nil
+}
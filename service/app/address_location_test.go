@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestAddressLocationFromString(t *testing.T) {
+	ref, err := AddressLocationFromString("A.0ae53cb6e3f42a79.PackNFT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref.Name != "PackNFT" || ref.Address != common.FlowAddressFromString("0ae53cb6e3f42a79") {
+		t.Errorf("unexpected reference %v", ref)
+	}
+
+	if s := ref.String(); s != "A.0ae53cb6e3f42a79.PackNFT" {
+		t.Errorf("expected the reference to round trip, got '%s'", s)
+	}
+
+	for _, s := range []string{"", "PackNFT", "A.0ae53cb6e3f42a79", "B.0ae53cb6e3f42a79.PackNFT", "A..PackNFT", "A.0ae53cb6e3f42a79.PackNFT.1"} {
+		if _, err := AddressLocationFromString(s); err == nil {
+			t.Errorf("expected an error for '%s'", s)
+		}
+	}
+}
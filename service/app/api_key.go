@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix        = "pds_"
+	apiKeyBytes         = 32
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8 // Identifies a key in listings without revealing it
+	maxAPIKeyNameLength = 100
+)
+
+var (
+	ErrAPIKeyInvalid   = &Error{Code: ErrorCodeAPIKeyInvalid, Err: errors.New("invalid API key")}
+	ErrAPIKeyForbidden = &Error{Code: ErrorCodeAPIKeyForbidden, Err: errors.New("API key is not allowed to act for this issuer")}
+)
+
+// APIKey authenticates requests of an issuer. Only the SHA-256 hash of the
+// key is stored, the key itself is returned once when created.
+type APIKey struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer    common.FlowAddress `gorm:"column:issuer;index"`
+	Name      string             `gorm:"column:name"`
+	Prefix    string             `gorm:"column:prefix"`               // Start of the key
+	KeyHash   string             `gorm:"column:key_hash;uniqueIndex"` // Hex encoded SHA-256 of the key
+	RevokedAt *time.Time         `gorm:"column:revoked_at"`
+	// Optional, collectible contracts distributions created or updated with
+	// the key may use, any the issuer may use if empty
+	AllowedContracts ContractList `gorm:"column:allowed_contracts"`
+}
+
+type apiKeyContextKey struct{}
+
+// ContextWithAPIKey returns a copy of 'ctx' holding the API key a request was
+// authenticated with, distributions created or updated with it are checked
+// against its allowed contracts.
+func ContextWithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// apiKeyFromContext returns the API key held by 'ctx', nil if none.
+func apiKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	return nil
+}
+
+// newAPIKey returns a new random key of 'issuer' and its plaintext value.
+func newAPIKey(issuer common.FlowAddress, name string, allowed ContractList) (*APIKey, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return nil, "", fmt.Errorf("name can be at most %d characters", maxAPIKeyNameLength)
+	}
+
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(b)
+
+	return &APIKey{
+		Issuer:  issuer,
+		Name:    name,
+		Prefix:  key[:apiKeyDisplayLength],
+		KeyHash: HashAPIKey(key),
+
+		AllowedContracts: allowed,
+	}, key, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of 'key'. Keys are random
+// so a plain hash suffices, unlike for passwords.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckDistribution checks the buckets of 'dist' only use collectible
+// contracts the key is allowed to reference.
+func (k APIKey) CheckDistribution(dist *Distribution) error {
+	return checkAllowedContracts(k.AllowedContracts, dist, fmt.Sprintf("API key %s", k.Prefix))
+}
+
+// Revoke revokes the key at 'now'.
+func (k *APIKey) Revoke(now time.Time) error {
+	if k.RevokedAt != nil {
+		return fmt.Errorf("API key %s is already revoked", k.ID)
+	}
+	k.RevokedAt = &now
+	return nil
+}
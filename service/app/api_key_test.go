@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	issuer := common.FlowAddress(flow.HexToAddress("0x1"))
+
+	k, key, err := newAPIKey(issuer, "Storefront", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, apiKeyPrefix) || !strings.HasPrefix(key, k.Prefix) {
+		t.Fatalf("unexpected key %q with prefix %q", key, k.Prefix)
+	}
+
+	if k.KeyHash != HashAPIKey(key) || strings.Contains(k.KeyHash, key) {
+		t.Fatalf("unexpected key hash %q", k.KeyHash)
+	}
+
+	if _, other, _ := newAPIKey(issuer, "Storefront", nil); other == key {
+		t.Fatal("expected keys to differ")
+	}
+
+	if _, _, err := newAPIKey(issuer, "", nil); err == nil {
+		t.Fatal("expected an error without a name")
+	}
+
+	if _, _, err := newAPIKey(issuer, strings.Repeat("a", maxAPIKeyNameLength+1), nil); err == nil {
+		t.Fatal("expected an error with a too long name")
+	}
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	k := APIKey{}
+	now := time.Now()
+
+	if err := k.Revoke(now); err != nil {
+		t.Fatal(err)
+	}
+
+	if k.RevokedAt == nil || !k.RevokedAt.Equal(now) {
+		t.Fatalf("unexpected revocation time %v", k.RevokedAt)
+	}
+
+	if err := k.Revoke(now); err == nil {
+		t.Fatal("expected an error revoking twice")
+	}
+}
+
+func TestAPIKeyCheckDistribution(t *testing.T) {
+	allowed := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+	other := AddressLocation{Name: "OtherNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+
+	dist := &Distribution{PackTemplate: PackTemplate{Buckets: []Bucket{{CollectibleReference: allowed}, {CollectibleReference: other}}}}
+
+	if err := (APIKey{}).CheckDistribution(dist); err != nil {
+		t.Errorf("expected any contract to be allowed without a list, got %s", err)
+	}
+
+	k := &APIKey{Prefix: "pds_01234567", AllowedContracts: ContractList{allowed}}
+	if err := k.CheckDistribution(dist); ErrorCode(err) != ErrorCodeDistributionInvalidBucket {
+		t.Errorf("expected a '%s' error for a contract which is not allowed, got %v", ErrorCodeDistributionInvalidBucket, err)
+	}
+
+	if got := apiKeyFromContext(ContextWithAPIKey(context.Background(), k)); got != k {
+		t.Errorf("expected the key of the context, got %v", got)
+	}
+	if got := apiKeyFromContext(context.Background()); got != nil {
+		t.Errorf("expected no key, got %v", got)
+	}
+}
@@ -2,43 +2,125 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
 	"github.com/google/uuid"
-	"github.com/onflow/flow-go-sdk/client"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // App handles all the application logic and interfaces directly with the database
 type App struct {
-	cfg        *config.Config
-	db         *gorm.DB
-	flowClient *client.Client
-	service    *ContractService
-	quit       chan bool // Chan type does not matter as we only use this to 'close'
+	cfg            *config.Config
+	db             *gorm.DB
+	flowClient     flow_helpers.FlowClient
+	service        *ContractService
+	clock          common.Clock
+	contracts      collectibleContracts
+	webhooks       *http.Client // Used to send gift intent webhooks
+	issuerWebhooks *http.Client // Used to send issuer webhooks, see newIssuerWebhookClient
+	notifiers      []KeyRotationNotifier
+	stats          *publicStatsCache
+	nonces         *nonceCache        // Nonces of signed issuer requests
+	quit           chan bool          // Chan type does not matter as we only use this to 'close'
+	done           chan struct{}      // Closed once the poller has returned
+	cancel         context.CancelFunc // Cancels the work of the poller
 }
 
-func New(cfg *config.Config, db *gorm.DB, flowClient *client.Client, poll bool) (*App, error) {
-	service, err := NewContractService(cfg, flowClient)
+func New(cfg *config.Config, db *gorm.DB, flowClient flow_helpers.FlowClient, poll bool) (*App, error) {
+	return NewWithClock(cfg, db, flowClient, common.RealClock{}, poll)
+}
+
+// NewWithClock is like New but allows injecting the Clock used by the poller
+// and timeouts, e.g. a common.VirtualClock in tests.
+func NewWithClock(cfg *config.Config, db *gorm.DB, flowClient flow_helpers.FlowClient, clock common.Clock, poll bool) (*App, error) {
+	service, err := NewContractService(cfg, flowClient, clock)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts, err := newCollectibleContracts(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	webhooks := &http.Client{Timeout: cfg.GiftWebhookTimeout}
+	issuerWebhooks := newIssuerWebhookClient(cfg.GiftWebhookTimeout, cfg.IssuerWebhookAllowPrivate)
+
+	notifiers := []KeyRotationNotifier{logKeyRotationNotifier{}}
+	if cfg.KeyRotationWebhookURL != "" {
+		notifiers = append(notifiers, WebhookKeyRotationNotifier{webhooks, cfg.KeyRotationWebhookURL})
+	}
+
+	if err := checkKeyRotations(db, service.keys); err != nil {
+		return nil, err
+	}
+
+	if err := checkJobVersions(db); err != nil {
+		return nil, err
+	}
+
 	quit := make(chan bool)
-	app := &App{cfg, db, flowClient, service, quit}
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	app := &App{cfg, db, flowClient, service, clock, contracts, webhooks, issuerWebhooks, notifiers, &publicStatsCache{}, newNonceCache(), quit, done, cancel}
+
+	if cfg.DryRun {
+		log.Warn("Dry-run mode, transactions are built and logged but never sent")
+	}
 
 	if poll {
-		go poller(app)
+		go poller(ctx, app)
+	} else {
+		close(done)
 	}
 
 	return app, nil
 }
 
-// Closes allows the poller to close controllably
+// Closes allows the poller to close controllably, see Shutdown. Waits for
+// at most the shutdown grace period.
 func (app *App) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), app.cfg.ShutdownGracePeriod)
+	defer cancel()
+	app.Shutdown(ctx)
+}
+
+// Shutdown stops the poller from starting new work and waits for its running
+// step to finish. Once 'ctx' is done the running step is cancelled instead,
+// rolling back its database transaction so it is redone after a restart.
+// Sent transactions are resumed on start, see resumeSentTransactions.
+func (app *App) Shutdown(ctx context.Context) {
 	close(app.quit)
+
+	if !drain(ctx, app.done, app.cancel) {
+		log.Warn("Shutdown grace period passed, cancelled running poller step")
+	}
+	app.cancel()
+
+	if err := app.service.Close(); err != nil {
+		log.WithFields(log.Fields{"error": err}).Warn("Error while closing contract service")
+	}
+}
+
+// draining tells if the app is shutting down
+func (app *App) draining() bool {
+	select {
+	case <-app.quit:
+		return true
+	default:
+		return false
+	}
 }
 
 // SetDistCap calls ContractService.SetDistCap which sends a transaction
@@ -49,9 +131,185 @@ func (app *App) SetDistCap(ctx context.Context, issuer common.FlowAddress) error
 
 // CreateDistribution validates a distribution, resolves it and stores it in database
 func (app *App) CreateDistribution(ctx context.Context, distribution *Distribution) error {
+	return app.createDistribution(ctx, distribution, nil)
+}
+
+// CreateDistributionIdempotent is CreateDistribution for a request with
+// idempotency key 'key' and body 'body'. If the key was used before for the
+// same body, returns the distribution created then and true instead of
+// creating another.
+func (app *App) CreateDistributionIdempotent(ctx context.Context, distribution *Distribution, key string, body []byte) (*Distribution, bool, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, false, err
+	}
+
+	requestHash := hashIdempotentRequest(body)
+
+	existing, err := idempotentDistribution(app.db, distribution.Issuer, key, requestHash)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, true, nil
+	}
+
+	err = app.createDistribution(ctx, distribution, func(tx *gorm.DB) error {
+		return InsertIdempotencyKey(tx, &IdempotencyKey{
+			Issuer:         distribution.Issuer,
+			Key:            key,
+			RequestHash:    requestHash,
+			DistributionID: distribution.ID,
+		})
+	})
+	if err != nil {
+		// A concurrent request with the same key may have stored it first
+		if existing, lookupErr := idempotentDistribution(app.db, distribution.Issuer, key, requestHash); lookupErr != nil || existing != nil {
+			return existing, existing != nil, lookupErr
+		}
+		return nil, false, err
+	}
+
+	return distribution, false, nil
+}
+
+// CreateDistributions validates and resolves all of 'distributions' before
+// storing them in one transaction, so either all or none of them are created.
+func (app *App) CreateDistributions(ctx context.Context, distributions []Distribution) error {
+	if len(distributions) == 0 {
+		return newError(ErrorCodeDistributionInvalid, "no distributions given")
+	}
+
+	if max := app.cfg.MaxBulkDistributions; max > 0 && len(distributions) > max {
+		return newError(ErrorCodeDistributionInvalid, "too many distributions, at most %d can be created at once", max)
+	}
+
+	// Distributions are told apart by issuer and Flow ID
+	seen := make(map[string]int, len(distributions))
+	for i, d := range distributions {
+		key := fmt.Sprintf("%s/%s", d.Issuer, d.FlowID)
+		if j, ok := seen[key]; ok {
+			return newError(ErrorCodeDistributionInvalid, "distributions %d and %d have the same issuer and distFlowID", j, i)
+		}
+		seen[key] = i
+	}
+
+	for i := range distributions {
+		if err := app.prepareDistribution(ctx, &distributions[i]); err != nil {
+			return fmt.Errorf("error in distribution %d: %w", i, err)
+		}
+	}
+
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		for i := range distributions {
+			if err := app.insertDistribution(tx, &distributions[i]); err != nil {
+				return fmt.Errorf("error in distribution %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+// createDistribution validates, resolves and stores 'distribution', calling
+// 'insert' (optional) in the same transaction.
+func (app *App) createDistribution(ctx context.Context, distribution *Distribution, insert func(tx *gorm.DB) error) error {
+	if err := app.prepareDistribution(ctx, distribution); err != nil {
+		return err
+	}
+
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		if err := app.insertDistribution(tx, distribution); err != nil {
+			return err
+		}
+
+		if insert != nil {
+			return insert(tx)
+		}
+
+		return nil
+	})
+}
+
+// prepareDistribution applies the policies of the collection of
+// 'distribution' (if any), then validates and resolves it.
+func (app *App) prepareDistribution(ctx context.Context, distribution *Distribution) error {
+	distribution.RequestID = logging.RequestID(ctx)
+
+	// Fill in what the distribution leaves out from its template (if any),
+	// which can also give its collection
+	if distribution.TemplateID != nil {
+		template, err := GetDistributionTemplate(app.db, *distribution.TemplateID)
+		if err != nil {
+			return err
+		}
+		if err := template.Apply(distribution); err != nil {
+			return err
+		}
+	}
+
+	// Fill in the policies of the collection (if any) the distribution leaves out
+	if distribution.CollectionID != nil {
+		collection, err := GetCollection(app.db, *distribution.CollectionID)
+		if err != nil {
+			return err
+		}
+		if err := collection.Apply(distribution); err != nil {
+			return err
+		}
+	}
+
 	// Check that distribution issuer address does not equal to AdminAddress
 	if distribution.Issuer == common.FlowAddressFromString(app.cfg.AdminAddress) {
-		return fmt.Errorf("issuer account should not be the same as PDS admin account")
+		return newError(ErrorCodeDistributionInvalid, "issuer account should not be the same as PDS admin account")
+	}
+
+	// Check that the Access API host override (if any) is allowed
+	if !app.accessAPIHostAllowed(distribution.AccessAPIHost) {
+		return newError(ErrorCodeDistributionInvalid, "access API host '%s' is not allowed", distribution.AccessAPIHost)
+	}
+
+	// Check that the settlement start time (if any) of a new distribution has
+	// not already passed, see UpdateDistribution for updated ones
+	if t := distribution.SettlementStartAt; t != nil && distribution.CreatedAt.IsZero() && !t.After(app.clock.Now()) {
+		return newError(ErrorCodeDistributionInvalid, "settlementStartAt must be in the future, got %s", t.UTC().Format(time.RFC3339))
+	}
+
+	// Check that the reveal time lock (if any) has not already passed
+	if t := distribution.PackTemplate.RevealNotBefore; t != nil && !t.After(app.clock.Now()) {
+		return newError(ErrorCodeDistributionInvalid, "revealNotBefore must be in the future, got %s", t.UTC().Format(time.RFC3339))
+	}
+
+	// Fungible tokens in packs need the FungibleToken contract address
+	if distribution.PackTemplate.FungibleToken != (FungibleTokenAmount{}) && app.cfg.FungibleTokenAddress == "" {
+		return newError(ErrorCodeDistributionInvalid, "packs can not hold fungible tokens, FUNGIBLE_TOKEN_ADDRESS is not configured")
+	}
+
+	// Check that the collectible contracts are configured for this network (if
+	// any are) and fill in their addresses
+	for i, bucket := range distribution.PackTemplate.Buckets {
+		ref, err := app.contracts.Resolve(bucket.CollectibleReference)
+		if err != nil {
+			return withCode(ErrorCodeDistributionInvalidBucket, fmt.Errorf("error in bucket %d: %w", i, err))
+		}
+		distribution.PackTemplate.Buckets[i].CollectibleReference = ref
+	}
+
+	// Check that the issuer is registered (if required) and allowed to
+	// distribute the collectibles
+	issuer, err := app.registeredIssuer(app.db, distribution.Issuer)
+	if err != nil {
+		return err
+	}
+	if issuer != nil {
+		if err := issuer.CheckDistribution(distribution); err != nil {
+			return err
+		}
+	}
+
+	// Check the API key of the request (if any) may reference them too
+	if key := apiKeyFromContext(ctx); key != nil {
+		if err := key.CheckDistribution(distribution); err != nil {
+			return err
+		}
 	}
 
 	// Resolve will also validate the distribution
@@ -59,61 +317,1480 @@ func (app *App) CreateDistribution(ctx context.Context, distribution *Distributi
 		return err
 	}
 
-	if err := InsertDistribution(app.db, distribution, app.cfg.BatchInsertSize); err != nil {
+	for i := range distribution.Packs {
+		if err := app.service.salts.EncryptPack(&distribution.Packs[i]); err != nil {
+			return err
+		}
+	}
+
+	// Packs are minted with the metadata (if any) if the PackNFT contract
+	// supports it
+	distribution.Metadata.OnChain = app.cfg.PackMetadataOnChain && !distribution.Metadata.IsEmpty()
+
+	// Distributions with a settlement start time wait for it before being set up
+	return distribution.Schedule(app.clock.Now())
+}
+
+// insertDistribution stores a resolved 'distribution' and queues the webhooks
+// of its issuer.
+func (app *App) insertDistribution(tx *gorm.DB, distribution *Distribution) error {
+	if err := InsertDistribution(tx, distribution, app.cfg.BatchInsertSize); err != nil {
 		return err
 	}
 
-	return nil
+	return queueDistributionWebhooks(tx, distribution, app.clock.Now())
 }
 
-// ListDistributions lists all distributions in the database. Uses 'limit' and 'offset' to
-// limit the fetched slice size.
-func (app *App) ListDistributions(ctx context.Context, limit, offset int) ([]Distribution, error) {
+// ListDistributions lists the distributions matching 'filter' and returns the
+// total number of them. Uses 'limit' and 'offset' to limit the fetched slice size.
+func (app *App) ListDistributions(ctx context.Context, filter DistributionFilter, limit, offset int) ([]Distribution, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+
 	opt := ParseListOptions(limit, offset)
 
-	return ListDistributions(app.db, opt)
+	list, err := ListDistributions(app.db, filter, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := CountDistributions(app.db, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
 }
 
-// GetDistribution returns a distribution from database based on its offchain ID (uuid).
-func (app *App) GetDistribution(ctx context.Context, id uuid.UUID) (*Distribution, error) {
-	distribution, err := GetDistributionBig(app.db, id)
+// ListDistributionsPage lists a page of at most 'limit' distributions
+// matching 'filter' after 'cursor' (from the start if empty). Returns the
+// cursor of the next page, empty after the last page.
+func (app *App) ListDistributionsPage(ctx context.Context, filter DistributionFilter, cursor string, limit int) ([]Distribution, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var after *DistributionCursor
+	if cursor != "" {
+		c, err := ParseDistributionCursor(cursor, filter)
+		if err != nil {
+			return nil, "", err
+		}
+		after = c
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	// One more to know if there is a next page
+	list, err := ListDistributionsAfter(app.db, filter, after, limit+1)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return distribution, nil
+	if len(list) <= limit {
+		return list, "", nil
+	}
+
+	list = list[:limit]
+	return list, newDistributionCursor(filter, &list[limit-1]).String(), nil
 }
 
-func (app *App) GetDistributionState(ctx context.Context, id uuid.UUID) (common.DistributionState, error) {
-	distribution, err := GetDistributionSmall(app.db, id)
+// ListDistributionPacks lists the packs of a distribution in any of 'states'
+// (all if empty) and returns the total number of them. Only the public fields
+// of the packs are read.
+func (app *App) ListDistributionPacks(ctx context.Context, distributionID uuid.UUID, states []common.PackState, limit, offset int) ([]Pack, int64, error) {
+	distribution, err := GetDistributionSmall(app.db, distributionID)
 	if err != nil {
-		return "", err
+		return nil, 0, err
 	}
 
-	return distribution.State, nil
+	opt := ParseListOptions(limit, offset)
+
+	list, err := ListDistributionPacks(packsOf(app.db, distribution), distributionID, states, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := CountDistributionPacksInStates(packsOf(app.db, distribution), distributionID, states)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
 }
 
-// AbortDistribution aborts a distribution.
-func (app *App) AbortDistribution(ctx context.Context, id uuid.UUID) error {
-	return app.db.Transaction(func(tx *gorm.DB) error {
-		distribution, err := GetDistributionSmall(tx, id)
+// PauseDistribution pauses the processing of a distribution which is not yet
+// complete. Settle and mint transactions not yet sent are held and no new
+// batches are queued, sent ones finish and their events are still handled.
+// A paused distribution does not start settling or minting.
+func (app *App) PauseDistribution(ctx context.Context, id uuid.UUID) (*Distribution, error) {
+	var res *Distribution
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
 		if err != nil {
 			return err
 		}
 
-		if err := app.service.Abort(ctx, tx, distribution); err != nil {
+		if err := distribution.SetPaused(app.clock.Now()); err != nil {
+			return err
+		}
+
+		if err := UpdateDistribution(tx, distribution); err != nil {
+			return err
+		}
+
+		held, err := transactions.Hold(tx, id, pausableTransactions)
+		if err != nil {
 			return err
 		}
 
+		log.WithFields(log.Fields{
+			"distID":           id,
+			"heldTransactions": held,
+			"requestID":        requestID(ctx, distribution),
+		}).Info("Distribution paused")
+
+		res = distribution
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
-// GetPack returns a pack from database based on its offchain ID (uuid).
-func (app *App) GetPack(ctx context.Context, id uuid.UUID) (*Pack, error) {
-	pack, err := GetPack(app.db, id)
+// ResumeDistribution resumes the processing of a paused distribution,
+// releasing its held transactions.
+func (app *App) ResumeDistribution(ctx context.Context, id uuid.UUID) (*Distribution, error) {
+	var res *Distribution
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		if err := distribution.SetResumed(); err != nil {
+			return err
+		}
+
+		if err := UpdateDistribution(tx, distribution); err != nil {
+			return err
+		}
+
+		released, err := transactions.Release(tx, id)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"distID":               id,
+			"releasedTransactions": released,
+			"requestID":            requestID(ctx, distribution),
+		}).Info("Distribution resumed")
+
+		res = distribution
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return pack, nil
+
+	return res, nil
+}
+
+// ArchiveDistribution archives a complete or closed distribution whose packs
+// do not change anymore, moving its packs to a separate table. Archived
+// distributions are left out of listings unless asked for.
+func (app *App) ArchiveDistribution(ctx context.Context, id uuid.UUID) (*Distribution, error) {
+	var res *Distribution
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		if err := distribution.SetArchived(app.clock.Now()); err != nil {
+			return err
+		}
+
+		pending, err := CountDistributionPacksNotInStates(tx, id, archivablePackStates(distribution))
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return newError(ErrorCodePackState, "%d packs of the distribution can still be revealed or opened", pending)
+		}
+
+		if err := ArchiveDistributionPacks(tx, id, app.cfg.BatchProcessSize); err != nil {
+			return err
+		}
+
+		if err := UpdateDistribution(tx, distribution); err != nil {
+			return err
+		}
+
+		res = distribution
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListOwnerPacks lists the packs of any distribution believed to be owned by
+// 'owner', as tracked from the events of the circulating pack contracts, in
+// any of 'states' (all if empty) and returns the total number of them. Only the
+// public fields of the packs are read.
+func (app *App) ListOwnerPacks(ctx context.Context, owner common.FlowAddress, states []common.PackState, limit, offset int) ([]Pack, int64, error) {
+	if owner == common.FlowAddress(flow.EmptyAddress) {
+		return nil, 0, fmt.Errorf("owner address required")
+	}
+
+	opt := ParseListOptions(limit, offset)
+
+	list, err := ListOwnerPacks(app.db, owner, states, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := CountOwnerPacks(app.db, owner, states)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+// CreateCollection creates a collection to group distributions of an issuer.
+func (app *App) CreateCollection(ctx context.Context, collection *Collection) error {
+	if err := collection.Validate(); err != nil {
+		return err
+	}
+
+	if !app.accessAPIHostAllowed(collection.AccessAPIHost) {
+		return fmt.Errorf("access API host '%s' is not allowed", collection.AccessAPIHost)
+	}
+
+	if collection.CollectibleReference.Name != "" {
+		ref, err := app.contracts.Resolve(collection.CollectibleReference)
+		if err != nil {
+			return err
+		}
+		collection.CollectibleReference = ref
+	}
+
+	return InsertCollection(app.db, collection)
+}
+
+// ListCollections lists collections, of an issuer if 'issuer' is not empty.
+func (app *App) ListCollections(ctx context.Context, issuer common.FlowAddress, limit, offset int) ([]Collection, error) {
+	opt := ParseListOptions(limit, offset)
+
+	return ListCollections(app.db, issuer, opt)
+}
+
+// GetCollection returns a collection and the roll-up stats of its distributions.
+func (app *App) GetCollection(ctx context.Context, id uuid.UUID) (*Collection, CollectionStats, error) {
+	collection, err := GetCollection(app.db, id)
+	if err != nil {
+		return nil, CollectionStats{}, err
+	}
+
+	distributions, err := CountCollectionDistributionsByState(app.db, id)
+	if err != nil {
+		return nil, CollectionStats{}, err
+	}
+
+	packs, err := CountCollectionPacksByState(app.db, id)
+	if err != nil {
+		return nil, CollectionStats{}, err
+	}
+
+	return collection, newCollectionStats(distributions, packs), nil
+}
+
+// GetCollectionIssuer returns the issuer of a collection.
+func (app *App) GetCollectionIssuer(ctx context.Context, id uuid.UUID) (common.FlowAddress, error) {
+	collection, err := GetCollection(app.db, id)
+	if err != nil {
+		return common.FlowAddress{}, err
+	}
+
+	return collection.Issuer, nil
+}
+
+// ListCollectionDistributions lists the distributions of a collection.
+func (app *App) ListCollectionDistributions(ctx context.Context, id uuid.UUID, limit, offset int) ([]Distribution, error) {
+	if _, err := GetCollection(app.db, id); err != nil {
+		return nil, err
+	}
+
+	opt := ParseListOptions(limit, offset)
+
+	return ListCollectionDistributions(app.db, id, opt)
+}
+
+// CreateDistributionTemplate creates a reusable drop format of an issuer.
+func (app *App) CreateDistributionTemplate(ctx context.Context, template *DistributionTemplate) error {
+	if err := app.prepareDistributionTemplate(template); err != nil {
+		return err
+	}
+
+	return InsertDistributionTemplate(app.db, template)
+}
+
+// ListDistributionTemplates lists templates, of an issuer if 'issuer' is not
+// empty.
+func (app *App) ListDistributionTemplates(ctx context.Context, issuer common.FlowAddress, limit, offset int) ([]DistributionTemplate, error) {
+	opt := ParseListOptions(limit, offset)
+
+	return ListDistributionTemplates(app.db, issuer, opt)
+}
+
+// GetDistributionTemplate returns a template.
+func (app *App) GetDistributionTemplate(ctx context.Context, id uuid.UUID) (*DistributionTemplate, error) {
+	return GetDistributionTemplate(app.db, id)
+}
+
+// UpdateDistributionTemplate replaces the template 'id' with 'update', its
+// issuer can not change. Distributions already created from it are not
+// changed.
+func (app *App) UpdateDistributionTemplate(ctx context.Context, id uuid.UUID, update *DistributionTemplate) error {
+	existing, err := GetDistributionTemplate(app.db, id)
+	if err != nil {
+		return err
+	}
+
+	if flow.Address(update.Issuer) == flow.EmptyAddress {
+		update.Issuer = existing.Issuer
+	}
+
+	if update.Issuer != existing.Issuer {
+		return fmt.Errorf("the issuer of a template can not be changed")
+	}
+
+	if err := app.prepareDistributionTemplate(update); err != nil {
+		return err
+	}
+
+	update.Model = existing.Model
+	update.ID = existing.ID
+
+	return UpdateDistributionTemplate(app.db, update)
+}
+
+// DeleteDistributionTemplate deletes a template, distributions already
+// created from it are kept.
+func (app *App) DeleteDistributionTemplate(ctx context.Context, id uuid.UUID) error {
+	if _, err := GetDistributionTemplate(app.db, id); err != nil {
+		return err
+	}
+
+	return DeleteDistributionTemplate(app.db, id)
+}
+
+// prepareDistributionTemplate validates 'template' and resolves the
+// collectible contract references of its buckets.
+func (app *App) prepareDistributionTemplate(template *DistributionTemplate) error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+
+	if !app.accessAPIHostAllowed(template.AccessAPIHost) {
+		return fmt.Errorf("access API host '%s' is not allowed", template.AccessAPIHost)
+	}
+
+	for i := range template.Buckets {
+		if template.Buckets[i].CollectibleReference.Name == "" {
+			continue
+		}
+		ref, err := app.contracts.Resolve(template.Buckets[i].CollectibleReference)
+		if err != nil {
+			return err
+		}
+		template.Buckets[i].CollectibleReference = ref
+	}
+
+	if template.CollectionID != nil {
+		collection, err := GetCollection(app.db, *template.CollectionID)
+		if err != nil {
+			return err
+		}
+		if collection.Issuer != template.Issuer {
+			return fmt.Errorf("collection %s is not of issuer %s", collection.ID, template.Issuer)
+		}
+	}
+
+	return nil
+}
+
+// GetDistribution returns a distribution from database based on its offchain ID (uuid).
+func (app *App) GetDistribution(ctx context.Context, id uuid.UUID) (*Distribution, error) {
+	distribution, err := GetDistributionBig(app.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return distribution, nil
+}
+
+// GetDistributionWithBuckets returns a distribution with its buckets but
+// without its packs.
+func (app *App) GetDistributionWithBuckets(ctx context.Context, id uuid.UUID) (*Distribution, error) {
+	return GetDistributionWithBuckets(app.db, id)
+}
+
+// GetDistributionsWithBuckets returns the distributions with 'ids' including
+// their buckets, those not found are left out.
+func (app *App) GetDistributionsWithBuckets(ctx context.Context, ids []uuid.UUID) ([]Distribution, error) {
+	return ListDistributionsWithBuckets(app.db, ids)
+}
+
+func (app *App) GetDistributionState(ctx context.Context, id uuid.UUID) (common.DistributionState, error) {
+	distribution, err := GetDistributionSmall(app.db, id)
+	if err != nil {
+		return "", err
+	}
+
+	return distribution.State, nil
+}
+
+// GetDistributionIssuer returns the issuer of a distribution.
+func (app *App) GetDistributionIssuer(ctx context.Context, id uuid.UUID) (common.FlowAddress, error) {
+	distribution, err := GetDistributionSmall(app.db, id)
+	if err != nil {
+		return common.FlowAddress{}, err
+	}
+
+	return distribution.Issuer, nil
+}
+
+// AbortDistribution aborts a distribution, returning the escrowed
+// collectibles of its cancelled packs to the issuer if 'returnEscrow' is set.
+func (app *App) AbortDistribution(ctx context.Context, id uuid.UUID, returnEscrow bool) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionSmall(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := app.service.Abort(ctx, tx, distribution, returnEscrow); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// UpdateDistribution applies 'update' to a distribution which has not started
+// settling and resolves it again, with new packs. Aborted distributions are
+// reinitialized, so they are started again.
+func (app *App) UpdateDistribution(ctx context.Context, id uuid.UUID, update DistributionUpdate) (*Distribution, error) {
+	var res *Distribution
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the distribution so it is not set up while updating
+		distribution, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		if err := validateUpdate(distribution.State); err != nil {
+			return err
+		}
+
+		if t := update.SettlementStartAt; t != nil && !t.After(app.clock.Now()) {
+			return newError(ErrorCodeDistributionInvalid, "settlementStartAt must be in the future, got %s", t.UTC().Format(time.RFC3339))
+		}
+
+		reinitialize := distribution.State == common.DistributionStateInvalid
+		if reinitialize {
+			if err := app.service.Reinitialize(ctx, tx, distribution); err != nil {
+				return err
+			}
+		}
+
+		// A draft is created once resolved
+		draft := distribution.State == common.DistributionStateDraft
+
+		update.Apply(distribution)
+
+		if err := app.prepareDistribution(ctx, distribution); err != nil {
+			return err
+		}
+
+		if err := ReplaceDistributionTemplate(tx, distribution, app.cfg.BatchInsertSize); err != nil {
+			return err
+		}
+
+		if reinitialize || draft {
+			if err := queueDistributionWebhooks(tx, distribution, app.clock.Now()); err != nil {
+				return err
+			}
+		}
+
+		res = distribution
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// RetryDistribution re-kicks the settlement or minting of a stuck
+// distribution.
+func (app *App) RetryDistribution(ctx context.Context, id uuid.UUID) (*DistributionRetry, error) {
+	var res *DistributionRetry
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionSmall(tx, id)
+		if err != nil {
+			return err
+		}
+
+		res, err = app.service.Retry(ctx, tx, distribution)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetPack returns a pack from database based on its offchain ID (uuid).
+func (app *App) GetPack(ctx context.Context, id uuid.UUID) (*Pack, error) {
+	pack, err := withArchivedPacks(app.db, func(db *gorm.DB) (*Pack, error) {
+		return GetPack(db, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetPackByCommitmentHash returns a pack from database based on its onchain
+// commitment hash.
+func (app *App) GetPackByCommitmentHash(ctx context.Context, commitmentHash common.BinaryValue) (*Pack, error) {
+	pack, err := withArchivedPacks(app.db, func(db *gorm.DB) (*Pack, error) {
+		return GetPackByCommitmentHash(db, commitmentHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetPackByFlowID returns a pack from database based on its PackNFT ID.
+// PackNFT IDs are unique per contract only, without 'packReference' the ID
+// has to match a single pack.
+func (app *App) GetPackByFlowID(ctx context.Context, id common.FlowID, packReference *AddressLocation) (*Pack, error) {
+	if packReference != nil {
+		return withArchivedPacks(app.db, func(db *gorm.DB) (*Pack, error) {
+			return GetPackByContractAndFlowID(db, *packReference, id)
+		})
+	}
+
+	list, err := GetPacksByFlowID(app.db, id, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) < 2 {
+		archived, err := GetPacksByFlowID(app.db.Table(archivedPacksTable), id, 2-len(list))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, archived...)
+	}
+
+	switch len(list) {
+	case 0:
+		return nil, gorm.ErrRecordNotFound
+	case 1:
+		return &list[0], nil
+	}
+	return nil, fmt.Errorf("packs of more than one contract have FlowID %s, a packReference is required", id)
+}
+
+// GetPackReveal returns what has been disclosed of the contents of 'pack'.
+func (app *App) GetPackReveal(ctx context.Context, pack *Pack) (*PackReveal, error) {
+	distribution, err := GetDistributionWithBuckets(app.db, pack.DistributionID)
+	if err != nil {
+		return nil, err
+	}
+	return packReveal(distribution, pack, app.clock.Now()), nil
+}
+
+// GetPackReveals is like GetPackReveal for each of 'packs' (by pack ID),
+// loading their contents (left out of lists of packs) and distributions in
+// one query each. Packs not found are left out.
+func (app *App) GetPackReveals(ctx context.Context, packs []*Pack) (map[uuid.UUID]*PackReveal, error) {
+	ids := make([]uuid.UUID, len(packs))
+	for i, p := range packs {
+		ids[i] = p.ID
+	}
+
+	full, err := ListPacks(app.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(full) < len(ids) {
+		found := make(map[uuid.UUID]bool, len(full))
+		for _, p := range full {
+			found[p.ID] = true
+		}
+		missing := []uuid.UUID{}
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		archived, err := ListPacks(app.db.Table(archivedPacksTable), missing)
+		if err != nil {
+			return nil, err
+		}
+		full = append(full, archived...)
+	}
+
+	distIDs := []uuid.UUID{}
+	seen := make(map[uuid.UUID]bool)
+	for _, p := range full {
+		if !seen[p.DistributionID] {
+			seen[p.DistributionID] = true
+			distIDs = append(distIDs, p.DistributionID)
+		}
+	}
+
+	distributions, err := ListDistributionsWithBuckets(app.db, distIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*Distribution, len(distributions))
+	for i := range distributions {
+		byID[distributions[i].ID] = &distributions[i]
+	}
+
+	now := app.clock.Now()
+	res := make(map[uuid.UUID]*PackReveal, len(full))
+	for i := range full {
+		if dist, ok := byID[full[i].DistributionID]; ok {
+			res[full[i].ID] = packReveal(dist, &full[i], now)
+		}
+	}
+
+	return res, nil
+}
+
+// StartOwnershipVerification creates a job verifying the onchain ownership of
+// all minted packs in a distribution against the owners in database.
+// The job is processed asynchronously by the poller.
+func (app *App) StartOwnershipVerification(ctx context.Context, distributionID uuid.UUID) (*OwnershipVerification, error) {
+	verification := &OwnershipVerification{
+		DistributionID: distributionID,
+		State:          common.OwnershipVerificationStateInit,
+	}
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionSmall(tx, distributionID)
+		if err != nil {
+			return err
+		}
+
+		if distribution.State != common.DistributionStateComplete {
+			return newError(ErrorCodeDistributionState, "distribution has to be in '%s' state, got '%s'", common.DistributionStateComplete, distribution.State)
+		}
+
+		return InsertOwnershipVerification(tx, verification)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// GetOwnershipVerification returns an ownership verification of a distribution
+// including the discrepancies found so far.
+func (app *App) GetOwnershipVerification(ctx context.Context, distributionID, id uuid.UUID) (*OwnershipVerification, error) {
+	verification, err := GetOwnershipVerification(app.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if verification.DistributionID != distributionID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return verification, nil
+}
+
+// GetCollectibleOwnershipCheck returns the report of the collectible
+// ownership check run when setting up a distribution.
+func (app *App) GetCollectibleOwnershipCheck(ctx context.Context, distributionID uuid.UUID) (*CollectibleOwnershipCheck, error) {
+	check, err := GetDistributionCollectibleOwnershipCheck(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// GetCompletionReport returns the report stored when a distribution was closed.
+func (app *App) GetCompletionReport(ctx context.Context, distributionID uuid.UUID) (*CompletionReport, error) {
+	report, err := GetDistributionCompletionReport(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ExportDistribution returns the manifest of the packs of a complete or
+// closed distribution in 'format' (json, the default, or csv), including
+// their salts if 'includeSalt' is set.
+func (app *App) ExportDistribution(ctx context.Context, distributionID uuid.UUID, format string, includeSalt bool) (*DistributionExport, error) {
+	if format == "" {
+		format = ExportFormatJSON
+	}
+
+	distribution, err := GetDistributionSmall(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateExport(distribution.State, format); err != nil {
+		return nil, err
+	}
+
+	return &DistributionExport{
+		DistributionID: distributionID,
+		Format:         format,
+		IncludeSalt:    includeSalt,
+		salts:          app.service.salts,
+		db:             packsOf(app.db, distribution),
+		batchSize:      app.cfg.BatchProcessSize,
+	}, nil
+}
+
+// GetDistributionProgress returns how far the settlement and minting of a
+// distribution have come.
+func (app *App) GetDistributionProgress(ctx context.Context, distributionID uuid.UUID) (*DistributionProgress, error) {
+	distribution, err := GetDistributionSmall(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement, err := GetDistributionSettlement(app.db, distributionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settlement, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	packs, err := CountDistributionPacksByState(packsOf(app.db, distribution), distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := newDistributionProgress(distribution.State, settlement, packs)
+	return &progress, nil
+}
+
+// CreateGiftIntents registers intended recipients for minted packs of a
+// distribution. A pack can have only one pending gift intent at a time.
+// Intents whose recipient already owns the pack are marked transferred.
+func (app *App) CreateGiftIntents(ctx context.Context, distributionID uuid.UUID, intents []GiftIntent) error {
+	if len(intents) == 0 {
+		return fmt.Errorf("no gift intents provided")
+	}
+
+	now := app.clock.Now()
+
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionSmall(tx, distributionID)
+		if err != nil {
+			return err
+		}
+
+		if distribution.ArchivedAt != nil {
+			return newError(ErrorCodeDistributionState, "distribution is archived")
+		}
+
+		seen := make(map[int64]bool, len(intents))
+
+		for i := range intents {
+			g := &intents[i]
+
+			if err := g.Validate(now); err != nil {
+				return fmt.Errorf("error in gift intent %d: %w", i, err)
+			}
+
+			if !g.PackFlowID.Valid {
+				return fmt.Errorf("error in gift intent %d: packFlowID must be defined", i)
+			}
+
+			if seen[g.PackFlowID.Int64] {
+				return fmt.Errorf("error in gift intent %d: duplicate pack %d", i, g.PackFlowID.Int64)
+			}
+			seen[g.PackFlowID.Int64] = true
+
+			pack, err := GetPackByDistributionAndFlowID(tx, distributionID, g.PackFlowID)
+			if err != nil {
+				return fmt.Errorf("error in gift intent %d: pack %d: %w", i, g.PackFlowID.Int64, err)
+			}
+
+			pending, err := ListPendingGiftIntentsForPack(tx, pack.ID)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("error in gift intent %d: pack %d already has a pending gift intent", i, g.PackFlowID.Int64)
+			}
+
+			g.DistributionID = distributionID
+			g.PackID = pack.ID
+			g.State = common.GiftIntentStatePending
+
+			// Transferred before the intent was registered
+			if pack.Owner == g.Recipient {
+				if err := g.Transferred("", now); err != nil {
+					return err
+				}
+			}
+		}
+
+		return InsertGiftIntents(tx, intents, app.cfg.BatchInsertSize)
+	})
+}
+
+// ListGiftIntents lists the gift intents of a distribution. Uses 'limit' and
+// 'offset' to limit the fetched slice size.
+func (app *App) ListGiftIntents(ctx context.Context, distributionID uuid.UUID, limit, offset int) ([]GiftIntent, error) {
+	opt := ParseListOptions(limit, offset)
+
+	return ListGiftIntents(app.db, distributionID, opt)
+}
+
+// GetGiftIntent returns a gift intent of a distribution.
+func (app *App) GetGiftIntent(ctx context.Context, distributionID, id uuid.UUID) (*GiftIntent, error) {
+	g, err := GetGiftIntent(app.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.DistributionID != distributionID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return g, nil
+}
+
+// ListOwnedCollectibleIDs lists the IDs of the collectibles of a contract held
+// by 'account', e.g. to build the buckets of a distribution from a treasury
+// account. The contract has to be one of the configured collectible contracts,
+// its address can be left out. Uses 'limit' and 'offset' to limit the fetched
+// slice size.
+func (app *App) ListOwnedCollectibleIDs(ctx context.Context, account common.FlowAddress, contractName string, contractAddress common.FlowAddress, limit, offset int) (AddressLocation, []common.FlowID, error) {
+	contract, err := app.contracts.Resolve(AddressLocation{Name: contractName, Address: contractAddress})
+	if err != nil {
+		return AddressLocation{}, nil, err
+	}
+
+	if flow.Address(contract.Address) == flow.EmptyAddress {
+		return AddressLocation{}, nil, fmt.Errorf("collectible contract address required")
+	}
+
+	opt := ParseListOptions(limit, offset)
+
+	ids, err := app.service.OwnedCollectibleIDs(ctx, contract, account, opt)
+	if err != nil {
+		return AddressLocation{}, nil, err
+	}
+
+	return contract, ids, nil
+}
+
+// SetIssuerBranding validates and stores the branding of an issuer, replacing
+// any earlier one.
+func (app *App) SetIssuerBranding(ctx context.Context, branding *IssuerBranding) error {
+	if err := branding.Validate(); err != nil {
+		return err
+	}
+
+	return SaveIssuerBranding(app.db, branding)
+}
+
+// GetIssuerBranding returns the branding of an issuer.
+func (app *App) GetIssuerBranding(ctx context.Context, issuer common.FlowAddress) (*IssuerBranding, error) {
+	return GetIssuerBranding(app.db, issuer)
+}
+
+// RotateIssuerCallbackSecret generates a new callback secret for an issuer,
+// replacing any earlier one.
+func (app *App) RotateIssuerCallbackSecret(ctx context.Context, issuer common.FlowAddress) (*IssuerCallbackSecret, error) {
+	secret, err := newCallbackSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &IssuerCallbackSecret{Issuer: issuer, Secret: secret}
+	if err := SaveIssuerCallbackSecret(app.db, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ReceiveIssuerCallback verifies the signature of the callback 'c' ('body' as
+// received) with the callback secret of its issuer and stores it. A callback
+// ID is accepted only once, 'timestamp' limits how long a signed callback
+// can be replayed before it is stored.
+func (app *App) ReceiveIssuerCallback(ctx context.Context, c *IssuerCallback, timestamp, signature string, body []byte) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		secret, err := GetIssuerCallbackSecret(tx, c.Issuer)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: no callback secret for issuer", ErrCallbackUnauthorized)
+		}
+		if err != nil {
+			return err
+		}
+
+		signedAt, err := VerifyCallback(secret.Secret, timestamp, signature, body, app.clock.Now(), app.cfg.IssuerCallbackMaxSkew)
+		if err != nil {
+			return err
+		}
+
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		if c.PackID != nil {
+			pack, err := GetPack(tx, *c.PackID)
+			if err != nil {
+				return err
+			}
+			distribution, err := GetDistributionSmall(tx, pack.DistributionID)
+			if err != nil {
+				return err
+			}
+			if distribution.Issuer != c.Issuer {
+				return fmt.Errorf("pack %s is not of a distribution of issuer %s", pack.ID, c.Issuer)
+			}
+		}
+
+		if _, err := GetIssuerCallback(tx, c.Issuer, c.CallbackID); err == nil {
+			return ErrCallbackReplayed
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		c.SignedAt = signedAt
+
+		return InsertIssuerCallback(tx, c)
+	})
+}
+
+// ListIssuerCallbacks lists the received callbacks of an issuer, most recent
+// first.
+func (app *App) ListIssuerCallbacks(ctx context.Context, issuer common.FlowAddress, limit, offset int) ([]IssuerCallback, error) {
+	return ListIssuerCallbacks(app.db, issuer, ParseListOptions(limit, offset))
+}
+
+// SetPublicStatsOptIn opts an issuer in or out of the public stats.
+// ReserveCollectibleIDs reserves 'count' collectible IDs of contract 'ref'
+// for a pack when minting collectibles on open. Reserving again for the same
+// pack and contract returns the earlier reservation.
+func (app *App) ReserveCollectibleIDs(ctx context.Context, packID uuid.UUID, ref AddressLocation, count int) (*IDReservation, error) {
+	if err := validateIDReservationCount(count, app.cfg.MintOnOpenMaxIDsPerPack); err != nil {
+		return nil, err
+	}
+
+	ref, err := app.contracts.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &IDReservation{}
+
+	err = app.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := GetPack(tx, packID); err != nil {
+			return err
+		}
+
+		existing, err := GetIDReservation(tx, packID, ref)
+		if err == nil {
+			if existing.Count != count {
+				return fmt.Errorf("%d collectible IDs already reserved for pack %s", existing.Count, packID)
+			}
+			reservation = existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		firstID, err := AdvanceIDCounter(tx, ref, app.cfg.MintOnOpenFirstID, count)
+		if err != nil {
+			return err
+		}
+
+		reservation = &IDReservation{
+			PackID:            packID,
+			Contract:          ref.String(),
+			ContractReference: ref,
+			FirstID:           firstID,
+			Count:             count,
+		}
+
+		return InsertIDReservation(tx, reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// ListCollectibleIDReservations lists the collectible IDs reserved for a pack.
+func (app *App) ListCollectibleIDReservations(ctx context.Context, packID uuid.UUID) ([]IDReservation, error) {
+	return ListIDReservations(app.db, packID)
+}
+
+// CreateIssuer registers an issuer organization for its address. Its allowed
+// collectible contracts are checked like the collectible references of
+// buckets.
+func (app *App) CreateIssuer(ctx context.Context, issuer *Issuer) error {
+	if err := issuer.Validate(); err != nil {
+		return err
+	}
+
+	if err := app.resolveContracts(issuer.AllowedContracts); err != nil {
+		return err
+	}
+
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := GetIssuer(tx, issuer.Address); err == nil {
+			return fmt.Errorf("issuer %s is already registered", issuer.Address)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return InsertIssuer(tx, issuer)
+	})
+}
+
+// UpdateIssuer replaces the name and allowed collectible contracts of a
+// registered issuer. Distributions created before are not affected.
+func (app *App) UpdateIssuer(ctx context.Context, address common.FlowAddress, name string, allowed ContractList) (*Issuer, error) {
+	if err := app.resolveContracts(allowed); err != nil {
+		return nil, err
+	}
+
+	issuer := &Issuer{}
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if issuer, err = GetIssuer(tx, address); err != nil {
+			return err
+		}
+
+		issuer.Name = name
+		issuer.AllowedContracts = allowed
+
+		if err := issuer.Validate(); err != nil {
+			return err
+		}
+
+		return UpdateIssuer(tx, issuer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issuer, nil
+}
+
+// GetIssuer returns the issuer registered for an address.
+func (app *App) GetIssuer(ctx context.Context, address common.FlowAddress) (*Issuer, error) {
+	return GetIssuer(app.db, address)
+}
+
+// ListIssuers lists the registered issuers in order of registration.
+// Uses 'limit' and 'offset' to limit the fetched slice size.
+func (app *App) ListIssuers(ctx context.Context, limit, offset int) ([]Issuer, error) {
+	return ListIssuers(app.db, ParseListOptions(limit, offset))
+}
+
+// registeredIssuer returns the issuer registered for 'address', nil if none.
+// Unregistered issuers are refused if 'IssuersRequired' is set.
+func (app *App) registeredIssuer(db *gorm.DB, address common.FlowAddress) (*Issuer, error) {
+	issuer, err := GetIssuer(db, address)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if app.cfg.IssuersRequired {
+			return nil, newError(ErrorCodeIssuerNotRegistered, "issuer %s is not registered", address)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+// resolveContracts fills in the addresses of the collectible contracts in
+// 'list' configured for this network (if any are).
+func (app *App) resolveContracts(list ContractList) error {
+	for i, ref := range list {
+		resolved, err := app.contracts.Resolve(ref)
+		if err != nil {
+			return fmt.Errorf("error in allowed contract %d: %w", i, err)
+		}
+		list[i] = resolved
+	}
+	return nil
+}
+
+// CreateAPIKey creates an API key of an issuer, returns it along with the
+// key itself which is not stored. Its allowed collectible contracts are
+// checked like the collectible references of buckets and have to be allowed
+// for the issuer.
+func (app *App) CreateAPIKey(ctx context.Context, issuer common.FlowAddress, name string, allowed ContractList) (*APIKey, string, error) {
+	registered, err := app.registeredIssuer(app.db, issuer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := app.resolveContracts(allowed); err != nil {
+		return nil, "", err
+	}
+
+	if registered != nil && len(registered.AllowedContracts) > 0 {
+		for _, ref := range allowed {
+			if !registered.AllowedContracts.Contains(ref) {
+				return nil, "", fmt.Errorf("collectible contract '%s' is not allowed for issuer %s", ref, issuer)
+			}
+		}
+	}
+
+	key, plaintext, err := newAPIKey(issuer, name, allowed)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := InsertAPIKey(app.db, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// ListAPIKeys lists the API keys of an issuer, including revoked ones.
+func (app *App) ListAPIKeys(ctx context.Context, issuer common.FlowAddress) ([]APIKey, error) {
+	return ListAPIKeys(app.db, issuer)
+}
+
+// RevokeAPIKey revokes an API key of an issuer, it can not be used after.
+func (app *App) RevokeAPIKey(ctx context.Context, issuer common.FlowAddress, id uuid.UUID) (*APIKey, error) {
+	key := &APIKey{}
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if key, err = GetAPIKey(tx, issuer, id); err != nil {
+			return err
+		}
+
+		if err := key.Revoke(app.clock.Now()); err != nil {
+			return err
+		}
+
+		return UpdateAPIKey(tx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// CreateIssuerWebhook registers 'url' to receive the webhook events of an
+// issuer, 'apiKeyID' is the API key used to register it if any. Returns the
+// webhook, its secret is returned only on creation.
+func (app *App) CreateIssuerWebhook(ctx context.Context, issuer common.FlowAddress, apiKeyID *uuid.UUID, url string) (*IssuerWebhook, error) {
+	if _, err := app.registeredIssuer(app.db, issuer); err != nil {
+		return nil, err
+	}
+
+	w, err := newIssuerWebhook(issuer, apiKeyID, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := InsertIssuerWebhook(app.db, w); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// ListIssuerWebhooks lists the webhooks of an issuer.
+func (app *App) ListIssuerWebhooks(ctx context.Context, issuer common.FlowAddress) ([]IssuerWebhook, error) {
+	return ListIssuerWebhooks(app.db, issuer)
+}
+
+// DeleteIssuerWebhook deletes a webhook of an issuer, its queued deliveries
+// are dropped.
+func (app *App) DeleteIssuerWebhook(ctx context.Context, issuer common.FlowAddress, id uuid.UUID) error {
+	return DeleteIssuerWebhook(app.db, issuer, id)
+}
+
+// AuthenticateAPIKey returns the API key matching 'key' if it has not been
+// revoked.
+func (app *App) AuthenticateAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	k, err := GetAPIKeyByHash(app.db, HashAPIKey(key))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if k.RevokedAt != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	return k, nil
+}
+
+// AuthenticateSignedRequest verifies the signature of a request of 'issuer'
+// ('uri' and 'body' as received) with the shared secret of the issuer, see
+// SignRequest. Each nonce is accepted once while 'timestamp' is within
+// 'IssuerCallbackMaxSkew'.
+func (app *App) AuthenticateSignedRequest(ctx context.Context, issuer common.FlowAddress, timestamp, nonce, signature, method, uri string, body []byte) error {
+	secret, err := GetIssuerCallbackSecret(app.db, issuer)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: no shared secret for issuer", ErrCallbackUnauthorized)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := app.clock.Now()
+	signedAt, err := VerifyRequest(secret.Secret, timestamp, nonce, method, uri, signature, body, now, app.cfg.IssuerCallbackMaxSkew)
+	if err != nil {
+		return err
+	}
+
+	return app.nonces.Use(issuer, nonce, now, signedAt.Add(app.cfg.IssuerCallbackMaxSkew))
+}
+
+func (app *App) SetPublicStatsOptIn(ctx context.Context, issuer common.FlowAddress, optIn bool) error {
+	return SetPublicStatsOptIn(app.db, issuer, optIn)
+}
+
+// GetPublicStats returns aggregate numbers over the distributions of opted in
+// issuers, cached for 'PublicStatsCacheTTL'.
+func (app *App) GetPublicStats(ctx context.Context) (PublicStats, error) {
+	now := app.clock.Now()
+	return app.stats.get(now, app.cfg.PublicStatsCacheTTL, func() (PublicStats, error) {
+		counts, err := countPublicStats(app.db)
+		if err != nil {
+			return PublicStats{}, err
+		}
+		return newPublicStats(counts, app.cfg.PublicStatsMinIssuers, app.cfg.PublicStatsRoundTo, now), nil
+	})
+}
+
+// ListDeadLetterTransactions lists transactions which ran out of attempts.
+// Uses 'limit' and 'offset' to limit the fetched slice size.
+func (app *App) ListDeadLetterTransactions(ctx context.Context, limit, offset int) ([]transactions.StorableTransaction, error) {
+	opt := ParseListOptions(limit, offset)
+
+	return transactions.ListDeadLetter(app.db, opt.Limit, opt.Offset)
+}
+
+// ListTransactionAudit lists the sent transactions (attempts) of a
+// distribution, oldest first.
+func (app *App) ListTransactionAudit(ctx context.Context, distributionID uuid.UUID, limit, offset int) ([]transactions.TransactionAttempt, error) {
+	opt := ParseListOptions(limit, offset)
+
+	if _, err := GetDistributionSmall(app.db, distributionID); err != nil {
+		return nil, err
+	}
+
+	return transactions.ListAttemptsByDistribution(app.db, distributionID, opt.Limit, opt.Offset)
+}
+
+// RequeueTransaction resets a dead-letter transaction to be sent again.
+func (app *App) RequeueTransaction(ctx context.Context, id uuid.UUID) (*transactions.StorableTransaction, error) {
+	var t *transactions.StorableTransaction
+
+	err := app.db.Transaction(func(tx *gorm.DB) (err error) {
+		if t, err = transactions.GetTransaction(tx, id); err != nil {
+			return err
+		}
+
+		if err := t.Requeue(); err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"ID":             t.ID,
+			"name":           t.Name,
+			"distributionID": t.DistributionID,
+		}).Info("Dead-letter transaction requeued")
+
+		return t.Save(tx)
+	})
+
+	return t, err
+}
+
+// ForceRevealPack stores a new reveal transaction for a pack whose reveal
+// request was handled but whose reveal transaction failed, see
+// ContractService.ForceReveal.
+func (app *App) ForceRevealPack(ctx context.Context, packID uuid.UUID, openRequest bool) (*transactions.StorableTransaction, error) {
+	var t *transactions.StorableTransaction
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		pack, dist, err := getForceablePack(tx, packID)
+		if err != nil {
+			return err
+		}
+
+		t, err = app.service.ForceReveal(ctx, tx, dist, pack, openRequest)
+		return err
+	})
+
+	return t, err
+}
+
+// ForceOpenPack stores a new open transaction for a pack whose open request
+// was handled but whose open transaction failed, see ContractService.ForceOpen.
+func (app *App) ForceOpenPack(ctx context.Context, packID uuid.UUID) (*transactions.StorableTransaction, error) {
+	var t *transactions.StorableTransaction
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		pack, dist, err := getForceablePack(tx, packID)
+		if err != nil {
+			return err
+		}
+
+		t, err = app.service.ForceOpen(ctx, tx, dist, pack)
+		return err
+	})
+
+	return t, err
+}
+
+// getForceablePack locks the pack 'packID' for forcing a transaction and
+// returns it with its distribution.
+func getForceablePack(tx *gorm.DB, packID uuid.UUID) (*Pack, *Distribution, error) {
+	pack, err := GetPack(tx.Clauses(clause.Locking{Strength: "UPDATE"}), packID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dist, err := GetDistributionSmall(tx, pack.DistributionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pack, dist, nil
+}
+
+// AddKeyRotationNotifier adds a notifier for key rotations, in addition to
+// logging and the optional webhook.
+func (app *App) AddKeyRotationNotifier(n KeyRotationNotifier) {
+	app.notifiers = append(app.notifiers, n)
+}
+
+// SetBroadcaster replaces how transactions are built, signed and sent, e.g.
+// with a mock in tests. Set it before anything is sent (New with 'poll'
+// false), it is not safe to swap while transactions are being sent.
+func (app *App) SetBroadcaster(f BroadcasterFunc) {
+	app.service.broadcasters = f
+}
+
+// RotateAndFreeze freezes sending, revokes the active admin keys using the
+// recovery key and switches to the standby keys. Sending stays frozen if the
+// rotation fails. Notifiers are notified when the rotation starts and ends.
+func (app *App) RotateAndFreeze(ctx context.Context, reason string) (*KeyRotation, error) {
+	if app.cfg.DryRun {
+		return nil, fmt.Errorf("key rotation: %w", ErrDryRun)
+	}
+
+	r := &KeyRotation{State: common.KeyRotationStateRevoking, Reason: reason}
+
+	active, standby := app.service.keys.indexes()
+	if err := r.SetKeyIndexes(active, standby); err != nil {
+		return nil, err
+	}
+
+	if err := InsertKeyRotation(app.db, r); err != nil {
+		return nil, err
+	}
+
+	app.notifyKeyRotation(ctx, r)
+
+	if err := app.service.RotateKeys(ctx, r); err != nil {
+		r.State = common.KeyRotationStateFailed
+		r.Error = err.Error()
+	} else {
+		r.State = common.KeyRotationStateComplete
+	}
+
+	if err := UpdateKeyRotation(app.db, r); err != nil {
+		return nil, err
+	}
+
+	app.notifyKeyRotation(ctx, r)
+
+	return r, nil
+}
+
+// FreezeSending stops sending any transactions until unfrozen.
+func (app *App) FreezeSending() {
+	log.Warn("Sending transactions frozen")
+	app.service.keys.SetFrozen(true)
+}
+
+// UnfreezeSending resumes sending transactions.
+func (app *App) UnfreezeSending() {
+	log.Warn("Sending transactions unfrozen")
+	app.service.keys.SetFrozen(false)
+}
+
+func (app *App) notifyKeyRotation(ctx context.Context, r *KeyRotation) {
+	for _, n := range app.notifiers {
+		if err := n.NotifyKeyRotation(ctx, r); err != nil {
+			log.WithFields(log.Fields{"keyRotationID": r.ID, "error": err}).Warn("Error while notifying of key rotation")
+		}
+	}
+}
+
+// accessAPIHostAllowed returns true if 'host' can be used as the Access API
+// host override of a distribution, empty if not overridden.
+func (app *App) accessAPIHostAllowed(host string) bool {
+	return host == "" || contains(app.cfg.AccessAPIOverrideHosts, host)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// BatchSizer adjusts the size of settlement and minting batches according to
+// the outcome of sealed batch transactions. The Flow SDK in use does not report
+// the computation used by a transaction, so running out of gas is the signal:
+// the size is halved when a batch exceeds the gas limit and grown by one for
+// each batch of the current size which seals, up to 'max'.
+// The size is kept in memory only, it starts from 'max' after a restart.
+type BatchSizer struct {
+	mu      sync.Mutex
+	max     int
+	current int
+}
+
+func NewBatchSizer(max int) *BatchSizer {
+	if max < 1 {
+		max = 1
+	}
+	return &BatchSizer{max: max, current: max}
+}
+
+// Size returns the batch size to use for new batches.
+func (b *BatchSizer) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current
+}
+
+// Observe adjusts the batch size according to the outcome of a batch of 'size'
+// items. Batches of a different size than the current one only shrink it.
+func (b *BatchSizer) Observe(size int, gasLimitExceeded bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gasLimitExceeded {
+		if next := size / 2; next < b.current {
+			b.current = next
+		}
+		if b.current < 1 {
+			b.current = 1
+		}
+		return
+	}
+
+	if size >= b.current && b.current < b.max {
+		b.current++
+	}
+}
+
+// observeBatchResult feeds the outcome of a finished settle or mint
+// transaction to its batch sizer, if 'AdaptiveBatchSize' is enabled.
+// A batch which ran out of gas is split into new transactions of the reduced
+// size so its collectibles or packs are still settled or minted, returns true
+// if split.
+func (svc *ContractService) observeBatchResult(db *gorm.DB, t *transactions.StorableTransaction) (bool, error) {
+	if !svc.cfg.AdaptiveBatchSize || t.BatchSize == 0 {
+		return false, nil
+	}
+
+	if t.State != common.TransactionStateComplete && t.State != common.TransactionStateFailed {
+		return false, nil
+	}
+
+	var sizer *BatchSizer
+	var operation string
+	switch t.Name {
+	case SETTLE_SCRIPT:
+		sizer, operation = svc.settleBatchSizer, metrics.OperationSettle
+	case MINT_SCRIPT:
+		sizer, operation = svc.mintBatchSizer, metrics.OperationMint
+	default:
+		return false, nil
+	}
+
+	gasLimitExceeded := t.State == common.TransactionStateFailed && flow_helpers.IsGasLimitExceededError(t.Error)
+
+	sizer.Observe(t.BatchSize, gasLimitExceeded)
+	metrics.SetBatchSize(operation, sizer.Size())
+
+	if !gasLimitExceeded || t.BatchSize == 1 {
+		return false, nil
+	}
+
+	// Both settle and mint transactions take the batch as their second argument
+	split, err := t.Split(1, sizer.Size())
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range split {
+		if err := s.Save(db); err != nil {
+			return false, err
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"name":           t.Name,
+		"ID":             t.ID,
+		"distributionID": t.DistributionID,
+		"batchSize":      t.BatchSize,
+		"newBatchSize":   sizer.Size(),
+		"batches":        len(split),
+	}).Warn("Batch exceeded the gas limit, split into smaller batches")
+
+	return true, nil
+}
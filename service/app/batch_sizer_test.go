@@ -0,0 +1,47 @@
+package app
+
+import "testing"
+
+func TestBatchSizer(t *testing.T) {
+	b := NewBatchSizer(40)
+
+	if b.Size() != 40 {
+		t.Fatalf("expected to start from 40, got %d", b.Size())
+	}
+
+	b.Observe(40, true)
+	if b.Size() != 20 {
+		t.Fatalf("expected 20 after exceeding the gas limit, got %d", b.Size())
+	}
+
+	// A smaller batch sealing does not grow the size
+	b.Observe(10, false)
+	if b.Size() != 20 {
+		t.Fatalf("expected 20, got %d", b.Size())
+	}
+
+	b.Observe(20, false)
+	if b.Size() != 21 {
+		t.Fatalf("expected 21, got %d", b.Size())
+	}
+
+	// An older bigger batch failing should not shrink more than it says
+	b.Observe(50, true)
+	if b.Size() != 21 {
+		t.Fatalf("expected 21, got %d", b.Size())
+	}
+
+	for i := 0; i < 100; i++ {
+		b.Observe(b.Size(), false)
+	}
+	if b.Size() != 40 {
+		t.Fatalf("expected to grow back to 40, got %d", b.Size())
+	}
+
+	for i := 0; i < 10; i++ {
+		b.Observe(b.Size(), true)
+	}
+	if b.Size() != 1 {
+		t.Fatalf("expected not to go below 1, got %d", b.Size())
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Broadcaster builds, signs, sends and awaits the results of transactions.
+// The app layer sends transactions only through a Broadcaster so the backend
+// can be swapped (e.g. a mock in tests) without touching the business logic.
+type Broadcaster interface {
+	// Prepare builds 't' and signs it with a proposal key of 'account'. The
+	// key stays locked until the returned UnlockKeyFunc is called.
+	Prepare(ctx context.Context, t *transactions.StorableTransaction, account *flow_helpers.Account) (*flow.Transaction, flow_helpers.UnlockKeyFunc, error)
+	// Sign signs 'tx' as proposer, payer and authorizer with a proposal key
+	// of 'account', for transactions not built from a StorableTransaction.
+	Sign(ctx context.Context, tx *flow.Transaction, account *flow_helpers.Account) (flow_helpers.UnlockKeyFunc, error)
+	// Send sends a prepared transaction.
+	Send(ctx context.Context, tx *flow.Transaction) error
+	// WaitForSeal blocks until the transaction is sealed, reverted or expired
+	// or the seal timeout passes, see flow_helpers.WaitForSeal for the errors.
+	WaitForSeal(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error)
+	// WaitForFinalize blocks until the transaction is finalized or sealed.
+	WaitForFinalize(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error)
+}
+
+// Fraction the delays between polls for transaction results are randomly
+// varied by, so transactions sent together are not polled in lockstep
+const resultPollJitter = 0.1
+
+// BroadcasterFunc returns the Broadcaster to use with 'flowClient', the
+// Access API client of the distribution a transaction belongs to.
+type BroadcasterFunc func(flowClient flow_helpers.FlowClient) Broadcaster
+
+// flowBroadcaster sends transactions through an Access API client.
+type flowBroadcaster struct {
+	flowClient flow_helpers.FlowClient
+	refBlocks  *flow_helpers.ReferenceBlockCache
+	clock      common.Clock
+	cfg        *config.Config
+}
+
+// newFlowBroadcaster returns the default BroadcasterFunc, referencing blocks
+// from 'refBlocks'.
+func newFlowBroadcaster(cfg *config.Config, refBlocks *flow_helpers.ReferenceBlockCache, clock common.Clock) BroadcasterFunc {
+	return func(flowClient flow_helpers.FlowClient) Broadcaster {
+		return &flowBroadcaster{flowClient, refBlocks, clock, cfg}
+	}
+}
+
+func (b *flowBroadcaster) Prepare(ctx context.Context, t *transactions.StorableTransaction, account *flow_helpers.Account) (*flow.Transaction, flow_helpers.UnlockKeyFunc, error) {
+	return t.Prepare(ctx, b.flowClient, b.refBlocks, account, b.cfg.TransactionGasLimit)
+}
+
+func (b *flowBroadcaster) Sign(ctx context.Context, tx *flow.Transaction, account *flow_helpers.Account) (flow_helpers.UnlockKeyFunc, error) {
+	return flow_helpers.SignProposeAndPayAs(ctx, b.flowClient, account, tx)
+}
+
+func (b *flowBroadcaster) Send(ctx context.Context, tx *flow.Transaction) error {
+	return b.flowClient.SendTransaction(ctx, *tx)
+}
+
+func (b *flowBroadcaster) WaitForSeal(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	return flow_helpers.WaitForSeal(ctx, b.flowClient, b.clock, id, flow_helpers.PollOptions{
+		Interval:    b.cfg.TransactionResultPollInterval,
+		MaxInterval: b.cfg.TransactionResultMaxPollInterval,
+		Timeout:     b.cfg.TransactionSealTimeout,
+		Jitter:      resultPollJitter,
+	})
+}
+
+func (b *flowBroadcaster) WaitForFinalize(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	// Backs off up to the poll interval of sealing, gives up (releasing the
+	// proposal key) when a transaction would not seal in time either
+	return flow_helpers.WaitForFinalize(ctx, b.flowClient, b.clock, id, flow_helpers.PollOptions{
+		Interval:    b.cfg.TransactionFinalizePollInterval,
+		MaxInterval: b.cfg.TransactionResultPollInterval,
+		Timeout:     b.cfg.TransactionSealTimeout,
+		Jitter:      resultPollJitter,
+	})
+}
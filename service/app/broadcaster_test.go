@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+)
+
+type resultClient struct {
+	flow_helpers.FlowClient
+	sent     []flow.Identifier
+	statuses []flow.TransactionStatus
+}
+
+func (c *resultClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	c.sent = append(c.sent, tx.ID())
+	return nil
+}
+
+func (c *resultClient) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error) {
+	status := c.statuses[0]
+	if len(c.statuses) > 1 {
+		c.statuses = c.statuses[1:]
+	}
+	return &flow.TransactionResult{Status: status}, nil
+}
+
+func TestFlowBroadcaster(t *testing.T) {
+	cfg := &config.Config{TransactionFinalizePollInterval: time.Millisecond, TransactionResultPollInterval: time.Millisecond}
+	clock := common.RealClock{}
+	c := &resultClient{statuses: []flow.TransactionStatus{flow.TransactionStatusPending, flow.TransactionStatusFinalized, flow.TransactionStatusSealed}}
+	b := newFlowBroadcaster(cfg, nil, clock)(c)
+
+	tx := flow.NewTransaction()
+	if err := b.Send(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 1 || c.sent[0] != tx.ID() {
+		t.Fatalf("expected the transaction to be sent through the client, got %v", c.sent)
+	}
+
+	result, err := b.WaitForFinalize(context.Background(), tx.ID())
+	if err != nil || result.Status != flow.TransactionStatusFinalized {
+		t.Fatalf("expected a finalized result, got %v (%v)", result, err)
+	}
+
+	result, err = b.WaitForSeal(context.Background(), tx.ID())
+	if err != nil || result.Status != flow.TransactionStatusSealed {
+		t.Fatalf("expected a sealed result, got %v (%v)", result, err)
+	}
+}
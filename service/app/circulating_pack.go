@@ -2,9 +2,12 @@ package app
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
 	"gorm.io/gorm"
 )
 
@@ -37,3 +40,45 @@ func (c CirculatingPackContract) String() string {
 func (c CirculatingPackContract) EventName(event string) string {
 	return fmt.Sprintf("%s.%s", c, event)
 }
+
+// packEvent is an event of a CirculatingPackContract emitted in the block at
+// 'height'.
+type packEvent struct {
+	name   string
+	height uint64
+	event  flow.Event
+}
+
+// sortPackEvents sorts 'events' in the order they were emitted.
+func sortPackEvents(events []packEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.height != b.height {
+			return a.height < b.height
+		}
+		if a.event.TransactionIndex != b.event.TransactionIndex {
+			return a.event.TransactionIndex < b.event.TransactionIndex
+		}
+		return a.event.EventIndex < b.event.EventIndex
+	})
+}
+
+// packEventOwner returns the owner of a pack after a WITHDRAW or DEPOSIT
+// event 'e', empty if the pack is not stored in an account.
+func packEventOwner(eventName string, evtValueMap map[string]cadence.Value, e flow.Event) (common.FlowAddress, error) {
+	owner := common.FlowAddress(flow.EmptyAddress)
+	if eventName != DEPOSIT {
+		return owner, nil
+	}
+
+	toValue, ok := evtValueMap["to"]
+	if !ok {
+		return owner, fmt.Errorf("could not read 'to' from event %s", e)
+	}
+
+	// 'to' is nil if the receiving collection is not stored in an account
+	if toValue.ToGoValue() == nil {
+		return owner, nil
+	}
+	return common.FlowAddressFromCadence(toValue)
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestSortPackEvents(t *testing.T) {
+	events := []packEvent{
+		{name: OPENED, height: 2, event: flow.Event{TransactionIndex: 0, EventIndex: 0}},
+		{name: WITHDRAW, height: 1, event: flow.Event{TransactionIndex: 1, EventIndex: 0}},
+		{name: DEPOSIT, height: 1, event: flow.Event{TransactionIndex: 0, EventIndex: 1}},
+		{name: DEPOSIT, height: 1, event: flow.Event{TransactionIndex: 1, EventIndex: 1}},
+		{name: WITHDRAW, height: 1, event: flow.Event{TransactionIndex: 0, EventIndex: 0}},
+	}
+
+	sortPackEvents(events)
+
+	expected := []string{WITHDRAW, DEPOSIT, WITHDRAW, DEPOSIT, OPENED}
+	for i, e := range events {
+		if e.name != expected[i] {
+			t.Fatalf("expected events in the order %v, got %v", expected, events)
+		}
+	}
+	if events[1].event.TransactionIndex != 0 || events[3].event.TransactionIndex != 1 {
+		t.Fatalf("expected events of a block ordered by transaction, got %v", events)
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/flow-go-sdk"
+)
+
+var flowAddressRegexp = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{1,16}$`)
+
+// collectibleContracts holds the collectible NFT contracts configured for
+// the current network, contract name to address.
+type collectibleContracts map[string]common.FlowAddress
+
+func newCollectibleContracts(cfg *config.Config) (collectibleContracts, error) {
+	res := make(collectibleContracts)
+	for name, address := range cfg.CollectibleContracts.Network(cfg.FlowNetwork) {
+		if name == "" {
+			return nil, fmt.Errorf("empty collectible contract name for network '%s'", cfg.FlowNetwork)
+		}
+		if !flowAddressRegexp.MatchString(address) {
+			return nil, fmt.Errorf("invalid address '%s' for collectible contract '%s'", address, name)
+		}
+		res[name] = common.FlowAddressFromString(address)
+	}
+	return res, nil
+}
+
+// Resolve checks that 'ref' refers to a configured collectible contract and
+// fills in its address if left out. All references are accepted as is if no
+// contracts are configured.
+func (cc collectibleContracts) Resolve(ref AddressLocation) (AddressLocation, error) {
+	if len(cc) == 0 {
+		return ref, nil
+	}
+
+	address, ok := cc[ref.Name]
+	if !ok {
+		return AddressLocation{}, fmt.Errorf("collectible contract '%s' is not configured", ref.Name)
+	}
+
+	if flow.Address(ref.Address) == flow.EmptyAddress {
+		ref.Address = address
+	} else if ref.Address != address {
+		return AddressLocation{}, fmt.Errorf(
+			"collectible contract '%s' address mismatch, expected %s got %s",
+			ref.Name, address, ref.Address,
+		)
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+)
+
+func TestCollectibleContractsResolve(t *testing.T) {
+	cfg := &config.Config{
+		FlowNetwork: "emulator",
+		CollectibleContracts: config.CollectibleContracts{
+			"emulator": {"ExampleNFT": "01cf0e2f2f715450", "OtherNFT": "0xf3fcd2c1a78f5eee"},
+			"testnet":  {"ExampleNFT": "f534d89914579e09"},
+		},
+	}
+
+	cc, err := newCollectibleContracts(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	example := common.FlowAddressFromString("01cf0e2f2f715450")
+
+	ref, err := cc.Resolve(AddressLocation{Name: "ExampleNFT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Address != example {
+		t.Errorf("expected address %s, got %s", example, ref.Address)
+	}
+
+	if _, err := cc.Resolve(AddressLocation{Name: "ExampleNFT", Address: example}); err != nil {
+		t.Errorf("expected matching address to resolve, got %s", err)
+	}
+
+	if _, err := cc.Resolve(AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("f534d89914579e09")}); err == nil {
+		t.Error("expected an error for an address of another network")
+	}
+
+	if _, err := cc.Resolve(AddressLocation{Name: "UnknownNFT"}); err == nil {
+		t.Error("expected an error for an unknown contract")
+	}
+
+	cfg.CollectibleContracts = nil
+	cc, err = newCollectibleContracts(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cc.Resolve(AddressLocation{Name: "UnknownNFT", Address: example}); err != nil {
+		t.Errorf("expected any contract to be allowed when not configured, got %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CollectibleOwnershipCheck is the report of checking, before a distribution
+// is set up, that its issuer owns all collectibles of its buckets. A
+// distribution with missing collectibles is aborted instead of failing
+// halfway through settlement, see CollectibleOwnershipCheck in config.
+type CollectibleOwnershipCheck struct {
+	gorm.Model
+	ID             uuid.UUID    `gorm:"column:id;primary_key;type:uuid;"`
+	DistributionID uuid.UUID    `gorm:"uniqueIndex"`
+	Distribution   Distribution `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Issuer       common.FlowAddress `gorm:"column:issuer"`
+	CheckedCount uint               `gorm:"column:checked_count"`
+
+	Missing []MissingCollectible `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// MissingCollectible is a collectible of a bucket which is not in the
+// collection of the issuer.
+type MissingCollectible struct {
+	gorm.Model
+	ID                          uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+	CollectibleOwnershipCheckID uuid.UUID `gorm:"index"`
+
+	BucketIndex       int             `gorm:"column:bucket_index"`
+	FlowID            common.FlowID   `gorm:"column:flow_id"`
+	ContractReference AddressLocation `gorm:"embedded;embeddedPrefix:contract_ref_"`
+}
+
+func (CollectibleOwnershipCheck) TableName() string {
+	return "collectible_ownership_checks"
+}
+
+func (c *CollectibleOwnershipCheck) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return nil
+}
+
+func (MissingCollectible) TableName() string {
+	return "missing_collectibles"
+}
+
+func (m *MissingCollectible) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return nil
+}
+
+// Passed returns true if the issuer owns all collectibles.
+func (c *CollectibleOwnershipCheck) Passed() bool {
+	return len(c.Missing) == 0
+}
+
+// findMissingCollectibles returns the collectibles of 'buckets' which are not
+// in 'owned' (collectible FlowIDs in the collection of the issuer, per
+// contract), in bucket order.
+func findMissingCollectibles(buckets []Bucket, owned map[AddressLocation]map[int64]bool) []MissingCollectible {
+	res := []MissingCollectible{}
+	for i, b := range buckets {
+		ids := make([]int64, 0, len(b.CollectibleCollection))
+		for _, id := range b.CollectibleCollection {
+			if !owned[b.CollectibleReference][id.Int64] {
+				ids = append(ids, id.Int64)
+			}
+		}
+		sort.Slice(ids, func(a, b int) bool { return ids[a] < ids[b] })
+
+		for _, id := range ids {
+			res = append(res, MissingCollectible{
+				BucketIndex:       i,
+				FlowID:            common.FlowID{Int64: id, Valid: true},
+				ContractReference: b.CollectibleReference,
+			})
+		}
+	}
+	return res
+}
+
+// checkCollectibleOwnership runs scripts listing the collectibles the issuer
+// of 'dist' owns, for each collectible contract of its buckets, and stores
+// the report replacing an earlier one.
+func (svc *ContractService) checkCollectibleOwnership(ctx context.Context, db *gorm.DB, dist *Distribution) (*CollectibleOwnershipCheck, error) {
+	withBuckets, err := GetDistributionWithBuckets(db, dist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := withBuckets.PackTemplate.Buckets
+
+	owned := make(map[AddressLocation]map[int64]bool)
+	checked := 0
+	for _, b := range buckets {
+		checked += len(b.CollectibleCollection)
+
+		if _, ok := owned[b.CollectibleReference]; ok {
+			continue
+		}
+
+		ids, err := svc.OwnedCollectibleIDs(ctx, b.CollectibleReference, dist.Issuer, ListOptions{Limit: -1})
+		if err != nil {
+			return nil, err
+		}
+
+		owned[b.CollectibleReference] = make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			owned[b.CollectibleReference][id.Int64] = true
+		}
+	}
+
+	check := &CollectibleOwnershipCheck{
+		DistributionID: dist.ID,
+		Issuer:         dist.Issuer,
+		CheckedCount:   uint(checked),
+		Missing:        findMissingCollectibles(buckets, owned),
+	}
+
+	if err := ReplaceCollectibleOwnershipCheck(db, check, svc.cfg.BatchInsertSize); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"distID":       dist.ID,
+		"checkedCount": check.CheckedCount,
+		"missingCount": len(check.Missing),
+	}).Debug("Collectible ownership checked")
+
+	return check, nil
+}
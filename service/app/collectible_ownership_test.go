@@ -0,0 +1,48 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestFindMissingCollectibles(t *testing.T) {
+	moments := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+	badges := AddressLocation{Name: "Badge", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+
+	id := func(i int64) common.FlowID {
+		return common.FlowID{Int64: i, Valid: true}
+	}
+
+	buckets := []Bucket{
+		{CollectibleReference: moments, CollectibleCollection: common.FlowIDList{id(3), id(1), id(2)}},
+		{CollectibleReference: badges, CollectibleCollection: common.FlowIDList{id(1), id(2)}},
+	}
+
+	owned := map[AddressLocation]map[int64]bool{
+		moments: {2: true},
+		badges:  {1: true, 2: true},
+	}
+
+	expected := []MissingCollectible{
+		{BucketIndex: 0, FlowID: id(1), ContractReference: moments},
+		{BucketIndex: 0, FlowID: id(3), ContractReference: moments},
+	}
+	if got := findMissingCollectibles(buckets, owned); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected missing collectibles %v, got %v", expected, got)
+	}
+
+	// Same FlowIDs of another contract are not owned
+	owned[moments] = map[int64]bool{1: true, 2: true, 3: true}
+	delete(owned, badges)
+	if got := findMissingCollectibles(buckets, owned); len(got) != 2 || got[0].BucketIndex != 1 || got[0].ContractReference != badges {
+		t.Errorf("expected the badges to be missing, got %v", got)
+	}
+
+	owned[badges] = map[int64]bool{1: true, 2: true}
+	if got := findMissingCollectibles(buckets, owned); len(got) != 0 {
+		t.Errorf("expected no missing collectibles, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCollectionNameLength        = 100
+	maxCollectionDescriptionLength = 1000
+)
+
+// Collection groups related distributions of an issuer, e.g. the drops of a
+// season. Its policies are shared by its distributions, which use them where
+// they leave them out.
+type Collection struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer      common.FlowAddress `gorm:"column:issuer;index"`
+	Name        string             `gorm:"column:name"`
+	Description string             `gorm:"column:description"` // Optional
+
+	// Shared policies, all optional
+	PackReference        AddressLocation `gorm:"embedded;embeddedPrefix:pack_ref_"`        // Reference to the pack NFT contract
+	CollectibleReference AddressLocation `gorm:"embedded;embeddedPrefix:collectible_ref_"` // Reference to the collectible NFT contract of buckets
+	AccessAPIHost        string          `gorm:"column:access_api_host"`
+	RevealWebhookURL     string          `gorm:"column:reveal_webhook_url"`
+}
+
+func (Collection) TableName() string {
+	return "collections"
+}
+
+func (c *Collection) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return nil
+}
+
+// Validate checks the issuer and name are set.
+func (c Collection) Validate() error {
+	if flow.Address(c.Issuer) == flow.EmptyAddress {
+		return fmt.Errorf("issuer is required")
+	}
+
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if utf8.RuneCountInString(c.Name) > maxCollectionNameLength {
+		return fmt.Errorf("name can be at most %d characters", maxCollectionNameLength)
+	}
+
+	if utf8.RuneCountInString(c.Description) > maxCollectionDescriptionLength {
+		return fmt.Errorf("description can be at most %d characters", maxCollectionDescriptionLength)
+	}
+
+	return nil
+}
+
+// Apply adds 'd' to the collection and fills in the policies 'd' leaves out.
+func (c Collection) Apply(d *Distribution) error {
+	if d.Issuer != c.Issuer {
+		return fmt.Errorf("collection %s is not of issuer %s", c.ID, d.Issuer)
+	}
+
+	d.CollectionID = &c.ID
+
+	if d.PackTemplate.PackReference.Name == "" {
+		d.PackTemplate.PackReference = c.PackReference
+	}
+
+	for i := range d.PackTemplate.Buckets {
+		if d.PackTemplate.Buckets[i].CollectibleReference.Name == "" {
+			d.PackTemplate.Buckets[i].CollectibleReference = c.CollectibleReference
+		}
+	}
+
+	if d.AccessAPIHost == "" {
+		d.AccessAPIHost = c.AccessAPIHost
+	}
+
+	if d.RevealWebhookURL == "" {
+		d.RevealWebhookURL = c.RevealWebhookURL
+	}
+
+	return nil
+}
+
+// CollectionStats rolls up the distributions of a collection.
+type CollectionStats struct {
+	DistributionCount    uint
+	DistributionsByState map[common.DistributionState]uint
+
+	PackCount     uint
+	SealedCount   uint // Minted, not revealed
+	RevealedCount uint // Revealed, not opened
+	OpenedCount   uint
+}
+
+// newCollectionStats returns the stats of a collection from the number of
+// its distributions and packs per state.
+func newCollectionStats(distributions map[common.DistributionState]uint, packs map[common.PackState]uint) CollectionStats {
+	stats := CollectionStats{DistributionsByState: distributions}
+
+	for _, count := range distributions {
+		stats.DistributionCount += count
+	}
+
+	// Same grouping as the completion report
+	report := CompletionReport{}
+	report.SetPackCounts(packs, 0)
+
+	stats.PackCount = report.PackCount
+	stats.SealedCount = report.SealedCount
+	stats.RevealedCount = report.RevealedCount
+	stats.OpenedCount = report.OpenedCount
+
+	return stats
+}
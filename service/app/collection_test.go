@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func TestCollectionApply(t *testing.T) {
+	issuer := common.FlowAddressFromString("0x2")
+	packRef := AddressLocation{Name: "PackNFT", Address: common.FlowAddressFromString("0x3")}
+	collectibleRef := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("0x4")}
+	otherRef := AddressLocation{Name: "OtherNFT", Address: common.FlowAddressFromString("0x5")}
+
+	c := Collection{
+		ID:                   uuid.New(),
+		Issuer:               issuer,
+		Name:                 "Season 1",
+		PackReference:        packRef,
+		CollectibleReference: collectibleRef,
+		AccessAPIHost:        "access.example:9000",
+	}
+
+	d := Distribution{
+		Issuer:           issuer,
+		RevealWebhookURL: "https://example.com/reveals",
+		PackTemplate:     PackTemplate{Buckets: []Bucket{{}, {CollectibleReference: otherRef}}},
+	}
+
+	if err := c.Apply(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.CollectionID == nil || *d.CollectionID != c.ID {
+		t.Fatalf("expected the distribution to be added to the collection, got %v", d.CollectionID)
+	}
+	if d.PackTemplate.PackReference != packRef || d.AccessAPIHost != c.AccessAPIHost {
+		t.Fatal("expected the policies of the collection to be filled in")
+	}
+	if d.PackTemplate.Buckets[0].CollectibleReference != collectibleRef || d.PackTemplate.Buckets[1].CollectibleReference != otherRef {
+		t.Fatal("expected only buckets without a collectible reference to use the one of the collection")
+	}
+	if d.RevealWebhookURL != "https://example.com/reveals" {
+		t.Fatal("expected the reveal webhook URL of the distribution to be kept")
+	}
+
+	if err := c.Apply(&Distribution{Issuer: common.FlowAddressFromString("0x6")}); err == nil {
+		t.Fatal("expected an error for a distribution of another issuer")
+	}
+
+	if err := (Collection{Name: "Season 1"}).Validate(); err == nil {
+		t.Fatal("expected an error for a collection without an issuer")
+	}
+	if err := (Collection{Issuer: issuer}).Validate(); err == nil {
+		t.Fatal("expected an error for a collection without a name")
+	}
+}
+
+func TestNewCollectionStats(t *testing.T) {
+	stats := newCollectionStats(
+		map[common.DistributionState]uint{common.DistributionStateComplete: 2, common.DistributionStateMinting: 1},
+		map[common.PackState]uint{common.PackStateInit: 1, common.PackStateSealed: 3, common.PackStateRevealed: 2, common.PackStateOpened: 4},
+	)
+
+	if stats.DistributionCount != 3 {
+		t.Errorf("expected 3 distributions, got %d", stats.DistributionCount)
+	}
+	if stats.PackCount != 10 || stats.SealedCount != 3 || stats.RevealedCount != 2 || stats.OpenedCount != 4 {
+		t.Errorf("unexpected pack counts %+v", stats)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// CommitmentHashVersion is the version of the commitment hash scheme of
+// packs of new distributions, see CommitmentHasher.
+const CommitmentHashVersion uint = 1
+
+// CommitmentHasher is a scheme of computing the commitment hashes of packs,
+// which the pack contract checks the contents of a pack against on reveal.
+// The version of the scheme is stored with each distribution, so packs keep
+// the scheme they were committed with when the pack contract (and the
+// current version) changes. A scheme must never change once released, add
+// another version instead.
+type CommitmentHasher interface {
+	// Version of the scheme, stored with distributions
+	Version() uint
+	// Length of the random salt of a pack in bytes
+	SaltLength() int
+	// Name of the hash algorithm, e.g. 'SHA2-256'
+	Algorithm() string
+	// Preimage returns the input hashed into the commitment hash of 'p'
+	Preimage(p *Pack) []byte
+	// Hash returns the commitment hash of 'p' with its salt
+	Hash(p *Pack) []byte
+}
+
+// commitmentHashers are the supported schemes by version.
+var commitmentHashers = map[uint]CommitmentHasher{
+	1: commitmentHasherV1{},
+}
+
+// GetCommitmentHasher returns the commitment hash scheme of 'version'.
+// Version 0 is version 1, the scheme of distributions created before
+// versions were recorded.
+func GetCommitmentHasher(version uint) (CommitmentHasher, error) {
+	if version == 0 {
+		version = 1
+	}
+	h, ok := commitmentHashers[version]
+	if !ok {
+		return nil, newError(ErrorCodeDistributionInvalid, "unknown commitment hash version %d", version)
+	}
+	return h, nil
+}
+
+// commitmentHasherV1 hashes with SHA2-256 the hex encoded salt followed by
+// the collectibles of a pack in slot order and its fungible token amount
+// (if any), joined by HASH_DELIM.
+// It is converting inputs to string and joining them with a delim to make the input more readable.
+// This will allow anyone to easily copy paste strings and verify the hash.
+// We also use the full reference (address and name) of a collectible to make
+// it more difficult to fiddle with the types of collectibles inside a pack.
+// A fungible token amount of the pack is the last input, in the format of a
+// collectible as that is how it is passed to the pack contract on reveal.
+type commitmentHasherV1 struct{}
+
+func (commitmentHasherV1) Version() uint {
+	return 1
+}
+
+func (commitmentHasherV1) SaltLength() int {
+	return SALT_LENGTH_IN_BYTES
+}
+
+func (commitmentHasherV1) Algorithm() string {
+	return "SHA2-256"
+}
+
+func (commitmentHasherV1) Preimage(p *Pack) []byte {
+	inputs := make([]string, 1+len(p.Collectibles))
+	inputs[0] = hex.EncodeToString(p.Salt)
+	for i, c := range p.Collectibles {
+		inputs[i+1] = c.HashString()
+	}
+	if !p.FungibleToken.IsZero() {
+		inputs = append(inputs, p.FungibleToken.HashString())
+	}
+	return []byte(strings.Join(inputs, HASH_DELIM))
+}
+
+func (h commitmentHasherV1) Hash(p *Pack) []byte {
+	hash := sha256.Sum256(h.Preimage(p))
+	return hash[:]
+}
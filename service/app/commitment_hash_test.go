@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestCommitmentHasherV1(t *testing.T) {
+	h, err := GetCommitmentHasher(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pack{
+		Salt: common.BinaryValue{0x01, 0x02},
+		Collectibles: Collectibles{
+			{ContractReference: AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}, FlowID: common.FlowID{Int64: 1, Valid: true}},
+			{ContractReference: AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}, FlowID: common.FlowID{Int64: 2, Valid: true}},
+		},
+	}
+
+	// SHA2-256 of "0102,A.0000000000000002.Moment.1,A.0000000000000002.Moment.2",
+	// the scheme must never change
+	expected := "bf57403105de9719ddd28a876ffae93c3cf8290df6c8e07015a04cc2688b48a4"
+	if got := hex.EncodeToString(p.Hash(h)); got != expected {
+		t.Errorf("expected hash %s, got %s", expected, got)
+	}
+}
+
+func TestGetCommitmentHasher(t *testing.T) {
+	h, err := GetCommitmentHasher(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version() != 1 {
+		t.Errorf("expected version 0 to be version 1, got %d", h.Version())
+	}
+
+	if _, err := GetCommitmentHasher(CommitmentHashVersion); err != nil {
+		t.Errorf("expected the current version to be supported, got %s", err)
+	}
+
+	if _, err := GetCommitmentHasher(99); err == nil || ErrorCode(err) != ErrorCodeDistributionInvalid {
+		t.Errorf("expected a '%s' error for an unknown version, got %v", ErrorCodeDistributionInvalid, err)
+	}
+}
+
+func TestResolveRecordsCommitmentHashVersion(t *testing.T) {
+	d := makeResolutionDistribution()
+	if err := d.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if d.CommitmentHashVersion != CommitmentHashVersion {
+		t.Errorf("expected version %d, got %d", CommitmentHashVersion, d.CommitmentHashVersion)
+	}
+	if len(d.Packs[0].Salt) != SALT_LENGTH_IN_BYTES {
+		t.Errorf("expected a salt of %d bytes, got %d", SALT_LENGTH_IN_BYTES, len(d.Packs[0].Salt))
+	}
+
+	d = makeResolutionDistribution()
+	d.CommitmentHashVersion = 99
+	if err := d.Resolve(); err == nil {
+		t.Error("expected an error for an unknown commitment hash version")
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CloseReasonAllOpened           = "all-opened"            // Every pack of the distribution has been opened
+	CloseReasonRevealWindowExpired = "reveal-window-expired" // Some packs were left unopened when the reveal window expired
+)
+
+// CompletionReport summarizes a distribution at the time it was closed.
+type CompletionReport struct {
+	gorm.Model
+	ID             uuid.UUID    `gorm:"column:id;primary_key;type:uuid;"`
+	DistributionID uuid.UUID    `gorm:"unique"`
+	Distribution   Distribution `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Reason string `gorm:"column:reason"`
+
+	PackCount                uint `gorm:"column:pack_count"`
+	SealedCount              uint `gorm:"column:sealed_count"`   // Minted, never revealed
+	RevealedCount            uint `gorm:"column:revealed_count"` // Revealed, never opened
+	OpenedCount              uint `gorm:"column:opened_count"`
+	RevokedCount             uint `gorm:"column:revoked_count"`
+	UnopenedCollectibleCount uint `gorm:"column:unopened_collectible_count"` // Collectibles left in escrow
+
+	TransactionCount       uint `gorm:"column:transaction_count"`
+	FailedTransactionCount uint `gorm:"column:failed_transaction_count"` // Failed or dead-letter
+
+	CloseTransactionID uuid.UUID `gorm:"column:close_transaction_id"` // Transaction destroying the shared capabilities onchain
+}
+
+func (CompletionReport) TableName() string {
+	return "distribution_completion_reports"
+}
+
+func (r *CompletionReport) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return nil
+}
+
+// SetPackCounts sets the pack counts of the report from the number of packs
+// per state, 'slotCount' is the number of collectibles per pack.
+func (r *CompletionReport) SetPackCounts(packs map[common.PackState]uint, slotCount int) {
+	r.PackCount, r.SealedCount, r.RevealedCount, r.OpenedCount, r.RevokedCount = 0, 0, 0, 0, 0
+	for state, count := range packs {
+		r.PackCount += count
+		switch state {
+		case common.PackStateSealed, common.PackStateRevealRequestHandled:
+			r.SealedCount += count
+		case common.PackStateRevealed, common.PackStateOpenRequestHandled:
+			r.RevealedCount += count
+		case common.PackStateOpened, common.PackStateEmpty:
+			r.OpenedCount += count
+		case common.PackStateRevoked:
+			r.RevokedCount += count
+		}
+	}
+	r.UnopenedCollectibleCount = (r.SealedCount + r.RevealedCount + r.RevokedCount) * uint(slotCount)
+}
+
+// SetTransactionCounts sets the transaction counts of the report from the
+// number of transactions per state.
+func (r *CompletionReport) SetTransactionCounts(transactions map[common.TransactionState]uint) {
+	r.TransactionCount, r.FailedTransactionCount = 0, 0
+	for state, count := range transactions {
+		r.TransactionCount += count
+		if state == common.TransactionStateFailed || state == common.TransactionStateDeadLetter {
+			r.FailedTransactionCount += count
+		}
+	}
+}
+
+// AllOpened returns true if every pack has been opened, revoked packs never
+// will be.
+func (r *CompletionReport) AllOpened() bool {
+	return r.OpenedCount+r.RevokedCount == r.PackCount
+}
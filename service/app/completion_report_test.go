@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestCompletionReportCounts(t *testing.T) {
+	report := CompletionReport{}
+
+	report.SetPackCounts(map[common.PackState]uint{
+		common.PackStateSealed:               2,
+		common.PackStateRevealRequestHandled: 1,
+		common.PackStateRevealed:             1,
+		common.PackStateOpened:               5,
+		common.PackStateEmpty:                1,
+	}, 3)
+
+	if report.PackCount != 10 || report.SealedCount != 3 || report.RevealedCount != 1 || report.OpenedCount != 6 {
+		t.Errorf("unexpected pack counts: %+v", report)
+	}
+
+	if report.UnopenedCollectibleCount != 12 {
+		t.Errorf("expected 12 unopened collectibles, got %d", report.UnopenedCollectibleCount)
+	}
+
+	if report.AllOpened() {
+		t.Error("expected not all packs to be opened")
+	}
+
+	report.SetPackCounts(map[common.PackState]uint{common.PackStateOpened: 4}, 3)
+
+	if !report.AllOpened() || report.UnopenedCollectibleCount != 0 {
+		t.Errorf("expected all packs to be opened: %+v", report)
+	}
+
+	report.SetTransactionCounts(map[common.TransactionState]uint{
+		common.TransactionStateComplete:   7,
+		common.TransactionStateFailed:     1,
+		common.TransactionStateDeadLetter: 2,
+	})
+
+	if report.TransactionCount != 10 || report.FailedTransactionCount != 3 {
+		t.Errorf("unexpected transaction counts: %+v", report)
+	}
+}
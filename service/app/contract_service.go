@@ -2,12 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/flow-hydraulics/flow-pds/service/config"
 	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
 	"github.com/flow-hydraulics/flow-pds/service/transactions"
 	"github.com/google/uuid"
 	"github.com/onflow/cadence"
@@ -23,26 +27,61 @@ const (
 	REVEALED       = "Revealed"
 	OPEN_REQUEST   = "OpenRequest"
 	OPENED         = "Opened"
+	DEPOSIT        = "Deposit"
+	WITHDRAW       = "Withdraw"
 )
 
 const (
-	SET_DIST_CAP_SCRIPT     = "./cadence-transactions/pds/set_pack_issuer_cap.cdc"
-	SETUP_COLLECTION_SCRIPT = "./cadence-transactions/collectibleNFT/setup_collection_and_link_provider.cdc"
-	SETTLE_SCRIPT           = "./cadence-transactions/pds/settle.cdc"
-	MINT_SCRIPT             = "./cadence-transactions/pds/mint_packNFT.cdc"
-	REVEAL_SCRIPT           = "./cadence-transactions/pds/reveal_packNFT.cdc"
-	OPEN_SCRIPT             = "./cadence-transactions/pds/open_packNFT.cdc"
-	UPDATE_STATE_SCRIPT     = "./cadence-transactions/pds/update_dist_state.cdc"
+	SET_DIST_CAP_SCRIPT                 = "./cadence-transactions/pds/set_pack_issuer_cap.cdc"
+	SETUP_COLLECTION_SCRIPT             = "./cadence-transactions/collectibleNFT/setup_collection_and_link_provider.cdc"
+	SETTLE_SCRIPT                       = "./cadence-transactions/pds/settle.cdc"
+	SETTLE_FUNGIBLE_TOKEN_SCRIPT        = "./cadence-transactions/pds/settle_fungible_token.cdc"
+	MINT_SCRIPT                         = "./cadence-transactions/pds/mint_packNFT.cdc"
+	MINT_WITH_METADATA_SCRIPT           = "./cadence-transactions/pds/mint_packNFT_with_metadata.cdc"
+	REVEAL_SCRIPT                       = "./cadence-transactions/pds/reveal_packNFT.cdc"
+	OPEN_SCRIPT                         = "./cadence-transactions/pds/open_packNFT.cdc"
+	UPDATE_STATE_SCRIPT                 = "./cadence-transactions/pds/update_dist_state.cdc"
+	REVOKE_KEYS_SCRIPT                  = "./cadence-transactions/keys/revoke-keys.cdc"
+	CLOSE_DIST_SCRIPT                   = "./cadence-transactions/pds/close_distribution.cdc"
+	RETURN_ESCROW_SCRIPT                = "./cadence-transactions/pds/return_escrow.cdc"
+	SETUP_ESCROW_VAULT_SCRIPT           = "./cadence-transactions/fungibleToken/setup_escrow_vault.cdc"
+	RETURN_FUNGIBLE_TOKEN_ESCROW_SCRIPT = "./cadence-transactions/pds/return_fungible_token_escrow.cdc"
+	COMMIT_SEED_SCRIPT                  = "./cadence-transactions/pds/commit_seed.cdc"
+	REVEAL_SEED_SCRIPT                  = "./cadence-transactions/pds/reveal_seed.cdc"
+	REVOKE_PACK_SCRIPT                  = "./cadence-transactions/pds/revoke_packNFT.cdc"
+	OWNED_PACK_IDS_SCRIPT               = "./cadence-scripts/packNFT/owned_pack_ids.cdc"
+	PACK_STATUS_SCRIPT                  = "./cadence-scripts/packNFT/pack_status.cdc"
+	OWNED_COLLECTIBLE_IDS_SCRIPT        = "./cadence-scripts/collectibleNFT/owned_collectible_ids.cdc"
+	BLOCK_ID_SCRIPT                     = "./cadence-scripts/pds/get_block_id.cdc"
+	CAN_REVOKE_SCRIPT                   = "./cadence-scripts/pds/can_revoke.cdc"
+	CAN_MINT_WITH_METADATA_SCRIPT       = "./cadence-scripts/pds/can_mint_with_metadata.cdc"
 )
 
 // ContractService handles interfacing with the chain
 type ContractService struct {
 	cfg        *config.Config
-	flowClient *client.Client
-	account    *flow_helpers.Account
+	flowClient flow_helpers.FlowClient
+	clients    *flow_helpers.ClientPool
+	keys       *keyState // Key set of the PDS account to sign with
+	clock      common.Clock
+	// Reference block of sent transactions, fetched from the default client
+	// also for distributions using another Access API host
+	refBlocks *flow_helpers.ReferenceBlockCache
+	// Limits the rate of all sent transactions
+	sendLimiter *SendLimiter
+	lanes       *LaneScheduler
+	// Sizes of settlement and minting batches, adjusted to gas usage
+	settleBatchSizer *BatchSizer
+	mintBatchSizer   *BatchSizer
+	// Builds, signs and sends transactions through an Access API client
+	broadcasters BroadcasterFunc
+	// Degrades the service while the user facing latency SLO is breached
+	slo *SLOGuard
+	// Encrypts pack salts at rest, nil if not configured
+	salts *SaltCipher
 }
 
-func NewContractService(cfg *config.Config, flowClient *client.Client) (*ContractService, error) {
+func NewContractService(cfg *config.Config, flowClient flow_helpers.FlowClient, clock common.Clock) (*ContractService, error) {
 	if cfg.AdminAddress != cfg.PDSAddress {
 		return nil, fmt.Errorf("admin (FLOW_PDS_ADMIN_ADDRESS) and pds (PDS_ADDRESS) addresses should equal")
 	}
@@ -60,47 +99,207 @@ func NewContractService(cfg *config.Config, flowClient *client.Client) (*Contrac
 	if len(flowAccount.Keys) < len(pdsAccount.PKeyIndexes) {
 		return nil, fmt.Errorf("too many key indexes given for admin account")
 	}
-	return &ContractService{cfg, flowClient, pdsAccount}, nil
+	keys, err := newKeyState(cfg, pdsAccount)
+	if err != nil {
+		return nil, err
+	}
+	clients := flow_helpers.NewClientPool(flowClient, cfg, clock)
+	refBlocks := flow_helpers.NewReferenceBlockCache(flowClient, clock, cfg.ReferenceBlockCacheTTL)
+	sendLimiter := NewSendLimiter(clock, cfg.TransactionSendRate, cfg.TransactionSendRatePerAccount, cfg.TransactionSendBurst)
+	lanes := NewLaneScheduler(map[transactions.Priority]uint{
+		transactions.PriorityUserFacing: cfg.TransactionWeightUserFacing,
+		transactions.PrioritySettlement: cfg.TransactionWeightSettlement,
+		transactions.PriorityMinting:    cfg.TransactionWeightMinting,
+	})
+	settleBatchSizer := NewBatchSizer(cfg.SettlementBatchSize)
+	mintBatchSizer := NewBatchSizer(cfg.MintingBatchSize)
+	metrics.SetBatchSize(metrics.OperationSettle, settleBatchSizer.Size())
+	metrics.SetBatchSize(metrics.OperationMint, mintBatchSizer.Size())
+	broadcasters := newFlowBroadcaster(cfg, refBlocks, clock)
+	slo := NewSLOGuard(cfg.UserFacingLatencySLO, cfg.SLODegradationHold)
+	salts, err := NewSaltCipher(cfg.SaltEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ContractService{cfg, flowClient, clients, keys, clock, refBlocks, sendLimiter, lanes, settleBatchSizer, mintBatchSizer, broadcasters, slo, salts}, nil
 }
 
-func (svc *ContractService) SetDistCap(ctx context.Context, db *gorm.DB, issuer common.FlowAddress) error {
-	logger := log.WithFields(log.Fields{
-		"method": "SetDistCap",
-		"issuer": issuer,
-	})
+// Close closes any per-distribution Access API clients
+func (svc *ContractService) Close() error {
+	return svc.clients.Close()
+}
 
-	logger.Info("Set distribution capability")
+// clientFor returns the Access API client to use for the given distribution.
+func (svc *ContractService) clientFor(dist *Distribution) (flow_helpers.FlowClient, error) {
+	return svc.clients.Get(dist.AccessAPIHost)
+}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+// clientForDistributionID returns the Access API client to use for the
+// distribution with the given ID. Returns the default client for uuid.Nil.
+func (svc *ContractService) clientForDistributionID(db *gorm.DB, distributionID uuid.UUID) (flow_helpers.FlowClient, error) {
+	if distributionID == uuid.Nil {
+		return svc.flowClient, nil
+	}
+
+	dist, err := GetDistributionSmall(db, distributionID)
 	if err != nil {
+		return nil, err
+	}
+
+	return svc.clientFor(dist)
+}
+
+// requestID returns the ID of the API request for the logs of handling
+// 'dist': the request 'ctx' serves, if any, otherwise the one which created
+// or last updated 'dist' (e.g. in the poller).
+func requestID(ctx context.Context, dist *Distribution) string {
+	if id := logging.RequestID(ctx); id != "" {
+		return id
+	}
+	return dist.RequestID
+}
+
+// broadcaster returns the Broadcaster sending through 'flowClient'.
+func (svc *ContractService) broadcaster(flowClient flow_helpers.FlowClient) Broadcaster {
+	return svc.broadcasters(flowClient)
+}
+
+// retryPolicy returns how expired and failed transactions are retried.
+func (svc *ContractService) retryPolicy() transactions.RetryPolicy {
+	return transactions.RetryPolicy{
+		MaxAttempts: svc.cfg.TransactionMaxAttempts,
+		Backoff:     svc.cfg.TransactionRetryBackoff,
+		MaxBackoff:  svc.cfg.TransactionRetryMaxBackoff,
+	}
+}
+
+// sendAndWaitForSeal stores 't' and sends it right away instead of leaving
+// it to the poller, blocking until it seals. Retries are waited for in place.
+// The stored state is kept up to date on the way so a transaction left in
+// 'sent' state (e.g. by a restart or a timeout) is handled by the poller like
+// any other.
+func (svc *ContractService) sendAndWaitForSeal(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, t *transactions.StorableTransaction) error {
+	if err := t.Save(db); err != nil {
 		return err
 	}
 
-	txScript, err := flow_helpers.ParseCadenceTemplate(SET_DIST_CAP_SCRIPT, nil)
-	if err != nil {
+	for {
+		err := svc.sendOnceAndWaitForSeal(ctx, db, flowClient, t)
+		if t.State != common.TransactionStateRetry {
+			return err
+		}
+		svc.clock.Sleep(t.SendNotBefore.Sub(svc.clock.Now()))
+	}
+}
+
+func (svc *ContractService) sendOnceAndWaitForSeal(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, t *transactions.StorableTransaction) error {
+	if svc.keys.Frozen() {
+		return ErrSendingFrozen
+	}
+
+	if oversized, err := svc.handleOversized(db, t); err != nil {
 		return err
+	} else if oversized {
+		return errors.New(t.Error)
 	}
 
-	tx := flow.NewTransaction().
-		SetScript(txScript).
-		SetGasLimit(svc.cfg.TransactionGasLimit).
-		SetReferenceBlockID(latestBlockHeader.ID)
+	account := svc.keys.Account()
+
+	svc.sendLimiter.Take(account.Address)
 
-	if err := tx.AddArgument(cadence.Address(issuer)); err != nil {
+	broadcaster := svc.broadcaster(flowClient)
+
+	if err := svc.fillRevealSalt(db, t); err != nil {
 		return err
 	}
 
-	unlockKey, err := flow_helpers.SignProposeAndPayAs(ctx, svc.flowClient, svc.account, tx)
+	if svc.cfg.DryRun {
+		return svc.dryRun(ctx, db, broadcaster, account, t)
+	}
+
+	tx, unlockKey, err := broadcaster.Prepare(ctx, t, account)
 	defer unlockKey()
 	if err != nil {
 		return err
 	}
 
-	if err := svc.flowClient.SendTransaction(ctx, *tx); err != nil {
+	t.TransactionID = tx.ID().Hex()
+	t.State = common.TransactionStateSent
+
+	// Save before sending, same as the poller
+	if err := t.Save(db); err != nil {
+		return err
+	}
+
+	if err := t.InsertAttempt(db, tx); err != nil {
+		return err
+	}
+
+	sendStart := time.Now()
+	err = broadcaster.Send(ctx, tx)
+	metrics.ObserveOperation(metrics.OperationSendTransaction, distributionMetricsByID(db, t.DistributionID), sendStart, err)
+	if err != nil {
+		if flow_helpers.IsRetryableSendError(err) {
+			t.Retry(err.Error(), svc.clock.Now(), svc.retryPolicy())
+		} else {
+			t.State = common.TransactionStateFailed
+			t.Error = err.Error()
+		}
+		return svc.saveWithAttempt(db, t, err)
+	}
+
+	result, err := broadcaster.WaitForSeal(ctx, tx.ID())
+	if result == nil || (err != nil && !errors.Is(err, flow_helpers.ErrTransactionReverted) && !errors.Is(err, flow_helpers.ErrTransactionExpired)) {
+		// Result unknown (e.g. timeout), leave it to the poller
+		return err
+	}
+
+	t.SetResult(result, svc.clock.Now(), svc.retryPolicy())
+
+	if t.State != common.TransactionStateSent && t.State != common.TransactionStateRetry {
+		metrics.CountOperation(metrics.OperationTransaction, distributionMetricsByID(db, t.DistributionID), metrics.FlowErrorCode(t.Error))
+	}
+
+	return svc.saveWithAttempt(db, t, err)
+}
+
+// saveWithAttempt saves 't' and the outcome of its current attempt,
+// returns 'err' if saving succeeds.
+// A retry is not saved to the transaction as the poller would pick it up
+// too, it stays 'sent' (and is resolved by the poller after a restart) until
+// resent.
+func (svc *ContractService) saveWithAttempt(db *gorm.DB, t *transactions.StorableTransaction, err error) error {
+	if saveErr := t.UpdateAttempt(db); saveErr != nil {
+		return saveErr
+	}
+	if t.State == common.TransactionStateRetry {
+		return err
+	}
+	if saveErr := t.Save(db); saveErr != nil {
+		return saveErr
+	}
+	return err
+}
+
+func (svc *ContractService) SetDistCap(ctx context.Context, db *gorm.DB, issuer common.FlowAddress) error {
+	logger := log.WithFields(log.Fields{
+		"method": "SetDistCap",
+		"issuer": issuer,
+	})
+
+	logger.Info("Set distribution capability")
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(SET_DIST_CAP_SCRIPT, nil)
+	if err != nil {
+		return err
+	}
+
+	t, err := transactions.NewTransaction(SET_DIST_CAP_SCRIPT, txScript, []cadence.Value{cadence.Address(issuer)})
+	if err != nil {
 		return err
 	}
 
-	if _, err := flow_helpers.WaitForSeal(ctx, svc.flowClient, tx.ID(), time.Minute*10); err != nil {
+	if err := svc.sendAndWaitForSeal(ctx, db, svc.flowClient, t); err != nil {
 		return err
 	}
 
@@ -110,27 +309,64 @@ func (svc *ContractService) SetDistCap(ctx context.Context, db *gorm.DB, issuer
 }
 
 // SetupDistribution will make sure the PDS account has a collection onchain
-// for the collectible NFTs in the Distribution.
+// for the collectible NFTs in the Distribution, and a vault for the fungible
+// token of its packs if any.
 // It also makes sure the withdraw capability is linked.
 func (svc *ContractService) SetupDistribution(ctx context.Context, db *gorm.DB, dist *Distribution) error {
 	logger := log.WithFields(log.Fields{
 		"method":     "SetupDistribution",
 		"distID":     dist.ID,
 		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
 	})
 
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err // rollback
+	}
+
 	logger.Info("Setup distribution")
 
+	// Reject the distribution before anything is escrowed if the issuer does
+	// not own all of its collectibles
+	if svc.cfg.CollectibleOwnershipCheck {
+		check, err := svc.checkCollectibleOwnership(ctx, db, dist)
+		if err != nil {
+			return err // rollback
+		}
+
+		if !check.Passed() {
+			logger.WithFields(log.Fields{
+				"checkedCount": check.CheckedCount,
+				"missingCount": len(check.Missing),
+			}).Warn("Issuer does not own all collectibles, aborting distribution")
+
+			return svc.Abort(ctx, db, dist, false)
+		}
+	}
+
 	// Make sure the distribution is in correct state
 	if err := dist.SetSetup(); err != nil {
 		return err // rollback
 	}
 
+	// Commit to the secret of the seed before anything is escrowed
+	if dist.SeedCommitReveal {
+		if err := svc.commitSeed(ctx, db, flowClient, dist); err != nil {
+			return err // rollback
+		}
+	}
+
 	// Update the distribution in database
 	if err := UpdateDistribution(db, dist); err != nil {
 		return err // rollback
 	}
 
+	// Notify the webhooks of the issuer
+	if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+		return err // rollback
+	}
+
 	buckets, err := GetDistributionBucketsSmall(db, dist.ID)
 	if err != nil {
 		return err // rollback
@@ -147,11 +383,6 @@ func (svc *ContractService) SetupDistribution(ctx context.Context, db *gorm.DB,
 			"contract_address": contract.Address,
 		}).Debug("Setting up collection and linking")
 
-		latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
-		if err != nil {
-			return err // rollback
-		}
-
 		txScript, err := flow_helpers.ParseCadenceTemplate(
 			SETUP_COLLECTION_SCRIPT,
 			&flow_helpers.CadenceTemplateVars{
@@ -163,36 +394,32 @@ func (svc *ContractService) SetupDistribution(ctx context.Context, db *gorm.DB,
 			return err // rollback
 		}
 
-		tx := flow.NewTransaction().
-			SetScript(txScript).
-			SetGasLimit(svc.cfg.TransactionGasLimit).
-			SetReferenceBlockID(latestBlockHeader.ID)
-
-		if err := tx.AddArgument(cadence.Path{Domain: "private", Identifier: contract.ProviderPath()}); err != nil {
-			return err
+		arguments := []cadence.Value{
+			cadence.Path{Domain: "private", Identifier: contract.ProviderPath()},
 		}
 
-		// Use anon function here to allow defer as soon as possible
-		err = func() error {
-			unlockKey, err := flow_helpers.SignProposeAndPayAs(ctx, svc.flowClient, svc.account, tx)
-			defer unlockKey()
-			if err != nil {
-				return err // rollback
-			}
-
-			if err := svc.flowClient.SendTransaction(ctx, *tx); err != nil {
-				return err // rollback
-			}
+		t, err := transactions.NewTransactionWithDistributionID(SETUP_COLLECTION_SCRIPT, txScript, arguments, dist.ID)
+		if err != nil {
+			return err // rollback
+		}
 
-			if _, err := flow_helpers.WaitForSeal(ctx, svc.flowClient, tx.ID(), time.Minute*10); err != nil {
-				return err // rollback
-			}
+		if err := svc.sendAndWaitForSeal(ctx, db, flowClient, t); err != nil {
+			return err // rollback
+		}
+	}
 
-			return nil
-		}()
+	if !dist.PackTemplate.FungibleToken.IsZero() {
+		logger.WithFields(log.Fields{
+			"token": dist.PackTemplate.FungibleToken.ContractReference.String(),
+		}).Debug("Setting up fungible token escrow vault")
 
+		t, err := newSetupEscrowVaultTransaction(dist)
 		if err != nil {
-			return err
+			return err // rollback
+		}
+
+		if err := svc.sendAndWaitForSeal(ctx, db, flowClient, t); err != nil {
+			return err // rollback
 		}
 	}
 
@@ -206,15 +433,35 @@ func (svc *ContractService) SetupDistribution(ctx context.Context, db *gorm.DB,
 // It lists all collectible NFTs in the distribution and creates batches
 // of 'SETTLE_BATCH_SIZE' from them.
 // It then creates and stores the settlement Flow transactions (PDS account withdraw from issuer to escrow) in
-// database to be later processed by a poller.
+// database to be later processed by a poller, including one for the
+// fungible tokens of the packs if they hold any.
 // Batching needs to be done to control the transaction size.
+// If 'SettlementMaxPendingBatches' is set only that many batches are queued
+// here, UpdateSettlementStatus queues the rest as earlier ones finish.
 func (svc *ContractService) StartSettlement(ctx context.Context, db *gorm.DB, dist *Distribution) error {
-	logger := log.WithFields(log.Fields{
+	logger := logging.Logger(logging.Settlement).WithFields(log.Fields{
 		"method":     "StartSettlement",
 		"distID":     dist.ID,
 		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
 	})
 
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err // rollback
+	}
+
+	// The packs of a commit-reveal distribution are resolved again once its
+	// seed is anchored, until then it stays set up
+	anchored, err := svc.resolveAnchoredSeed(ctx, db, flowClient, dist)
+	if err != nil {
+		return err // rollback
+	}
+	if !anchored {
+		logger.WithFields(log.Fields{"anchorHeight": dist.SeedAnchorHeight}).Trace("Waiting for the seed anchor block")
+		return nil
+	}
+
 	logger.Info("Start settlement")
 
 	// Make sure the distribution is in correct state
@@ -227,7 +474,12 @@ func (svc *ContractService) StartSettlement(ctx context.Context, db *gorm.DB, di
 		return err // rollback
 	}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+	// Notify the webhooks of the issuer
+	if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+		return err // rollback
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
 	if err != nil {
 		return err // rollback
 	}
@@ -281,52 +533,20 @@ func (svc *ContractService) StartSettlement(ctx context.Context, db *gorm.DB, di
 		return err // rollback
 	}
 
-	err = NotSettledCollectiblesInBatches(db, settlement.ID, svc.cfg.SettlementBatchSize, func(tx *gorm.DB, batchNumber int, batch SettlementCollectibles) error {
-		for contract, collectibles := range batch.GroupByContract() {
-			txScript, err := flow_helpers.ParseCadenceTemplate(
-				SETTLE_SCRIPT,
-				&flow_helpers.CadenceTemplateVars{
-					CollectibleNFTName:    contract.Name,
-					CollectibleNFTAddress: contract.Address.String(),
-				},
-			)
-			if err != nil {
-				return err // rollback
-			}
-
-			batchLogger := logger.WithFields(log.Fields{
-				"batchNumber": batchNumber,
-			})
-
-			batchLogger.Debug("Initiating settle transaction")
-
-			flowIDs := make([]cadence.Value, len(collectibles))
-			for i, c := range batch {
-				flowIDs[i] = cadence.UInt64(c.FlowID.Int64)
-			}
-
-			arguments := []cadence.Value{
-				cadence.UInt64(dist.FlowID.Int64),
-				cadence.NewArray(flowIDs),
-			}
-
-			t, err := transactions.NewTransactionWithDistributionID(SETTLE_SCRIPT, txScript, arguments, dist.ID)
-			if err != nil {
-				return err // rollback
-			}
-
-			if err := t.Save(db); err != nil {
-				return err // rollback
-			}
+	if _, err := svc.queueSettleBatches(db, dist, &settlement, svc.cfg.SettlementMaxPendingBatches); err != nil {
+		return err // rollback
+	}
 
-			batchLogger.Trace("Settle transaction saved")
+	if !dist.PackTemplate.FungibleToken.IsZero() {
+		// The fungible tokens of all packs are escrowed in one transaction
+		t, err := newSettleFungibleTokenTransaction(dist)
+		if err != nil {
+			return err // rollback
 		}
 
-		return nil
-	})
-
-	if err != nil {
-		return err // rollback
+		if err := t.Save(db); err != nil {
+			return err // rollback
+		}
 	}
 
 	logger.Trace("Start settlement complete")
@@ -334,354 +554,1476 @@ func (svc *ContractService) StartSettlement(ctx context.Context, db *gorm.DB, di
 	return nil // commit
 }
 
-// StartMinting sets the given distributions state to 'minting' and starts the minting
-// phase onchain.
-// It creates a CirculatingPackContract to allow onchain monitoring
-// (listening for events) of any pack that has been put to circulation.
-// It then lists all Pack NFTs in the distribution and creates batches
-// of 'MINT_BATCH_SIZE' from them.
-// It then creates and stores the minting Flow transactions in database to be
-// later processed by a poller.
-// Batching needs to be done to control the transaction size.
-func (svc *ContractService) StartMinting(ctx context.Context, db *gorm.DB, dist *Distribution) error {
-	logger := log.WithFields(log.Fields{
-		"method":     "StartMinting",
+// queueSettleBatches creates and stores settle transactions for collectibles
+// of 'settlement' not yet queued, batches of 'SettlementBatchSize' (or less
+// if adjusted to gas usage) per
+// collectible contract, at most 'maxBatches' (0 means no limit).
+// The transactions withdraw from the issuer using the provider capability the
+// issuer shared with the PDS when the distribution was created.
+// Returns the number of transactions created.
+func (svc *ContractService) queueSettleBatches(db *gorm.DB, dist *Distribution, settlement *Settlement, maxBatches int) (int, error) {
+	logger := logging.Logger(logging.Settlement).WithFields(log.Fields{
+		"method":     "queueSettleBatches",
 		"distID":     dist.ID,
 		"distFlowID": dist.FlowID,
+		"requestID":  dist.RequestID,
 	})
 
-	logger.Info("Start minting")
+	queued := 0
 
-	// Make sure the distribution is in correct state
-	if err := dist.SetMinting(); err != nil {
-		return err // rollback
-	}
+	for maxBatches <= 0 || queued < maxBatches {
+		batch, err := NotQueuedSettlementCollectibles(db, settlement.ID, svc.batchSize(svc.settleBatchSizer))
+		if err != nil {
+			return queued, err
+		}
 
-	// Update the distribution in database
-	if err := UpdateDistribution(db, dist); err != nil {
-		return err // rollback
-	}
+		if len(batch) == 0 {
+			break
+		}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
-	if err != nil {
-		return err // rollback
-	}
+		// Collectibles of the first contract only, the rest go to later batches
+		collectibles := batch.GroupByContract()[batch[0].ContractReference]
+		contract := batch[0].ContractReference
 
-	// Init a CirculatingPackContract
-	cpc := CirculatingPackContract{
-		Name:         dist.PackTemplate.PackReference.Name,
-		Address:      dist.PackTemplate.PackReference.Address,
-		StartAtBlock: latestBlockHeader.Height - 1,
-	}
+		batchLogger := logger.WithFields(log.Fields{
+			"batchNumber": queued + 1,
+			"contract":    contract.String(),
+		})
 
-	// Try to find an existing one (CirculatingPackContract)
-	if existing, err := GetCirculatingPackContract(db, cpc.Name, cpc.Address); err != nil {
-		// Insert the newly initialized if not found
-		if err := InsertCirculatingPackContract(db, &cpc); err != nil {
-			return err // rollback
-		}
-	} else { // err == nil, existing found
-		if cpc.StartAtBlock < existing.StartAtBlock {
-			// Situation where a new cpc has lower blockheight (LastCheckedBlock) than an old one.
-			// Should not happen in production but can happen in tests.
+		batchLogger.Debug("Initiating settle transaction")
 
-			logger.WithFields(log.Fields{
-				"existingID":      existing.ID,
-				"existingName":    existing.Name,
-				"existingAddress": existing.Address,
-				"existingStart":   existing.StartAtBlock,
-				"newID":           cpc.ID,
-				"newName":         cpc.Name,
-				"newAddress":      cpc.Address,
-				"newStart":        cpc.StartAtBlock,
-			}).Warn("CirculatingPackContract with higher block height found in database, should not happen in production")
+		t, err := newSettleTransaction(dist, contract, collectibles)
+		if err != nil {
+			return queued, err
+		}
 
-			existing.StartAtBlock = cpc.StartAtBlock
-			if err := UpdateCirculatingPackContract(db, existing); err != nil {
-				return err // rollback
-			}
+		if err := t.Save(db); err != nil {
+			return queued, err
+		}
 
-			// Use the existing one from now on
-			cpc = *existing
+		if err := SetSettlementCollectiblesQueued(db, collectibles); err != nil {
+			return queued, err
 		}
-	}
 
-	minting := Minting{
-		DistributionID: dist.ID,
-		CurrentCount:   0,
-		TotalCount:     0, // Update later
-		StartAtBlock:   latestBlockHeader.Height - 1,
-	}
+		queued++
 
-	if err := InsertMinting(db, &minting); err != nil {
-		return err // rollback
+		batchLogger.Trace("Settle transaction saved")
 	}
 
-	totalPackCount := 0
-
-	err = DistributionPacksInBatches(db, dist.ID, svc.cfg.MintingBatchSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
-		totalPackCount += len(batch)
+	return queued, nil
+}
 
-		txScript, err := flow_helpers.ParseCadenceTemplate(
-			MINT_SCRIPT,
-			&flow_helpers.CadenceTemplateVars{
-				PackNFTName:    dist.PackTemplate.PackReference.Name,
-				PackNFTAddress: dist.PackTemplate.PackReference.Address.String(),
-			},
-		)
-		if err != nil {
-			return err
-		}
+// newSettleTransaction returns a settle transaction withdrawing 'collectibles'
+// (all of 'contract') from the issuer to escrow, using the withdraw
+// capability the issuer shared for 'contract' (if any).
+func newSettleTransaction(dist *Distribution, contract AddressLocation, collectibles SettlementCollectibles) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(
+		SETTLE_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			CollectibleNFTName:    contract.Name,
+			CollectibleNFTAddress: contract.Address.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		batchLogger := logger.WithFields(log.Fields{
-			"batchNumber": batchNumber,
-		})
+	flowIDs := make([]cadence.Value, len(collectibles))
+	for i, c := range collectibles {
+		flowIDs[i] = cadence.UInt64(c.FlowID.Int64)
+	}
 
-		batchLogger.Debug("Initiating mint transaction")
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.NewArray(flowIDs),
+		cadence.String(contract.String()),
+	}
 
-		commitmentHashes := make([]cadence.Value, len(batch))
-		for i, p := range batch {
-			commitmentHashes[i] = cadence.NewString(p.CommitmentHash.String())
-		}
+	t, err := transactions.NewTransactionWithDistributionID(SETTLE_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return nil, err
+	}
 
-		arguments := []cadence.Value{
-			cadence.UInt64(dist.FlowID.Int64),
-			cadence.NewArray(commitmentHashes),
-			cadence.Address(dist.Issuer),
-		}
+	t.BatchSize = len(collectibles)
 
-		t, err := transactions.NewTransactionWithDistributionID(MINT_SCRIPT, txScript, arguments, dist.ID)
-		if err != nil {
-			return err // rollback
-		}
+	return t, nil
+}
 
-		if err := t.Save(db); err != nil {
-			return err // rollback
-		}
+// newMintTransaction returns a mint transaction minting 'packs' to the issuer.
+func newMintTransaction(dist *Distribution, packs []Pack) (*transactions.StorableTransaction, error) {
+	// Packs minted with metadata use another script, the transaction is
+	// handled as any mint transaction
+	script := MINT_SCRIPT
+	if dist.Metadata.OnChain {
+		script = MINT_WITH_METADATA_SCRIPT
+	}
 
-		batchLogger.Trace("Mint transaction saved")
+	txScript, err := flow_helpers.ParseCadenceTemplate(
+		script,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    dist.PackTemplate.PackReference.Name,
+			PackNFTAddress: dist.PackTemplate.PackReference.Address.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
-	})
+	commitmentHashes := make([]cadence.Value, len(packs))
+	for i, p := range packs {
+		commitmentHashes[i] = cadence.NewString(p.CommitmentHash.String())
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.NewArray(commitmentHashes),
+		cadence.Address(dist.Issuer),
+	}
+
+	if dist.Metadata.OnChain {
+		arguments = append(arguments, dist.Metadata.cadenceValue())
+	}
 
+	t, err := transactions.NewTransactionWithDistributionID(MINT_SCRIPT, txScript, arguments, dist.ID)
 	if err != nil {
-		return err // rollback
+		return nil, err
 	}
 
-	minting.TotalCount = uint(totalPackCount)
+	t.BatchSize = len(packs)
+	t.Priority = transactions.PriorityMinting
 
-	if err := UpdateMinting(db, &minting); err != nil {
-		return err // rollback
+	return t, nil
+}
+
+// packCollectibleArguments returns the contract addresses, contract names,
+// IDs and contract identifiers of the collectibles of 'pack' as arguments of
+// a reveal or open transaction. A fungible token amount of the pack is
+// passed last as a collectible of the token contract whose ID is the amount,
+// matching the commitment hash.
+func packCollectibleArguments(pack *Pack) []cadence.Value {
+	collectibleCount := len(pack.Collectibles)
+	collectibleContractAddresses := make([]cadence.Value, collectibleCount)
+	collectibleContractNames := make([]cadence.Value, collectibleCount)
+	collectibleIDs := make([]cadence.Value, collectibleCount)
+	collectibleCollections := make([]cadence.Value, collectibleCount)
+
+	for i, c := range pack.Collectibles {
+		collectibleContractAddresses[i] = cadence.Address(c.ContractReference.Address)
+		collectibleContractNames[i] = cadence.String(c.ContractReference.Name)
+		collectibleIDs[i] = cadence.UInt64(c.FlowID.Int64)
+		collectibleCollections[i] = cadence.String(c.ContractReference.String())
 	}
 
-	logger.Trace("Start minting complete")
+	if ft := pack.FungibleToken; !ft.IsZero() {
+		collectibleContractAddresses = append(collectibleContractAddresses, cadence.Address(ft.ContractReference.Address))
+		collectibleContractNames = append(collectibleContractNames, cadence.String(ft.ContractReference.Name))
+		collectibleIDs = append(collectibleIDs, cadence.UInt64(ft.Amount))
+		collectibleCollections = append(collectibleCollections, cadence.String(ft.ContractReference.String()))
+	}
 
-	return nil // commit
+	return []cadence.Value{
+		cadence.NewArray(collectibleContractAddresses),
+		cadence.NewArray(collectibleContractNames),
+		cadence.NewArray(collectibleIDs),
+		cadence.NewArray(collectibleCollections),
+	}
 }
 
-// Abort a distribution
-func (svc *ContractService) Abort(ctx context.Context, db *gorm.DB, dist *Distribution) error {
-	logger := log.WithFields(log.Fields{
-		"method":     "Abort",
-		"distID":     dist.ID,
-		"distFlowID": dist.FlowID,
-	})
+// packProviderPathsArgument returns the escrow provider path of each
+// collectible contract of 'pack', by contract identifier, as an argument of a
+// reveal or open transaction.
+func packProviderPathsArgument(pack *Pack) cadence.Value {
+	contracts := pack.CollectibleContracts()
+	paths := make([]cadence.KeyValuePair, len(contracts))
+	for i, contract := range contracts {
+		paths[i] = cadence.KeyValuePair{
+			Key:   cadence.String(contract.String()),
+			Value: cadence.Path{Domain: "private", Identifier: contract.ProviderPath()},
+		}
+	}
+	return cadence.NewDictionary(paths)
+}
 
-	logger.Info("Abort")
+// packTemplateVars returns the template variables of a reveal or open
+// transaction of 'pack', importing each of its collectible contracts (and
+// the fungible token contract if the pack holds a token amount).
+func packTemplateVars(pack *Pack) *flow_helpers.CadenceTemplateVars {
+	contracts := pack.CollectibleContracts()
+	collectibleNFTs := make([]flow_helpers.CadenceContract, len(contracts))
+	for i, contract := range contracts {
+		collectibleNFTs[i] = flow_helpers.CadenceContract{
+			Name:       contract.Name,
+			Address:    contract.Address.String(),
+			Identifier: contract.String(),
+		}
+	}
 
-	// Make sure the distribution is in correct state
-	if err := dist.SetInvalid(); err != nil {
-		return err // rollback
+	vars := &flow_helpers.CadenceTemplateVars{
+		PackNFTName:     pack.ContractReference.Name,
+		PackNFTAddress:  pack.ContractReference.Address.String(),
+		CollectibleNFTs: collectibleNFTs,
 	}
 
-	// Update the distribution in database
-	if err := UpdateDistribution(db, dist); err != nil {
-		return err // rollback
+	if !pack.FungibleToken.IsZero() {
+		vars.TokenName = pack.FungibleToken.ContractReference.Name
+		vars.TokenAddress = pack.FungibleToken.ContractReference.Address.String()
 	}
 
-	// Update distribution state onchain
+	return vars
+}
 
-	txScript, err := flow_helpers.ParseCadenceTemplate(UPDATE_STATE_SCRIPT, nil)
+// Index of the salt argument of reveal transactions
+const revealSaltArgument = 6
+
+// newRevealTransaction returns a transaction revealing 'pack' of 'dist',
+// also opening it to 'owner' if the pack is already revealed and
+// 'openRequest' is set. The salt is left out of the stored arguments,
+// fillRevealSalt fills it in when sending.
+func newRevealTransaction(dist *Distribution, pack *Pack, owner common.FlowAddress, openRequest bool) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(REVEAL_SCRIPT, packTemplateVars(pack))
 	if err != nil {
-		return err // rollback
+		return nil, err
 	}
 
 	arguments := []cadence.Value{
 		cadence.UInt64(dist.FlowID.Int64),
-		cadence.UInt8(1),
+		cadence.UInt64(pack.FlowID.Int64),
 	}
+	arguments = append(arguments, packCollectibleArguments(pack)...)
+	arguments = append(arguments,
+		cadence.String(""), // Salt, see revealSaltArgument
+		cadence.Address(owner),
+		cadence.NewBool(openRequest),
+		packProviderPathsArgument(pack),
+	)
+	arguments = append(arguments, packFungibleTokenPathsArguments(dist, pack)...)
 
-	t, err := transactions.NewTransactionWithDistributionID(UPDATE_STATE_SCRIPT, txScript, arguments, dist.ID)
+	t, err := transactions.NewTransactionWithDistributionID(REVEAL_SCRIPT, txScript, arguments, dist.ID)
 	if err != nil {
-		return err // rollback
-	}
-
-	if err := t.Save(db); err != nil {
-		return err // rollback
+		return nil, err
 	}
 
-	logger.WithFields(log.Fields{
-		"state":    1,
-		"stateStr": "invalid",
-	}).Info("Distribution state update transaction saved")
+	t.PackID = pack.ID
+	t.Priority = transactions.PriorityUserFacing
 
-	return nil // commit
+	return t, nil
 }
 
-// UpdateSettlementStatus polls for 'Deposit' events regarding the given distributions
-// collectible NFTs.
-// It updates the settelement status in database accordingly.
-func (svc *ContractService) UpdateSettlementStatus(ctx context.Context, db *gorm.DB, dist *Distribution) error {
-	logger := log.WithFields(log.Fields{
-		"method":     "UpdateSettlementStatus",
-		"distID":     dist.ID,
-		"distFlowID": dist.FlowID,
-	})
+// fillRevealSalt decrypts the salt of the pack of reveal transaction 't' and
+// sets it to be sent with it, without storing it. Other transactions are left
+// as they are.
+func (svc *ContractService) fillRevealSalt(db *gorm.DB, t *transactions.StorableTransaction) error {
+	if t.Name != REVEAL_SCRIPT {
+		return nil
+	}
 
-	logger.Trace("Update settlement status")
+	pack, err := GetPack(db, t.PackID)
+	if err != nil {
+		return fmt.Errorf("error while getting pack of reveal transaction: %w", err)
+	}
 
-	settlement, err := GetDistributionSettlement(db, dist.ID)
+	salt, err := svc.salts.PackSalt(pack)
 	if err != nil {
-		return err // rollback
+		return err
 	}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+	args, err := withRevealSalt(t, cadence.String(salt.String()))
 	if err != nil {
-		return err // rollback
+		return err
 	}
 
-	begin := settlement.StartAtBlock + 1
-	end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+	t.SetSendArguments(args)
 
-	logger = logger.WithFields(log.Fields{
-		"blockBegin": begin,
-		"blockEnd":   end,
-	})
+	return nil
+}
 
-	if begin > end {
-		// Nothing to update
-		logger.Trace("No blocks to handle")
-		return nil // commit
+// withRevealSalt returns the arguments of reveal transaction 't' with the
+// salt argument set to 'salt'.
+func withRevealSalt(t *transactions.StorableTransaction, salt cadence.String) ([]cadence.Value, error) {
+	args, err := t.ArgumentsAsCadence()
+	if err != nil {
+		return nil, err
 	}
 
-	err = NotSettledCollectiblesInBatches(db, settlement.ID, svc.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch SettlementCollectibles) error {
-		for contract, collectibles := range batch.GroupByContract() {
-			arr, err := svc.flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
-				Type:        fmt.Sprintf("%s.Deposit", contract.String()),
-				StartHeight: begin,
-				EndHeight:   end,
-			})
-			if err != nil {
-				return err
-			}
-
-			for _, be := range arr {
-				for _, e := range be.Events {
-					eventLogger := logger.WithFields(log.Fields{"eventType": e.Type, "eventID": e.ID()})
+	if len(args) <= revealSaltArgument {
+		return nil, fmt.Errorf("reveal transaction has no argument at index %d", revealSaltArgument)
+	}
 
-					eventLogger.Trace("Handling event")
+	args[revealSaltArgument] = salt
 
-					evtValueMap := flow_helpers.EventValuesToMap(e)
+	return args, nil
+}
 
-					collectibleFlowIDCadence, ok := evtValueMap["id"]
-					if !ok {
-						err := fmt.Errorf("could not read 'id' from event %s", e)
-						return err
-					}
+// newOpenTransaction returns a transaction opening the revealed 'pack' of
+// 'dist', releasing its collectibles (and fungible tokens) from escrow to
+// 'owner'.
+func newOpenTransaction(dist *Distribution, pack *Pack, owner common.FlowAddress) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(OPEN_SCRIPT, packTemplateVars(pack))
+	if err != nil {
+		return nil, err
+	}
 
-					collectibleFlowID, err := common.FlowIDFromCadence(collectibleFlowIDCadence)
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.UInt64(pack.FlowID.Int64),
+	}
+	arguments = append(arguments, packCollectibleArguments(pack)...)
+	arguments = append(arguments,
+		cadence.Address(owner),
+		packProviderPathsArgument(pack),
+	)
+	arguments = append(arguments, packFungibleTokenPathsArguments(dist, pack)...)
 
-					if err != nil {
-						return err
-					}
+	t, err := transactions.NewTransactionWithDistributionID(OPEN_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return nil, err
+	}
 
-					addressCadence, ok := evtValueMap["to"]
-					if !ok {
-						err := fmt.Errorf("could not read 'to' from event %s", e)
-						return err
-					}
+	t.PackID = pack.ID
+	t.Priority = transactions.PriorityUserFacing
 
-					address, err := common.FlowAddressFromCadence(addressCadence)
-					if err != nil {
-						return err
-					}
+	return t, nil
+}
 
-					if address == settlement.EscrowAddress {
-						if i, ok := collectibles.ContainsID(collectibleFlowID); ok {
-							// Collectible in "collectibles" at index "i"
+// saveRevealTransactions stores a transaction revealing 'pack' of 'dist' and
+// returns it. The pack contract refuses to reveal before the time lock
+// passes, the reveal is scheduled for when it does. If 'openRequest' is set
+// a second identical transaction is stored, opening the pack once revealed.
+func (svc *ContractService) saveRevealTransactions(db *gorm.DB, dist *Distribution, pack *Pack, owner common.FlowAddress, openRequest bool, logger *log.Entry) (*transactions.StorableTransaction, error) {
+	// Fail the request if the salt can not be decrypted when sending
+	if _, err := svc.salts.PackSalt(pack); err != nil {
+		return nil, err
+	}
 
-							// Make sure the collectible is in correct state
-							if err := collectibles[i].SetSettled(); err != nil {
-								return err
-							}
+	t, err := newRevealTransaction(dist, pack, owner, openRequest)
+	if err != nil {
+		return nil, err
+	}
 
-							// Update the collectible in database
-							if err := UpdateSettlementCollectible(db, &collectibles[i]); err != nil {
-								return err
-							}
+	if err := dist.PackTemplate.CheckRevealLock(svc.clock.Now()); errors.Is(err, ErrRevealLocked) {
+		t.SendNotBefore = dist.PackTemplate.RevealNotBefore
+		logger.WithFields(log.Fields{"error": err}).Info("Reveal requested before time lock, scheduling reveal")
+	}
 
-							settlement.IncrementCount()
-						}
-					}
+	if err := t.Save(db); err != nil {
+		return nil, err
+	}
 
-					eventLogger.Trace("Handling event complete")
-				}
-			}
+	if openRequest { // NOTE: This block should run only if we want to reveal AND open the pack
+		// Reset the ID to save a second indentical transaction
+		open := *t
+		open.ID = uuid.Nil
+		if err := open.Save(db); err != nil {
+			return nil, err
 		}
+	}
 
-		return nil
+	return t, nil
+}
+
+// StartMinting sets the given distributions state to 'minting' and starts the minting
+// phase onchain.
+// It creates a CirculatingPackContract to allow onchain monitoring
+// (listening for events) of any pack that has been put to circulation.
+// It then lists all Pack NFTs in the distribution and creates batches
+// of 'MINT_BATCH_SIZE' from them.
+// It then creates and stores the minting Flow transactions in database to be
+// later processed by a poller.
+// Batching needs to be done to control the transaction size.
+// If 'MintingMaxPendingBatches' is set only that many batches are queued
+// here, UpdateMintingStatus queues the rest as earlier ones finish.
+func (svc *ContractService) StartMinting(ctx context.Context, db *gorm.DB, dist *Distribution) error {
+	logger := logging.Logger(logging.Minting).WithFields(log.Fields{
+		"method":     "StartMinting",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
 	})
 
+	flowClient, err := svc.clientFor(dist)
 	if err != nil {
 		return err // rollback
 	}
 
-	if settlement.IsComplete() {
-		// TODO: consider updating the distribution separately
+	logger.Info("Start minting")
 
-		// Make sure the distribution is in correct state
-		if err := dist.SetSettled(); err != nil {
-			return err // rollback
-		}
+	// Make sure the distribution is in correct state
+	if err := dist.SetMinting(); err != nil {
+		return err // rollback
+	}
 
-		// Update the distribution in database
-		if err := UpdateDistribution(db, dist); err != nil {
+	// Mint without the metadata if the PackNFT contract can not store it
+	if dist.Metadata.OnChain {
+		canMint, err := svc.distributionSupports(ctx, flowClient, dist, CAN_MINT_WITH_METADATA_SCRIPT)
+		if err != nil {
 			return err // rollback
 		}
+		if !canMint {
+			logger.Warn("Distribution has no metadata operator, minting packs without metadata")
+			dist.Metadata.OnChain = false
+		}
+	}
 
-		logger.Info("Settlement complete")
+	// Update the distribution in database
+	if err := UpdateDistribution(db, dist); err != nil {
+		return err // rollback
 	}
 
-	settlement.StartAtBlock = end
+	// Notify the webhooks of the issuer
+	if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+		return err // rollback
+	}
 
-	// Update the settlement status in database
-	if err := UpdateSettlement(db, settlement); err != nil {
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
 		return err // rollback
 	}
 
-	logger.Trace("Update settlement status complete")
+	// Init a CirculatingPackContract
+	cpc := CirculatingPackContract{
+		Name:         dist.PackTemplate.PackReference.Name,
+		Address:      dist.PackTemplate.PackReference.Address,
+		StartAtBlock: latestBlockHeader.Height - 1,
+	}
 
-	return nil // commit
-}
+	// Try to find an existing one (CirculatingPackContract)
+	if existing, err := GetCirculatingPackContract(db, cpc.Name, cpc.Address); err != nil {
+		// Insert the newly initialized if not found
+		if err := InsertCirculatingPackContract(db, &cpc); err != nil {
+			return err // rollback
+		}
+	} else { // err == nil, existing found
+		if cpc.StartAtBlock < existing.StartAtBlock {
+			// Situation where a new cpc has lower blockheight (LastCheckedBlock) than an old one.
+			// Should not happen in production but can happen in tests.
 
-// UpdateMintingStatus polls for 'Mint' events regarding the given distributions
-// Pack NFTs.
-// It updates the minting status in database accordingly.
-func (svc *ContractService) UpdateMintingStatus(ctx context.Context, db *gorm.DB, dist *Distribution) error {
-	logger := log.WithFields(log.Fields{
-		"method":     "UpdateMintingStatus",
-		"distID":     dist.ID,
-		"distFlowID": dist.FlowID,
-	})
+			logger.WithFields(log.Fields{
+				"existingID":      existing.ID,
+				"existingName":    existing.Name,
+				"existingAddress": existing.Address,
+				"existingStart":   existing.StartAtBlock,
+				"newID":           cpc.ID,
+				"newName":         cpc.Name,
+				"newAddress":      cpc.Address,
+				"newStart":        cpc.StartAtBlock,
+			}).Warn("CirculatingPackContract with higher block height found in database, should not happen in production")
 
-	logger.Trace("Update minting status")
+			existing.StartAtBlock = cpc.StartAtBlock
+			if err := UpdateCirculatingPackContract(db, existing); err != nil {
+				return err // rollback
+			}
 
-	minting, err := GetDistributionMinting(db, dist.ID)
-	if err != nil {
+			// Use the existing one from now on
+			cpc = *existing
+		}
+	}
+
+	minting := Minting{
+		DistributionID: dist.ID,
+		CurrentCount:   0,
+		TotalCount:     0, // Update later
+		StartAtBlock:   latestBlockHeader.Height - 1,
+	}
+
+	if err := InsertMinting(db, &minting); err != nil {
 		return err // rollback
 	}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+	totalPackCount, err := CountDistributionPacks(db, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	minting.TotalCount = uint(totalPackCount)
+
+	if err := UpdateMinting(db, &minting); err != nil {
+		return err // rollback
+	}
+
+	if _, err := svc.queueMintBatches(db, dist, svc.cfg.MintingMaxPendingBatches); err != nil {
+		return err // rollback
+	}
+
+	logger.Trace("Start minting complete")
+
+	return nil // commit
+}
+
+// queueMintBatches creates and stores mint transactions for packs of 'dist'
+// not yet queued, batches of 'MintingBatchSize' (or less if adjusted to gas
+// usage), at most 'maxBatches' (0 means no limit).
+// Returns the number of transactions created.
+func (svc *ContractService) queueMintBatches(db *gorm.DB, dist *Distribution, maxBatches int) (int, error) {
+	logger := logging.Logger(logging.Minting).WithFields(log.Fields{
+		"method":     "queueMintBatches",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  dist.RequestID,
+	})
+
+	queued := 0
+
+	for maxBatches <= 0 || queued < maxBatches {
+		batch, err := NotQueuedMintPacks(db, dist.ID, svc.batchSize(svc.mintBatchSizer))
+		if err != nil {
+			return queued, err
+		}
+
+		if len(batch) == 0 {
+			break
+		}
+
+		batchLogger := logger.WithFields(log.Fields{
+			"batchNumber": queued + 1,
+		})
+
+		batchLogger.Debug("Initiating mint transaction")
+
+		t, err := newMintTransaction(dist, batch)
+		if err != nil {
+			return queued, err
+		}
+
+		if err := t.Save(db); err != nil {
+			return queued, err
+		}
+
+		if err := SetPacksMintQueued(db, batch); err != nil {
+			return queued, err
+		}
+
+		queued++
+
+		batchLogger.Trace("Mint transaction saved")
+	}
+
+	return queued, nil
+}
+
+// Abort a distribution. Settle and mint transactions not yet sent are
+// cancelled, the packs which were never minted are cancelled once the sent
+// ones have finished (see FinishCancellation). If 'returnEscrow' is set the
+// escrowed collectibles of the cancelled packs are returned to the issuer.
+func (svc *ContractService) Abort(ctx context.Context, db *gorm.DB, dist *Distribution, returnEscrow bool) error {
+	logger := log.WithFields(log.Fields{
+		"method":     "Abort",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	logger.Info("Abort")
+
+	if dist.State == common.DistributionStateInvalid || dist.State == common.DistributionStateCancelled {
+		return newError(ErrorCodeDistributionState, "distribution is already aborted")
+	}
+
+	// Make sure the distribution is in correct state
+	if err := dist.SetInvalid(); err != nil {
+		return err // rollback
+	}
+
+	// Held transactions are cancelled below
+	dist.PausedAt = nil
+
+	// Update the distribution in database
+	if err := UpdateDistribution(db, dist); err != nil {
+		return err // rollback
+	}
+
+	// Halt settlement and minting
+	cancelled, err := transactions.CancelUnsent(db, dist.ID, []string{SETTLE_SCRIPT, SETTLE_FUNGIBLE_TOKEN_SCRIPT, MINT_SCRIPT})
+	if err != nil {
+		return err // rollback
+	}
+
+	if err := InsertDistributionCancellation(db, &DistributionCancellation{DistributionID: dist.ID, ReturnEscrow: returnEscrow}); err != nil {
+		return err // rollback
+	}
+
+	logger.WithFields(log.Fields{
+		"cancelledTransactions": cancelled,
+		"returnEscrow":          returnEscrow,
+	}).Info("Settlement and minting halted")
+
+	// Notify the webhooks of the issuer
+	if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+		return err // rollback
+	}
+
+	// Update distribution state onchain
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(UPDATE_STATE_SCRIPT, nil)
+	if err != nil {
+		return err // rollback
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.UInt8(1),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(UPDATE_STATE_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	if err := t.Save(db); err != nil {
+		return err // rollback
+	}
+
+	logger.WithFields(log.Fields{
+		"state":    1,
+		"stateStr": "invalid",
+	}).Info("Distribution state update transaction saved")
+
+	return nil // commit
+}
+
+// Reinitialize prepares the aborted 'dist' to be updated and started again.
+// Only distributions which never started settling can be reinitialized, the
+// PDS has not escrowed any of their collectibles. Their cancellation is
+// dropped and their state onchain set back to initialized.
+func (svc *ContractService) Reinitialize(ctx context.Context, db *gorm.DB, dist *Distribution) error {
+	logger := log.WithFields(log.Fields{
+		"method":     "Reinitialize",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	if dist.State != common.DistributionStateInvalid {
+		return newError(ErrorCodeDistributionState, "only distributions in '%s' state can be reinitialized, state is '%s'", common.DistributionStateInvalid, dist.State)
+	}
+
+	if _, err := GetDistributionSettlement(db, dist.ID); err == nil {
+		return newError(ErrorCodeDistributionState, "distribution has started settling, create a new distribution instead")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err // rollback
+	}
+
+	// The state update sent when aborting has to land first
+	pending, err := transactions.CountPending(db, dist.ID, UPDATE_STATE_SCRIPT)
+	if err != nil {
+		return err // rollback
+	}
+	if pending > 0 {
+		return newError(ErrorCodeTransactionsInFlight, "distribution has %d state update transactions in flight, retry once they have finished", pending)
+	}
+
+	if err := DeleteDistributionCancellation(db, dist.ID); err != nil {
+		return err // rollback
+	}
+
+	// Update distribution state onchain
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(UPDATE_STATE_SCRIPT, nil)
+	if err != nil {
+		return err // rollback
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.UInt8(0),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(UPDATE_STATE_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	if err := t.Save(db); err != nil {
+		return err // rollback
+	}
+
+	logger.WithFields(log.Fields{
+		"state":    0,
+		"stateStr": "initialized",
+	}).Info("Distribution state update transaction saved")
+
+	return nil
+}
+
+// FinishCancellation finishes the cancellation 'c' of the aborted 'dist'.
+// It waits for the settle and mint transactions sent before aborting to
+// finish and handles their events up to the latest sealed block, then
+// cancels the packs which were never minted and, if requested, stores
+// transactions returning their escrowed collectibles to the issuer. Once
+// those have finished a distribution which started settling is set to
+// cancelled. Cancellations which are not yet ready are left for a later run.
+func (svc *ContractService) FinishCancellation(ctx context.Context, db *gorm.DB, dist *Distribution, c *DistributionCancellation) error {
+	logger := log.WithFields(log.Fields{
+		"method":     "FinishCancellation",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	if c.PacksCancelled {
+		return svc.finishEscrowReturn(db, dist, c, logger)
+	}
+
+	for _, name := range []string{SETTLE_SCRIPT, SETTLE_FUNGIBLE_TOKEN_SCRIPT, MINT_SCRIPT} {
+		pending, err := transactions.CountPending(db, dist.ID, name)
+		if err != nil {
+			return err // rollback
+		}
+		if pending > 0 {
+			logger.WithFields(log.Fields{"name": name, "pending": pending}).Trace("Waiting for pending transactions")
+			return nil // commit
+		}
+	}
+
+	settlement, err := GetDistributionSettlement(db, dist.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err // rollback
+	}
+
+	minting, err := GetDistributionMinting(db, dist.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err // rollback
+	}
+
+	if settlement != nil || minting != nil {
+		// Catch up with the events of the finished transactions
+		caughtUp, err := svc.catchUpCancelledEvents(ctx, db, dist, settlement, minting, logger)
+		if err != nil || !caughtUp {
+			return err
+		}
+	}
+
+	cancelledPacks, err := CancelDistributionPacks(db, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	c.CancelledPackCount = uint(cancelledPacks)
+
+	if c.ReturnEscrow && settlement != nil && cancelledPacks > 0 {
+		returned := SettlementCollectibles{}
+
+		err := DistributionPacksInBatches(db, dist.ID, svc.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+			collectibles := cancelledPackCollectibles(batch)
+			if len(collectibles) == 0 {
+				return nil
+			}
+
+			flowIDs := make([]int64, 0, len(collectibles))
+			for collectible := range collectibles {
+				flowIDs = append(flowIDs, collectible.FlowID.Int64)
+			}
+
+			settled, err := SettledCollectiblesByFlowIDs(db, settlement.ID, flowIDs)
+			if err != nil {
+				return err
+			}
+
+			for _, s := range settled {
+				if collectibles[Collectible{FlowID: s.FlowID, ContractReference: s.ContractReference}] {
+					returned = append(returned, s)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err // rollback
+		}
+
+		for _, batch := range returnEscrowBatches(returned, svc.batchSize(svc.settleBatchSizer)) {
+			t, err := newReturnEscrowTransaction(dist, batch[0].ContractReference, batch)
+			if err != nil {
+				return err // rollback
+			}
+
+			if err := t.Save(db); err != nil {
+				return err // rollback
+			}
+		}
+
+		c.ReturnedCollectibleCount = uint(len(returned))
+	}
+
+	if c.ReturnEscrow && settlement != nil && cancelledPacks > 0 && !dist.PackTemplate.FungibleToken.IsZero() {
+		settled, err := svc.fungibleTokenSettled(db, dist)
+		if err != nil {
+			return err // rollback
+		}
+
+		if settled {
+			amount := dist.PackTemplate.FungibleToken.Amount * uint64(cancelledPacks)
+
+			t, err := newReturnFungibleTokenEscrowTransaction(dist, amount)
+			if err != nil {
+				return err // rollback
+			}
+
+			if err := t.Save(db); err != nil {
+				return err // rollback
+			}
+
+			c.ReturnedFungibleTokenAmount = amount
+		}
+	}
+
+	c.PacksCancelled = true
+
+	if c.ReturnedCollectibleCount > 0 || c.ReturnedFungibleTokenAmount > 0 {
+		if err := UpdateDistributionCancellation(db, c); err != nil {
+			return err // rollback
+		}
+
+		logger.WithFields(log.Fields{
+			"cancelledPacks":        c.CancelledPackCount,
+			"returnedCollectibles":  c.ReturnedCollectibleCount,
+			"returnedFungibleToken": FungibleTokenAmountString(c.ReturnedFungibleTokenAmount),
+		}).Info("Packs cancelled, waiting for escrow return")
+
+		return nil // commit
+	}
+
+	return svc.completeCancellation(db, dist, c, settlement != nil, logger)
+}
+
+// finishEscrowReturn completes the cancellation 'c' of 'dist' once its
+// return transactions have finished. Dead-letter ones are waited for, the
+// collectibles (or fungible tokens) of failed or cancelled ones are left in
+// escrow.
+func (svc *ContractService) finishEscrowReturn(db *gorm.DB, dist *Distribution, c *DistributionCancellation, logger *log.Entry) error {
+	var failed int64
+
+	for _, name := range returnEscrowTransactions {
+		unfinished, err := transactions.CountInStates(db, dist.ID, name, unfinishedReturnStates)
+		if err != nil {
+			return err // rollback
+		}
+		if unfinished > 0 {
+			logger.WithFields(log.Fields{"name": name, "unfinished": unfinished}).Trace("Waiting for return transactions")
+			return nil // commit
+		}
+
+		n, err := transactions.CountInStates(db, dist.ID, name, failedReturnStates)
+		if err != nil {
+			return err // rollback
+		}
+		failed += n
+	}
+
+	c.FailedReturnCount = uint(failed)
+
+	if failed > 0 {
+		logger.WithField("failedReturns", failed).Warn("Return transactions failed, their collectibles are left in escrow")
+	}
+
+	return svc.completeCancellation(db, dist, c, true, logger)
+}
+
+// completeCancellation marks the cancellation 'c' of 'dist' complete and,
+// if 'settlementStarted', sets the distribution to cancelled.
+func (svc *ContractService) completeCancellation(db *gorm.DB, dist *Distribution, c *DistributionCancellation, settlementStarted bool, logger *log.Entry) error {
+	now := svc.clock.Now()
+	c.Complete = true
+	c.CompletedAt = &now
+
+	if err := UpdateDistributionCancellation(db, c); err != nil {
+		return err // rollback
+	}
+
+	if settlementStarted {
+		if err := dist.SetCancelled(); err != nil {
+			return err // rollback
+		}
+
+		if err := UpdateDistribution(db, dist); err != nil {
+			return err // rollback
+		}
+
+		// Notify the webhooks of the issuer
+		if err := queueDistributionWebhooks(db, dist, now); err != nil {
+			return err // rollback
+		}
+	}
+
+	logger.WithFields(log.Fields{
+		"cancelledPacks":       c.CancelledPackCount,
+		"returnedCollectibles": c.ReturnedCollectibleCount,
+		"failedReturns":        c.FailedReturnCount,
+		"state":                dist.State,
+	}).Info("Distribution cancellation complete")
+
+	return nil // commit
+}
+
+// catchUpCancelledEvents handles settle and mint events of the aborted 'dist'
+// up to the latest sealed block, at most 'MaxBlocksPerCheck' blocks per call.
+// Returns true once caught up.
+func (svc *ContractService) catchUpCancelledEvents(ctx context.Context, db *gorm.DB, dist *Distribution, settlement *Settlement, minting *Minting, logger *log.Entry) (bool, error) {
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return false, err
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return false, err
+	}
+
+	caughtUp := true
+
+	if settlement != nil && settlement.StartAtBlock < latestBlockHeader.Height {
+		begin := settlement.StartAtBlock + 1
+		end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+
+		if err := svc.handleSettleEvents(ctx, db, flowClient, settlement, begin, end, logger); err != nil {
+			return false, err
+		}
+
+		settlement.StartAtBlock = end
+		if err := UpdateSettlement(db, settlement); err != nil {
+			return false, err
+		}
+
+		caughtUp = caughtUp && end >= latestBlockHeader.Height
+	}
+
+	if minting != nil && minting.StartAtBlock < latestBlockHeader.Height {
+		begin := minting.StartAtBlock + 1
+		end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+
+		if err := svc.handleMintEvents(ctx, db, flowClient, dist, minting, begin, end, logger); err != nil {
+			return false, err
+		}
+
+		minting.StartAtBlock = end
+		if err := UpdateMinting(db, minting); err != nil {
+			return false, err
+		}
+
+		caughtUp = caughtUp && end >= latestBlockHeader.Height
+	}
+
+	return caughtUp, nil
+}
+
+// Retry re-kicks the settlement or minting of 'dist' when it is stuck, e.g.
+// after its transactions failed during an Access API outage. Events are
+// handled from the stored checkpoint up to the latest sealed block, then the
+// collectibles (or packs) not yet settled (or minted) are queued again in new
+// batches, replacing dead-letter transactions. Distributions with
+// transactions still in flight can not be retried. Stalled distributions are
+// set back to the state they timed out in first.
+func (svc *ContractService) Retry(ctx context.Context, db *gorm.DB, dist *Distribution) (*DistributionRetry, error) {
+	logger := log.WithFields(log.Fields{
+		"method":     "Retry",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	// A stalled distribution is set back to the state it timed out in, with
+	// its held transactions released. Distributions which stalled waiting to
+	// settle or mint continue from there.
+	unstalled := dist.State == common.DistributionStateStalled
+	if unstalled {
+		if err := dist.SetUnstalled(); err != nil {
+			return nil, err
+		}
+
+		if err := UpdateDistribution(db, dist); err != nil {
+			return nil, err // rollback
+		}
+
+		released, err := transactions.Release(db, dist.ID)
+		if err != nil {
+			return nil, err // rollback
+		}
+
+		if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+			return nil, err // rollback
+		}
+
+		logger.WithFields(log.Fields{
+			"state":                dist.State,
+			"releasedTransactions": released,
+		}).Info("Stalled distribution resumed")
+
+		if _, err := retryStage(dist.State); err != nil {
+			return &DistributionRetry{State: dist.State, Unstalled: true}, nil // commit
+		}
+	}
+
+	name, err := retryStage(dist.State)
+	if err != nil {
+		return nil, err
+	}
+
+	if dist.Paused() {
+		return nil, newError(ErrorCodeDistributionState, "distribution is paused, resume it first")
+	}
+
+	pending, err := transactions.CountPending(db, dist.ID, name)
+	if err != nil {
+		return nil, err // rollback
+	}
+	if pending > 0 {
+		return nil, newError(ErrorCodeTransactionsInFlight, "distribution has %d transactions in flight, retry once they have finished", pending)
+	}
+
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	// Events of the finished transactions have to be handled first, or their
+	// items would be queued again
+	checkpoint := func(startAtBlock uint64, handleEvents func(begin, end uint64) error) error {
+		begin := startAtBlock + 1
+		if begin > latestBlockHeader.Height {
+			return nil
+		}
+		if latestBlockHeader.Height > begin+svc.cfg.MaxBlocksPerCheck {
+			return fmt.Errorf("events are handled up to block %d, %d blocks behind the latest sealed block, retry once caught up", startAtBlock, latestBlockHeader.Height-startAtBlock)
+		}
+		return handleEvents(begin, latestBlockHeader.Height)
+	}
+
+	res := &DistributionRetry{State: dist.State, Unstalled: unstalled}
+
+	if res.CancelledTransactions, err = transactions.CancelDeadLetter(db, dist.ID, name); err != nil {
+		return nil, err // rollback
+	}
+
+	switch name {
+	case SETTLE_SCRIPT:
+		settlement, err := GetDistributionSettlement(db, dist.ID)
+		if err != nil {
+			return nil, err // rollback
+		}
+
+		err = checkpoint(settlement.StartAtBlock, func(begin, end uint64) error {
+			return svc.handleSettleEvents(ctx, db, flowClient, settlement, begin, end, logger)
+		})
+		if err != nil {
+			return nil, err // rollback
+		}
+
+		if settlement.StartAtBlock < latestBlockHeader.Height {
+			settlement.StartAtBlock = latestBlockHeader.Height
+		}
+		res.CheckpointBlock = settlement.StartAtBlock
+
+		if err := UpdateSettlement(db, settlement); err != nil {
+			return nil, err // rollback
+		}
+
+		if res.RequeuedCount, err = ResetSettlementCollectiblesQueued(db, settlement.ID); err != nil {
+			return nil, err // rollback
+		}
+
+		if res.QueuedTransactions, err = svc.queueSettleBatches(db, dist, settlement, svc.cfg.SettlementMaxPendingBatches); err != nil {
+			return nil, err // rollback
+		}
+
+	case MINT_SCRIPT:
+		minting, err := GetDistributionMinting(db, dist.ID)
+		if err != nil {
+			return nil, err // rollback
+		}
+
+		err = checkpoint(minting.StartAtBlock, func(begin, end uint64) error {
+			return svc.handleMintEvents(ctx, db, flowClient, dist, minting, begin, end, logger)
+		})
+		if err != nil {
+			return nil, err // rollback
+		}
+
+		if minting.StartAtBlock < latestBlockHeader.Height {
+			minting.StartAtBlock = latestBlockHeader.Height
+		}
+		res.CheckpointBlock = minting.StartAtBlock
+
+		if err := UpdateMinting(db, minting); err != nil {
+			return nil, err // rollback
+		}
+
+		if res.RequeuedCount, err = ResetPacksMintQueued(db, dist.ID); err != nil {
+			return nil, err // rollback
+		}
+
+		if res.QueuedTransactions, err = svc.queueMintBatches(db, dist, svc.cfg.MintingMaxPendingBatches); err != nil {
+			return nil, err // rollback
+		}
+	}
+
+	logger.WithFields(log.Fields{
+		"checkpointBlock":       res.CheckpointBlock,
+		"cancelledTransactions": res.CancelledTransactions,
+		"requeued":              res.RequeuedCount,
+		"queuedTransactions":    res.QueuedTransactions,
+	}).Info("Distribution retried")
+
+	return res, nil // commit
+}
+
+// Teardown closes a complete distribution once all of its packs have been
+// opened, or 'DistributionRevealWindow' has passed since it completed.
+// It stores a transaction destroying the capabilities the issuer shared with
+// the PDS for the distribution, a completion report and sets the
+// distribution to 'closed'. Distributions with transactions still pending are
+// left for a later run.
+// Returns the report, nil if the distribution was not closed.
+func (svc *ContractService) Teardown(ctx context.Context, db *gorm.DB, dist *Distribution) (*CompletionReport, error) {
+	logger := log.WithFields(log.Fields{
+		"method":     "Teardown",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	if dist.State != common.DistributionStateComplete {
+		return nil, newError(ErrorCodeDistributionState, "distribution has to be in '%s' state, got '%s'", common.DistributionStateComplete, dist.State)
+	}
+
+	packs, err := CountDistributionPacksByState(db, dist.ID)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	buckets, err := GetDistributionBucketsSmall(db, dist.ID)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	slotCount := 0
+	for _, b := range buckets {
+		slotCount += int(b.CollectibleCount)
+	}
+
+	report := &CompletionReport{DistributionID: dist.ID}
+	report.SetPackCounts(packs, slotCount)
+
+	if report.AllOpened() {
+		report.Reason = CloseReasonAllOpened
+	} else {
+		completedAt := dist.UpdatedAt // Completed before completion times were stored
+		if dist.CompletedAt != nil {
+			completedAt = *dist.CompletedAt
+		}
+		window := svc.cfg.DistributionRevealWindow
+		if window <= 0 || svc.clock.Now().Before(completedAt.Add(window)) {
+			return nil, nil // Packs can still be opened
+		}
+		report.Reason = CloseReasonRevealWindowExpired
+	}
+
+	pending, err := transactions.CountPending(db, dist.ID, "")
+	if err != nil {
+		return nil, err // rollback
+	}
+	if pending > 0 {
+		logger.WithFields(log.Fields{"pending": pending}).Debug("Transactions pending, postponing teardown")
+		return nil, nil
+	}
+
+	txCounts, err := transactions.CountByState(db, dist.ID)
+	if err != nil {
+		return nil, err // rollback
+	}
+	report.SetTransactionCounts(txCounts)
+
+	// Make sure the distribution is in correct state
+	if err := dist.SetClosed(); err != nil {
+		return nil, err // rollback
+	}
+
+	// Update the distribution in database
+	if err := UpdateDistribution(db, dist); err != nil {
+		return nil, err // rollback
+	}
+
+	// Notify the webhooks of the issuer
+	if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+		return nil, err // rollback
+	}
+
+	// Destroy the shared capabilities onchain
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(CLOSE_DIST_SCRIPT, nil)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(CLOSE_DIST_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return nil, err // rollback
+	}
+
+	if err := t.Save(db); err != nil {
+		return nil, err // rollback
+	}
+
+	report.CloseTransactionID = t.ID
+
+	if err := InsertCompletionReport(db, report); err != nil {
+		return nil, err // rollback
+	}
+
+	logger.WithFields(log.Fields{
+		"reason":                   report.Reason,
+		"packCount":                report.PackCount,
+		"openedCount":              report.OpenedCount,
+		"unopenedCollectibleCount": report.UnopenedCollectibleCount,
+		"failedTransactionCount":   report.FailedTransactionCount,
+	}).Info("Distribution closed")
+
+	return report, nil // commit
+}
+
+// UpdateSettlementStatus polls for 'Deposit' events regarding the given distributions
+// collectible NFTs.
+// It updates the settelement status in database accordingly.
+func (svc *ContractService) UpdateSettlementStatus(ctx context.Context, db *gorm.DB, dist *Distribution) error {
+	logger := logging.Logger(logging.Settlement).WithFields(log.Fields{
+		"method":     "UpdateSettlementStatus",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err // rollback
+	}
+
+	logger.Trace("Update settlement status")
+
+	settlement, err := GetDistributionSettlement(db, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	if limit := svc.cfg.SettlementMaxPendingBatches; limit > 0 && !dist.Paused() {
+		// Controlled batches, keep at most 'limit' settle transactions pending
+		pending, err := transactions.CountPending(db, dist.ID, SETTLE_SCRIPT)
+		if err != nil {
+			return err // rollback
+		}
+		if int(pending) < limit {
+			queued, err := svc.queueSettleBatches(db, dist, settlement, limit-int(pending))
+			if err != nil {
+				return err // rollback
+			}
+			if queued > 0 {
+				logger.WithFields(log.Fields{"pending": pending, "queued": queued}).Debug("Queued settle batches")
+			}
+		}
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return err // rollback
+	}
+
+	begin := settlement.StartAtBlock + 1
+	end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+
+	logger = logger.WithFields(log.Fields{
+		"blockBegin": begin,
+		"blockEnd":   end,
+	})
+
+	if begin > end {
+		// Nothing to update
+		logger.Trace("No blocks to handle")
+		return nil // commit
+	}
+
+	if err := svc.handleSettleEvents(ctx, db, flowClient, settlement, begin, end, logger); err != nil {
+		return err // rollback
+	}
+
+	fungibleTokenSettled, err := svc.fungibleTokenSettled(db, dist)
+	if err != nil {
+		return err // rollback
+	}
+
+	if settlement.IsComplete() && fungibleTokenSettled {
+		// TODO: consider updating the distribution separately
+
+		// Make sure the distribution is in correct state
+		if err := dist.SetSettled(); err != nil {
+			return err // rollback
+		}
+
+		// Update the distribution in database
+		if err := UpdateDistribution(db, dist); err != nil {
+			return err // rollback
+		}
+
+		// Notify the webhooks of the issuer
+		if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+			return err // rollback
+		}
+
+		// Everything is escrowed, the secret of the seed can be revealed
+		if dist.SeedCommitReveal {
+			t, err := newRevealSeedTransaction(dist)
+			if err != nil {
+				return err // rollback
+			}
+
+			if err := t.Save(db); err != nil {
+				return err // rollback
+			}
+		}
+
+		logger.Info("Settlement complete")
+	}
+
+	settlement.StartAtBlock = end
+
+	// Update the settlement status in database
+	if err := UpdateSettlement(db, settlement); err != nil {
+		return err // rollback
+	}
+
+	logger.Trace("Update settlement status complete")
+
+	return nil // commit
+}
+
+// fungibleTokenSettled returns true if the fungible tokens of the packs of
+// 'dist' have been escrowed, or the packs hold none. Unlike collectibles,
+// which are settled from their Deposit events, this is read from the state
+// of the settle transaction.
+func (svc *ContractService) fungibleTokenSettled(db *gorm.DB, dist *Distribution) (bool, error) {
+	if dist.PackTemplate.FungibleToken.IsZero() {
+		return true, nil
+	}
+
+	complete, err := transactions.CountInStates(db, dist.ID, SETTLE_FUNGIBLE_TOKEN_SCRIPT, []common.TransactionState{common.TransactionStateComplete})
+	if err != nil {
+		return false, err
+	}
+
+	return complete > 0, nil
+}
+
+// handleSettleEvents settles the collectibles of 'settlement' deposited to
+// escrow between blocks 'begin' and 'end' (inclusive), counting them in
+// 'settlement'. The events of each collectible contract with collectibles
+// left to settle are fetched once, only the collectibles they deposited are
+// loaded.
+func (svc *ContractService) handleSettleEvents(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, settlement *Settlement, begin, end uint64, logger *log.Entry) error {
+	contracts, err := NotSettledCollectibleContracts(db, settlement.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, contract := range contracts {
+		arr, err := flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
+			Type:        fmt.Sprintf("%s.Deposit", contract.String()),
+			StartHeight: begin,
+			EndHeight:   end,
+		})
+		if err != nil {
+			return err
+		}
+
+		flowIDs, err := escrowDepositIDs(arr, settlement.EscrowAddress)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(flowIDs); start += svc.cfg.BatchProcessSize {
+			chunk := flowIDs[start:]
+			if len(chunk) > svc.cfg.BatchProcessSize {
+				chunk = chunk[:svc.cfg.BatchProcessSize]
+			}
+
+			collectibles, err := NotSettledCollectiblesByFlowIDs(db, settlement.ID, contract, chunk)
+			if err != nil {
+				return err
+			}
+
+			if err := SetSettlementCollectiblesSettled(db, collectibles); err != nil {
+				return err
+			}
+
+			for range collectibles {
+				settlement.IncrementCount()
+			}
+		}
+
+		logger.WithFields(log.Fields{
+			"contract": contract.String(),
+			"deposits": len(flowIDs),
+		}).Trace("Handled deposit events")
+	}
+
+	return nil
+}
+
+// UpdateMintingStatus polls for 'Mint' events regarding the given distributions
+// Pack NFTs.
+// It updates the minting status in database accordingly.
+func (svc *ContractService) UpdateMintingStatus(ctx context.Context, db *gorm.DB, dist *Distribution) error {
+	logger := logging.Logger(logging.Minting).WithFields(log.Fields{
+		"method":     "UpdateMintingStatus",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+	})
+
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err // rollback
+	}
+
+	logger.Trace("Update minting status")
+
+	minting, err := GetDistributionMinting(db, dist.ID)
+	if err != nil {
+		return err // rollback
+	}
+
+	if limit := svc.cfg.MintingMaxPendingBatches; limit > 0 && !dist.Paused() {
+		// Controlled batches, keep at most 'limit' mint transactions pending
+		pending, err := transactions.CountPending(db, dist.ID, MINT_SCRIPT)
+		if err != nil {
+			return err // rollback
+		}
+		if int(pending) < limit {
+			queued, err := svc.queueMintBatches(db, dist, limit-int(pending))
+			if err != nil {
+				return err // rollback
+			}
+			if queued > 0 {
+				logger.WithFields(log.Fields{"pending": pending, "queued": queued}).Debug("Queued mint batches")
+			}
+		}
+	} else if !dist.Paused() {
+		// All batches were queued when the minting started, queue the packs
+		// of failed ones again
+		queued, err := svc.queueMintBatches(db, dist, 0)
+		if err != nil {
+			return err // rollback
+		}
+		if queued > 0 {
+			logger.WithFields(log.Fields{"queued": queued}).Debug("Queued mint batches of failed packs")
+		}
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
 	if err != nil {
 		return err // rollback
 	}
@@ -700,60 +2042,383 @@ func (svc *ContractService) UpdateMintingStatus(ctx context.Context, db *gorm.DB
 		return nil // commit
 	}
 
+	if err := svc.handleMintEvents(ctx, db, flowClient, dist, minting, begin, end, logger); err != nil {
+		return err // rollback
+	}
+
+	if minting.IsComplete() {
+		// Distribution is now complete
+
+		// TODO: consider updating the distribution separately
+
+		// Make sure the distribution is in correct state
+		if err := dist.SetComplete(); err != nil {
+			return err // rollback
+		}
+
+		completedAt := svc.clock.Now()
+		dist.CompletedAt = &completedAt
+
+		// Update the distribution in database
+		if err := UpdateDistribution(db, dist); err != nil {
+			return err // rollback
+		}
+
+		// Notify the webhooks of the issuer
+		if err := queueDistributionWebhooks(db, dist, svc.clock.Now()); err != nil {
+			return err // rollback
+		}
+
+		logger.Info("Minting complete")
+
+		// Update distribution state onchain
+
+		txScript, err := flow_helpers.ParseCadenceTemplate(UPDATE_STATE_SCRIPT, nil)
+		if err != nil {
+			return err // rollback
+		}
+
+		arguments := []cadence.Value{
+			cadence.UInt64(dist.FlowID.Int64),
+			cadence.UInt8(2),
+		}
+
+		t, err := transactions.NewTransactionWithDistributionID(UPDATE_STATE_SCRIPT, txScript, arguments, dist.ID)
+		if err != nil {
+			return err // rollback
+		}
+
+		if err := t.Save(db); err != nil {
+			return err // rollback
+		}
+
+		logger.WithFields(log.Fields{
+			"state":    2,
+			"stateStr": "complete",
+		}).Info("Distribution state update transaction saved")
+	}
+
+	minting.StartAtBlock = end
+
+	// Update the minting status in database
+	if err := UpdateMinting(db, minting); err != nil {
+		return err // rollback
+	}
+
+	logger.Trace("Update minting status complete")
+
+	return nil // commit
+}
+
+// handleMintEvents seals the packs of 'dist' minted between blocks 'begin'
+// and 'end' (inclusive), counting them in 'minting'.
+func (svc *ContractService) handleMintEvents(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, dist *Distribution, minting *Minting, begin, end uint64, logger *log.Entry) error {
 	reference := dist.PackTemplate.PackReference.String()
 
-	arr, err := svc.flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
+	arr, err := flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
 		Type:        fmt.Sprintf("%s.Mint", reference),
 		StartHeight: begin,
 		EndHeight:   end,
 	})
 	if err != nil {
-		return err // rollback
+		return err
 	}
 
 	for _, be := range arr {
 		for _, e := range be.Events {
 			eventLogger := logger.WithFields(log.Fields{"eventType": e.Type, "eventID": e.ID()})
 
-			eventLogger.Trace("Handling event")
+			eventLogger.Trace("Handling event")
+
+			evtValueMap := flow_helpers.EventValuesToMap(e)
+
+			packFlowIDCadence, ok := evtValueMap["id"]
+			if !ok {
+				return fmt.Errorf("could not read 'id' from event %s", e)
+			}
+
+			packFlowID, err := common.FlowIDFromCadence(packFlowIDCadence)
+			if err != nil {
+				return err
+			}
+
+			commitmentHashCadence, ok := evtValueMap["commitHash"]
+			if !ok {
+				return fmt.Errorf("could not read 'commitHash' from event %s", e)
+			}
+
+			commitmentHash, err := common.BinaryValueFromCadence(commitmentHashCadence)
+			if err != nil {
+				return err
+			}
+
+			pack, err := GetMintingPack(db, commitmentHash)
+			if err != nil {
+				eventLogger.WithFields(log.Fields{
+					"packFlowID":     packFlowID,
+					"commitmentHash": commitmentHash,
+					"error":          err,
+				}).Warn("Error while handling event")
+				continue // ignore this commitmenthash, go to next event
+			}
+
+			// Set the FlowID of the pack
+			// Make sure the pack is in correct state
+			if err := pack.Seal(packFlowID); err != nil {
+				return err
+			}
+
+			// Packs are minted to the issuer
+			pack.Owner = dist.Issuer
+
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
+				return err
+			}
+
+			minting.IncrementCount()
+
+			eventLogger.Trace("Handling event complete")
+		}
+	}
+
+	return nil
+}
+
+// UpdateCirculatingPackContract polls for 'REVEAL_REQUEST', 'REVEALED', 'OPEN_REQUEST', 'OPENED',
+// 'WITHDRAW' and 'DEPOSIT' events regarding the given CirculatingPackContract.
+// It handles each the 'REVEAL_REQUEST' and 'OPEN_REQUEST' events by creating
+// and storing an appropriate Flow transaction in database to be later processed by a poller.
+// 'REVEALED' and 'OPENED' events are used to sync the state of a pack in database with onchain state.
+// 'WITHDRAW' and 'DEPOSIT' events are used to keep track of the owner of a pack.
+func (svc *ContractService) UpdateCirculatingPackContract(ctx context.Context, db *gorm.DB, cpc *CirculatingPackContract) error {
+	logger := logging.Logger(logging.Events).WithFields(log.Fields{
+		"method": "UpdateCirculatingPack",
+		"cpcID":  cpc.ID,
+	})
+
+	logger.Trace("Update circulating pack")
+
+	eventNames := []string{
+		REVEAL_REQUEST,
+		REVEALED,
+		OPEN_REQUEST,
+		OPENED,
+		WITHDRAW,
+		DEPOSIT,
+	}
+
+	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return err // rollback
+	}
+
+	begin := cpc.StartAtBlock + 1
+	end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+
+	logger = logger.WithFields(log.Fields{
+		"blockBegin": begin,
+		"blockEnd":   end,
+	})
+
+	if begin > end {
+		logger.Trace("No blocks to handle")
+		return nil // commit
+	}
+
+	contractRef := AddressLocation{Name: cpc.Name, Address: cpc.Address}
+
+	events := []packEvent{}
+	for _, eventName := range eventNames {
+		arr, err := svc.flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
+			Type:        cpc.EventName(eventName),
+			StartHeight: begin,
+			EndHeight:   end,
+		})
+		if err != nil {
+			return err // rollback
+		}
+
+		for _, be := range arr {
+			for _, e := range be.Events {
+				events = append(events, packEvent{name: eventName, height: be.Height, event: e})
+			}
+		}
+	}
+
+	// Handle the events in the order they were emitted, e.g. for a pack
+	// transferred more than once between 'begin' and 'end'
+	sortPackEvents(events)
+
+	for _, pe := range events {
+		eventName, e := pe.name, pe.event
+		eventLogger := logger.WithFields(log.Fields{"eventType": e.Type, "eventID": e.ID()})
+
+		eventLogger.Debug("Handling event")
+
+		evtValueMap := flow_helpers.EventValuesToMap(e)
+
+		packFlowIDCadence, ok := evtValueMap["id"]
+		if !ok {
+			err := fmt.Errorf("could not read 'id' from event %s", e)
+			return err // rollback
+		}
+
+		packFlowID, err := common.FlowIDFromCadence(packFlowIDCadence)
+		if err != nil {
+			return err // rollback
+		}
+
+		pack, err := GetPackByContractAndFlowID(db, contractRef, packFlowID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if archived, err := GetPackByContractAndFlowID(db.Table(archivedPacksTable), contractRef, packFlowID); err == nil {
+				if err := handleArchivedPackEvent(db, archived, eventName, evtValueMap, e, eventLogger); err != nil {
+					return err // rollback
+				}
+				continue
+			}
+		}
+		if err != nil {
+			return err // rollback
+		}
+
+		distribution, err := GetDistributionSmall(db, pack.DistributionID)
+		if err != nil {
+			return err // rollback
+		}
+
+		eventLogger = eventLogger.WithFields(log.Fields{
+			"distID":     distribution.ID,
+			"distFlowID": distribution.FlowID,
+			"requestID":  requestID(ctx, distribution),
+			"packID":     pack.ID,
+			"packFlowID": pack.FlowID,
+		})
+
+		if distribution.State == common.DistributionStateClosed && (eventName == REVEAL_REQUEST || eventName == OPEN_REQUEST) {
+			// The shared capabilities are gone, the request can not be fulfilled
+			eventLogger.Warn("Reveal or open requested for a pack of a closed distribution, ignoring")
+			continue
+		}
+
+		if pack.State == common.PackStateRevoked && (eventName == REVEAL_REQUEST || eventName == OPEN_REQUEST) {
+			eventLogger.Warn("Reveal or open requested for a revoked pack, ignoring")
+			continue
+		}
+
+		switch eventName {
+		// -- REVEAL_REQUEST, Owner has requested to reveal a pack ------------
+		case REVEAL_REQUEST:
+
+			if pack.State == common.PackStateRevealRequestHandled {
+				// Revealed by the issuer meanwhile, see App.RevealPacks
+				eventLogger.Info("Reveal requested for a pack already being revealed, ignoring")
+				continue
+			}
+
+			// Make sure the pack is in correct state
+			if err := pack.RevealRequestHandled(); err != nil {
+				err := fmt.Errorf("error while handling %s: %w", eventName, err)
+				return err // rollback
+			}
+
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
+				return err // rollback
+			}
+
+			// Get the owner of the pack from the transaction that emitted the open request event
+			tx, err := svc.flowClient.GetTransaction(ctx, e.TransactionID)
+			if err != nil {
+				return err // rollback
+			}
+			owner := common.FlowAddress(tx.Authorizers[0])
+
+			openRequestValue, ok := evtValueMap["openRequest"]
+			if !ok { // TODO(nanuuki): rollback or use a default value for openRequest?
+				err := fmt.Errorf("could not read 'openRequest' from event %s", e)
+				return err // rollback
+			}
+
+			openRequest := openRequestValue.ToGoValue().(bool)
+			eventLogger = eventLogger.WithFields(log.Fields{"openRequest": openRequest})
+
+			if _, err := svc.saveRevealTransactions(db, distribution, pack, owner, openRequest, eventLogger); err != nil {
+				return err // rollback
+			}
+
+			eventLogger.Info("Pack reveal transaction created")
+
+		// -- REVEALED, Pack has been revealed onchain ------------------------
+		case REVEALED:
+
+			// Make sure the pack is in correct state
+			if err := pack.Reveal(); err != nil {
+				err := fmt.Errorf("error while handling %s: %w", eventName, err)
+				return err // rollback
+			}
+
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
+				return err // rollback
+			}
 
-			evtValueMap := flow_helpers.EventValuesToMap(e)
+			// Queue the revealed stage for the reveal webhook
+			if distribution.RevealWebhookURL != "" {
+				e := []RevealEvent{{DistributionID: distribution.ID, PackID: pack.ID, Stage: RevealStageRevealed}}
+				if err := InsertRevealEvents(db, e, 1); err != nil {
+					return err // rollback
+				}
+			}
 
-			packFlowIDCadence, ok := evtValueMap["id"]
-			if !ok {
-				err := fmt.Errorf("could not read 'id' from event %s", e)
+			if err := queuePackWebhooks(db, distribution, pack, WebhookEventPackRevealed, svc.clock.Now()); err != nil {
 				return err // rollback
 			}
 
-			packFlowID, err := common.FlowIDFromCadence(packFlowIDCadence)
-			if err != nil {
+		// -- OPEN_REQUEST, Owner has requested to open a pack ----------------
+		case OPEN_REQUEST:
+
+			if pack.State == common.PackStateOpenRequestHandled {
+				// Opened by the issuer meanwhile, see App.RevealPacks
+				eventLogger.Info("Open requested for a pack already being opened, ignoring")
+				continue
+			}
+
+			// Make sure the pack is in correct state
+			if err := pack.OpenRequestHandled(); err != nil {
+				err := fmt.Errorf("error while handling %s: %w", eventName, err)
 				return err // rollback
 			}
 
-			commitmentHashCadence, ok := evtValueMap["commitHash"]
-			if !ok {
-				err := fmt.Errorf("could not read 'commitHash' from event %s", e)
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
 				return err // rollback
 			}
 
-			commitmentHash, err := common.BinaryValueFromCadence(commitmentHashCadence)
+			// Get the owner of the pack from the transaction that emitted the open request event
+			tx, err := svc.flowClient.GetTransaction(ctx, e.TransactionID)
 			if err != nil {
 				return err // rollback
 			}
+			owner := common.FlowAddress(tx.Authorizers[0])
 
-			pack, err := GetMintingPack(db, commitmentHash)
+			t, err := newOpenTransaction(distribution, pack, owner)
 			if err != nil {
-				eventLogger.WithFields(log.Fields{
-					"packFlowID":     packFlowID,
-					"commitmentHash": commitmentHash,
-					"error":          err,
-				}).Warn("Error while handling event")
-				continue // ignore this commitmenthash, go to next event
+				return err // rollback
 			}
 
-			// Set the FlowID of the pack
+			if err := t.Save(db); err != nil {
+				return err // rollback
+			}
+
+			eventLogger.Info("Pack open transaction created")
+
+		// -- OPENED, Pack has been opened onchain ----------------------------
+		case OPENED:
+
 			// Make sure the pack is in correct state
-			if err := pack.Seal(packFlowID); err != nil {
+			if err := pack.Open(); err != nil {
+				err := fmt.Errorf("error while handling %s: %w", eventName, err)
 				return err // rollback
 			}
 
@@ -762,354 +2427,354 @@ func (svc *ContractService) UpdateMintingStatus(ctx context.Context, db *gorm.DB
 				return err // rollback
 			}
 
-			minting.IncrementCount()
-
-			eventLogger.Trace("Handling event complete")
-		}
-	}
+			if err := queuePackWebhooks(db, distribution, pack, WebhookEventPackOpened, svc.clock.Now()); err != nil {
+				return err // rollback
+			}
 
-	if minting.IsComplete() {
-		// Distribution is now complete
+		// -- WITHDRAW, Pack has been withdrawn from a collection -------------
+		case WITHDRAW:
 
-		// TODO: consider updating the distribution separately
+			// The owner is unknown until the pack is deposited again
+			if pack.Owner, err = packEventOwner(eventName, evtValueMap, e); err != nil {
+				return err // rollback
+			}
 
-		// Make sure the distribution is in correct state
-		if err := dist.SetComplete(); err != nil {
-			return err // rollback
-		}
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
+				return err // rollback
+			}
 
-		// Update the distribution in database
-		if err := UpdateDistribution(db, dist); err != nil {
-			return err // rollback
-		}
+			eventLogger.Debug("Pack owner cleared")
 
-		logger.Info("Minting complete")
+		// -- DEPOSIT, Pack has been deposited to a collection ----------------
+		case DEPOSIT:
 
-		// Update distribution state onchain
+			owner, err := packEventOwner(eventName, evtValueMap, e)
+			if err != nil {
+				return err // rollback
+			}
 
-		txScript, err := flow_helpers.ParseCadenceTemplate(UPDATE_STATE_SCRIPT, nil)
-		if err != nil {
-			return err // rollback
-		}
+			pack.Owner = owner
 
-		arguments := []cadence.Value{
-			cadence.UInt64(dist.FlowID.Int64),
-			cadence.UInt8(2),
-		}
+			// Update the pack in database
+			if err := UpdatePack(db, pack); err != nil {
+				return err // rollback
+			}
 
-		t, err := transactions.NewTransactionWithDistributionID(UPDATE_STATE_SCRIPT, txScript, arguments, dist.ID)
-		if err != nil {
-			return err // rollback
-		}
+			// Check if the pack was gifted as intended
+			if err := observeGiftTransfers(db, pack, e.TransactionID.String(), svc.clock.Now()); err != nil {
+				return err // rollback
+			}
 
-		if err := t.Save(db); err != nil {
-			return err // rollback
+			eventLogger.WithFields(log.Fields{"owner": owner}).Debug("Pack owner updated")
 		}
 
-		logger.WithFields(log.Fields{
-			"state":    2,
-			"stateStr": "complete",
-		}).Info("Distribution state update transaction saved")
+		eventLogger.Trace("Handling event complete")
 	}
 
-	minting.StartAtBlock = end
+	cpc.StartAtBlock = end
 
-	// Update the minting status in database
-	if err := UpdateMinting(db, minting); err != nil {
+	// Update the CirculatingPackContract in database
+	if err := UpdateCirculatingPackContract(db, cpc); err != nil {
 		return err // rollback
 	}
 
-	logger.Trace("Update minting status complete")
+	logger.Trace("Update circulating pack complete")
 
 	return nil // commit
 }
 
-// UpdateCirculatingPackContract polls for 'REVEAL_REQUEST', 'REVEALED', 'OPEN_REQUEST' and 'OPENED' events
-// regarding the given CirculatingPackContract.
-// It handles each the 'REVEAL_REQUEST' and 'OPEN_REQUEST' events by creating
-// and storing an appropriate Flow transaction in database to be later processed by a poller.
-// 'REVEALED' and 'OPENED' events are used to sync the state of a pack in database with onchain state.
-func (svc *ContractService) UpdateCirculatingPackContract(ctx context.Context, db *gorm.DB, cpc *CirculatingPackContract) error {
+// ForceReveal stores a new transaction revealing 'pack' of 'dist', for when
+// the reveal transaction of a handled reveal request failed for good. The
+// pack has to be waiting for its reveal both in database and onchain, with
+// no reveal transaction in flight. If 'openRequest' is set the pack is also
+// opened, like for a reveal request asking to open.
+func (svc *ContractService) ForceReveal(ctx context.Context, db *gorm.DB, dist *Distribution, pack *Pack, openRequest bool) (*transactions.StorableTransaction, error) {
 	logger := log.WithFields(log.Fields{
-		"method": "UpdateCirculatingPack",
-		"cpcID":  cpc.ID,
+		"method":      "ForceReveal",
+		"distID":      dist.ID,
+		"distFlowID":  dist.FlowID,
+		"requestID":   requestID(ctx, dist),
+		"packID":      pack.ID,
+		"packFlowID":  pack.FlowID,
+		"openRequest": openRequest,
 	})
 
-	logger.Trace("Update circulating pack")
+	if err := svc.checkForceable(ctx, db, dist, pack, common.PackStateRevealRequestHandled, REVEAL_SCRIPT, packStatusSealed); err != nil {
+		return nil, err // rollback
+	}
 
-	eventNames := []string{
-		REVEAL_REQUEST,
-		REVEALED,
-		OPEN_REQUEST,
-		OPENED,
+	t, err := svc.saveRevealTransactions(db, dist, pack, pack.Owner, openRequest, logger)
+	if err != nil {
+		return nil, err // rollback
 	}
 
-	latestBlockHeader, err := svc.flowClient.GetLatestBlockHeader(ctx, true)
+	logger.WithFields(log.Fields{"owner": pack.Owner}).Info("Forced pack reveal transaction created")
+
+	return t, nil
+}
+
+// ForceOpen stores a new transaction opening 'pack' of 'dist', for when the
+// open transaction of a handled open request failed for good. The pack has
+// to be waiting to be opened both in database and onchain, with no open
+// transaction in flight.
+func (svc *ContractService) ForceOpen(ctx context.Context, db *gorm.DB, dist *Distribution, pack *Pack) (*transactions.StorableTransaction, error) {
+	logger := log.WithFields(log.Fields{
+		"method":     "ForceOpen",
+		"distID":     dist.ID,
+		"distFlowID": dist.FlowID,
+		"requestID":  requestID(ctx, dist),
+		"packID":     pack.ID,
+		"packFlowID": pack.FlowID,
+	})
+
+	if err := svc.checkForceable(ctx, db, dist, pack, common.PackStateOpenRequestHandled, OPEN_SCRIPT, packStatusRevealed); err != nil {
+		return nil, err // rollback
+	}
+
+	t, err := newOpenTransaction(dist, pack, pack.Owner)
 	if err != nil {
-		return err // rollback
+		return nil, err // rollback
 	}
 
-	begin := cpc.StartAtBlock + 1
-	end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+	if err := t.Save(db); err != nil {
+		return nil, err // rollback
+	}
 
-	logger = logger.WithFields(log.Fields{
-		"blockBegin": begin,
-		"blockEnd":   end,
+	logger.WithFields(log.Fields{"owner": pack.Owner}).Info("Forced pack open transaction created")
+
+	return t, nil
+}
+
+// checkForceable checks a transaction named 'name' can be forced for 'pack'
+// of 'dist': 'pack' is in 'state', has no such transaction in flight and its
+// status onchain is 'status'.
+func (svc *ContractService) checkForceable(ctx context.Context, db *gorm.DB, dist *Distribution, pack *Pack, state common.PackState, name string, status uint8) error {
+	if dist.State == common.DistributionStateClosed {
+		// The shared capabilities are gone, the request can not be fulfilled
+		return newError(ErrorCodeDistributionState, "distribution is closed")
+	}
+
+	if err := pack.checkForceable(state); err != nil {
+		return err
+	}
+
+	pending, err := transactions.CountPendingForPack(db, pack.ID, name)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return newError(ErrorCodeTransactionsInFlight, "pack has %d transactions in flight, retry once they have finished", pending)
+	}
+
+	onchain, err := svc.PackStatus(ctx, dist, pack)
+	if err != nil {
+		return err
+	}
+
+	return checkPackStatus(onchain, status)
+}
+
+// PackStatus runs a script returning the status of 'pack' onchain, see
+// PackNFT.Status.
+func (svc *ContractService) PackStatus(ctx context.Context, dist *Distribution, pack *Pack) (uint8, error) {
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return 0, err
+	}
+
+	script, err := flow_helpers.ParseCadenceTemplate(
+		PACK_STATUS_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    pack.ContractReference.Name,
+			PackNFTAddress: pack.ContractReference.Address.String(),
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := flowClient.ExecuteScriptAtLatestBlock(ctx, script, []cadence.Value{cadence.UInt64(pack.FlowID.Int64)})
+	if err != nil {
+		return 0, err
+	}
+
+	status, ok := value.(cadence.UInt8)
+	if !ok {
+		return 0, fmt.Errorf("unexpected script result for pack %s: %v", pack.ID, value)
+	}
+
+	return uint8(status), nil
+}
+
+// distributionSupports executes 'scriptPath', a script of the PDS contract
+// returning whether 'dist' supports an optional operator of IPackNFT (shared
+// by the issuer when creating the distribution).
+func (svc *ContractService) distributionSupports(ctx context.Context, flowClient flow_helpers.FlowClient, dist *Distribution, scriptPath string) (bool, error) {
+	script, err := flow_helpers.ParseCadenceTemplate(scriptPath, nil)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := flowClient.ExecuteScriptAtLatestBlock(ctx, script, []cadence.Value{cadence.UInt64(dist.FlowID.Int64)})
+	if err != nil {
+		return false, err
+	}
+
+	supports, ok := value.(cadence.Bool)
+	if !ok {
+		return false, fmt.Errorf("unexpected script result for distribution %s: %v", dist.ID, value)
+	}
+
+	return bool(supports), nil
+}
+
+// UpdateOwnershipVerification checks the next batch of packs in an ownership
+// verification. For each distinct believed owner in the batch it lists the
+// pack IDs in the owners onchain collection and stores a discrepancy for each
+// pack which is not there (or has no known owner).
+// The verification is set complete once all packs have been checked.
+func (svc *ContractService) UpdateOwnershipVerification(ctx context.Context, db *gorm.DB, v *OwnershipVerification) error {
+	logger := log.WithFields(log.Fields{
+		"method":         "UpdateOwnershipVerification",
+		"verificationID": v.ID,
+		"distID":         v.DistributionID,
 	})
 
-	if begin > end {
-		logger.Trace("No blocks to handle")
-		return nil // commit
+	logger.Trace("Update ownership verification")
+
+	dist, err := GetDistributionSmall(db, v.DistributionID)
+	if err != nil {
+		return err // rollback
 	}
 
-	contractRef := AddressLocation{Name: cpc.Name, Address: cpc.Address}
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err // rollback
+	}
 
-	for _, eventName := range eventNames {
-		arr, err := svc.flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
-			Type:        cpc.EventName(eventName),
-			StartHeight: begin,
-			EndHeight:   end,
-		})
-		if err != nil {
+	packs, err := ListVerifiablePacks(packsOf(db, dist), dist.ID, v.LastPackFlowID, svc.cfg.OwnershipVerificationBatchSize)
+	if err != nil {
+		return err // rollback
+	}
+
+	if len(packs) == 0 {
+		v.State = common.OwnershipVerificationStateComplete
+		if err := UpdateOwnershipVerification(db, v); err != nil {
 			return err // rollback
 		}
 
-		for _, be := range arr {
-			for _, e := range be.Events {
-				eventLogger := logger.WithFields(log.Fields{"eventType": e.Type, "eventID": e.ID()})
+		logger.WithFields(log.Fields{"checkedCount": v.CheckedCount}).Info("Ownership verification complete")
 
-				eventLogger.Debug("Handling event")
+		return nil // commit
+	}
 
-				evtValueMap := flow_helpers.EventValuesToMap(e)
+	script, err := flow_helpers.ParseCadenceTemplate(
+		OWNED_PACK_IDS_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			PackNFTName:    dist.PackTemplate.PackReference.Name,
+			PackNFTAddress: dist.PackTemplate.PackReference.Address.String(),
+		},
+	)
+	if err != nil {
+		return err // rollback
+	}
 
-				packFlowIDCadence, ok := evtValueMap["id"]
-				if !ok {
-					err := fmt.Errorf("could not read 'id' from event %s", e)
-					return err // rollback
-				}
+	owned := make(map[common.FlowAddress]map[int64]bool)
+	for _, p := range packs {
+		if _, ok := owned[p.Owner]; ok || p.Owner == (common.FlowAddress{}) {
+			continue
+		}
 
-				packFlowID, err := common.FlowIDFromCadence(packFlowIDCadence)
-				if err != nil {
-					return err // rollback
-				}
+		value, err := flowClient.ExecuteScriptAtLatestBlock(ctx, script, []cadence.Value{cadence.Address(p.Owner)})
+		if err != nil {
+			return err // rollback
+		}
 
-				pack, err := GetPackByContractAndFlowID(db, contractRef, packFlowID)
-				if err != nil {
-					return err // rollback
-				}
+		ids, ok := value.(cadence.Array)
+		if !ok {
+			err := fmt.Errorf("unexpected script result for owner %s: %v", p.Owner, value)
+			return err // rollback
+		}
 
-				distribution, err := GetDistributionSmall(db, pack.DistributionID)
-				if err != nil {
-					return err // rollback
-				}
+		owned[p.Owner] = make(map[int64]bool, len(ids.Values))
+		for _, id := range ids.Values {
+			flowID, err := common.FlowIDFromCadence(id)
+			if err != nil {
+				return err // rollback
+			}
+			owned[p.Owner][flowID.Int64] = true
+		}
+	}
 
-				eventLogger = eventLogger.WithFields(log.Fields{
-					"distID":     distribution.ID,
-					"distFlowID": distribution.FlowID,
-					"packID":     pack.ID,
-					"packFlowID": pack.FlowID,
-				})
-
-				switch eventName {
-				// -- REVEAL_REQUEST, Owner has requested to reveal a pack ------------
-				case REVEAL_REQUEST:
-
-					// Make sure the pack is in correct state
-					if err := pack.RevealRequestHandled(); err != nil {
-						err := fmt.Errorf("error while handling %s: %w", eventName, err)
-						return err // rollback
-					}
-
-					// Update the pack in database
-					if err := UpdatePack(db, pack); err != nil {
-						return err // rollback
-					}
-
-					// Get the owner of the pack from the transaction that emitted the open request event
-					tx, err := svc.flowClient.GetTransaction(ctx, e.TransactionID)
-					if err != nil {
-						return err // rollback
-					}
-					owner := tx.Authorizers[0]
-
-					// NOTE: this only handles one collectible contract per pack
-					contract := pack.Collectibles[0].ContractReference
-
-					collectibleCount := len(pack.Collectibles)
-					collectibleContractAddresses := make([]cadence.Value, collectibleCount)
-					collectibleContractNames := make([]cadence.Value, collectibleCount)
-					collectibleIDs := make([]cadence.Value, collectibleCount)
-
-					for i, c := range pack.Collectibles {
-						collectibleContractAddresses[i] = cadence.Address(c.ContractReference.Address)
-						collectibleContractNames[i] = cadence.String(c.ContractReference.Name)
-						collectibleIDs[i] = cadence.UInt64(c.FlowID.Int64)
-					}
-
-					openRequestValue, ok := evtValueMap["openRequest"]
-					if !ok { // TODO(nanuuki): rollback or use a default value for openRequest?
-						err := fmt.Errorf("could not read 'openRequest' from event %s", e)
-						return err // rollback
-					}
-
-					openRequest := openRequestValue.ToGoValue().(bool)
-					eventLogger = eventLogger.WithFields(log.Fields{"openRequest": openRequest})
-
-					arguments := []cadence.Value{
-						cadence.UInt64(distribution.FlowID.Int64),
-						cadence.UInt64(pack.FlowID.Int64),
-						cadence.NewArray(collectibleContractAddresses),
-						cadence.NewArray(collectibleContractNames),
-						cadence.NewArray(collectibleIDs),
-						cadence.String(pack.Salt.String()),
-						cadence.Address(owner),
-						cadence.NewBool(openRequest),
-						cadence.Path{Domain: "private", Identifier: contract.ProviderPath()},
-					}
-
-					// NOTE: this only handles one collectible contract per pack
-					txScript, err := flow_helpers.ParseCadenceTemplate(
-						REVEAL_SCRIPT,
-						&flow_helpers.CadenceTemplateVars{
-							PackNFTName:           pack.ContractReference.Name,
-							PackNFTAddress:        pack.ContractReference.Address.String(),
-							CollectibleNFTName:    contract.Name,
-							CollectibleNFTAddress: contract.Address.String(),
-						},
-					)
-					if err != nil {
-						return err // rollback
-					}
-
-					t, err := transactions.NewTransactionWithDistributionID(REVEAL_SCRIPT, txScript, arguments, distribution.ID)
-					if err != nil {
-						return err // rollback
-					}
-
-					if err := t.Save(db); err != nil {
-						return err // rollback
-					}
-
-					if openRequest { // NOTE: This block should run only if we want to reveal AND open the pack
-						// Reset the ID to save a second indentical transaction
-						t.ID = uuid.Nil
-						if err := t.Save(db); err != nil {
-							return err // rollback
-						}
-					}
-
-					eventLogger.Info("Pack reveal transaction created")
-
-				// -- REVEALED, Pack has been revealed onchain ------------------------
-				case REVEALED:
-
-					// Make sure the pack is in correct state
-					if err := pack.Reveal(); err != nil {
-						err := fmt.Errorf("error while handling %s: %w", eventName, err)
-						return err // rollback
-					}
-
-					// Update the pack in database
-					if err := UpdatePack(db, pack); err != nil {
-						return err // rollback
-					}
-
-				// -- OPEN_REQUEST, Owner has requested to open a pack ----------------
-				case OPEN_REQUEST:
-
-					// Make sure the pack is in correct state
-					if err := pack.OpenRequestHandled(); err != nil {
-						err := fmt.Errorf("error while handling %s: %w", eventName, err)
-						return err // rollback
-					}
-
-					// Update the pack in database
-					if err := UpdatePack(db, pack); err != nil {
-						return err // rollback
-					}
-
-					// Get the owner of the pack from the transaction that emitted the open request event
-					tx, err := svc.flowClient.GetTransaction(ctx, e.TransactionID)
-					if err != nil {
-						return err // rollback
-					}
-					owner := tx.Authorizers[0]
-
-					// NOTE: this only handles one collectible contract per pack
-					contract := pack.Collectibles[0].ContractReference
-
-					collectibleCount := len(pack.Collectibles)
-
-					collectibleContractAddresses := make([]cadence.Value, collectibleCount)
-					collectibleContractNames := make([]cadence.Value, collectibleCount)
-					collectibleIDs := make([]cadence.Value, collectibleCount)
-
-					for i, c := range pack.Collectibles {
-						collectibleContractAddresses[i] = cadence.Address(c.ContractReference.Address)
-						collectibleContractNames[i] = cadence.String(c.ContractReference.Name)
-						collectibleIDs[i] = cadence.UInt64(c.FlowID.Int64)
-					}
-
-					arguments := []cadence.Value{
-						cadence.UInt64(distribution.FlowID.Int64),
-						cadence.UInt64(pack.FlowID.Int64),
-						cadence.NewArray(collectibleContractAddresses),
-						cadence.NewArray(collectibleContractNames),
-						cadence.NewArray(collectibleIDs),
-						cadence.Address(owner),
-						cadence.Path{Domain: "private", Identifier: contract.ProviderPath()},
-					}
-
-					txScript, err := flow_helpers.ParseCadenceTemplate(
-						OPEN_SCRIPT,
-						&flow_helpers.CadenceTemplateVars{
-							CollectibleNFTName:    contract.Name,
-							CollectibleNFTAddress: contract.Address.String(),
-						},
-					)
-					if err != nil {
-						return err // rollback
-					}
-
-					t, err := transactions.NewTransactionWithDistributionID(OPEN_SCRIPT, txScript, arguments, distribution.ID)
-					if err != nil {
-						return err // rollback
-					}
-
-					if err := t.Save(db); err != nil {
-						return err // rollback
-					}
-
-					eventLogger.Info("Pack open transaction created")
-
-				// -- OPENED, Pack has been opened onchain ----------------------------
-				case OPENED:
-
-					// Make sure the pack is in correct state
-					if err := pack.Open(); err != nil {
-						err := fmt.Errorf("error while handling %s: %w", eventName, err)
-						return err // rollback
-					}
-
-					// Update the pack in database
-					if err := UpdatePack(db, pack); err != nil {
-						return err // rollback
-					}
-				}
+	discrepancies := findOwnershipDiscrepancies(packs, owned)
+	for i := range discrepancies {
+		discrepancies[i].OwnershipVerificationID = v.ID
+	}
 
-				eventLogger.Trace("Handling event complete")
-			}
+	if len(discrepancies) > 0 {
+		if err := InsertOwnershipDiscrepancies(db, discrepancies, svc.cfg.BatchInsertSize); err != nil {
+			return err // rollback
 		}
+
+		logger.WithFields(log.Fields{"discrepancyCount": len(discrepancies)}).Warn("Pack ownership discrepancies found")
 	}
 
-	cpc.StartAtBlock = end
+	v.State = common.OwnershipVerificationStateRunning
+	v.CheckedCount += uint(len(packs))
+	v.LastPackFlowID = packs[len(packs)-1].FlowID
 
-	// Update the CirculatingPackContract in database
-	if err := UpdateCirculatingPackContract(db, cpc); err != nil {
+	if err := UpdateOwnershipVerification(db, v); err != nil {
 		return err // rollback
 	}
 
-	logger.Trace("Update circulating pack complete")
+	logger.Trace("Update ownership verification complete")
 
 	return nil // commit
 }
+
+// OwnedCollectibleIDs runs a script returning the IDs of the 'contract'
+// collectibles owned by 'account', empty if the account has no public collection.
+// Uses 'opt' to page through the IDs in the order the collection returns them.
+func (svc *ContractService) OwnedCollectibleIDs(ctx context.Context, contract AddressLocation, account common.FlowAddress, opt ListOptions) ([]common.FlowID, error) {
+	script, err := flow_helpers.ParseCadenceTemplate(
+		OWNED_COLLECTIBLE_IDS_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			CollectibleNFTName:    contract.Name,
+			CollectibleNFTAddress: contract.Address.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := uint64(math.MaxUint64)
+	if opt.Limit >= 0 {
+		limit = uint64(opt.Limit)
+	}
+
+	arguments := []cadence.Value{
+		cadence.Address(account),
+		cadence.UInt64(opt.Offset),
+		cadence.UInt64(limit),
+	}
+
+	value, err := svc.flowClient.ExecuteScriptAtLatestBlock(ctx, script, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, ok := value.(cadence.Array)
+	if !ok {
+		return nil, fmt.Errorf("unexpected script result for account %s: %v", account, value)
+	}
+
+	res := make([]common.FlowID, len(ids.Values))
+	for i, id := range ids.Values {
+		if res[i], err = common.FlowIDFromCadence(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+)
+
+// DistributionCosts is the FLOW paid (UFix64) for the transactions sent on
+// behalf of a distribution, in total and per transaction name.
+type DistributionCosts struct {
+	DistributionID uuid.UUID
+	Fees           uint64
+	Attempts       uint
+	ByName         []transactions.FeeSummary
+}
+
+// GetDistributionCosts returns the fees paid for the transactions of a
+// distribution. Only attempts recorded with their distribution are counted.
+func (app *App) GetDistributionCosts(ctx context.Context, distributionID uuid.UUID) (*DistributionCosts, error) {
+	if _, err := GetDistributionSmall(app.db, distributionID); err != nil {
+		return nil, err
+	}
+
+	byName, err := transactions.SumFeesByName(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := &DistributionCosts{DistributionID: distributionID, ByName: byName}
+	for _, s := range byName {
+		costs.Fees += s.Fees
+		costs.Attempts += s.Attempts
+	}
+
+	return costs, nil
+}
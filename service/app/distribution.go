@@ -1,8 +1,8 @@
 package app
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
@@ -10,21 +10,60 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrRevealLocked is returned when trying to reveal a pack before the reveal
+// time lock of its distribution has passed
+var ErrRevealLocked = &Error{Code: ErrorCodeRevealLocked, Err: errors.New("pack reveal is time locked")}
+
 type Distribution struct {
 	gorm.Model
 	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
 
-	FlowID       common.FlowID            `gorm:"column:flow_id"` // A reference on the PDS Contract to this distribution
-	Issuer       common.FlowAddress       `gorm:"column:issuer"`
-	State        common.DistributionState `gorm:"column:state;not null;default:null"`
-	PackTemplate PackTemplate             `gorm:"embedded;embeddedPrefix:template_"`
-	Packs        []Pack                   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	FlowID        common.FlowID            `gorm:"column:flow_id"` // A reference on the PDS Contract to this distribution
+	Issuer        common.FlowAddress       `gorm:"column:issuer;index"`
+	State         common.DistributionState `gorm:"column:state;not null;default:null;index"`
+	PackTemplate  PackTemplate             `gorm:"embedded;embeddedPrefix:template_"`
+	Packs         []Pack                   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	AccessAPIHost string                   `gorm:"column:access_api_host"`   // Optional override of the global Access API host(s)
+	CompletedAt   *time.Time               `gorm:"column:completed_at"`      // Set when minting completes
+	ArchivedAt    *time.Time               `gorm:"column:archived_at;index"` // Set when archived, its packs are then in ArchivedPack
+	PausedAt      *time.Time               `gorm:"column:paused_at"`         // Set while paused, no new settle or mint batches are sent
+
+	TimedState      common.DistributionState `gorm:"column:timed_state"`       // State the poller timed the distribution in, see handleStateTimeouts
+	TimedStateSince *time.Time               `gorm:"column:timed_state_since"` // When the poller first saw the distribution in TimedState
+	StalledState    common.DistributionState `gorm:"column:stalled_state"`     // Set while stalled, the state it timed out in
+
+	SettlementStartAt *time.Time `gorm:"column:settlement_start_at;index"` // Optional, the distribution is not set up before this
+
+	Metadata DistributionMetadata `gorm:"embedded;embeddedPrefix:metadata_"` // Optional, display metadata given by the issuer
+
+	RevealWebhookURL string     `gorm:"column:reveal_webhook_url"` // Optional, receives the reveal stages of packs
+	TeasedAt         *time.Time `gorm:"column:teased_at"`          // Set once the teased stage has been queued for the packs
+
+	CollectionID *uuid.UUID `gorm:"column:collection_id;index"` // Optional, collection the distribution belongs to
+	TemplateID   *uuid.UUID `gorm:"column:template_id;index"`   // Optional, template the distribution was created from
+
+	ResolveSeed      int64 `gorm:"column:resolve_seed"`      // Seed the packs were resolved from, see ResolvePacks
+	ResolveAlgorithm uint  `gorm:"column:resolve_algorithm"` // Version of the algorithm the packs were resolved with, 0 if not recorded
+
+	CommitmentHashVersion uint `gorm:"column:commitment_hash_version"` // Version of the commitment hash scheme of the packs, see CommitmentHasher (0 is version 1)
+
+	SeedCommitReveal  bool               `gorm:"column:seed_commit_reveal"`   // Optional, the packs are resolved again from a seed anchored on-chain, see seed_commitment.go
+	SeedSecret        common.BinaryValue `gorm:"column:seed_secret"`          // private, committed to on-chain when set up, revealed once settled
+	SeedAnchorHeight  uint64             `gorm:"column:seed_anchor_height"`   // Height of the block the seed is derived from along with the secret
+	SeedAnchorBlockID string             `gorm:"column:seed_anchor_block_id"` // Set once the packs are resolved from the anchored seed
+
+	RequestID string `gorm:"column:request_id"` // API request which created or last updated the distribution, logged when handling it
 }
 
 type PackTemplate struct {
-	PackReference AddressLocation `gorm:"embedded;embeddedPrefix:pack_ref_"`             // Reference to the pack NFT contract
-	PackCount     uint            `gorm:"column:pack_count"`                             // How many packs to create
-	Buckets       []Bucket        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // How to distribute collectibles in a pack
+	PackReference   AddressLocation `gorm:"embedded;embeddedPrefix:pack_ref_"`             // Reference to the pack NFT contract
+	PackCount       uint            `gorm:"column:pack_count"`                             // How many packs to create
+	Buckets         []Bucket        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // How to distribute collectibles in a pack
+	RevealNotBefore *time.Time      `gorm:"column:reveal_not_before"`                      // Optional, packs can not be revealed before this (enforced by the pack contract)
+	TeaseNotBefore  *time.Time      `gorm:"column:tease_not_before"`                       // Optional, the tiers of pack slots are disclosed from this on (two-stage reveal)
+
+	FungibleToken             FungibleTokenAmount `gorm:"embedded;embeddedPrefix:fungible_token_"` // Optional, amount of a fungible token in each pack
+	FungibleTokenReceiverPath string              `gorm:"column:fungible_token_receiver_path"`     // Public path of the token receiver of pack owners (and the issuer), e.g. 'flowTokenReceiver'
 }
 
 type Bucket struct {
@@ -35,6 +74,9 @@ type Bucket struct {
 	CollectibleReference  AddressLocation   `gorm:"embedded;embeddedPrefix:collectible_ref_"` // Reference to the collectible NFT contract
 	CollectibleCount      uint              `gorm:"column:collectible_count"`                 // How many collectibles to pick from this bucket
 	CollectibleCollection common.FlowIDList `gorm:"column:collectible_collection"`            // Collection of collectibles to pick from
+	CollectibleTiers      CollectibleTiers  `gorm:"column:collectible_tiers"`                 // Optional, tier of collectibles for the teased stage of a reveal
+	TierWeights           TierCounts        `gorm:"column:tier_weights"`                      // Optional, slots are filled by tier in proportion to these weights
+	GuaranteedTiers       TierCounts        `gorm:"column:guaranteed_tiers"`                  // Optional, number of slots of each pack guaranteed to a tier
 }
 
 type Pack struct {
@@ -45,9 +87,20 @@ type Pack struct {
 	ContractReference AddressLocation    `gorm:"embedded;embeddedPrefix:contract_ref_"` // Reference to the collectible NFT contract
 	FlowID            common.FlowID      `gorm:"column:flow_id;index"`                  // ID of the pack NFT
 	State             common.PackState   `gorm:"column:state;not null;default:null"`    // public
-	Salt              common.BinaryValue `gorm:"column:salt"`                           // private
+	Salt              common.BinaryValue `gorm:"column:salt"`                           // private, encrypted if SaltEncrypted
 	CommitmentHash    common.BinaryValue `gorm:"column:commitment_hash;index"`          // public
 	Collectibles      Collectibles       `gorm:"column:collectibles"`                   // private
+	Owner             common.FlowAddress `gorm:"column:owner;index"`                    // Believed owner, tracked from PackNFT events
+	MintQueued        bool               `gorm:"column:mint_queued"`                    // True once included in a mint transaction
+	MintAttempts      uint               `gorm:"column:mint_attempts"`                  // Number of failed mint transactions including the pack
+	MintError         string             `gorm:"column:mint_error"`                     // Error of the latest failed mint transaction
+	RevokedAt         *time.Time         `gorm:"column:revoked_at"`                     // Set when revoked, see Pack.Revoke
+	RevokeReason      string             `gorm:"column:revoke_reason"`                  // private
+	ReplacesPackID    *uuid.UUID         `gorm:"column:replaces_pack_id;index"`         // Set on a replacement pack, see PackReplacement
+
+	FungibleToken FungibleTokenAmount `gorm:"embedded;embeddedPrefix:fungible_token_"` // private, optional amount of a fungible token in the pack
+
+	SaltEncrypted bool `gorm:"column:salt_encrypted"` // Set if the salt is encrypted at rest, see SaltCipher
 }
 
 func (Distribution) TableName() string {
@@ -84,65 +137,52 @@ func (p *Pack) BeforeCreate(tx *gorm.DB) (err error) {
 // - set the distributions state to resolved
 func (dist *Distribution) Resolve() error {
 	if dist.State != common.DistributionStateInit {
-		return fmt.Errorf("distribution has to be in 'init' state, got '%s'", dist.State)
+		return newError(ErrorCodeDistributionState, "distribution has to be in 'init' state, got '%s'", dist.State)
 	}
 
 	if err := dist.Validate(); err != nil {
 		return fmt.Errorf("distribution validation error: %w", err)
 	}
 
-	packCount := int(dist.PackTemplate.PackCount)
-	packSlotCount, err := dist.PackTemplate.PackSlotCount()
+	// New distributions use the current commitment hash scheme unless given
+	if dist.CommitmentHashVersion == 0 {
+		dist.CommitmentHashVersion = CommitmentHashVersion
+	}
+
+	hasher, err := GetCommitmentHasher(dist.CommitmentHashVersion)
 	if err != nil {
 		return err
 	}
 
-	// Init packs and their slots
-	packs := make([]Pack, packCount)
-	for i := range packs {
-		packs[i].State = common.PackStateInit
-		packs[i].ContractReference = dist.PackTemplate.PackReference
-		packs[i].Collectibles = make([]Collectible, packSlotCount)
+	seed, err := newResolveSeed()
+	if err != nil {
+		return fmt.Errorf("error while generating resolve seed: %w", err)
 	}
 
 	// Distributing collectibles
-	slotBaseIndex := 0
-	for _, bucket := range dist.PackTemplate.Buckets {
-		// How many collectibles to pick from this bucket per pack
-		countPerPack := int(bucket.CollectibleCount)
-		// How many collectibles to pick from this bucket in total
-		countTotal := packCount * countPerPack
-
-		// TODO (latenssi): Is this safe enough?
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-		// Generate a slice of random indexes to bucket.CollectibleCollection
-		permutation := r.Perm(len(bucket.CollectibleCollection))
-
-		for i := 0; i < countTotal; i++ {
-			randomIndex := permutation[i]
-			packIndex := i % packCount
-			slotIndex := (i / packCount) + slotBaseIndex
-
-			collectible := Collectible{
-				ContractReference: bucket.CollectibleReference,
-				FlowID:            bucket.CollectibleCollection[randomIndex],
-			}
-
-			packs[packIndex].Collectibles[slotIndex] = collectible
-		}
+	contents, err := ResolvePacks(dist.PackTemplate, seed, ResolveAlgorithmVersion)
+	if err != nil {
+		return err
+	}
 
-		slotBaseIndex += countPerPack
+	packs := make([]Pack, len(contents))
+	for i := range packs {
+		packs[i].State = common.PackStateInit
+		packs[i].ContractReference = dist.PackTemplate.PackReference
+		packs[i].Collectibles = contents[i]
+		packs[i].FungibleToken = dist.PackTemplate.FungibleToken
 	}
 
 	// Setting commitment hashes of each pack
 	for i := range packs {
-		if err := packs[i].SetCommitmentHash(); err != nil {
+		if err := packs[i].SetCommitmentHash(hasher); err != nil {
 			return fmt.Errorf("error while hashing pack %d: %w", i+1, err)
 		}
 	}
 
 	dist.Packs = packs
+	dist.ResolveSeed = seed
+	dist.ResolveAlgorithm = ResolveAlgorithmVersion
 	dist.State = common.DistributionStateResolved
 
 	return nil
@@ -150,7 +190,7 @@ func (dist *Distribution) Resolve() error {
 
 func (dist *Distribution) SetState(target common.DistributionState, prereq common.DistributionState) error {
 	if dist.State != prereq {
-		return fmt.Errorf("distribution can not be set to '%s' from '%s'", target, dist.State)
+		return newError(ErrorCodeDistributionState, "distribution can not be set to '%s' from '%s'", target, dist.State)
 	}
 
 	dist.State = target
@@ -183,10 +223,15 @@ func (dist *Distribution) SetComplete() error {
 	return dist.SetState(common.DistributionStateComplete, common.DistributionStateMinting)
 }
 
+// SetClosed sets the status to "closed" if preceding state was valid
+func (dist *Distribution) SetClosed() error {
+	return dist.SetState(common.DistributionStateClosed, common.DistributionStateComplete)
+}
+
 // SetInvalid sets the status to "invalid" if preceding state was valid
 func (dist *Distribution) SetInvalid() error {
-	if dist.State == common.DistributionStateComplete {
-		return fmt.Errorf("distribution can not be set to '%s' from '%s'", common.DistributionStateInvalid, dist.State)
+	if dist.State == common.DistributionStateComplete || dist.State == common.DistributionStateClosed || dist.State == common.DistributionStateCancelled {
+		return newError(ErrorCodeDistributionState, "distribution can not be set to '%s' from '%s'", common.DistributionStateInvalid, dist.State)
 	}
 
 	dist.State = common.DistributionStateInvalid
@@ -194,6 +239,11 @@ func (dist *Distribution) SetInvalid() error {
 	return nil
 }
 
+// SetCancelled sets the status to "cancelled" if preceding state was valid
+func (dist *Distribution) SetCancelled() error {
+	return dist.SetState(common.DistributionStateCancelled, common.DistributionStateInvalid)
+}
+
 func (d Distribution) TemplateCollectibleCount() (int, error) {
 	packSlotCount, err := d.PackTemplate.PackSlotCount()
 	if err != nil {
@@ -202,6 +252,15 @@ func (d Distribution) TemplateCollectibleCount() (int, error) {
 	return int(d.PackTemplate.PackCount) * packSlotCount, nil
 }
 
+// CheckRevealLock returns an error wrapping ErrRevealLocked if packs of the
+// template can not yet be revealed at 'now'.
+func (pt PackTemplate) CheckRevealLock(now time.Time) error {
+	if pt.RevealNotBefore != nil && now.Before(*pt.RevealNotBefore) {
+		return fmt.Errorf("%w until %s", ErrRevealLocked, pt.RevealNotBefore.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
 // PackSlotCount returns the number of slots in each Pack described by PackTemplate
 // (sum of all buckets ColletibleCounts)
 func (pt PackTemplate) PackSlotCount() (int, error) {
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Packs of archived distributions are moved to this table, keeping the table
+// of packs small
+const archivedPacksTable = "archived_distribution_packs"
+
+// ArchivedPack is a pack of an archived distribution.
+type ArchivedPack Pack
+
+func (ArchivedPack) TableName() string {
+	return archivedPacksTable
+}
+
+// SetArchived archives a distribution which is complete or closed.
+func (dist *Distribution) SetArchived(now time.Time) error {
+	if dist.ArchivedAt != nil {
+		return newError(ErrorCodeDistributionState, "distribution is already archived")
+	}
+
+	if dist.State != common.DistributionStateComplete && dist.State != common.DistributionStateClosed {
+		return newError(ErrorCodeDistributionState, "only distributions in '%s' or '%s' state can be archived, state is '%s'", common.DistributionStateComplete, common.DistributionStateClosed, dist.State)
+	}
+
+	dist.ArchivedAt = &now
+
+	return nil
+}
+
+// archivablePackStates returns the states in which packs of 'dist' do not
+// change anymore. Reveal and open requests of closed distributions are
+// ignored, so their sealed packs stay sealed.
+func archivablePackStates(dist *Distribution) []common.PackState {
+	states := []common.PackState{common.PackStateOpened, common.PackStateEmpty, common.PackStateCancelled, common.PackStateMintFailed, common.PackStateRevoked}
+	if dist.State == common.DistributionStateClosed {
+		states = append(states, common.PackStateSealed)
+	}
+	return states
+}
+
+// packsOf returns 'db' reading and writing the packs of 'dist', archived or
+// not.
+func packsOf(db *gorm.DB, dist *Distribution) *gorm.DB {
+	if dist.ArchivedAt != nil {
+		return db.Table(archivedPacksTable)
+	}
+	return db
+}
+
+// withArchivedPacks runs 'get' on the packs and, if not found, on the packs
+// of archived distributions.
+func withArchivedPacks(db *gorm.DB, get func(db *gorm.DB) (*Pack, error)) (*Pack, error) {
+	pack, err := get(db)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return get(db.Table(archivedPacksTable))
+	}
+	return pack, err
+}
+
+// handleArchivedPackEvent handles event 'e' of a pack of an archived
+// distribution, of which only the owner is tracked.
+func handleArchivedPackEvent(db *gorm.DB, pack *Pack, eventName string, evtValueMap map[string]cadence.Value, e flow.Event, logger *log.Entry) error {
+	logger = logger.WithFields(log.Fields{"distID": pack.DistributionID, "packID": pack.ID})
+
+	if eventName != WITHDRAW && eventName != DEPOSIT {
+		logger.Warn("Event for a pack of an archived distribution, ignoring")
+		return nil
+	}
+
+	owner, err := packEventOwner(eventName, evtValueMap, e)
+	if err != nil {
+		return err
+	}
+
+	pack.Owner = owner
+
+	if err := UpdateArchivedPack(db, pack); err != nil {
+		return err
+	}
+
+	logger.WithFields(log.Fields{"owner": owner}).Debug("Archived pack owner updated")
+
+	return nil
+}
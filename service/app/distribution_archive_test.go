@@ -0,0 +1,53 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestSetArchived(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, state := range []common.DistributionState{common.DistributionStateComplete, common.DistributionStateClosed} {
+		dist := Distribution{State: state}
+		if err := dist.SetArchived(now); err != nil {
+			t.Errorf("expected '%s' to be archivable, got %v", state, err)
+		}
+		if dist.ArchivedAt == nil || !dist.ArchivedAt.Equal(now) {
+			t.Errorf("expected archivedAt to be set, got %v", dist.ArchivedAt)
+		}
+		if err := dist.SetArchived(now); ErrorCode(err) != ErrorCodeDistributionState {
+			t.Errorf("expected an error archiving twice, got %v", err)
+		}
+	}
+
+	for _, state := range []common.DistributionState{common.DistributionStateMinting, common.DistributionStateInvalid} {
+		dist := Distribution{State: state}
+		if err := dist.SetArchived(now); ErrorCode(err) != ErrorCodeDistributionState {
+			t.Errorf("expected '%s' not to be archivable, got %v", state, err)
+		}
+	}
+}
+
+func TestArchivablePackStates(t *testing.T) {
+	contains := func(states []common.PackState, state common.PackState) bool {
+		for _, s := range states {
+			if s == state {
+				return true
+			}
+		}
+		return false
+	}
+
+	complete := archivablePackStates(&Distribution{State: common.DistributionStateComplete})
+	if !contains(complete, common.PackStateOpened) || contains(complete, common.PackStateSealed) {
+		t.Errorf("expected only final pack states of a complete distribution, got %v", complete)
+	}
+
+	closed := archivablePackStates(&Distribution{State: common.DistributionStateClosed})
+	if !contains(closed, common.PackStateSealed) || contains(closed, common.PackStateRevealRequestHandled) {
+		t.Errorf("expected sealed packs of a closed distribution to be archivable, got %v", closed)
+	}
+}
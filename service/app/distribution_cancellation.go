@@ -0,0 +1,143 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	"gorm.io/gorm"
+)
+
+// DistributionCancellation tracks the cancellation of an aborted
+// distribution. Settle and mint transactions already sent when aborting can
+// not be stopped, so the packs which were never minted are cancelled (and
+// their escrowed collectibles returned) only once those have finished, see
+// ContractService.FinishCancellation. Distributions which started settling
+// are set to cancelled once the return transactions have finished.
+type DistributionCancellation struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	DistributionID uuid.UUID  `gorm:"column:distribution_id;uniqueIndex"`
+	ReturnEscrow   bool       `gorm:"column:return_escrow"` // Return the escrowed collectibles of cancelled packs to the issuer
+	Complete       bool       `gorm:"column:complete;index"`
+	CompletedAt    *time.Time `gorm:"column:completed_at"`
+
+	PacksCancelled bool `gorm:"column:packs_cancelled"` // Waiting for the return transactions once set
+
+	CancelledPackCount       uint `gorm:"column:cancelled_pack_count"`
+	ReturnedCollectibleCount uint `gorm:"column:returned_collectible_count"` // Included in return transactions
+	FailedReturnCount        uint `gorm:"column:failed_return_count"`        // Return transactions which failed or were cancelled
+
+	ReturnedFungibleTokenAmount uint64 `gorm:"column:returned_fungible_token_amount"` // In 1e-8 units, included in a return transaction
+}
+
+func (DistributionCancellation) TableName() string {
+	return "distribution_cancellations"
+}
+
+func (c *DistributionCancellation) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return nil
+}
+
+// Transactions returning escrow to the issuer of a cancelled distribution
+var returnEscrowTransactions = []string{RETURN_ESCROW_SCRIPT, RETURN_FUNGIBLE_TOKEN_ESCROW_SCRIPT}
+
+// Return transactions still to be sent or waiting for a result, and
+// dead-letter ones waiting for an admin to requeue or cancel them.
+var unfinishedReturnStates = []common.TransactionState{
+	common.TransactionStateInit,
+	common.TransactionStateRetry,
+	common.TransactionStateSent,
+	common.TransactionStateDeadLetter,
+}
+
+// Return transactions whose collectibles were left in escrow.
+var failedReturnStates = []common.TransactionState{
+	common.TransactionStateFailed,
+	common.TransactionStateCancelled,
+}
+
+// cancelledPackCollectibles returns the collectibles of the cancelled packs
+// in 'packs'.
+func cancelledPackCollectibles(packs []Pack) map[Collectible]bool {
+	res := make(map[Collectible]bool)
+	for _, p := range packs {
+		if p.State != common.PackStateCancelled {
+			continue
+		}
+		for _, c := range p.Collectibles {
+			res[c] = true
+		}
+	}
+	return res
+}
+
+// returnEscrowBatches groups 'cc' by contract in batches of at most
+// 'batchSize', ordered by contract and FlowID.
+func returnEscrowBatches(cc SettlementCollectibles, batchSize int) []SettlementCollectibles {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	sorted := make(SettlementCollectibles, len(cc))
+	copy(sorted, cc)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].ContractReference, sorted[j].ContractReference
+		if a != b {
+			return a.String() < b.String()
+		}
+		return sorted[i].FlowID.LessThan(sorted[j].FlowID)
+	})
+
+	res := []SettlementCollectibles{}
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && j-i < batchSize && sorted[j].ContractReference == sorted[i].ContractReference {
+			j++
+		}
+		res = append(res, sorted[i:j])
+		i = j
+	}
+
+	return res
+}
+
+// newReturnEscrowTransaction returns a transaction returning 'collectibles'
+// (all of 'contract') from escrow to the issuer of 'dist'.
+func newReturnEscrowTransaction(dist *Distribution, contract AddressLocation, collectibles SettlementCollectibles) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(
+		RETURN_ESCROW_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			CollectibleNFTName:    contract.Name,
+			CollectibleNFTAddress: contract.Address.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	flowIDs := make([]cadence.Value, len(collectibles))
+	for i, c := range collectibles {
+		flowIDs[i] = cadence.UInt64(c.FlowID.Int64)
+	}
+
+	arguments := []cadence.Value{
+		cadence.Address(dist.Issuer),
+		cadence.NewArray(flowIDs),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(RETURN_ESCROW_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.BatchSize = len(collectibles)
+
+	return t, nil
+}
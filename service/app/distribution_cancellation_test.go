@@ -0,0 +1,93 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestCancelledPackCollectibles(t *testing.T) {
+	contract := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+	collectible := func(id int64) Collectible {
+		return Collectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: contract}
+	}
+
+	packs := []Pack{
+		{State: common.PackStateCancelled, Collectibles: Collectibles{collectible(1), collectible(2)}},
+		{State: common.PackStateSealed, Collectibles: Collectibles{collectible(3)}},
+		{State: common.PackStateCancelled, Collectibles: Collectibles{collectible(4)}},
+	}
+
+	res := cancelledPackCollectibles(packs)
+
+	if len(res) != 3 || !res[collectible(1)] || !res[collectible(2)] || !res[collectible(4)] {
+		t.Errorf("unexpected collectibles: %v", res)
+	}
+
+	if res[collectible(3)] {
+		t.Error("expected collectibles of minted packs to be excluded")
+	}
+}
+
+func TestReturnEscrowBatches(t *testing.T) {
+	a := AddressLocation{Name: "A", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+	b := AddressLocation{Name: "B", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+	collectible := func(contract AddressLocation, id int64) SettlementCollectible {
+		return SettlementCollectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: contract}
+	}
+
+	cc := SettlementCollectibles{
+		collectible(b, 2), collectible(a, 3), collectible(a, 1),
+		collectible(b, 1), collectible(a, 2),
+	}
+
+	batches := returnEscrowBatches(cc, 2)
+
+	expected := []struct {
+		contract AddressLocation
+		ids      []int64
+	}{
+		{a, []int64{1, 2}},
+		{a, []int64{3}},
+		{b, []int64{1, 2}},
+	}
+
+	if len(batches) != len(expected) {
+		t.Fatalf("expected %d batches, got %d", len(expected), len(batches))
+	}
+
+	for i, batch := range batches {
+		if len(batch) != len(expected[i].ids) {
+			t.Fatalf("batch %d: expected %d collectibles, got %d", i, len(expected[i].ids), len(batch))
+		}
+		for j, c := range batch {
+			if c.ContractReference != expected[i].contract || c.FlowID.Int64 != expected[i].ids[j] {
+				t.Errorf("batch %d: unexpected collectible %d: %v", i, j, c)
+			}
+		}
+	}
+
+	if len(returnEscrowBatches(nil, 2)) != 0 {
+		t.Error("expected no batches")
+	}
+}
+
+func TestSetCancelled(t *testing.T) {
+	dist := Distribution{State: common.DistributionStateSettling}
+	if err := dist.SetCancelled(); err == nil {
+		t.Error("expected an error for a distribution which is not aborted")
+	}
+
+	dist.State = common.DistributionStateInvalid
+	if err := dist.SetCancelled(); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateCancelled {
+		t.Errorf("expected state '%s', got '%s'", common.DistributionStateCancelled, dist.State)
+	}
+
+	if err := dist.SetInvalid(); err == nil {
+		t.Error("expected a cancelled distribution not to be aborted again")
+	}
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Clone returns a draft distribution with the bucket structure, metadata and
+// settings of 'dist' but no collectibles, for a recurring drop with 'flowID'.
+// The collectibles of its buckets are given by updating the draft, which
+// resolves it, see DistributionUpdate. Times of 'dist' (reveal time lock,
+// teased stage, settlement start) belong to its drop and are left out.
+func (dist *Distribution) Clone(flowID common.FlowID) Distribution {
+	buckets := make([]Bucket, len(dist.PackTemplate.Buckets))
+	for i, b := range dist.PackTemplate.Buckets {
+		buckets[i] = Bucket{
+			CollectibleReference: b.CollectibleReference,
+			CollectibleCount:     b.CollectibleCount,
+			TierWeights:          b.TierWeights,
+			GuaranteedTiers:      b.GuaranteedTiers,
+		}
+	}
+
+	return Distribution{
+		FlowID: flowID,
+		Issuer: dist.Issuer,
+		State:  common.DistributionStateDraft,
+		PackTemplate: PackTemplate{
+			PackReference:             dist.PackTemplate.PackReference,
+			PackCount:                 dist.PackTemplate.PackCount,
+			Buckets:                   buckets,
+			FungibleToken:             dist.PackTemplate.FungibleToken,
+			FungibleTokenReceiverPath: dist.PackTemplate.FungibleTokenReceiverPath,
+		},
+		Metadata: DistributionMetadata{
+			Title:       dist.Metadata.Title,
+			Description: dist.Metadata.Description,
+			ImageURI:    dist.Metadata.ImageURI,
+		},
+		AccessAPIHost:    dist.AccessAPIHost,
+		RevealWebhookURL: dist.RevealWebhookURL,
+		CollectionID:     dist.CollectionID,
+		TemplateID:       dist.TemplateID,
+		SeedCommitReveal: dist.SeedCommitReveal,
+	}
+}
+
+// fillDraftBuckets fills in what the 'buckets' updating a draft leave out
+// from the buckets of the draft, in the same order.
+func fillDraftBuckets(buckets []Bucket, draft []Bucket) {
+	if len(buckets) != len(draft) {
+		return
+	}
+
+	for i, b := range draft {
+		if buckets[i].CollectibleReference.Name == "" {
+			buckets[i].CollectibleReference = b.CollectibleReference
+		}
+		if buckets[i].CollectibleCount == 0 {
+			buckets[i].CollectibleCount = b.CollectibleCount
+		}
+		if buckets[i].TierWeights == nil {
+			buckets[i].TierWeights = b.TierWeights
+		}
+		if buckets[i].GuaranteedTiers == nil {
+			buckets[i].GuaranteedTiers = b.GuaranteedTiers
+		}
+	}
+}
+
+// CloneDistribution creates a draft distribution from the distribution 'id',
+// see Distribution.Clone.
+func (app *App) CloneDistribution(ctx context.Context, id uuid.UUID, flowID common.FlowID) (*Distribution, error) {
+	if !flowID.Valid {
+		return nil, newError(ErrorCodeDistributionInvalid, "distFlowID is required")
+	}
+
+	source, err := GetDistributionWithBuckets(app.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := source.Clone(flowID)
+
+	if err := InsertDistribution(app.db, &clone, app.cfg.BatchInsertSize); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"distID":     clone.ID,
+		"distFlowID": clone.FlowID,
+		"sourceID":   source.ID,
+	}).Info("Distribution cloned")
+
+	return &clone, nil
+}
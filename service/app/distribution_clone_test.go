@@ -0,0 +1,76 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func TestDistributionClone(t *testing.T) {
+	collectible := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+	collectionID := uuid.New()
+	revealNotBefore := time.Now()
+
+	dist := Distribution{
+		ID:     uuid.New(),
+		FlowID: common.FlowID{Int64: 1, Valid: true},
+		Issuer: common.FlowAddressFromString("f3fcd2c1a78f5eee"),
+		State:  common.DistributionStateComplete,
+		PackTemplate: PackTemplate{
+			PackCount:       2,
+			RevealNotBefore: &revealNotBefore,
+			Buckets: []Bucket{
+				{
+					ID:                    uuid.New(),
+					CollectibleReference:  collectible,
+					CollectibleCount:      1,
+					CollectibleCollection: common.FlowIDList{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}},
+					CollectibleTiers:      CollectibleTiers{1: "rare", 2: "common"},
+					TierWeights:           TierCounts{"rare": 1, "common": 3},
+				},
+			},
+		},
+		Packs:            []Pack{{}, {}},
+		RevealWebhookURL: "https://example.com/reveals",
+		CollectionID:     &collectionID,
+	}
+
+	clone := dist.Clone(common.FlowID{Int64: 2, Valid: true})
+
+	if clone.State != common.DistributionStateDraft {
+		t.Errorf("expected state %s, got %s", common.DistributionStateDraft, clone.State)
+	}
+	if clone.FlowID.Int64 != 2 || clone.Issuer != dist.Issuer {
+		t.Errorf("expected the given distFlowID and the same issuer, got %s and %s", clone.FlowID, clone.Issuer)
+	}
+	if clone.PackTemplate.PackCount != 2 || clone.RevealWebhookURL != dist.RevealWebhookURL || clone.CollectionID != dist.CollectionID {
+		t.Errorf("expected the settings of the distribution to be cloned")
+	}
+	if clone.PackTemplate.RevealNotBefore != nil {
+		t.Errorf("expected the reveal time lock to be left out")
+	}
+	if clone.Packs != nil {
+		t.Errorf("expected no packs")
+	}
+
+	b := clone.PackTemplate.Buckets[0]
+	if b.CollectibleReference != collectible || b.CollectibleCount != 1 || b.TierWeights["common"] != 3 {
+		t.Errorf("expected the bucket structure to be cloned, got %+v", b)
+	}
+	if b.CollectibleCollection != nil || b.CollectibleTiers != nil || b.ID != uuid.Nil {
+		t.Errorf("expected the bucket collectibles to be left out, got %+v", b)
+	}
+
+	// Updating the draft only needs the collectibles of its buckets
+	DistributionUpdate{Buckets: []Bucket{{CollectibleCollection: common.FlowIDList{{Int64: 5, Valid: true}}}}}.Apply(&clone)
+
+	b = clone.PackTemplate.Buckets[0]
+	if clone.State != common.DistributionStateInit {
+		t.Errorf("expected state %s, got %s", common.DistributionStateInit, clone.State)
+	}
+	if b.CollectibleReference != collectible || b.CollectibleCount != 1 || len(b.CollectibleCollection) != 1 {
+		t.Errorf("expected the bucket to be filled in from the draft, got %+v", b)
+	}
+}
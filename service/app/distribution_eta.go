@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+)
+
+// How many of the latest sealed batch transactions of a distribution the
+// seal latency is averaged over
+const etaLatencySampleSize = 20
+
+// DistributionETA is an estimate of when the settlement and minting of a
+// distribution in progress complete. It assumes the distribution has the
+// send rate to itself, batches of other distributions and user facing
+// transactions sent meanwhile delay it. Estimates are nil for a distribution
+// which is not settling or minting, and for a stage already complete.
+type DistributionETA struct {
+	DistributionID uuid.UUID
+	Progress       DistributionProgress
+
+	SettleBatches uint // Settle transactions left, of the current batch size
+	MintBatches   uint // Mint transactions left, of the current batch size
+
+	// Average from sending a batch transaction to its result, of the latest
+	// sealed batches of the distribution or EstimatedSealLatency in config
+	SealLatency         time.Duration
+	SealLatencyObserved bool
+
+	SettledAt *time.Time
+	MintedAt  *time.Time // The distribution completes once minted
+}
+
+// etaParams are the rates and sizes settlement and minting progress at.
+type etaParams struct {
+	SendRate         int // Transactions per second, 0 for no limit
+	SettleBatchSize  int
+	MintBatchSize    int
+	SettleMaxPending int // 0 for no limit
+	MintMaxPending   int // 0 for no limit
+	SealLatency      time.Duration
+}
+
+// batchCount returns how many batches of 'size' 'count' items take.
+func batchCount(count uint, size int) uint {
+	if size < 1 {
+		size = 1
+	}
+	return (count + uint(size) - 1) / uint(size)
+}
+
+// stageDuration returns how long sending 'batches' transactions and waiting
+// for the last one to seal takes. Transactions are sent one per send rate
+// interval, or one per 'maxPending'th of the seal latency if fewer may be
+// pending at the same time.
+func stageDuration(batches uint, sendRate, maxPending int, latency time.Duration) time.Duration {
+	if batches == 0 {
+		return 0
+	}
+
+	var interval time.Duration
+	if sendRate > 0 {
+		interval = time.Second / time.Duration(sendRate)
+	}
+	if maxPending > 0 {
+		if i := latency / time.Duration(maxPending); i > interval {
+			interval = i
+		}
+	}
+
+	return time.Duration(batches-1)*interval + latency
+}
+
+// estimateCompletion returns the estimate of when the settlement and minting
+// of a distribution at 'progress' complete at 'now'. While settling, minting
+// is estimated to start once settled.
+func estimateCompletion(now time.Time, progress DistributionProgress, params etaParams) DistributionETA {
+	eta := DistributionETA{Progress: progress, SealLatency: params.SealLatency}
+
+	var mintStart time.Time
+
+	switch progress.State {
+	case common.DistributionStateSettling:
+		if progress.SettleTotal == 0 {
+			// Settlement not set up yet
+			return eta
+		}
+		left := uint(0)
+		if progress.SettleTotal > progress.SettledCount {
+			left = progress.SettleTotal - progress.SettledCount
+		}
+		eta.SettleBatches = batchCount(left, params.SettleBatchSize)
+		settledAt := now.Add(stageDuration(eta.SettleBatches, params.SendRate, params.SettleMaxPending, params.SealLatency))
+		eta.SettledAt = &settledAt
+		mintStart = settledAt
+	case common.DistributionStateMinting:
+		mintStart = now
+	default:
+		return eta
+	}
+
+	left := uint(0)
+	if progress.PackCount > progress.MintedCount {
+		left = progress.PackCount - progress.MintedCount
+	}
+	eta.MintBatches = batchCount(left, params.MintBatchSize)
+	mintedAt := mintStart.Add(stageDuration(eta.MintBatches, params.SendRate, params.MintMaxPending, params.SealLatency))
+	eta.MintedAt = &mintedAt
+
+	return eta
+}
+
+// averageLatency returns the average time from sending to the result of
+// 'attempts', false if there are none.
+func averageLatency(attempts []transactions.TransactionAttempt) (time.Duration, bool) {
+	if len(attempts) == 0 {
+		return 0, false
+	}
+
+	var sum time.Duration
+	for _, a := range attempts {
+		sum += a.UpdatedAt.Sub(a.CreatedAt)
+	}
+	return sum / time.Duration(len(attempts)), true
+}
+
+// GetDistributionETA returns the estimate of when the settlement and minting
+// of a distribution complete, from its progress, the send rate, the current
+// batch sizes and the seal latency of its latest sealed batches. Estimates
+// are updated as batches seal.
+func (app *App) GetDistributionETA(ctx context.Context, distributionID uuid.UUID) (*DistributionETA, error) {
+	progress, err := app.GetDistributionProgress(ctx, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := transactions.ListRecentAttempts(app.db, distributionID, []string{SETTLE_SCRIPT, MINT_SCRIPT}, common.TransactionStateComplete, etaLatencySampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	latency, observed := averageLatency(attempts)
+	if !observed {
+		latency = app.cfg.EstimatedSealLatency
+	}
+
+	eta := estimateCompletion(app.clock.Now(), *progress, etaParams{
+		SendRate:         app.cfg.TransactionSendRate,
+		SettleBatchSize:  app.service.batchSize(app.service.settleBatchSizer),
+		MintBatchSize:    app.service.batchSize(app.service.mintBatchSizer),
+		SettleMaxPending: app.cfg.SettlementMaxPendingBatches,
+		MintMaxPending:   app.cfg.MintingMaxPendingBatches,
+		SealLatency:      latency,
+	})
+	eta.DistributionID = distributionID
+	eta.SealLatencyObserved = observed
+
+	return &eta, nil
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"gorm.io/gorm"
+)
+
+func TestStageDuration(t *testing.T) {
+	latency := 10 * time.Second
+
+	if d := stageDuration(0, 10, 0, latency); d != 0 {
+		t.Errorf("expected no batches to take no time, got %s", d)
+	}
+
+	if d := stageDuration(1, 10, 0, latency); d != latency {
+		t.Errorf("expected a single batch to take the seal latency, got %s", d)
+	}
+
+	// One per 100ms at 10 per second
+	if d := stageDuration(11, 10, 0, latency); d != 11*time.Second {
+		t.Errorf("expected 11 batches to take 11s, got %s", d)
+	}
+
+	// Two pending at a time, one per 5s
+	if d := stageDuration(11, 10, 2, latency); d != time.Minute {
+		t.Errorf("expected 11 batches of at most 2 pending to take 1m, got %s", d)
+	}
+
+	if d := stageDuration(11, 0, 0, latency); d != latency {
+		t.Errorf("expected batches without a send rate to take the seal latency, got %s", d)
+	}
+}
+
+func TestEstimateCompletion(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	params := etaParams{SendRate: 10, SettleBatchSize: 40, MintBatchSize: 40, SealLatency: 10 * time.Second}
+
+	settling := DistributionProgress{State: common.DistributionStateSettling, SettledCount: 20, SettleTotal: 100, PackCount: 50}
+	eta := estimateCompletion(now, settling, params)
+	if eta.SettleBatches != 2 || eta.MintBatches != 2 {
+		t.Fatalf("expected 2 settle and 2 mint batches, got %d and %d", eta.SettleBatches, eta.MintBatches)
+	}
+	if eta.SettledAt == nil || !eta.SettledAt.Equal(now.Add(10100*time.Millisecond)) {
+		t.Errorf("unexpected settlement estimate %v", eta.SettledAt)
+	}
+	if eta.MintedAt == nil || !eta.MintedAt.Equal(now.Add(20200*time.Millisecond)) {
+		t.Errorf("unexpected minting estimate %v", eta.MintedAt)
+	}
+
+	minting := DistributionProgress{State: common.DistributionStateMinting, SettledCount: 100, SettleTotal: 100, MintedCount: 45, PackCount: 50}
+	eta = estimateCompletion(now, minting, params)
+	if eta.SettledAt != nil || eta.SettleBatches != 0 {
+		t.Errorf("expected no settlement estimate while minting, got %v", eta.SettledAt)
+	}
+	if eta.MintBatches != 1 || eta.MintedAt == nil || !eta.MintedAt.Equal(now.Add(10*time.Second)) {
+		t.Errorf("unexpected minting estimate %v", eta.MintedAt)
+	}
+
+	for _, p := range []DistributionProgress{
+		{State: common.DistributionStateSettling, PackCount: 50}, // Settlement not set up yet
+		{State: common.DistributionStateResolved, PackCount: 50},
+		{State: common.DistributionStateComplete, MintedCount: 50, PackCount: 50},
+	} {
+		if eta := estimateCompletion(now, p, params); eta.SettledAt != nil || eta.MintedAt != nil {
+			t.Errorf("expected no estimates in %+v, got %v and %v", p, eta.SettledAt, eta.MintedAt)
+		}
+	}
+}
+
+func TestAverageLatency(t *testing.T) {
+	if _, ok := averageLatency(nil); ok {
+		t.Errorf("expected no latency without attempts")
+	}
+
+	sent := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	attempts := []transactions.TransactionAttempt{
+		{Model: gorm.Model{CreatedAt: sent, UpdatedAt: sent.Add(4 * time.Second)}},
+		{Model: gorm.Model{CreatedAt: sent, UpdatedAt: sent.Add(8 * time.Second)}},
+	}
+	if d, ok := averageLatency(attempts); !ok || d != 6*time.Second {
+		t.Errorf("expected an average of 6s, got %s", d)
+	}
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"gorm.io/gorm"
+)
+
+// Formats of a distribution export
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+const exportCollectiblesDelim = " "
+
+// DistributionExport is the manifest of the packs of a minted distribution,
+// written in batches so large distributions are not held in memory.
+type DistributionExport struct {
+	DistributionID uuid.UUID
+	Format         string
+	IncludeSalt    bool // Salts allow verifying commitment hashes, of revealed packs only
+
+	db        *gorm.DB
+	batchSize int
+	salts     *SaltCipher // Decrypts the salts to include
+}
+
+// distributionExportPack is one pack of a distribution export.
+type distributionExportPack struct {
+	PackID         uuid.UUID           `json:"packID"`
+	FlowID         common.FlowID       `json:"flowID"`
+	State          common.PackState    `json:"state"`
+	CommitmentHash common.BinaryValue  `json:"commitmentHash"`
+	Salt           common.BinaryValue  `json:"salt,omitempty"`
+	Collectibles   []string            `json:"collectibles"`
+	Owner          *common.FlowAddress `json:"owner"` // Nil if not known
+}
+
+// validateExport checks a distribution in 'state' can be exported in 'format'.
+func validateExport(state common.DistributionState, format string) error {
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		return fmt.Errorf("unknown export format '%s', expected '%s' or '%s'", format, ExportFormatJSON, ExportFormatCSV)
+	}
+
+	if state != common.DistributionStateComplete && state != common.DistributionStateClosed {
+		return newError(ErrorCodeDistributionState, "only distributions in '%s' or '%s' state can be exported, state is '%s'", common.DistributionStateComplete, common.DistributionStateClosed, state)
+	}
+
+	return nil
+}
+
+// ContentType returns the media type of the export.
+func (e *DistributionExport) ContentType() string {
+	if e.Format == ExportFormatCSV {
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+// FileName returns the name to download the export as.
+func (e *DistributionExport) FileName() string {
+	return fmt.Sprintf("distribution-%s.%s", e.DistributionID, e.Format)
+}
+
+// Write writes the export to 'w', a JSON array or CSV with a header row.
+func (e *DistributionExport) Write(w io.Writer) error {
+	write, flush := e.jsonWriter(w)
+	if e.Format == ExportFormatCSV {
+		write, flush = e.csvWriter(w)
+	}
+
+	err := DistributionPacksInBatches(e.db, e.DistributionID, e.batchSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+		for i := range batch {
+			if e.IncludeSalt && packContentsPublic(batch[i].State) {
+				salt, err := e.salts.PackSalt(&batch[i])
+				if err != nil {
+					return err
+				}
+				batch[i].Salt = salt
+			}
+			if err := write(e.exportPack(&batch[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// exportPack returns 'p' as exported. The contents and salt of a pack are
+// left out until it is revealed, as they would disclose what it holds.
+func (e *DistributionExport) exportPack(p *Pack) distributionExportPack {
+	res := distributionExportPack{
+		PackID:         p.ID,
+		FlowID:         p.FlowID,
+		State:          p.State,
+		CommitmentHash: p.CommitmentHash,
+		Collectibles:   []string{},
+	}
+
+	if packContentsPublic(p.State) {
+		if e.IncludeSalt {
+			res.Salt = p.Salt
+		}
+		for _, c := range p.Collectibles {
+			res.Collectibles = append(res.Collectibles, c.String())
+		}
+	}
+
+	if p.Owner != common.FlowAddress(flow.EmptyAddress) {
+		owner := p.Owner
+		res.Owner = &owner
+	}
+
+	return res
+}
+
+func (e *DistributionExport) jsonWriter(w io.Writer) (func(distributionExportPack) error, func() error) {
+	count := 0
+
+	write := func(p distributionExportPack) error {
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		delim := ","
+		if count == 0 {
+			delim = "["
+		}
+		count++
+		_, err = fmt.Fprintf(w, "%s\n%s", delim, b)
+		return err
+	}
+
+	flush := func() error {
+		end := "\n]\n"
+		if count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(w, end)
+		return err
+	}
+
+	return write, flush
+}
+
+func (e *DistributionExport) csvWriter(w io.Writer) (func(distributionExportPack) error, func() error) {
+	cw := csv.NewWriter(w)
+	header := false
+
+	write := func(p distributionExportPack) error {
+		if !header {
+			header = true
+			if err := cw.Write(e.csvHeader()); err != nil {
+				return err
+			}
+		}
+
+		owner := ""
+		if p.Owner != nil {
+			owner = p.Owner.String()
+		}
+
+		row := []string{p.PackID.String(), "", string(p.State), p.CommitmentHash.String()}
+		if p.FlowID.Valid {
+			row[1] = p.FlowID.String()
+		}
+		if e.IncludeSalt {
+			row = append(row, p.Salt.String())
+		}
+		row = append(row, strings.Join(p.Collectibles, exportCollectiblesDelim), owner)
+
+		return cw.Write(row)
+	}
+
+	flush := func() error {
+		if !header {
+			if err := cw.Write(e.csvHeader()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return write, flush
+}
+
+func (e *DistributionExport) csvHeader() []string {
+	header := []string{"packID", "flowID", "state", "commitmentHash"}
+	if e.IncludeSalt {
+		header = append(header, "salt")
+	}
+	return append(header, "collectibles", "owner")
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func exportTestPacks() []Pack {
+	collectible := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+	return []Pack{
+		{
+			ID:             uuid.MustParse("7b5e8b4a-1c4f-4b0e-9c3a-2a6b1d2e3f40"),
+			FlowID:         common.FlowID{Int64: 1, Valid: true},
+			State:          common.PackStateOpened,
+			CommitmentHash: common.BinaryValue{0xab},
+			Salt:           common.BinaryValue{0xcd},
+			Collectibles: Collectibles{
+				{FlowID: common.FlowID{Int64: 10, Valid: true}, ContractReference: collectible},
+				{FlowID: common.FlowID{Int64: 11, Valid: true}, ContractReference: collectible},
+			},
+			Owner: common.FlowAddressFromString("f3fcd2c1a78f5eee"),
+		},
+		{
+			ID:             uuid.MustParse("5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d"),
+			FlowID:         common.FlowID{Int64: 2, Valid: true},
+			State:          common.PackStateSealed,
+			CommitmentHash: common.BinaryValue{0x12},
+			Salt:           common.BinaryValue{0x34},
+			Collectibles: Collectibles{
+				{FlowID: common.FlowID{Int64: 12, Valid: true}, ContractReference: collectible},
+			},
+		},
+		{
+			ID:             uuid.MustParse("0d1c2b3a-4f5e-4d6c-8b7a-9a8b7c6d5e4f"),
+			State:          common.PackStateCancelled,
+			CommitmentHash: common.BinaryValue{0xef},
+			Salt:           common.BinaryValue{0x01},
+		},
+	}
+}
+
+func writeTestExport(t *testing.T, e *DistributionExport, packs []Pack) string {
+	var buf bytes.Buffer
+	write, flush := e.jsonWriter(&buf)
+	if e.Format == ExportFormatCSV {
+		write, flush = e.csvWriter(&buf)
+	}
+	for i := range packs {
+		if err := write(e.exportPack(&packs[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestValidateExport(t *testing.T) {
+	if err := validateExport(common.DistributionStateComplete, ExportFormatCSV); err != nil {
+		t.Error(err)
+	}
+	if err := validateExport(common.DistributionStateClosed, ExportFormatJSON); err != nil {
+		t.Error(err)
+	}
+	if err := validateExport(common.DistributionStateMinting, ExportFormatJSON); err == nil {
+		t.Error("expected an error for a distribution still minting")
+	}
+	if err := validateExport(common.DistributionStateComplete, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestDistributionExportCSV(t *testing.T) {
+	e := &DistributionExport{Format: ExportFormatCSV}
+
+	expected := "packID,flowID,state,commitmentHash,collectibles,owner\n" +
+		"7b5e8b4a-1c4f-4b0e-9c3a-2a6b1d2e3f40,1,opened,ab,A.01cf0e2f2f715450.ExampleNFT.10 A.01cf0e2f2f715450.ExampleNFT.11,f3fcd2c1a78f5eee\n" +
+		"5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d,2,sealed,12,,\n" +
+		"0d1c2b3a-4f5e-4d6c-8b7a-9a8b7c6d5e4f,,cancelled,ef,,\n"
+	if res := writeTestExport(t, e, exportTestPacks()); res != expected {
+		t.Errorf("unexpected export:\n%s", res)
+	}
+
+	e.IncludeSalt = true
+	res := writeTestExport(t, e, exportTestPacks())
+	if !strings.HasPrefix(res, "packID,flowID,state,commitmentHash,salt,collectibles,owner\n7b5e8b4a-1c4f-4b0e-9c3a-2a6b1d2e3f40,1,opened,ab,cd,") {
+		t.Errorf("expected the salts in the export:\n%s", res)
+	}
+	if !strings.Contains(res, "\n5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d,2,sealed,12,,,\n") {
+		t.Errorf("expected no salt nor collectibles for a sealed pack:\n%s", res)
+	}
+
+	if res := writeTestExport(t, &DistributionExport{Format: ExportFormatCSV}, nil); res != "packID,flowID,state,commitmentHash,collectibles,owner\n" {
+		t.Errorf("expected only the header for no packs, got %q", res)
+	}
+}
+
+func TestDistributionExportJSON(t *testing.T) {
+	e := &DistributionExport{Format: ExportFormatJSON}
+
+	var packs []map[string]interface{}
+	if err := json.Unmarshal([]byte(writeTestExport(t, e, exportTestPacks())), &packs); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packs) != 3 {
+		t.Fatalf("expected 3 packs, got %d", len(packs))
+	}
+	if _, ok := packs[0]["salt"]; ok {
+		t.Error("expected no salt unless included")
+	}
+	if packs[0]["owner"] != "f3fcd2c1a78f5eee" || packs[2]["owner"] != nil {
+		t.Errorf("unexpected owners %v, %v", packs[0]["owner"], packs[2]["owner"])
+	}
+	if c, ok := packs[0]["collectibles"].([]interface{}); !ok || len(c) != 2 || c[0] != "A.01cf0e2f2f715450.ExampleNFT.10" {
+		t.Errorf("unexpected collectibles %v", packs[0]["collectibles"])
+	}
+
+	e.IncludeSalt = true
+	if err := json.Unmarshal([]byte(writeTestExport(t, e, exportTestPacks())), &packs); err != nil {
+		t.Fatal(err)
+	}
+	if packs[0]["salt"] != "cd" {
+		t.Errorf("expected the salt, got %v", packs[0]["salt"])
+	}
+	if c, ok := packs[1]["collectibles"].([]interface{}); !ok || len(c) != 0 || packs[1]["salt"] != nil {
+		t.Errorf("expected no collectibles nor salt for a sealed pack, got %v and %v", packs[1]["collectibles"], packs[1]["salt"])
+	}
+
+	if res := writeTestExport(t, e, nil); res != "[]\n" {
+		t.Errorf("expected an empty array for no packs, got %q", res)
+	}
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+// Columns of a CSV of bucket definitions, one row per bucket. Rows with the
+// same distFlowID make up one distribution, the distribution columns have to
+// be the same in each of its rows.
+const (
+	importColumnDistFlowID            = "distFlowID"
+	importColumnIssuer                = "issuer"
+	importColumnPackReference         = "packReference"
+	importColumnPackCount             = "packCount"
+	importColumnCollectibleReference  = "collectibleReference"
+	importColumnCollectibleCount      = "collectibleCount"
+	importColumnCollectibleCollection = "collectibleCollection"
+	importColumnRevealNotBefore       = "revealNotBefore" // Optional
+	importColumnCollectionID          = "collectionID"    // Optional
+)
+
+var importRequiredColumns = []string{
+	importColumnDistFlowID,
+	importColumnIssuer,
+	importColumnPackReference,
+	importColumnPackCount,
+	importColumnCollectibleReference,
+	importColumnCollectibleCount,
+	importColumnCollectibleCollection,
+}
+
+// Columns which are the same in each row of a distribution
+var importDistributionColumns = []string{
+	importColumnIssuer,
+	importColumnPackReference,
+	importColumnPackCount,
+	importColumnRevealNotBefore,
+	importColumnCollectionID,
+}
+
+// ParseDistributionsCSV parses the distributions defined by a CSV of bucket
+// definitions with a header row, see the importColumn constants. Collectible
+// IDs of a collection are separated by spaces like in exports. Distributions
+// are returned in the order of their first row.
+func ParseDistributionsCSV(r io.Reader) ([]Distribution, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, newError(ErrorCodeDistributionInvalid, "empty CSV, expected a header row")
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, newError(ErrorCodeDistributionInvalid, "CSV is missing column '%s'", name)
+		}
+	}
+
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	distributions := []Distribution{}
+	firstRows := map[string][]string{} // distFlowID -> first row of the distribution
+	indexes := map[string]int{}        // distFlowID -> index in 'distributions'
+
+	for rowNumber := 2; ; rowNumber++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, withCode(ErrorCodeDistributionInvalid, err)
+		}
+
+		key := value(row, importColumnDistFlowID)
+
+		if first, ok := firstRows[key]; ok {
+			for _, name := range importDistributionColumns {
+				if value(row, name) != value(first, name) {
+					return nil, newError(ErrorCodeDistributionInvalid, "row %d: column '%s' differs from earlier rows of distribution %s", rowNumber, name, key)
+				}
+			}
+		} else {
+			dist, err := importDistribution(row, value)
+			if err != nil {
+				return nil, withCode(ErrorCodeDistributionInvalid, fmt.Errorf("row %d: %w", rowNumber, err))
+			}
+			firstRows[key] = row
+			indexes[key] = len(distributions)
+			distributions = append(distributions, dist)
+		}
+
+		bucket, err := importBucket(row, value)
+		if err != nil {
+			return nil, withCode(ErrorCodeDistributionInvalidBucket, fmt.Errorf("row %d: %w", rowNumber, err))
+		}
+
+		pt := &distributions[indexes[key]].PackTemplate
+		pt.Buckets = append(pt.Buckets, bucket)
+	}
+
+	if len(distributions) == 0 {
+		return nil, newError(ErrorCodeDistributionInvalid, "CSV has no bucket definitions")
+	}
+
+	return distributions, nil
+}
+
+func importDistribution(row []string, value func([]string, string) string) (Distribution, error) {
+	flowID, err := common.FlowIDFromString(value(row, importColumnDistFlowID))
+	if err != nil || !flowID.Valid {
+		return Distribution{}, fmt.Errorf("invalid %s '%s'", importColumnDistFlowID, value(row, importColumnDistFlowID))
+	}
+
+	packReference, err := AddressLocationFromString(value(row, importColumnPackReference))
+	if err != nil {
+		return Distribution{}, err
+	}
+
+	packCount, err := strconv.ParseUint(value(row, importColumnPackCount), 10, 32)
+	if err != nil {
+		return Distribution{}, fmt.Errorf("invalid %s '%s'", importColumnPackCount, value(row, importColumnPackCount))
+	}
+
+	dist := Distribution{
+		State:  common.DistributionStateInit,
+		FlowID: flowID,
+		Issuer: common.FlowAddressFromString(value(row, importColumnIssuer)),
+		PackTemplate: PackTemplate{
+			PackReference: packReference,
+			PackCount:     uint(packCount),
+		},
+	}
+
+	if s := value(row, importColumnRevealNotBefore); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("invalid %s '%s', expected RFC 3339", importColumnRevealNotBefore, s)
+		}
+		dist.PackTemplate.RevealNotBefore = &t
+	}
+
+	if s := value(row, importColumnCollectionID); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return Distribution{}, fmt.Errorf("invalid %s '%s'", importColumnCollectionID, s)
+		}
+		dist.CollectionID = &id
+	}
+
+	return dist, nil
+}
+
+func importBucket(row []string, value func([]string, string) string) (Bucket, error) {
+	collectibleReference, err := AddressLocationFromString(value(row, importColumnCollectibleReference))
+	if err != nil {
+		return Bucket{}, err
+	}
+
+	collectibleCount, err := strconv.ParseUint(value(row, importColumnCollectibleCount), 10, 32)
+	if err != nil {
+		return Bucket{}, fmt.Errorf("invalid %s '%s'", importColumnCollectibleCount, value(row, importColumnCollectibleCount))
+	}
+
+	fields := strings.Fields(value(row, importColumnCollectibleCollection))
+	collection := make(common.FlowIDList, len(fields))
+	for i, s := range fields {
+		id, err := common.FlowIDFromString(s)
+		if err != nil {
+			return Bucket{}, fmt.Errorf("invalid collectible ID '%s'", s)
+		}
+		collection[i] = id
+	}
+
+	return Bucket{
+		CollectibleReference:  collectibleReference,
+		CollectibleCount:      uint(collectibleCount),
+		CollectibleCollection: collection,
+	}, nil
+}
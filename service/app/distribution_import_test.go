@@ -0,0 +1,89 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestParseDistributionsCSV(t *testing.T) {
+	csv := `distFlowID,issuer,packReference,packCount,collectibleReference,collectibleCount,collectibleCollection
+1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,2,A.01cf0e2f2f715450.ExampleNFT,1,10 11
+2,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,1,A.01cf0e2f2f715450.ExampleNFT,2,20 21
+1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,2,A.01cf0e2f2f715450.OtherNFT,1,30 31
+`
+
+	distributions, err := ParseDistributionsCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(distributions) != 2 {
+		t.Fatalf("expected 2 distributions, got %d", len(distributions))
+	}
+
+	first := distributions[0]
+	if first.FlowID != (common.FlowID{Int64: 1, Valid: true}) {
+		t.Errorf("expected first distribution to have Flow ID 1, got %s", first.FlowID)
+	}
+	if first.State != common.DistributionStateInit {
+		t.Errorf("expected state %s, got %s", common.DistributionStateInit, first.State)
+	}
+	if first.Issuer != common.FlowAddressFromString("f3fcd2c1a78f5eee") {
+		t.Errorf("unexpected issuer %s", first.Issuer)
+	}
+	if first.PackTemplate.PackReference.String() != "A.01cf0e2f2f715450.PackNFT" || first.PackTemplate.PackCount != 2 {
+		t.Errorf("unexpected pack template %+v", first.PackTemplate)
+	}
+	if len(first.PackTemplate.Buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(first.PackTemplate.Buckets))
+	}
+
+	bucket := first.PackTemplate.Buckets[1]
+	if bucket.CollectibleReference.Name != "OtherNFT" || bucket.CollectibleCount != 1 {
+		t.Errorf("unexpected bucket %+v", bucket)
+	}
+	if len(bucket.CollectibleCollection) != 2 || bucket.CollectibleCollection[1].Int64 != 31 {
+		t.Errorf("unexpected collection %v", bucket.CollectibleCollection)
+	}
+
+	if len(distributions[1].PackTemplate.Buckets) != 1 {
+		t.Errorf("expected second distribution to have 1 bucket, got %d", len(distributions[1].PackTemplate.Buckets))
+	}
+}
+
+func TestParseDistributionsCSVErrors(t *testing.T) {
+	header := "distFlowID,issuer,packReference,packCount,collectibleReference,collectibleCount,collectibleCollection\n"
+
+	cases := []struct {
+		name string
+		csv  string
+		code string
+	}{
+		{"empty", "", ErrorCodeDistributionInvalid},
+		{"no rows", header, ErrorCodeDistributionInvalid},
+		{"missing column", "distFlowID,issuer\n1,f3fcd2c1a78f5eee\n", ErrorCodeDistributionInvalid},
+		{"invalid pack count", header + "1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,x,A.01cf0e2f2f715450.ExampleNFT,1,10\n", ErrorCodeDistributionInvalid},
+		{"invalid collectible", header + "1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,1,A.01cf0e2f2f715450.ExampleNFT,1,10 x\n", ErrorCodeDistributionInvalidBucket},
+		{"invalid reference", header + "1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,1,ExampleNFT,1,10\n", ErrorCodeDistributionInvalidBucket},
+		{
+			"conflicting rows",
+			header +
+				"1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,1,A.01cf0e2f2f715450.ExampleNFT,1,10\n" +
+				"1,f3fcd2c1a78f5eee,A.01cf0e2f2f715450.PackNFT,2,A.01cf0e2f2f715450.ExampleNFT,1,11\n",
+			ErrorCodeDistributionInvalid,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := ParseDistributionsCSV(strings.NewReader(c.csv))
+		if err == nil {
+			t.Errorf("%s: expected an error", c.name)
+			continue
+		}
+		if code := ErrorCode(err); code != c.code {
+			t.Errorf("%s: expected code %s, got %s (%s)", c.name, c.code, code, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Orders of listed distributions
+const (
+	DistributionSortCreatedDesc = "-createdAt" // Newest first, the default
+	DistributionSortCreatedAsc  = "createdAt"
+	DistributionSortUpdatedDesc = "-updatedAt"
+	DistributionSortUpdatedAsc  = "updatedAt"
+)
+
+var distributionSortColumns = map[string]string{
+	DistributionSortCreatedDesc: "created_at desc",
+	DistributionSortCreatedAsc:  "created_at asc",
+	DistributionSortUpdatedDesc: "updated_at desc",
+	DistributionSortUpdatedAsc:  "updated_at asc",
+}
+
+var distributionStates = map[common.DistributionState]bool{
+	common.DistributionStateInit:      true,
+	common.DistributionStateInvalid:   true,
+	common.DistributionStateResolved:  true,
+	common.DistributionStateScheduled: true,
+	common.DistributionStateSetup:     true,
+	common.DistributionStateSettling:  true,
+	common.DistributionStateSettled:   true,
+	common.DistributionStateMinting:   true,
+	common.DistributionStateComplete:  true,
+	common.DistributionStateClosed:    true,
+	common.DistributionStateCancelled: true,
+	common.DistributionStateStalled:   true,
+	common.DistributionStateDraft:     true,
+}
+
+// DistributionFilter selects and orders the distributions to list, all
+// fields are optional.
+type DistributionFilter struct {
+	States       []common.DistributionState // Any of
+	Issuer       *common.FlowAddress
+	CreatedAfter *time.Time
+	Sort         string
+	Archived     bool // Only archived distributions, otherwise they are left out
+}
+
+// Validate checks the states and sort order are known.
+func (f DistributionFilter) Validate() error {
+	for _, s := range f.States {
+		if !distributionStates[s] {
+			return fmt.Errorf("unknown distribution state '%s'", s)
+		}
+	}
+
+	if f.Sort != "" {
+		if _, ok := distributionSortColumns[f.Sort]; !ok {
+			return fmt.Errorf("unknown sort order '%s'", f.Sort)
+		}
+	}
+
+	return nil
+}
+
+// where applies the filter to 'db'.
+func (f DistributionFilter) where(db *gorm.DB) *gorm.DB {
+	if len(f.States) > 0 {
+		db = db.Where("state IN ?", f.States)
+	}
+
+	if f.Issuer != nil {
+		db = db.Where("issuer = ?", *f.Issuer)
+	}
+
+	if f.CreatedAfter != nil {
+		db = db.Where("created_at > ?", *f.CreatedAfter)
+	}
+
+	if f.Archived {
+		db = db.Where("archived_at IS NOT NULL")
+	} else {
+		db = db.Where("archived_at IS NULL")
+	}
+
+	return db
+}
+
+// sort returns the sort order of the filter, the default if not set.
+func (f DistributionFilter) sort() string {
+	if _, ok := distributionSortColumns[f.Sort]; ok {
+		return f.Sort
+	}
+	return DistributionSortCreatedDesc
+}
+
+// order returns the SQL order of the filter. Ties are ordered by ID so pages
+// do not overlap.
+func (f DistributionFilter) order() string {
+	return distributionSortColumns[f.sort()] + ", id asc"
+}
+
+// afterCondition returns the SQL condition selecting the distributions after
+// a cursor in the order of the filter, taking the sort value twice and the
+// ID.
+func (f DistributionFilter) afterCondition() string {
+	split := strings.Fields(distributionSortColumns[f.sort()])
+	column, op := split[0], ">"
+	if split[1] == "desc" {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s %s ? OR (%s = ? AND id > ?))", column, op, column)
+}
+
+// DistributionCursor is the position of the last distribution of a page in
+// the order it was listed in, see newDistributionCursor.
+type DistributionCursor struct {
+	Sort string    `json:"s"`
+	Time time.Time `json:"t"` // Value of the sort column
+	ID   uuid.UUID `json:"id"`
+}
+
+// newDistributionCursor returns the cursor after 'd' in the order of 'filter'.
+func newDistributionCursor(filter DistributionFilter, d *Distribution) DistributionCursor {
+	c := DistributionCursor{Sort: filter.sort(), Time: d.CreatedAt, ID: d.ID}
+	if c.Sort == DistributionSortUpdatedAsc || c.Sort == DistributionSortUpdatedDesc {
+		c.Time = d.UpdatedAt
+	}
+	return c
+}
+
+// String encodes the cursor, opaque to clients.
+func (c DistributionCursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseDistributionCursor decodes a cursor of distributions listed in the
+// order of 'filter'.
+func ParseDistributionCursor(s string, filter DistributionFilter) (*DistributionCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var c DistributionCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	if c.Sort != filter.sort() {
+		return nil, fmt.Errorf("cursor is for sort order '%s', not '%s'", c.Sort, filter.sort())
+	}
+
+	return &c, nil
+}
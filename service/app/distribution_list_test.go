@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func TestDistributionFilterValidate(t *testing.T) {
+	for name, c := range map[string]struct {
+		filter DistributionFilter
+		valid  bool
+	}{
+		"empty":         {DistributionFilter{}, true},
+		"states":        {DistributionFilter{States: []common.DistributionState{common.DistributionStateSettling, common.DistributionStateMinting}}, true},
+		"sort":          {DistributionFilter{Sort: DistributionSortUpdatedAsc}, true},
+		"unknown state": {DistributionFilter{States: []common.DistributionState{"done"}}, false},
+		"unknown sort":  {DistributionFilter{Sort: "name"}, false},
+	} {
+		if err := c.filter.Validate(); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid %t, got %v", name, c.valid, err)
+		}
+	}
+}
+
+func TestDistributionFilterOrder(t *testing.T) {
+	if o := (DistributionFilter{}).order(); o != "created_at desc, id asc" {
+		t.Errorf("unexpected default order %q", o)
+	}
+
+	if o := (DistributionFilter{Sort: DistributionSortUpdatedAsc}).order(); o != "updated_at asc, id asc" {
+		t.Errorf("unexpected order %q", o)
+	}
+}
+
+func TestDistributionFilterAfterCondition(t *testing.T) {
+	if c := (DistributionFilter{}).afterCondition(); c != "(created_at < ? OR (created_at = ? AND id > ?))" {
+		t.Errorf("unexpected default condition %q", c)
+	}
+
+	if c := (DistributionFilter{Sort: DistributionSortUpdatedAsc}).afterCondition(); c != "(updated_at > ? OR (updated_at = ? AND id > ?))" {
+		t.Errorf("unexpected condition %q", c)
+	}
+}
+
+func TestDistributionCursor(t *testing.T) {
+	d := Distribution{ID: uuid.New()}
+	d.CreatedAt = time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	d.UpdatedAt = d.CreatedAt.Add(time.Hour)
+
+	filter := DistributionFilter{Sort: DistributionSortUpdatedDesc}
+	s := newDistributionCursor(filter, &d).String()
+
+	c, err := ParseDistributionCursor(s, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != d.ID || !c.Time.Equal(d.UpdatedAt) || c.Sort != DistributionSortUpdatedDesc {
+		t.Errorf("unexpected cursor %+v", c)
+	}
+
+	if _, err := ParseDistributionCursor(s, DistributionFilter{}); err == nil {
+		t.Errorf("expected cursor of another sort order to be refused")
+	}
+
+	if _, err := ParseDistributionCursor("not a cursor", filter); err == nil {
+		t.Errorf("expected invalid cursor to be refused")
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/onflow/cadence"
+)
+
+const (
+	maxMetadataTitleLength       = 100
+	maxMetadataDescriptionLength = 1000
+)
+
+var metadataImageURISchemes = []string{"https", "http", "ipfs"}
+
+// DistributionMetadata is display metadata of a distribution given by its
+// issuer, e.g. to render the drop on a marketplace. All fields are optional.
+type DistributionMetadata struct {
+	Title       string `gorm:"column:title"`
+	Description string `gorm:"column:description"`
+	ImageURI    string `gorm:"column:image_uri"`
+	OnChain     bool   `gorm:"column:on_chain"` // Set if the packs are minted with the metadata, see PackMetadataOnChain
+}
+
+// IsEmpty returns true if no metadata is given.
+func (m DistributionMetadata) IsEmpty() bool {
+	return m.Title == "" && m.Description == "" && m.ImageURI == ""
+}
+
+// Validate checks the length of the title and description and that the image
+// URI is absolute with an allowed scheme.
+func (m DistributionMetadata) Validate() error {
+	if utf8.RuneCountInString(m.Title) > maxMetadataTitleLength {
+		return fmt.Errorf("title can be at most %d characters", maxMetadataTitleLength)
+	}
+
+	if utf8.RuneCountInString(m.Description) > maxMetadataDescriptionLength {
+		return fmt.Errorf("description can be at most %d characters", maxMetadataDescriptionLength)
+	}
+
+	return validateURI("image URI", m.ImageURI, metadataImageURISchemes)
+}
+
+// cadenceValue returns the metadata passed to the PackNFT contract when
+// minting, keyed by the fields of the MetadataViews.Display view.
+func (m DistributionMetadata) cadenceValue() cadence.Dictionary {
+	return cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String("name"), Value: cadence.String(m.Title)},
+		{Key: cadence.String("description"), Value: cadence.String(m.Description)},
+		{Key: cadence.String("thumbnail"), Value: cadence.String(m.ImageURI)},
+	})
+}
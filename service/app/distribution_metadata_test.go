@@ -0,0 +1,54 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+)
+
+func TestDistributionMetadataValidate(t *testing.T) {
+	valid := []DistributionMetadata{
+		{},
+		{Title: "Series 1", Description: "The first series", ImageURI: "https://example.com/series-1.png"},
+		{ImageURI: "ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"},
+	}
+	for _, m := range valid {
+		if err := m.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", m, err)
+		}
+	}
+
+	invalid := []DistributionMetadata{
+		{Title: strings.Repeat("a", maxMetadataTitleLength+1)},
+		{Description: strings.Repeat("a", maxMetadataDescriptionLength+1)},
+		{ImageURI: "ftp://example.com/series-1.png"},
+		{ImageURI: "series-1.png"},
+	}
+	for _, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", m)
+		}
+	}
+}
+
+func TestDistributionMetadataCadenceValue(t *testing.T) {
+	m := DistributionMetadata{Title: "Series 1", ImageURI: "https://example.com/series-1.png"}
+
+	if m.IsEmpty() || !(DistributionMetadata{OnChain: true}).IsEmpty() {
+		t.Errorf("expected only metadata without title, description and image URI to be empty")
+	}
+
+	expected := map[string]string{"name": "Series 1", "description": "", "thumbnail": "https://example.com/series-1.png"}
+
+	pairs := m.cadenceValue().Pairs
+	if len(pairs) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(pairs))
+	}
+	for _, p := range pairs {
+		k, v := p.Key.(cadence.String), p.Value.(cadence.String)
+		if expected[string(k)] != string(v) {
+			t.Errorf("expected '%s' to be '%s', got '%s'", k, expected[string(k)], v)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// Distributions in these states can be paused, i.e. before settling starts
+// and until minting completes.
+var pausableDistributionStates = map[common.DistributionState]bool{
+	common.DistributionStateResolved:  true,
+	common.DistributionStateScheduled: true,
+	common.DistributionStateSetup:     true,
+	common.DistributionStateSettling:  true,
+	common.DistributionStateSettled:   true,
+	common.DistributionStateMinting:   true,
+}
+
+// Transactions held while a distribution is paused
+var pausableTransactions = []string{SETTLE_SCRIPT, SETTLE_FUNGIBLE_TOKEN_SCRIPT, MINT_SCRIPT}
+
+// Paused returns true while the processing of 'dist' is paused.
+func (dist *Distribution) Paused() bool {
+	return dist.PausedAt != nil
+}
+
+// SetPaused pauses a distribution which is not yet complete.
+func (dist *Distribution) SetPaused(now time.Time) error {
+	if dist.Paused() {
+		return newError(ErrorCodeDistributionState, "distribution is already paused")
+	}
+
+	if !pausableDistributionStates[dist.State] {
+		return newError(ErrorCodeDistributionState, "distribution in '%s' state can not be paused", dist.State)
+	}
+
+	dist.PausedAt = &now
+
+	return nil
+}
+
+// SetResumed resumes a paused distribution.
+func (dist *Distribution) SetResumed() error {
+	if !dist.Paused() {
+		return newError(ErrorCodeDistributionState, "distribution is not paused")
+	}
+
+	dist.PausedAt = nil
+
+	// The time spent paused does not count towards the timeout of the state
+	dist.TimedState = ""
+	dist.TimedStateSince = nil
+
+	return nil
+}
+
+// unpausedDistributions returns the distributions in 'list' which are not
+// paused.
+func unpausedDistributions(list []Distribution) []Distribution {
+	res := make([]Distribution, 0, len(list))
+	for _, dist := range list {
+		if !dist.Paused() {
+			res = append(res, dist)
+		}
+	}
+	return res
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestSetPaused(t *testing.T) {
+	now := time.Now()
+
+	dist := Distribution{State: common.DistributionStateComplete}
+	if err := dist.SetPaused(now); err == nil {
+		t.Error("expected an error for a complete distribution")
+	}
+
+	dist.State = common.DistributionStateSettling
+	if err := dist.SetResumed(); err == nil {
+		t.Error("expected an error for a distribution which is not paused")
+	}
+
+	if err := dist.SetPaused(now); err != nil {
+		t.Fatal(err)
+	}
+	if !dist.Paused() {
+		t.Error("expected the distribution to be paused")
+	}
+
+	if err := dist.SetPaused(now); err == nil {
+		t.Error("expected an error for a distribution which is already paused")
+	}
+
+	if err := dist.SetResumed(); err != nil {
+		t.Fatal(err)
+	}
+	if dist.Paused() {
+		t.Error("expected the distribution to be resumed")
+	}
+}
+
+func TestUnpausedDistributions(t *testing.T) {
+	now := time.Now()
+	list := []Distribution{{FlowID: common.FlowID{Int64: 1, Valid: true}}, {FlowID: common.FlowID{Int64: 2, Valid: true}, PausedAt: &now}}
+
+	res := unpausedDistributions(list)
+	if len(res) != 1 || res[0].FlowID.Int64 != 1 {
+		t.Errorf("expected only the distribution which is not paused, got %v", res)
+	}
+}
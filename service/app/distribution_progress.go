@@ -0,0 +1,74 @@
+package app
+
+import (
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// Events of a distribution progress stream
+const (
+	DistributionEventState    = "state"    // The state of the distribution changed
+	DistributionEventProgress = "progress" // Collectibles were settled or packs minted
+	DistributionEventEnd      = "end"      // The distribution makes no more progress
+)
+
+// DistributionProgress is a snapshot of the settlement and minting of a
+// distribution.
+type DistributionProgress struct {
+	State        common.DistributionState
+	SettledCount uint // Collectibles settled into escrow
+	SettleTotal  uint // Collectibles to settle, 0 until settling starts
+	MintedCount  uint // Packs minted, in any state after minting
+	PackCount    uint
+}
+
+// newDistributionProgress returns the progress of a distribution in 'state'
+// from its settlement (nil if not started) and the number of its packs per
+// state.
+func newDistributionProgress(state common.DistributionState, settlement *Settlement, packs map[common.PackState]uint) DistributionProgress {
+	p := DistributionProgress{State: state}
+
+	if settlement != nil {
+		p.SettledCount = settlement.CurrentCount
+		p.SettleTotal = settlement.TotalCount
+	}
+
+	for state, count := range packs {
+		p.PackCount += count
+		if packMinted(state) {
+			p.MintedCount += count
+		}
+	}
+
+	return p
+}
+
+// Final returns true once the distribution is no longer settling or minting.
+func (p DistributionProgress) Final() bool {
+	switch p.State {
+	case common.DistributionStateComplete, common.DistributionStateClosed, common.DistributionStateInvalid, common.DistributionStateCancelled:
+		return true
+	}
+	return false
+}
+
+// DistributionEvents returns the events to emit when the progress of a
+// distribution goes from 'prev' to 'next', both events for the first
+// snapshot (nil 'prev').
+func DistributionEvents(prev *DistributionProgress, next DistributionProgress) []string {
+	events := []string{}
+
+	if prev == nil || prev.State != next.State {
+		events = append(events, DistributionEventState)
+	}
+
+	if prev == nil || prev.SettledCount != next.SettledCount || prev.SettleTotal != next.SettleTotal ||
+		prev.MintedCount != next.MintedCount || prev.PackCount != next.PackCount {
+		events = append(events, DistributionEventProgress)
+	}
+
+	if next.Final() && (prev == nil || !prev.Final()) {
+		events = append(events, DistributionEventEnd)
+	}
+
+	return events
+}
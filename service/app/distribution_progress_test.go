@@ -0,0 +1,63 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestNewDistributionProgress(t *testing.T) {
+	p := newDistributionProgress(common.DistributionStateSettling, nil, map[common.PackState]uint{
+		common.PackStateInit: 3,
+	})
+	if p.SettledCount != 0 || p.SettleTotal != 0 || p.MintedCount != 0 || p.PackCount != 3 {
+		t.Errorf("unexpected progress before settlement %+v", p)
+	}
+
+	p = newDistributionProgress(common.DistributionStateMinting, &Settlement{CurrentCount: 6, TotalCount: 6}, map[common.PackState]uint{
+		common.PackStateInit:      1,
+		common.PackStateSealed:    1,
+		common.PackStateOpened:    1,
+		common.PackStateCancelled: 1,
+	})
+	if p.SettledCount != 6 || p.SettleTotal != 6 || p.MintedCount != 2 || p.PackCount != 4 {
+		t.Errorf("unexpected progress while minting %+v", p)
+	}
+}
+
+func TestDistributionEvents(t *testing.T) {
+	settling := DistributionProgress{State: common.DistributionStateSettling, SettleTotal: 4, PackCount: 2}
+
+	if events := DistributionEvents(nil, settling); !reflect.DeepEqual(events, []string{DistributionEventState, DistributionEventProgress}) {
+		t.Errorf("expected state and progress for the first snapshot, got %v", events)
+	}
+
+	if events := DistributionEvents(&settling, settling); len(events) != 0 {
+		t.Errorf("expected no events without changes, got %v", events)
+	}
+
+	settled := settling
+	settled.SettledCount = 1
+	if events := DistributionEvents(&settling, settled); !reflect.DeepEqual(events, []string{DistributionEventProgress}) {
+		t.Errorf("expected progress, got %v", events)
+	}
+
+	minting := settled
+	minting.State = common.DistributionStateMinting
+	if events := DistributionEvents(&settled, minting); !reflect.DeepEqual(events, []string{DistributionEventState}) {
+		t.Errorf("expected a state change, got %v", events)
+	}
+
+	complete := minting
+	complete.State, complete.MintedCount = common.DistributionStateComplete, 2
+	if events := DistributionEvents(&minting, complete); !reflect.DeepEqual(events, []string{DistributionEventState, DistributionEventProgress, DistributionEventEnd}) {
+		t.Errorf("expected the stream to end on completion, got %v", events)
+	}
+
+	closed := complete
+	closed.State = common.DistributionStateClosed
+	if events := DistributionEvents(&complete, closed); !reflect.DeepEqual(events, []string{DistributionEventState}) {
+		t.Errorf("expected the end only once, got %v", events)
+	}
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// DistributionRetry is the result of retrying a stuck distribution.
+type DistributionRetry struct {
+	State                 common.DistributionState // 'settling' or 'minting'
+	CheckpointBlock       uint64                   // Events are handled up to this block
+	CancelledTransactions int64                    // Dead-letter transactions replaced by the new batches
+	RequeuedCount         int64                    // Collectibles or packs queued again
+	QueuedTransactions    int
+	Unstalled             bool // Set if the distribution was stalled, it is set back to 'State'
+}
+
+// retryStage returns the name of the transactions a distribution in 'state'
+// waits for, if it can be retried.
+func retryStage(state common.DistributionState) (string, error) {
+	switch state {
+	case common.DistributionStateSettling:
+		return SETTLE_SCRIPT, nil
+	case common.DistributionStateMinting:
+		return MINT_SCRIPT, nil
+	}
+	return "", newError(ErrorCodeDistributionState, "only distributions in '%s' or '%s' state can be retried, state is '%s'", common.DistributionStateSettling, common.DistributionStateMinting, state)
+}
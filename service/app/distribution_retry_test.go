@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestRetryStage(t *testing.T) {
+	if name, err := retryStage(common.DistributionStateSettling); err != nil || name != SETTLE_SCRIPT {
+		t.Errorf("expected settle transactions for settling, got '%s': %v", name, err)
+	}
+
+	if name, err := retryStage(common.DistributionStateMinting); err != nil || name != MINT_SCRIPT {
+		t.Errorf("expected mint transactions for minting, got '%s': %v", name, err)
+	}
+
+	for _, state := range []common.DistributionState{
+		common.DistributionStateInit,
+		common.DistributionStateSettled,
+		common.DistributionStateComplete,
+		common.DistributionStateInvalid,
+	} {
+		if _, err := retryStage(state); err == nil {
+			t.Errorf("expected an error for '%s'", state)
+		}
+	}
+}
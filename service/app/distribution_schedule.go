@@ -0,0 +1,23 @@
+package app
+
+import (
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// Schedule sets a resolved distribution to "scheduled" if its settlement
+// start time is after 'now', otherwise it is left resolved.
+func (dist *Distribution) Schedule(now time.Time) error {
+	if dist.SettlementStartAt == nil || !dist.SettlementStartAt.After(now) {
+		return nil
+	}
+
+	return dist.SetState(common.DistributionStateScheduled, common.DistributionStateResolved)
+}
+
+// SetStarted sets a scheduled distribution back to "resolved" once its
+// settlement start time has passed, so it is set up.
+func (dist *Distribution) SetStarted() error {
+	return dist.SetState(common.DistributionStateResolved, common.DistributionStateScheduled)
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestDistributionSchedule(t *testing.T) {
+	now := time.Now()
+	start := now.Add(time.Hour)
+
+	dist := Distribution{State: common.DistributionStateResolved}
+	if err := dist.Schedule(now); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateResolved {
+		t.Errorf("expected a distribution without a settlement start time to stay resolved, got '%s'", dist.State)
+	}
+
+	dist.SettlementStartAt = &start
+	if err := dist.Schedule(start); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateResolved {
+		t.Errorf("expected a distribution whose settlement start time has passed to stay resolved, got '%s'", dist.State)
+	}
+
+	if err := dist.Schedule(now); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateScheduled {
+		t.Errorf("expected the distribution to be scheduled, got '%s'", dist.State)
+	}
+
+	if err := dist.SetStarted(); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateResolved {
+		t.Errorf("expected the started distribution to be resolved, got '%s'", dist.State)
+	}
+
+	if err := dist.SetStarted(); err == nil {
+		t.Error("expected an error for a distribution which is not scheduled")
+	}
+}
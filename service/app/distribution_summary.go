@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DistributionSummary is an overview of a distribution for dashboards.
+type DistributionSummary struct {
+	DistributionID uuid.UUID
+	Progress       DistributionProgress
+	SettledPercent float64 // Of the collectibles to settle, 0 until settling starts
+	MintedPercent  float64 // Of the packs
+
+	PacksByState        map[common.PackState]uint
+	Slots               []SlotSummary // One per bucket of the pack template
+	TransactionsByState map[common.TransactionState]uint
+	ErrorCount          uint // Failed and dead-letter transactions
+
+	CreatedAt         time.Time
+	SettlingStartedAt *time.Time
+	SettledAt         *time.Time // Set once all collectibles are settled
+	MintingStartedAt  *time.Time
+	MintedAt          *time.Time // Set when minting completes
+}
+
+// SlotSummary tells how well a bucket of a pack template fills its slots,
+// CollectibleCount slots in each pack.
+type SlotSummary struct {
+	CollectibleReference AddressLocation
+	SlotCount            uint    // Slots of the bucket in all packs
+	AvailableCount       uint    // Collectibles of the bucket to fill the slots with
+	FillRate             float64 // Percent of the slots the collectibles can fill
+}
+
+// newDistributionSummary returns the summary of 'dist' (with its buckets)
+// from its settlement and minting (nil if not started) and the number of its
+// packs and transactions per state.
+func newDistributionSummary(dist *Distribution, settlement *Settlement, minting *Minting, packs map[common.PackState]uint, txs map[common.TransactionState]uint) DistributionSummary {
+	progress := newDistributionProgress(dist.State, settlement, packs)
+
+	s := DistributionSummary{
+		DistributionID:      dist.ID,
+		Progress:            progress,
+		SettledPercent:      percent(progress.SettledCount, progress.SettleTotal),
+		MintedPercent:       percent(progress.MintedCount, progress.PackCount),
+		PacksByState:        packs,
+		Slots:               make([]SlotSummary, len(dist.PackTemplate.Buckets)),
+		TransactionsByState: txs,
+		ErrorCount:          txs[common.TransactionStateFailed] + txs[common.TransactionStateDeadLetter],
+		CreatedAt:           dist.CreatedAt,
+		MintedAt:            dist.CompletedAt,
+	}
+
+	for i, b := range dist.PackTemplate.Buckets {
+		slots := b.CollectibleCount * dist.PackTemplate.PackCount
+		available := uint(len(b.CollectibleCollection))
+		filled := available
+		if filled > slots {
+			filled = slots
+		}
+		s.Slots[i] = SlotSummary{
+			CollectibleReference: b.CollectibleReference,
+			SlotCount:            slots,
+			AvailableCount:       available,
+			FillRate:             percent(filled, slots),
+		}
+	}
+
+	if settlement != nil {
+		s.SettlingStartedAt = &settlement.CreatedAt
+		// The last update of a complete settlement settled its last collectible
+		if settlement.IsComplete() {
+			s.SettledAt = &settlement.UpdatedAt
+		}
+	}
+
+	if minting != nil {
+		s.MintingStartedAt = &minting.CreatedAt
+	}
+
+	return s
+}
+
+// percent returns 'count' in percent of 'total', 0 if 'total' is 0.
+func percent(count, total uint) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// GetDistributionSummary returns an overview of the packs, slots,
+// transactions and timing of a distribution.
+func (app *App) GetDistributionSummary(ctx context.Context, distributionID uuid.UUID) (*DistributionSummary, error) {
+	distribution, err := GetDistributionWithBuckets(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement, err := GetDistributionSettlement(app.db, distributionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settlement, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	minting, err := GetDistributionMinting(app.db, distributionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		minting, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	packs, err := CountDistributionPacksByState(packsOf(app.db, distribution), distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := transactions.CountByState(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := newDistributionSummary(distribution, settlement, minting, packs, txs)
+	return &summary, nil
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestNewDistributionSummary(t *testing.T) {
+	created := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	completed := created.Add(2 * time.Hour)
+
+	dist := &Distribution{
+		State:       common.DistributionStateComplete,
+		CompletedAt: &completed,
+		PackTemplate: PackTemplate{
+			PackCount: 4,
+			Buckets: []Bucket{
+				{CollectibleCount: 2, CollectibleCollection: common.FlowIDList{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}, {Int64: 3, Valid: true}, {Int64: 4, Valid: true}, {Int64: 5, Valid: true}, {Int64: 6, Valid: true}}},
+				{CollectibleCount: 1, CollectibleCollection: common.FlowIDList{{Int64: 7, Valid: true}, {Int64: 8, Valid: true}, {Int64: 9, Valid: true}, {Int64: 10, Valid: true}, {Int64: 11, Valid: true}}},
+			},
+		},
+	}
+	dist.CreatedAt = created
+
+	settlement := &Settlement{CurrentCount: 12, TotalCount: 12}
+	settlement.CreatedAt = created.Add(time.Minute)
+	settlement.UpdatedAt = created.Add(time.Hour)
+
+	minting := &Minting{}
+	minting.CreatedAt = created.Add(time.Hour)
+
+	packs := map[common.PackState]uint{common.PackStateSealed: 3, common.PackStateOpened: 1}
+	txs := map[common.TransactionState]uint{
+		common.TransactionStateComplete:   5,
+		common.TransactionStateFailed:     1,
+		common.TransactionStateDeadLetter: 2,
+	}
+
+	s := newDistributionSummary(dist, settlement, minting, packs, txs)
+
+	if s.SettledPercent != 100 || s.MintedPercent != 100 {
+		t.Errorf("expected settlement and minting to be done, got %v and %v", s.SettledPercent, s.MintedPercent)
+	}
+	if s.ErrorCount != 3 {
+		t.Errorf("expected 3 errors, got %d", s.ErrorCount)
+	}
+
+	if len(s.Slots) != 2 {
+		t.Fatalf("expected 2 slots, got %d", len(s.Slots))
+	}
+	if s.Slots[0].SlotCount != 8 || s.Slots[0].AvailableCount != 6 || s.Slots[0].FillRate != 75 {
+		t.Errorf("unexpected first slot %+v", s.Slots[0])
+	}
+	if s.Slots[1].SlotCount != 4 || s.Slots[1].FillRate != 100 {
+		t.Errorf("expected a bucket with spare collectibles to fill all slots, got %+v", s.Slots[1])
+	}
+
+	if !s.CreatedAt.Equal(created) || s.SettlingStartedAt == nil || !s.SettlingStartedAt.Equal(settlement.CreatedAt) {
+		t.Errorf("unexpected start times %v %v", s.CreatedAt, s.SettlingStartedAt)
+	}
+	if s.SettledAt == nil || !s.SettledAt.Equal(settlement.UpdatedAt) {
+		t.Errorf("expected settled at %v, got %v", settlement.UpdatedAt, s.SettledAt)
+	}
+	if s.MintingStartedAt == nil || s.MintedAt == nil || !s.MintedAt.Equal(completed) {
+		t.Errorf("unexpected minting times %v %v", s.MintingStartedAt, s.MintedAt)
+	}
+}
+
+func TestNewDistributionSummaryNotStarted(t *testing.T) {
+	dist := &Distribution{State: common.DistributionStateResolved, PackTemplate: PackTemplate{PackCount: 2}}
+
+	s := newDistributionSummary(dist, nil, nil, map[common.PackState]uint{common.PackStateInit: 2}, map[common.TransactionState]uint{})
+
+	if s.SettledPercent != 0 || s.MintedPercent != 0 || s.ErrorCount != 0 {
+		t.Errorf("unexpected summary before settlement %+v", s)
+	}
+	if s.SettlingStartedAt != nil || s.SettledAt != nil || s.MintingStartedAt != nil || s.MintedAt != nil {
+		t.Errorf("expected no timestamps before settlement %+v", s)
+	}
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTemplateNameLength        = 100
+	maxTemplateDescriptionLength = 1000
+)
+
+// DistributionTemplate is a reusable drop format of an issuer: the pack
+// contract, pack count, buckets and settings of its distributions. A
+// distribution created from a template only gives the collectibles of each
+// bucket, the template fills in what it leaves out.
+type DistributionTemplate struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer      common.FlowAddress `gorm:"column:issuer;index"`
+	Name        string             `gorm:"column:name"`
+	Description string             `gorm:"column:description"` // Optional
+
+	PackReference AddressLocation `gorm:"embedded;embeddedPrefix:pack_ref_"` // Reference to the pack NFT contract
+	PackCount     uint            `gorm:"column:pack_count"`                 // Optional, how many packs to create
+	Buckets       TemplateBuckets `gorm:"column:buckets"`
+
+	AccessAPIHost    string     `gorm:"column:access_api_host"`    // Optional
+	RevealWebhookURL string     `gorm:"column:reveal_webhook_url"` // Optional
+	CollectionID     *uuid.UUID `gorm:"column:collection_id"`      // Optional, collection of the distributions
+}
+
+func (DistributionTemplate) TableName() string {
+	return "distribution_templates"
+}
+
+func (t *DistributionTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New()
+	return nil
+}
+
+// TemplateBucket is the structure of a bucket of a template, without its
+// collectibles.
+type TemplateBucket struct {
+	CollectibleReference AddressLocation `json:"collectibleReference"` // Reference to the collectible NFT contract
+	CollectibleCount     uint            `json:"collectibleCount"`     // How many collectibles to pick from this bucket
+}
+
+// TemplateBuckets are the buckets of a template. Stored as JSON.
+type TemplateBuckets []TemplateBucket
+
+func (TemplateBuckets) GormDataType() string {
+	return "text"
+}
+
+// Scan template buckets from database.
+func (tb *TemplateBuckets) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+		*tb = nil
+		return nil
+	default:
+		return fmt.Errorf("failed to unmarshal TemplateBuckets value: %v", value)
+	}
+	if len(b) == 0 {
+		*tb = nil
+		return nil
+	}
+	return json.Unmarshal(b, tb)
+}
+
+// Convert template buckets to database storable format.
+func (tb TemplateBuckets) Value() (driver.Value, error) {
+	if len(tb) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(tb)
+	return string(b), err
+}
+
+// Validate checks the issuer, name and buckets are set.
+func (t DistributionTemplate) Validate() error {
+	if flow.Address(t.Issuer) == flow.EmptyAddress {
+		return fmt.Errorf("issuer is required")
+	}
+
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if utf8.RuneCountInString(t.Name) > maxTemplateNameLength {
+		return fmt.Errorf("name can be at most %d characters", maxTemplateNameLength)
+	}
+
+	if utf8.RuneCountInString(t.Description) > maxTemplateDescriptionLength {
+		return fmt.Errorf("description can be at most %d characters", maxTemplateDescriptionLength)
+	}
+
+	if len(t.Buckets) == 0 {
+		return fmt.Errorf("at least one bucket is required")
+	}
+
+	for i, b := range t.Buckets {
+		if b.CollectibleCount == 0 {
+			return fmt.Errorf("error in bucket %d: collectibleCount is required", i)
+		}
+	}
+
+	return nil
+}
+
+// Apply creates 'd' from the template, filling in what 'd' leaves out. The
+// buckets of 'd' give the collectibles of the buckets of the template, in
+// the same order.
+func (t DistributionTemplate) Apply(d *Distribution) error {
+	if d.Issuer != t.Issuer {
+		return newError(ErrorCodeDistributionInvalid, "template %s is not of issuer %s", t.ID, d.Issuer)
+	}
+
+	if len(d.PackTemplate.Buckets) != len(t.Buckets) {
+		return newError(ErrorCodeDistributionInvalid, "template %s has %d buckets, got %d", t.ID, len(t.Buckets), len(d.PackTemplate.Buckets))
+	}
+
+	d.TemplateID = &t.ID
+
+	if d.PackTemplate.PackReference.Name == "" {
+		d.PackTemplate.PackReference = t.PackReference
+	}
+
+	if d.PackTemplate.PackCount == 0 {
+		d.PackTemplate.PackCount = t.PackCount
+	}
+
+	for i, b := range t.Buckets {
+		if d.PackTemplate.Buckets[i].CollectibleReference.Name == "" {
+			d.PackTemplate.Buckets[i].CollectibleReference = b.CollectibleReference
+		}
+		if d.PackTemplate.Buckets[i].CollectibleCount == 0 {
+			d.PackTemplate.Buckets[i].CollectibleCount = b.CollectibleCount
+		}
+	}
+
+	if d.AccessAPIHost == "" {
+		d.AccessAPIHost = t.AccessAPIHost
+	}
+
+	if d.RevealWebhookURL == "" {
+		d.RevealWebhookURL = t.RevealWebhookURL
+	}
+
+	if d.CollectionID == nil {
+		d.CollectionID = t.CollectionID
+	}
+
+	return nil
+}
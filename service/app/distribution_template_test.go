@@ -0,0 +1,69 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func TestDistributionTemplateApply(t *testing.T) {
+	issuer := common.FlowAddressFromString("0x2")
+	packRef := AddressLocation{Name: "PackNFT", Address: common.FlowAddressFromString("0x3")}
+	collectibleRef := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("0x4")}
+	collectionID := uuid.New()
+
+	tmpl := DistributionTemplate{
+		ID:            uuid.New(),
+		Issuer:        issuer,
+		Name:          "Weekly drop",
+		PackReference: packRef,
+		PackCount:     10,
+		Buckets: TemplateBuckets{
+			{CollectibleReference: collectibleRef, CollectibleCount: 4},
+			{CollectibleReference: collectibleRef, CollectibleCount: 1},
+		},
+		CollectionID: &collectionID,
+	}
+
+	d := Distribution{
+		Issuer:       issuer,
+		PackTemplate: PackTemplate{Buckets: []Bucket{{}, {CollectibleCount: 2}}},
+	}
+
+	if err := tmpl.Apply(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.TemplateID == nil || *d.TemplateID != tmpl.ID {
+		t.Fatalf("expected the template of the distribution to be set, got %v", d.TemplateID)
+	}
+	if d.PackTemplate.PackReference != packRef || d.PackTemplate.PackCount != 10 {
+		t.Fatal("expected the pack reference and count of the template to be filled in")
+	}
+	if d.PackTemplate.Buckets[0].CollectibleReference != collectibleRef || d.PackTemplate.Buckets[0].CollectibleCount != 4 {
+		t.Fatal("expected the buckets to be filled in from the template")
+	}
+	if d.PackTemplate.Buckets[1].CollectibleCount != 2 {
+		t.Fatal("expected the collectible count of the distribution to be kept")
+	}
+	if d.CollectionID == nil || *d.CollectionID != collectionID {
+		t.Fatal("expected the collection of the template to be filled in")
+	}
+
+	if err := tmpl.Apply(&Distribution{Issuer: issuer, PackTemplate: PackTemplate{Buckets: []Bucket{{}}}}); err == nil {
+		t.Fatal("expected an error for a distribution with a different number of buckets")
+	}
+	if err := tmpl.Apply(&Distribution{Issuer: common.FlowAddressFromString("0x6")}); err == nil {
+		t.Fatal("expected an error for a distribution of another issuer")
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (DistributionTemplate{Issuer: issuer, Name: "Weekly drop"}).Validate(); err == nil {
+		t.Fatal("expected an error for a template without buckets")
+	}
+	if err := (DistributionTemplate{Issuer: issuer, Name: "Weekly drop", Buckets: TemplateBuckets{{}}}).Validate(); err == nil {
+		t.Fatal("expected an error for a bucket without a collectible count")
+	}
+}
@@ -1,10 +1,15 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 )
 
@@ -130,3 +135,102 @@ func TestDistributionResolution(t *testing.T) {
 		}
 	}
 }
+
+func TestPackTemplateRevealLock(t *testing.T) {
+	notBefore := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	pt := PackTemplate{RevealNotBefore: &notBefore}
+
+	if err := pt.CheckRevealLock(notBefore.Add(-time.Second)); !errors.Is(err, ErrRevealLocked) {
+		t.Errorf("expected reveal to be locked, got %v", err)
+	}
+
+	if err := pt.CheckRevealLock(notBefore); err != nil {
+		t.Errorf("expected reveal to be allowed, got %v", err)
+	}
+
+	if err := (PackTemplate{}).CheckRevealLock(notBefore); err != nil {
+		t.Errorf("expected reveal to be allowed without a lock, got %v", err)
+	}
+}
+
+func TestPackTemplateValidationMultipleContracts(t *testing.T) {
+	moment := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+	badge := AddressLocation{Name: "Badge", Address: common.FlowAddress(flow.HexToAddress("0x3"))}
+	collection := makeCollection(4)
+
+	pt := PackTemplate{
+		PackReference: AddressLocation{Name: "TestPackNFT", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+		PackCount:     2,
+		Buckets: []Bucket{
+			{CollectibleReference: moment, CollectibleCount: 2, CollectibleCollection: collection},
+			// The same IDs of another contract are other collectibles
+			{CollectibleReference: badge, CollectibleCount: 1, CollectibleCollection: collection[:2]},
+		},
+	}
+	if err := pt.Validate(); err != nil {
+		t.Fatalf("expected buckets of several contracts to be valid, got %s", err)
+	}
+
+	pt.Buckets[1].CollectibleReference = moment
+	if err := pt.Validate(); err == nil {
+		t.Error("expected an error for a collectible in two buckets")
+	}
+
+	pt.Buckets[1].CollectibleReference = AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x4"))}
+	if err := pt.Validate(); err == nil {
+		t.Error("expected an error for contracts with the same name")
+	}
+}
+
+func TestPackCollectibleContracts(t *testing.T) {
+	moment := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+	badge := AddressLocation{Name: "Badge", Address: common.FlowAddress(flow.HexToAddress("0x3"))}
+
+	p := Pack{Collectibles: Collectibles{
+		{ContractReference: moment, FlowID: common.FlowID{Int64: 1, Valid: true}},
+		{ContractReference: badge, FlowID: common.FlowID{Int64: 1, Valid: true}},
+		{ContractReference: moment, FlowID: common.FlowID{Int64: 2, Valid: true}},
+	}}
+
+	if got := p.CollectibleContracts(); !reflect.DeepEqual(got, []AddressLocation{moment, badge}) {
+		t.Errorf("expected the contracts of the pack in slot order, got %v", got)
+	}
+
+	args := packCollectibleArguments(&p)
+	if len(args) != 4 || len(args[3].(cadence.Array).Values) != 3 {
+		t.Fatalf("expected the collection of each collectible, got %v", args)
+	}
+	if got := args[3].(cadence.Array).Values[1]; got != cadence.String(badge.String()) {
+		t.Errorf("expected the collection of the second collectible to be %s, got %s", badge, got)
+	}
+
+	if paths := packProviderPathsArgument(&p).(cadence.Dictionary); len(paths.Pairs) != 2 {
+		t.Errorf("expected a provider path per contract, got %v", paths)
+	}
+}
+
+func TestAccessAPIHostOverride(t *testing.T) {
+	app := &App{
+		cfg:   &config.Config{AccessAPIOverrideHosts: []string{"access.example.com:9000"}},
+		clock: common.RealClock{},
+	}
+
+	for host, allowed := range map[string]bool{
+		"":                        true,
+		"access.example.com:9000": true,
+		"other.example.com:9000":  false,
+	} {
+		if got := app.accessAPIHostAllowed(host); got != allowed {
+			t.Errorf("expected host %q allowed %v, got %v", host, allowed, got)
+		}
+	}
+
+	d := Distribution{
+		Issuer:        common.FlowAddress(flow.HexToAddress("0x1")),
+		AccessAPIHost: "other.example.com:9000",
+	}
+	err := app.prepareDistribution(context.Background(), &d)
+	if code := ErrorCode(err); code != ErrorCodeDistributionInvalid {
+		t.Errorf("expected a host not allowed to be rejected with %s, got %v", ErrorCodeDistributionInvalid, err)
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Distributions in these states are timed by the poller and set to stalled
+// once they exceed the timeout of their state, see stateTimeout.
+var timedDistributionStates = []common.DistributionState{
+	common.DistributionStateSetup,
+	common.DistributionStateSettling,
+	common.DistributionStateSettled,
+	common.DistributionStateMinting,
+}
+
+func isTimedDistributionState(state common.DistributionState) bool {
+	for _, s := range timedDistributionStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// stateTimeout returns the max time a distribution can spend in 'state', 0 if
+// it does not time out.
+func stateTimeout(cfg *config.Config, state common.DistributionState) time.Duration {
+	switch state {
+	case common.DistributionStateSetup:
+		return cfg.SetupTimeout
+	case common.DistributionStateSettling, common.DistributionStateSettled:
+		return cfg.SettlementTimeout
+	case common.DistributionStateMinting:
+		return cfg.MintingTimeout
+	}
+	return 0
+}
+
+// TimedOut times the state of 'dist' at 'now' and returns true if it has been
+// in it for longer than 'timeout'. The timer starts when a state is first
+// seen, TimedState and TimedStateSince are set then.
+func (dist *Distribution) TimedOut(now time.Time, timeout time.Duration) bool {
+	if dist.TimedState != dist.State || dist.TimedStateSince == nil {
+		dist.TimedState = dist.State
+		dist.TimedStateSince = &now
+		return false
+	}
+	return timeout > 0 && now.Sub(*dist.TimedStateSince) > timeout
+}
+
+// SetStalled sets the status to "stalled", remembering the state it timed
+// out in.
+func (dist *Distribution) SetStalled() error {
+	if !isTimedDistributionState(dist.State) {
+		return newError(ErrorCodeDistributionState, "distribution can not be set to '%s' from '%s'", common.DistributionStateStalled, dist.State)
+	}
+
+	dist.StalledState = dist.State
+	dist.State = common.DistributionStateStalled
+
+	return nil
+}
+
+// SetUnstalled sets a stalled distribution back to the state it timed out in,
+// restarting its timer.
+func (dist *Distribution) SetUnstalled() error {
+	if dist.State != common.DistributionStateStalled {
+		return newError(ErrorCodeDistributionState, "distribution is not stalled")
+	}
+
+	dist.State = dist.StalledState
+	dist.StalledState = ""
+	dist.TimedState = ""
+	dist.TimedStateSince = nil
+
+	return nil
+}
+
+// stalledWebhookPayload is the body of stalled distribution alerts
+type stalledWebhookPayload struct {
+	Event          string                   `json:"event"`
+	DistributionID uuid.UUID                `json:"distID"`
+	DistFlowID     common.FlowID            `json:"distFlowID"`
+	Issuer         common.FlowAddress       `json:"issuer"`
+	StalledState   common.DistributionState `json:"stalledState"`
+	StateSince     time.Time                `json:"stateSince"`
+	Timeout        string                   `json:"timeout"`
+}
+
+// handleStateTimeouts sets the distributions which exceeded the timeout of
+// their state to stalled. Their settle and mint transactions not yet sent are
+// held, so they stop using the send capacity until an admin retries or aborts
+// them. Paused distributions are not timed.
+func handleStateTimeouts(ctx context.Context, app *App) error {
+	if app.cfg.SetupTimeout <= 0 && app.cfg.SettlementTimeout <= 0 && app.cfg.MintingTimeout <= 0 {
+		return nil
+	}
+
+	now := app.clock.Now()
+	stalled := []Distribution{}
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		list := []Distribution{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("state IN ?", timedDistributionStates).
+			Where("paused_at IS NULL").
+			Order("updated_at asc").
+			Find(&list).Error
+		if err != nil {
+			return err
+		}
+
+		for _, dist := range list {
+			timeout := stateTimeout(app.cfg, dist.State)
+			if timeout <= 0 {
+				continue
+			}
+
+			timedState := dist.TimedState
+
+			if !dist.TimedOut(now, timeout) {
+				if dist.TimedState != timedState {
+					// Timer started
+					if err := UpdateDistribution(tx, &dist); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			if err := dist.SetStalled(); err != nil {
+				return err
+			}
+
+			if err := UpdateDistribution(tx, &dist); err != nil {
+				return err
+			}
+
+			held, err := transactions.Hold(tx, dist.ID, pausableTransactions)
+			if err != nil {
+				return err
+			}
+
+			if err := queueDistributionWebhooks(tx, &dist, now); err != nil {
+				return err
+			}
+
+			log.WithFields(log.Fields{
+				"distID":           dist.ID,
+				"distFlowID":       dist.FlowID,
+				"stalledState":     dist.StalledState,
+				"stateSince":       dist.TimedStateSince,
+				"timeout":          timeout,
+				"heldTransactions": held,
+			}).Error("Distribution stalled")
+
+			stalled = append(stalled, dist)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if app.cfg.StalledWebhookURL == "" {
+		return nil
+	}
+
+	for _, dist := range stalled {
+		body, err := json.Marshal(stalledWebhookPayload{
+			Event:          "distribution.stalled",
+			DistributionID: dist.ID,
+			DistFlowID:     dist.FlowID,
+			Issuer:         dist.Issuer,
+			StalledState:   dist.StalledState,
+			StateSince:     *dist.TimedStateSince,
+			Timeout:        stateTimeout(app.cfg, dist.StalledState).String(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := postWebhook(ctx, app.webhooks, app.cfg.StalledWebhookURL, body); err != nil {
+			log.WithFields(log.Fields{
+				"distID": dist.ID,
+				"error":  err,
+			}).Warn("Error while sending stalled distribution alert")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+)
+
+func TestTimedOut(t *testing.T) {
+	now := time.Now()
+	dist := Distribution{State: common.DistributionStateSettling}
+
+	if dist.TimedOut(now, time.Hour) {
+		t.Error("expected the timer to start when the state is first seen")
+	}
+	if dist.TimedState != common.DistributionStateSettling || dist.TimedStateSince == nil || !dist.TimedStateSince.Equal(now) {
+		t.Errorf("expected the timer to start at %s in settling, got %v in %s", now, dist.TimedStateSince, dist.TimedState)
+	}
+
+	if dist.TimedOut(now.Add(time.Hour), time.Hour) {
+		t.Error("expected the distribution not to time out at the timeout")
+	}
+	if !dist.TimedOut(now.Add(time.Hour+time.Second), time.Hour) {
+		t.Error("expected the distribution to time out after the timeout")
+	}
+
+	// The timer restarts in a new state
+	dist.State = common.DistributionStateSettled
+	if dist.TimedOut(now.Add(2*time.Hour), time.Hour) {
+		t.Error("expected the timer to restart in a new state")
+	}
+	if !dist.TimedStateSince.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("expected the timer to restart at %s, got %s", now.Add(2*time.Hour), dist.TimedStateSince)
+	}
+}
+
+func TestSetStalled(t *testing.T) {
+	now := time.Now()
+	dist := Distribution{State: common.DistributionStateComplete}
+
+	if err := dist.SetStalled(); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a complete distribution, got %v", ErrorCodeDistributionState, err)
+	}
+	if err := dist.SetUnstalled(); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a distribution which is not stalled, got %v", ErrorCodeDistributionState, err)
+	}
+
+	dist.State = common.DistributionStateMinting
+	dist.TimedOut(now, time.Hour)
+
+	if err := dist.SetStalled(); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateStalled || dist.StalledState != common.DistributionStateMinting {
+		t.Errorf("expected the distribution to stall in minting, got %s (%s)", dist.State, dist.StalledState)
+	}
+
+	if err := dist.SetUnstalled(); err != nil {
+		t.Fatal(err)
+	}
+	if dist.State != common.DistributionStateMinting || dist.StalledState != "" || dist.TimedStateSince != nil {
+		t.Errorf("expected the distribution to be back in minting with its timer reset, got %s (%s, %v)", dist.State, dist.StalledState, dist.TimedStateSince)
+	}
+}
+
+func TestStateTimeout(t *testing.T) {
+	cfg := &config.Config{SetupTimeout: time.Minute, SettlementTimeout: time.Hour, MintingTimeout: 2 * time.Hour}
+
+	cases := map[common.DistributionState]time.Duration{
+		common.DistributionStateResolved: 0,
+		common.DistributionStateSetup:    time.Minute,
+		common.DistributionStateSettling: time.Hour,
+		common.DistributionStateSettled:  time.Hour,
+		common.DistributionStateMinting:  2 * time.Hour,
+		common.DistributionStateComplete: 0,
+	}
+	for state, expected := range cases {
+		if got := stateTimeout(cfg, state); got != expected {
+			t.Errorf("expected a timeout of %s in %s, got %s", expected, state, got)
+		}
+	}
+}
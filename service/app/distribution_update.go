@@ -0,0 +1,96 @@
+package app
+
+import (
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// DistributionUpdate changes a distribution which has not started settling,
+// nil fields are left as they are.
+type DistributionUpdate struct {
+	PackReference     *AddressLocation
+	PackCount         *uint
+	Buckets           []Bucket // Replaces the buckets of the pack template if not nil
+	RevealNotBefore   *time.Time
+	TeaseNotBefore    *time.Time
+	AccessAPIHost     *string
+	RevealWebhookURL  *string
+	SettlementStartAt *time.Time
+	Metadata          *DistributionMetadata // Replaces the metadata if not nil
+}
+
+// validateUpdate checks a distribution in 'state' can be updated. Drafts,
+// resolved and scheduled distributions have not been set up yet, aborted
+// (invalid) ones can be updated if they never started settling, see
+// ContractService.Reinitialize.
+func validateUpdate(state common.DistributionState) error {
+	switch state {
+	case common.DistributionStateDraft, common.DistributionStateResolved, common.DistributionStateScheduled, common.DistributionStateInvalid:
+		return nil
+	}
+	return newError(ErrorCodeDistributionState, "only distributions in '%s', '%s', '%s' or '%s' state can be updated, state is '%s'", common.DistributionStateDraft, common.DistributionStateResolved, common.DistributionStateScheduled, common.DistributionStateInvalid, state)
+}
+
+// Apply applies the update to 'dist' and sets it back to 'init' to be
+// resolved again. The packs of 'dist' are dropped and its buckets replaced
+// by new ones, so they are stored anew.
+func (u DistributionUpdate) Apply(dist *Distribution) {
+	pt := &dist.PackTemplate
+
+	if u.PackReference != nil {
+		pt.PackReference = *u.PackReference
+	}
+
+	if u.PackCount != nil {
+		pt.PackCount = *u.PackCount
+	}
+
+	buckets := pt.Buckets
+	if u.Buckets != nil {
+		buckets = u.Buckets
+
+		// The buckets of a draft only need their collectibles
+		if dist.State == common.DistributionStateDraft {
+			fillDraftBuckets(buckets, pt.Buckets)
+		}
+	}
+	pt.Buckets = make([]Bucket, len(buckets))
+	for i, b := range buckets {
+		pt.Buckets[i] = Bucket{
+			CollectibleReference:  b.CollectibleReference,
+			CollectibleCount:      b.CollectibleCount,
+			CollectibleCollection: b.CollectibleCollection,
+			CollectibleTiers:      b.CollectibleTiers,
+			TierWeights:           b.TierWeights,
+			GuaranteedTiers:       b.GuaranteedTiers,
+		}
+	}
+
+	if u.RevealNotBefore != nil {
+		pt.RevealNotBefore = u.RevealNotBefore
+	}
+
+	if u.TeaseNotBefore != nil {
+		pt.TeaseNotBefore = u.TeaseNotBefore
+	}
+
+	if u.AccessAPIHost != nil {
+		dist.AccessAPIHost = *u.AccessAPIHost
+	}
+
+	if u.RevealWebhookURL != nil {
+		dist.RevealWebhookURL = *u.RevealWebhookURL
+	}
+
+	if u.SettlementStartAt != nil {
+		dist.SettlementStartAt = u.SettlementStartAt
+	}
+
+	if u.Metadata != nil {
+		dist.Metadata = *u.Metadata
+	}
+
+	dist.State = common.DistributionStateInit
+	dist.Packs = nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func TestValidateUpdate(t *testing.T) {
+	for _, state := range []common.DistributionState{common.DistributionStateDraft, common.DistributionStateResolved, common.DistributionStateScheduled, common.DistributionStateInvalid} {
+		if err := validateUpdate(state); err != nil {
+			t.Errorf("expected distribution in '%s' state to be updatable, got %s", state, err)
+		}
+	}
+
+	for _, state := range []common.DistributionState{common.DistributionStateSetup, common.DistributionStateSettling, common.DistributionStateComplete} {
+		err := validateUpdate(state)
+		if err == nil {
+			t.Errorf("expected distribution in '%s' state not to be updatable", state)
+			continue
+		}
+		if code := ErrorCode(err); code != ErrorCodeDistributionState {
+			t.Errorf("expected code %s, got %s", ErrorCodeDistributionState, code)
+		}
+	}
+}
+
+func TestDistributionUpdateApply(t *testing.T) {
+	collectible := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+	bucketID := uuid.New()
+
+	dist := Distribution{
+		State:         common.DistributionStateResolved,
+		AccessAPIHost: "access:9000",
+		PackTemplate: PackTemplate{
+			PackCount: 2,
+			Buckets: []Bucket{
+				{ID: bucketID, CollectibleReference: collectible, CollectibleCount: 1, CollectibleCollection: common.FlowIDList{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}}},
+			},
+		},
+		Packs: []Pack{{}, {}},
+	}
+
+	packCount := uint(1)
+	DistributionUpdate{PackCount: &packCount}.Apply(&dist)
+
+	if dist.State != common.DistributionStateInit {
+		t.Errorf("expected state %s, got %s", common.DistributionStateInit, dist.State)
+	}
+	if dist.PackTemplate.PackCount != 1 {
+		t.Errorf("expected pack count 1, got %d", dist.PackTemplate.PackCount)
+	}
+	if dist.Packs != nil {
+		t.Errorf("expected packs to be dropped")
+	}
+	if dist.AccessAPIHost != "access:9000" {
+		t.Errorf("expected access API host to be left as is, got %s", dist.AccessAPIHost)
+	}
+	if len(dist.PackTemplate.Buckets) != 1 || dist.PackTemplate.Buckets[0].CollectibleCount != 1 {
+		t.Fatalf("expected buckets to be kept, got %+v", dist.PackTemplate.Buckets)
+	}
+	if dist.PackTemplate.Buckets[0].ID == bucketID {
+		t.Errorf("expected kept buckets to be stored anew")
+	}
+
+	host := ""
+	DistributionUpdate{
+		AccessAPIHost: &host,
+		Buckets:       []Bucket{{CollectibleReference: collectible, CollectibleCount: 2}},
+	}.Apply(&dist)
+
+	if dist.AccessAPIHost != "" {
+		t.Errorf("expected access API host to be cleared, got %s", dist.AccessAPIHost)
+	}
+	if len(dist.PackTemplate.Buckets) != 1 || dist.PackTemplate.Buckets[0].CollectibleCount != 2 {
+		t.Errorf("expected buckets to be replaced, got %+v", dist.PackTemplate.Buckets)
+	}
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var ErrDryRun = &Error{Code: ErrorCodeDryRun, Err: errors.New("not available in dry-run mode")}
+
+// dryRun builds and signs 't' as if it was sent, logs it and sets it
+// complete without sending it. The effects of settle and mint transactions
+// are simulated as the events the state machine waits for never happen.
+func (svc *ContractService) dryRun(ctx context.Context, db *gorm.DB, broadcaster Broadcaster, account *flow_helpers.Account, t *transactions.StorableTransaction) error {
+	tx, unlockKey, err := broadcaster.Prepare(ctx, t, account)
+	// Nothing waits for the transaction to finalize, unlock right away
+	unlockKey()
+	if err != nil {
+		return err
+	}
+
+	t.TransactionID = tx.ID().Hex()
+	t.State = common.TransactionStateComplete
+	t.Error = ""
+
+	logger := log.WithFields(log.Fields{
+		"function":       "dryRun",
+		"ID":             t.ID,
+		"name":           t.Name,
+		"distributionID": t.DistributionID,
+		"transactionID":  t.TransactionID,
+		"batchSize":      t.BatchSize,
+		"proposerKey":    t.ProposerKeyIndex,
+	})
+
+	logger.Info("Dry-run, transaction not sent")
+	logger.WithFields(log.Fields{"arguments": string(t.Arguments)}).Debug("Dry-run transaction arguments")
+
+	switch t.Name {
+	case SETTLE_SCRIPT:
+		if err := simulateSettle(db, t); err != nil {
+			return fmt.Errorf("error while simulating settlement: %w", err)
+		}
+	case MINT_SCRIPT:
+		if err := simulateMint(db, t); err != nil {
+			return fmt.Errorf("error while simulating minting: %w", err)
+		}
+	}
+
+	return t.Save(db)
+}
+
+// simulateSettle sets the collectibles of a settle transaction settled, as
+// their 'Deposit' events to escrow would.
+func simulateSettle(db *gorm.DB, t *transactions.StorableTransaction) error {
+	flowIDs, err := settleFlowIDs(t)
+	if err != nil {
+		return err
+	}
+
+	settlement, err := GetDistributionSettlement(db, t.DistributionID)
+	if err != nil {
+		return err
+	}
+
+	collectibles, err := QueuedSettlementCollectiblesByFlowIDs(db, settlement.ID, flowIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range collectibles {
+		if err := collectibles[i].SetSettled(); err != nil {
+			return err
+		}
+
+		if err := UpdateSettlementCollectible(db, &collectibles[i]); err != nil {
+			return err
+		}
+
+		settlement.IncrementCount()
+	}
+
+	return UpdateSettlement(db, settlement)
+}
+
+// simulateMint seals the packs of a mint transaction, as their 'Mint' events
+// would. The packs get made up FlowIDs, counting up per distribution.
+func simulateMint(db *gorm.DB, t *transactions.StorableTransaction) error {
+	hashes, err := arrayArgument(t, 1)
+	if err != nil {
+		return err
+	}
+
+	dist, err := GetDistributionSmall(db, t.DistributionID)
+	if err != nil {
+		return err
+	}
+
+	minting, err := GetDistributionMinting(db, t.DistributionID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range hashes {
+		commitmentHash, err := common.BinaryValueFromCadence(v)
+		if err != nil {
+			return err
+		}
+
+		pack, err := GetMintingPack(db, commitmentHash)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"commitmentHash": commitmentHash,
+				"error":          err,
+			}).Warn("Dry-run, pack of mint transaction not found")
+			continue
+		}
+
+		if err := pack.Seal(common.FlowID{Int64: int64(minting.CurrentCount) + 1, Valid: true}); err != nil {
+			return err
+		}
+
+		pack.Owner = dist.Issuer
+
+		if err := UpdatePack(db, pack); err != nil {
+			return err
+		}
+
+		minting.IncrementCount()
+	}
+
+	return UpdateMinting(db, minting)
+}
+
+// settleFlowIDs returns the collectible FlowIDs of a settle transaction.
+func settleFlowIDs(t *transactions.StorableTransaction) ([]int64, error) {
+	ids, err := arrayArgument(t, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	flowIDs := make([]int64, len(ids))
+	for i, v := range ids {
+		id, err := common.FlowIDFromCadence(v)
+		if err != nil {
+			return nil, err
+		}
+		flowIDs[i] = id.Int64
+	}
+
+	return flowIDs, nil
+}
+
+// arrayArgument returns the items of the array argument at 'index' of 't'.
+func arrayArgument(t *transactions.StorableTransaction, index int) ([]cadence.Value, error) {
+	args, err := t.ArgumentsAsCadence()
+	if err != nil {
+		return nil, err
+	}
+
+	if index >= len(args) {
+		return nil, fmt.Errorf("transaction has no argument at index %d", index)
+	}
+
+	arr, ok := args[index].(cadence.Array)
+	if !ok {
+		return nil, fmt.Errorf("argument at index %d is not an array", index)
+	}
+
+	return arr.Values, nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// recordingBroadcaster builds transactions without an Access API and counts
+// what would reach the network.
+type recordingBroadcaster struct {
+	prepared int
+	sent     int
+}
+
+func (b *recordingBroadcaster) Prepare(ctx context.Context, t *transactions.StorableTransaction, account *flow_helpers.Account) (*flow.Transaction, flow_helpers.UnlockKeyFunc, error) {
+	b.prepared++
+	return flow.NewTransaction().SetScript([]byte(t.Script)), flow_helpers.EmptyUnlockKey, nil
+}
+
+func (b *recordingBroadcaster) Sign(ctx context.Context, tx *flow.Transaction, account *flow_helpers.Account) (flow_helpers.UnlockKeyFunc, error) {
+	return flow_helpers.EmptyUnlockKey, nil
+}
+
+func (b *recordingBroadcaster) Send(ctx context.Context, tx *flow.Transaction) error {
+	b.sent++
+	return nil
+}
+
+func (b *recordingBroadcaster) WaitForSeal(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	return nil, errors.New("not sent")
+}
+
+func (b *recordingBroadcaster) WaitForFinalize(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	return nil, errors.New("not sent")
+}
+
+func TestDryRun(t *testing.T) {
+	app, db := newTestApp(t, func(cfg *config.Config) {
+		cfg.DryRun = true
+	})
+
+	b := &recordingBroadcaster{}
+	app.service.broadcasters = func(flowClient flow_helpers.FlowClient) Broadcaster { return b }
+
+	logs := test.NewGlobal()
+
+	// Sent by the poller
+	queued, err := transactions.NewTransaction("test", []byte("transaction {}"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queued.Save(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handleSendableTransactions(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := transactions.GetTransaction(db, queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.State != common.TransactionStateComplete || stored.TransactionID == "" {
+		t.Errorf("expected the transaction to be built and set complete, got state %s and ID %q", stored.State, stored.TransactionID)
+	}
+
+	// Sent outside of the poller
+	direct, err := transactions.NewTransaction("test", []byte("transaction {}"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.service.sendAndWaitForSeal(context.Background(), db, testFlowClient{}, direct); err != nil {
+		t.Fatalf("expected no error in dry-run mode, got %s", err)
+	}
+
+	if b.prepared != 2 || b.sent != 0 {
+		t.Errorf("expected 2 transactions built and none sent, got %d built and %d sent", b.prepared, b.sent)
+	}
+
+	logged := 0
+	for _, e := range logs.AllEntries() {
+		if e.Message == "Dry-run, transaction not sent" {
+			logged++
+		}
+	}
+	if logged != 2 {
+		t.Errorf("expected 2 transactions logged, got %d", logged)
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Machine readable codes of errors returned by the API, see README
+const (
+	ErrorCodeInvalidRequest            = "invalid_request" // Default for errors without a code
+	ErrorCodeNotFound                  = "not_found"
+	ErrorCodeUnauthorized              = "unauthorized"
+	ErrorCodeForbidden                 = "forbidden"
+	ErrorCodeRateLimited               = "rate_limited"
+	ErrorCodeAdminAPIDisabled          = "admin_api_disabled"
+	ErrorCodeAPIKeyInvalid             = "api_key_invalid"
+	ErrorCodeAPIKeyForbidden           = "api_key_forbidden"
+	ErrorCodeTokenInvalid              = "token_invalid"
+	ErrorCodeSignatureInvalid          = "signature_invalid"
+	ErrorCodeReplayed                  = "replayed"
+	ErrorCodeIdempotencyKeyReused      = "idempotency_key_reused"
+	ErrorCodeDistributionInvalid       = "distribution_invalid"
+	ErrorCodeDistributionInvalidBucket = "distribution_invalid_bucket"
+	ErrorCodeInsufficientEscrow        = "insufficient_escrow"
+	ErrorCodeDistributionState         = "distribution_state"
+	ErrorCodeTransactionsInFlight      = "transactions_in_flight"
+	ErrorCodeRevealLocked              = "reveal_locked"
+	ErrorCodePackState                 = "pack_state"
+	ErrorCodeIssuerNotRegistered       = "issuer_not_registered"
+	ErrorCodeDryRun                    = "dry_run"
+	ErrorCodeSendingFrozen             = "sending_frozen"
+)
+
+// Error is an error with a machine readable code.
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError returns an error with 'code' and a message formatted like
+// fmt.Errorf.
+func newError(code string, format string, a ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// withCode gives 'err' the 'code', unless it already has one.
+func withCode(code string, err error) error {
+	if err == nil || ErrorCode(err) != "" {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// ErrorCode returns the code of the outermost Error wrapped by 'err', empty
+// if none.
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
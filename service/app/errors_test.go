@@ -0,0 +1,79 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestErrorCode(t *testing.T) {
+	err := newError(ErrorCodeDistributionState, "distribution is already aborted")
+	if code := ErrorCode(fmt.Errorf("abort: %w", err)); code != ErrorCodeDistributionState {
+		t.Errorf("expected the code through wrapping, got '%s'", code)
+	}
+
+	if code := ErrorCode(errors.New("no code")); code != "" {
+		t.Errorf("expected no code, got '%s'", code)
+	}
+
+	// The innermost code is kept
+	if code := ErrorCode(withCode(ErrorCodeDistributionInvalid, fmt.Errorf("outer: %w", err))); code != ErrorCodeDistributionState {
+		t.Errorf("expected the code of the wrapped error, got '%s'", code)
+	}
+
+	if !errors.Is(fmt.Errorf("%w: expired", ErrAPIKeyInvalid), ErrAPIKeyInvalid) || ErrorCode(ErrAPIKeyInvalid) != ErrorCodeAPIKeyInvalid {
+		t.Error("expected sentinel errors to keep working with errors.Is and have a code")
+	}
+}
+
+func TestDistributionValidationErrorCodes(t *testing.T) {
+	ref := AddressLocation{Name: "TestNFT", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+
+	valid := func() Distribution {
+		return Distribution{
+			FlowID: common.FlowID{Int64: 1, Valid: true},
+			Issuer: common.FlowAddress(flow.HexToAddress("0x1")),
+			PackTemplate: PackTemplate{
+				PackReference: ref,
+				PackCount:     2,
+				Buckets: []Bucket{{
+					CollectibleReference:  ref,
+					CollectibleCount:      2,
+					CollectibleCollection: makeCollection(4),
+				}},
+			},
+		}
+	}
+
+	d := valid()
+	if err := d.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	d = valid()
+	d.FlowID = common.FlowID{}
+	if code := ErrorCode(d.Validate()); code != ErrorCodeDistributionInvalid {
+		t.Errorf("expected '%s' without flowID, got '%s'", ErrorCodeDistributionInvalid, code)
+	}
+
+	d = valid()
+	d.PackTemplate.Buckets[0].CollectibleCount = 0
+	if code := ErrorCode(d.Validate()); code != ErrorCodeDistributionInvalidBucket {
+		t.Errorf("expected '%s' for an empty slot count, got '%s'", ErrorCodeDistributionInvalidBucket, code)
+	}
+
+	d = valid()
+	d.PackTemplate.PackCount = 3
+	if code := ErrorCode(d.Validate()); code != ErrorCodeInsufficientEscrow {
+		t.Errorf("expected '%s' for too few collectibles, got '%s'", ErrorCodeInsufficientEscrow, code)
+	}
+
+	d = valid()
+	d.PackTemplate.Buckets[0].CollectibleCount = 5
+	if code := ErrorCode(d.Validate()); code != ErrorCodeInsufficientEscrow {
+		t.Errorf("expected '%s' for a bucket smaller than a pack, got '%s'", ErrorCodeInsufficientEscrow, code)
+	}
+}
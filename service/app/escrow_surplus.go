@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EscrowSurplus lists the collectibles of a distribution left in the PDS
+// escrow which no pack needs, e.g. those of packs which failed to mint or were
+// cancelled, or ones the issuer escrowed on top of the resolution.
+type EscrowSurplus struct {
+	DistributionID     uuid.UUID
+	Collectibles       Collectibles // Ordered by contract and FlowID
+	QueuedTransactions int          // Return transactions queued, see App.ReturnEscrowSurplus
+}
+
+// Distributions in these states have nothing in flight which could still
+// move their collectibles in or out of escrow.
+var escrowSurplusStates = []common.DistributionState{
+	common.DistributionStateComplete,
+	common.DistributionStateClosed,
+	common.DistributionStateCancelled,
+}
+
+// Packs in these states need their collectibles to stay in escrow, until
+// opened or until the settlement of their distribution is done.
+var escrowedPackStates = map[common.PackState]bool{
+	common.PackStateInit:                 true,
+	common.PackStateSealed:               true,
+	common.PackStateRevealRequestHandled: true,
+	common.PackStateRevealed:             true,
+	common.PackStateOpenRequestHandled:   true,
+}
+
+// validateEscrowSurplus checks the surplus of a distribution in 'state' can
+// be reconciled.
+func validateEscrowSurplus(state common.DistributionState) error {
+	for _, s := range escrowSurplusStates {
+		if s == state {
+			return nil
+		}
+	}
+	return newError(ErrorCodeDistributionState, "escrow surplus can only be reconciled once a distribution is complete, closed or cancelled, state is '%s'", state)
+}
+
+// bucketCollectibles returns the collectibles of all 'buckets'.
+func bucketCollectibles(buckets []Bucket) map[Collectible]bool {
+	res := make(map[Collectible]bool)
+	for _, b := range buckets {
+		for _, id := range b.CollectibleCollection {
+			res[Collectible{FlowID: id, ContractReference: b.CollectibleReference}] = true
+		}
+	}
+	return res
+}
+
+// releaseEscrowedPacks removes the collectibles of the 'packs' which still
+// need escrow from 'surplus'.
+func releaseEscrowedPacks(surplus map[Collectible]bool, packs []Pack) {
+	for _, p := range packs {
+		if !escrowedPackStates[p.State] {
+			continue
+		}
+		for _, c := range p.Collectibles {
+			delete(surplus, c)
+		}
+	}
+}
+
+// sortedCollectibles returns 'cc' ordered by contract and FlowID.
+func sortedCollectibles(cc map[Collectible]bool) Collectibles {
+	res := make(Collectibles, 0, len(cc))
+	for c := range cc {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].ContractReference, res[j].ContractReference
+		if a != b {
+			return a.String() < b.String()
+		}
+		return res[i].FlowID.LessThan(res[j].FlowID)
+	})
+	return res
+}
+
+// EscrowSurplus reconciles the escrow with the packs of 'dist'. Collectibles
+// of its buckets held in escrow are surplus unless a pack of 'dist', or of
+// another distribution of the same issuer, still needs them.
+func (svc *ContractService) EscrowSurplus(ctx context.Context, db *gorm.DB, dist *Distribution) (*EscrowSurplus, error) {
+	if err := validateEscrowSurplus(dist.State); err != nil {
+		return nil, err
+	}
+
+	candidates := bucketCollectibles(dist.PackTemplate.Buckets)
+
+	if err := svc.unclaimedEscrow(ctx, db, dist.Issuer, candidates); err != nil {
+		return nil, err
+	}
+
+	return &EscrowSurplus{
+		DistributionID: dist.ID,
+		Collectibles:   sortedCollectibles(candidates),
+	}, nil
+}
+
+// unclaimedEscrow removes the collectibles which are not held in escrow, or
+// which a pack of a distribution of 'issuer' still needs, from 'candidates'.
+func (svc *ContractService) unclaimedEscrow(ctx context.Context, db *gorm.DB, issuer common.FlowAddress, candidates map[Collectible]bool) error {
+	escrow := common.FlowAddressFromString(svc.cfg.AdminAddress)
+
+	contracts := make(map[AddressLocation]bool)
+	for c := range candidates {
+		contracts[c.ContractReference] = true
+	}
+
+	for contract := range contracts {
+		ids, err := svc.OwnedCollectibleIDs(ctx, contract, escrow, ListOptions{Limit: -1})
+		if err != nil {
+			return err
+		}
+
+		escrowed := make(map[common.FlowID]bool, len(ids))
+		for _, id := range ids {
+			escrowed[id] = true
+		}
+
+		for c := range candidates {
+			if c.ContractReference == contract && !escrowed[c.FlowID] {
+				delete(candidates, c)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	dists, err := ListDistributions(db, DistributionFilter{Issuer: &issuer}, ListOptions{Limit: -1})
+	if err != nil {
+		return err
+	}
+
+	for i := range dists {
+		err := DistributionPacksInBatches(packsOf(db, &dists[i]), dists[i].ID, svc.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+			releaseEscrowedPacks(candidates, batch)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReturnEscrowSurplus queues transactions returning the escrow surplus of
+// 'dist' to its issuer, in batches of the settlement batch size. Refused while
+// return transactions of 'dist' are in flight, their collectibles would be
+// counted as surplus again.
+func (svc *ContractService) ReturnEscrowSurplus(ctx context.Context, db *gorm.DB, dist *Distribution) (*EscrowSurplus, error) {
+	if err := validateEscrowSurplus(dist.State); err != nil {
+		return nil, err
+	}
+
+	pending, err := transactions.CountInStates(db, dist.ID, RETURN_ESCROW_SCRIPT, unfinishedReturnStates)
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, newError(ErrorCodeTransactionsInFlight, "distribution has %d return transactions in flight, retry once they have finished", pending)
+	}
+
+	surplus, err := svc.EscrowSurplus(ctx, db, dist)
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(SettlementCollectibles, len(surplus.Collectibles))
+	for i, c := range surplus.Collectibles {
+		returned[i] = SettlementCollectible{FlowID: c.FlowID, ContractReference: c.ContractReference}
+	}
+
+	for _, batch := range returnEscrowBatches(returned, svc.batchSize(svc.settleBatchSizer)) {
+		t, err := newReturnEscrowTransaction(dist, batch[0].ContractReference, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := t.Save(db); err != nil {
+			return nil, err
+		}
+
+		surplus.QueuedTransactions++
+	}
+
+	log.WithFields(log.Fields{
+		"distID":             dist.ID,
+		"distFlowID":         dist.FlowID,
+		"returned":           len(returned),
+		"queuedTransactions": surplus.QueuedTransactions,
+	}).Info("Escrow surplus returned to issuer")
+
+	return surplus, nil
+}
+
+// GetEscrowSurplus returns the collectibles of a distribution left in escrow
+// which no pack needs.
+func (app *App) GetEscrowSurplus(ctx context.Context, id uuid.UUID) (*EscrowSurplus, error) {
+	dist, err := GetDistributionWithBuckets(app.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.service.EscrowSurplus(ctx, app.db, dist)
+}
+
+// ReturnEscrowSurplus returns the escrow surplus of a distribution to its
+// issuer.
+func (app *App) ReturnEscrowSurplus(ctx context.Context, id uuid.UUID) (*EscrowSurplus, error) {
+	var res *EscrowSurplus
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		dist, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		res, err = app.service.ReturnEscrowSurplus(ctx, tx, dist)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
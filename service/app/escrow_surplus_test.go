@@ -0,0 +1,57 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestEscrowSurplusCollectibles(t *testing.T) {
+	moments := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+	cards := AddressLocation{Name: "Card", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+
+	collectible := func(contract AddressLocation, id int64) Collectible {
+		return Collectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: contract}
+	}
+
+	buckets := []Bucket{
+		{CollectibleReference: moments, CollectibleCollection: common.FlowIDList{{Int64: 3, Valid: true}, {Int64: 1, Valid: true}, {Int64: 2, Valid: true}}},
+		{CollectibleReference: cards, CollectibleCollection: common.FlowIDList{{Int64: 1, Valid: true}, {Int64: 4, Valid: true}}},
+	}
+
+	packs := []Pack{
+		{State: common.PackStateSealed, Collectibles: Collectibles{collectible(moments, 1)}},
+		{State: common.PackStateOpened, Collectibles: Collectibles{collectible(moments, 2)}},
+		{State: common.PackStateCancelled, Collectibles: Collectibles{collectible(cards, 4)}},
+		{State: common.PackStateRevealed, Collectibles: Collectibles{collectible(cards, 5)}},
+	}
+
+	surplus := bucketCollectibles(buckets)
+	releaseEscrowedPacks(surplus, packs)
+
+	// Only the sealed pack still needs escrow, the opened one's collectible
+	// left escrow and the cancelled one's is surplus
+	expected := Collectibles{
+		collectible(cards, 1),
+		collectible(cards, 4),
+		collectible(moments, 2),
+		collectible(moments, 3),
+	}
+	if got := sortedCollectibles(surplus); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the surplus %v, got %v", expected, got)
+	}
+}
+
+func TestValidateEscrowSurplus(t *testing.T) {
+	for _, state := range escrowSurplusStates {
+		if err := validateEscrowSurplus(state); err != nil {
+			t.Errorf("expected the surplus of a %s distribution to be reconciled, got %v", state, err)
+		}
+	}
+
+	if err := validateEscrowSurplus(common.DistributionStateMinting); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a minting distribution, got %v", ErrorCodeDistributionState, err)
+	}
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+)
+
+// Max total amount of a fungible token escrowed for a distribution, in
+// 1e-8 units (amounts are stored as signed integers)
+const maxFungibleTokenTotalAmount = math.MaxInt64
+
+// FungibleTokenAmount is an amount of a fungible token, e.g. FLOW or USDC,
+// included in a pack.
+type FungibleTokenAmount struct {
+	ContractReference AddressLocation `gorm:"embedded;embeddedPrefix:ref_"` // Reference to the fungible token contract
+	Amount            uint64          `gorm:"column:amount"`                // In 1e-8 units, the precision of UFix64
+}
+
+// IsZero returns true if there is no amount.
+func (ft FungibleTokenAmount) IsZero() bool {
+	return ft.Amount == 0
+}
+
+// UFix64 returns the amount as a cadence value.
+func (ft FungibleTokenAmount) UFix64() cadence.UFix64 {
+	return cadence.UFix64(ft.Amount)
+}
+
+// HashString returns the amount as an input of the commitment hash of a
+// pack, in the format of a collectible whose ID is the amount in 1e-8 units,
+// e.g. 'A.0ae53cb6e3f42a79.FlowToken.500000000' for 5 FLOW.
+func (ft FungibleTokenAmount) HashString() string {
+	return fmt.Sprintf("%s.%d", ft.ContractReference, ft.Amount)
+}
+
+// EscrowVaultPath returns the storage path identifier of the vault of the
+// PDS account escrowing the fungible token 'al'.
+func (al AddressLocation) EscrowVaultPath() string {
+	return fmt.Sprintf("%s_%s_EscrowVault", al.Name, al.Address)
+}
+
+// FungibleTokenAmountFromString parses an amount of a fungible token, e.g.
+// '5.0'.
+func FungibleTokenAmountFromString(s string) (uint64, error) {
+	v, err := cadence.NewUFix64(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fungible token amount '%s': %w", s, err)
+	}
+	return uint64(v), nil
+}
+
+// FungibleTokenAmountString returns 'amount' (in 1e-8 units) as a decimal
+// string, e.g. '5.00000000'.
+func FungibleTokenAmountString(amount uint64) string {
+	return cadence.UFix64(amount).String()
+}
+
+// Validate checks the fungible token amount of each of 'packCount' packs.
+func (ft FungibleTokenAmount) Validate(packCount uint) error {
+	if err := ft.ContractReference.Validate(); err != nil {
+		return fmt.Errorf("error while validating fungible token ContractReference: %w", err)
+	}
+
+	if ft.IsZero() {
+		return fmt.Errorf("fungible token amount can not be zero")
+	}
+
+	if packCount > 0 && ft.Amount > maxFungibleTokenTotalAmount/uint64(packCount) {
+		return fmt.Errorf("fungible token amount %s of %d packs is too large", FungibleTokenAmountString(ft.Amount), packCount)
+	}
+
+	return nil
+}
+
+// FungibleTokenTotalAmount returns the amount of a fungible token escrowed for the packs
+// of 'pt', zero if its packs hold no fungible tokens.
+func (pt PackTemplate) FungibleTokenTotalAmount() uint64 {
+	return pt.FungibleToken.Amount * uint64(pt.PackCount)
+}
+
+// newSetupEscrowVaultTransaction returns a transaction creating the vault
+// of the PDS account escrowing the fungible token of the packs of 'dist'.
+func newSetupEscrowVaultTransaction(dist *Distribution) (*transactions.StorableTransaction, error) {
+	token := dist.PackTemplate.FungibleToken.ContractReference
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(
+		SETUP_ESCROW_VAULT_SCRIPT,
+		&flow_helpers.CadenceTemplateVars{
+			TokenName:    token.Name,
+			TokenAddress: token.Address.String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []cadence.Value{
+		cadence.Path{Domain: "storage", Identifier: token.EscrowVaultPath()},
+	}
+
+	return transactions.NewTransactionWithDistributionID(SETUP_ESCROW_VAULT_SCRIPT, txScript, arguments, dist.ID)
+}
+
+// newSettleFungibleTokenTransaction returns a transaction withdrawing the
+// fungible tokens of all packs of 'dist' from the issuer to escrow, using the
+// withdraw capability the issuer shared for the token.
+func newSettleFungibleTokenTransaction(dist *Distribution) (*transactions.StorableTransaction, error) {
+	token := dist.PackTemplate.FungibleToken.ContractReference
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(SETTLE_FUNGIBLE_TOKEN_SCRIPT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.String(token.String()),
+		cadence.UFix64(dist.PackTemplate.FungibleTokenTotalAmount()),
+		cadence.Path{Domain: "storage", Identifier: token.EscrowVaultPath()},
+	}
+
+	return transactions.NewTransactionWithDistributionID(SETTLE_FUNGIBLE_TOKEN_SCRIPT, txScript, arguments, dist.ID)
+}
+
+// newReturnFungibleTokenEscrowTransaction returns a transaction returning
+// 'amount' of the fungible token of the packs of 'dist' from escrow to the
+// issuer.
+func newReturnFungibleTokenEscrowTransaction(dist *Distribution, amount uint64) (*transactions.StorableTransaction, error) {
+	token := dist.PackTemplate.FungibleToken.ContractReference
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(RETURN_FUNGIBLE_TOKEN_ESCROW_SCRIPT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []cadence.Value{
+		cadence.Address(dist.Issuer),
+		cadence.UFix64(amount),
+		cadence.Path{Domain: "storage", Identifier: token.EscrowVaultPath()},
+		cadence.Path{Domain: "public", Identifier: dist.PackTemplate.FungibleTokenReceiverPath},
+	}
+
+	return transactions.NewTransactionWithDistributionID(RETURN_FUNGIBLE_TOKEN_ESCROW_SCRIPT, txScript, arguments, dist.ID)
+}
+
+// packFungibleTokenPathsArguments returns the public receiver path of the
+// owner and the storage path of the escrow vault of the fungible token of
+// 'pack', by contract identifier, as arguments of a reveal or open
+// transaction. Both are empty if the pack holds no fungible tokens.
+func packFungibleTokenPathsArguments(dist *Distribution, pack *Pack) []cadence.Value {
+	receiverPaths := []cadence.KeyValuePair{}
+	escrowPaths := []cadence.KeyValuePair{}
+
+	if !pack.FungibleToken.IsZero() {
+		token := pack.FungibleToken.ContractReference
+		receiverPaths = append(receiverPaths, cadence.KeyValuePair{
+			Key:   cadence.String(token.String()),
+			Value: cadence.Path{Domain: "public", Identifier: dist.PackTemplate.FungibleTokenReceiverPath},
+		})
+		escrowPaths = append(escrowPaths, cadence.KeyValuePair{
+			Key:   cadence.String(token.String()),
+			Value: cadence.Path{Domain: "storage", Identifier: token.EscrowVaultPath()},
+		})
+	}
+
+	return []cadence.Value{
+		cadence.NewDictionary(receiverPaths),
+		cadence.NewDictionary(escrowPaths),
+	}
+}
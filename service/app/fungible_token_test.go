@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestFungibleTokenAmountFromString(t *testing.T) {
+	amount, err := FungibleTokenAmountFromString("5.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != 500000000 {
+		t.Errorf("expected 500000000 units, got %d", amount)
+	}
+	if s := FungibleTokenAmountString(amount); s != "5.00000000" {
+		t.Errorf("expected '5.00000000', got '%s'", s)
+	}
+
+	if _, err := FungibleTokenAmountFromString("-1.0"); err == nil {
+		t.Error("expected an error for a negative amount")
+	}
+}
+
+func TestPackTemplateValidationFungibleToken(t *testing.T) {
+	flowToken := AddressLocation{Name: "FlowToken", Address: common.FlowAddress(flow.HexToAddress("0x3"))}
+
+	pt := PackTemplate{
+		PackReference: AddressLocation{Name: "TestPackNFT", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+		PackCount:     2,
+		Buckets: []Bucket{{
+			CollectibleReference:  AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+			CollectibleCount:      1,
+			CollectibleCollection: makeCollection(2),
+		}},
+		FungibleToken:             FungibleTokenAmount{ContractReference: flowToken, Amount: 500000000},
+		FungibleTokenReceiverPath: "flowTokenReceiver",
+	}
+	if err := pt.Validate(); err != nil {
+		t.Fatalf("expected a fungible token amount to be valid, got %s", err)
+	}
+	if total := pt.FungibleTokenTotalAmount(); total != 1000000000 {
+		t.Errorf("expected a total of 1000000000 units, got %d", total)
+	}
+
+	pt.FungibleTokenReceiverPath = ""
+	if err := pt.Validate(); err == nil {
+		t.Error("expected an error for a missing receiver path")
+	}
+	pt.FungibleTokenReceiverPath = "flowTokenReceiver"
+
+	pt.FungibleToken.Amount = 0
+	if err := pt.Validate(); err == nil {
+		t.Error("expected an error for a zero amount")
+	}
+
+	pt.FungibleToken.Amount = maxFungibleTokenTotalAmount
+	if err := pt.Validate(); err == nil {
+		t.Error("expected an error for a total amount overflowing")
+	}
+}
+
+func TestPackFungibleToken(t *testing.T) {
+	moment := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+	flowToken := AddressLocation{Name: "FlowToken", Address: common.FlowAddress(flow.HexToAddress("0x3"))}
+
+	p := Pack{
+		Salt:         common.BinaryValue("salt"),
+		Collectibles: Collectibles{{ContractReference: moment, FlowID: common.FlowID{Int64: 1, Valid: true}}},
+	}
+	withoutToken := p.Hash(commitmentHasherV1{})
+
+	p.FungibleToken = FungibleTokenAmount{ContractReference: flowToken, Amount: 500000000}
+	withToken := p.Hash(commitmentHasherV1{})
+	if bytes.Equal(withoutToken, withToken) {
+		t.Error("expected the fungible token amount to change the commitment hash")
+	}
+
+	p.FungibleToken.Amount = 600000000
+	if bytes.Equal(withToken, p.Hash(commitmentHasherV1{})) {
+		t.Error("expected the commitment hash to cover the amount")
+	}
+
+	if s := p.FungibleToken.HashString(); s != "A.0000000000000003.FlowToken.600000000" {
+		t.Errorf("unexpected hash string '%s'", s)
+	}
+
+	args := packCollectibleArguments(&p)
+	ids := args[2].(cadence.Array).Values
+	if len(ids) != 2 || ids[1] != cadence.UInt64(600000000) {
+		t.Fatalf("expected the amount to be passed as the last collectible, got %v", ids)
+	}
+	if got := args[3].(cadence.Array).Values[1]; got != cadence.String(flowToken.String()) {
+		t.Errorf("expected the collection of the amount to be %s, got %s", flowToken, got)
+	}
+
+	dist := Distribution{PackTemplate: PackTemplate{FungibleTokenReceiverPath: "flowTokenReceiver"}}
+	paths := packFungibleTokenPathsArguments(&dist, &p)
+	if len(paths[0].(cadence.Dictionary).Pairs) != 1 || len(paths[1].(cadence.Dictionary).Pairs) != 1 {
+		t.Errorf("expected the receiver and escrow paths of the token, got %v", paths)
+	}
+
+	p.FungibleToken = FungibleTokenAmount{}
+	if paths := packFungibleTokenPathsArguments(&dist, &p); len(paths[0].(cadence.Dictionary).Pairs) != 0 {
+		t.Errorf("expected no paths for a pack without fungible tokens, got %v", paths)
+	}
+}
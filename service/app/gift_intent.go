@@ -0,0 +1,163 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"gorm.io/gorm"
+)
+
+// Webhook events of gift intents
+const (
+	GiftEventTransferred = "gift.transferred"
+	GiftEventReminder    = "gift.reminder"
+	GiftEventExpired     = "gift.expired"
+)
+
+// GiftIntent is an issuers intent to transfer (gift) a minted pack to a
+// recipient. The transfer is observed from the 'Deposit' events of the pack
+// contract. If a webhook URL is given, the issuer is notified when the
+// transfer is observed, when a reminder is due and when the intent expires.
+type GiftIntent struct {
+	gorm.Model
+	ID             uuid.UUID    `gorm:"column:id;primary_key;type:uuid;"`
+	DistributionID uuid.UUID    `gorm:"index"`
+	Distribution   Distribution `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	PackID     uuid.UUID              `gorm:"column:pack_id;index"`
+	PackFlowID common.FlowID          `gorm:"column:pack_flow_id"`
+	Recipient  common.FlowAddress     `gorm:"column:recipient"`
+	State      common.GiftIntentState `gorm:"column:state;not null;default:null"`
+
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`  // Optional
+	RemindAt   *time.Time `gorm:"column:remind_at"`   // Optional, when to remind if not transferred yet
+	WebhookURL string     `gorm:"column:webhook_url"` // Optional
+
+	TransferredAt         *time.Time `gorm:"column:transferred_at"`
+	TransferTransactionID string     `gorm:"column:transfer_transaction_id"`
+
+	ReminderSent    bool `gorm:"column:reminder_sent"`
+	Notified        bool `gorm:"column:notified"` // Webhook has been sent for the current (final) state
+	WebhookAttempts uint `gorm:"column:webhook_attempts"`
+}
+
+func (GiftIntent) TableName() string {
+	return "gift_intents"
+}
+
+func (g *GiftIntent) BeforeCreate(tx *gorm.DB) (err error) {
+	g.ID = uuid.New()
+	return nil
+}
+
+// Validate checks a new gift intent, 'now' is the time of registration.
+func (g GiftIntent) Validate(now time.Time) error {
+	if flow.Address(g.Recipient) == flow.EmptyAddress {
+		return fmt.Errorf("gift recipient must be defined")
+	}
+
+	if g.ExpiresAt != nil && !g.ExpiresAt.After(now) {
+		return fmt.Errorf("gift expiresAt must be in the future")
+	}
+
+	if g.RemindAt != nil {
+		if !g.RemindAt.After(now) {
+			return fmt.Errorf("gift remindAt must be in the future")
+		}
+		if g.ExpiresAt != nil && !g.RemindAt.Before(*g.ExpiresAt) {
+			return fmt.Errorf("gift remindAt must be before expiresAt")
+		}
+		if g.WebhookURL == "" {
+			return fmt.Errorf("gift remindAt requires a webhookURL")
+		}
+	}
+
+	if g.WebhookURL != "" {
+		u, err := url.Parse(g.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid gift webhookURL '%s'", g.WebhookURL)
+		}
+	}
+
+	return nil
+}
+
+func (g *GiftIntent) IsPending() bool {
+	return g.State == common.GiftIntentStatePending
+}
+
+// Transferred marks the transfer to the recipient as observed.
+func (g *GiftIntent) Transferred(transactionID string, at time.Time) error {
+	if !g.IsPending() {
+		return fmt.Errorf("gift intent not pending, got '%s'", g.State)
+	}
+	g.State = common.GiftIntentStateTransferred
+	g.TransferredAt = &at
+	g.TransferTransactionID = transactionID
+	g.Notified = false
+	g.WebhookAttempts = 0
+	return nil
+}
+
+// WebhookEvent returns the webhook event due for the gift intent at 'now',
+// or an empty string if none.
+func (g GiftIntent) WebhookEvent(now time.Time) string {
+	if g.WebhookURL == "" {
+		return ""
+	}
+
+	switch g.State {
+	case common.GiftIntentStateTransferred:
+		if !g.Notified {
+			return GiftEventTransferred
+		}
+	case common.GiftIntentStateExpired:
+		if !g.Notified {
+			return GiftEventExpired
+		}
+	case common.GiftIntentStatePending:
+		if g.RemindAt != nil && !g.ReminderSent && !now.Before(*g.RemindAt) {
+			return GiftEventReminder
+		}
+	}
+
+	return ""
+}
+
+// WebhookHandled marks 'event' as handled (sent or given up on).
+func (g *GiftIntent) WebhookHandled(event string) {
+	if event == GiftEventReminder {
+		g.ReminderSent = true
+	} else {
+		g.Notified = true
+	}
+	g.WebhookAttempts = 0
+}
+
+// observeGiftTransfers marks the pending gift intents of 'pack' whose recipient
+// is the current owner of the pack as transferred.
+func observeGiftTransfers(db *gorm.DB, pack *Pack, transactionID string, now time.Time) error {
+	intents, err := ListPendingGiftIntentsForPack(db, pack.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range intents {
+		g := &intents[i]
+		if g.Recipient != pack.Owner {
+			continue
+		}
+		if err := g.Transferred(transactionID, now); err != nil {
+			return err
+		}
+		if err := UpdateGiftIntent(db, g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestGiftIntentWebhookEvent(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	remindAt := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+
+	for _, c := range []struct {
+		name     string
+		intent   GiftIntent
+		expected string
+	}{
+		{"no webhook", GiftIntent{State: common.GiftIntentStateTransferred}, ""},
+		{"transferred", GiftIntent{State: common.GiftIntentStateTransferred, WebhookURL: "http://x"}, GiftEventTransferred},
+		{"transferred notified", GiftIntent{State: common.GiftIntentStateTransferred, WebhookURL: "http://x", Notified: true}, ""},
+		{"expired", GiftIntent{State: common.GiftIntentStateExpired, WebhookURL: "http://x"}, GiftEventExpired},
+		{"reminder due", GiftIntent{State: common.GiftIntentStatePending, WebhookURL: "http://x", RemindAt: &remindAt}, GiftEventReminder},
+		{"reminder sent", GiftIntent{State: common.GiftIntentStatePending, WebhookURL: "http://x", RemindAt: &remindAt, ReminderSent: true}, ""},
+		{"reminder not due", GiftIntent{State: common.GiftIntentStatePending, WebhookURL: "http://x", RemindAt: &later}, ""},
+	} {
+		if got := c.intent.WebhookEvent(now); got != c.expected {
+			t.Errorf("%s: expected event %q, got %q", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestGiftIntentValidate(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	soon := now.Add(time.Hour)
+	later := now.Add(2 * time.Hour)
+	recipient := common.FlowAddressFromString("0x1")
+
+	for _, c := range []struct {
+		name   string
+		intent GiftIntent
+		valid  bool
+	}{
+		{"minimal", GiftIntent{Recipient: recipient}, true},
+		{"no recipient", GiftIntent{}, false},
+		{"expired", GiftIntent{Recipient: recipient, ExpiresAt: &past}, false},
+		{"reminder", GiftIntent{Recipient: recipient, RemindAt: &soon, ExpiresAt: &later, WebhookURL: "https://example.com/hook"}, true},
+		{"reminder after expiry", GiftIntent{Recipient: recipient, RemindAt: &later, ExpiresAt: &soon, WebhookURL: "https://example.com/hook"}, false},
+		{"reminder without webhook", GiftIntent{Recipient: recipient, RemindAt: &soon}, false},
+		{"invalid webhook", GiftIntent{Recipient: recipient, WebhookURL: "ftp://example.com"}, false},
+	} {
+		err := c.intent.Validate(now)
+		if c.valid && err != nil {
+			t.Errorf("%s: expected valid, got %s", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IDCounter holds the next free collectible ID of a contract minting
+// collectibles on open.
+type IDCounter struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Contract string `gorm:"column:contract;uniqueIndex"` // AddressLocation.String() of the collectible contract
+	NextID   int64  `gorm:"column:next_id"`
+}
+
+func (IDCounter) TableName() string {
+	return "id_counters"
+}
+
+func (c *IDCounter) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return nil
+}
+
+// IDReservation is a range of collectible IDs reserved for a pack, to be
+// minted when the pack is opened. IDs are reserved once per pack and
+// contract, reserving again returns the same range.
+type IDReservation struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	PackID            uuid.UUID       `gorm:"column:pack_id;uniqueIndex:idx_id_reservation_pack"`
+	Contract          string          `gorm:"column:contract;uniqueIndex:idx_id_reservation_pack"`
+	ContractReference AddressLocation `gorm:"embedded;embeddedPrefix:contract_ref_"` // Reference to the collectible NFT contract
+	FirstID           int64           `gorm:"column:first_id"`
+	Count             int             `gorm:"column:count"`
+}
+
+func (IDReservation) TableName() string {
+	return "id_reservations"
+}
+
+func (r *IDReservation) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return nil
+}
+
+// IDs returns the reserved collectible IDs.
+func (r IDReservation) IDs() []common.FlowID {
+	ids := make([]common.FlowID, r.Count)
+	for i := range ids {
+		ids[i] = common.FlowID{Int64: r.FirstID + int64(i), Valid: true}
+	}
+	return ids
+}
+
+func validateIDReservationCount(count, max int) error {
+	if count < 1 {
+		return fmt.Errorf("at least one collectible ID must be reserved")
+	}
+
+	if count > max {
+		return fmt.Errorf("at most %d collectible IDs can be reserved for a pack", max)
+	}
+
+	return nil
+}
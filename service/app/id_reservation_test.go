@@ -0,0 +1,27 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestIDReservationIDs(t *testing.T) {
+	ids := IDReservation{FirstID: 11, Count: 3}.IDs()
+	if len(ids) != 3 || ids[0].Int64 != 11 || ids[2].Int64 != 13 || !ids[1].Valid {
+		t.Fatalf("unexpected IDs %v", ids)
+	}
+
+	for _, c := range []struct {
+		count int
+		valid bool
+	}{
+		{1, true},
+		{100, true},
+		{0, false},
+		{-1, false},
+		{101, false},
+	} {
+		if err := validateIDReservationCount(c.count, 100); (err == nil) != c.valid {
+			t.Errorf("count %d: expected valid %t, got %v", c.count, c.valid, err)
+		}
+	}
+}
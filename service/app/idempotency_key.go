@@ -0,0 +1,72 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxIdempotencyKeyLength = 255
+
+var ErrIdempotencyKeyReused = &Error{Code: ErrorCodeIdempotencyKeyReused, Err: errors.New("idempotency key already used for a different request")}
+
+// IdempotencyKey records the distribution created by a request with an
+// 'Idempotency-Key', so a retry of the request returns the same distribution
+// instead of creating another one. Keys are chosen by the issuer and are kept
+// as long as the distribution.
+type IdempotencyKey struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer         common.FlowAddress `gorm:"column:issuer;uniqueIndex:idx_idempotency_key"`
+	Key            string             `gorm:"column:idempotency_key;uniqueIndex:idx_idempotency_key"`
+	RequestHash    string             `gorm:"column:request_hash"` // Hex encoded SHA-256 of the request body
+	DistributionID uuid.UUID          `gorm:"column:distribution_id;index"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	return nil
+}
+
+// hashIdempotentRequest returns the request hash of an IdempotencyKey for
+// 'body'.
+func hashIdempotentRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key is required and can be at most %d characters", maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// idempotentDistribution returns the distribution created with 'key' of
+// 'issuer', nil if the key is unused. Returns ErrIdempotencyKeyReused if the
+// key was used for a request other than 'requestHash'.
+func idempotentDistribution(db *gorm.DB, issuer common.FlowAddress, key, requestHash string) (*Distribution, error) {
+	k, err := GetIdempotencyKey(db, issuer, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if k.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	return GetDistributionSmall(db, k.DistributionID)
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	for _, c := range []struct {
+		key   string
+		valid bool
+	}{
+		{"", false},
+		{"retry-1", true},
+		{strings.Repeat("k", maxIdempotencyKeyLength), true},
+		{strings.Repeat("k", maxIdempotencyKeyLength+1), false},
+	} {
+		if err := validateIdempotencyKey(c.key); (err == nil) != c.valid {
+			t.Errorf("key of length %d: expected valid %v, got error %v", len(c.key), c.valid, err)
+		}
+	}
+}
+
+func TestHashIdempotentRequest(t *testing.T) {
+	a := hashIdempotentRequest([]byte(`{"distFlowID":1}`))
+
+	if a != hashIdempotentRequest([]byte(`{"distFlowID":1}`)) {
+		t.Error("expected the same body to hash the same")
+	}
+
+	if a == hashIdempotentRequest([]byte(`{"distFlowID":2}`)) {
+		t.Error("expected a different body to hash differently")
+	}
+}
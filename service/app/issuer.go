@@ -0,0 +1,145 @@
+package app
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+	"gorm.io/gorm"
+)
+
+const maxIssuerNameLength = 100
+
+// Issuer is an organization distributing packs from its Flow account
+// 'Address'. Its API keys, webhooks and distributions are those of the
+// address. Registering issuers is optional unless 'IssuersRequired' is set.
+type Issuer struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Address common.FlowAddress `gorm:"column:address;uniqueIndex"`
+	Name    string             `gorm:"column:name"`
+	// Optional, collectible contracts the issuer may distribute, any if empty
+	AllowedContracts ContractList `gorm:"column:allowed_contracts"`
+}
+
+// ContractList is a list of contract references stored as a JSON array of
+// their string representations.
+type ContractList []AddressLocation
+
+func (Issuer) TableName() string {
+	return "issuers"
+}
+
+func (i *Issuer) BeforeCreate(tx *gorm.DB) (err error) {
+	i.ID = uuid.New()
+	return nil
+}
+
+// Validate checks the address and name are set.
+func (i Issuer) Validate() error {
+	if flow.Address(i.Address) == flow.EmptyAddress {
+		return fmt.Errorf("address is required")
+	}
+
+	if i.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if utf8.RuneCountInString(i.Name) > maxIssuerNameLength {
+		return fmt.Errorf("name can be at most %d characters", maxIssuerNameLength)
+	}
+
+	return nil
+}
+
+// CheckDistribution checks the buckets of 'dist' only use collectible
+// contracts the issuer is allowed to distribute.
+func (i Issuer) CheckDistribution(dist *Distribution) error {
+	return checkAllowedContracts(i.AllowedContracts, dist, fmt.Sprintf("issuer %s", i.Address))
+}
+
+// checkAllowedContracts checks the buckets of 'dist' only use collectible
+// contracts in 'allowed', any if empty. 'subject' names whom the list
+// belongs to in the error.
+func checkAllowedContracts(allowed ContractList, dist *Distribution, subject string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	for n, b := range dist.PackTemplate.Buckets {
+		if !allowed.Contains(b.CollectibleReference) {
+			return newError(ErrorCodeDistributionInvalidBucket, "error in bucket %d: collectible contract '%s' is not allowed for %s", n, b.CollectibleReference, subject)
+		}
+	}
+
+	return nil
+}
+
+// Contains returns true if 'ref' is in the list.
+func (l ContractList) Contains(ref AddressLocation) bool {
+	for _, c := range l {
+		if c == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (ContractList) GormDataType() string {
+	return "text"
+}
+
+// Scan contract list from database.
+func (l *ContractList) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+		*l = nil
+		return nil
+	default:
+		return fmt.Errorf("failed to unmarshal ContractList value: %v", value)
+	}
+	if len(b) == 0 {
+		*l = nil
+		return nil
+	}
+
+	refs := []string{}
+	if err := json.Unmarshal(b, &refs); err != nil {
+		return err
+	}
+
+	list := make(ContractList, len(refs))
+	for i, s := range refs {
+		ref, err := AddressLocationFromString(s)
+		if err != nil {
+			return err
+		}
+		list[i] = ref
+	}
+	*l = list
+
+	return nil
+}
+
+// Convert contract list to database storable format.
+func (l ContractList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return "", nil
+	}
+	refs := make([]string, len(l))
+	for i, ref := range l {
+		refs[i] = ref.String()
+	}
+	b, err := json.Marshal(refs)
+	return string(b), err
+}
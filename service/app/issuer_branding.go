@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxBrandingDisplayNameLength = 100
+
+var (
+	logoURISchemes    = []string{"https", "http", "ipfs"}
+	supportURLSchemes = []string{"https", "http", "mailto"}
+)
+
+// IssuerBranding is display metadata of an issuer, surfaced on the
+// distributions and packs of the issuer so consumers of the API can render
+// issuer context.
+type IssuerBranding struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer      common.FlowAddress `gorm:"column:issuer;uniqueIndex"`
+	DisplayName string             `gorm:"column:display_name"`
+	LogoURI     string             `gorm:"column:logo_uri"`    // Optional
+	SupportURL  string             `gorm:"column:support_url"` // Optional
+}
+
+func (IssuerBranding) TableName() string {
+	return "issuer_brandings"
+}
+
+func (b *IssuerBranding) BeforeCreate(tx *gorm.DB) (err error) {
+	b.ID = uuid.New()
+	return nil
+}
+
+// Validate checks the display name is set and the URIs are absolute with an
+// allowed scheme.
+func (b IssuerBranding) Validate() error {
+	if b.DisplayName == "" {
+		return fmt.Errorf("display name is required")
+	}
+
+	if utf8.RuneCountInString(b.DisplayName) > maxBrandingDisplayNameLength {
+		return fmt.Errorf("display name can be at most %d characters", maxBrandingDisplayNameLength)
+	}
+
+	if err := validateURI("logo URI", b.LogoURI, logoURISchemes); err != nil {
+		return err
+	}
+
+	if err := validateURI("support URL", b.SupportURL, supportURLSchemes); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateURI(name, s string, schemes []string) error {
+	if s == "" {
+		return nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	if !contains(schemes, u.Scheme) {
+		return fmt.Errorf("invalid %s '%s', scheme should be one of %v", name, s, schemes)
+	}
+
+	if u.Host == "" && u.Opaque == "" {
+		return fmt.Errorf("invalid %s '%s'", name, s)
+	}
+
+	return nil
+}
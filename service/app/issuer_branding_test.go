@@ -0,0 +1,35 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIssuerBrandingValidate(t *testing.T) {
+	valid := IssuerBranding{
+		DisplayName: "Example Studios",
+		LogoURI:     "ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi",
+		SupportURL:  "mailto:support@example.com",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid branding, got %s", err)
+	}
+
+	if err := (IssuerBranding{DisplayName: "Example Studios"}).Validate(); err != nil {
+		t.Fatalf("expected URIs to be optional, got %s", err)
+	}
+
+	invalid := map[string]IssuerBranding{
+		"no display name":      {LogoURI: "https://example.com/logo.png"},
+		"too long name":        {DisplayName: strings.Repeat("x", maxBrandingDisplayNameLength+1)},
+		"relative logo URI":    {DisplayName: "Example", LogoURI: "/logo.png"},
+		"javascript logo URI":  {DisplayName: "Example", LogoURI: "javascript:alert(1)"},
+		"ipfs support URL":     {DisplayName: "Example", SupportURL: "ipfs://bafy"},
+		"support URL, no host": {DisplayName: "Example", SupportURL: "https://"},
+	}
+	for name, b := range invalid {
+		if err := b.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCallbackIDLength    = 100
+	maxCallbackEventLength = 100
+	callbackSecretBytes    = 32
+)
+
+// Events of issuer callbacks the PDS knows of, others are stored as is.
+const (
+	CallbackEventPaymentConfirmed = "payment.confirmed" // Off-chain payment of a pack confirmed, requires a pack
+)
+
+var (
+	ErrCallbackUnauthorized = &Error{Code: ErrorCodeSignatureInvalid, Err: errors.New("invalid callback signature")}
+	ErrCallbackReplayed     = &Error{Code: ErrorCodeReplayed, Err: errors.New("callback already received")}
+)
+
+// IssuerCallbackSecret is the shared secret an issuer signs its callbacks to
+// the PDS with (HMAC-SHA256).
+type IssuerCallbackSecret struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer common.FlowAddress `gorm:"column:issuer;uniqueIndex"`
+	Secret string             `gorm:"column:secret"` // Hex encoded
+}
+
+func (IssuerCallbackSecret) TableName() string {
+	return "issuer_callback_secrets"
+}
+
+func (s *IssuerCallbackSecret) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// newCallbackSecret returns a random hex encoded secret.
+func newCallbackSecret() (string, error) {
+	b := make([]byte, callbackSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// IssuerCallback is a verified callback of an issuer system to the PDS.
+// CallbackID is chosen by the issuer and can be received only once.
+type IssuerCallback struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer     common.FlowAddress `gorm:"column:issuer;uniqueIndex:idx_issuer_callback_id"`
+	CallbackID string             `gorm:"column:callback_id;uniqueIndex:idx_issuer_callback_id"`
+	Event      string             `gorm:"column:event"`
+	PackID     *uuid.UUID         `gorm:"column:pack_id;index"` // Optional
+	Data       datatypes.JSON     `gorm:"column:data"`          // Optional, as sent by the issuer
+	SignedAt   time.Time          `gorm:"column:signed_at"`
+}
+
+func (IssuerCallback) TableName() string {
+	return "issuer_callbacks"
+}
+
+func (c *IssuerCallback) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return nil
+}
+
+// Validate checks the callback ID and event are set.
+func (c IssuerCallback) Validate() error {
+	if c.CallbackID == "" {
+		return fmt.Errorf("callback ID is required")
+	}
+
+	if len(c.CallbackID) > maxCallbackIDLength {
+		return fmt.Errorf("callback ID can be at most %d characters", maxCallbackIDLength)
+	}
+
+	if c.Event == "" {
+		return fmt.Errorf("event is required")
+	}
+
+	if len(c.Event) > maxCallbackEventLength {
+		return fmt.Errorf("event can be at most %d characters", maxCallbackEventLength)
+	}
+
+	if c.Event == CallbackEventPaymentConfirmed && c.PackID == nil {
+		return fmt.Errorf("event '%s' requires a pack", c.Event)
+	}
+
+	return nil
+}
+
+// SignCallback returns the hex encoded HMAC-SHA256 of 'timestamp' (unix
+// seconds), a '.' and 'body' with the hex encoded 'secret'.
+func SignCallback(secret, timestamp string, body []byte) (string, error) {
+	key, err := hex.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyCallback checks 'signature' is the signature of 'timestamp' and 'body'
+// with 'secret' and that 'timestamp' is at most 'maxSkew' from 'now'.
+// Returns the time of signing.
+func VerifyCallback(secret, timestamp, signature string, body []byte, now time.Time, maxSkew time.Duration) (time.Time, error) {
+	return verifySigned(secret, timestamp, timestamp, signature, body, now, maxSkew)
+}
+
+// verifySigned is VerifyCallback for 'signed' passed to SignCallback as the
+// timestamp, starting with 'timestamp'.
+func verifySigned(secret, timestamp, signed, signature string, body []byte, now time.Time, maxSkew time.Duration) (time.Time, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid timestamp", ErrCallbackUnauthorized)
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	if skew := now.Sub(signedAt); skew > maxSkew || skew < -maxSkew {
+		return time.Time{}, fmt.Errorf("%w: timestamp too far from current time", ErrCallbackUnauthorized)
+	}
+
+	expected, err := SignCallback(secret, signed, body)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return time.Time{}, ErrCallbackUnauthorized
+	}
+
+	return signedAt, nil
+}
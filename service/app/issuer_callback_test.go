@@ -0,0 +1,80 @@
+package app
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVerifyCallback(t *testing.T) {
+	secret, err := newCallbackSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"callbackID":"1","event":"payment.confirmed"}`)
+	sign := func(at time.Time) (string, string) {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		sig, err := SignCallback(secret, ts, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts, sig
+	}
+
+	ts, sig := sign(now.Add(-time.Minute))
+	signedAt, err := VerifyCallback(secret, ts, sig, body, now, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signedAt.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("unexpected signing time %v", signedAt)
+	}
+
+	other, _ := newCallbackSecret()
+	stale, staleSig := sign(now.Add(-10 * time.Minute))
+	future, futureSig := sign(now.Add(10 * time.Minute))
+
+	for _, c := range []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+	}{
+		{"other secret", other, ts, sig, body},
+		{"modified body", secret, ts, sig, []byte(`{"callbackID":"2","event":"payment.confirmed"}`)},
+		{"modified timestamp", secret, strconv.FormatInt(now.Unix(), 10), sig, body},
+		{"stale", secret, stale, staleSig, body},
+		{"future", secret, future, futureSig, body},
+		{"invalid timestamp", secret, "yesterday", sig, body},
+	} {
+		if _, err := VerifyCallback(c.secret, c.timestamp, c.signature, c.body, now, 5*time.Minute); !errors.Is(err, ErrCallbackUnauthorized) {
+			t.Errorf("%s: expected ErrCallbackUnauthorized, got %v", c.name, err)
+		}
+	}
+}
+
+func TestIssuerCallbackValidate(t *testing.T) {
+	packID := uuid.New()
+
+	for _, c := range []struct {
+		name     string
+		callback IssuerCallback
+		valid    bool
+	}{
+		{"valid", IssuerCallback{CallbackID: "1", Event: "order.created"}, true},
+		{"payment", IssuerCallback{CallbackID: "1", Event: CallbackEventPaymentConfirmed, PackID: &packID}, true},
+		{"payment without pack", IssuerCallback{CallbackID: "1", Event: CallbackEventPaymentConfirmed}, false},
+		{"no callback ID", IssuerCallback{Event: "order.created"}, false},
+		{"no event", IssuerCallback{CallbackID: "1"}, false},
+	} {
+		if err := c.callback.Validate(); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid %t, got %v", c.name, c.valid, err)
+		}
+	}
+}
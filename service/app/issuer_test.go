@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestIssuerValidate(t *testing.T) {
+	address := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+
+	if err := (Issuer{Address: address, Name: "Example"}).Validate(); err != nil {
+		t.Errorf("expected issuer to be valid, got %s", err)
+	}
+
+	for name, i := range map[string]Issuer{
+		"no address": {Name: "Example"},
+		"no name":    {Address: address},
+	} {
+		if err := i.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestIssuerCheckDistribution(t *testing.T) {
+	allowed := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+	other := AddressLocation{Name: "OtherNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}
+
+	dist := &Distribution{PackTemplate: PackTemplate{Buckets: []Bucket{{CollectibleReference: allowed}, {CollectibleReference: other}}}}
+
+	if err := (Issuer{}).CheckDistribution(dist); err != nil {
+		t.Errorf("expected any contract to be allowed without a list, got %s", err)
+	}
+
+	if err := (Issuer{AllowedContracts: ContractList{allowed, other}}).CheckDistribution(dist); err != nil {
+		t.Errorf("expected allowed contracts to pass, got %s", err)
+	}
+
+	err := (Issuer{AllowedContracts: ContractList{allowed}}).CheckDistribution(dist)
+	if err == nil {
+		t.Fatal("expected a contract which is not allowed to be refused")
+	}
+	if code := ErrorCode(err); code != ErrorCodeDistributionInvalidBucket {
+		t.Errorf("expected code %s, got %s", ErrorCodeDistributionInvalidBucket, code)
+	}
+}
+
+func TestContractListValueScan(t *testing.T) {
+	list := ContractList{{Name: "ExampleNFT", Address: common.FlowAddressFromString("01cf0e2f2f715450")}}
+
+	v, err := list.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != `["A.01cf0e2f2f715450.ExampleNFT"]` {
+		t.Errorf("unexpected stored value %v", v)
+	}
+
+	scanned := ContractList{}
+	if err := scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 1 || scanned[0] != list[0] {
+		t.Errorf("expected %v, got %v", list, scanned)
+	}
+
+	if err := scanned.Scan(""); err != nil || scanned != nil {
+		t.Errorf("expected an empty value to scan to nil, got %v %v", scanned, err)
+	}
+}
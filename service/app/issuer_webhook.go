@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Webhook events of distributions are 'distribution.' followed by the new
+// state (e.g. 'distribution.settled'), packs have the events below.
+const (
+	WebhookEventPackRevealed = "pack.revealed"
+	WebhookEventPackOpened   = "pack.opened"
+	WebhookEventPackRevoked  = "pack.revoked"
+)
+
+// Headers of webhook deliveries, the signature is the same as of issuer
+// callbacks (see SignCallback) keyed with the secret of the webhook.
+const (
+	WebhookDeliveryHeader  = "X-PDS-Delivery"
+	WebhookEventHeader     = "X-PDS-Event"
+	WebhookTimestampHeader = "X-PDS-Timestamp"
+	WebhookSignatureHeader = "X-PDS-Signature"
+)
+
+const maxWebhookRetryInterval = time.Hour
+
+var errWebhookTargetNotPublic = errors.New("webhook target is not a public address")
+
+// IssuerWebhook receives the state changes of the distributions and packs of
+// an issuer. A webhook registered with an API key stops receiving events
+// once the key is revoked.
+type IssuerWebhook struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer   common.FlowAddress `gorm:"column:issuer;index"`
+	APIKeyID *uuid.UUID         `gorm:"column:api_key_id;index"` // Optional, key the webhook was registered with
+	URL      string             `gorm:"column:url"`
+	Secret   string             `gorm:"column:secret"` // Hex encoded, signs deliveries
+}
+
+func (IssuerWebhook) TableName() string {
+	return "issuer_webhooks"
+}
+
+func (w *IssuerWebhook) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return nil
+}
+
+// newIssuerWebhook returns a new webhook of 'issuer' with a random secret.
+func newIssuerWebhook(issuer common.FlowAddress, apiKeyID *uuid.UUID, webhookURL string) (*IssuerWebhook, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL '%s'", webhookURL)
+	}
+
+	secret, err := newCallbackSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuerWebhook{Issuer: issuer, APIKeyID: apiKeyID, URL: webhookURL, Secret: secret}, nil
+}
+
+// WebhookDelivery is an event queued for a webhook (an outbox). Deliveries
+// are queued in the same database transaction as the state change they
+// report, so no event is lost or sent for a change which was rolled back.
+type WebhookDelivery struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	WebhookID     uuid.UUID      `gorm:"column:webhook_id;index"`
+	Event         string         `gorm:"column:event"`
+	Payload       datatypes.JSON `gorm:"column:payload"`
+	Handled       bool           `gorm:"column:handled;index"` // Sent or given up on
+	Attempts      uint           `gorm:"column:attempts"`
+	NextAttemptAt time.Time      `gorm:"column:next_attempt_at;index"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	d.ID = uuid.New()
+	return nil
+}
+
+// Failed records a failed attempt at 'now' and schedules the next one,
+// backing off exponentially from 'interval'. Returns false once 'maxAttempts'
+// is reached.
+func (d *WebhookDelivery) Failed(now time.Time, interval time.Duration, maxAttempts uint) bool {
+	d.Attempts++
+	if d.Attempts >= maxAttempts {
+		return false
+	}
+
+	wait := interval
+	for i := uint(1); i < d.Attempts && wait < maxWebhookRetryInterval; i++ {
+		wait *= 2
+	}
+	if wait > maxWebhookRetryInterval {
+		wait = maxWebhookRetryInterval
+	}
+
+	d.NextAttemptAt = now.Add(wait)
+	return true
+}
+
+// webhookEventPayload is the body of webhook deliveries
+type webhookEventPayload struct {
+	Event          string                   `json:"event"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	DistributionID uuid.UUID                `json:"distID"`
+	DistFlowID     common.FlowID            `json:"distFlowID"`
+	State          common.DistributionState `json:"state,omitempty"`
+	PackID         *uuid.UUID               `json:"packID,omitempty"`
+	PackFlowID     *common.FlowID           `json:"packFlowID,omitempty"`
+	PackState      common.PackState         `json:"packState,omitempty"`
+}
+
+// queueDistributionWebhooks queues the current state of 'dist' for the
+// webhooks of its issuer.
+func queueDistributionWebhooks(db *gorm.DB, dist *Distribution, now time.Time) error {
+	return queueWebhooks(db, dist.Issuer, now, webhookEventPayload{
+		Event:          "distribution." + string(dist.State),
+		CreatedAt:      now,
+		DistributionID: dist.ID,
+		DistFlowID:     dist.FlowID,
+		State:          dist.State,
+	})
+}
+
+// queuePackWebhooks queues 'event' of 'p' for the webhooks of the issuer of
+// 'dist'.
+func queuePackWebhooks(db *gorm.DB, dist *Distribution, p *Pack, event string, now time.Time) error {
+	return queueWebhooks(db, dist.Issuer, now, webhookEventPayload{
+		Event:          event,
+		CreatedAt:      now,
+		DistributionID: dist.ID,
+		DistFlowID:     dist.FlowID,
+		PackID:         &p.ID,
+		PackFlowID:     &p.FlowID,
+		PackState:      p.State,
+	})
+}
+
+func queueWebhooks(db *gorm.DB, issuer common.FlowAddress, now time.Time, payload webhookEventPayload) error {
+	webhooks, err := ListActiveIssuerWebhooks(db, issuer)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]WebhookDelivery, len(webhooks))
+	for i, w := range webhooks {
+		deliveries[i] = WebhookDelivery{
+			WebhookID:     w.ID,
+			Event:         payload.Event,
+			Payload:       datatypes.JSON(body),
+			NextAttemptAt: now,
+		}
+	}
+
+	return InsertWebhookDeliveries(db, deliveries)
+}
+
+// newIssuerWebhookClient returns the client issuer webhooks are sent with.
+// Issuers choose the URLs, so unless 'allowPrivate' is set connections to
+// private, loopback and link-local addresses are refused. The address is
+// checked as dialed, after DNS resolution and on redirects as well.
+func newIssuerWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: checkWebhookTarget}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would dial the target instead
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// checkWebhookTarget refuses to connect to 'address' (IP and port) unless
+// the IP is public.
+func checkWebhookTarget(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", errWebhookTargetNotPublic, host)
+	}
+
+	return nil
+}
+
+// sendWebhookDelivery posts 'd' to 'w' signed with the secret of 'w'. Any
+// non 2xx response is considered an error.
+func sendWebhookDelivery(ctx context.Context, client *http.Client, w *IssuerWebhook, d *WebhookDelivery, now time.Time) error {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	signature, err := SignCallback(w.Secret, timestamp, []byte(d.Payload))
+	if err != nil {
+		return err
+	}
+
+	return postWebhookWithHeaders(ctx, client, w.URL, []byte(d.Payload), map[string]string{
+		WebhookDeliveryHeader:  d.ID.String(),
+		WebhookEventHeader:     d.Event,
+		WebhookTimestampHeader: timestamp,
+		WebhookSignatureHeader: signature,
+	})
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestNewIssuerWebhook(t *testing.T) {
+	issuer := common.FlowAddressFromString("0x01cf0e2f2f715450")
+
+	for _, u := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+		if _, err := newIssuerWebhook(issuer, nil, u); err == nil {
+			t.Errorf("expected an error for URL '%s'", u)
+		}
+	}
+
+	w, err := newIssuerWebhook(issuer, nil, "https://example.com/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Secret) != 2*callbackSecretBytes {
+		t.Errorf("expected a %d byte hex encoded secret, got '%s'", callbackSecretBytes, w.Secret)
+	}
+}
+
+func TestWebhookDeliveryBackoff(t *testing.T) {
+	now := time.Now()
+	d := WebhookDelivery{}
+
+	expected := []time.Duration{
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+	}
+
+	for i, wait := range expected {
+		if !d.Failed(now, 30*time.Second, 10) {
+			t.Fatalf("attempt %d: expected a retry", i+1)
+		}
+		if got := d.NextAttemptAt.Sub(now); got != wait {
+			t.Errorf("attempt %d: expected to wait %s, got %s", i+1, wait, got)
+		}
+	}
+
+	// Capped
+	for d.Attempts < 8 {
+		d.Failed(now, 30*time.Second, 10)
+	}
+	if got := d.NextAttemptAt.Sub(now); got != maxWebhookRetryInterval {
+		t.Errorf("expected to wait at most %s, got %s", maxWebhookRetryInterval, got)
+	}
+
+	d.Failed(now, 30*time.Second, 10)
+	if d.Failed(now, 30*time.Second, 10) {
+		t.Error("expected to give up after the max attempts")
+	}
+}
+
+func TestIssuerWebhookClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Resolved names are checked as well
+	local := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	for _, u := range []string{srv.URL, local} {
+		err := postWebhook(context.Background(), newIssuerWebhookClient(time.Second, false), u, []byte("{}"))
+		if !errors.Is(err, errWebhookTargetNotPublic) {
+			t.Errorf("%s: expected a loopback target to be refused, got %v", u, err)
+		}
+	}
+
+	if err := postWebhook(context.Background(), newIssuerWebhookClient(time.Second, true), srv.URL, []byte("{}")); err != nil {
+		t.Errorf("expected a loopback target to be allowed if opted in, got %v", err)
+	}
+
+	for _, addr := range []string{"10.0.0.1:443", "169.254.169.254:80", "[::1]:443", "[fe80::1]:443", "0.0.0.0:80"} {
+		if err := checkWebhookTarget("tcp", addr, nil); !errors.Is(err, errWebhookTargetNotPublic) {
+			t.Errorf("%s: expected the target to be refused, got %v", addr, err)
+		}
+	}
+	if err := checkWebhookTarget("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("expected a public target to be allowed, got %v", err)
+	}
+}
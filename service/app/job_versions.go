@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// checkJobVersions warns about unfinished transactions created by a newer
+// version of the PDS (e.g. after a rollback), this version leaves them alone.
+func checkJobVersions(db *gorm.DB) error {
+	newer, err := transactions.CountNewerJobs(db)
+	if err != nil {
+		return err
+	}
+
+	if newer > 0 {
+		log.WithFields(log.Fields{
+			"count":      newer,
+			"jobVersion": transactions.JobVersion,
+		}).Warn("Transactions of a newer job version found, they are not sent or checked by this version")
+	}
+
+	return nil
+}
+
+// handleOutdatedJobs migrates transactions of an older job version which a
+// previous version of the PDS left to be sent, or re-plans them if there is
+// no migration.
+func handleOutdatedJobs(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		outdated, err := transactions.ListOutdatedJobs(tx, app.cfg.BatchProcessSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range outdated {
+			t := &outdated[i]
+
+			logger := log.WithFields(log.Fields{
+				"ID":             t.ID,
+				"name":           t.Name,
+				"distributionID": t.DistributionID,
+				"jobVersion":     t.JobVersion,
+			})
+
+			from := t.JobVersion
+
+			migrated, err := t.MigrateJob()
+			if err != nil {
+				return err
+			}
+
+			if migrated {
+				if from < 2 && t.Name == REVEAL_SCRIPT {
+					// Version 1 stored the salt, it is filled in when sending now
+					args, err := withRevealSalt(t, cadence.String(""))
+					if err != nil {
+						return err
+					}
+					if err := t.SetArguments(args); err != nil {
+						return err
+					}
+				}
+
+				if err := t.Save(tx); err != nil {
+					return err
+				}
+				logger.WithFields(log.Fields{"newJobVersion": t.JobVersion}).Info("Transaction migrated")
+				continue
+			}
+
+			if err := app.service.replanJob(tx, t, from); err != nil {
+				return fmt.Errorf("error while re-planning transaction %s: %w", t.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// replanJob replaces a transaction of an older job version which can not be
+// migrated. Settle and mint batches are rebuilt with the current code from
+// the collectibles and packs they were for, 't' is set failed. Other
+// transactions are moved to dead-letter for an operator to look at.
+func (svc *ContractService) replanJob(db *gorm.DB, t *transactions.StorableTransaction, from uint) error {
+	logger := log.WithFields(log.Fields{
+		"ID":             t.ID,
+		"name":           t.Name,
+		"distributionID": t.DistributionID,
+		"jobVersion":     from,
+	})
+
+	reason := fmt.Sprintf("created by job version %d, can not be migrated to %d", from, transactions.JobVersion)
+
+	var replanned []*transactions.StorableTransaction
+
+	switch t.Name {
+	case SETTLE_SCRIPT:
+		dist, err := GetDistributionSmall(db, t.DistributionID)
+		if err != nil {
+			return err
+		}
+
+		settlement, err := GetDistributionSettlement(db, dist.ID)
+		if err != nil {
+			return err
+		}
+
+		flowIDs, err := settleFlowIDs(t)
+		if err != nil {
+			return err
+		}
+
+		collectibles, err := QueuedSettlementCollectiblesByFlowIDs(db, settlement.ID, flowIDs)
+		if err != nil {
+			return err
+		}
+
+		for contract, cc := range collectibles.GroupByContract() {
+			n, err := newSettleTransaction(dist, contract, cc)
+			if err != nil {
+				return err
+			}
+			replanned = append(replanned, n)
+		}
+
+	case MINT_SCRIPT:
+		dist, err := GetDistributionSmall(db, t.DistributionID)
+		if err != nil {
+			return err
+		}
+
+		hashes, err := arrayArgument(t, 1)
+		if err != nil {
+			return err
+		}
+
+		packs := []Pack{}
+		for _, v := range hashes {
+			commitmentHash, err := common.BinaryValueFromCadence(v)
+			if err != nil {
+				return err
+			}
+
+			pack, err := GetMintingPack(db, commitmentHash)
+			if err != nil {
+				// Minted already
+				continue
+			}
+
+			packs = append(packs, *pack)
+		}
+
+		if len(packs) > 0 {
+			n, err := newMintTransaction(dist, packs)
+			if err != nil {
+				return err
+			}
+			replanned = append(replanned, n)
+		}
+
+	default:
+		t.State = common.TransactionStateDeadLetter
+		t.Error = reason
+		logger.Error("Transaction can not be migrated, moved to dead-letter")
+		return t.Save(db)
+	}
+
+	for _, n := range replanned {
+		n.Priority = t.Priority
+		if err := n.Save(db); err != nil {
+			return err
+		}
+	}
+
+	t.State = common.TransactionStateFailed
+	t.Error = fmt.Sprintf("%s, re-planned as %d new transactions", reason, len(replanned))
+
+	logger.WithFields(log.Fields{"transactions": len(replanned)}).Warn("Transaction can not be migrated, re-planned")
+
+	return t.Save(db)
+}
@@ -0,0 +1,349 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrSendingFrozen is returned when trying to send a transaction while
+// sending is frozen, e.g. during a key rotation
+var ErrSendingFrozen = &Error{Code: ErrorCodeSendingFrozen, Err: errors.New("sending transactions is frozen")}
+
+// KeyRotation records a rotate-and-freeze operation: sending is frozen, the
+// keys the PDS account signed with are revoked onchain using the recovery key
+// and the service switches to the standby key set.
+type KeyRotation struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	State             common.KeyRotationState `gorm:"column:state;not null;default:null"`
+	Reason            string                  `gorm:"column:reason"`
+	RevokedKeyIndexes datatypes.JSON          `gorm:"column:revoked_key_indexes"`
+	StandbyKeyIndexes datatypes.JSON          `gorm:"column:standby_key_indexes"`
+	TransactionID     string                  `gorm:"column:transaction_id"` // Flow ID of the revoke transaction
+	Error             string                  `gorm:"column:error"`
+}
+
+func (KeyRotation) TableName() string {
+	return "key_rotations"
+}
+
+func (r *KeyRotation) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return nil
+}
+
+// SetKeyIndexes sets the key indexes to revoke and to switch to.
+func (r *KeyRotation) SetKeyIndexes(revoked, standby []int) error {
+	revokedJSON, err := json.Marshal(revoked)
+	if err != nil {
+		return err
+	}
+	standbyJSON, err := json.Marshal(standby)
+	if err != nil {
+		return err
+	}
+	r.RevokedKeyIndexes, r.StandbyKeyIndexes = revokedJSON, standbyJSON
+	return nil
+}
+
+// Revoked returns the key indexes revoked (or to be revoked) by the rotation.
+func (r *KeyRotation) Revoked() ([]int, error) {
+	res := []int{}
+	if len(r.RevokedKeyIndexes) == 0 {
+		return res, nil
+	}
+	return res, json.Unmarshal(r.RevokedKeyIndexes, &res)
+}
+
+// KeyRotationNotifier is notified when a key rotation starts and when it
+// completes or fails. Notifiers are plugged in with App.AddKeyRotationNotifier.
+type KeyRotationNotifier interface {
+	NotifyKeyRotation(ctx context.Context, r *KeyRotation) error
+}
+
+// logKeyRotationNotifier logs key rotations, it is always in use.
+type logKeyRotationNotifier struct{}
+
+func (logKeyRotationNotifier) NotifyKeyRotation(ctx context.Context, r *KeyRotation) error {
+	logger := log.WithFields(log.Fields{
+		"keyRotationID":     r.ID,
+		"state":             r.State,
+		"reason":            r.Reason,
+		"revokedKeyIndexes": string(r.RevokedKeyIndexes),
+		"standbyKeyIndexes": string(r.StandbyKeyIndexes),
+		"transactionID":     r.TransactionID,
+	})
+	if r.State == common.KeyRotationStateFailed {
+		logger.WithFields(log.Fields{"error": r.Error}).Error("Key rotation failed, sending stays frozen")
+	} else {
+		logger.Warn("Key rotation")
+	}
+	return nil
+}
+
+// keyRotationWebhookPayload is the body of key rotation webhook requests
+type keyRotationWebhookPayload struct {
+	Event             string                  `json:"event"`
+	KeyRotationID     uuid.UUID               `json:"keyRotationID"`
+	State             common.KeyRotationState `json:"state"`
+	Reason            string                  `json:"reason,omitempty"`
+	RevokedKeyIndexes json.RawMessage         `json:"revokedKeyIndexes,omitempty"`
+	StandbyKeyIndexes json.RawMessage         `json:"standbyKeyIndexes,omitempty"`
+	TransactionID     string                  `json:"transactionID,omitempty"`
+	Error             string                  `json:"error,omitempty"`
+}
+
+// WebhookKeyRotationNotifier posts key rotations to a URL as JSON.
+// Any non 2xx response is considered an error.
+type WebhookKeyRotationNotifier struct {
+	Client *http.Client
+	URL    string
+}
+
+func (n WebhookKeyRotationNotifier) NotifyKeyRotation(ctx context.Context, r *KeyRotation) error {
+	body, err := json.Marshal(keyRotationWebhookPayload{
+		Event:             "key-rotation",
+		KeyRotationID:     r.ID,
+		State:             r.State,
+		Reason:            r.Reason,
+		RevokedKeyIndexes: json.RawMessage(r.RevokedKeyIndexes),
+		StandbyKeyIndexes: json.RawMessage(r.StandbyKeyIndexes),
+		TransactionID:     r.TransactionID,
+		Error:             r.Error,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// keyState holds the key set the PDS account currently signs with, the
+// optional standby and recovery keys, and whether sending is frozen.
+type keyState struct {
+	mu       sync.RWMutex
+	account  *flow_helpers.Account
+	standby  *flow_helpers.Account
+	recovery *flow_helpers.Account
+	frozen   bool
+}
+
+// Account returns the key set to sign transactions with.
+func (k *keyState) Account() *flow_helpers.Account {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.account
+}
+
+func (k *keyState) Frozen() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.frozen
+}
+
+func (k *keyState) SetFrozen(frozen bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.frozen = frozen
+}
+
+// indexes returns the key indexes of the active and standby key sets.
+func (k *keyState) indexes() (active, standby []int) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	active, standby = k.account.KeyIndexes(), []int{}
+	if k.standby != nil {
+		standby = k.standby.KeyIndexes()
+	}
+	return active, standby
+}
+
+// switchToStandby makes the standby key set the active one, the standby
+// can be used only once.
+func (k *keyState) switchToStandby() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.standby == nil {
+		return fmt.Errorf("no standby key set configured")
+	}
+	k.account, k.standby = k.standby, nil
+	return nil
+}
+
+// newKeyState initializes the key state from 'cfg', 'account' being the
+// active key set of the PDS account.
+func newKeyState(cfg *config.Config, account *flow_helpers.Account) (*keyState, error) {
+	k := &keyState{account: account}
+
+	active := make(map[int]bool)
+	for _, i := range account.KeyIndexes() {
+		active[i] = true
+	}
+
+	if cfg.StandbyPrivateKey != "" {
+		if len(cfg.StandbyPrivateKeyIndexes) == 0 {
+			return nil, fmt.Errorf("standby key indexes (FLOW_PDS_STANDBY_PRIVATE_KEY_INDEXES) are required with a standby key")
+		}
+		for _, i := range cfg.StandbyPrivateKeyIndexes {
+			if active[i] {
+				return nil, fmt.Errorf("standby key index %d is also an admin key index", i)
+			}
+		}
+		k.standby = flow_helpers.NewAccount(account.Address, cfg.StandbyPrivateKey, cfg.StandbyPrivateKeyType, cfg.StandbyPrivateKeyIndexes)
+	}
+
+	if cfg.RecoveryPrivateKey != "" {
+		if active[cfg.RecoveryPrivateKeyIndex] {
+			return nil, fmt.Errorf("recovery key index %d is also an admin key index", cfg.RecoveryPrivateKeyIndex)
+		}
+		k.recovery = flow_helpers.NewAccount(account.Address, cfg.RecoveryPrivateKey, cfg.RecoveryPrivateKeyType, []int{cfg.RecoveryPrivateKeyIndex})
+	}
+
+	return k, nil
+}
+
+// RotateKeys freezes sending, revokes the keys the PDS account signs with
+// using the recovery key and switches to the standby key set. Sending is
+// resumed if all of it succeeds, otherwise it stays frozen until unfrozen by
+// an admin. The revoke transaction ID is set to 'r'.
+func (svc *ContractService) RotateKeys(ctx context.Context, r *KeyRotation) error {
+	svc.keys.SetFrozen(true)
+
+	svc.keys.mu.RLock()
+	active, standby, recovery := svc.keys.account, svc.keys.standby, svc.keys.recovery
+	svc.keys.mu.RUnlock()
+
+	if standby == nil || recovery == nil {
+		return fmt.Errorf("standby and recovery keys need to be configured to rotate keys")
+	}
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(REVOKE_KEYS_SCRIPT, nil)
+	if err != nil {
+		return err
+	}
+
+	revoked := active.KeyIndexes()
+	indexes := make([]cadence.Value, len(revoked))
+	for i, idx := range revoked {
+		indexes[i] = cadence.NewInt(idx)
+	}
+
+	tx := flow.NewTransaction().
+		SetScript(txScript).
+		SetGasLimit(svc.cfg.TransactionGasLimit)
+
+	if err := tx.AddArgument(cadence.NewArray(indexes)); err != nil {
+		return err
+	}
+
+	referenceBlock, err := svc.refBlocks.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx.SetReferenceBlockID(referenceBlock.ID)
+
+	broadcaster := svc.broadcaster(svc.flowClient)
+
+	unlock, err := broadcaster.Sign(ctx, tx, recovery)
+	defer unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := broadcaster.Send(ctx, tx); err != nil {
+		return err
+	}
+
+	r.TransactionID = tx.ID().Hex()
+
+	if _, err := broadcaster.WaitForSeal(ctx, tx.ID()); err != nil {
+		return fmt.Errorf("error while revoking keys: %w", err)
+	}
+
+	if err := svc.keys.switchToStandby(); err != nil {
+		return err
+	}
+
+	svc.keys.SetFrozen(false)
+
+	return nil
+}
+
+// checkKeyRotations freezes sending if a key rotation was left unfinished
+// (e.g. the service stopped while revoking) or if the active key set has
+// already been revoked by a previous rotation.
+func checkKeyRotations(db *gorm.DB, keys *keyState) error {
+	rotations, err := ListKeyRotations(db)
+	if err != nil {
+		return err
+	}
+
+	active, _ := keys.indexes()
+
+	for _, r := range rotations {
+		logger := log.WithFields(log.Fields{"keyRotationID": r.ID, "state": r.State})
+
+		if r.State == common.KeyRotationStateRevoking {
+			logger.Error("Unfinished key rotation found, sending frozen")
+			keys.SetFrozen(true)
+			return nil
+		}
+
+		revoked, err := r.Revoked()
+		if err != nil {
+			return err
+		}
+
+		if r.State == common.KeyRotationStateComplete && intersects(revoked, active) {
+			logger.Error("Admin key indexes were revoked by a key rotation, sending frozen")
+			keys.SetFrozen(true)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func intersects(a, b []int) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
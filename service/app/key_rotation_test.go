@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestNewKeyState(t *testing.T) {
+	address := flow.HexToAddress("0x01")
+	account := flow_helpers.NewAccount(address, "admin", "local", []int{0, 1, 2})
+
+	cfg := &config.Config{
+		StandbyPrivateKey:        "standby",
+		StandbyPrivateKeyIndexes: []int{2, 3},
+	}
+	if _, err := newKeyState(cfg, account); err == nil {
+		t.Fatal("expected an error for an overlapping standby key index")
+	}
+
+	cfg.StandbyPrivateKeyIndexes = []int{3, 4}
+	cfg.RecoveryPrivateKey = "recovery"
+	cfg.RecoveryPrivateKeyIndex = 0
+	if _, err := newKeyState(cfg, account); err == nil {
+		t.Fatal("expected an error for an overlapping recovery key index")
+	}
+
+	cfg.RecoveryPrivateKeyIndex = 5
+	k, err := newKeyState(cfg, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.switchToStandby(); err != nil {
+		t.Fatal(err)
+	}
+	if active, standby := k.indexes(); len(active) != 2 || active[0] != 3 || len(standby) != 0 {
+		t.Fatalf("expected to sign with the standby keys, got %v (standby %v)", active, standby)
+	}
+	if err := k.switchToStandby(); err == nil {
+		t.Fatal("expected the standby keys to be usable only once")
+	}
+}
+
+func TestKeyRotationRevoked(t *testing.T) {
+	r := KeyRotation{}
+	if revoked, err := r.Revoked(); err != nil || len(revoked) != 0 {
+		t.Fatalf("expected no revoked indexes, got %v (%v)", revoked, err)
+	}
+
+	if err := r.SetKeyIndexes([]int{0, 1}, []int{3}); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := r.Revoked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !intersects(revoked, []int{1, 2}) || intersects(revoked, []int{3}) {
+		t.Fatalf("unexpected revoked indexes %v", revoked)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+)
+
+// LaneScheduler picks the lane (priority) to send the next transaction from,
+// with smooth weighted round robin over the lanes which have transactions to
+// send: with weights 6, 3 and 1 and all lanes busy, 6 of 10 sends are user
+// facing. Empty lanes do not bank credit for later. Lanes with a weight of 0
+// are only sent from when all weighted lanes are empty, in priority order.
+type LaneScheduler struct {
+	mu      sync.Mutex
+	weights map[transactions.Priority]int
+	current map[transactions.Priority]int
+}
+
+func NewLaneScheduler(weights map[transactions.Priority]uint) *LaneScheduler {
+	s := &LaneScheduler{
+		weights: make(map[transactions.Priority]int, len(transactions.Priorities)),
+		current: make(map[transactions.Priority]int, len(transactions.Priorities)),
+	}
+	for _, p := range transactions.Priorities {
+		s.weights[p] = int(weights[p])
+	}
+	return s
+}
+
+// Next calls 'try' for lanes in scheduling order until it returns true (a
+// transaction of that lane was found) and returns that lane. Returns false if
+// all lanes are empty.
+func (s *LaneScheduler) Next(try func(transactions.Priority) (bool, error)) (transactions.Priority, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.order()
+
+	for i, p := range order {
+		found, err := try(p)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !found {
+			// Empty, does not take part in this round
+			if s.current[p] > 0 {
+				s.current[p] = 0
+			}
+			continue
+		}
+
+		if s.weights[p] > 0 {
+			// Credit this and the untried lanes, charge the chosen one
+			total := 0
+			for _, q := range order[i:] {
+				s.current[q] += s.weights[q]
+				total += s.weights[q]
+			}
+			s.current[p] -= total
+		}
+
+		return p, true, nil
+	}
+
+	return "", false, nil
+}
+
+// order returns the lanes by the credit they would have this round, highest
+// first, lanes with a weight of 0 last.
+func (s *LaneScheduler) order() []transactions.Priority {
+	order := make([]transactions.Priority, len(transactions.Priorities))
+	copy(order, transactions.Priorities)
+
+	rank := func(p transactions.Priority) int {
+		return s.current[p] + s.weights[p]
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		wi, wj := s.weights[order[i]] > 0, s.weights[order[j]] > 0
+		if wi != wj {
+			return wi
+		}
+		return rank(order[i]) > rank(order[j])
+	})
+
+	return order
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+)
+
+func countLanes(t *testing.T, s *LaneScheduler, n int, busy map[transactions.Priority]bool) map[transactions.Priority]int {
+	t.Helper()
+	counts := map[transactions.Priority]int{}
+	for i := 0; i < n; i++ {
+		p, ok, err := s.Next(func(p transactions.Priority) (bool, error) { return busy[p], nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			counts[p]++
+		}
+	}
+	return counts
+}
+
+func TestLaneSchedulerWeights(t *testing.T) {
+	s := NewLaneScheduler(map[transactions.Priority]uint{
+		transactions.PriorityUserFacing: 6,
+		transactions.PrioritySettlement: 3,
+		transactions.PriorityMinting:    1,
+	})
+
+	all := map[transactions.Priority]bool{
+		transactions.PriorityUserFacing: true,
+		transactions.PrioritySettlement: true,
+		transactions.PriorityMinting:    true,
+	}
+
+	counts := countLanes(t, s, 100, all)
+	if counts[transactions.PriorityUserFacing] != 60 || counts[transactions.PrioritySettlement] != 30 || counts[transactions.PriorityMinting] != 10 {
+		t.Fatalf("expected 60/30/10, got %v", counts)
+	}
+
+	// First pick goes to the highest priority
+	s = NewLaneScheduler(map[transactions.Priority]uint{
+		transactions.PriorityUserFacing: 1,
+		transactions.PrioritySettlement: 1,
+		transactions.PriorityMinting:    1,
+	})
+	if p, _, _ := s.Next(func(transactions.Priority) (bool, error) { return true, nil }); p != transactions.PriorityUserFacing {
+		t.Fatalf("expected %s first, got %s", transactions.PriorityUserFacing, p)
+	}
+}
+
+func TestLaneSchedulerEmptyLanes(t *testing.T) {
+	s := NewLaneScheduler(map[transactions.Priority]uint{
+		transactions.PriorityUserFacing: 6,
+		transactions.PrioritySettlement: 3,
+		transactions.PriorityMinting:    1,
+	})
+
+	// Minting only, gets every send
+	counts := countLanes(t, s, 50, map[transactions.Priority]bool{transactions.PriorityMinting: true})
+	if counts[transactions.PriorityMinting] != 50 {
+		t.Fatalf("expected all sends to go to minting, got %v", counts)
+	}
+
+	// An idle lane does not bank credit, once user facing transactions show
+	// up minting keeps its share
+	counts = countLanes(t, s, 70, map[transactions.Priority]bool{
+		transactions.PriorityUserFacing: true,
+		transactions.PriorityMinting:    true,
+	})
+	if m := counts[transactions.PriorityMinting]; m < 9 || m > 11 {
+		t.Fatalf("expected minting to get about 10 of 70 sends, got %v", counts)
+	}
+
+	// Nothing to send
+	if _, ok, _ := s.Next(func(transactions.Priority) (bool, error) { return false, nil }); ok {
+		t.Fatal("expected no lane")
+	}
+}
+
+func TestLaneSchedulerZeroWeight(t *testing.T) {
+	s := NewLaneScheduler(map[transactions.Priority]uint{
+		transactions.PriorityUserFacing: 1,
+		transactions.PrioritySettlement: 1,
+	})
+
+	counts := countLanes(t, s, 10, map[transactions.Priority]bool{
+		transactions.PrioritySettlement: true,
+		transactions.PriorityMinting:    true,
+	})
+	if counts[transactions.PriorityMinting] != 0 {
+		t.Fatalf("expected minting to wait for the weighted lanes, got %v", counts)
+	}
+
+	counts = countLanes(t, s, 10, map[transactions.Priority]bool{transactions.PriorityMinting: true})
+	if counts[transactions.PriorityMinting] != 10 {
+		t.Fatalf("expected minting to get all sends once alone, got %v", counts)
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// mintCommitmentHashes returns the commitment hashes of the packs minted by
+// the mint transaction 't'.
+func mintCommitmentHashes(t *transactions.StorableTransaction) ([]common.BinaryValue, error) {
+	args, err := t.ArgumentsAsCadence()
+	if err != nil {
+		return nil, err
+	}
+
+	// Mint transactions take the commitment hashes as their second argument
+	if len(args) < 2 {
+		return nil, fmt.Errorf("mint transaction %s has %d arguments", t.ID, len(args))
+	}
+
+	arr, ok := args[1].(cadence.Array)
+	if !ok {
+		return nil, fmt.Errorf("mint transaction %s has no array of commitment hashes", t.ID)
+	}
+
+	res := make([]common.BinaryValue, len(arr.Values))
+	for i, v := range arr.Values {
+		s, ok := v.(cadence.String)
+		if !ok {
+			return nil, fmt.Errorf("mint transaction %s has an invalid commitment hash %s", t.ID, v)
+		}
+		if res[i], err = common.BinaryValueFromHexString(string(s)); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// failMint counts a failed mint transaction with 'mintError' against 'pack'.
+// The pack is queued again in a later batch until 'maxAttempts' (0 means no
+// limit) is reached, then it is set to mint-failed. Returns true if the pack
+// failed permanently.
+func failMint(pack *Pack, mintError string, maxAttempts uint) (bool, error) {
+	pack.MintAttempts++
+	pack.MintError = mintError
+
+	if maxAttempts > 0 && pack.MintAttempts >= maxAttempts {
+		return true, pack.SetMintFailed()
+	}
+
+	pack.MintQueued = false
+
+	return false, nil
+}
+
+// handleFailedMint retries the packs of the failed mint transaction 't' of a
+// distribution in later batches, setting those which failed too many times
+// to mint-failed. Call only for failed transactions which were not split into
+// new ones.
+func (svc *ContractService) handleFailedMint(db *gorm.DB, t *transactions.StorableTransaction) error {
+	if t.Name != MINT_SCRIPT || t.State != common.TransactionStateFailed {
+		return nil
+	}
+
+	logger := logging.Logger(logging.Minting).WithFields(log.Fields{
+		"method":         "handleFailedMint",
+		"ID":             t.ID,
+		"distributionID": t.DistributionID,
+	})
+
+	hashes, err := mintCommitmentHashes(t)
+	if err != nil {
+		return err
+	}
+
+	packs, err := ListMintingPacksByCommitmentHashes(db, t.DistributionID, hashes)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range packs {
+		permanent, err := failMint(&packs[i], t.Error, svc.cfg.MintMaxAttempts)
+		if err != nil {
+			return err
+		}
+		if permanent {
+			failed++
+		}
+
+		if err := UpdatePack(db, &packs[i]); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		minting, err := GetDistributionMinting(db, t.DistributionID)
+		if err != nil {
+			return err
+		}
+
+		minting.FailedCount += uint(failed)
+
+		if err := UpdateMinting(db, minting); err != nil {
+			return err
+		}
+	}
+
+	logger.WithFields(log.Fields{
+		"requeued": len(packs) - failed,
+		"failed":   failed,
+		"error":    t.Error,
+	}).Warn("Mint transaction failed, retrying its packs")
+
+	return nil
+}
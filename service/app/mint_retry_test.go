@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+)
+
+func TestMintCommitmentHashes(t *testing.T) {
+	hashes := []common.BinaryValue{{0x01, 0x02}, {0xab}}
+
+	values := make([]cadence.Value, len(hashes))
+	for i, h := range hashes {
+		values[i] = cadence.NewString(h.String())
+	}
+
+	tx, err := transactions.NewTransaction(MINT_SCRIPT, []byte(""), []cadence.Value{cadence.UInt64(1), cadence.NewArray(values), cadence.Address{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := mintCommitmentHashes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != len(hashes) || res[0].String() != hashes[0].String() || res[1].String() != hashes[1].String() {
+		t.Errorf("expected %v, got %v", hashes, res)
+	}
+}
+
+func TestFailMint(t *testing.T) {
+	pack := Pack{State: common.PackStateInit, MintQueued: true}
+
+	permanent, err := failMint(&pack, "reverted", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permanent || pack.MintQueued || pack.State != common.PackStateInit {
+		t.Errorf("expected the pack to be queued again, got %+v", pack)
+	}
+
+	pack.MintQueued = true
+
+	permanent, err = failMint(&pack, "reverted again", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !permanent || pack.State != common.PackStateMintFailed || pack.MintAttempts != 2 || pack.MintError != "reverted again" {
+		t.Errorf("expected the pack to fail permanently, got %+v", pack)
+	}
+
+	unlimited := Pack{State: common.PackStateInit, MintAttempts: 100}
+	if permanent, err := failMint(&unlimited, "reverted", 0); err != nil || permanent {
+		t.Errorf("expected packs to be retried forever without a limit, got %v %v", permanent, err)
+	}
+}
+
+func TestMintingIsComplete(t *testing.T) {
+	m := Minting{CurrentCount: 8, FailedCount: 1, TotalCount: 10}
+	if m.IsComplete() {
+		t.Error("expected minting not to be complete")
+	}
+
+	m.FailedCount = 2
+	if !m.IsComplete() {
+		t.Error("expected failed packs to count towards completion")
+	}
+}
@@ -13,6 +13,7 @@ type Minting struct {
 	Distribution   Distribution `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
 	CurrentCount uint   `gorm:"column:current_count"`
+	FailedCount  uint   `gorm:"column:failed_count"` // Packs which failed to mint too many times, see PackStateMintFailed
 	TotalCount   uint   `gorm:"column:total_count"`
 	StartAtBlock uint64 `gorm:"column:start_at_block"`
 }
@@ -26,8 +27,9 @@ func (m *Minting) BeforeCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// IsComplete returns true once each pack is either minted or failed.
 func (m *Minting) IsComplete() bool {
-	return m.CurrentCount >= m.TotalCount
+	return m.CurrentCount+m.FailedCount >= m.TotalCount
 }
 
 func (m *Minting) IncrementCount() {
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DiscrepancyUnknownOwner = "unknown-owner" // No owner has been recorded for the pack
+	DiscrepancyNotOwned     = "not-owned"     // Pack is not in the collection of its believed owner
+)
+
+// OwnershipVerification represents a job verifying the onchain ownership of
+// all minted packs in a distribution against the owners in database.
+// Packs are checked in batches by the poller, ordered by their FlowID.
+type OwnershipVerification struct {
+	gorm.Model
+	ID             uuid.UUID    `gorm:"column:id;primary_key;type:uuid;"`
+	DistributionID uuid.UUID    `gorm:"index"`
+	Distribution   Distribution `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	State          common.OwnershipVerificationState `gorm:"column:state;not null;default:null"`
+	CheckedCount   uint                              `gorm:"column:checked_count"`
+	LastPackFlowID common.FlowID                     `gorm:"column:last_pack_flow_id"` // Cursor, packs up to this FlowID have been checked
+
+	Discrepancies []OwnershipDiscrepancy `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// OwnershipDiscrepancy is a pack whose onchain owner does not match the owner in database.
+type OwnershipDiscrepancy struct {
+	gorm.Model
+	ID                      uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+	OwnershipVerificationID uuid.UUID `gorm:"index"`
+
+	PackID        uuid.UUID          `gorm:"column:pack_id"`
+	PackFlowID    common.FlowID      `gorm:"column:pack_flow_id"`
+	ExpectedOwner common.FlowAddress `gorm:"column:expected_owner"`
+	Reason        string             `gorm:"column:reason"`
+}
+
+func (OwnershipVerification) TableName() string {
+	return "ownership_verifications"
+}
+
+func (v *OwnershipVerification) BeforeCreate(tx *gorm.DB) (err error) {
+	v.ID = uuid.New()
+	return nil
+}
+
+func (OwnershipDiscrepancy) TableName() string {
+	return "ownership_discrepancies"
+}
+
+func (d *OwnershipDiscrepancy) BeforeCreate(tx *gorm.DB) (err error) {
+	d.ID = uuid.New()
+	return nil
+}
+
+func (v *OwnershipVerification) IsComplete() bool {
+	return v.State == common.OwnershipVerificationStateComplete
+}
+
+// findOwnershipDiscrepancies compares the believed owners of 'packs' with
+// 'owned' (pack FlowIDs in the onchain collection of each owner).
+func findOwnershipDiscrepancies(packs []Pack, owned map[common.FlowAddress]map[int64]bool) []OwnershipDiscrepancy {
+	res := []OwnershipDiscrepancy{}
+	for _, p := range packs {
+		reason := ""
+		if p.Owner == (common.FlowAddress{}) {
+			reason = DiscrepancyUnknownOwner
+		} else if !owned[p.Owner][p.FlowID.Int64] {
+			reason = DiscrepancyNotOwned
+		}
+
+		if reason != "" {
+			res = append(res, OwnershipDiscrepancy{
+				PackID:        p.ID,
+				PackFlowID:    p.FlowID,
+				ExpectedOwner: p.Owner,
+				Reason:        reason,
+			})
+		}
+	}
+	return res
+}
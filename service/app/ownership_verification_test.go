@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestFindOwnershipDiscrepancies(t *testing.T) {
+	alice := common.FlowAddress(flow.HexToAddress("0x1"))
+	bob := common.FlowAddress(flow.HexToAddress("0x2"))
+
+	packs := []Pack{
+		{FlowID: common.FlowID{Int64: 1, Valid: true}, Owner: alice},
+		{FlowID: common.FlowID{Int64: 2, Valid: true}, Owner: alice}, // Transferred, missed the event
+		{FlowID: common.FlowID{Int64: 3, Valid: true}, Owner: bob},
+		{FlowID: common.FlowID{Int64: 4, Valid: true}}, // No owner recorded
+	}
+
+	owned := map[common.FlowAddress]map[int64]bool{
+		alice: {1: true},
+		bob:   {2: true, 3: true},
+	}
+
+	dd := findOwnershipDiscrepancies(packs, owned)
+
+	if len(dd) != 2 {
+		t.Fatalf("expected 2 discrepancies, got %d", len(dd))
+	}
+
+	if dd[0].PackFlowID.Int64 != 2 || dd[0].Reason != DiscrepancyNotOwned || dd[0].ExpectedOwner != alice {
+		t.Errorf("unexpected discrepancy: %+v", dd[0])
+	}
+
+	if dd[1].PackFlowID.Int64 != 4 || dd[1].Reason != DiscrepancyUnknownOwner {
+		t.Errorf("unexpected discrepancy: %+v", dd[1])
+	}
+}
@@ -1,22 +1,19 @@
 package app
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"strings"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 )
 
-const SALT_LENGTH_IN_BYTES = 32 // 256-bit
+const SALT_LENGTH_IN_BYTES = 32 // 256-bit, of commitment hash version 1
 const HASH_DELIM = ","
 
 // SetCommitmentHash should
 // - validate the pack
 // - decide on a random salt value
-// - calculate the commitment hash for the pack
-func (p *Pack) SetCommitmentHash() error {
+// - calculate the commitment hash for the pack with the scheme 'h'
+func (p *Pack) SetCommitmentHash(h CommitmentHasher) error {
 	if err := p.Validate(); err != nil {
 		return fmt.Errorf("pack validation error: %w", err)
 	}
@@ -29,31 +26,45 @@ func (p *Pack) SetCommitmentHash() error {
 		return fmt.Errorf("commitmentHash is already set")
 	}
 
-	salt, err := common.GenerateRandomBytes(SALT_LENGTH_IN_BYTES)
+	salt, err := common.GenerateRandomBytes(h.SaltLength())
 	if err != nil {
 		return err
 	}
 
 	p.Salt = salt
-	p.CommitmentHash = p.Hash()
+	p.CommitmentHash = p.Hash(h)
 
 	return nil
 }
 
-// Hash outputs the 'commitmentHash' of a pack.
-// It is converting inputs to string and joining them with a delim to make the input more readable.
-// This will allow anyone to easily copy paste strings and verify the hash.
-// We also use the full reference (address and name) of a collectible to make
-// it more difficult to fiddle with the types of collectibles inside a pack.
-func (p *Pack) Hash() []byte {
-	inputs := make([]string, 1+len(p.Collectibles))
-	inputs[0] = hex.EncodeToString(p.Salt)
-	for i, c := range p.Collectibles {
-		inputs[i+1] = c.HashString()
+// Hash outputs the 'commitmentHash' of a pack with the scheme 'h'.
+func (p *Pack) Hash(h CommitmentHasher) []byte {
+	return h.Hash(p)
+}
+
+// CollectibleContracts returns the distinct contracts of the collectibles of
+// a pack, in slot order.
+func (p *Pack) CollectibleContracts() []AddressLocation {
+	res := []AddressLocation{}
+	seen := make(map[AddressLocation]bool)
+	for _, c := range p.Collectibles {
+		if !seen[c.ContractReference] {
+			seen[c.ContractReference] = true
+			res = append(res, c.ContractReference)
+		}
+	}
+	return res
+}
+
+// SetMintFailed sets a pack which was never minted as failed to mint
+func (p *Pack) SetMintFailed() error {
+	if p.State != common.PackStateInit {
+		return fmt.Errorf("pack in unexpected state: %s", p.State)
 	}
-	input := strings.Join(inputs, HASH_DELIM)
-	hash := sha256.Sum256([]byte(input))
-	return hash[:]
+
+	p.State = common.PackStateMintFailed
+
+	return nil
 }
 
 // Seal should set the FlowID of the pack and set it as sealed
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How many packs a bulk reveal can include
+const maxBulkRevealPacks = 1000
+
+// Actions of a bulk reveal on a pack
+const (
+	BulkRevealActionReveal = "reveal"
+	BulkRevealActionOpen   = "open"
+)
+
+// BulkRevealResult is the transaction stored for a pack of a bulk reveal.
+type BulkRevealResult struct {
+	PackID        uuid.UUID
+	TransactionID uuid.UUID
+	Action        string // BulkRevealActionReveal or BulkRevealActionOpen
+}
+
+// validateBulkReveal checks the pack IDs of a bulk reveal: at least one, at
+// most maxBulkRevealPacks and no duplicates.
+func validateBulkReveal(packIDs []uuid.UUID) error {
+	if len(packIDs) == 0 {
+		return newError(ErrorCodeInvalidRequest, "no pack IDs provided")
+	}
+
+	if len(packIDs) > maxBulkRevealPacks {
+		return newError(ErrorCodeInvalidRequest, "at most %d packs can be revealed at once, got %d", maxBulkRevealPacks, len(packIDs))
+	}
+
+	seen := make(map[uuid.UUID]bool, len(packIDs))
+	for _, id := range packIDs {
+		if seen[id] {
+			return newError(ErrorCodeInvalidRequest, "duplicate pack ID %s", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// bulkRevealAction returns the action of a bulk reveal on 'p' and sets 'p' to
+// the state its request would: sealed packs are revealed (and opened if
+// 'open' is set), revealed packs are opened if 'open' is set. Packs are
+// opened to their believed owner, which has to be known.
+func (p *Pack) bulkRevealAction(open bool) (string, error) {
+	if open && p.Owner == (common.FlowAddress{}) {
+		return "", newError(ErrorCodePackState, "owner of pack %s is not known", p.ID)
+	}
+
+	switch {
+	case p.State == common.PackStateSealed:
+		return BulkRevealActionReveal, p.RevealRequestHandled()
+	case p.State == common.PackStateRevealed && open:
+		return BulkRevealActionOpen, p.OpenRequestHandled()
+	case open:
+		return "", newError(ErrorCodePackState, "pack %s has to be in '%s' or '%s' state, state is '%s'", p.ID, common.PackStateSealed, common.PackStateRevealed, p.State)
+	}
+	return "", newError(ErrorCodePackState, "pack %s has to be in '%s' state, state is '%s'", p.ID, common.PackStateSealed, p.State)
+}
+
+// RevealPacks reveals the packs 'packIDs' of a distribution on behalf of the
+// issuer, e.g. for a promotional mass reveal, as if their owners had
+// requested it. If 'open' is set the packs are also opened, to the believed
+// owner of each pack. The transactions are sent in the user facing lane.
+// All packs have to be sealed (or revealed, to open them) or none is
+// revealed. Reveals are rejected with ErrRevealLocked before the
+// 'RevealNotBefore' of the distribution, instead of being queued.
+func (app *App) RevealPacks(ctx context.Context, distributionID uuid.UUID, packIDs []uuid.UUID, open bool) ([]BulkRevealResult, error) {
+	if err := validateBulkReveal(packIDs); err != nil {
+		return nil, err
+	}
+
+	res := make([]BulkRevealResult, 0, len(packIDs))
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		dist, err := GetDistributionSmall(tx, distributionID)
+		if err != nil {
+			return err
+		}
+
+		if dist.State == common.DistributionStateClosed {
+			// The shared capabilities are gone, the packs can not be revealed
+			return newError(ErrorCodeDistributionState, "distribution is closed")
+		}
+
+		if err := dist.PackTemplate.CheckRevealLock(app.clock.Now()); err != nil {
+			return err
+		}
+
+		logger := log.WithFields(log.Fields{
+			"method":     "RevealPacks",
+			"distID":     dist.ID,
+			"distFlowID": dist.FlowID,
+			"requestID":  requestID(ctx, dist),
+			"open":       open,
+		})
+
+		for _, id := range packIDs {
+			pack, err := GetPack(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+			if err != nil {
+				return err
+			}
+
+			if pack.DistributionID != dist.ID {
+				return fmt.Errorf("pack %s is not of distribution %s: %w", id, dist.ID, gorm.ErrRecordNotFound)
+			}
+
+			action, err := pack.bulkRevealAction(open)
+			if err != nil {
+				return err
+			}
+
+			if err := UpdatePack(tx, pack); err != nil {
+				return err
+			}
+
+			var t *transactions.StorableTransaction
+			if action == BulkRevealActionReveal {
+				t, err = app.service.saveRevealTransactions(tx, dist, pack, pack.Owner, open, logger)
+			} else {
+				t, err = newOpenTransaction(dist, pack, pack.Owner)
+				if err == nil {
+					err = t.Save(tx)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			res = append(res, BulkRevealResult{PackID: pack.ID, TransactionID: t.ID, Action: action})
+		}
+
+		logger.WithFields(log.Fields{"packCount": len(res)}).Info("Packs revealed by the issuer")
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestValidateBulkReveal(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	if err := validateBulkReveal([]uuid.UUID{a, b}); err != nil {
+		t.Errorf("expected pack IDs to be valid, got %v", err)
+	}
+
+	tooMany := make([]uuid.UUID, maxBulkRevealPacks+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	for _, ids := range [][]uuid.UUID{nil, {a, b, a}, tooMany} {
+		if err := validateBulkReveal(ids); ErrorCode(err) != ErrorCodeInvalidRequest {
+			t.Errorf("expected %d pack IDs to be invalid, got %v", len(ids), err)
+		}
+	}
+}
+
+func TestPackBulkRevealAction(t *testing.T) {
+	owner := common.FlowAddress(flow.HexToAddress("0x1"))
+
+	p := Pack{State: common.PackStateSealed, Owner: owner}
+	if name, err := p.bulkRevealAction(true); err != nil || name != BulkRevealActionReveal || p.State != common.PackStateRevealRequestHandled {
+		t.Errorf("expected a sealed pack to be revealed, got '%s' in '%s' state, %v", name, p.State, err)
+	}
+
+	p = Pack{State: common.PackStateRevealed, Owner: owner}
+	if name, err := p.bulkRevealAction(true); err != nil || name != BulkRevealActionOpen || p.State != common.PackStateOpenRequestHandled {
+		t.Errorf("expected a revealed pack to be opened, got '%s' in '%s' state, %v", name, p.State, err)
+	}
+
+	p = Pack{State: common.PackStateRevealed, Owner: owner}
+	if _, err := p.bulkRevealAction(false); ErrorCode(err) != ErrorCodePackState {
+		t.Errorf("expected a revealed pack not to be revealed again, got %v", err)
+	}
+
+	p = Pack{State: common.PackStateSealed}
+	if _, err := p.bulkRevealAction(true); ErrorCode(err) != ErrorCodePackState {
+		t.Errorf("expected a pack without a known owner not to be opened, got %v", err)
+	}
+	if _, err := p.bulkRevealAction(false); err != nil {
+		t.Errorf("expected a pack without a known owner to be revealed, got %v", err)
+	}
+
+	for _, state := range []common.PackState{common.PackStateInit, common.PackStateOpened, common.PackStateRevoked} {
+		p := Pack{State: state, Owner: owner}
+		if _, err := p.bulkRevealAction(true); ErrorCode(err) != ErrorCodePackState {
+			t.Errorf("expected a pack in '%s' state not to be revealed, got %v", state, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// Statuses of a pack onchain, see PackNFT.Status
+const (
+	packStatusSealed uint8 = iota
+	packStatusRevealed
+	packStatusOpened
+	packStatusRevoked
+)
+
+var packStatusNames = map[uint8]string{
+	packStatusSealed:   "sealed",
+	packStatusRevealed: "revealed",
+	packStatusOpened:   "opened",
+	packStatusRevoked:  "revoked",
+}
+
+// checkForceable checks a reveal or open transaction can be forced for 'p',
+// its request has to have been handled ('p' in 'state') but not fulfilled.
+// The transaction is sent on behalf of the believed owner of 'p'.
+func (p *Pack) checkForceable(state common.PackState) error {
+	if p.State != state {
+		return newError(ErrorCodePackState, "pack has to be in '%s' state, state is '%s'", state, p.State)
+	}
+	if p.Owner == (common.FlowAddress{}) {
+		return newError(ErrorCodePackState, "owner of pack is not known")
+	}
+	return nil
+}
+
+// checkPackStatus checks the 'status' of a pack onchain is 'expected'. A pack
+// which already moved on is synced from its events instead of being forced.
+func checkPackStatus(status, expected uint8) error {
+	if status != expected {
+		name, ok := packStatusNames[status]
+		if !ok {
+			name = "unknown"
+		}
+		return newError(ErrorCodePackState, "pack has to be %s onchain, it is %s", packStatusNames[expected], name)
+	}
+	return nil
+}
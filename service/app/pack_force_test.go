@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestPackCheckForceable(t *testing.T) {
+	owner := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+
+	p := Pack{State: common.PackStateRevealRequestHandled, Owner: owner}
+	if err := p.checkForceable(common.PackStateRevealRequestHandled); err != nil {
+		t.Errorf("expected pack to be forceable, got %s", err)
+	}
+
+	cases := []struct {
+		name string
+		pack Pack
+	}{
+		{"wrong state", Pack{State: common.PackStateRevealed, Owner: owner}},
+		{"unknown owner", Pack{State: common.PackStateRevealRequestHandled}},
+	}
+
+	for _, c := range cases {
+		err := c.pack.checkForceable(common.PackStateRevealRequestHandled)
+		if err == nil {
+			t.Errorf("%s: expected an error", c.name)
+			continue
+		}
+		if code := ErrorCode(err); code != ErrorCodePackState {
+			t.Errorf("%s: expected code %s, got %s", c.name, ErrorCodePackState, code)
+		}
+	}
+}
+
+func TestCheckPackStatus(t *testing.T) {
+	if err := checkPackStatus(packStatusSealed, packStatusSealed); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+
+	for _, status := range []uint8{packStatusRevealed, packStatusOpened, 7} {
+		err := checkPackStatus(status, packStatusSealed)
+		if err == nil {
+			t.Errorf("expected an error for status %d", status)
+			continue
+		}
+		if code := ErrorCode(err); code != ErrorCodePackState {
+			t.Errorf("expected code %s, got %s", ErrorCodePackState, code)
+		}
+	}
+}
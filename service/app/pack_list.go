@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// PackStateMinted selects the packs which have been minted, in any state
+// but 'init', 'cancelled' and 'mint-failed', when listing packs.
+const PackStateMinted = "minted"
+
+// PackStateUnopened selects the packs which have been minted and not yet
+// opened, when listing packs.
+const PackStateUnopened = "unopened"
+
+// packMinted returns true if packs in 'state' have been minted.
+func packMinted(state common.PackState) bool {
+	return state != common.PackStateInit && state != common.PackStateCancelled && state != common.PackStateMintFailed
+}
+
+var packStates = []common.PackState{
+	common.PackStateInit,
+	common.PackStateSealed,
+	common.PackStateRevealRequestHandled,
+	common.PackStateRevealed,
+	common.PackStateOpenRequestHandled,
+	common.PackStateOpened,
+	common.PackStateEmpty,
+	common.PackStateCancelled,
+	common.PackStateMintFailed,
+	common.PackStateRevoked,
+}
+
+// ParsePackStates parses the pack states to list, 'minted' standing for all
+// states after minting and 'unopened' for those before opening.
+func ParsePackStates(states []string) ([]common.PackState, error) {
+	res := []common.PackState{}
+
+	for _, s := range states {
+		s = strings.TrimSpace(s)
+
+		if s == PackStateMinted {
+			for _, state := range packStates {
+				if packMinted(state) {
+					res = append(res, state)
+				}
+			}
+			continue
+		}
+
+		if s == PackStateUnopened {
+			res = append(res,
+				common.PackStateSealed,
+				common.PackStateRevealRequestHandled,
+				common.PackStateRevealed,
+				common.PackStateOpenRequestHandled,
+			)
+			continue
+		}
+
+		found := false
+		for _, state := range packStates {
+			if common.PackState(s) == state {
+				res = append(res, state)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown pack state '%s'", s)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestParsePackStates(t *testing.T) {
+	states, err := ParsePackStates([]string{"sealed", " revealed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || states[0] != common.PackStateSealed || states[1] != common.PackStateRevealed {
+		t.Fatalf("unexpected states %v", states)
+	}
+
+	minted, err := ParsePackStates([]string{PackStateMinted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(minted) != len(packStates)-3 {
+		t.Fatalf("expected all states but init, cancelled and mint-failed, got %v", minted)
+	}
+	for _, s := range minted {
+		if s == common.PackStateInit || s == common.PackStateCancelled || s == common.PackStateMintFailed {
+			t.Fatalf("expected minted not to include %s", s)
+		}
+	}
+
+	unopened, err := ParsePackStates([]string{PackStateUnopened})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unopened) != 4 {
+		t.Fatalf("expected the states from sealed to open-request-handled, got %v", unopened)
+	}
+	for _, s := range unopened {
+		if s == common.PackStateInit || s == common.PackStateOpened || s == common.PackStateEmpty || s == common.PackStateCancelled {
+			t.Fatalf("expected unopened not to include %s", s)
+		}
+	}
+
+	if _, err := ParsePackStates([]string{"lost"}); err == nil {
+		t.Fatal("expected an error for an unknown state")
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+// PackProof discloses how the commitment hash of a revealed pack is computed
+// from its salt and contents, so anyone can check the revealed contents
+// match the hash committed on-chain when the pack was minted.
+type PackProof struct {
+	PackID                uuid.UUID
+	FlowID                common.FlowID
+	ContractReference     AddressLocation
+	CommitmentHash        common.BinaryValue // Committed on-chain at mint
+	CommitmentHashVersion uint               // See CommitmentHasher
+	Algorithm             string
+	Salt                  common.BinaryValue
+	Collectibles          Collectibles       // In slot order, as hashed (a fungible token amount follows them in the preimage)
+	Preimage              string             // Input of the hash algorithm
+	ComputedHash          common.BinaryValue // Hash of the preimage
+	Verified              bool               // Set if the computed hash is the commitment hash
+}
+
+// packProofStates are the states in which the contents of a pack are public,
+// as the pack contract emits them on reveal.
+var packProofStates = []common.PackState{
+	common.PackStateRevealed,
+	common.PackStateOpenRequestHandled,
+	common.PackStateOpened,
+	common.PackStateEmpty,
+}
+
+// packContentsPublic returns true if the contents and salt of packs in
+// 'state' are public.
+func packContentsPublic(state common.PackState) bool {
+	for _, s := range packProofStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePackProof checks the proof of a pack in 'state' can be disclosed.
+func validatePackProof(state common.PackState) error {
+	if packContentsPublic(state) {
+		return nil
+	}
+	return newError(ErrorCodePackState, "the proof of a pack is only available once it is revealed, state is '%s'", state)
+}
+
+// newPackProof returns the proof of 'p' with its plain text 'salt',
+// computing its commitment hash with 'h'.
+func newPackProof(p *Pack, salt common.BinaryValue, h CommitmentHasher) PackProof {
+	withSalt := *p
+	withSalt.Salt = salt
+
+	computed := common.BinaryValue(h.Hash(&withSalt))
+
+	return PackProof{
+		PackID:                p.ID,
+		FlowID:                p.FlowID,
+		ContractReference:     p.ContractReference,
+		CommitmentHash:        p.CommitmentHash,
+		CommitmentHashVersion: h.Version(),
+		Algorithm:             h.Algorithm(),
+		Salt:                  salt,
+		Collectibles:          p.Collectibles,
+		Preimage:              string(h.Preimage(&withSalt)),
+		ComputedHash:          computed,
+		Verified:              bytes.Equal(computed, p.CommitmentHash),
+	}
+}
+
+// GetPackProof returns the proof of inclusion of the contents of a revealed
+// pack in its commitment hash.
+func (app *App) GetPackProof(ctx context.Context, id uuid.UUID) (*PackProof, error) {
+	pack, err := app.GetPack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePackProof(pack.State); err != nil {
+		return nil, err
+	}
+
+	dist, err := GetDistributionSmall(app.db, pack.DistributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := GetCommitmentHasher(dist.CommitmentHashVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := app.service.salts.PackSalt(pack)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := newPackProof(pack, salt, hasher)
+	return &proof, nil
+}
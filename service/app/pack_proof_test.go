@@ -0,0 +1,53 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestNewPackProof(t *testing.T) {
+	h := commitmentHasherV1{}
+	salt := common.BinaryValue{0x01, 0x02}
+
+	p := Pack{
+		State: common.PackStateRevealed,
+		Collectibles: Collectibles{
+			{ContractReference: AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}, FlowID: common.FlowID{Int64: 1, Valid: true}},
+			{ContractReference: AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))}, FlowID: common.FlowID{Int64: 2, Valid: true}},
+		},
+		Salt: salt,
+	}
+	p.CommitmentHash = p.Hash(h)
+
+	// Stored salts may be encrypted, the proof takes the plain text one
+	p.Salt = common.BinaryValue("encrypted")
+
+	proof := newPackProof(&p, salt, h)
+	if !proof.Verified {
+		t.Error("expected the proof to verify")
+	}
+	if proof.Preimage != "0102,A.0000000000000002.Moment.1,A.0000000000000002.Moment.2" {
+		t.Errorf("unexpected preimage '%s'", proof.Preimage)
+	}
+	if proof.Algorithm != "SHA2-256" || proof.CommitmentHashVersion != 1 {
+		t.Errorf("unexpected scheme %s (version %d)", proof.Algorithm, proof.CommitmentHashVersion)
+	}
+	if string(p.Salt) != "encrypted" {
+		t.Error("expected the pack to be left unchanged")
+	}
+
+	if newPackProof(&p, common.BinaryValue{0x01, 0x03}, h).Verified {
+		t.Error("expected a proof with another salt not to verify")
+	}
+}
+
+func TestValidatePackProof(t *testing.T) {
+	if err := validatePackProof(common.PackStateOpened); err != nil {
+		t.Errorf("expected the proof of an opened pack to be available, got %s", err)
+	}
+	if err := validatePackProof(common.PackStateSealed); ErrorCode(err) != ErrorCodePackState {
+		t.Errorf("expected a '%s' error for a sealed pack, got %v", ErrorCodePackState, err)
+	}
+}
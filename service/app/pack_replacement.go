@@ -0,0 +1,415 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk/client"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PackReplacement links a pack damaged by an operational error (e.g. a reveal
+// failing because of a corrupted salt, or a revoked pack) to the pack minted
+// to replace it. The replacement holds equivalent contents, the same number
+// of collectibles of each bucket and tier, drawn from the reserve of the
+// distribution: collectibles of its buckets held in escrow which no pack
+// uses. It is minted to the issuer like the other packs.
+type PackReplacement struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	DistributionID    uuid.UUID  `gorm:"column:distribution_id;index"`
+	OriginalPackID    uuid.UUID  `gorm:"column:original_pack_id;uniqueIndex"`
+	ReplacementPackID uuid.UUID  `gorm:"column:replacement_pack_id;uniqueIndex"`
+	Reason            string     `gorm:"column:reason"`
+	MintTransactionID uuid.UUID  `gorm:"column:mint_transaction_id"`
+	StartAtBlock      uint64     `gorm:"column:start_at_block"` // Mint events are handled up to this block
+	Complete          bool       `gorm:"column:complete;index"` // Set once the replacement is minted
+	CompletedAt       *time.Time `gorm:"column:completed_at"`
+}
+
+func (PackReplacement) TableName() string {
+	return "pack_replacements"
+}
+
+func (r *PackReplacement) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return nil
+}
+
+// validatePackReplacement checks 'original' of 'dist' can be replaced. Revoked packs can be, as can packs whose reveal request was
+// handled but never fulfilled ('revealPending' reveal transactions are in
+// flight), those are revoked when replaced.
+func validatePackReplacement(dist *Distribution, original *Pack, revealPending int64) error {
+	if dist.State != common.DistributionStateComplete {
+		return newError(ErrorCodeDistributionState, "packs can only be replaced once a distribution is complete, state is '%s'", dist.State)
+	}
+
+	if dist.ArchivedAt != nil {
+		return newError(ErrorCodeDistributionState, "packs of an archived distribution can not be replaced")
+	}
+
+	if original.ReplacesPackID != nil {
+		return newError(ErrorCodePackState, "pack is a replacement itself")
+	}
+
+	switch original.State {
+	case common.PackStateRevoked:
+		return nil
+	case common.PackStateRevealRequestHandled:
+		if revealPending > 0 {
+			return newError(ErrorCodeTransactionsInFlight, "pack has %d reveal transactions in flight, replace it once they have finished", revealPending)
+		}
+		return nil
+	}
+
+	return newError(ErrorCodePackState, "only '%s' packs or packs whose reveal failed can be replaced, state is '%s'", common.PackStateRevoked, original.State)
+}
+
+// bucketSlot identifies the bucket and tier a collectible was picked from.
+type bucketSlot struct {
+	bucket int
+	tier   string
+}
+
+// drawReplacement returns a collectible for each slot of 'original', of the
+// same bucket and tier, taken from 'reserve' in order of FlowID. The drawn
+// collectibles are removed from 'reserve'.
+func drawReplacement(original Collectibles, buckets []Bucket, reserve map[Collectible]bool) (Collectibles, error) {
+	slots := make(map[Collectible]bucketSlot)
+	for i, b := range buckets {
+		for _, id := range b.CollectibleCollection {
+			c := Collectible{FlowID: id, ContractReference: b.CollectibleReference}
+			slots[c] = bucketSlot{bucket: i, tier: b.CollectibleTiers[id.Int64]}
+		}
+	}
+
+	available := make(map[bucketSlot]Collectibles)
+	for _, c := range sortedCollectibles(reserve) {
+		if s, ok := slots[c]; ok {
+			available[s] = append(available[s], c)
+		}
+	}
+
+	res := make(Collectibles, len(original))
+	for i, c := range original {
+		s, ok := slots[c]
+		if !ok {
+			return nil, fmt.Errorf("collectible in slot #%d is not in a bucket of the distribution", i+1)
+		}
+
+		if len(available[s]) == 0 {
+			return nil, newError(ErrorCodeInsufficientEscrow, "reserve has no collectible left for slot #%d (bucket #%d, tier '%s')", i+1, s.bucket+1, s.tier)
+		}
+
+		res[i] = available[s][0]
+		available[s] = available[s][1:]
+		delete(reserve, res[i])
+	}
+
+	return res, nil
+}
+
+// ReplacePack draws the contents of a replacement for 'original' of 'dist'
+// from its reserve and queues the transaction minting it. A pack whose reveal
+// failed is revoked first.
+func (svc *ContractService) ReplacePack(ctx context.Context, db *gorm.DB, dist *Distribution, original *Pack, reason string) (*PackReplacement, error) {
+	revealPending, err := transactions.CountPendingForPack(db, original.ID, REVEAL_SCRIPT)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePackReplacement(dist, original, revealPending); err != nil {
+		return nil, err
+	}
+
+	if err := validateRevokeReason(reason); err != nil {
+		return nil, err
+	}
+
+	if _, err := GetPackReplacementByOriginal(db, original.ID); err == nil {
+		return nil, newError(ErrorCodePackState, "pack has already been replaced")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := svc.clock.Now()
+
+	if original.State != common.PackStateRevoked {
+		original.setRevoked(now, reason)
+		if _, err := svc.saveRevocation(ctx, db, dist, original, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// The reserve, collectibles of the buckets no pack of the distribution
+	// uses, nor a pack of another distribution still needs
+	reserve := bucketCollectibles(dist.PackTemplate.Buckets)
+	err = DistributionPacksInBatches(db, dist.ID, svc.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+		for _, p := range batch {
+			for _, c := range p.Collectibles {
+				delete(reserve, c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := svc.unclaimedEscrow(ctx, db, dist.Issuer, reserve); err != nil {
+		return nil, err
+	}
+
+	collectibles, err := drawReplacement(original.Collectibles, dist.PackTemplate.Buckets, reserve)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := GetCommitmentHasher(dist.CommitmentHashVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := Pack{
+		DistributionID:    dist.ID,
+		ContractReference: original.ContractReference,
+		State:             common.PackStateInit,
+		Collectibles:      collectibles,
+		FungibleToken:     original.FungibleToken, // The amount of the revoked original stays in escrow
+		ReplacesPackID:    &original.ID,
+		MintQueued:        true,
+	}
+
+	if err := replacement.SetCommitmentHash(hasher); err != nil {
+		return nil, err
+	}
+
+	if err := svc.salts.EncryptPack(&replacement); err != nil {
+		return nil, err
+	}
+
+	if err := db.Omit(clause.Associations).Create(&replacement).Error; err != nil {
+		return nil, err
+	}
+
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return nil, err
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newMintTransaction(dist, []Pack{replacement})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Save(db); err != nil {
+		return nil, err
+	}
+
+	r := PackReplacement{
+		DistributionID:    dist.ID,
+		OriginalPackID:    original.ID,
+		ReplacementPackID: replacement.ID,
+		Reason:            reason,
+		MintTransactionID: t.ID,
+		StartAtBlock:      latestBlockHeader.Height,
+	}
+
+	if err := InsertPackReplacement(db, &r); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"distID":            dist.ID,
+		"distFlowID":        dist.FlowID,
+		"originalPackID":    original.ID,
+		"replacementPackID": replacement.ID,
+		"reason":            reason,
+	}).Info("Pack replacement queued")
+
+	return &r, nil
+}
+
+// UpdatePackReplacement seals the replacement pack of 'r' once its Mint
+// event is seen, completing 'r'. A failed mint transaction is queued again
+// until the pack is set to mint-failed, see handleFailedMint.
+func (svc *ContractService) UpdatePackReplacement(ctx context.Context, db *gorm.DB, r *PackReplacement) error {
+	dist, err := GetDistributionSmall(db, r.DistributionID)
+	if err != nil {
+		return err
+	}
+
+	replacement, err := GetPack(db, r.ReplacementPackID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case replacement.State == common.PackStateMintFailed:
+		now := svc.clock.Now()
+		r.Complete = true
+		r.CompletedAt = &now
+
+		log.WithFields(log.Fields{
+			"distID":            dist.ID,
+			"originalPackID":    r.OriginalPackID,
+			"replacementPackID": replacement.ID,
+			"error":             replacement.MintError,
+		}).Error("Replacement pack failed to mint")
+
+		return UpdatePackReplacement(db, r)
+
+	case replacement.State == common.PackStateInit && !replacement.MintQueued:
+		t, err := newMintTransaction(dist, []Pack{*replacement})
+		if err != nil {
+			return err
+		}
+
+		if err := t.Save(db); err != nil {
+			return err
+		}
+
+		replacement.MintQueued = true
+		if err := UpdatePack(db, replacement); err != nil {
+			return err
+		}
+
+		r.MintTransactionID = t.ID
+	}
+
+	flowClient, err := svc.clientFor(dist)
+	if err != nil {
+		return err
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	begin := r.StartAtBlock + 1
+	end := min(latestBlockHeader.Height, begin+svc.cfg.MaxBlocksPerCheck)
+
+	if begin > end {
+		return UpdatePackReplacement(db, r)
+	}
+
+	arr, err := flowClient.GetEventsForHeightRange(ctx, client.EventRangeQuery{
+		Type:        fmt.Sprintf("%s.Mint", dist.PackTemplate.PackReference.String()),
+		StartHeight: begin,
+		EndHeight:   end,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, be := range arr {
+		for _, e := range be.Events {
+			evtValueMap := flow_helpers.EventValuesToMap(e)
+
+			commitmentHash, err := common.BinaryValueFromCadence(evtValueMap["commitHash"])
+			if err != nil {
+				return err
+			}
+
+			if commitmentHash.String() != replacement.CommitmentHash.String() {
+				continue
+			}
+
+			packFlowID, err := common.FlowIDFromCadence(evtValueMap["id"])
+			if err != nil {
+				return err
+			}
+
+			if err := replacement.Seal(packFlowID); err != nil {
+				return err
+			}
+
+			// Packs are minted to the issuer
+			replacement.Owner = dist.Issuer
+
+			if err := UpdatePack(db, replacement); err != nil {
+				return err
+			}
+
+			now := svc.clock.Now()
+			r.Complete = true
+			r.CompletedAt = &now
+
+			log.WithFields(log.Fields{
+				"distID":            dist.ID,
+				"originalPackID":    r.OriginalPackID,
+				"replacementPackID": replacement.ID,
+				"packFlowID":        packFlowID,
+			}).Info("Replacement pack minted")
+		}
+	}
+
+	r.StartAtBlock = end
+
+	return UpdatePackReplacement(db, r)
+}
+
+// handlePackReplacements seals the replacement packs minted since the last
+// poll.
+func handlePackReplacements(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		list, err := ListIncompletePackReplacements(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range list {
+			if err := app.service.UpdatePackReplacement(ctx, tx, &list[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ReplacePack mints a replacement for a damaged pack of a complete
+// distribution, see PackReplacement.
+func (app *App) ReplacePack(ctx context.Context, id uuid.UUID, reason string) (*PackReplacement, error) {
+	var res *PackReplacement
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		original, err := GetPack(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		dist, err := GetDistributionWithBuckets(tx, original.DistributionID)
+		if err != nil {
+			return err
+		}
+
+		res, err = app.service.ReplacePack(ctx, tx, dist, original, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetPackReplacement returns the replacement of a pack.
+func (app *App) GetPackReplacement(ctx context.Context, id uuid.UUID) (*PackReplacement, error) {
+	return GetPackReplacementByOriginal(app.db, id)
+}
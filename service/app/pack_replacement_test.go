@@ -0,0 +1,84 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestDrawReplacement(t *testing.T) {
+	moments := AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x1"))}
+
+	collectible := func(id int64) Collectible {
+		return Collectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: moments}
+	}
+
+	buckets := []Bucket{
+		{
+			CollectibleReference:  moments,
+			CollectibleCollection: common.FlowIDList{{Int64: 1, Valid: true}, {Int64: 2, Valid: true}, {Int64: 3, Valid: true}, {Int64: 4, Valid: true}},
+			CollectibleTiers:      map[int64]string{1: "common", 2: "rare", 3: "common", 4: "rare"},
+		},
+		{
+			CollectibleReference:  moments,
+			CollectibleCollection: common.FlowIDList{{Int64: 10, Valid: true}, {Int64: 11, Valid: true}},
+		},
+	}
+
+	reserve := map[Collectible]bool{
+		collectible(4):  true,
+		collectible(3):  true,
+		collectible(11): true,
+		collectible(20): true, // Not in a bucket
+	}
+
+	// Each slot gets a collectible of the same bucket and tier
+	got, err := drawReplacement(Collectibles{collectible(2), collectible(1), collectible(10)}, buckets, reserve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Collectibles{collectible(4), collectible(3), collectible(11)}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the replacement %v, got %v", expected, got)
+	}
+	if len(reserve) != 1 {
+		t.Errorf("expected the drawn collectibles to be removed from the reserve, left %v", reserve)
+	}
+
+	reserve = map[Collectible]bool{collectible(3): true}
+	if _, err := drawReplacement(Collectibles{collectible(2)}, buckets, reserve); ErrorCode(err) != ErrorCodeInsufficientEscrow {
+		t.Errorf("expected a '%s' error without a rare collectible left, got %v", ErrorCodeInsufficientEscrow, err)
+	}
+}
+
+func TestValidatePackReplacement(t *testing.T) {
+	dist := &Distribution{State: common.DistributionStateComplete}
+
+	if err := validatePackReplacement(dist, &Pack{State: common.PackStateRevoked}, 0); err != nil {
+		t.Errorf("expected a revoked pack to be replaceable, got %v", err)
+	}
+
+	if err := validatePackReplacement(dist, &Pack{State: common.PackStateRevealRequestHandled}, 0); err != nil {
+		t.Errorf("expected a pack whose reveal failed to be replaceable, got %v", err)
+	}
+
+	if err := validatePackReplacement(dist, &Pack{State: common.PackStateRevealRequestHandled}, 1); ErrorCode(err) != ErrorCodeTransactionsInFlight {
+		t.Errorf("expected a '%s' error while revealing, got %v", ErrorCodeTransactionsInFlight, err)
+	}
+
+	if err := validatePackReplacement(dist, &Pack{State: common.PackStateSealed}, 0); ErrorCode(err) != ErrorCodePackState {
+		t.Errorf("expected a '%s' error for a sealed pack, got %v", ErrorCodePackState, err)
+	}
+
+	original := uuid.New()
+	if err := validatePackReplacement(dist, &Pack{State: common.PackStateRevoked, ReplacesPackID: &original}, 0); ErrorCode(err) != ErrorCodePackState {
+		t.Errorf("expected a '%s' error for a replacement pack, got %v", ErrorCodePackState, err)
+	}
+
+	closed := &Distribution{State: common.DistributionStateClosed}
+	if err := validatePackReplacement(closed, &Pack{State: common.PackStateRevoked}, 0); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a closed distribution, got %v", ErrorCodeDistributionState, err)
+	}
+}
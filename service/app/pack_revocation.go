@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxRevokeReasonLength = 255
+
+// PackRevocation is the result of revoking a pack.
+type PackRevocation struct {
+	PackID        uuid.UUID
+	FlowID        common.FlowID
+	RevokedAt     time.Time
+	Reason        string
+	TransactionID *uuid.UUID // Transaction freezing the PackNFT onchain, if any
+}
+
+// Revoke sets a minted, unopened pack as revoked. Packs whose reveal or open
+// request is being handled can not be revoked, their transactions may
+// already be sent.
+func (p *Pack) Revoke(now time.Time, reason string) error {
+	if p.State != common.PackStateSealed && p.State != common.PackStateRevealed {
+		return newError(ErrorCodePackState, "only '%s' or '%s' packs can be revoked, state is '%s'", common.PackStateSealed, common.PackStateRevealed, p.State)
+	}
+
+	if err := validateRevokeReason(reason); err != nil {
+		return err
+	}
+
+	p.setRevoked(now, reason)
+
+	return nil
+}
+
+func (p *Pack) setRevoked(now time.Time, reason string) {
+	p.State = common.PackStateRevoked
+	p.RevokedAt = &now
+	p.RevokeReason = reason
+}
+
+func validateRevokeReason(reason string) error {
+	if utf8.RuneCountInString(reason) > maxRevokeReasonLength {
+		return newError(ErrorCodeInvalidRequest, "reason can be at most %d characters", maxRevokeReasonLength)
+	}
+	return nil
+}
+
+// newRevokeTransaction returns a transaction freezing 'pack' of 'dist'
+// onchain, it can not be revealed or opened after this.
+func newRevokeTransaction(dist *Distribution, pack *Pack) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(REVOKE_PACK_SCRIPT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.UInt64(pack.FlowID.Int64),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(REVOKE_PACK_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.PackID = pack.ID
+
+	return t, nil
+}
+
+// RevokePack revokes 'pack' of 'dist'. Its reveal and open requests are
+// ignored from then on. With PackRevocationOnChain the PackNFT is also frozen
+// onchain, unless 'dist' is closed (its shared capabilities are gone, its
+// requests are ignored anyway).
+func (svc *ContractService) RevokePack(ctx context.Context, db *gorm.DB, dist *Distribution, pack *Pack, reason string) (*PackRevocation, error) {
+	now := svc.clock.Now()
+
+	if err := pack.Revoke(now, reason); err != nil {
+		return nil, err
+	}
+
+	return svc.saveRevocation(ctx, db, dist, pack, now)
+}
+
+// saveRevocation stores 'pack' of 'dist' revoked at 'now', queueing the
+// transaction freezing it onchain if enabled and the PackNFT contract of
+// 'dist' supports it.
+func (svc *ContractService) saveRevocation(ctx context.Context, db *gorm.DB, dist *Distribution, pack *Pack, now time.Time) (*PackRevocation, error) {
+	if err := UpdatePack(db, pack); err != nil {
+		return nil, err
+	}
+
+	res := &PackRevocation{
+		PackID:    pack.ID,
+		FlowID:    pack.FlowID,
+		RevokedAt: now,
+		Reason:    pack.RevokeReason,
+	}
+
+	if svc.cfg.PackRevocationOnChain && dist.State != common.DistributionStateClosed {
+		flowClient, err := svc.clientFor(dist)
+		if err != nil {
+			return nil, err
+		}
+
+		canRevoke, err := svc.distributionSupports(ctx, flowClient, dist, CAN_REVOKE_SCRIPT)
+		if err != nil {
+			return nil, err
+		}
+
+		if canRevoke {
+			t, err := newRevokeTransaction(dist, pack)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := t.Save(db); err != nil {
+				return nil, err
+			}
+
+			res.TransactionID = &t.ID
+		} else {
+			log.WithFields(log.Fields{
+				"distID":     dist.ID,
+				"distFlowID": dist.FlowID,
+				"packID":     pack.ID,
+			}).Warn("Distribution has no revoke operator, not revoking pack onchain")
+		}
+	}
+
+	if err := queuePackWebhooks(db, dist, pack, WebhookEventPackRevoked, now); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"distID":        dist.ID,
+		"distFlowID":    dist.FlowID,
+		"packID":        pack.ID,
+		"packFlowID":    pack.FlowID,
+		"reason":        pack.RevokeReason,
+		"transactionID": res.TransactionID,
+	}).Warn("Pack revoked")
+
+	return res, nil
+}
+
+// RevokePack revokes a minted, unopened pack, e.g. one sold fraudulently.
+func (app *App) RevokePack(ctx context.Context, id uuid.UUID, reason string) (*PackRevocation, error) {
+	var res *PackRevocation
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		pack, err := GetPack(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		dist, err := GetDistributionSmall(tx, pack.DistributionID)
+		if err != nil {
+			return err
+		}
+
+		res, err = app.service.RevokePack(ctx, tx, dist, pack, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestPackRevoke(t *testing.T) {
+	now := time.Now()
+
+	for _, state := range []common.PackState{common.PackStateInit, common.PackStateRevealRequestHandled, common.PackStateOpened, common.PackStateRevoked} {
+		p := Pack{State: state}
+		if err := p.Revoke(now, ""); ErrorCode(err) != ErrorCodePackState {
+			t.Errorf("expected a '%s' error revoking a %s pack, got %v", ErrorCodePackState, state, err)
+		}
+	}
+
+	p := Pack{State: common.PackStateSealed}
+	if err := p.Revoke(now, strings.Repeat("a", maxRevokeReasonLength+1)); ErrorCode(err) != ErrorCodeInvalidRequest {
+		t.Errorf("expected a '%s' error for a too long reason, got %v", ErrorCodeInvalidRequest, err)
+	}
+	if p.State != common.PackStateSealed {
+		t.Errorf("expected the pack to stay sealed, got %s", p.State)
+	}
+
+	for _, state := range []common.PackState{common.PackStateSealed, common.PackStateRevealed} {
+		p := Pack{State: state}
+		if err := p.Revoke(now, "sold fraudulently"); err != nil {
+			t.Fatal(err)
+		}
+		if p.State != common.PackStateRevoked || p.RevokedAt == nil || !p.RevokedAt.Equal(now) || p.RevokeReason != "sold fraudulently" {
+			t.Errorf("expected the %s pack to be revoked at %s, got %s at %v (%s)", state, now, p.State, p.RevokedAt, p.RevokeReason)
+		}
+	}
+}
+
+func TestCompletionReportRevokedPacks(t *testing.T) {
+	r := CompletionReport{}
+	r.SetPackCounts(map[common.PackState]uint{
+		common.PackStateOpened:  3,
+		common.PackStateRevoked: 1,
+	}, 2)
+
+	if r.RevokedCount != 1 || r.UnopenedCollectibleCount != 2 {
+		t.Errorf("expected 1 revoked pack with 2 collectibles left in escrow, got %d and %d", r.RevokedCount, r.UnopenedCollectibleCount)
+	}
+	if !r.AllOpened() {
+		t.Error("expected revoked packs not to keep the distribution open")
+	}
+}
@@ -8,49 +8,95 @@ import (
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
 	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
-	"go.uber.org/ratelimit"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TODO: refactor the db transaction logic
 
-// poller is responsible for the main operation of the service
-func poller(app *App) {
+// pollerRun is a step of the poller, each step commits or rolls back its
+// own database transactions
+type pollerRun struct {
+	name string
+	run  func(ctx context.Context, app *App) error
+}
+
+var pollerRuns = []pollerRun{
+	{"handleSLOs", handleSLOs},
+
+	{"handleScheduled", handleScheduled},
+	{"handleResolved", handleResolved},
+	{"handleSetup", handleSetup},
+	{"handleSettling", handleSettling},
+	{"handleSettled", handleSettled},
+	{"handleMinting", handleMinting},
+	{"handleComplete", handleComplete},
+	{"handleTeardown", handleTeardown},
+	{"handleCancellations", handleCancellations},
+	{"handleStateTimeouts", handleStateTimeouts},
+	{"handlePackReplacements", handlePackReplacements},
+
+	{"pollCirculatingPackContractEvents", pollCirculatingPackContractEvents},
+	{"handleOwnershipVerifications", handleOwnershipVerifications},
+	{"handleGiftIntents", handleGiftIntents},
+	{"handleTeasers", handleTeasers},
+	{"handleRevealWebhooks", handleRevealWebhooks},
+	{"handleWebhookDeliveries", handleWebhookDeliveries},
+
+	{"handleOutdatedJobs", handleOutdatedJobs},
+	{"handleSentTransactions", handleSentTransactions},
+	{"handleSendableTransactions", handleSendableTransactions},
+}
 
-	ticker := time.NewTicker(time.Second) // TODO (latenssi): configurable?
-	transactionRatelimiter := ratelimit.New(app.cfg.TransactionSendRate)
+// poller is responsible for the main operation of the service. It returns
+// once the app is shutting down, after finishing the running step. 'ctx' is
+// cancelled if the step takes longer than the shutdown grace period.
+func poller(ctx context.Context, app *App) {
+	defer close(app.done)
 
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
+	ticker := app.clock.NewTicker(time.Second) // TODO (latenssi): configurable?
+	defer ticker.Stop()
+
+	logPollerRun("resumeSentTransactions", resumeSentTransactions(ctx, app))
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.Chan():
 			log.Trace("Poll start")
 
-			logPollerRun("handleResolved", handleResolved(ctx, app))
-			logPollerRun("handleSetup", handleSetup(ctx, app))
-			logPollerRun("handleSettling", handleSettling(ctx, app))
-			logPollerRun("handleSettled", handleSettled(ctx, app))
-			logPollerRun("handleMinting", handleMinting(ctx, app))
-			logPollerRun("handleComplete", handleComplete(ctx, app))
-
-			logPollerRun("pollCirculatingPackContractEvents", pollCirculatingPackContractEvents(ctx, app))
-
-			logPollerRun("handleSentTransactions", handleSentTransactions(ctx, app))
-			logPollerRun("handleSendableTransactions", handleSendableTransactions(ctx, app, transactionRatelimiter))
+			for _, p := range pollerRuns {
+				if app.draining() {
+					break
+				}
+				logPollerRun(p.name, p.run(ctx, app))
+			}
 
 			log.Trace("Poll end")
 		case <-app.quit:
-			cancel()
-			ticker.Stop()
+			log.Info("Poller stopped")
 			return
 		}
 	}
 }
 
+// drain waits for 'done' to be closed. Once 'ctx' is done 'cancel' is called
+// first and drain returns false.
+func drain(ctx context.Context, done <-chan struct{}, cancel func()) bool {
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return false
+	}
+}
+
 func min(x, y uint64) uint64 {
 	if x > y {
 		return y
@@ -71,6 +117,24 @@ func logPollerRun(pollerName string, err error) {
 	}
 }
 
+// distributionMetrics returns the metrics labels of a distribution
+func distributionMetrics(dist *Distribution) metrics.Distribution {
+	return metrics.Distribution{ID: dist.ID, PackCount: dist.PackTemplate.PackCount}
+}
+
+// distributionMetricsByID returns the metrics labels of a distribution or
+// empty labels if the distribution is not found (e.g. uuid.Nil)
+func distributionMetricsByID(db *gorm.DB, distributionID uuid.UUID) metrics.Distribution {
+	if distributionID == uuid.Nil {
+		return metrics.Distribution{}
+	}
+	dist, err := GetDistributionSmall(db, distributionID)
+	if err != nil {
+		return metrics.Distribution{}
+	}
+	return distributionMetrics(dist)
+}
+
 func listDistributionsByState(db *gorm.DB, state common.DistributionState) ([]Distribution, error) {
 	list := []Distribution{}
 	return list, db.
@@ -79,6 +143,17 @@ func listDistributionsByState(db *gorm.DB, state common.DistributionState) ([]Di
 		Find(&list).Error
 }
 
+// listDueScheduledDistributions lists the scheduled distributions whose
+// settlement start time is not after 'now', earliest first.
+func listDueScheduledDistributions(db *gorm.DB, now time.Time) ([]Distribution, error) {
+	list := []Distribution{}
+	return list, db.
+		Where(&Distribution{State: common.DistributionStateScheduled}).
+		Where("settlement_start_at <= ?", now).
+		Order("settlement_start_at asc").
+		Find(&list).Error
+}
+
 func listCirculatingPackContracts(db *gorm.DB) ([]CirculatingPackContract, error) {
 	list := []CirculatingPackContract{}
 	return list, db.
@@ -87,32 +162,110 @@ func listCirculatingPackContracts(db *gorm.DB) ([]CirculatingPackContract, error
 		Find(&list).Error
 }
 
-func handleResolved(ctx context.Context, app *App) error {
+func listUnfinishedOwnershipVerifications(db *gorm.DB) ([]OwnershipVerification, error) {
+	list := []OwnershipVerification{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("state <> ?", common.OwnershipVerificationStateComplete).
+		Order("updated_at asc").
+		Limit(10). // Pick 10 (arbitrary) most least recently updated
+		Find(&list).Error
+}
+
+// inProgressDistributionStates are the states counted against 'MaxConcurrentDistributions'
+var inProgressDistributionStates = []common.DistributionState{
+	common.DistributionStateSetup,
+	common.DistributionStateSettling,
+	common.DistributionStateSettled,
+	common.DistributionStateMinting,
+}
+
+func countDistributionsByStates(db *gorm.DB, states []common.DistributionState) (int64, error) {
+	var count int64
+	return count, db.
+		Model(&Distribution{}).
+		Where("state IN ?", states).
+		Count(&count).Error
+}
+
+// handleScheduled sets scheduled distributions back to resolved once their
+// settlement start time has passed, handleResolved then sets them up.
+func handleScheduled(ctx context.Context, app *App) error {
 	return app.db.Transaction(func(tx *gorm.DB) error {
-		resolved, err := listDistributionsByState(tx, common.DistributionStateResolved)
+		now := app.clock.Now()
+
+		// Locked, so distributions being updated are not started meanwhile
+		scheduled, err := listDueScheduledDistributions(tx.Clauses(clause.Locking{Strength: "UPDATE"}), now)
 		if err != nil {
 			return err
 		}
 
-		for _, dist := range resolved {
-			if err := app.service.SetupDistribution(ctx, tx, &dist); err != nil {
+		for _, dist := range scheduled {
+			if err := dist.SetStarted(); err != nil {
+				return err
+			}
+
+			if err := UpdateDistribution(tx, &dist); err != nil {
 				return err
 			}
+
+			if err := queueDistributionWebhooks(tx, &dist, now); err != nil {
+				return err
+			}
+
+			log.WithFields(log.Fields{
+				"distID":            dist.ID,
+				"distFlowID":        dist.FlowID,
+				"settlementStartAt": dist.SettlementStartAt,
+			}).Info("Scheduled distribution started")
 		}
 
 		return nil
 	})
 }
 
-func handleSetup(ctx context.Context, app *App) error {
+func handleResolved(ctx context.Context, app *App) error {
+	if app.service.slo.Degraded() {
+		log.Trace("Latency SLO breached, not starting distributions")
+		return nil
+	}
+
 	return app.db.Transaction(func(tx *gorm.DB) error {
-		setup, err := listDistributionsByState(tx, common.DistributionStateSetup)
+		// Locked, so distributions being updated are not set up meanwhile
+		resolved, err := listDistributionsByState(tx.Clauses(clause.Locking{Strength: "UPDATE"}), common.DistributionStateResolved)
 		if err != nil {
 			return err
 		}
 
-		for _, dist := range setup {
-			if err := app.service.StartSettlement(ctx, tx, &dist); err != nil {
+		resolved = unpausedDistributions(resolved)
+
+		// Limit the number of distributions in progress, the rest will be
+		// picked up (oldest first) once others complete
+		if limit := app.cfg.MaxConcurrentDistributions; limit > 0 && len(resolved) > 0 {
+			inProgress, err := countDistributionsByStates(tx, inProgressDistributionStates)
+			if err != nil {
+				return err
+			}
+
+			available := limit - int(inProgress)
+			if available <= 0 {
+				log.WithFields(log.Fields{
+					"inProgress": inProgress,
+					"waiting":    len(resolved),
+				}).Trace("Max concurrent distributions reached")
+				return nil
+			}
+
+			if available < len(resolved) {
+				resolved = resolved[:available]
+			}
+		}
+
+		for _, dist := range resolved {
+			start := time.Now()
+			err := app.service.SetupDistribution(ctx, tx, &dist)
+			metrics.ObserveOperation(metrics.OperationSetup, distributionMetrics(&dist), start, err)
+			if err != nil {
 				return err
 			}
 		}
@@ -121,22 +274,70 @@ func handleSetup(ctx context.Context, app *App) error {
 	})
 }
 
-func handleSettling(ctx context.Context, app *App) error {
+func handleSetup(ctx context.Context, app *App) error {
 	return app.db.Transaction(func(tx *gorm.DB) error {
-		settling, err := listDistributionsByState(tx, common.DistributionStateSettling)
+		setup, err := listDistributionsByState(tx, common.DistributionStateSetup)
 		if err != nil {
 			return err
 		}
 
-		for _, dist := range settling {
-			if err := app.service.UpdateSettlementStatus(ctx, tx, &dist); err != nil {
+		for _, dist := range unpausedDistributions(setup) {
+			start := time.Now()
+			err := app.service.StartSettlement(ctx, tx, &dist)
+			metrics.ObserveOperation(metrics.OperationSettle, distributionMetrics(&dist), start, err)
+			if err != nil {
 				return err
 			}
 		}
+
 		return nil
 	})
 }
 
+// handleSettling updates the settlement of settling distributions. Each
+// distribution commits its progress (settled collectibles, queued batches and
+// the block its events are handled up to) in its own database transaction, so
+// an error in one does not discard the progress of the others and a restart
+// resumes from the last commit. Each distribution is re-read and locked in
+// its transaction, one which left settling since it was listed (e.g. was
+// cancelled) is skipped.
+func handleSettling(ctx context.Context, app *App) error {
+	settling, err := listDistributionsByState(app.db, common.DistributionStateSettling)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, listed := range settling {
+		err := app.db.Transaction(func(tx *gorm.DB) error {
+			dist, err := GetDistributionSmall(tx.Clauses(clause.Locking{Strength: "UPDATE"}), listed.ID)
+			if err != nil {
+				return err
+			}
+
+			if dist.State != common.DistributionStateSettling {
+				return nil
+			}
+
+			start := time.Now()
+			err = app.service.UpdateSettlementStatus(ctx, tx, dist)
+			metrics.ObserveOperation(metrics.OperationSettle, distributionMetrics(dist), start, err)
+			return err
+		})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"distID": listed.ID,
+				"error":  err,
+			}).Warn("Error while updating settlement")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
 func handleSettled(ctx context.Context, app *App) error {
 	return app.db.Transaction(func(tx *gorm.DB) error {
 		settled, err := listDistributionsByState(tx, common.DistributionStateSettled)
@@ -144,8 +345,11 @@ func handleSettled(ctx context.Context, app *App) error {
 			return err
 		}
 
-		for _, dist := range settled {
-			if err := app.service.StartMinting(ctx, tx, &dist); err != nil {
+		for _, dist := range unpausedDistributions(settled) {
+			start := time.Now()
+			err := app.service.StartMinting(ctx, tx, &dist)
+			metrics.ObserveOperation(metrics.OperationMint, distributionMetrics(&dist), start, err)
+			if err != nil {
 				return err
 			}
 		}
@@ -162,7 +366,10 @@ func handleMinting(ctx context.Context, app *App) error {
 		}
 
 		for _, dist := range minting {
-			if err := app.service.UpdateMintingStatus(ctx, tx, &dist); err != nil {
+			start := time.Now()
+			err := app.service.UpdateMintingStatus(ctx, tx, &dist)
+			metrics.ObserveOperation(metrics.OperationMint, distributionMetrics(&dist), start, err)
+			if err != nil {
 				return err
 			}
 		}
@@ -198,6 +405,58 @@ func handleComplete(ctx context.Context, app *App) error {
 	})
 }
 
+// handleTeardown closes complete distributions whose packs have all been
+// opened or whose reveal window has passed, if teardown is enabled.
+func handleTeardown(ctx context.Context, app *App) error {
+	if !app.cfg.DistributionTeardown {
+		return nil
+	}
+
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		complete, err := listDistributionsByState(tx, common.DistributionStateComplete)
+		if err != nil {
+			return err
+		}
+
+		for _, dist := range complete {
+			start := time.Now()
+			report, err := app.service.Teardown(ctx, tx, &dist)
+			if report != nil || err != nil {
+				metrics.ObserveOperation(metrics.OperationTeardown, distributionMetrics(&dist), start, err)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// handleCancellations cancels the packs of aborted distributions once their
+// pending settle and mint transactions have finished.
+func handleCancellations(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		list, err := ListIncompleteDistributionCancellations(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range list {
+			dist, err := GetDistributionSmall(tx, c.DistributionID)
+			if err != nil {
+				return err
+			}
+
+			if err := app.service.FinishCancellation(ctx, tx, dist, &c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 func pollCirculatingPackContractEvents(ctx context.Context, app *App) error {
 	return app.db.Transaction(func(tx *gorm.DB) error {
 		cc, err := listCirculatingPackContracts(tx)
@@ -206,7 +465,248 @@ func pollCirculatingPackContractEvents(ctx context.Context, app *App) error {
 		}
 
 		for _, c := range cc {
-			if err := app.service.UpdateCirculatingPackContract(ctx, tx, &c); err != nil {
+			start := time.Now()
+			err := app.service.UpdateCirculatingPackContract(ctx, tx, &c)
+			metrics.ObserveOperation(metrics.OperationEvents, metrics.Distribution{}, start, err)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func handleOwnershipVerifications(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		list, err := listUnfinishedOwnershipVerifications(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range list {
+			if err := app.service.UpdateOwnershipVerification(ctx, tx, &v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// handleGiftIntents expires gift intents and sends their due webhooks
+func handleGiftIntents(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		now := app.clock.Now()
+
+		if err := ExpireGiftIntents(tx, now); err != nil {
+			return err
+		}
+
+		list, err := ListGiftIntentsWithWebhookDue(tx, now, app.cfg.BatchProcessSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range list {
+			g := &list[i]
+			event := g.WebhookEvent(now)
+			if event == "" {
+				continue
+			}
+
+			logger := log.WithFields(log.Fields{
+				"giftIntentID": g.ID,
+				"distID":       g.DistributionID,
+				"event":        event,
+			})
+
+			if err := sendGiftWebhook(ctx, app.webhooks, g, event); err != nil {
+				g.WebhookAttempts++
+				if g.WebhookAttempts < app.cfg.GiftWebhookMaxAttempts {
+					logger.WithFields(log.Fields{"error": err, "attempts": g.WebhookAttempts}).Warn("Error while sending gift intent webhook, retrying later")
+					if err := UpdateGiftIntent(tx, g); err != nil {
+						return err
+					}
+					continue
+				}
+				logger.WithFields(log.Fields{"error": err, "attempts": g.WebhookAttempts}).Error("Giving up sending gift intent webhook")
+			}
+
+			g.WebhookHandled(event)
+
+			if err := UpdateGiftIntent(tx, g); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// handleTeasers marks distributions whose teased stage is due as teased and
+// queues the teased reveal webhooks of their packs
+func handleTeasers(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		now := app.clock.Now()
+
+		list, err := ListDistributionsTeaseDue(tx, now)
+		if err != nil {
+			return err
+		}
+
+		for i := range list {
+			dist := &list[i]
+
+			if dist.RevealWebhookURL != "" {
+				err := DistributionPacksInBatches(tx, dist.ID, app.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+					events := make([]RevealEvent, 0, len(batch))
+					for _, p := range batch {
+						if p.State == common.PackStateInit {
+							continue
+						}
+						events = append(events, RevealEvent{DistributionID: dist.ID, PackID: p.ID, Stage: RevealStageTeased})
+					}
+					if len(events) == 0 {
+						return nil
+					}
+					return InsertRevealEvents(tx, events, app.cfg.BatchProcessSize)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			dist.TeasedAt = &now
+
+			if err := UpdateDistribution(tx, dist); err != nil {
+				return err
+			}
+
+			log.WithFields(log.Fields{"distID": dist.ID}).Info("Distribution teased")
+		}
+
+		return nil
+	})
+}
+
+// handleRevealWebhooks sends queued reveal webhooks
+func handleRevealWebhooks(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		list, err := ListUnhandledRevealEvents(tx, app.cfg.BatchProcessSize)
+		if err != nil {
+			return err
+		}
+
+		now := app.clock.Now()
+		distributions := make(map[uuid.UUID]*Distribution)
+
+		for i := range list {
+			e := &list[i]
+
+			logger := log.WithFields(log.Fields{
+				"distID": e.DistributionID,
+				"packID": e.PackID,
+				"event":  e.WebhookEvent(),
+			})
+
+			dist, ok := distributions[e.DistributionID]
+			if !ok {
+				if dist, err = GetDistributionWithBuckets(tx, e.DistributionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				distributions[e.DistributionID] = dist
+			}
+
+			pack, err := GetPack(tx, e.PackID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if dist == nil || pack == nil || dist.RevealWebhookURL == "" {
+				logger.Warn("Reveal webhook no longer deliverable, dropping")
+				e.Handled = true
+				if err := UpdateRevealEvent(tx, e); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := sendRevealWebhook(ctx, app.webhooks, dist, pack, e, packReveal(dist, pack, now)); err != nil {
+				e.Attempts++
+				if e.Attempts < app.cfg.RevealWebhookMaxAttempts {
+					logger.WithFields(log.Fields{"error": err, "attempts": e.Attempts}).Warn("Error while sending reveal webhook, retrying later")
+					if err := UpdateRevealEvent(tx, e); err != nil {
+						return err
+					}
+					continue
+				}
+				logger.WithFields(log.Fields{"error": err, "attempts": e.Attempts}).Error("Giving up sending reveal webhook")
+			}
+
+			e.Handled = true
+
+			if err := UpdateRevealEvent(tx, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// handleWebhookDeliveries sends the due deliveries of issuer webhooks
+func handleWebhookDeliveries(ctx context.Context, app *App) error {
+	return app.db.Transaction(func(tx *gorm.DB) error {
+		now := app.clock.Now()
+
+		list, err := ListDueWebhookDeliveries(tx, now, app.cfg.BatchProcessSize)
+		if err != nil {
+			return err
+		}
+
+		webhooks := make(map[uuid.UUID]*IssuerWebhook)
+
+		for i := range list {
+			d := &list[i]
+
+			logger := log.WithFields(log.Fields{
+				"webhookID":  d.WebhookID,
+				"deliveryID": d.ID,
+				"event":      d.Event,
+			})
+
+			w, ok := webhooks[d.WebhookID]
+			if !ok {
+				if w, err = GetIssuerWebhook(tx, d.WebhookID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				webhooks[d.WebhookID] = w
+			}
+
+			if w == nil {
+				logger.Warn("Webhook deleted, dropping delivery")
+				d.Handled = true
+				if err := UpdateWebhookDelivery(tx, d); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := sendWebhookDelivery(ctx, app.issuerWebhooks, w, d, now); err != nil {
+				if d.Failed(now, app.cfg.IssuerWebhookRetryInterval, app.cfg.IssuerWebhookMaxAttempts) {
+					logger.WithFields(log.Fields{"error": err, "attempts": d.Attempts}).Warn("Error while sending webhook delivery, retrying later")
+					if err := UpdateWebhookDelivery(tx, d); err != nil {
+						return err
+					}
+					continue
+				}
+				logger.WithFields(log.Fields{"error": err, "attempts": d.Attempts}).Error("Giving up sending webhook delivery")
+			}
+
+			d.Handled = true
+
+			if err := UpdateWebhookDelivery(tx, d); err != nil {
 				return err
 			}
 		}
@@ -217,21 +717,87 @@ func pollCirculatingPackContractEvents(ctx context.Context, app *App) error {
 
 // handleSendableTransactions sends all transactions which are sendable (state is init or retry)
 // with no regard to account proposal key sequence number
-func handleSendableTransactions(ctx context.Context, app *App, rateLimiter ratelimit.Limiter) error {
+func handleSendableTransactions(ctx context.Context, app *App) error {
 	handleCount := 0
 
 	for handleCount < app.cfg.BatchProcessSize {
-		// Rate limit
-		rateLimiter.Take()
+		// Stop between transactions when shutting down, each is sent and
+		// stored in its own database transaction
+		if app.draining() {
+			return nil
+		}
+
+		if app.service.keys.Frozen() {
+			log.Trace("Sending frozen, not sending transactions")
+			return nil
+		}
+
+		if !flow_helpers.Available(app.service.flowClient) {
+			log.Trace("Access API circuit breaker open, not sending transactions")
+			return nil
+		}
+
+		account := app.service.keys.Account()
+
+		// Rate limit, shared with transactions sent outside of the poller
+		app.service.sendLimiter.Take(account.Address)
 
 		err := app.db.Transaction(func(dbtx *gorm.DB) (err error) {
-			t, err := transactions.GetNextSendable(dbtx)
+			// Pick the next transaction from the lanes by priority weight
+			var t *transactions.StorableTransaction
+			_, found, err := app.service.lanes.Next(func(priority transactions.Priority) (bool, error) {
+				// Minting waits while user facing transactions are late
+				if priority == transactions.PriorityMinting && app.service.slo.Degraded() {
+					return false, nil
+				}
+				next, err := transactions.GetNextSendable(dbtx, app.clock.Now(), priority)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return false, nil
+				}
+				t = next
+				return err == nil, err
+			})
 			if err != nil {
 				err = fmt.Errorf("error while getting transaction from database: %w", err)
 				return
 			}
+			if !found {
+				err = gorm.ErrRecordNotFound
+				return
+			}
+
+			// Split or fail transactions the network would reject as too large
+			oversized, err := app.service.handleOversized(dbtx, t)
+			if err != nil {
+				err = fmt.Errorf("error while checking transaction size: %w", err)
+				return
+			}
+			if oversized {
+				return
+			}
+
+			flowClient, err := app.service.clientForDistributionID(dbtx, t.DistributionID)
+			if err != nil {
+				err = fmt.Errorf("error while getting Access API client: %w", err)
+				return
+			}
 
-			tx, unlockKey, err := t.Prepare(ctx, app.service.flowClient, app.service.account, app.service.cfg.TransactionGasLimit)
+			broadcaster := app.service.broadcaster(flowClient)
+
+			if err = app.service.fillRevealSalt(dbtx, t); err != nil {
+				err = fmt.Errorf("error while filling in reveal salt: %w", err)
+				return
+			}
+
+			// Build and log, but do not send
+			if app.cfg.DryRun {
+				if err = app.service.dryRun(ctx, dbtx, broadcaster, account, t); err != nil {
+					err = fmt.Errorf("error while dry-running transaction: %w", err)
+				}
+				return
+			}
+
+			tx, unlockKey, err := broadcaster.Prepare(ctx, t, account)
 
 			defer func() {
 				// Make sure to unlock if we had an error to prevent deadlocks
@@ -258,22 +824,39 @@ func handleSendableTransactions(ctx context.Context, app *App, rateLimiter ratel
 				return
 			}
 
-			if err = app.service.flowClient.SendTransaction(ctx, *tx); err != nil {
-				err = fmt.Errorf("error while sending transaction: %w", err)
+			if err = t.InsertAttempt(dbtx, tx); err != nil {
+				err = fmt.Errorf("error while saving transaction attempt: %w", err)
+				return
+			}
 
-				t.State = common.TransactionStateFailed
-				t.Error = err.Error()
+			sendStart := time.Now()
+			sendErr := broadcaster.Send(ctx, tx)
+			metrics.ObserveOperation(metrics.OperationSendTransaction, distributionMetricsByID(dbtx, t.DistributionID), sendStart, sendErr)
+			if sendErr != nil {
+				// Cant't return the error as that would rollback this db transaction
+				defer unlockKey()
 
-				if err = t.Save(dbtx); err != nil {
-					err = fmt.Errorf("error while saving transaction: %w", err)
+				if flow_helpers.IsRetryableSendError(sendErr) {
+					t.Retry(sendErr.Error(), app.clock.Now(), app.service.retryPolicy())
+				} else {
+					t.State = common.TransactionStateFailed
+					t.Error = fmt.Sprintf("error while sending transaction: %s", sendErr)
+
+					if err = app.service.handleFailedMint(dbtx, t); err != nil {
+						err = fmt.Errorf("error while retrying failed mint: %w", err)
+						return
+					}
+				}
+
+				if err = t.UpdateAttempt(dbtx); err != nil {
+					err = fmt.Errorf("error while saving transaction attempt: %w", err)
 					return
 				}
 
-				// Cant't return the error here as that would rollback this db transaction
-			}
+				if err = t.Save(dbtx); err != nil {
+					err = fmt.Errorf("error while saving transaction: %w", err)
+				}
 
-			// Double check
-			if err != nil {
 				return
 			}
 
@@ -289,19 +872,19 @@ func handleSendableTransactions(ctx context.Context, app *App, rateLimiter ratel
 
 			// Wait for the transaction to finalize (be included in a block, not yet sealed)
 			// in a goroutine to unlock the used key
-			go func(ctx context.Context, app *App, unlockKey flow_helpers.UnlockKeyFunc, logger *log.Entry) {
+			go func(ctx context.Context, broadcaster Broadcaster, id flow.Identifier, unlockKey flow_helpers.UnlockKeyFunc, logger *log.Entry) {
 				defer unlockKey()
-				if _, err := t.WaitForFinalize(ctx, app.service.flowClient); err != nil {
+				if _, err := broadcaster.WaitForFinalize(ctx, id); err != nil {
 					logger.WithFields(log.Fields{"error": err.Error()}).Warn("Error while waiting for transaction to finalize")
 				}
-			}(context.Background(), app, unlockKey, logger)
+			}(context.Background(), broadcaster, tx.ID(), unlockKey, logger)
 
 			return
 		})
 
 		if err != nil {
-			// Ignore ErrRecordNotFound and ErrNoAccountKeyAvailable and stop iteration
-			if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, flow_helpers.ErrNoAccountKeyAvailable) {
+			// Ignore ErrRecordNotFound, ErrNoAccountKeyAvailable and ErrCircuitOpen and stop iteration
+			if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, flow_helpers.ErrNoAccountKeyAvailable) || errors.Is(err, flow_helpers.ErrCircuitOpen) {
 				break
 			}
 			return err
@@ -318,32 +901,14 @@ func handleSendableTransactions(ctx context.Context, app *App, rateLimiter ratel
 func handleSentTransactions(ctx context.Context, app *App) error {
 	handleCount := 0
 
-	for handleCount < app.cfg.BatchProcessSize {
-		err := app.db.Transaction(func(dbtx *gorm.DB) (err error) {
+	for handleCount < app.cfg.BatchProcessSize && !app.draining() {
+		err := app.db.Transaction(func(dbtx *gorm.DB) error {
 			t, err := transactions.GetNextSent(dbtx)
 			if err != nil {
-				err = fmt.Errorf("error while getting transaction from database: %w", err)
-				return
-			}
-
-			if err = t.HandleResult(ctx, app.service.flowClient); err != nil {
-				err = fmt.Errorf("error while handling transaction result: %w", err)
-				return
+				return fmt.Errorf("error while getting transaction from database: %w", err)
 			}
 
-			log.WithFields(log.Fields{
-				"function":       "handleSentTransactions",
-				"ID":             t.ID,
-				"name":           t.Name,
-				"distributionID": t.DistributionID,
-			}).Trace("Sent transaction handled")
-
-			if err = t.Save(dbtx); err != nil {
-				err = fmt.Errorf("error while saving transaction: %w", err)
-				return
-			}
-
-			return nil
+			return handleSentTransaction(ctx, app, dbtx, t)
 		})
 
 		if err != nil {
@@ -359,3 +924,112 @@ func handleSentTransactions(ctx context.Context, app *App) error {
 
 	return nil
 }
+
+// resumeSentTransactions checks the results of all transactions left in
+// 'sent' state by a previous run (e.g. the service restarted before they
+// sealed) before the poller starts, instead of waiting for them to come up
+// in handleSentTransactions batches. Distributions and packs then advance
+// as usual from the onchain events of the sealed transactions.
+func resumeSentTransactions(ctx context.Context, app *App) error {
+	ids, err := transactions.ListSentIDs(app.db)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	states := make(map[common.TransactionState]int)
+	failedCount := 0
+
+	for _, id := range ids {
+		if app.draining() {
+			break
+		}
+
+		err := app.db.Transaction(func(dbtx *gorm.DB) error {
+			t, err := transactions.GetTransaction(dbtx, id)
+			if err != nil {
+				return err
+			}
+
+			if t.State != common.TransactionStateSent {
+				// Handled meanwhile (e.g. another instance)
+				return nil
+			}
+
+			if err := handleSentTransaction(ctx, app, dbtx, t); err != nil {
+				return err
+			}
+
+			states[t.State]++
+
+			return nil
+		})
+
+		if err != nil {
+			// Left for handleSentTransactions to retry
+			failedCount++
+			log.WithFields(log.Fields{"ID": id, "error": err}).Warn("Error while resuming sent transaction")
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"count":    len(ids),
+		"complete": states[common.TransactionStateComplete],
+		"pending":  states[common.TransactionStateSent] + failedCount,
+		"retry":    states[common.TransactionStateRetry],
+		"failed":   states[common.TransactionStateFailed] + states[common.TransactionStateDeadLetter],
+	}).Info("Resumed sent transactions")
+
+	return nil
+}
+
+// handleSentTransaction checks the result of a sent transaction and updates
+// its state (and the state of its current attempt) in database
+func handleSentTransaction(ctx context.Context, app *App, dbtx *gorm.DB, t *transactions.StorableTransaction) error {
+	flowClient, err := app.service.clientForDistributionID(dbtx, t.DistributionID)
+	if err != nil {
+		return fmt.Errorf("error while getting Access API client: %w", err)
+	}
+
+	if err := t.HandleResult(ctx, flowClient, app.cfg.TransactionExpiryMargin, app.service.retryPolicy(), app.clock.Now()); err != nil {
+		return fmt.Errorf("error while handling transaction result: %w", err)
+	}
+
+	if t.State != common.TransactionStateSent {
+		if err := t.UpdateAttempt(dbtx); err != nil {
+			return fmt.Errorf("error while saving transaction attempt: %w", err)
+		}
+	}
+
+	if t.State != common.TransactionStateSent && t.State != common.TransactionStateRetry {
+		metrics.CountOperation(metrics.OperationTransaction, distributionMetricsByID(dbtx, t.DistributionID), metrics.FlowErrorCode(t.Error))
+	}
+
+	split, err := app.service.observeBatchResult(dbtx, t)
+	if err != nil {
+		return fmt.Errorf("error while adjusting batch size: %w", err)
+	}
+
+	// The packs of a split batch are minted by the new transactions
+	if !split {
+		if err := app.service.handleFailedMint(dbtx, t); err != nil {
+			return fmt.Errorf("error while retrying failed mint: %w", err)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"function":       "handleSentTransaction",
+		"ID":             t.ID,
+		"name":           t.Name,
+		"distributionID": t.DistributionID,
+	}).Trace("Sent transaction handled")
+
+	if err := t.Save(dbtx); err != nil {
+		return fmt.Errorf("error while saving transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// testFlowClient is an Access API with an admin account of one key, other
+// calls are not expected.
+type testFlowClient struct {
+	flow_helpers.FlowClient
+}
+
+func (testFlowClient) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (*flow.Account, error) {
+	return &flow.Account{Address: address, Keys: []*flow.AccountKey{{Index: 0}}}, nil
+}
+
+func (testFlowClient) Close() error {
+	return nil
+}
+
+// newTestApp returns an app on a temporary sqlite database, not polling and
+// without an Access API. 'configure' (if any) adjusts the configuration.
+func newTestApp(t *testing.T, configure func(cfg *config.Config)) (*App, *gorm.DB) {
+	t.Setenv("FLOW_PDS_ADMIN_ADDRESS", "f8d6e0586b0a20c7")
+	t.Setenv("PDS_ADDRESS", "f8d6e0586b0a20c7")
+	t.Setenv("FLOW_PDS_ADMIN_PRIVATE_KEY", "unused")
+	t.Setenv("NON_FUNGIBLE_TOKEN_ADDRESS", "f8d6e0586b0a20c7")
+
+	cfg, err := config.ParseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.DatabaseType = "sqlite"
+	cfg.DatabaseDSN = path.Join(t.TempDir(), "test.db")
+	if configure != nil {
+		configure(cfg)
+	}
+
+	db, err := common.NewGormDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := transactions.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := New(cfg, db, testFlowClient{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Close)
+
+	return app, db
+}
+
+func TestDrain(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+
+	if !drain(context.Background(), done, func() { t.Error("expected cancel not to be called") }) {
+		t.Error("expected drain to return true once done")
+	}
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	cancelCtx()
+
+	done = make(chan struct{})
+	cancelled := false
+	cancel := func() {
+		cancelled = true
+		close(done)
+	}
+
+	if drain(ctx, done, cancel) {
+		t.Error("expected drain to return false after the context is done")
+	}
+	if !cancelled {
+		t.Error("expected cancel to be called")
+	}
+}
+
+func TestHandleResolvedMaxConcurrentDistributions(t *testing.T) {
+	app, db := newTestApp(t, func(cfg *config.Config) {
+		cfg.MaxConcurrentDistributions = 3
+		cfg.CollectibleOwnershipCheck = false
+	})
+
+	issuer := common.FlowAddressFromString("01cf0e2f2f715450")
+	start := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	settling := Distribution{Issuer: issuer, State: common.DistributionStateSettling}
+	if err := db.Create(&settling).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Created newest first, the oldest are started first
+	resolved := make([]Distribution, 4)
+	for i := range resolved {
+		resolved[i] = Distribution{Issuer: issuer, State: common.DistributionStateResolved}
+		resolved[i].CreatedAt = start.Add(-time.Duration(i) * time.Minute)
+		resolved[i].UpdatedAt = resolved[i].CreatedAt
+		if err := db.Create(&resolved[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := handleResolved(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, d := range resolved {
+		dist, err := GetDistributionSmall(db, d.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := common.DistributionStateResolved
+		if i >= 2 {
+			expected = common.DistributionStateSetup
+		}
+		if dist.State != expected {
+			t.Errorf("distribution %d: expected state %s, got %s", i, expected, dist.State)
+		}
+	}
+
+	// At the limit nothing more is started
+	if err := handleResolved(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := countDistributionsByStates(db, inProgressDistributionStates); err != nil || n != 3 {
+		t.Errorf("expected 3 distributions in progress, got %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PublicStatsOptIn marks an issuer whose distributions are included in the
+// public stats.
+type PublicStatsOptIn struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	Issuer common.FlowAddress `gorm:"column:issuer;uniqueIndex"`
+}
+
+func (PublicStatsOptIn) TableName() string {
+	return "public_stats_opt_ins"
+}
+
+func (o *PublicStatsOptIn) BeforeCreate(tx *gorm.DB) (err error) {
+	o.ID = uuid.New()
+	return nil
+}
+
+// publicStatsCounts are the exact counts over the opted in issuers, never
+// exposed as is.
+type publicStatsCounts struct {
+	Issuers                uint // Opted in issuers with at least one distribution
+	PacksMinted            uint
+	DistributionsCompleted uint
+}
+
+// PublicStats are aggregate numbers safe to expose unauthenticated. The counts
+// are nil (withheld) while fewer than the configured minimum number of
+// issuers have opted in, so the numbers of a single issuer can not be told
+// apart, and are rounded down to hide the exact size of individual drops.
+type PublicStats struct {
+	PacksMinted            *uint
+	DistributionsCompleted *uint
+	UpdatedAt              time.Time
+}
+
+func newPublicStats(counts publicStatsCounts, minIssuers, roundTo uint, now time.Time) PublicStats {
+	stats := PublicStats{UpdatedAt: now}
+
+	if counts.Issuers == 0 || counts.Issuers < minIssuers {
+		return stats
+	}
+
+	packs, dists := roundDown(counts.PacksMinted, roundTo), roundDown(counts.DistributionsCompleted, roundTo)
+	stats.PacksMinted, stats.DistributionsCompleted = &packs, &dists
+
+	return stats
+}
+
+func roundDown(n, to uint) uint {
+	if to <= 1 {
+		return n
+	}
+	return n / to * to
+}
+
+// publicStatsCache keeps the public stats for a while so the unauthenticated
+// endpoint does not query the database on every request.
+type publicStatsCache struct {
+	mu        sync.Mutex
+	stats     *PublicStats
+	fetchedAt time.Time
+}
+
+func (c *publicStatsCache) get(now time.Time, ttl time.Duration, fetch func() (PublicStats, error)) (PublicStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats != nil && now.Sub(c.fetchedAt) < ttl {
+		return *c.stats, nil
+	}
+
+	stats, err := fetch()
+	if err != nil {
+		return PublicStats{}, err
+	}
+
+	c.stats, c.fetchedAt = &stats, now
+
+	return stats, nil
+}
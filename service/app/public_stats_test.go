@@ -0,0 +1,60 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPublicStats(t *testing.T) {
+	now := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	counts := publicStatsCounts{Issuers: 2, PacksMinted: 12345, DistributionsCompleted: 17}
+
+	stats := newPublicStats(counts, 3, 10, now)
+	if stats.PacksMinted != nil || stats.DistributionsCompleted != nil {
+		t.Fatal("expected counts to be withheld below the minimum number of issuers")
+	}
+
+	counts.Issuers = 3
+	stats = newPublicStats(counts, 3, 10, now)
+	if stats.PacksMinted == nil || *stats.PacksMinted != 12340 {
+		t.Fatalf("expected 12340 packs, got %v", stats.PacksMinted)
+	}
+	if stats.DistributionsCompleted == nil || *stats.DistributionsCompleted != 10 {
+		t.Fatalf("expected 10 distributions, got %v", stats.DistributionsCompleted)
+	}
+
+	stats = newPublicStats(counts, 0, 0, now)
+	if *stats.PacksMinted != 12345 || *stats.DistributionsCompleted != 17 {
+		t.Fatal("expected exact counts without rounding")
+	}
+
+	if stats := newPublicStats(publicStatsCounts{}, 0, 0, now); stats.PacksMinted != nil {
+		t.Fatal("expected counts to be withheld without opted in issuers")
+	}
+}
+
+func TestPublicStatsCache(t *testing.T) {
+	now := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := &publicStatsCache{}
+	fetches := 0
+	fetch := func() (PublicStats, error) {
+		fetches++
+		return PublicStats{UpdatedAt: now}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.get(now.Add(time.Duration(i)*time.Second), time.Minute, fetch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("expected 1 fetch within the TTL, got %d", fetches)
+	}
+
+	if _, err := c.get(now.Add(time.Minute), time.Minute, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Fatalf("expected a fetch after the TTL, got %d", fetches)
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// RateLimiter limits the rate of requests per client (e.g. an API key or an
+// IP address) with a token bucket each. Buckets are kept in memory, per
+// instance of the PDS.
+type RateLimiter struct {
+	mu          sync.Mutex
+	clock       common.Clock
+	rate        int
+	burst       int
+	clients     map[string]*tokenBucket
+	lastExpired time.Time
+}
+
+func NewRateLimiter(clock common.Clock, rate, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{clock: clock, rate: rate, burst: burst, clients: make(map[string]*tokenBucket)}
+}
+
+// Allow takes a token of 'client' and returns 0 if the request can be
+// served, otherwise how long to wait before retrying. Refused requests do
+// not take a token.
+func (l *RateLimiter) Allow(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+
+	// Drop buckets of idle clients now and then, they would be full again
+	if now.Sub(l.lastExpired) > time.Minute {
+		idle := time.Duration(float64(l.burst) / float64(l.rate) * float64(time.Second))
+		for k, b := range l.clients {
+			if now.Sub(b.last) > idle {
+				delete(l.clients, k)
+			}
+		}
+		l.lastExpired = now
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = newTokenBucket(l.rate, l.burst, now)
+		l.clients[client] = b
+	}
+
+	return b.take(now)
+}
+
+// Wait returns 0 if a request of 'client' can be served, otherwise how long
+// to wait before retrying, without taking a token.
+func (l *RateLimiter) Wait(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.clients[client]
+	if !ok {
+		return 0
+	}
+	return b.wait(l.clock.Now())
+}
+
+// take takes a token at 'now' if one is available and returns 0, otherwise
+// returns how long until one is. Unlike reserve the balance never goes
+// negative.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	if wait := b.wait(now); wait > 0 {
+		return wait
+	}
+	b.tokens--
+	return 0
+}
+
+// wait refills 'b' up to 'now' and returns 0 if a token is available,
+// otherwise how long until one is.
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
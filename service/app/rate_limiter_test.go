@@ -0,0 +1,61 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	l := NewRateLimiter(clock, 2, 2)
+
+	for i := 0; i < 2; i++ {
+		if w := l.Allow("a"); w != 0 {
+			t.Fatalf("expected request %d within the burst to be allowed, got wait %s", i, w)
+		}
+	}
+
+	if w := l.Allow("a"); w != 500*time.Millisecond {
+		t.Fatalf("expected to wait for the next token, got %s", w)
+	}
+
+	// Refused requests take no token
+	if w := l.Allow("a"); w != 500*time.Millisecond {
+		t.Fatalf("expected the same wait after a refused request, got %s", w)
+	}
+
+	if w := l.Wait("a"); w != 500*time.Millisecond {
+		t.Fatalf("expected the wait without taking a token, got %s", w)
+	}
+
+	if w := l.Allow("b"); w != 0 {
+		t.Fatalf("expected another client to be allowed, got wait %s", w)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+
+	if w := l.Wait("a"); w != 0 {
+		t.Fatalf("expected a token to be available after waiting, got wait %s", w)
+	}
+	if w := l.Allow("a"); w != 0 {
+		t.Fatalf("expected a request to be allowed after waiting, got wait %s", w)
+	}
+}
+
+func TestRateLimiterDropsIdleClients(t *testing.T) {
+	clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	l := NewRateLimiter(clock, 10, 5)
+
+	l.Allow("a")
+	clock.Advance(2 * time.Minute)
+	l.Allow("b")
+
+	if _, ok := l.clients["a"]; ok {
+		t.Error("expected the idle client to be dropped")
+	}
+	if _, ok := l.clients["b"]; !ok {
+		t.Error("expected the active client to be kept")
+	}
+}
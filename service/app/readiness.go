@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Dependencies checked for readiness
+const (
+	ReadinessCheckDatabase     = "database"
+	ReadinessCheckAccessAPI    = "accessAPI"
+	ReadinessCheckAdminAccount = "adminAccount"
+)
+
+// ReadinessCheck is the result of checking a dependency of the PDS.
+type ReadinessCheck struct {
+	Name  string
+	Error string // Empty if the check passed
+}
+
+// Readiness is the result of the readiness checks, ready if all passed.
+type Readiness struct {
+	Ready  bool
+	Checks []ReadinessCheck
+}
+
+// readinessCheckFunc checks a dependency, returning an error if it is
+// unusable.
+type readinessCheckFunc func(ctx context.Context) error
+
+// CheckReadiness checks the PDS can serve requests and make progress: the
+// database can be queried, the Access API is reachable and the admin account
+// can be fetched. The checks run concurrently, each with 'timeout'.
+func (app *App) CheckReadiness(ctx context.Context, timeout time.Duration) Readiness {
+	return runReadinessChecks(ctx, timeout, []string{
+		ReadinessCheckDatabase,
+		ReadinessCheckAccessAPI,
+		ReadinessCheckAdminAccount,
+	}, []readinessCheckFunc{
+		func(ctx context.Context) error {
+			return app.db.WithContext(ctx).Exec("SELECT 1").Error
+		},
+		func(ctx context.Context) error {
+			return app.flowClient.Ping(ctx)
+		},
+		func(ctx context.Context) error {
+			_, err := app.flowClient.GetAccount(ctx, flow.HexToAddress(app.cfg.AdminAddress))
+			return err
+		},
+	})
+}
+
+// runReadinessChecks runs 'checks' named 'names' concurrently.
+func runReadinessChecks(ctx context.Context, timeout time.Duration, names []string, checks []readinessCheckFunc) Readiness {
+	res := Readiness{Ready: true, Checks: make([]ReadinessCheck, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check readinessCheckFunc) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			res.Checks[i] = ReadinessCheck{Name: names[i]}
+			if err := check(ctx); err != nil {
+				res.Checks[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, c := range res.Checks {
+		if c.Error != "" {
+			res.Ready = false
+		}
+	}
+
+	return res
+}
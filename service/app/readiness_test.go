@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunReadinessChecks(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	hangs := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	res := runReadinessChecks(context.Background(), 10*time.Millisecond, []string{"a", "b"}, []readinessCheckFunc{ok, ok})
+	if !res.Ready || len(res.Checks) != 2 || res.Checks[0].Name != "a" || res.Checks[1].Name != "b" {
+		t.Fatalf("expected ready with both checks, got %+v", res)
+	}
+
+	res = runReadinessChecks(context.Background(), 10*time.Millisecond, []string{"a", "b"}, []readinessCheckFunc{ok, hangs})
+	if res.Ready {
+		t.Fatal("expected not ready with a check timing out")
+	}
+	if res.Checks[0].Error != "" || res.Checks[1].Error == "" {
+		t.Errorf("expected only the hanging check to fail, got %+v", res.Checks)
+	}
+}
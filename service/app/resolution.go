@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ResolveAlgorithmVersion is the version of the algorithm distributing the
+// collectibles of a distribution into its packs, recorded with the seed. Bump
+// it whenever the packs resolved from a seed change and keep resolving older
+// versions in ResolvePacks so that their distributions can still be verified.
+const ResolveAlgorithmVersion uint = 1
+
+// Resolution tells whether the packs of a distribution are the ones its
+// recorded seed resolves to.
+type Resolution struct {
+	DistributionID   uuid.UUID
+	Seed             int64
+	AlgorithmVersion uint
+	PackCount        uint // Packs of the distribution
+	MatchingCount    uint // Packs with contents resolved from the seed
+	Verified         bool // Set if all packs match (and the seed derives from the anchor of a commit-reveal distribution)
+
+	// Commit-reveal distributions only, see seed_commitment.go
+	SeedSecret        common.BinaryValue
+	SeedAnchorHeight  uint64
+	SeedAnchorBlockID string
+}
+
+// newResolveSeed returns a random seed for resolving a distribution.
+func newResolveSeed() (int64, error) {
+	b, err := common.GenerateRandomBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// bucketSeed derives the seed of a bucket from the seed of the distribution
+// and the collectibles of the bucket, so that buckets are shuffled
+// independently of the order they are loaded in.
+func bucketSeed(seed int64, bucket Bucket) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(bucket.CollectibleReference.String()))
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(bucket.CollectibleCount))
+	h.Write(b)
+	for _, id := range bucket.CollectibleCollection {
+		binary.BigEndian.PutUint64(b, uint64(id.Int64))
+		h.Write(b)
+	}
+	return seed ^ int64(h.Sum64())
+}
+
+// ResolvePacks returns the collectibles of each pack of 'pt' resolved from
+// 'seed' with algorithm 'version'. The result only depends on its arguments,
+// which makes it possible to reproduce the packs of a distribution offline.
+func ResolvePacks(pt PackTemplate, seed int64, version uint) ([]Collectibles, error) {
+	if version != ResolveAlgorithmVersion {
+		return nil, fmt.Errorf("unknown resolve algorithm version %d", version)
+	}
+
+	packCount := int(pt.PackCount)
+	packSlotCount, err := pt.PackSlotCount()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Collectibles, packCount)
+	for i := range res {
+		res[i] = make(Collectibles, packSlotCount)
+	}
+
+	slotBaseIndex := 0
+	for bucketIndex, bucket := range pt.Buckets {
+		// How many collectibles to pick from this bucket per pack
+		countPerPack := int(bucket.CollectibleCount)
+		// How many collectibles to pick from this bucket in total
+		countTotal := packCount * countPerPack
+
+		r := rand.New(rand.NewSource(bucketSeed(seed, bucket)))
+
+		// Buckets weighted by tier deal the tiers of their slots first
+		if bucket.Weighted() {
+			picks, err := bucket.resolveWeighted(r, packCount)
+			if err != nil {
+				return nil, withCode(ErrorCodeDistributionInvalidBucket, fmt.Errorf("error in bucket %d: %w", bucketIndex, err))
+			}
+
+			for packIndex, ids := range picks {
+				for i, id := range ids {
+					res[packIndex][slotBaseIndex+i] = Collectible{
+						ContractReference: bucket.CollectibleReference,
+						FlowID:            id,
+					}
+				}
+			}
+
+			slotBaseIndex += countPerPack
+			continue
+		}
+
+		// Generate a slice of random indexes to bucket.CollectibleCollection
+		permutation := r.Perm(len(bucket.CollectibleCollection))
+
+		for i := 0; i < countTotal; i++ {
+			randomIndex := permutation[i]
+			packIndex := i % packCount
+			slotIndex := (i / packCount) + slotBaseIndex
+
+			res[packIndex][slotIndex] = Collectible{
+				ContractReference: bucket.CollectibleReference,
+				FlowID:            bucket.CollectibleCollection[randomIndex],
+			}
+		}
+
+		slotBaseIndex += countPerPack
+	}
+
+	return res, nil
+}
+
+// packContentsKey returns a key of the collectibles of a pack which does not
+// depend on the order of its slots.
+func packContentsKey(cc Collectibles) string {
+	keys := make([]string, len(cc))
+	for i, c := range cc {
+		keys[i] = c.HashString()
+	}
+	sort.Strings(keys)
+	return fmt.Sprint(keys)
+}
+
+// resolutionMatcher counts packs matching the packs resolved from a seed.
+// Packs are stored in no particular order so they are matched by contents.
+type resolutionMatcher struct {
+	expected map[string]int
+	matching uint
+}
+
+func newResolutionMatcher(resolved []Collectibles) *resolutionMatcher {
+	m := &resolutionMatcher{expected: make(map[string]int, len(resolved))}
+	for _, cc := range resolved {
+		m.expected[packContentsKey(cc)]++
+	}
+	return m
+}
+
+func (m *resolutionMatcher) match(cc Collectibles) {
+	key := packContentsKey(cc)
+	if m.expected[key] > 0 {
+		m.expected[key]--
+		m.matching++
+	}
+}
+
+// VerifyResolution returns the number of 'packs' (collectibles of each) which
+// match the packs of 'pt' resolved from 'seed' with algorithm 'version'. All
+// of them match if the packs were resolved from the seed.
+func VerifyResolution(pt PackTemplate, seed int64, version uint, packs []Collectibles) (uint, error) {
+	resolved, err := ResolvePacks(pt, seed, version)
+	if err != nil {
+		return 0, err
+	}
+
+	m := newResolutionMatcher(resolved)
+	for _, cc := range packs {
+		m.match(cc)
+	}
+
+	return m.matching, nil
+}
+
+// undisclosedPackStates are the states of minted packs whose contents are not
+// public yet.
+var undisclosedPackStates = []common.PackState{
+	common.PackStateInit,
+	common.PackStateSealed,
+	common.PackStateRevealRequestHandled,
+}
+
+// validateResolutionDisclosure checks the seed of a distribution in 'state'
+// with 'undisclosed' packs (see undisclosedPackStates) can be disclosed. The
+// seed tells which collectibles end up together in a pack, so a complete
+// distribution discloses it only once all of its packs are revealed.
+func validateResolutionDisclosure(state common.DistributionState, undisclosed int64) error {
+	switch state {
+	case common.DistributionStateClosed, common.DistributionStateCancelled:
+		return nil
+	case common.DistributionStateComplete:
+		if undisclosed == 0 {
+			return nil
+		}
+		return newError(ErrorCodeDistributionState, "the resolution of a '%s' distribution can only be verified once all of its packs are revealed, %d are not", state, undisclosed)
+	}
+	return newError(ErrorCodeDistributionState, "the resolution of a distribution can only be verified in '%s', '%s' or '%s' state, state is '%s'", common.DistributionStateComplete, common.DistributionStateClosed, common.DistributionStateCancelled, state)
+}
+
+// GetDistributionResolution discloses the seed the packs of a distribution
+// were resolved from and reproduces them from it to verify the packs stored.
+func (app *App) GetDistributionResolution(ctx context.Context, distributionID uuid.UUID) (*Resolution, error) {
+	distribution, err := GetDistributionWithBuckets(app.db, distributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var undisclosed int64
+	if distribution.State == common.DistributionStateComplete {
+		undisclosed, err = CountDistributionPacksInStates(packsOf(app.db, distribution), distributionID, undisclosedPackStates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateResolutionDisclosure(distribution.State, undisclosed); err != nil {
+		return nil, err
+	}
+
+	if distribution.ResolveAlgorithm == 0 {
+		return nil, newError(ErrorCodeDistributionState, "distribution has no recorded resolve seed")
+	}
+
+	resolved, err := ResolvePacks(distribution.PackTemplate, distribution.ResolveSeed, distribution.ResolveAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	res := Resolution{
+		DistributionID:   distributionID,
+		Seed:             distribution.ResolveSeed,
+		AlgorithmVersion: distribution.ResolveAlgorithm,
+	}
+
+	m := newResolutionMatcher(resolved)
+	err = DistributionPacksInBatches(packsOf(app.db, distribution), distributionID, app.cfg.BatchProcessSize, func(tx *gorm.DB, batchNumber int, batch []Pack) error {
+		for _, p := range batch {
+			if p.ReplacesPackID != nil {
+				continue // Not resolved, see PackReplacement
+			}
+			res.PackCount++
+			m.match(p.Collectibles)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res.MatchingCount = m.matching
+	res.Verified = res.PackCount == uint(len(resolved)) && res.MatchingCount == res.PackCount
+
+	if distribution.SeedCommitReveal {
+		res.SeedSecret = distribution.SeedSecret
+		res.SeedAnchorHeight = distribution.SeedAnchorHeight
+		res.SeedAnchorBlockID = distribution.SeedAnchorBlockID
+		res.Verified = res.Verified && anchoredSeedMatches(distribution)
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func makeResolutionDistribution() Distribution {
+	weighted := makeTieredBucket(30, 10, 2)
+	weighted.CollectibleReference = AddressLocation{Name: "Card", Address: common.FlowAddress(flow.HexToAddress("0x2"))}
+	weighted.TierWeights = TierCounts{"common": 1}
+	weighted.GuaranteedTiers = TierCounts{"rare": 1}
+
+	return Distribution{
+		State:  common.DistributionStateInit,
+		FlowID: common.FlowID{Int64: 1, Valid: true},
+		Issuer: common.FlowAddress(flow.HexToAddress("0x1")),
+		PackTemplate: PackTemplate{
+			PackReference: AddressLocation{Name: "TestPackNFT", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+			PackCount:     10,
+			Buckets: []Bucket{
+				{
+					CollectibleReference:  AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+					CollectibleCount:      3,
+					CollectibleCollection: makeCollection(40),
+				},
+				weighted,
+			},
+		},
+	}
+}
+
+func TestResolvePacksIsReproducible(t *testing.T) {
+	pt := makeResolutionDistribution().PackTemplate
+
+	a, err := ResolvePacks(pt, 42, ResolveAlgorithmVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ResolvePacks(pt, 42, ResolveAlgorithmVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("expected the same seed to resolve the same packs")
+	}
+
+	c, err := ResolvePacks(pt, 43, ResolveAlgorithmVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Error("expected another seed to resolve other packs")
+	}
+
+	// The order of buckets does not change the contents of the packs
+	pt.Buckets[0], pt.Buckets[1] = pt.Buckets[1], pt.Buckets[0]
+	if matching, err := VerifyResolution(pt, 42, ResolveAlgorithmVersion, a); err != nil || matching != 10 {
+		t.Errorf("expected all packs to match with buckets reordered, got %d (%v)", matching, err)
+	}
+
+	if _, err := ResolvePacks(pt, 42, ResolveAlgorithmVersion+1); err == nil {
+		t.Error("expected an error for an unknown algorithm version")
+	}
+}
+
+func TestResolveRecordsSeed(t *testing.T) {
+	d := makeResolutionDistribution()
+	if err := d.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.ResolveAlgorithm != ResolveAlgorithmVersion {
+		t.Errorf("expected algorithm version %d, got %d", ResolveAlgorithmVersion, d.ResolveAlgorithm)
+	}
+
+	packs := make([]Collectibles, len(d.Packs))
+	for i, p := range d.Packs {
+		packs[i] = p.Collectibles
+	}
+	// Stored packs come in no particular order
+	packs[0], packs[9] = packs[9], packs[0]
+
+	matching, err := VerifyResolution(d.PackTemplate, d.ResolveSeed, d.ResolveAlgorithm, packs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matching != 10 {
+		t.Errorf("expected all 10 packs to match, got %d", matching)
+	}
+
+	// Swapping collectibles between packs is detected
+	packs[1][0], packs[2][0] = packs[2][0], packs[1][0]
+	if matching, _ := VerifyResolution(d.PackTemplate, d.ResolveSeed, d.ResolveAlgorithm, packs); matching != 8 {
+		t.Errorf("expected 8 packs to match, got %d", matching)
+	}
+}
+
+func TestValidateResolutionDisclosure(t *testing.T) {
+	if err := validateResolutionDisclosure(common.DistributionStateClosed, 3); err != nil {
+		t.Errorf("expected a closed distribution to disclose its seed, got %s", err)
+	}
+	if err := validateResolutionDisclosure(common.DistributionStateComplete, 0); err != nil {
+		t.Errorf("expected a complete distribution with all packs revealed to disclose its seed, got %s", err)
+	}
+	if err := validateResolutionDisclosure(common.DistributionStateComplete, 3); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a complete distribution with sealed packs, got %v", ErrorCodeDistributionState, err)
+	}
+	if err := validateResolutionDisclosure(common.DistributionStateMinting, 0); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a minting distribution, got %v", ErrorCodeDistributionState, err)
+	}
+}
+
+func TestGetDistributionResolutionSealedPacks(t *testing.T) {
+	app, db := newTestApp(t, nil)
+
+	d := makeResolutionDistribution()
+	if err := d.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	d.State = common.DistributionStateComplete
+	for i := range d.Packs {
+		d.Packs[i].State = common.PackStateRevealed
+	}
+	d.Packs[0].State = common.PackStateSealed
+	if err := InsertDistribution(db, &d, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.GetDistributionResolution(context.Background(), d.ID); ErrorCode(err) != ErrorCodeDistributionState {
+		t.Errorf("expected a '%s' error for a complete distribution with a sealed pack, got %v", ErrorCodeDistributionState, err)
+	}
+
+	if err := db.Model(&Pack{}).Where("id = ?", d.Packs[0].ID).Update("state", common.PackStateOpened).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := app.GetDistributionResolution(context.Background(), d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Verified {
+		t.Errorf("expected the resolution to be verified once all packs are revealed, got %+v", res)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Transactions scheduled for when the reveals of a distribution open
+var revealTransactions = []string{REVEAL_SCRIPT, OPEN_SCRIPT}
+
+// SetRevealNotBefore sets when the packs of 'dist' can be revealed, nil opens
+// reveals at once. Unlike the rest of the pack template it can be changed
+// until the distribution is closed, e.g. to open packs at an announced time
+// after minting. A time must be in the future (at 'now') and after the
+// teased stage, if any.
+func (dist *Distribution) SetRevealNotBefore(t *time.Time, now time.Time) error {
+	switch dist.State {
+	case common.DistributionStateClosed, common.DistributionStateCancelled:
+		return newError(ErrorCodeDistributionState, "reveals of a distribution in '%s' state can not be scheduled", dist.State)
+	}
+
+	if t != nil && !t.After(now) {
+		return newError(ErrorCodeDistributionInvalid, "revealNotBefore must be in the future, got %s", t.UTC().Format(time.RFC3339))
+	}
+
+	if t != nil && dist.PackTemplate.TeaseNotBefore != nil && !dist.PackTemplate.TeaseNotBefore.Before(*t) {
+		return newError(ErrorCodeDistributionInvalid, "revealNotBefore must be after teaseNotBefore")
+	}
+
+	dist.PackTemplate.RevealNotBefore = t
+
+	return nil
+}
+
+// ScheduleReveals sets when the packs of a distribution can be revealed, see
+// Distribution.SetRevealNotBefore. Reveals (and opens) requested before then
+// are queued, those already queued are moved to the new time, or sent at once
+// if the reveals are opened.
+func (app *App) ScheduleReveals(ctx context.Context, id uuid.UUID, notBefore *time.Time) (*Distribution, error) {
+	var res *Distribution
+
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		distribution, err := GetDistributionWithBuckets(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		if err := distribution.SetRevealNotBefore(notBefore, app.clock.Now()); err != nil {
+			return err
+		}
+
+		if err := UpdateDistribution(tx, distribution); err != nil {
+			return err
+		}
+
+		rescheduled, err := transactions.Reschedule(tx, id, revealTransactions, notBefore)
+		if err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"distID":          id,
+			"revealNotBefore": notBefore,
+			"rescheduled":     rescheduled,
+			"requestID":       requestID(ctx, distribution),
+		}).Info("Distribution reveals scheduled")
+
+		res = distribution
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestDistributionSetRevealNotBefore(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	friday := now.Add(72 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	dist := Distribution{State: common.DistributionStateComplete}
+	if err := dist.SetRevealNotBefore(&friday, now); err != nil {
+		t.Fatal(err)
+	}
+	if dist.PackTemplate.RevealNotBefore == nil || !dist.PackTemplate.RevealNotBefore.Equal(friday) {
+		t.Errorf("expected reveals to open at %s, got %v", friday, dist.PackTemplate.RevealNotBefore)
+	}
+
+	if err := dist.SetRevealNotBefore(&past, now); err == nil {
+		t.Errorf("expected a time in the past to be rejected")
+	}
+
+	if err := dist.SetRevealNotBefore(nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if dist.PackTemplate.RevealNotBefore != nil {
+		t.Errorf("expected reveals to be open, got %v", dist.PackTemplate.RevealNotBefore)
+	}
+
+	dist.PackTemplate.TeaseNotBefore = &friday
+	if err := dist.SetRevealNotBefore(&friday, now); err == nil {
+		t.Errorf("expected a time not after the teased stage to be rejected")
+	}
+
+	for _, state := range []common.DistributionState{common.DistributionStateClosed, common.DistributionStateCancelled} {
+		dist := Distribution{State: state}
+		if err := dist.SetRevealNotBefore(nil, now); err == nil {
+			t.Errorf("expected reveals of a distribution in '%s' state not to be schedulable", state)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Stages of a two-stage reveal, a distribution with a 'TeaseNotBefore'
+// discloses the tier of each slot of its packs from then on (teased) and the
+// full contents of a pack once it is revealed onchain (revealed).
+const (
+	RevealStageTeased   = "teased"
+	RevealStageRevealed = "revealed"
+)
+
+// CollectibleTiers maps the FlowIDs of the collectibles of a bucket to a tier
+// (e.g. rarity), disclosed per slot in the teased stage. Stored as JSON.
+type CollectibleTiers map[int64]string
+
+func (CollectibleTiers) GormDataType() string {
+	return "text"
+}
+
+// Scan collectible tiers from database.
+func (ct *CollectibleTiers) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+		*ct = nil
+		return nil
+	default:
+		return fmt.Errorf("failed to unmarshal CollectibleTiers value: %v", value)
+	}
+	if len(b) == 0 {
+		*ct = nil
+		return nil
+	}
+	return json.Unmarshal(b, ct)
+}
+
+// Convert collectible tiers to database storable format.
+func (ct CollectibleTiers) Value() (driver.Value, error) {
+	if len(ct) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(ct)
+	return string(b), err
+}
+
+// Teased returns true if the slot tiers of packs of the template are
+// disclosed at 'now'.
+func (pt PackTemplate) Teased(now time.Time) bool {
+	return pt.TeaseNotBefore != nil && !now.Before(*pt.TeaseNotBefore)
+}
+
+// Teaser returns the tier of each slot of 'p', empty for collectibles without
+// a tier.
+func (pt PackTemplate) Teaser(p *Pack) []string {
+	tiers := make(map[string]string)
+	for _, bucket := range pt.Buckets {
+		for id, tier := range bucket.CollectibleTiers {
+			c := Collectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: bucket.CollectibleReference}
+			tiers[c.String()] = tier
+		}
+	}
+
+	res := make([]string, len(p.Collectibles))
+	for i, c := range p.Collectibles {
+		res[i] = tiers[c.String()]
+	}
+
+	return res
+}
+
+// PackReveal is what has been disclosed of the contents of a pack.
+type PackReveal struct {
+	Teaser       []string     // Tier per slot, once teased
+	Collectibles Collectibles // Once revealed onchain
+}
+
+// packReveal returns what has been disclosed of 'p' (of 'dist') at 'now'.
+func packReveal(dist *Distribution, p *Pack, now time.Time) *PackReveal {
+	res := &PackReveal{}
+
+	if p.State == common.PackStateInit {
+		// Not minted yet
+		return res
+	}
+
+	if dist.PackTemplate.Teased(now) {
+		res.Teaser = dist.PackTemplate.Teaser(p)
+	}
+
+	switch p.State {
+	case common.PackStateRevealed, common.PackStateOpenRequestHandled, common.PackStateOpened:
+		res.Collectibles = p.Collectibles
+	}
+
+	return res
+}
+
+// RevealEvent is a reveal stage of a pack to be posted to the reveal webhook
+// of its distribution.
+type RevealEvent struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	DistributionID uuid.UUID `gorm:"column:distribution_id;index"`
+	PackID         uuid.UUID `gorm:"column:pack_id"`
+	Stage          string    `gorm:"column:stage"`
+	Handled        bool      `gorm:"column:handled;index"` // Sent or given up on
+	Attempts       uint      `gorm:"column:attempts"`
+}
+
+func (RevealEvent) TableName() string {
+	return "reveal_events"
+}
+
+func (e *RevealEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return nil
+}
+
+// WebhookEvent returns the name of the webhook event of the stage.
+func (e RevealEvent) WebhookEvent() string {
+	return "pack." + e.Stage
+}
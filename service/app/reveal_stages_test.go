@@ -0,0 +1,77 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestPackReveal(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	ref := AddressLocation{Name: "ExampleNFT", Address: common.FlowAddressFromString("0x1")}
+	collectible := func(id int64) Collectible {
+		return Collectible{FlowID: common.FlowID{Int64: id, Valid: true}, ContractReference: ref}
+	}
+
+	buckets := []Bucket{
+		{CollectibleReference: ref, CollectibleTiers: CollectibleTiers{3: "common", 4: "common"}},
+		{CollectibleReference: ref, CollectibleTiers: CollectibleTiers{1: "rare"}},
+	}
+	pack := Pack{State: common.PackStateSealed, Collectibles: Collectibles{collectible(4), collectible(1), collectible(2)}}
+
+	for _, c := range []struct {
+		name           string
+		teaseNotBefore *time.Time
+		state          common.PackState
+		teaser         []string
+		collectibles   bool
+	}{
+		{"not teased", &later, common.PackStateSealed, nil, false},
+		{"no tease", nil, common.PackStateRevealed, nil, true},
+		{"teased", &past, common.PackStateSealed, []string{"common", "rare", ""}, false},
+		{"teased and revealed", &past, common.PackStateOpened, []string{"common", "rare", ""}, true},
+		{"not minted", &past, common.PackStateInit, nil, false},
+	} {
+		dist := &Distribution{PackTemplate: PackTemplate{Buckets: buckets, TeaseNotBefore: c.teaseNotBefore}}
+		p := pack
+		p.State = c.state
+
+		reveal := packReveal(dist, &p, now)
+		if !reflect.DeepEqual(reveal.Teaser, c.teaser) {
+			t.Errorf("%s: expected teaser %v, got %v", c.name, c.teaser, reveal.Teaser)
+		}
+		if got := reveal.Collectibles != nil; got != c.collectibles {
+			t.Errorf("%s: expected collectibles disclosed %t, got %t", c.name, c.collectibles, got)
+		}
+	}
+}
+
+func TestCollectibleTiersScan(t *testing.T) {
+	tiers := CollectibleTiers{1: "rare", 2: "common"}
+
+	v, err := tiers.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned CollectibleTiers
+	if err := scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, tiers) {
+		t.Fatalf("expected %v, got %v", tiers, scanned)
+	}
+
+	empty, err := CollectibleTiers(nil).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scanned.Scan(empty); err != nil || scanned != nil {
+		t.Fatalf("expected empty tiers, got %v (%v)", scanned, err)
+	}
+}
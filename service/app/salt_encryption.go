@@ -0,0 +1,93 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// SaltCipher encrypts the salts of packs at rest with AES-GCM, using a key
+// encryption key (KEK) from configuration. Salts protect the contents of
+// packs not yet revealed, encrypted they are only decrypted to reveal a pack
+// (or export it with salts), so a leaked database does not disclose them.
+// A nil SaltCipher (no KEK configured) stores salts in plain text.
+//
+// Encrypted salts are stored as nonce || ciphertext, authenticated with the
+// commitment hash of the pack so they can not be swapped between packs.
+type SaltCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSaltCipher returns a SaltCipher for the hex encoded AES key 'kek' (16,
+// 24 or 32 bytes), nil if 'kek' is empty.
+func NewSaltCipher(kek string) (*SaltCipher, error) {
+	if kek == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt encryption key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SaltCipher{aead}, nil
+}
+
+// EncryptPack encrypts the salt of 'p', which has to have its commitment hash
+// set. Does nothing without a KEK or if the salt is already encrypted.
+func (c *SaltCipher) EncryptPack(p *Pack) error {
+	if c == nil || p.SaltEncrypted {
+		return nil
+	}
+
+	if p.CommitmentHash.IsEmpty() {
+		return fmt.Errorf("commitmentHash has to be set to encrypt the salt")
+	}
+
+	nonce, err := common.GenerateRandomBytes(c.aead.NonceSize())
+	if err != nil {
+		return err
+	}
+
+	p.Salt = c.aead.Seal(nonce, nonce, p.Salt, p.CommitmentHash)
+	p.SaltEncrypted = true
+
+	return nil
+}
+
+// PackSalt returns the salt of 'p' in plain text, decrypting it if
+// encrypted. 'p' is left as is.
+func (c *SaltCipher) PackSalt(p *Pack) (common.BinaryValue, error) {
+	if !p.SaltEncrypted {
+		return p.Salt, nil
+	}
+
+	if c == nil {
+		return nil, fmt.Errorf("salt of pack %s is encrypted but no salt encryption key is configured", p.ID)
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(p.Salt) < nonceSize {
+		return nil, fmt.Errorf("encrypted salt of pack %s is too short", p.ID)
+	}
+
+	salt, err := c.aead.Open(nil, p.Salt[:nonceSize], p.Salt[nonceSize:], p.CommitmentHash)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting salt of pack %s: %w", p.ID, err)
+	}
+
+	return salt, nil
+}
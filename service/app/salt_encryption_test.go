@@ -0,0 +1,107 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+)
+
+const testSaltEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestSaltCipher(t *testing.T) {
+	c, err := NewSaltCipher(testSaltEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt := common.BinaryValue("0123456789abcdef0123456789abcdef")
+	p := Pack{Salt: append(common.BinaryValue{}, salt...), CommitmentHash: common.BinaryValue("hash")}
+
+	if err := c.EncryptPack(&p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.SaltEncrypted || bytes.Contains(p.Salt, salt) {
+		t.Fatal("expected the salt to be encrypted")
+	}
+
+	decrypted, err := c.PackSalt(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, salt) {
+		t.Errorf("expected the salt back, got %x", decrypted)
+	}
+	if !p.SaltEncrypted {
+		t.Error("expected the pack to keep its encrypted salt")
+	}
+
+	// Encrypted salts are bound to the commitment hash of their pack
+	other := p
+	other.CommitmentHash = common.BinaryValue("other")
+	if _, err := c.PackSalt(&other); err == nil {
+		t.Error("expected an error for a salt of another pack")
+	}
+
+	var none *SaltCipher
+	if _, err := none.PackSalt(&p); err == nil {
+		t.Error("expected an error decrypting without a key")
+	}
+
+	plain := Pack{Salt: salt, CommitmentHash: common.BinaryValue("hash")}
+	if err := none.EncryptPack(&plain); err != nil || plain.SaltEncrypted {
+		t.Errorf("expected salts to stay in plain text without a key, got %v", err)
+	}
+	if s, err := c.PackSalt(&plain); err != nil || !bytes.Equal(s, salt) {
+		t.Errorf("expected a plain text salt as is, got %x (%v)", s, err)
+	}
+}
+
+func TestWithRevealSalt(t *testing.T) {
+	args := make([]cadence.Value, revealSaltArgument+2)
+	for i := range args {
+		args[i] = cadence.UInt64(i)
+	}
+	args[revealSaltArgument] = cadence.String("")
+
+	tx, err := transactions.NewTransaction(REVEAL_SCRIPT, nil, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filled, err := withRevealSalt(tx, cadence.String("0123"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filled[revealSaltArgument] != cadence.String("0123") || filled[revealSaltArgument+1] != cadence.UInt64(revealSaltArgument+1) {
+		t.Errorf("expected only the salt to be filled in, got %v", filled)
+	}
+
+	// Sending with the salt does not store it
+	tx.SetSendArguments(filled)
+	if stored, err := tx.ArgumentsAsCadence(); err != nil || stored[revealSaltArgument] != cadence.String("") {
+		t.Errorf("expected the stored salt to stay empty, got %v (%v)", stored, err)
+	}
+
+	short, err := transactions.NewTransaction(REVEAL_SCRIPT, nil, args[:revealSaltArgument])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := withRevealSalt(short, cadence.String("0123")); err == nil {
+		t.Error("expected an error without a salt argument")
+	}
+}
+
+func TestNewSaltCipher(t *testing.T) {
+	if c, err := NewSaltCipher(""); c != nil || err != nil {
+		t.Errorf("expected no cipher without a key, got %v (%v)", c, err)
+	}
+	if _, err := NewSaltCipher("0102"); err == nil {
+		t.Error("expected an error for a key of invalid length")
+	}
+	if _, err := NewSaltCipher("not hex"); err == nil {
+		t.Error("expected an error for a key which is not hex")
+	}
+}
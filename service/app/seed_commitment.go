@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Commit-reveal distributions (SeedCommitReveal) resolve their packs from a
+// seed anchored on-chain, so the issuer can not pick a seed resulting in
+// packs it prefers:
+//  1. When set up, the PDS commits to a random secret on-chain (its hash)
+//     along with the height of a later block, the anchor block.
+//  2. Once the anchor block is sealed, the seed is derived from the secret
+//     and the ID of the anchor block (see anchoredSeed) and the packs are
+//     resolved again from it before settling.
+//  3. Once settled, the secret is revealed on-chain. Anyone can then derive
+//     the seed and reproduce the packs (see ResolvePacks).
+
+const SEED_SECRET_LENGTH_IN_BYTES = 32
+
+// seedCommitment returns the hash of 'secret' committed to on-chain.
+func seedCommitment(secret []byte) []byte {
+	hash := sha256.Sum256(secret)
+	return hash[:]
+}
+
+// anchoredSeed derives the resolve seed of a commit-reveal distribution from
+// its secret and the ID of its anchor block: the first 8 bytes (big endian)
+// of SHA2-256(secret || blockID).
+func anchoredSeed(secret []byte, blockID flow.Identifier) int64 {
+	hash := sha256.Sum256(append(append([]byte{}, secret...), blockID[:]...))
+	return int64(binary.BigEndian.Uint64(hash[:8]))
+}
+
+// anchoredSeedMatches returns true if the packs of the commit-reveal
+// distribution were resolved from the seed derived from its secret and
+// anchor block.
+func anchoredSeedMatches(dist *Distribution) bool {
+	if !dist.SeedAnchored() {
+		return false
+	}
+	return anchoredSeed(dist.SeedSecret, flow.HexToID(dist.SeedAnchorBlockID)) == dist.ResolveSeed
+}
+
+// SeedAnchored returns true if the packs of the distribution are resolved
+// from its anchored seed, always true if it does not use commit-reveal.
+func (dist *Distribution) SeedAnchored() bool {
+	return !dist.SeedCommitReveal || dist.SeedAnchorBlockID != ""
+}
+
+// commitSeed generates the secret of a commit-reveal distribution and
+// commits to it on-chain, along with an anchor block 'SeedAnchorDelay' blocks
+// after the latest sealed one.
+func (svc *ContractService) commitSeed(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, dist *Distribution) error {
+	secret, err := common.GenerateRandomBytes(SEED_SECRET_LENGTH_IN_BYTES)
+	if err != nil {
+		return err
+	}
+
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	anchorHeight := latestBlockHeader.Height + svc.cfg.SeedAnchorDelay
+
+	txScript, err := flow_helpers.ParseCadenceTemplate(COMMIT_SEED_SCRIPT, nil)
+	if err != nil {
+		return err
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.String(hex.EncodeToString(seedCommitment(secret))),
+		cadence.UInt64(anchorHeight),
+	}
+
+	t, err := transactions.NewTransactionWithDistributionID(COMMIT_SEED_SCRIPT, txScript, arguments, dist.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.sendAndWaitForSeal(ctx, db, flowClient, t); err != nil {
+		return err
+	}
+
+	if t.State != common.TransactionStateComplete {
+		return fmt.Errorf("seed commitment transaction %s did not complete: %s", t.TransactionID, t.Error)
+	}
+
+	dist.SeedSecret = secret
+	dist.SeedAnchorHeight = anchorHeight
+
+	return nil
+}
+
+// anchorBlockID returns the ID of the block at 'height' and true, or false if
+// it is not there yet.
+func (svc *ContractService) anchorBlockID(ctx context.Context, flowClient flow_helpers.FlowClient, height uint64) (flow.Identifier, bool, error) {
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return flow.EmptyID, false, err
+	}
+	if latestBlockHeader.Height < height {
+		return flow.EmptyID, false, nil
+	}
+
+	script, err := flow_helpers.ParseCadenceTemplate(BLOCK_ID_SCRIPT, nil)
+	if err != nil {
+		return flow.EmptyID, false, err
+	}
+
+	value, err := flowClient.ExecuteScriptAtLatestBlock(ctx, script, []cadence.Value{cadence.UInt64(height)})
+	if err != nil {
+		return flow.EmptyID, false, err
+	}
+
+	optional, ok := value.(cadence.Optional)
+	if !ok {
+		return flow.EmptyID, false, fmt.Errorf("unexpected script result for block %d: %v", height, value)
+	}
+	if optional.Value == nil {
+		return flow.EmptyID, false, nil
+	}
+
+	array, ok := optional.Value.(cadence.Array)
+	if !ok || len(array.Values) != len(flow.EmptyID) {
+		return flow.EmptyID, false, fmt.Errorf("unexpected script result for block %d: %v", height, value)
+	}
+
+	var id flow.Identifier
+	for i, v := range array.Values {
+		b, ok := v.(cadence.UInt8)
+		if !ok {
+			return flow.EmptyID, false, fmt.Errorf("unexpected script result for block %d: %v", height, value)
+		}
+		id[i] = byte(b)
+	}
+
+	return id, true, nil
+}
+
+// resolveAnchoredSeed resolves the packs of a commit-reveal distribution
+// again from its anchored seed once the anchor block is sealed. Returns false
+// if the anchor block is not sealed yet.
+func (svc *ContractService) resolveAnchoredSeed(ctx context.Context, db *gorm.DB, flowClient flow_helpers.FlowClient, dist *Distribution) (bool, error) {
+	if dist.SeedAnchored() {
+		return true, nil
+	}
+
+	blockID, ok, err := svc.anchorBlockID(ctx, flowClient, dist.SeedAnchorHeight)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// The distribution may have been listed without its buckets
+	withBuckets, err := GetDistributionWithBuckets(db, dist.ID)
+	if err != nil {
+		return false, err
+	}
+
+	hasher, err := GetCommitmentHasher(dist.CommitmentHashVersion)
+	if err != nil {
+		return false, err
+	}
+
+	seed := anchoredSeed(dist.SeedSecret, blockID)
+
+	contents, err := ResolvePacks(withBuckets.PackTemplate, seed, ResolveAlgorithmVersion)
+	if err != nil {
+		return false, err
+	}
+
+	packs := []Pack{}
+	if err := db.Where(&Pack{DistributionID: dist.ID}).Find(&packs).Error; err != nil {
+		return false, err
+	}
+
+	if len(packs) != len(contents) {
+		return false, fmt.Errorf("distribution has %d packs, %d resolved from its anchored seed", len(packs), len(contents))
+	}
+
+	for i := range packs {
+		packs[i].Collectibles = contents[i]
+		packs[i].Salt = nil
+		packs[i].SaltEncrypted = false
+		packs[i].CommitmentHash = nil
+		if err := packs[i].SetCommitmentHash(hasher); err != nil {
+			return false, fmt.Errorf("error while hashing pack %d: %w", i+1, err)
+		}
+		if err := svc.salts.EncryptPack(&packs[i]); err != nil {
+			return false, err
+		}
+		if err := UpdatePack(db, &packs[i]); err != nil {
+			return false, err
+		}
+	}
+
+	dist.ResolveSeed = seed
+	dist.ResolveAlgorithm = ResolveAlgorithmVersion
+	dist.SeedAnchorBlockID = blockID.Hex()
+
+	log.WithFields(log.Fields{
+		"distID":      dist.ID,
+		"distFlowID":  dist.FlowID,
+		"anchorBlock": dist.SeedAnchorBlockID,
+	}).Info("Resolved packs from anchored seed")
+
+	return true, nil
+}
+
+// newRevealSeedTransaction returns a transaction revealing the secret of a
+// commit-reveal distribution on-chain.
+func newRevealSeedTransaction(dist *Distribution) (*transactions.StorableTransaction, error) {
+	txScript, err := flow_helpers.ParseCadenceTemplate(REVEAL_SEED_SCRIPT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := []cadence.Value{
+		cadence.UInt64(dist.FlowID.Int64),
+		cadence.String(hex.EncodeToString(dist.SeedSecret)),
+	}
+
+	return transactions.NewTransactionWithDistributionID(REVEAL_SEED_SCRIPT, txScript, arguments, dist.ID)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestAnchoredSeed(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	blockA := flow.HexToID("0a")
+	blockB := flow.HexToID("0b")
+
+	if anchoredSeed(secret, blockA) != anchoredSeed(secret, blockA) {
+		t.Error("expected the same secret and block to derive the same seed")
+	}
+	if anchoredSeed(secret, blockA) == anchoredSeed(secret, blockB) {
+		t.Error("expected another anchor block to derive another seed")
+	}
+
+	// SHA2-256 of the secret, as checked by PDS.revealSeed
+	if got := hex.EncodeToString(seedCommitment([]byte{})); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("unexpected commitment '%s'", got)
+	}
+}
+
+func TestAnchoredSeedMatches(t *testing.T) {
+	secret := common.BinaryValue("0123456789abcdef0123456789abcdef")
+	blockID := flow.HexToID("0a")
+
+	d := Distribution{SeedCommitReveal: true, SeedSecret: secret, SeedAnchorHeight: 100}
+	if d.SeedAnchored() || anchoredSeedMatches(&d) {
+		t.Error("expected the seed not to be anchored before the anchor block is known")
+	}
+
+	d.SeedAnchorBlockID = blockID.Hex()
+	d.ResolveSeed = anchoredSeed(secret, blockID)
+	if !d.SeedAnchored() || !anchoredSeedMatches(&d) {
+		t.Error("expected the seed to derive from the secret and anchor block")
+	}
+
+	d.ResolveSeed++
+	if anchoredSeedMatches(&d) {
+		t.Error("expected a seed not derived from the anchor block not to match")
+	}
+
+	if !(&Distribution{}).SeedAnchored() {
+		t.Error("expected distributions without commit-reveal to count as anchored")
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// tokenBucket allows 'rate' events per second with bursts of up to 'burst'.
+// Tokens can be reserved ahead, the balance then goes negative and later
+// reservations wait longer.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst int, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: float64(rate), burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// reserve takes a token at 'now' and returns how long to wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// SendLimiter limits the rate of sent transactions per proposer (and payer)
+// account, with a global cap over all accounts so access node limits are
+// respected in total. A rate of 0 disables that limit.
+type SendLimiter struct {
+	mu          sync.Mutex
+	clock       common.Clock
+	burst       int
+	accountRate int
+	global      *tokenBucket
+	accounts    map[flow.Address]*tokenBucket
+}
+
+func NewSendLimiter(clock common.Clock, globalRate, accountRate, burst int) *SendLimiter {
+	l := &SendLimiter{
+		clock:       clock,
+		burst:       burst,
+		accountRate: accountRate,
+		accounts:    make(map[flow.Address]*tokenBucket),
+	}
+	if globalRate > 0 {
+		l.global = newTokenBucket(globalRate, burst, clock.Now())
+	}
+	return l
+}
+
+// Take blocks until a transaction proposed or paid by 'accounts' can be sent.
+func (l *SendLimiter) Take(accounts ...flow.Address) {
+	if wait := l.reserve(accounts); wait > 0 {
+		l.clock.Sleep(wait)
+	}
+}
+
+func (l *SendLimiter) reserve(accounts []flow.Address) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+
+	var wait time.Duration
+	if l.global != nil {
+		if w := l.global.reserve(now); w > 0 {
+			metrics.ObserveSendThrottle(metrics.ThrottleGlobal, "", w)
+			wait = w
+		}
+	}
+
+	if l.accountRate <= 0 {
+		return wait
+	}
+
+	seen := make(map[flow.Address]bool, len(accounts))
+	for _, a := range accounts {
+		if seen[a] {
+			continue
+		}
+		seen[a] = true
+
+		b, ok := l.accounts[a]
+		if !ok {
+			b = newTokenBucket(l.accountRate, l.burst, now)
+			l.accounts[a] = b
+		}
+
+		if w := b.reserve(now); w > 0 {
+			metrics.ObserveSendThrottle(metrics.ThrottleAccount, a.Hex(), w)
+			if w > wait {
+				wait = w
+			}
+		}
+	}
+
+	return wait
+}
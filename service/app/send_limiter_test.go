@@ -0,0 +1,60 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	b := newTokenBucket(10, 2, now)
+
+	// Burst
+	for i := 0; i < 2; i++ {
+		if w := b.reserve(now); w != 0 {
+			t.Fatalf("expected no wait within burst, got %s", w)
+		}
+	}
+
+	// Reservations queue up
+	if w := b.reserve(now); w != 100*time.Millisecond {
+		t.Fatalf("expected to wait 100ms, got %s", w)
+	}
+	if w := b.reserve(now); w != 200*time.Millisecond {
+		t.Fatalf("expected to wait 200ms, got %s", w)
+	}
+
+	// Refills up to burst only
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if w := b.reserve(now); w != 0 {
+			t.Fatalf("expected no wait after refill, got %s", w)
+		}
+	}
+	if w := b.reserve(now); w == 0 {
+		t.Fatal("expected to wait after burst")
+	}
+}
+
+func TestSendLimiterPerAccount(t *testing.T) {
+	clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	l := NewSendLimiter(clock, 100, 1, 1)
+
+	alice := flow.HexToAddress("0x1")
+	bob := flow.HexToAddress("0x2")
+
+	if w := l.reserve([]flow.Address{alice}); w != 0 {
+		t.Fatalf("expected no wait for the first send, got %s", w)
+	}
+
+	if w := l.reserve([]flow.Address{bob, bob}); w != 10*time.Millisecond {
+		t.Fatalf("expected bob to wait only for the global limit, got %s", w)
+	}
+
+	if w := l.reserve([]flow.Address{alice}); w != time.Second {
+		t.Fatalf("expected alice to wait for the account limit, got %s", w)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
 	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk/client"
 	"gorm.io/gorm"
 )
 
@@ -31,6 +33,7 @@ type SettlementCollectible struct {
 	FlowID            common.FlowID   `gorm:"column:flow_id;"`                       // ID of the collectible NFT
 	ContractReference AddressLocation `gorm:"embedded;embeddedPrefix:contract_ref_"` // Reference to the collectible NFT contract
 	IsSettled         bool            `gorm:"column:is_settled"`
+	IsQueued          bool            `gorm:"column:is_queued"` // True once included in a settle transaction
 }
 
 type SettlementCollectibles []SettlementCollectible
@@ -91,3 +94,45 @@ func (cc SettlementCollectibles) GroupByContract() map[AddressLocation]Settlemen
 	}
 	return res
 }
+
+// escrowDepositIDs returns the IDs of the collectibles deposited to 'escrow'
+// by the Deposit events in 'blockEvents', each ID once.
+func escrowDepositIDs(blockEvents []client.BlockEvents, escrow common.FlowAddress) ([]int64, error) {
+	res := []int64{}
+	seen := map[int64]bool{}
+
+	for _, be := range blockEvents {
+		for _, e := range be.Events {
+			evtValueMap := flow_helpers.EventValuesToMap(e)
+
+			collectibleFlowIDCadence, ok := evtValueMap["id"]
+			if !ok {
+				return nil, fmt.Errorf("could not read 'id' from event %s", e)
+			}
+
+			collectibleFlowID, err := common.FlowIDFromCadence(collectibleFlowIDCadence)
+			if err != nil {
+				return nil, err
+			}
+
+			addressCadence, ok := evtValueMap["to"]
+			if !ok {
+				return nil, fmt.Errorf("could not read 'to' from event %s", e)
+			}
+
+			address, err := common.FlowAddressFromCadence(addressCadence)
+			if err != nil {
+				return nil, err
+			}
+
+			if address != escrow || seen[collectibleFlowID.Int64] {
+				continue
+			}
+
+			seen[collectibleFlowID.Int64] = true
+			res = append(res, collectibleFlowID.Int64)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+)
+
+func depositEvent(id uint64, to flow.Address) flow.Event {
+	eventType := &cadence.EventType{
+		Fields: []cadence.Field{
+			{Identifier: "id", Type: cadence.UInt64Type{}},
+			{Identifier: "to", Type: cadence.OptionalType{Type: cadence.AddressType{}}},
+		},
+	}
+	values := []cadence.Value{
+		cadence.UInt64(id),
+		cadence.NewOptional(cadence.NewAddress(to)),
+	}
+	return flow.Event{Type: "A.0000000000000002.Moment.Deposit", Value: cadence.NewEvent(values).WithType(eventType)}
+}
+
+func TestEscrowDepositIDs(t *testing.T) {
+	escrow := flow.HexToAddress("0x3")
+	issuer := flow.HexToAddress("0x1")
+
+	blockEvents := []client.BlockEvents{
+		{Height: 10, Events: []flow.Event{depositEvent(1, escrow), depositEvent(2, issuer)}},
+		{Height: 11, Events: []flow.Event{depositEvent(3, escrow), depositEvent(1, escrow)}},
+	}
+
+	ids, err := escrowDepositIDs(blockEvents, common.FlowAddress(escrow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deposits to other accounts are ignored, redeposits counted once
+	if expected := []int64{1, 3}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected the IDs %v, got %v", expected, ids)
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+const maxRequestNonceLength = 100
+
+var ErrRequestReplayed = &Error{Code: ErrorCodeReplayed, Err: errors.New("request nonce already used")}
+
+// SignRequest returns the signature of a request of an issuer signed with
+// its shared (callback) secret, the same as SignCallback over 'timestamp',
+// 'nonce', 'method' and 'uri' (path and query, e.g.
+// '/v1/distributions?issuer=0x1') joined by '.'.
+func SignRequest(secret, timestamp, nonce, method, uri string, body []byte) (string, error) {
+	return SignCallback(secret, signedRequestPrefix(timestamp, nonce, method, uri), body)
+}
+
+// VerifyRequest is like VerifyCallback for a request signed with
+// SignRequest.
+func VerifyRequest(secret, timestamp, nonce, method, uri, signature string, body []byte, now time.Time, maxSkew time.Duration) (time.Time, error) {
+	if nonce == "" || len(nonce) > maxRequestNonceLength {
+		return time.Time{}, fmt.Errorf("%w: nonce is required and can be at most %d characters", ErrCallbackUnauthorized, maxRequestNonceLength)
+	}
+
+	return verifySigned(secret, timestamp, signedRequestPrefix(timestamp, nonce, method, uri), signature, body, now, maxSkew)
+}
+
+// signedRequestPrefix returns what is signed before the body.
+func signedRequestPrefix(timestamp, nonce, method, uri string) string {
+	return strings.Join([]string{timestamp, nonce, strings.ToUpper(method), uri}, ".")
+}
+
+// nonceCache remembers the nonces of signed requests until their timestamp
+// falls out of the accepted window, so a captured request can not be
+// replayed. Nonces are kept in memory, per instance of the PDS.
+type nonceCache struct {
+	mu          sync.Mutex
+	nonces      map[string]time.Time // Expiry by issuer and nonce
+	lastExpired time.Time
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{nonces: make(map[string]time.Time)}
+}
+
+// Use records 'nonce' of 'issuer' as used until 'expires'. Returns
+// ErrRequestReplayed if it was used before.
+func (c *nonceCache) Use(issuer common.FlowAddress, nonce string, now, expires time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired nonces now and then
+	if now.Sub(c.lastExpired) > time.Minute {
+		for k, e := range c.nonces {
+			if !e.After(now) {
+				delete(c.nonces, k)
+			}
+		}
+		c.lastExpired = now
+	}
+
+	key := issuer.String() + "/" + nonce
+	if e, ok := c.nonces[key]; ok && e.After(now) {
+		return ErrRequestReplayed
+	}
+
+	c.nonces[key] = expires
+	return nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	secret, err := newCallbackSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"issuer":"0x1"}`)
+
+	sig, err := SignRequest(secret, ts, "n1", "POST", "/v1/distributions", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyRequest(secret, ts, "n1", "post", "/v1/distributions", sig, body, now, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name   string
+		nonce  string
+		method string
+		path   string
+	}{
+		{"other nonce", "n2", "POST", "/v1/distributions"},
+		{"other method", "n1", "PUT", "/v1/distributions"},
+		{"other path", "n1", "POST", "/v1/collections"},
+		{"no nonce", "", "POST", "/v1/distributions"},
+	} {
+		if _, err := VerifyRequest(secret, ts, c.nonce, c.method, c.path, sig, body, now, time.Minute); !errors.Is(err, ErrCallbackUnauthorized) {
+			t.Errorf("%s: expected ErrCallbackUnauthorized, got %v", c.name, err)
+		}
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	c := newNonceCache()
+	issuer := common.FlowAddress(flow.HexToAddress("0x1"))
+	other := common.FlowAddress(flow.HexToAddress("0x2"))
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := c.Use(issuer, "n1", now, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Use(issuer, "n1", now.Add(time.Second), now.Add(time.Minute)); !errors.Is(err, ErrRequestReplayed) {
+		t.Fatalf("expected ErrRequestReplayed, got %v", err)
+	}
+
+	if err := c.Use(other, "n1", now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("expected nonces to be per issuer, got %v", err)
+	}
+
+	if err := c.Use(issuer, "n1", now.Add(2*time.Minute), now.Add(3*time.Minute)); err != nil {
+		t.Fatalf("expected an expired nonce to be accepted, got %v", err)
+	}
+}
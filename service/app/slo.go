@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SLOGuard tracks a latency SLO and tells whether the service is degraded,
+// i.e. should shed lower priority work to keep user facing operations
+// responsive. The service degrades as soon as a latency above the target is
+// observed and recovers once no latency above it was seen for 'hold', so a
+// latency hovering around the target does not flip it back and forth.
+// A target of 0 disables the guard.
+type SLOGuard struct {
+	mu         sync.Mutex
+	target     time.Duration
+	hold       time.Duration
+	degraded   bool
+	lastBreach time.Time
+}
+
+func NewSLOGuard(target, hold time.Duration) *SLOGuard {
+	return &SLOGuard{target: target, hold: hold}
+}
+
+// Observe records 'latency' observed at 'now' and returns true if the
+// degraded state changed.
+func (g *SLOGuard) Observe(latency time.Duration, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.target <= 0 {
+		return false
+	}
+
+	if latency > g.target {
+		g.lastBreach = now
+		if !g.degraded {
+			g.degraded = true
+			return true
+		}
+		return false
+	}
+
+	if g.degraded && now.Sub(g.lastBreach) >= g.hold {
+		g.degraded = false
+		return true
+	}
+
+	return false
+}
+
+// Degraded returns true while lower priority work should be shed.
+func (g *SLOGuard) Degraded() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.degraded
+}
+
+// batchSize returns the size of new batches of 'sizer', halved while the
+// service is degraded so the batches leave room for user facing transactions.
+func (svc *ContractService) batchSize(sizer *BatchSizer) int {
+	size := sizer.Size()
+	if svc.slo.Degraded() && size > 1 {
+		size /= 2
+	}
+	return size
+}
+
+// pendingLatency returns how long the longest waiting user facing transaction
+// has been waiting at 'now', 0 if there is none. Scheduled transactions (e.g.
+// reveals waiting for a time lock) wait from when they are first due,
+// retries count from when the transaction was created.
+func pendingLatency(db *gorm.DB, now time.Time) (time.Duration, error) {
+	t, err := transactions.GetOldestPending(db, now, transactions.PriorityUserFacing)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	since := t.CreatedAt
+	if t.RetryCount == 0 && t.SendNotBefore != nil && t.SendNotBefore.After(since) {
+		since = *t.SendNotBefore
+	}
+
+	return now.Sub(since), nil
+}
+
+// handleSLOs checks the user facing latency SLO and degrades or recovers the
+// service accordingly.
+func handleSLOs(ctx context.Context, app *App) error {
+	if app.cfg.UserFacingLatencySLO <= 0 {
+		return nil
+	}
+
+	now := app.clock.Now()
+
+	latency, err := pendingLatency(app.db, now)
+	if err != nil {
+		return err
+	}
+
+	metrics.SetSLOLatency(metrics.SLOUserFacingLatency, latency)
+
+	if !app.service.slo.Observe(latency, now) {
+		return nil
+	}
+
+	degraded := app.service.slo.Degraded()
+	metrics.SetSLOBreached(metrics.SLOUserFacingLatency, degraded)
+
+	logger := log.WithFields(log.Fields{
+		"slo":     metrics.SLOUserFacingLatency,
+		"target":  app.cfg.UserFacingLatencySLO,
+		"latency": latency,
+	})
+
+	if degraded {
+		logger.Error("Latency SLO breached, shedding lower priority work")
+	} else {
+		logger.Info("Latency SLO recovered, resuming lower priority work")
+	}
+
+	return nil
+}
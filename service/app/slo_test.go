@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLOGuard(t *testing.T) {
+	g := NewSLOGuard(time.Minute, 30*time.Second)
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	if g.Observe(10*time.Second, now) || g.Degraded() {
+		t.Fatal("expected the guard not to degrade below the target")
+	}
+
+	if !g.Observe(2*time.Minute, now) || !g.Degraded() {
+		t.Fatal("expected the guard to degrade above the target")
+	}
+
+	if g.Observe(3*time.Minute, now.Add(time.Second)) {
+		t.Fatal("expected no change while breached")
+	}
+
+	// Held after the last breach
+	if g.Observe(0, now.Add(20*time.Second)) || !g.Degraded() {
+		t.Fatal("expected the guard to stay degraded during the hold")
+	}
+
+	if !g.Observe(0, now.Add(31*time.Second)) || g.Degraded() {
+		t.Fatal("expected the guard to recover after the hold")
+	}
+}
+
+func TestSLOGuardDisabled(t *testing.T) {
+	g := NewSLOGuard(0, time.Minute)
+
+	if g.Observe(time.Hour, time.Now()) || g.Degraded() {
+		t.Fatal("expected a disabled guard never to degrade")
+	}
+}
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"errors"
+	"time"
+
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -11,6 +15,9 @@ func Migrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(&Distribution{}, &Bucket{}, &Pack{}); err != nil {
 		return err
 	}
+	if err := db.AutoMigrate(&ArchivedPack{}); err != nil {
+		return err
+	}
 	if err := db.AutoMigrate(&Settlement{}, &SettlementCollectible{}); err != nil {
 		return err
 	}
@@ -20,6 +27,61 @@ func Migrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(&CirculatingPackContract{}); err != nil {
 		return err
 	}
+	if err := db.AutoMigrate(&OwnershipVerification{}, &OwnershipDiscrepancy{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&GiftIntent{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&CompletionReport{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&KeyRotation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&IssuerBranding{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&PublicStatsOptIn{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&RevealEvent{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&IssuerCallbackSecret{}, &IssuerCallback{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&IDCounter{}, &IDReservation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&Collection{}); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&DistributionTemplate{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&APIKey{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&IssuerWebhook{}, &WebhookDelivery{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&IdempotencyKey{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&DistributionCancellation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&Issuer{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&PackReplacement{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&CollectibleOwnershipCheck{}, &MissingCollectible{}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -63,15 +125,77 @@ func UpdateDistribution(db *gorm.DB, d *Distribution) error {
 	return db.Omit(clause.Associations).Save(d).Error
 }
 
+// Update a distribution and replace its buckets and packs, e.g. after it was
+// resolved again
+func ReplaceDistributionTemplate(db *gorm.DB, d *Distribution, batchSize int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where(&Bucket{DistributionID: d.ID}).Delete(&Bucket{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where(&Pack{DistributionID: d.ID}).Delete(&Pack{}).Error; err != nil {
+			return err
+		}
+
+		if err := UpdateDistribution(tx, d); err != nil {
+			return err
+		}
+
+		for i := range d.PackTemplate.Buckets {
+			d.PackTemplate.Buckets[i].DistributionID = d.ID
+		}
+
+		for i := range d.Packs {
+			d.Packs[i].DistributionID = d.ID
+		}
+
+		if err := tx.Omit(clause.Associations).CreateInBatches(d.PackTemplate.Buckets, batchSize).Error; err != nil {
+			return err
+		}
+
+		return tx.Omit(clause.Associations).CreateInBatches(d.Packs, batchSize).Error
+	})
+}
+
 // List distributions
-func ListDistributions(db *gorm.DB, opt ListOptions) ([]Distribution, error) {
+func ListDistributions(db *gorm.DB, filter DistributionFilter, opt ListOptions) ([]Distribution, error) {
+	list := []Distribution{}
+	if err := filter.where(db.Omit(clause.Associations)).Order(filter.order()).Limit(opt.Limit).Offset(opt.Offset).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// List distributions after a cursor (from the start if nil)
+func ListDistributionsAfter(db *gorm.DB, filter DistributionFilter, cursor *DistributionCursor, limit int) ([]Distribution, error) {
 	list := []Distribution{}
-	if err := db.Omit(clause.Associations).Order("created_at desc").Limit(opt.Limit).Offset(opt.Offset).Find(&list).Error; err != nil {
+	q := filter.where(db.Omit(clause.Associations))
+	if cursor != nil {
+		q = q.Where(filter.afterCondition(), cursor.Time, cursor.Time, cursor.ID)
+	}
+	if err := q.Order(filter.order()).Limit(limit).Find(&list).Error; err != nil {
 		return nil, err
 	}
 	return list, nil
 }
 
+// Count distributions matching a filter
+func CountDistributions(db *gorm.DB, filter DistributionFilter) (int64, error) {
+	var count int64
+	return count, filter.where(db.Model(&Distribution{})).Count(&count).Error
+}
+
+// List distributions of a collection
+func ListCollectionDistributions(db *gorm.DB, collectionID uuid.UUID, opt ListOptions) ([]Distribution, error) {
+	list := []Distribution{}
+	return list, db.Omit(clause.Associations).
+		Where("collection_id = ?", collectionID).
+		Order("created_at desc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
 // Get distribution
 func GetDistributionBig(db *gorm.DB, id uuid.UUID) (*Distribution, error) {
 	distribution := Distribution{}
@@ -89,9 +213,26 @@ func GetDistributionSmall(db *gorm.DB, id uuid.UUID) (*Distribution, error) {
 	return &distribution, nil
 }
 
+// Get distribution with its buckets but without packs
+func GetDistributionWithBuckets(db *gorm.DB, id uuid.UUID) (*Distribution, error) {
+	distribution := Distribution{}
+	if err := db.Omit(clause.Associations).Preload("Buckets").First(&distribution, id).Error; err != nil {
+		return nil, err
+	}
+	return &distribution, nil
+}
+
+// Get the Distributions with 'ids' including their buckets, those not found
+// are left out
+func ListDistributionsWithBuckets(db *gorm.DB, ids []uuid.UUID) ([]Distribution, error) {
+	list := []Distribution{}
+	return list, db.Omit(clause.Associations).Preload("Buckets").Where("id IN ?", ids).Find(&list).Error
+}
+
 type BucketSmall struct {
 	ID                   uuid.UUID       `gorm:"column:id;primary_key;type:uuid;"`
 	CollectibleReference AddressLocation `gorm:"embedded;embeddedPrefix:collectible_ref_"`
+	CollectibleCount     uint            `gorm:"column:collectible_count"`
 }
 
 func GetDistributionBucketsSmall(db *gorm.DB, distributionID uuid.UUID) ([]BucketSmall, error) {
@@ -111,6 +252,12 @@ func GetPack(db *gorm.DB, id uuid.UUID) (*Pack, error) {
 	return &pack, nil
 }
 
+// Get the Packs with 'ids', those not found are left out
+func ListPacks(db *gorm.DB, ids []uuid.UUID) ([]Pack, error) {
+	list := []Pack{}
+	return list, db.Where("id IN ?", ids).Find(&list).Error
+}
+
 // Get Packs for a Distribution and process in batches of 'batchSize'
 func DistributionPacksInBatches(db *gorm.DB, distributionID uuid.UUID, batchSize int, processBatch func(tx *gorm.DB, batchNumber int, batch []Pack) error) error {
 	batch := []Pack{}
@@ -131,6 +278,24 @@ func GetMintingPack(db *gorm.DB, commitmentHash common.BinaryValue) (*Pack, erro
 	return &pack, nil
 }
 
+// GetPackByCommitmentHash returns a pack by its commitmentHash
+func GetPackByCommitmentHash(db *gorm.DB, commitmentHash common.BinaryValue) (*Pack, error) {
+	pack := Pack{}
+	if err := db.Where(&Pack{CommitmentHash: commitmentHash}).First(&pack).Error; err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// Get Packs with FlowID 'id' over all pack contracts, at most 'limit'
+func GetPacksByFlowID(db *gorm.DB, id common.FlowID, limit int) ([]Pack, error) {
+	list := []Pack{}
+	if err := db.Where(&Pack{FlowID: id}).Order("created_at ASC").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
 func GetPackByContractAndFlowID(db *gorm.DB, ref AddressLocation, id common.FlowID) (*Pack, error) {
 	pack := Pack{}
 	if err := db.Where(&Pack{ContractReference: ref, FlowID: id}).First(&pack).Error; err != nil {
@@ -139,6 +304,14 @@ func GetPackByContractAndFlowID(db *gorm.DB, ref AddressLocation, id common.Flow
 	return &pack, nil
 }
 
+func GetPackByDistributionAndFlowID(db *gorm.DB, distributionID uuid.UUID, id common.FlowID) (*Pack, error) {
+	pack := Pack{}
+	if err := db.Omit(clause.Associations).Where(&Pack{DistributionID: distributionID, FlowID: id}).First(&pack).Error; err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
 func UpdatePack(db *gorm.DB, d *Pack) error {
 	return db.Omit(clause.Associations).Save(d).Error
 }
@@ -170,6 +343,163 @@ func UpdateSettlementCollectible(db *gorm.DB, d *SettlementCollectible) error {
 	return db.Omit(clause.Associations).Save(d).Error
 }
 
+// Mark SettlementCollectibles as included in a settle transaction
+func SetSettlementCollectiblesQueued(db *gorm.DB, cc SettlementCollectibles) error {
+	ids := make([]uuid.UUID, len(cc))
+	for i, c := range cc {
+		ids[i] = c.ID
+	}
+	return db.Model(&SettlementCollectible{}).Where("id IN ?", ids).Update("is_queued", true).Error
+}
+
+// Mark SettlementCollectibles as settled
+func SetSettlementCollectiblesSettled(db *gorm.DB, cc SettlementCollectibles) error {
+	if len(cc) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(cc))
+	for i, c := range cc {
+		ids[i] = c.ID
+	}
+	return db.Model(&SettlementCollectible{}).Where("id IN ?", ids).Update("is_settled", true).Error
+}
+
+// Mark the not settled SettlementCollectibles of a Settlement as not included in a settle transaction
+func ResetSettlementCollectiblesQueued(db *gorm.DB, settlementId uuid.UUID) (int64, error) {
+	res := db.Model(&SettlementCollectible{}).
+		Where("settlement_id = ? AND is_queued = ? AND is_settled = ?", settlementId, true, false).
+		Update("is_queued", false)
+	return res.RowsAffected, res.Error
+}
+
+// Mark Packs as included in a mint transaction
+func SetPacksMintQueued(db *gorm.DB, pp []Pack) error {
+	ids := make([]uuid.UUID, len(pp))
+	for i, p := range pp {
+		ids[i] = p.ID
+	}
+	return db.Model(&Pack{}).Where("id IN ?", ids).Update("mint_queued", true).Error
+}
+
+// Mark the not minted Packs of a Distribution as not included in a mint transaction
+func ResetPacksMintQueued(db *gorm.DB, distributionID uuid.UUID) (int64, error) {
+	res := db.Model(&Pack{}).
+		Where("distribution_id = ? AND mint_queued = ? AND state = ?", distributionID, true, common.PackStateInit).
+		Update("mint_queued", false)
+	return res.RowsAffected, res.Error
+}
+
+// Get Packs of a Distribution not yet included in a mint transaction, at most 'limit'
+func NotQueuedMintPacks(db *gorm.DB, distributionID uuid.UUID, limit int) ([]Pack, error) {
+	list := []Pack{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("distribution_id = ? AND mint_queued = ? AND state = ?", distributionID, false, common.PackStateInit).
+		Order("created_at asc, id asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// List the Packs of a Distribution by commitment hash which are not yet
+// minted
+func ListMintingPacksByCommitmentHashes(db *gorm.DB, distributionID uuid.UUID, hashes []common.BinaryValue) ([]Pack, error) {
+	list := []Pack{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("distribution_id = ? AND state = ? AND commitment_hash IN ?", distributionID, common.PackStateInit, hashes).
+		Find(&list).Error
+}
+
+// Count the Packs of a Distribution
+func CountDistributionPacks(db *gorm.DB, distributionID uuid.UUID) (int64, error) {
+	var count int64
+	return count, db.Model(&Pack{}).Where(&Pack{DistributionID: distributionID}).Count(&count).Error
+}
+
+// List the public fields of Packs of a Distribution in any of 'states' (all
+// if empty), in order of creation
+func ListDistributionPacks(db *gorm.DB, distributionID uuid.UUID, states []common.PackState, opt ListOptions) ([]Pack, error) {
+	list := []Pack{}
+	return list, packsInStates(db.Omit(clause.Associations), distributionID, states).
+		Select("id", "distribution_id", "created_at", "updated_at", "flow_id", "state", "commitment_hash").
+		Order("created_at asc, id asc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Count the Packs of a Distribution in any of 'states' (all if empty)
+func CountDistributionPacksInStates(db *gorm.DB, distributionID uuid.UUID, states []common.PackState) (int64, error) {
+	var count int64
+	return count, packsInStates(db.Model(&Pack{}), distributionID, states).Count(&count).Error
+}
+
+// Count the Packs of a Distribution in none of 'states'
+func CountDistributionPacksNotInStates(db *gorm.DB, distributionID uuid.UUID, states []common.PackState) (int64, error) {
+	var count int64
+	return count, db.Model(&Pack{}).Where(&Pack{DistributionID: distributionID}).Where("state NOT IN ?", states).Count(&count).Error
+}
+
+// Move the Packs of a Distribution to the archived packs in batches of 'batchSize'
+func ArchiveDistributionPacks(db *gorm.DB, distributionID uuid.UUID, batchSize int) error {
+	batch := []Pack{}
+	err := db.
+		Unscoped().
+		Omit(clause.Associations).
+		Where(&Pack{DistributionID: distributionID}).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, batchNumber int) error {
+			archived := make([]ArchivedPack, len(batch))
+			for i, p := range batch {
+				archived[i] = ArchivedPack(p)
+			}
+			return db.Omit(clause.Associations).Create(&archived).Error
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Unscoped().Where(&Pack{DistributionID: distributionID}).Delete(&Pack{}).Error
+}
+
+// Update an archived Pack
+func UpdateArchivedPack(db *gorm.DB, p *Pack) error {
+	return db.Table(archivedPacksTable).Omit(clause.Associations).Save(p).Error
+}
+
+// List the Packs believed to be owned by 'owner' in any of 'states' (all if
+// empty), of any distribution
+func ListOwnerPacks(db *gorm.DB, owner common.FlowAddress, states []common.PackState, opt ListOptions) ([]Pack, error) {
+	list := []Pack{}
+	return list, ownerPacksInStates(db.Omit(clause.Associations), owner, states).
+		Select("id", "distribution_id", "created_at", "updated_at", "contract_ref_name", "contract_ref_address", "flow_id", "state", "commitment_hash", "owner").
+		Order("created_at asc, id asc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Count the Packs believed to be owned by 'owner' in any of 'states' (all if empty)
+func CountOwnerPacks(db *gorm.DB, owner common.FlowAddress, states []common.PackState) (int64, error) {
+	var count int64
+	return count, ownerPacksInStates(db.Model(&Pack{}), owner, states).Count(&count).Error
+}
+
+func ownerPacksInStates(db *gorm.DB, owner common.FlowAddress, states []common.PackState) *gorm.DB {
+	db = db.Where("owner = ?", owner)
+	if len(states) > 0 {
+		db = db.Where("state IN ?", states)
+	}
+	return db
+}
+
+func packsInStates(db *gorm.DB, distributionID uuid.UUID, states []common.PackState) *gorm.DB {
+	db = db.Where(&Pack{DistributionID: distributionID})
+	if len(states) > 0 {
+		db = db.Where("state IN ?", states)
+	}
+	return db
+}
+
 // Get Settlement
 func GetDistributionSettlement(db *gorm.DB, distributionID uuid.UUID) (*Settlement, error) {
 	settlement := Settlement{}
@@ -179,15 +509,51 @@ func GetDistributionSettlement(db *gorm.DB, distributionID uuid.UUID) (*Settleme
 	return &settlement, nil
 }
 
-// Get SettlementCollectibles that have not been settled for a Settlement and process in batches of 'batchSize'
-func NotSettledCollectiblesInBatches(db *gorm.DB, settlementId uuid.UUID, batchSize int, processBatch func(tx *gorm.DB, batchNumber int, batch SettlementCollectibles) error) error {
-	batch := SettlementCollectibles{}
-	return db.
+// Get the contracts of the SettlementCollectibles of a Settlement which have not been settled
+func NotSettledCollectibleContracts(db *gorm.DB, settlementId uuid.UUID) ([]AddressLocation, error) {
+	list := []AddressLocation{}
+	return list, db.Model(&SettlementCollectible{}).
+		Select("DISTINCT contract_ref_name AS name, contract_ref_address AS address").
+		Where("settlement_id = ? AND is_settled = ?", settlementId, false).
+		Scan(&list).Error
+}
+
+// Get not yet settled SettlementCollectibles of a Settlement by their contract and FlowIDs
+func NotSettledCollectiblesByFlowIDs(db *gorm.DB, settlementId uuid.UUID, contract AddressLocation, flowIDs []int64) (SettlementCollectibles, error) {
+	list := SettlementCollectibles{}
+	return list, db.
 		Omit(clause.Associations).
-		Where(&SettlementCollectible{SettlementID: settlementId, IsSettled: false}).
-		FindInBatches(&batch, batchSize, func(tx *gorm.DB, batchNumber int) error {
-			return processBatch(tx, batchNumber, batch)
-		}).Error
+		Where("settlement_id = ? AND is_settled = ? AND contract_ref_name = ? AND contract_ref_address = ? AND flow_id IN ?", settlementId, false, contract.Name, contract.Address, flowIDs).
+		Find(&list).Error
+}
+
+// Get SettlementCollectibles of a Settlement not yet included in a settle transaction, at most 'limit'
+func NotQueuedSettlementCollectibles(db *gorm.DB, settlementId uuid.UUID, limit int) (SettlementCollectibles, error) {
+	list := SettlementCollectibles{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("settlement_id = ? AND is_queued = ? AND is_settled = ?", settlementId, false, false).
+		Order("created_at asc, id asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// Get queued, not yet settled SettlementCollectibles of a Settlement by their FlowIDs
+func QueuedSettlementCollectiblesByFlowIDs(db *gorm.DB, settlementId uuid.UUID, flowIDs []int64) (SettlementCollectibles, error) {
+	list := SettlementCollectibles{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("settlement_id = ? AND is_queued = ? AND is_settled = ? AND flow_id IN ?", settlementId, true, false, flowIDs).
+		Find(&list).Error
+}
+
+// Get settled SettlementCollectibles of a Settlement by their FlowIDs
+func SettledCollectiblesByFlowIDs(db *gorm.DB, settlementId uuid.UUID, flowIDs []int64) (SettlementCollectibles, error) {
+	list := SettlementCollectibles{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("settlement_id = ? AND is_settled = ? AND flow_id IN ?", settlementId, true, flowIDs).
+		Find(&list).Error
 }
 
 // Get Settlement
@@ -236,3 +602,726 @@ func GetDistributionMinting(db *gorm.DB, distributionID uuid.UUID) (*Minting, er
 func UpdateMinting(db *gorm.DB, d *Minting) error {
 	return db.Omit(clause.Associations).Save(d).Error
 }
+
+// Insert OwnershipVerification
+func InsertOwnershipVerification(db *gorm.DB, v *OwnershipVerification) error {
+	return db.Omit(clause.Associations).Create(v).Error
+}
+
+// Update OwnershipVerification
+func UpdateOwnershipVerification(db *gorm.DB, v *OwnershipVerification) error {
+	return db.Omit(clause.Associations).Save(v).Error
+}
+
+// Get OwnershipVerification including discrepancies
+func GetOwnershipVerification(db *gorm.DB, id uuid.UUID) (*OwnershipVerification, error) {
+	verification := OwnershipVerification{}
+	if err := db.Preload("Discrepancies").First(&verification, id).Error; err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// Insert OwnershipDiscrepancies
+func InsertOwnershipDiscrepancies(db *gorm.DB, dd []OwnershipDiscrepancy, batchSize int) error {
+	return db.Omit(clause.Associations).CreateInBatches(dd, batchSize).Error
+}
+
+// ReplaceCollectibleOwnershipCheck stores 'c' and its missing collectibles,
+// replacing an earlier check of the same distribution
+func ReplaceCollectibleOwnershipCheck(db *gorm.DB, c *CollectibleOwnershipCheck, batchSize int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		previous := tx.Model(&CollectibleOwnershipCheck{}).Select("id").Where(&CollectibleOwnershipCheck{DistributionID: c.DistributionID})
+		if err := tx.Unscoped().Where("collectible_ownership_check_id IN (?)", previous).Delete(&MissingCollectible{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where(&CollectibleOwnershipCheck{DistributionID: c.DistributionID}).Delete(&CollectibleOwnershipCheck{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(c).Error; err != nil {
+			return err
+		}
+
+		for i := range c.Missing {
+			c.Missing[i].CollectibleOwnershipCheckID = c.ID
+		}
+
+		return tx.Omit(clause.Associations).CreateInBatches(c.Missing, batchSize).Error
+	})
+}
+
+// Get the CollectibleOwnershipCheck of a distribution including missing collectibles
+func GetDistributionCollectibleOwnershipCheck(db *gorm.DB, distributionID uuid.UUID) (*CollectibleOwnershipCheck, error) {
+	check := CollectibleOwnershipCheck{}
+	if err := db.Preload("Missing", func(db *gorm.DB) *gorm.DB {
+		return db.Order("bucket_index asc, flow_id asc")
+	}).Where(&CollectibleOwnershipCheck{DistributionID: distributionID}).First(&check).Error; err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// ListVerifiablePacks lists at most 'limit' minted, unopened packs of a
+// distribution with a FlowID greater than 'after', ordered by FlowID.
+func ListVerifiablePacks(db *gorm.DB, distributionID uuid.UUID, after common.FlowID, limit int) ([]Pack, error) {
+	list := []Pack{}
+	q := db.
+		Omit(clause.Associations).
+		Where(&Pack{DistributionID: distributionID}).
+		Where("flow_id IS NOT NULL").
+		Where("state NOT IN ?", []common.PackState{common.PackStateOpened, common.PackStateEmpty})
+	if after.Valid {
+		q = q.Where("flow_id > ?", after.Int64)
+	}
+	return list, q.Order("flow_id asc").Limit(limit).Find(&list).Error
+}
+
+// Insert GiftIntents
+func InsertGiftIntents(db *gorm.DB, gg []GiftIntent, batchSize int) error {
+	return db.Omit(clause.Associations).CreateInBatches(gg, batchSize).Error
+}
+
+// Update GiftIntent
+func UpdateGiftIntent(db *gorm.DB, g *GiftIntent) error {
+	return db.Omit(clause.Associations).Save(g).Error
+}
+
+// Get GiftIntent
+func GetGiftIntent(db *gorm.DB, id uuid.UUID) (*GiftIntent, error) {
+	g := GiftIntent{}
+	if err := db.Omit(clause.Associations).First(&g, id).Error; err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// List GiftIntents of a distribution
+func ListGiftIntents(db *gorm.DB, distributionID uuid.UUID, opt ListOptions) ([]GiftIntent, error) {
+	list := []GiftIntent{}
+	if err := db.Omit(clause.Associations).
+		Where(&GiftIntent{DistributionID: distributionID}).
+		Order("created_at desc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// List pending GiftIntents of a pack
+func ListPendingGiftIntentsForPack(db *gorm.DB, packID uuid.UUID) ([]GiftIntent, error) {
+	list := []GiftIntent{}
+	return list, db.Omit(clause.Associations).
+		Where(&GiftIntent{PackID: packID, State: common.GiftIntentStatePending}).
+		Find(&list).Error
+}
+
+// ExpireGiftIntents marks pending GiftIntents which have expired by 'now' as expired
+func ExpireGiftIntents(db *gorm.DB, now time.Time) error {
+	return db.Model(&GiftIntent{}).
+		Where("state = ? AND expires_at <= ?", common.GiftIntentStatePending, now).
+		Updates(map[string]interface{}{"state": common.GiftIntentStateExpired, "notified": false}).Error
+}
+
+// ListGiftIntentsWithWebhookDue lists at most 'limit' GiftIntents which have a
+// webhook event due at 'now', least recently updated first
+func ListGiftIntentsWithWebhookDue(db *gorm.DB, now time.Time, limit int) ([]GiftIntent, error) {
+	list := []GiftIntent{}
+	return list, db.Omit(clause.Associations).
+		Where("webhook_url <> ''").
+		Where(
+			"(state IN ? AND notified = ?) OR (state = ? AND remind_at <= ? AND reminder_sent = ?)",
+			[]common.GiftIntentState{common.GiftIntentStateTransferred, common.GiftIntentStateExpired}, false,
+			common.GiftIntentStatePending, now, false,
+		).
+		Order("updated_at asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// CountDistributionPacksByState returns the number of packs per state in a distribution
+func CountDistributionPacksByState(db *gorm.DB, distributionID uuid.UUID) (map[common.PackState]uint, error) {
+	rows := []struct {
+		State common.PackState
+		Count uint
+	}{}
+	err := db.Model(&Pack{}).
+		Select("state, count(*) as count").
+		Where(&Pack{DistributionID: distributionID}).
+		Group("state").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[common.PackState]uint, len(rows))
+	for _, r := range rows {
+		res[r.State] = r.Count
+	}
+	return res, nil
+}
+
+// Insert CompletionReport
+func InsertCompletionReport(db *gorm.DB, r *CompletionReport) error {
+	return db.Omit(clause.Associations).Create(r).Error
+}
+
+// Get CompletionReport
+func GetDistributionCompletionReport(db *gorm.DB, distributionID uuid.UUID) (*CompletionReport, error) {
+	report := CompletionReport{}
+	if err := db.Omit(clause.Associations).Where(&CompletionReport{DistributionID: distributionID}).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Insert KeyRotation
+func InsertKeyRotation(db *gorm.DB, r *KeyRotation) error {
+	return db.Omit(clause.Associations).Create(r).Error
+}
+
+// Update KeyRotation
+func UpdateKeyRotation(db *gorm.DB, r *KeyRotation) error {
+	return db.Omit(clause.Associations).Save(r).Error
+}
+
+// List KeyRotations, oldest first
+func ListKeyRotations(db *gorm.DB) ([]KeyRotation, error) {
+	list := []KeyRotation{}
+	if err := db.Omit(clause.Associations).Order("created_at asc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Get IssuerBranding of an issuer
+func GetIssuerBranding(db *gorm.DB, issuer common.FlowAddress) (*IssuerBranding, error) {
+	branding := IssuerBranding{}
+	if err := db.Omit(clause.Associations).Where(&IssuerBranding{Issuer: issuer}).First(&branding).Error; err != nil {
+		return nil, err
+	}
+	return &branding, nil
+}
+
+// Insert or update the IssuerBranding of an issuer
+func SaveIssuerBranding(db *gorm.DB, b *IssuerBranding) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		existing, err := GetIssuerBranding(tx, b.Issuer)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Omit(clause.Associations).Create(b).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		b.Model, b.ID = existing.Model, existing.ID
+
+		return tx.Omit(clause.Associations).Save(b).Error
+	})
+}
+
+// Opt an issuer in or out of the public stats
+func SetPublicStatsOptIn(db *gorm.DB, issuer common.FlowAddress, optIn bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Hard delete, the issuer column is unique
+		if err := tx.Unscoped().Where(&PublicStatsOptIn{Issuer: issuer}).Delete(&PublicStatsOptIn{}).Error; err != nil {
+			return err
+		}
+		if !optIn {
+			return nil
+		}
+		return tx.Create(&PublicStatsOptIn{Issuer: issuer}).Error
+	})
+}
+
+// Count packs minted and distributions completed by opted in issuers
+func countPublicStats(db *gorm.DB) (publicStatsCounts, error) {
+	counts := publicStatsCounts{}
+	var issuers, packs, dists int64
+
+	optedIn := db.Model(&PublicStatsOptIn{}).Select("issuer")
+
+	err := db.Model(&PublicStatsOptIn{}).
+		Where("issuer IN (?)", db.Model(&Distribution{}).Select("issuer")).
+		Count(&issuers).Error
+	if err != nil {
+		return counts, err
+	}
+
+	err = db.Model(&Pack{}).
+		Joins("JOIN distributions ON distributions.id = distribution_packs.distribution_id AND distributions.deleted_at IS NULL").
+		Where("distributions.issuer IN (?)", optedIn).
+		Where("distribution_packs.state <> ?", common.PackStateInit).
+		Count(&packs).Error
+	if err != nil {
+		return counts, err
+	}
+
+	err = db.Model(&Distribution{}).
+		Where("issuer IN (?)", optedIn).
+		Where("state IN ?", []common.DistributionState{common.DistributionStateComplete, common.DistributionStateClosed}).
+		Count(&dists).Error
+	if err != nil {
+		return counts, err
+	}
+
+	counts.Issuers, counts.PacksMinted, counts.DistributionsCompleted = uint(issuers), uint(packs), uint(dists)
+
+	return counts, nil
+}
+
+// List complete (or closed) distributions with a teased stage due at 'now'
+// which has not been queued yet
+func ListDistributionsTeaseDue(db *gorm.DB, now time.Time) ([]Distribution, error) {
+	list := []Distribution{}
+	return list, db.Omit(clause.Associations).
+		Where("state IN ?", []common.DistributionState{common.DistributionStateComplete, common.DistributionStateClosed}).
+		Where("template_tease_not_before <= ? AND teased_at IS NULL", now).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// Insert RevealEvents
+func InsertRevealEvents(db *gorm.DB, ee []RevealEvent, batchSize int) error {
+	return db.Omit(clause.Associations).CreateInBatches(ee, batchSize).Error
+}
+
+// Update RevealEvent
+func UpdateRevealEvent(db *gorm.DB, e *RevealEvent) error {
+	return db.Omit(clause.Associations).Save(e).Error
+}
+
+// ListUnhandledRevealEvents lists at most 'limit' RevealEvents which have not
+// been sent (or given up on), oldest first
+func ListUnhandledRevealEvents(db *gorm.DB, limit int) ([]RevealEvent, error) {
+	list := []RevealEvent{}
+	return list, db.Omit(clause.Associations).
+		Where("handled = ?", false).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// Get IssuerCallbackSecret of an issuer
+func GetIssuerCallbackSecret(db *gorm.DB, issuer common.FlowAddress) (*IssuerCallbackSecret, error) {
+	secret := IssuerCallbackSecret{}
+	if err := db.Omit(clause.Associations).Where(&IssuerCallbackSecret{Issuer: issuer}).First(&secret).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// Insert or update the IssuerCallbackSecret of an issuer
+func SaveIssuerCallbackSecret(db *gorm.DB, s *IssuerCallbackSecret) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		existing, err := GetIssuerCallbackSecret(tx, s.Issuer)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Omit(clause.Associations).Create(s).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		s.Model, s.ID = existing.Model, existing.ID
+
+		return tx.Omit(clause.Associations).Save(s).Error
+	})
+}
+
+// Get IssuerCallback of an issuer by the ID the issuer gave it
+func GetIssuerCallback(db *gorm.DB, issuer common.FlowAddress, callbackID string) (*IssuerCallback, error) {
+	callback := IssuerCallback{}
+	if err := db.Omit(clause.Associations).Where(&IssuerCallback{Issuer: issuer, CallbackID: callbackID}).First(&callback).Error; err != nil {
+		return nil, err
+	}
+	return &callback, nil
+}
+
+// Insert IssuerCallback
+func InsertIssuerCallback(db *gorm.DB, c *IssuerCallback) error {
+	return db.Omit(clause.Associations).Create(c).Error
+}
+
+// List IssuerCallbacks of an issuer, most recent first
+func ListIssuerCallbacks(db *gorm.DB, issuer common.FlowAddress, opt ListOptions) ([]IssuerCallback, error) {
+	list := []IssuerCallback{}
+	return list, db.Omit(clause.Associations).
+		Where(&IssuerCallback{Issuer: issuer}).
+		Order("created_at desc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Get IDReservation of a pack for a collectible contract
+func GetIDReservation(db *gorm.DB, packID uuid.UUID, ref AddressLocation) (*IDReservation, error) {
+	reservation := IDReservation{}
+	if err := db.Omit(clause.Associations).Where(&IDReservation{PackID: packID, Contract: ref.String()}).First(&reservation).Error; err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// List IDReservations of a pack
+func ListIDReservations(db *gorm.DB, packID uuid.UUID) ([]IDReservation, error) {
+	list := []IDReservation{}
+	return list, db.Omit(clause.Associations).
+		Where(&IDReservation{PackID: packID}).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// Insert IDReservation
+func InsertIDReservation(db *gorm.DB, r *IDReservation) error {
+	return db.Omit(clause.Associations).Create(r).Error
+}
+
+// Advance the IDCounter of a collectible contract by 'count', creating it
+// starting from 'firstID' if needed, and return the first ID of the advanced
+// range. The update locks the counter until the surrounding transaction ends
+// so concurrent reservations never get overlapping ranges.
+func AdvanceIDCounter(db *gorm.DB, ref AddressLocation, firstID int64, count int) (int64, error) {
+	contract := ref.String()
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&IDCounter{Contract: contract, NextID: firstID}).Error; err != nil {
+		return 0, err
+	}
+
+	if err := db.Model(&IDCounter{}).
+		Where("contract = ?", contract).
+		Update("next_id", gorm.Expr("next_id + ?", count)).Error; err != nil {
+		return 0, err
+	}
+
+	counter := IDCounter{}
+	if err := db.Where(&IDCounter{Contract: contract}).First(&counter).Error; err != nil {
+		return 0, err
+	}
+
+	return counter.NextID - int64(count), nil
+}
+
+// Insert Collection
+func InsertCollection(db *gorm.DB, c *Collection) error {
+	return db.Omit(clause.Associations).Create(c).Error
+}
+
+// Get Collection
+func GetCollection(db *gorm.DB, id uuid.UUID) (*Collection, error) {
+	collection := Collection{}
+	if err := db.Omit(clause.Associations).First(&collection, id).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// List Collections, of an issuer if 'issuer' is not empty, most recent first
+func ListCollections(db *gorm.DB, issuer common.FlowAddress, opt ListOptions) ([]Collection, error) {
+	list := []Collection{}
+	q := db.Omit(clause.Associations)
+	if flow.Address(issuer) != flow.EmptyAddress {
+		q = q.Where(&Collection{Issuer: issuer})
+	}
+	return list, q.
+		Order("created_at desc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Insert DistributionTemplate
+func InsertDistributionTemplate(db *gorm.DB, t *DistributionTemplate) error {
+	return db.Omit(clause.Associations).Create(t).Error
+}
+
+// Get DistributionTemplate
+func GetDistributionTemplate(db *gorm.DB, id uuid.UUID) (*DistributionTemplate, error) {
+	template := DistributionTemplate{}
+	if err := db.Omit(clause.Associations).First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// List DistributionTemplates, of an issuer if 'issuer' is not empty, most
+// recent first
+func ListDistributionTemplates(db *gorm.DB, issuer common.FlowAddress, opt ListOptions) ([]DistributionTemplate, error) {
+	list := []DistributionTemplate{}
+	q := db.Omit(clause.Associations)
+	if flow.Address(issuer) != flow.EmptyAddress {
+		q = q.Where(&DistributionTemplate{Issuer: issuer})
+	}
+	return list, q.
+		Order("created_at desc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Update DistributionTemplate
+func UpdateDistributionTemplate(db *gorm.DB, t *DistributionTemplate) error {
+	return db.Omit(clause.Associations).Save(t).Error
+}
+
+// Delete DistributionTemplate, distributions created from it are kept
+func DeleteDistributionTemplate(db *gorm.DB, id uuid.UUID) error {
+	return db.Delete(&DistributionTemplate{}, id).Error
+}
+
+// CountCollectionDistributionsByState returns the number of distributions
+// per state in a collection
+func CountCollectionDistributionsByState(db *gorm.DB, collectionID uuid.UUID) (map[common.DistributionState]uint, error) {
+	rows := []struct {
+		State common.DistributionState
+		Count uint
+	}{}
+	err := db.Model(&Distribution{}).
+		Select("state, count(*) as count").
+		Where("collection_id = ?", collectionID).
+		Group("state").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[common.DistributionState]uint, len(rows))
+	for _, r := range rows {
+		res[r.State] = r.Count
+	}
+	return res, nil
+}
+
+// CountCollectionPacksByState returns the number of packs per state over the
+// distributions of a collection
+func CountCollectionPacksByState(db *gorm.DB, collectionID uuid.UUID) (map[common.PackState]uint, error) {
+	rows := []struct {
+		State common.PackState
+		Count uint
+	}{}
+	err := db.Model(&Pack{}).
+		Select("state, count(*) as count").
+		Where("distribution_id IN (?)", db.Model(&Distribution{}).Select("id").Where("collection_id = ?", collectionID)).
+		Group("state").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[common.PackState]uint, len(rows))
+	for _, r := range rows {
+		res[r.State] = r.Count
+	}
+	return res, nil
+}
+
+// Insert APIKey
+func InsertAPIKey(db *gorm.DB, k *APIKey) error {
+	return db.Omit(clause.Associations).Create(k).Error
+}
+
+// Get APIKey of an issuer
+func GetAPIKey(db *gorm.DB, issuer common.FlowAddress, id uuid.UUID) (*APIKey, error) {
+	key := APIKey{}
+	if err := db.Omit(clause.Associations).Where(&APIKey{Issuer: issuer}).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Get APIKey by the hash of the key
+func GetAPIKeyByHash(db *gorm.DB, hash string) (*APIKey, error) {
+	key := APIKey{}
+	if err := db.Omit(clause.Associations).Where(&APIKey{KeyHash: hash}).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List APIKeys of an issuer, oldest first
+func ListAPIKeys(db *gorm.DB, issuer common.FlowAddress) ([]APIKey, error) {
+	list := []APIKey{}
+	return list, db.Omit(clause.Associations).
+		Where(&APIKey{Issuer: issuer}).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// Update APIKey
+func UpdateAPIKey(db *gorm.DB, k *APIKey) error {
+	return db.Omit(clause.Associations).Save(k).Error
+}
+
+// Insert IssuerWebhook
+func InsertIssuerWebhook(db *gorm.DB, w *IssuerWebhook) error {
+	return db.Omit(clause.Associations).Create(w).Error
+}
+
+// Get IssuerWebhook by ID
+func GetIssuerWebhook(db *gorm.DB, id uuid.UUID) (*IssuerWebhook, error) {
+	w := IssuerWebhook{}
+	if err := db.Omit(clause.Associations).First(&w, id).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// List IssuerWebhooks of an issuer, oldest first
+func ListIssuerWebhooks(db *gorm.DB, issuer common.FlowAddress) ([]IssuerWebhook, error) {
+	list := []IssuerWebhook{}
+	return list, db.Omit(clause.Associations).
+		Where(&IssuerWebhook{Issuer: issuer}).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// ListActiveIssuerWebhooks lists the IssuerWebhooks of an issuer which were
+// not registered with a since revoked API key
+func ListActiveIssuerWebhooks(db *gorm.DB, issuer common.FlowAddress) ([]IssuerWebhook, error) {
+	list := []IssuerWebhook{}
+	revoked := db.Model(&APIKey{}).Select("id").Where("revoked_at IS NOT NULL")
+	return list, db.Omit(clause.Associations).
+		Where(&IssuerWebhook{Issuer: issuer}).
+		Where("api_key_id IS NULL OR api_key_id NOT IN (?)", revoked).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// Delete IssuerWebhook of an issuer
+func DeleteIssuerWebhook(db *gorm.DB, issuer common.FlowAddress, id uuid.UUID) error {
+	res := db.Where(&IssuerWebhook{Issuer: issuer}).Delete(&IssuerWebhook{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Insert WebhookDeliveries
+func InsertWebhookDeliveries(db *gorm.DB, dd []WebhookDelivery) error {
+	return db.Omit(clause.Associations).Create(&dd).Error
+}
+
+// Update WebhookDelivery
+func UpdateWebhookDelivery(db *gorm.DB, d *WebhookDelivery) error {
+	return db.Omit(clause.Associations).Save(d).Error
+}
+
+// ListDueWebhookDeliveries lists at most 'limit' WebhookDeliveries which have
+// not been sent (or given up on) and are due at 'now', oldest first
+func ListDueWebhookDeliveries(db *gorm.DB, now time.Time, limit int) ([]WebhookDelivery, error) {
+	list := []WebhookDelivery{}
+	return list, db.Omit(clause.Associations).
+		Where("handled = ? AND next_attempt_at <= ?", false, now).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// Get IdempotencyKey of an issuer
+func GetIdempotencyKey(db *gorm.DB, issuer common.FlowAddress, key string) (*IdempotencyKey, error) {
+	k := IdempotencyKey{}
+	if err := db.Omit(clause.Associations).Where(&IdempotencyKey{Issuer: issuer, Key: key}).First(&k).Error; err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Insert IdempotencyKey
+func InsertIdempotencyKey(db *gorm.DB, k *IdempotencyKey) error {
+	return db.Omit(clause.Associations).Create(k).Error
+}
+
+// Cancel the Packs of a Distribution which were never minted
+func CancelDistributionPacks(db *gorm.DB, distributionID uuid.UUID) (int64, error) {
+	res := db.Model(&Pack{}).
+		Where("distribution_id = ? AND state = ?", distributionID, common.PackStateInit).
+		Update("state", common.PackStateCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// Insert DistributionCancellation
+func InsertDistributionCancellation(db *gorm.DB, c *DistributionCancellation) error {
+	return db.Omit(clause.Associations).Create(c).Error
+}
+
+// Update DistributionCancellation
+func UpdateDistributionCancellation(db *gorm.DB, c *DistributionCancellation) error {
+	return db.Omit(clause.Associations).Save(c).Error
+}
+
+// Delete the DistributionCancellation of a Distribution, e.g. once it is
+// reinitialized
+func DeleteDistributionCancellation(db *gorm.DB, distributionID uuid.UUID) error {
+	return db.Unscoped().Where(&DistributionCancellation{DistributionID: distributionID}).Delete(&DistributionCancellation{}).Error
+}
+
+// List DistributionCancellations which are not complete, in order of creation
+func ListIncompleteDistributionCancellations(db *gorm.DB) ([]DistributionCancellation, error) {
+	list := []DistributionCancellation{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("complete = ?", false).
+		Order("created_at asc").
+		Find(&list).Error
+}
+
+// Insert Issuer
+func InsertIssuer(db *gorm.DB, i *Issuer) error {
+	return db.Omit(clause.Associations).Create(i).Error
+}
+
+// Update Issuer
+func UpdateIssuer(db *gorm.DB, i *Issuer) error {
+	return db.Omit(clause.Associations).Save(i).Error
+}
+
+// Get the Issuer registered for an address
+func GetIssuer(db *gorm.DB, address common.FlowAddress) (*Issuer, error) {
+	issuer := Issuer{}
+	if err := db.Omit(clause.Associations).Where(&Issuer{Address: address}).First(&issuer).Error; err != nil {
+		return nil, err
+	}
+	return &issuer, nil
+}
+
+// List Issuers in order of registration
+func ListIssuers(db *gorm.DB, opt ListOptions) ([]Issuer, error) {
+	list := []Issuer{}
+	return list, db.
+		Omit(clause.Associations).
+		Order("created_at asc").
+		Limit(opt.Limit).
+		Offset(opt.Offset).
+		Find(&list).Error
+}
+
+// Insert PackReplacement
+func InsertPackReplacement(db *gorm.DB, r *PackReplacement) error {
+	return db.Omit(clause.Associations).Create(r).Error
+}
+
+// Update PackReplacement
+func UpdatePackReplacement(db *gorm.DB, r *PackReplacement) error {
+	return db.Omit(clause.Associations).Save(r).Error
+}
+
+// Get the PackReplacement of the original pack 'id'
+func GetPackReplacementByOriginal(db *gorm.DB, id uuid.UUID) (*PackReplacement, error) {
+	r := PackReplacement{}
+	if err := db.Omit(clause.Associations).Where(&PackReplacement{OriginalPackID: id}).First(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List PackReplacements whose replacement pack is not minted yet
+func ListIncompletePackReplacements(db *gorm.DB) ([]PackReplacement, error) {
+	list := []PackReplacement{}
+	return list, db.
+		Omit(clause.Associations).
+		Where("complete = ?", false).
+		Order("created_at asc").
+		Find(&list).Error
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+// TierCounts maps the tiers of a bucket (see CollectibleTiers) to a count,
+// the weight of a tier or the number of slots guaranteed to it. Stored as
+// JSON.
+type TierCounts map[string]uint
+
+func (TierCounts) GormDataType() string {
+	return "text"
+}
+
+// Scan tier counts from database.
+func (tc *TierCounts) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+		*tc = nil
+		return nil
+	default:
+		return fmt.Errorf("failed to unmarshal TierCounts value: %v", value)
+	}
+	if len(b) == 0 {
+		*tc = nil
+		return nil
+	}
+	return json.Unmarshal(b, tc)
+}
+
+// Convert tier counts to database storable format.
+func (tc TierCounts) Value() (driver.Value, error) {
+	if len(tc) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(tc)
+	return string(b), err
+}
+
+// Sum returns the sum of the counts.
+func (tc TierCounts) Sum() uint {
+	res := uint(0)
+	for _, c := range tc {
+		res += c
+	}
+	return res
+}
+
+// sortedTiers returns the tiers of 'tc' in order, so that resolving does not
+// depend on map iteration order.
+func (tc TierCounts) sortedTiers() []string {
+	res := make([]string, 0, len(tc))
+	for tier := range tc {
+		res = append(res, tier)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Weighted returns true if the slots of the bucket are filled by tier, using
+// its tier weights and guaranteed tiers, instead of picking uniformly from
+// its collection.
+func (b Bucket) Weighted() bool {
+	return len(b.TierWeights) > 0 || len(b.GuaranteedTiers) > 0
+}
+
+// tierCollections returns the collectibles of the collection of the bucket
+// by tier, in collection order.
+func (b Bucket) tierCollections() map[string][]common.FlowID {
+	res := make(map[string][]common.FlowID)
+	for _, id := range b.CollectibleCollection {
+		if tier, ok := b.CollectibleTiers[id.Int64]; ok {
+			res[tier] = append(res[tier], id)
+		}
+	}
+	return res
+}
+
+// TierAllocation returns how many collectibles of each tier 'packCount' packs
+// take from the weighted bucket in total. Each pack gets its guaranteed
+// tiers, the remaining slots of all packs are split between tiers in
+// proportion to their weights (largest remainder first). Returns an error
+// explaining why if a tier does not have enough collectibles.
+func (b Bucket) TierAllocation(packCount uint) (map[string]int, error) {
+	guaranteed := b.GuaranteedTiers.Sum()
+	if guaranteed > b.CollectibleCount {
+		return nil, fmt.Errorf("%d slots guaranteed to tiers but the bucket has %d slots per pack", guaranteed, b.CollectibleCount)
+	}
+
+	res := make(map[string]int)
+	for tier, count := range b.GuaranteedTiers {
+		res[tier] = int(count * packCount)
+	}
+
+	remaining := int((b.CollectibleCount - guaranteed) * packCount)
+	totalWeight := int(b.TierWeights.Sum())
+
+	if remaining > 0 {
+		if totalWeight == 0 {
+			return nil, fmt.Errorf("%d slots per pack are not guaranteed to a tier and there are no tier weights to fill them", b.CollectibleCount-guaranteed)
+		}
+
+		tiers := b.TierWeights.sortedTiers()
+
+		allocated := 0
+		for _, tier := range tiers {
+			share := remaining * int(b.TierWeights[tier]) / totalWeight
+			res[tier] += share
+			allocated += share
+		}
+
+		// Largest remainders get the slots left over by rounding down
+		sort.SliceStable(tiers, func(i, j int) bool {
+			return remaining*int(b.TierWeights[tiers[i]])%totalWeight > remaining*int(b.TierWeights[tiers[j]])%totalWeight
+		})
+		for i := 0; allocated < remaining; i++ {
+			res[tiers[i]]++
+			allocated++
+		}
+	}
+
+	tiers := make([]string, 0, len(res))
+	for tier := range res {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+
+	collections := b.tierCollections()
+	for _, tier := range tiers {
+		if need, have := res[tier], len(collections[tier]); need > have {
+			return nil, tierShortageError(b, tier, need, have, packCount)
+		}
+	}
+
+	return res, nil
+}
+
+func tierShortageError(b Bucket, tier string, need, have int, packCount uint) error {
+	guaranteed := int(b.GuaranteedTiers[tier] * packCount)
+	return newError(ErrorCodeInsufficientEscrow,
+		"tier '%s' needs %d collectibles (%d guaranteed and %d by weight for %d packs) but has %d",
+		tier, need, guaranteed, need-guaranteed, packCount, have,
+	)
+}
+
+// resolveWeighted picks the collectibles of each of 'packCount' packs from
+// the weighted bucket. The tiers of all slots are allocated up front (see
+// TierAllocation) and dealt to the packs at random, guaranteed ones to each
+// pack, then each slot gets a random collectible of its tier. The order of
+// the slots of a pack is shuffled so that a teaser does not tell guaranteed
+// slots apart.
+func (b Bucket) resolveWeighted(r *rand.Rand, packCount int) ([][]common.FlowID, error) {
+	allocation, err := b.TierAllocation(uint(packCount))
+	if err != nil {
+		return nil, err
+	}
+
+	countPerPack := int(b.CollectibleCount)
+
+	// Tiers of the slots which are not guaranteed, of all packs
+	weighted := []string{}
+	for _, tier := range b.TierWeights.sortedTiers() {
+		for i := allocation[tier] - int(b.GuaranteedTiers[tier])*packCount; i > 0; i-- {
+			weighted = append(weighted, tier)
+		}
+	}
+	r.Shuffle(len(weighted), func(i, j int) { weighted[i], weighted[j] = weighted[j], weighted[i] })
+
+	// Collectibles of each tier in random order, shuffled in tier order so
+	// that the packs only depend on the seed of 'r'
+	collections := b.tierCollections()
+	collectionTiers := make([]string, 0, len(collections))
+	for tier := range collections {
+		collectionTiers = append(collectionTiers, tier)
+	}
+	sort.Strings(collectionTiers)
+	for _, tier := range collectionTiers {
+		ids := collections[tier]
+		r.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	}
+
+	guaranteedTiers := b.GuaranteedTiers.sortedTiers()
+
+	res := make([][]common.FlowID, packCount)
+	for p := range res {
+		tiers := make([]string, 0, countPerPack)
+		for _, tier := range guaranteedTiers {
+			for i := uint(0); i < b.GuaranteedTiers[tier]; i++ {
+				tiers = append(tiers, tier)
+			}
+		}
+		for len(tiers) < countPerPack {
+			tiers = append(tiers, weighted[0])
+			weighted = weighted[1:]
+		}
+		r.Shuffle(len(tiers), func(i, j int) { tiers[i], tiers[j] = tiers[j], tiers[i] })
+
+		res[p] = make([]common.FlowID, countPerPack)
+		for i, tier := range tiers {
+			res[p][i] = collections[tier][0]
+			collections[tier] = collections[tier][1:]
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// makeTieredBucket returns a bucket of 'common' common and 'rare' rare
+// collectibles.
+func makeTieredBucket(commonCount, rareCount int, collectibleCount uint) Bucket {
+	collection := makeCollection(commonCount + rareCount)
+	tiers := make(CollectibleTiers)
+	for i, id := range collection {
+		if i < commonCount {
+			tiers[id.Int64] = "common"
+		} else {
+			tiers[id.Int64] = "rare"
+		}
+	}
+	return Bucket{
+		CollectibleReference:  AddressLocation{Name: "Moment", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+		CollectibleCount:      collectibleCount,
+		CollectibleCollection: collection,
+		CollectibleTiers:      tiers,
+	}
+}
+
+func TestBucketTierAllocation(t *testing.T) {
+	b := makeTieredBucket(100, 20, 3)
+	b.TierWeights = TierCounts{"common": 2, "rare": 1}
+	b.GuaranteedTiers = TierCounts{"rare": 1}
+
+	// 10 guaranteed rares, the other 20 slots split 2:1 rounding the larger
+	// remainder up
+	allocation, err := b.TierAllocation(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]int{"common": 13, "rare": 17}; !reflect.DeepEqual(allocation, expected) {
+		t.Errorf("expected %v, got %v", expected, allocation)
+	}
+
+	_, err = b.TierAllocation(15)
+	if err == nil || ErrorCode(err) != ErrorCodeInsufficientEscrow || !strings.Contains(err.Error(), "tier 'rare' needs 25 collectibles (15 guaranteed and 10 by weight for 15 packs) but has 20") {
+		t.Errorf("expected the rare tier to be reported short, got %v", err)
+	}
+
+	b.GuaranteedTiers = TierCounts{"rare": 4}
+	if _, err := b.TierAllocation(1); err == nil {
+		t.Error("expected an error for more guaranteed slots than slots")
+	}
+
+	b.GuaranteedTiers = TierCounts{"rare": 1}
+	b.TierWeights = nil
+	if _, err := b.TierAllocation(1); err == nil {
+		t.Error("expected an error for slots without a tier weight")
+	}
+}
+
+func TestResolveWeightedBuckets(t *testing.T) {
+	b := makeTieredBucket(40, 10, 4)
+	b.TierWeights = TierCounts{"common": 1}
+	b.GuaranteedTiers = TierCounts{"rare": 1}
+
+	d := Distribution{
+		State:  common.DistributionStateInit,
+		FlowID: common.FlowID{Int64: 1, Valid: true},
+		Issuer: common.FlowAddress(flow.HexToAddress("0x1")),
+		PackTemplate: PackTemplate{
+			PackReference: AddressLocation{Name: "TestPackNFT", Address: common.FlowAddress(flow.HexToAddress("0x2"))},
+			PackCount:     10,
+			Buckets:       []Bucket{b},
+		},
+	}
+
+	if err := d.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[common.FlowID]bool)
+	for i, p := range d.Packs {
+		rares := 0
+		for _, c := range p.Collectibles {
+			if seen[c.FlowID] {
+				t.Fatalf("collectible %d is in several packs", c.FlowID.Int64)
+			}
+			seen[c.FlowID] = true
+			if b.CollectibleTiers[c.FlowID.Int64] == "rare" {
+				rares++
+			}
+		}
+		if rares != 1 {
+			t.Errorf("expected pack %d to hold one rare, got %d", i, rares)
+		}
+	}
+}
+
+func TestBucketValidationWeighted(t *testing.T) {
+	b := makeTieredBucket(10, 2, 2)
+	b.TierWeights = TierCounts{"common": 1, "legendary": 1}
+	if err := b.Validate(); err == nil {
+		t.Error("expected an error for a tier without collectibles")
+	}
+
+	b.TierWeights = TierCounts{"common": 1}
+	delete(b.CollectibleTiers, 1)
+	if err := b.Validate(); err == nil {
+		t.Error("expected an error for a collectible without a tier")
+	}
+}
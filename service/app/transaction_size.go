@@ -0,0 +1,81 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// handleOversized checks 't' against the network size limits before it is
+// sent. A settle or mint batch exceeding them is split into smaller batches
+// which are stored as new transactions, any other transaction exceeding them
+// is failed as resending it would not help. In both cases 't' is set failed
+// and saved, and true is returned so it is not sent.
+func (svc *ContractService) handleOversized(db *gorm.DB, t *transactions.StorableTransaction) (bool, error) {
+	err := t.CheckSize(transactions.SizeLimits{
+		MaxByteSize:       svc.cfg.TransactionMaxByteSize,
+		MaxArgumentValues: svc.cfg.TransactionMaxArgumentValues,
+	})
+
+	var tooLarge *transactions.TooLargeError
+	if !errors.As(err, &tooLarge) {
+		return false, err
+	}
+
+	logger := log.WithFields(log.Fields{
+		"name":           t.Name,
+		"ID":             t.ID,
+		"distributionID": t.DistributionID,
+		"byteSize":       tooLarge.ByteSize,
+		"argumentValues": tooLarge.ArgumentValues,
+	})
+
+	t.State = common.TransactionStateFailed
+	t.Error = tooLarge.Error()
+
+	batchable := t.Name == SETTLE_SCRIPT || t.Name == MINT_SCRIPT
+	if !batchable || t.BatchSize <= 1 {
+		metrics.CountOversizedTransaction(t.Name, metrics.OversizedFailed)
+		logger.Error("Transaction exceeds size limits and can not be split")
+		if err := svc.handleFailedMint(db, t); err != nil {
+			return false, err
+		}
+		return true, t.Save(db)
+	}
+
+	size := int(float64(t.BatchSize) * tooLarge.Ratio())
+	if size >= t.BatchSize {
+		size = t.BatchSize / 2
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	// Both settle and mint transactions take the batch as their second argument
+	split, err := t.Split(1, size)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range split {
+		if err := s.Save(db); err != nil {
+			return false, err
+		}
+	}
+
+	t.Error = fmt.Sprintf("%s, split into %d batches", t.Error, len(split))
+
+	metrics.CountOversizedTransaction(t.Name, metrics.OversizedSplit)
+	logger.WithFields(log.Fields{
+		"batchSize":    t.BatchSize,
+		"newBatchSize": size,
+		"batches":      len(split),
+	}).Warn("Batch exceeds size limits, split into smaller batches")
+
+	return true, t.Save(db)
+}
@@ -2,21 +2,41 @@ package app
 
 import (
 	"fmt"
+	"net/url"
+	"unicode/utf8"
 
 	"github.com/onflow/flow-go-sdk"
 )
 
+// Max length of a collectible tier in runes
+const maxCollectibleTierLength = 50
+
 func (dist Distribution) Validate() error {
 	if !dist.FlowID.Valid {
-		return fmt.Errorf("distribution flowID must be defined")
+		return newError(ErrorCodeDistributionInvalid, "distribution flowID must be defined")
 	}
 
 	if flow.Address(dist.Issuer) == flow.EmptyAddress {
-		return fmt.Errorf("distribution issuer must be defined")
+		return newError(ErrorCodeDistributionInvalid, "distribution issuer must be defined")
 	}
 
 	if err := dist.PackTemplate.Validate(); err != nil {
-		return fmt.Errorf("error while validating pack template: %w", err)
+		return withCode(ErrorCodeDistributionInvalid, fmt.Errorf("error while validating pack template: %w", err))
+	}
+
+	if _, err := GetCommitmentHasher(dist.CommitmentHashVersion); err != nil {
+		return err
+	}
+
+	if err := dist.Metadata.Validate(); err != nil {
+		return withCode(ErrorCodeDistributionInvalid, fmt.Errorf("error while validating metadata: %w", err))
+	}
+
+	if dist.RevealWebhookURL != "" {
+		u, err := url.Parse(dist.RevealWebhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return newError(ErrorCodeDistributionInvalid, "invalid revealWebhookURL '%s'", dist.RevealWebhookURL)
+		}
 	}
 
 	return nil
@@ -35,19 +55,60 @@ func (pt PackTemplate) Validate() error {
 		return fmt.Errorf("error while validating PackReference: %w", err)
 	}
 
+	if pt.TeaseNotBefore != nil && pt.RevealNotBefore != nil && !pt.TeaseNotBefore.Before(*pt.RevealNotBefore) {
+		return fmt.Errorf("teaseNotBefore must be before revealNotBefore")
+	}
+
+	if pt.FungibleToken != (FungibleTokenAmount{}) {
+		if err := pt.FungibleToken.Validate(pt.PackCount); err != nil {
+			return err
+		}
+		if pt.FungibleTokenReceiverPath == "" {
+			return fmt.Errorf("fungible token receiver path must be defined")
+		}
+	}
+
+	// Packs may hold collectibles of several contracts, the contracts are
+	// imported by name in the reveal and open transactions
+	contractNames := make(map[string]AddressLocation)
+	// Bucket of each collectible, by contract
+	collectibleBuckets := make(map[AddressLocation]map[int64]int)
+
 	for i, bucket := range pt.Buckets {
 		if err := bucket.Validate(); err != nil {
-			return fmt.Errorf("error in bucket %d: %w", i, err)
+			return withCode(ErrorCodeDistributionInvalidBucket, fmt.Errorf("error in bucket %d: %w", i, err))
+		}
+
+		ref := bucket.CollectibleReference
+		if other, ok := contractNames[ref.Name]; ok && other != ref {
+			return newError(ErrorCodeDistributionInvalidBucket, "error in bucket %d: collectible contracts %s and %s have the same name", i, other, ref)
+		}
+		contractNames[ref.Name] = ref
+
+		if collectibleBuckets[ref] == nil {
+			collectibleBuckets[ref] = make(map[int64]int)
+		}
+		for _, id := range bucket.CollectibleCollection {
+			if j, ok := collectibleBuckets[ref][id.Int64]; ok {
+				return newError(ErrorCodeDistributionInvalidBucket, "error in bucket %d: collectible %d of %s is also in bucket %d", i, id.Int64, ref, j)
+			}
+			collectibleBuckets[ref][id.Int64] = i
 		}
 
 		requiredCount := int(pt.PackCount * bucket.CollectibleCount)
 		allocatedCount := len(bucket.CollectibleCollection)
 		if requiredCount > allocatedCount {
-			return fmt.Errorf(
+			return newError(ErrorCodeInsufficientEscrow,
 				"collection too small for bucket %d, required %d got %d",
 				i, requiredCount, allocatedCount,
 			)
 		}
+
+		if bucket.Weighted() {
+			if _, err := bucket.TierAllocation(pt.PackCount); err != nil {
+				return withCode(ErrorCodeDistributionInvalidBucket, fmt.Errorf("error in bucket %d: %w", i, err))
+			}
+		}
 	}
 
 	return nil
@@ -67,12 +128,49 @@ func (bucket Bucket) Validate() error {
 	}
 
 	if int(bucket.CollectibleCount) > len(bucket.CollectibleCollection) {
-		return fmt.Errorf(
+		return newError(ErrorCodeInsufficientEscrow,
 			"collection too small, required %d got %d",
 			int(bucket.CollectibleCount), len(bucket.CollectibleCollection),
 		)
 	}
 
+	if len(bucket.CollectibleTiers) > 0 {
+		inCollection := make(map[int64]bool, len(bucket.CollectibleCollection))
+		for _, id := range bucket.CollectibleCollection {
+			inCollection[id.Int64] = true
+		}
+
+		for id, tier := range bucket.CollectibleTiers {
+			if !inCollection[id] {
+				return fmt.Errorf("tier given for collectible %d which is not in the collection", id)
+			}
+			if tier == "" || utf8.RuneCountInString(tier) > maxCollectibleTierLength {
+				return fmt.Errorf("tier of collectible %d must be 1 to %d characters", id, maxCollectibleTierLength)
+			}
+		}
+	}
+
+	if bucket.Weighted() {
+		// Collectibles without a tier would never be picked
+		for _, id := range bucket.CollectibleCollection {
+			if _, ok := bucket.CollectibleTiers[id.Int64]; !ok {
+				return fmt.Errorf("collectible %d has no tier, all collectibles need a tier when using tier weights or guaranteed tiers", id.Int64)
+			}
+		}
+
+		tiers := make(map[string]bool)
+		for _, tier := range bucket.CollectibleTiers {
+			tiers[tier] = true
+		}
+		for _, tc := range []TierCounts{bucket.TierWeights, bucket.GuaranteedTiers} {
+			for _, tier := range tc.sortedTiers() {
+				if !tiers[tier] {
+					return fmt.Errorf("tier '%s' has no collectibles", tier)
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
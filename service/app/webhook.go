@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+// giftWebhookPayload is the body of gift intent webhook requests
+type giftWebhookPayload struct {
+	Event          string                 `json:"event"`
+	GiftIntentID   uuid.UUID              `json:"giftIntentID"`
+	DistributionID uuid.UUID              `json:"distID"`
+	PackFlowID     common.FlowID          `json:"packFlowID"`
+	Recipient      common.FlowAddress     `json:"recipient"`
+	State          common.GiftIntentState `json:"state"`
+	ExpiresAt      *time.Time             `json:"expiresAt,omitempty"`
+	TransferredAt  *time.Time             `json:"transferredAt,omitempty"`
+	TransactionID  string                 `json:"transactionID,omitempty"`
+}
+
+// sendGiftWebhook posts 'event' regarding 'g' to the webhook URL of 'g'.
+// Any non 2xx response is considered an error.
+func sendGiftWebhook(ctx context.Context, client *http.Client, g *GiftIntent, event string) error {
+	body, err := json.Marshal(giftWebhookPayload{
+		Event:          event,
+		GiftIntentID:   g.ID,
+		DistributionID: g.DistributionID,
+		PackFlowID:     g.PackFlowID,
+		Recipient:      g.Recipient,
+		State:          g.State,
+		ExpiresAt:      g.ExpiresAt,
+		TransferredAt:  g.TransferredAt,
+		TransactionID:  g.TransferTransactionID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return postWebhook(ctx, client, g.WebhookURL, body)
+}
+
+// revealWebhookPayload is the body of reveal webhook requests
+type revealWebhookPayload struct {
+	Event          string        `json:"event"`
+	DistributionID uuid.UUID     `json:"distID"`
+	PackID         uuid.UUID     `json:"packID"`
+	PackFlowID     common.FlowID `json:"packFlowID"`
+	Teaser         []string      `json:"teaser,omitempty"`
+	Collectibles   []string      `json:"collectibles,omitempty"`
+}
+
+// sendRevealWebhook posts the reveal stage 'e' of 'p' to the reveal webhook
+// URL of 'dist'. Any non 2xx response is considered an error.
+func sendRevealWebhook(ctx context.Context, client *http.Client, dist *Distribution, p *Pack, e *RevealEvent, reveal *PackReveal) error {
+	payload := revealWebhookPayload{
+		Event:          e.WebhookEvent(),
+		DistributionID: dist.ID,
+		PackID:         p.ID,
+		PackFlowID:     p.FlowID,
+		Teaser:         reveal.Teaser,
+	}
+
+	if e.Stage == RevealStageRevealed {
+		payload.Collectibles = make([]string, len(reveal.Collectibles))
+		for i, c := range reveal.Collectibles {
+			payload.Collectibles[i] = c.String()
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return postWebhook(ctx, client, dist.RevealWebhookURL, body)
+}
+
+// postWebhook posts the JSON 'body' to 'url'.
+func postWebhook(ctx context.Context, client *http.Client, url string, body []byte) error {
+	return postWebhookWithHeaders(ctx, client, url, body, nil)
+}
+
+// postWebhookWithHeaders is like postWebhook, setting 'headers'.
+func postWebhookWithHeaders(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -32,6 +32,11 @@ func (a FlowAddress) Value() (driver.Value, error) {
 }
 
 func (a *FlowAddress) Scan(value interface{}) error {
+	if value == nil {
+		// Column added after the row was created
+		*a = FlowAddress(flow.EmptyAddress)
+		return nil
+	}
 	bytes, ok := value.([]byte)
 	if !ok {
 		return fmt.Errorf("failed to unmarshal FlowAddress value: %v", value)
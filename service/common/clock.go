@@ -0,0 +1,161 @@
+package common
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of time so schedulers, pollers and timeouts
+// can be driven by virtual time in tests and simulations.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the Clock counterpart of time.Ticker.
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// RealClock is a Clock backed by the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (RealClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (RealClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t realTicker) Chan() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()                  { t.t.Stop() }
+
+// VirtualClock is a Clock which only moves forward when told to (Advance).
+// Timers and tickers fire in order as virtual time passes them.
+type VirtualClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*virtualWaiter
+}
+
+type virtualWaiter struct {
+	at     time.Time
+	period time.Duration // Non-zero for tickers
+	c      chan time.Time
+}
+
+type virtualTicker struct {
+	clock  *VirtualClock
+	waiter *virtualWaiter
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	c := &VirtualClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *VirtualClock) After(d time.Duration) <-chan time.Time {
+	return c.addWaiter(d, 0).c
+}
+
+func (c *VirtualClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+func (c *VirtualClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for VirtualClock.NewTicker")
+	}
+	return &virtualTicker{clock: c, waiter: c.addWaiter(d, d)}
+}
+
+// Advance moves the virtual time forward by 'd', firing any timers and
+// tickers that become due along the way.
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	target := c.now.Add(d)
+
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool {
+			return c.waiters[i].at.Before(c.waiters[j].at)
+		})
+
+		if len(c.waiters) == 0 || c.waiters[0].at.After(target) {
+			break
+		}
+
+		w := c.waiters[0]
+		c.now = w.at
+
+		// Drop the tick if the receiver is not keeping up, like time.Ticker
+		select {
+		case w.c <- c.now:
+		default:
+		}
+
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			c.waiters = c.waiters[1:]
+		}
+	}
+
+	c.now = target
+}
+
+// BlockUntil blocks until at least 'n' timers or tickers are waiting on the clock.
+// Useful in tests to make sure a goroutine has reached its wait before advancing.
+func (c *VirtualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *VirtualClock) addWaiter(d, period time.Duration) *virtualWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &virtualWaiter{at: c.now.Add(d), period: period, c: make(chan time.Time, 1)}
+
+	if d <= 0 {
+		w.c <- c.now
+		return w
+	}
+
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+
+	return w
+}
+
+func (c *VirtualClock) removeWaiter(w *virtualWaiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range c.waiters {
+		if v == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *virtualTicker) Chan() <-chan time.Time { return t.waiter.c }
+func (t *virtualTicker) Stop()                  { t.clock.removeWaiter(t.waiter) }
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVirtualClockAfter(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start)
+
+	c := clock.After(time.Minute)
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-c:
+		t.Fatal("did not expect timer to fire yet")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case fired := <-c:
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Fatalf("expected timer to fire at %s, got %s", start.Add(time.Minute), fired)
+		}
+	default:
+		t.Fatal("expected timer to fire")
+	}
+}
+
+func TestVirtualClockTicker(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	ticks := 0
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Second)
+		<-ticker.Chan()
+		ticks++
+	}
+
+	if ticks != 5 {
+		t.Fatalf("expected 5 ticks, got %d", ticks)
+	}
+
+	if got := clock.Now(); !got.Equal(time.Unix(5, 0)) {
+		t.Fatalf("expected clock to be at %s, got %s", time.Unix(5, 0), got)
+	}
+}
+
+func TestVirtualClockSleep(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Hour)
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected sleep to return after advancing the clock")
+	}
+}
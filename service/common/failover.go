@@ -0,0 +1,274 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Errors which mean the database is unreachable or can not be written to
+// - postgres: read_only_sql_transaction, admin/crash shutdown, cannot connect now, connection exceptions
+// - mysql: --read-only, super-read-only, read only transaction
+var failoverErrorRegexp = regexp.MustCompile(`SQLSTATE (25006|57P01|57P02|57P03|08\w{3})|Error (1290|1836|1792)|invalid connection`)
+
+// newFailoverGormDB opens a gorm.DB which fails over between the primary
+// and standby databases in 'cfg'.
+func newFailoverGormDB(cfg *config.Config) (*gorm.DB, error) {
+	if cfg.DatabaseType != dbTypePostgresql && cfg.DatabaseType != dbTypeMysql {
+		return nil, fmt.Errorf("standby database not supported for database type '%s'", cfg.DatabaseType)
+	}
+
+	options := &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+		// Connectivity is checked below, primary may be down on startup
+		DisableAutomaticPing: true,
+	}
+
+	dsns := []string{cfg.DatabaseDSN, cfg.DatabaseStandbyDSN}
+	dbs := make([]*gorm.DB, len(dsns))
+
+	for i, dsn := range dsns {
+		var dialector gorm.Dialector
+		switch cfg.DatabaseType {
+		case dbTypePostgresql:
+			dialector = postgres.Open(dsn)
+		case dbTypeMysql:
+			// Querying the version on open would fail if the database is down
+			dialector = mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: true})
+		}
+
+		db, err := gorm.Open(dialector, options)
+		if err != nil {
+			return nil, err
+		}
+		dbs[i] = db
+	}
+
+	pool := &failoverConnPool{
+		dbType:   cfg.DatabaseType,
+		interval: cfg.DatabaseFailoverInterval,
+		dbs:      make([]*sql.DB, len(dbs)),
+	}
+
+	for i, db := range dbs {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		pool.dbs[i] = sqlDB
+	}
+
+	if err := pool.failover(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Use the primary gorm.DB, only switching the connection pool
+	db := dbs[0]
+	db.Config.ConnPool = pool
+	db.Statement.ConnPool = pool
+
+	return db, nil
+}
+
+// failoverConnPool is a gorm.ConnPool which switches between a primary and a
+// standby database when the active one becomes unreachable or read-only.
+// The failed call is not retried, callers (pollers) are expected to retry.
+type failoverConnPool struct {
+	dbType   string
+	interval time.Duration // Min time between failover checks
+
+	mu        sync.RWMutex
+	dbs       []*sql.DB // Primary first
+	active    int
+	lastCheck time.Time
+}
+
+func (p *failoverConnPool) current() *sql.DB {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dbs[p.active]
+}
+
+// check triggers a failover if 'err' means the active database is no
+// longer usable. Returns 'err' as is.
+func (p *failoverConnPool) check(err error) error {
+	if err == nil || !isDatabaseFailoverError(err) {
+		return err
+	}
+
+	p.mu.Lock()
+	skip := time.Since(p.lastCheck) < p.interval
+	if !skip {
+		p.lastCheck = time.Now()
+	}
+	p.mu.Unlock()
+
+	if !skip {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if fErr := p.failover(ctx); fErr != nil {
+				log.WithFields(log.Fields{"error": fErr}).Error("Database failover failed")
+			}
+		}()
+	}
+
+	return err
+}
+
+// failover activates the first (primary first) database which is reachable
+// and writable.
+func (p *failoverConnPool) failover(ctx context.Context) error {
+	for i, db := range p.dbs {
+		if err := checkWritable(ctx, p.dbType, db); err != nil {
+			log.WithFields(log.Fields{"database": i, "error": err}).Warn("Database not usable")
+			continue
+		}
+
+		p.mu.Lock()
+		previous := p.active
+		p.active = i
+		p.mu.Unlock()
+
+		if previous != i {
+			log.WithFields(log.Fields{"from": previous, "to": i}).Warn("Database failover, switched active database (0 = primary, 1 = standby)")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("no writable database available")
+}
+
+// checkWritable returns an error if 'db' can not be connected to or is in
+// read-only (recovery/replica) mode.
+func checkWritable(ctx context.Context, dbType string, db *sql.DB) error {
+	var readOnly bool
+	switch dbType {
+	case dbTypePostgresql:
+		if err := db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&readOnly); err != nil {
+			return err
+		}
+	case dbTypeMysql:
+		if err := db.QueryRowContext(ctx, "SELECT @@global.read_only").Scan(&readOnly); err != nil {
+			return err
+		}
+	}
+	if readOnly {
+		return fmt.Errorf("database is read-only")
+	}
+	return nil
+}
+
+func isDatabaseFailoverError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return failoverErrorRegexp.MatchString(err.Error())
+}
+
+func (p *failoverConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := p.current().PrepareContext(ctx, query)
+	return stmt, p.check(err)
+}
+
+func (p *failoverConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := p.current().ExecContext(ctx, query, args...)
+	return res, p.check(err)
+}
+
+func (p *failoverConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := p.current().QueryContext(ctx, query, args...)
+	return rows, p.check(err)
+}
+
+func (p *failoverConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := p.current().QueryRowContext(ctx, query, args...)
+	_ = p.check(row.Err())
+	return row
+}
+
+// BeginTx implements gorm.ConnPoolBeginner
+func (p *failoverConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	tx, err := p.current().BeginTx(ctx, opts)
+	if err != nil {
+		return nil, p.check(err)
+	}
+	return &failoverTx{tx, p}, nil
+}
+
+// GetDBConn implements gorm.GetDBConnector
+func (p *failoverConnPool) GetDBConn() (*sql.DB, error) {
+	return p.current(), nil
+}
+
+func (p *failoverConnPool) Ping() error {
+	return p.check(p.current().Ping())
+}
+
+func (p *failoverConnPool) Close() error {
+	var err error
+	for _, db := range p.dbs {
+		if closeErr := db.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// failoverTx is a transaction of a failoverConnPool, errors are checked the
+// same way as outside of transactions.
+type failoverTx struct {
+	tx   *sql.Tx
+	pool *failoverConnPool
+}
+
+func (t *failoverTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	return stmt, t.pool.check(err)
+}
+
+func (t *failoverTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	return res, t.pool.check(err)
+}
+
+func (t *failoverTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	return rows, t.pool.check(err)
+}
+
+func (t *failoverTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	_ = t.pool.check(row.Err())
+	return row
+}
+
+func (t *failoverTx) Commit() error {
+	return t.pool.check(t.tx.Commit())
+}
+
+func (t *failoverTx) Rollback() error {
+	return t.pool.check(t.tx.Rollback())
+}
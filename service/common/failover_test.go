@@ -0,0 +1,203 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDatabase is a database of the "failovertest" driver, by DSN.
+type fakeDatabase struct {
+	mu       sync.Mutex
+	down     bool
+	readOnly bool
+}
+
+func (d *fakeDatabase) set(down, readOnly bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down, d.readOnly = down, readOnly
+}
+
+func (d *fakeDatabase) state() (down, readOnly bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.down, d.readOnly
+}
+
+var fakeDatabases = map[string]*fakeDatabase{
+	"primary": {},
+	"standby": {},
+}
+
+func init() {
+	sql.Register("failovertest", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	db := fakeDatabases[dsn]
+	if down, _ := db.state(); down {
+		return nil, errors.New("dial tcp: connection refused (SQLSTATE 08001)")
+	}
+	return &fakeConn{db}, nil
+}
+
+// fakeConn answers the read-only check of postgres and fails writes while
+// its database is read-only.
+type fakeConn struct {
+	db *fakeDatabase
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if down, _ := c.db.state(); down {
+		return nil, driver.ErrBadConn
+	}
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	down, readOnly := c.db.state()
+	if down {
+		return nil, driver.ErrBadConn
+	}
+	return &fakeRows{value: readOnly}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	down, readOnly := c.db.state()
+	if down {
+		return nil, driver.ErrBadConn
+	}
+	if readOnly {
+		return nil, errors.New("ERROR: cannot execute INSERT in a read-only transaction (SQLSTATE 25006)")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeRows is a single row of a single boolean.
+type fakeRows struct {
+	value bool
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestIsDatabaseFailoverError(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected bool
+	}{
+		{fmt.Errorf("wrapped: %w", driver.ErrBadConn), true},
+		{errors.New("ERROR: cannot execute INSERT in a read-only transaction (SQLSTATE 25006)"), true},
+		{errors.New("FATAL: terminating connection due to administrator command (SQLSTATE 57P01)"), true},
+		{errors.New("Error 1290: The MySQL server is running with the --read-only option"), true},
+		{errors.New("ERROR: duplicate key value violates unique constraint (SQLSTATE 23505)"), false},
+		{errors.New("record not found"), false},
+	} {
+		if got := isDatabaseFailoverError(c.err); got != c.expected {
+			t.Errorf("expected %v for %q, got %v", c.expected, c.err, got)
+		}
+	}
+}
+
+func TestFailoverConnPool(t *testing.T) {
+	ctx := context.Background()
+	primary, standby := fakeDatabases["primary"], fakeDatabases["standby"]
+	primary.set(false, false)
+	standby.set(false, true)
+
+	pool := &failoverConnPool{dbType: dbTypePostgresql}
+	for _, dsn := range []string{"primary", "standby"} {
+		db, err := sql.Open("failovertest", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.dbs = append(pool.dbs, db)
+	}
+	defer pool.Close()
+
+	waitForActive := func(i int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for pool.current() != pool.dbs[i] {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout while waiting for database %d to be active", i)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := pool.failover(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(0)
+
+	// Primary demoted to a read-only replica, the standby promoted
+	primary.set(false, true)
+	standby.set(false, false)
+	if _, err := pool.ExecContext(ctx, "INSERT"); !isDatabaseFailoverError(err) {
+		t.Fatalf("expected the read-only error, got %v", err)
+	}
+	waitForActive(1)
+	if _, err := pool.ExecContext(ctx, "INSERT"); err != nil {
+		t.Errorf("expected writes to go to the standby, got %v", err)
+	}
+
+	// Standby unreachable, primary writable again, detected in a transaction
+	primary.set(false, false)
+	standby.set(true, false)
+	tx, err := pool.BeginTx(ctx, nil)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, "INSERT")
+	}
+	if !isDatabaseFailoverError(err) {
+		t.Fatalf("expected the connection error, got %v", err)
+	}
+	waitForActive(0)
+	if _, err := pool.ExecContext(ctx, "INSERT"); err != nil {
+		t.Errorf("expected writes to go to the primary, got %v", err)
+	}
+
+	// Nothing to fail over to, the active database is kept
+	primary.set(true, false)
+	if err := pool.failover(ctx); err == nil {
+		t.Error("expected an error without a writable database")
+	}
+	waitForActive(0)
+}
@@ -18,6 +18,10 @@ const (
 )
 
 func NewGormDB(cfg *config.Config) (*gorm.DB, error) {
+	if cfg.DatabaseStandbyDSN != "" {
+		return newFailoverGormDB(cfg)
+	}
+
 	var dialector gorm.Dialector
 	switch cfg.DatabaseType {
 	default:
@@ -43,6 +47,13 @@ func NewGormDB(cfg *config.Config) (*gorm.DB, error) {
 }
 
 func CloseGormDB(db *gorm.DB) {
+	if pool, ok := db.Config.ConnPool.(*failoverConnPool); ok {
+		if err := pool.Close(); err != nil {
+			panic("unable to close database")
+		}
+		return
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		panic("unable to close database")
@@ -3,6 +3,9 @@ package common
 type DistributionState string
 type PackState string
 type TransactionState string
+type OwnershipVerificationState string
+type GiftIntentState string
+type KeyRotationState string
 
 const (
 	DistributionStateInit     DistributionState = "init"
@@ -13,6 +16,15 @@ const (
 	DistributionStateSettled  DistributionState = "settled"
 	DistributionStateMinting  DistributionState = "minting"
 	DistributionStateComplete DistributionState = "complete"
+	DistributionStateClosed   DistributionState = "closed"
+	// Aborted after settlement started, set once its escrow is returned
+	DistributionStateCancelled DistributionState = "cancelled"
+	// Resolved, waiting for its settlement start time before being set up
+	DistributionStateScheduled DistributionState = "scheduled"
+	// Exceeded the max duration of its state, waits for an admin to retry or abort it
+	DistributionStateStalled DistributionState = "stalled"
+	// Cloned from another distribution, waits for the collectibles of its buckets before being resolved
+	DistributionStateDraft DistributionState = "draft"
 )
 
 const (
@@ -23,6 +35,12 @@ const (
 	PackStateOpenRequestHandled   PackState = "open-request-handled"
 	PackStateOpened               PackState = "opened"
 	PackStateEmpty                PackState = "empty"
+	// Never minted, its distribution was aborted
+	PackStateCancelled PackState = "cancelled"
+	// Never minted, its mint transactions failed too many times
+	PackStateMintFailed PackState = "mint-failed"
+	// Minted, revoked by an admin (e.g. sold fraudulently), never revealed or opened
+	PackStateRevoked PackState = "revoked"
 )
 
 const (
@@ -31,4 +49,26 @@ const (
 	TransactionStateSent     TransactionState = "sent"
 	TransactionStateFailed   TransactionState = "failed"
 	TransactionStateComplete TransactionState = "complete"
+	// Out of retries, waits for an admin to requeue it
+	TransactionStateDeadLetter TransactionState = "dead-letter"
+	// Never sent, its distribution was aborted
+	TransactionStateCancelled TransactionState = "cancelled"
+)
+
+const (
+	OwnershipVerificationStateInit     OwnershipVerificationState = "init"
+	OwnershipVerificationStateRunning  OwnershipVerificationState = "running"
+	OwnershipVerificationStateComplete OwnershipVerificationState = "complete"
+)
+
+const (
+	GiftIntentStatePending     GiftIntentState = "pending"
+	GiftIntentStateTransferred GiftIntentState = "transferred"
+	GiftIntentStateExpired     GiftIntentState = "expired"
+)
+
+const (
+	KeyRotationStateRevoking KeyRotationState = "revoking"
+	KeyRotationStateComplete KeyRotationState = "complete"
+	KeyRotationStateFailed   KeyRotationState = "failed"
 )
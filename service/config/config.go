@@ -1,51 +1,409 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 )
 
+// Config of the PDS. Values which may hold secrets are redacted when shown
+// (see Redacted) unless their field is tagged `redact:"false"`.
 type Config struct {
 	// -- Admin (or the PDS) account --
 
-	AdminAddress           string `env:"FLOW_PDS_ADMIN_ADDRESS,notEmpty"`
+	AdminAddress           string `env:"FLOW_PDS_ADMIN_ADDRESS,notEmpty" redact:"false"`
 	AdminPrivateKey        string `env:"FLOW_PDS_ADMIN_PRIVATE_KEY,notEmpty"`
-	AdminPrivateKeyIndexes []int  `env:"FLOW_PDS_ADMIN_PRIVATE_KEY_INDEXES,notEmpty" envDefault:"0" envSeparator:","`
-	AdminPrivateKeyType    string `env:"FLOW_PDS_ADMIN_PRIVATE_KEY_TYPE,notEmpty" envDefault:"local"`
+	AdminPrivateKeyIndexes []int  `env:"FLOW_PDS_ADMIN_PRIVATE_KEY_INDEXES,notEmpty" envDefault:"0" envSeparator:"," redact:"false"`
+	AdminPrivateKeyType    string `env:"FLOW_PDS_ADMIN_PRIVATE_KEY_TYPE,notEmpty" envDefault:"local" redact:"false"`
+
+	// -- Key compromise response --
+
+	// Standby key set of the admin account, switched to by the rotate-and-freeze
+	// admin operation. The keys have to be added to the account beforehand.
+	StandbyPrivateKey        string `env:"FLOW_PDS_STANDBY_PRIVATE_KEY"`
+	StandbyPrivateKeyIndexes []int  `env:"FLOW_PDS_STANDBY_PRIVATE_KEY_INDEXES" envSeparator:"," redact:"false"`
+	StandbyPrivateKeyType    string `env:"FLOW_PDS_STANDBY_PRIVATE_KEY_TYPE" envDefault:"local" redact:"false"`
+	// Pre-authorized (full weight) key of the admin account used to revoke the
+	// active keys when rotating
+	RecoveryPrivateKey      string `env:"FLOW_PDS_RECOVERY_PRIVATE_KEY"`
+	RecoveryPrivateKeyIndex int    `env:"FLOW_PDS_RECOVERY_PRIVATE_KEY_INDEX"`
+	RecoveryPrivateKeyType  string `env:"FLOW_PDS_RECOVERY_PRIVATE_KEY_TYPE" envDefault:"local" redact:"false"`
+	// Optional URL notified (POST, JSON) of key rotations
+	KeyRotationWebhookURL string `env:"FLOW_PDS_KEY_ROTATION_WEBHOOK_URL"`
+
+	// -- Pack salts --
+
+	// Optional, hex encoded AES key (16, 24 or 32 bytes) encrypting the salts
+	// of packs at rest, salts are stored in plain text if not set. Packs with
+	// encrypted salts can not be revealed without it.
+	SaltEncryptionKey string `env:"FLOW_PDS_SALT_ENCRYPTION_KEY"`
 
 	// -- Flow addresses --
 	// Address of the PDS account, usually this should equal to 'AdminAddress'
-	PDSAddress              string `env:"PDS_ADDRESS,notEmpty"`
-	NonFungibleTokenAddress string `env:"NON_FUNGIBLE_TOKEN_ADDRESS,notEmpty"`
+	PDSAddress              string `env:"PDS_ADDRESS,notEmpty" redact:"false"`
+	NonFungibleTokenAddress string `env:"NON_FUNGIBLE_TOKEN_ADDRESS,notEmpty" redact:"false"`
+	// Optional, required for packs holding fungible tokens
+	FungibleTokenAddress string `env:"FUNGIBLE_TOKEN_ADDRESS" redact:"false"`
+
+	// -- Collectible contracts --
+
+	// Flow network the PDS is running on (emulator, testnet, mainnet),
+	// selects which of the 'CollectibleContracts' are used
+	FlowNetwork string `env:"FLOW_PDS_NETWORK" envDefault:"emulator" redact:"false"`
+	// Collectible NFT contracts per network as JSON, see CollectibleContracts.
+	// If set for the current network, distributions can only use the listed
+	// contracts and may leave out the contract address. Any contract is
+	// allowed if not set.
+	CollectibleContracts CollectibleContracts `env:"FLOW_PDS_COLLECTIBLE_CONTRACTS" redact:"false"`
+	// First collectible ID reserved for a contract minting collectibles on
+	// open, used when IDs of the contract are reserved the first time
+	MintOnOpenFirstID int64 `env:"FLOW_PDS_MINT_ON_OPEN_FIRST_ID" envDefault:"1"`
+	// Max number of collectible IDs reserved for a pack at once
+	MintOnOpenMaxIDsPerPack int `env:"FLOW_PDS_MINT_ON_OPEN_MAX_IDS_PER_PACK" envDefault:"100"`
 
 	// -- Database --
 
 	DatabaseDSN  string `env:"FLOW_PDS_DATABASE_DSN" envDefault:"pds.db"`
-	DatabaseType string `env:"FLOW_PDS_DATABASE_TYPE" envDefault:"sqlite"`
+	DatabaseType string `env:"FLOW_PDS_DATABASE_TYPE" envDefault:"sqlite" redact:"false"`
+	// Optional standby database (e.g. a replica in another region). If set, the
+	// PDS fails over between the primary and the standby when the active one becomes
+	// unreachable or read-only (e.g. after a managed database failover).
+	// Not supported for sqlite.
+	DatabaseStandbyDSN string `env:"FLOW_PDS_DATABASE_STANDBY_DSN"`
+	// Min time between failover checks
+	DatabaseFailoverInterval time.Duration `env:"FLOW_PDS_DATABASE_FAILOVER_INTERVAL" envDefault:"5s"`
+
+	// -- Cadence templates --
+
+	// Directory to load Cadence transaction and script templates from instead of
+	// the ones embedded in the binary, using the same layout as the repository
+	// (e.g. 'cadence-transactions/pds/settle.cdc'). Templates not found in the
+	// directory fall back to the embedded ones.
+	CadenceTemplateDir string `env:"FLOW_PDS_CADENCE_TEMPLATE_DIR" redact:"false"`
 
 	// -- Host and chain access --
 
-	Host          string `env:"FLOW_PDS_HOST"`
-	Port          int    `env:"FLOW_PDS_PORT" envDefault:"3000"`
-	AccessAPIHost string `env:"FLOW_PDS_ACCESS_API_HOST" envDefault:"localhost:3569"`
+	Host string `env:"FLOW_PDS_HOST" redact:"false"`
+	Port int    `env:"FLOW_PDS_PORT" envDefault:"3000"`
+
+	// Port of the gRPC API (see proto/flowpds/v1/pds.proto), served on
+	// 'Host' next to the REST API. Disabled if 0.
+	GRPCPort int `env:"FLOW_PDS_GRPC_PORT" envDefault:"0"`
+
+	// Timeout of each dependency check of the readiness probe (/readyz)
+	ReadinessCheckTimeout time.Duration `env:"FLOW_PDS_READINESS_CHECK_TIMEOUT" envDefault:"5s"`
+
+	// Interval at which distribution event streams (/v1/distributions/{id}/events)
+	// check the progress of their distribution
+	DistributionEventsInterval time.Duration `env:"FLOW_PDS_DISTRIBUTION_EVENTS_INTERVAL" envDefault:"2s"`
+
+	// Max time to drain on SIGINT or SIGTERM. Running HTTP and gRPC requests and
+	// the running poller step are let finish, work still running afterwards is
+	// cancelled and its database transaction rolled back.
+	ShutdownGracePeriod time.Duration `env:"FLOW_PDS_SHUTDOWN_GRACE_PERIOD" envDefault:"25s"`
+
+	// Bearer token required by admin endpoints (e.g. /v1/system/config),
+	// admin endpoints are disabled if not set
+	AdminAPIToken string `env:"FLOW_PDS_ADMIN_API_TOKEN"`
+
+	// Require an API key of the issuer (or the admin token) for mutating
+	// endpoints, keys are created through the admin API
+	APIKeysRequired bool `env:"FLOW_PDS_API_KEYS_REQUIRED" envDefault:"false"`
+
+	// Only serve issuers registered through the admin API: distributions, API
+	// keys and webhooks of other addresses are refused
+	IssuersRequired bool `env:"FLOW_PDS_ISSUERS_REQUIRED" envDefault:"false"`
+	// Require authentication for reading distributions too, issuers only see
+	// their own distributions (the admin token sees all)
+	IssuerIsolation bool `env:"FLOW_PDS_ISSUER_ISOLATION" envDefault:"false"`
+
+	// URL of an OpenID Connect identity provider whose JWTs are accepted on
+	// the mutating endpoints (like API keys, which become required), signed
+	// with a key from the JWKS of its OpenID configuration. Not accepted if
+	// not set.
+	JWTIssuerURL string `env:"FLOW_PDS_JWT_ISSUER_URL" redact:"false"`
+	// JWKS URL of the identity provider, found from its OpenID configuration
+	// if not set
+	JWTJWKSURL string `env:"FLOW_PDS_JWT_JWKS_URL" redact:"false"`
+	// Audience ('aud' claim) tokens need to be issued for, required with
+	// JWTIssuerURL
+	JWTAudience string `env:"FLOW_PDS_JWT_AUDIENCE" redact:"false"`
+	// Claim holding the Flow address of the issuer a token can act for,
+	// required with JWTIssuerURL unless JWTAnyIssuer is set
+	JWTIssuerClaim string `env:"FLOW_PDS_JWT_ISSUER_CLAIM" redact:"false"`
+	// Let tokens act for any issuer, instead of the one of JWTIssuerClaim
+	JWTAnyIssuer bool `env:"FLOW_PDS_JWT_ANY_ISSUER" envDefault:"false"`
+	// How long to cache the JWKS of the identity provider
+	JWTJWKSCacheTTL time.Duration `env:"FLOW_PDS_JWT_JWKS_CACHE_TTL" envDefault:"1h"`
+
+	// Requests per second allowed per client of the REST API, keyed by the
+	// bearer token (once authenticated) or else the client IP. Disabled if 0.
+	RateLimit      int `env:"FLOW_PDS_RATE_LIMIT" envDefault:"0"`
+	RateLimitBurst int `env:"FLOW_PDS_RATE_LIMIT_BURST" envDefault:"20"`
+	// Take the client IP from the X-Forwarded-For header, only set this
+	// behind a proxy which sets the header
+	RateLimitTrustForwardedFor bool `env:"FLOW_PDS_RATE_LIMIT_TRUST_FORWARDED_FOR" envDefault:"false"`
+	// Proxies whose X-Forwarded-For hops are skipped to find the client IP,
+	// besides the one the PDS is connected to
+	RateLimitTrustedProxies TrustedProxies `env:"FLOW_PDS_RATE_LIMIT_TRUSTED_PROXIES" redact:"false"`
+
+	// Origins allowed to call the REST API from a browser (CORS), "*" allows
+	// any and CORS is disabled if empty
+	CORSAllowedOrigins []string `env:"FLOW_PDS_CORS_ALLOWED_ORIGINS" envDefault:"*" envSeparator:"," redact:"false"`
+	// Methods and headers (besides the CORS safelisted ones) allowed in CORS
+	// requests
+	CORSAllowedMethods []string `env:"FLOW_PDS_CORS_ALLOWED_METHODS" envDefault:"GET,HEAD,POST" envSeparator:"," redact:"false"`
+	CORSAllowedHeaders []string `env:"FLOW_PDS_CORS_ALLOWED_HEADERS" envSeparator:"," redact:"false"`
+
+	// Directory holding the files of swagger-ui-dist (see 'make swagger-ui'),
+	// served with the Swagger UI at /docs. Not served if not set.
+	SwaggerUIDir string `env:"FLOW_PDS_SWAGGER_UI_DIR" redact:"false"`
+
+	// Comma separated list of Access API hosts. If more than one is given,
+	// reads are load balanced between them and calls fail over to the next host
+	// when one becomes unavailable or rate limits us.
+	AccessAPIHosts []string `env:"FLOW_PDS_ACCESS_API_HOST" envDefault:"localhost:3569" envSeparator:"," redact:"false"`
+	// How often to health check the Access API hosts (multiple hosts only)
+	AccessAPIHealthCheckInterval time.Duration `env:"FLOW_PDS_ACCESS_API_HEALTH_CHECK_INTERVAL" envDefault:"10s"`
+
+	// Stop calling the Access API after this many consecutive calls failed with
+	// the access node unavailable, 0 disables the circuit breaker
+	AccessAPIBreakerThreshold int `env:"FLOW_PDS_ACCESS_API_BREAKER_THRESHOLD" envDefault:"5"`
+	// How long to wait before probing the Access API again
+	AccessAPIBreakerCooldown time.Duration `env:"FLOW_PDS_ACCESS_API_BREAKER_COOLDOWN" envDefault:"10s"`
+
+	// Comma separated list of Access API hosts a distribution is allowed to
+	// use instead of the global hosts (e.g. a dedicated node for a high-profile drop).
+	// Per-distribution overrides are disabled if empty.
+	AccessAPIOverrideHosts []string `env:"FLOW_PDS_ACCESS_API_OVERRIDE_HOSTS" envSeparator:"," redact:"false"`
+
+	// Root height of the spork served by the Access API hosts, queries for
+	// older blocks go to the historical access nodes
+	AccessAPISporkRootHeight uint64 `env:"FLOW_PDS_ACCESS_API_SPORK_ROOT_HEIGHT" envDefault:"0"`
+	// Comma separated list of historical (past spork) access nodes as
+	// 'rootHeight=host', each serving the blocks up to the root height of
+	// the next spork
+	AccessAPIHistoricalHosts []string `env:"FLOW_PDS_ACCESS_API_HISTORICAL_HOSTS" envSeparator:"," redact:"false"`
+
+	// Use a secure (TLS) gRPC connection to the Access API
+	AccessAPIUseTLS bool `env:"FLOW_PDS_ACCESS_API_USE_TLS" envDefault:"false"`
+	// PEM encoded CA certificate(s) used to verify the Access API host,
+	// system root CAs are used if not set
+	AccessAPITLSCACertFile string `env:"FLOW_PDS_ACCESS_API_TLS_CA_CERT_FILE" redact:"false"`
+	// PEM encoded client certificate and key, only needed if the Access API
+	// requires client certificates (mutual TLS)
+	AccessAPITLSCertFile string `env:"FLOW_PDS_ACCESS_API_TLS_CERT_FILE" redact:"false"`
+	AccessAPITLSKeyFile  string `env:"FLOW_PDS_ACCESS_API_TLS_KEY_FILE" redact:"false"`
+	// Override the server name used to verify the Access API certificate
+	AccessAPITLSServerName string `env:"FLOW_PDS_ACCESS_API_TLS_SERVER_NAME" redact:"false"`
+
+	// -- Issuer callbacks --
+
+	// How far the timestamp of a signed issuer callback or request can be from
+	// the current time, limits how long a captured callback can be replayed
+	IssuerCallbackMaxSkew time.Duration `env:"FLOW_PDS_ISSUER_CALLBACK_MAX_SKEW" envDefault:"5m"`
+
+	// -- Public stats --
+
+	// Min number of opted in issuers (with distributions) before the public
+	// stats are shown, so the numbers of a single issuer are not exposed
+	PublicStatsMinIssuers uint `env:"FLOW_PDS_PUBLIC_STATS_MIN_ISSUERS" envDefault:"3"`
+	// Public counts are rounded down to a multiple of this
+	PublicStatsRoundTo uint `env:"FLOW_PDS_PUBLIC_STATS_ROUND_TO" envDefault:"10"`
+	// How long to cache the public stats
+	PublicStatsCacheTTL time.Duration `env:"FLOW_PDS_PUBLIC_STATS_CACHE_TTL" envDefault:"1m"`
+
+	// -- Logging --
+
+	// Global log level (trace, debug, info, warn, error)
+	LogLevel string `env:"FLOW_PDS_LOG_LEVEL" envDefault:"info" redact:"false"`
+	// Log format, 'text' or 'json'
+	LogFormat string `env:"FLOW_PDS_LOG_FORMAT" envDefault:"text" redact:"false"`
+	// Per-subsystem log level overrides, global level is used if not set
+	LogLevelHTTP       string `env:"FLOW_PDS_LOG_LEVEL_HTTP" redact:"false"`
+	LogLevelSettlement string `env:"FLOW_PDS_LOG_LEVEL_SETTLEMENT" redact:"false"`
+	LogLevelMinting    string `env:"FLOW_PDS_LOG_LEVEL_MINTING" redact:"false"`
+	LogLevelEvents     string `env:"FLOW_PDS_LOG_LEVEL_EVENTS" redact:"false"`
+
+	// -- Dry-run --
+
+	// Build, sign and log transactions but never send them, settle and mint
+	// transactions are simulated so distributions still walk through all
+	// states. Use with a throwaway database.
+	DryRun bool `env:"FLOW_PDS_DRY_RUN" envDefault:"false"`
+
+	// -- Transaction confirmation --
+
+	// How often to poll for the result of a transaction while waiting for it to seal
+	TransactionResultPollInterval time.Duration `env:"FLOW_PDS_TRANSACTION_RESULT_POLL_INTERVAL" envDefault:"1s"`
+	// Max delay between polls for the result of a transaction, the delay grows
+	// by half from the poll interval for each poll (with some jitter)
+	TransactionResultMaxPollInterval time.Duration `env:"FLOW_PDS_TRANSACTION_RESULT_MAX_POLL_INTERVAL" envDefault:"5s"`
+	// How often to poll for the result of a sent transaction while waiting for it to
+	// finalize (the proposal key is released once it does)
+	TransactionFinalizePollInterval time.Duration `env:"FLOW_PDS_TRANSACTION_FINALIZE_POLL_INTERVAL" envDefault:"100ms"`
+	// Max time to wait for a transaction to seal when waiting synchronously
+	// (e.g. setting up a distribution)
+	TransactionSealTimeout time.Duration `env:"FLOW_PDS_TRANSACTION_SEAL_TIMEOUT" envDefault:"10m"`
+	// Number of blocks to wait past the expiry of a transactions reference block
+	// (600 blocks) before a sent but unexecuted transaction is considered expired
+	// and retried. Guards against lagging access nodes.
+	TransactionExpiryMargin uint64 `env:"FLOW_PDS_TRANSACTION_EXPIRY_MARGIN" envDefault:"10"`
+	// How many times to send a transaction which expires or fails with a retryable
+	// error (e.g. connection reset, sequence number mismatch) before giving up.
+	// 0 means no limit.
+	TransactionMaxAttempts uint `env:"FLOW_PDS_TRANSACTION_MAX_ATTEMPTS" envDefault:"10"`
+	// Delay before the first retry of a transaction, doubled for each retry
+	// after that up to 'TransactionRetryMaxBackoff'
+	TransactionRetryBackoff    time.Duration `env:"FLOW_PDS_TRANSACTION_RETRY_BACKOFF" envDefault:"1s"`
+	TransactionRetryMaxBackoff time.Duration `env:"FLOW_PDS_TRANSACTION_RETRY_MAX_BACKOFF" envDefault:"5m"`
+	// How long to reuse the latest sealed block as the reference block of sent
+	// transactions, refreshed in the background after half of it. 0 fetches
+	// the latest block for every transaction.
+	ReferenceBlockCacheTTL time.Duration `env:"FLOW_PDS_REFERENCE_BLOCK_CACHE_TTL" envDefault:"5s"`
 
 	// -- Rates etc. ---
 
-	// How many transactions to send per second at max
-	TransactionSendRate int    `env:"FLOW_PDS_SEND_RATE" envDefault:"10"`
+	// How many distributions can be in progress (setup, settling or minting) at
+	// the same time, others wait in 'resolved' state. 0 means no limit.
+	MaxConcurrentDistributions int `env:"FLOW_PDS_MAX_CONCURRENT_DISTRIBUTIONS" envDefault:"0"`
+
+	// How many transactions to send per second at max, over all accounts
+	TransactionSendRate int `env:"FLOW_PDS_SEND_RATE" envDefault:"10"`
+	// How many transactions proposed or paid by a single account to send per
+	// second at max, 0 means only the global limit applies
+	TransactionSendRatePerAccount int `env:"FLOW_PDS_SEND_RATE_PER_ACCOUNT" envDefault:"0"`
+	// How many transactions can be sent at once before the rates apply
+	TransactionSendBurst int `env:"FLOW_PDS_SEND_BURST" envDefault:"1"`
+
+	// Weights of the send lanes, user facing (reveal and open), settlement
+	// (setup, settle and state changes) and minting transactions are sent
+	// from in this ratio while all lanes have transactions to send. A lane
+	// with a weight of 0 is only sent from when the others are empty
+	TransactionWeightUserFacing uint `env:"FLOW_PDS_SEND_WEIGHT_USER_FACING" envDefault:"6"`
+	TransactionWeightSettlement uint `env:"FLOW_PDS_SEND_WEIGHT_SETTLEMENT" envDefault:"3"`
+	TransactionWeightMinting    uint `env:"FLOW_PDS_SEND_WEIGHT_MINTING" envDefault:"1"`
+
 	TransactionGasLimit uint64 `env:"FLOW_PDS_GAS_LIMIT" envDefault:"9999"`
-	// Going much above 40 will cause the transactions to use more than 9999 gas
+	// Network limits transactions are checked against before sending, a
+	// settlement or minting batch exceeding them is split into smaller batches.
+	// Max encoded size of a transaction (the network default), and max number
+	// of argument values (array items included), 0 means no limit.
+	TransactionMaxByteSize       int `env:"FLOW_PDS_TRANSACTION_MAX_BYTE_SIZE" envDefault:"1500000"`
+	TransactionMaxArgumentValues int `env:"FLOW_PDS_TRANSACTION_MAX_ARGUMENT_VALUES" envDefault:"0"`
+	// Going much above 40 will cause the transactions to use more than 9999 gas,
+	// see AdaptiveBatchSize
 	SettlementBatchSize int `env:"FLOW_PDS_SETTLEMENT_BATCH_SIZE" envDefault:"40"`
 	MintingBatchSize    int `env:"FLOW_PDS_MINTING_BATCH_SIZE" envDefault:"40"`
+	// Halve the settlement and minting batch sizes when a batch runs out of gas
+	// (and split the failed batch), grow them back towards the configured sizes
+	// as batches seal
+	AdaptiveBatchSize bool `env:"FLOW_PDS_ADAPTIVE_BATCH_SIZE" envDefault:"false"`
+
+	// How many settle transactions of a distribution can be pending at the
+	// same time, more are queued as earlier ones finish. 0 queues all of them
+	// when the settlement starts.
+	SettlementMaxPendingBatches int `env:"FLOW_PDS_SETTLEMENT_MAX_PENDING_BATCHES" envDefault:"0"`
+	// How many mint transactions of a distribution can be pending at the same
+	// time, more are queued as earlier ones finish. 0 queues all of them when
+	// the minting starts.
+	MintingMaxPendingBatches int `env:"FLOW_PDS_MINTING_MAX_PENDING_BATCHES" envDefault:"0"`
+
+	// Seal latency (from sending a transaction to its result) assumed when
+	// estimating the completion of a settlement or minting until batch
+	// transactions of the distribution have sealed, see DistributionETA
+	EstimatedSealLatency time.Duration `env:"FLOW_PDS_ESTIMATED_SEAL_LATENCY" envDefault:"10s"`
+	// How many blocks after committing to the secret of a commit-reveal
+	// distribution its seed is anchored to, the packs are resolved once the
+	// block at that height is sealed.
+	SeedAnchorDelay uint64 `env:"FLOW_PDS_SEED_ANCHOR_DELAY" envDefault:"10"`
+	// How many failed mint transactions a pack can be included in, its later
+	// batches retrying only the failed packs. Once reached the pack is set to
+	// mint-failed and no longer blocks the completion of its distribution.
+	// 0 retries forever.
+	MintMaxAttempts uint `env:"FLOW_PDS_MINT_MAX_ATTEMPTS" envDefault:"3"`
 
 	// The batch sizes for database batch handling (big inserts or batch processing)
 	BatchInsertSize  int `env:"FLOW_PDS_BATCH_INSERT_SIZE" envDefault:"1000"`
 	BatchProcessSize int `env:"FLOW_PDS_BATCH_PROCESS_SIZE" envDefault:"1000"`
 
+	// Max number of distributions created by one bulk request
+	// (/v1/distributions/bulk), unlimited if 0
+	MaxBulkDistributions int `env:"FLOW_PDS_MAX_BULK_DISTRIBUTIONS" envDefault:"100"`
+
+	// Close complete distributions (destroy the capabilities the issuer shared
+	// with the PDS and store a completion report) once all packs are opened or
+	// the reveal window has passed
+	DistributionTeardown bool `env:"FLOW_PDS_DISTRIBUTION_TEARDOWN" envDefault:"false"`
+	// How long packs of a complete distribution can be revealed and opened
+	// before it is closed, 0 waits for all packs to be opened
+	DistributionRevealWindow time.Duration `env:"FLOW_PDS_DISTRIBUTION_REVEAL_WINDOW" envDefault:"0"`
+
+	// Max time a distribution can spend in setup, settling (settling and
+	// settled) and minting before it is set to stalled, 0 never stalls it
+	SetupTimeout      time.Duration `env:"FLOW_PDS_SETUP_TIMEOUT" envDefault:"0"`
+	SettlementTimeout time.Duration `env:"FLOW_PDS_SETTLEMENT_TIMEOUT" envDefault:"0"`
+	MintingTimeout    time.Duration `env:"FLOW_PDS_MINTING_TIMEOUT" envDefault:"0"`
+	// Optional URL notified (POST, JSON) when a distribution stalls
+	StalledWebhookURL string `env:"FLOW_PDS_STALLED_WEBHOOK_URL"`
+
+	// Freeze revoked packs onchain, the PackNFT contract has to support
+	// revoking (IPackNFT.IOperator.revoke)
+	PackRevocationOnChain bool `env:"FLOW_PDS_PACK_REVOCATION_ON_CHAIN" envDefault:"false"`
+
+	// Mint the packs of distributions with metadata along with it, the
+	// PackNFT contract has to support it (IPackNFT.IOperator.mintWithMetadata)
+	PackMetadataOnChain bool `env:"FLOW_PDS_PACK_METADATA_ON_CHAIN" envDefault:"false"`
+
+	// Check that the issuer owns all collectibles of a distribution before
+	// setting it up, distributions with missing collectibles are aborted
+	CollectibleOwnershipCheck bool `env:"FLOW_PDS_COLLECTIBLE_OWNERSHIP_CHECK" envDefault:"true"`
+
+	// How many packs to check per poll when verifying pack ownership
+	OwnershipVerificationBatchSize int `env:"FLOW_PDS_OWNERSHIP_VERIFICATION_BATCH_SIZE" envDefault:"100"`
+
+	// Timeout of a single gift intent webhook request
+	GiftWebhookTimeout time.Duration `env:"FLOW_PDS_GIFT_WEBHOOK_TIMEOUT" envDefault:"10s"`
+	// How many times to try delivering a gift intent webhook before giving up
+	GiftWebhookMaxAttempts uint `env:"FLOW_PDS_GIFT_WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	// How many times to try delivering a reveal webhook before giving up,
+	// requests use 'GiftWebhookTimeout'
+	RevealWebhookMaxAttempts uint `env:"FLOW_PDS_REVEAL_WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	// How many times to try delivering an event to an issuer webhook before
+	// giving up, requests use 'GiftWebhookTimeout'
+	IssuerWebhookMaxAttempts uint `env:"FLOW_PDS_ISSUER_WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	// Wait before the first retry of a failed issuer webhook delivery,
+	// doubled on each retry up to an hour
+	IssuerWebhookRetryInterval time.Duration `env:"FLOW_PDS_ISSUER_WEBHOOK_RETRY_INTERVAL" envDefault:"30s"`
+	// Allow issuer webhooks to private, loopback and link-local addresses,
+	// e.g. for local development
+	IssuerWebhookAllowPrivate bool `env:"FLOW_PDS_ISSUER_WEBHOOK_ALLOW_PRIVATE" envDefault:"false"`
+
 	// Maximum number of blocks to query for when fetching events from Flow gateway
 	MaxBlocksPerCheck uint64 `env:"FLOW_PDS_MAX_BLOCKS_PER_CHECK" envDefault:"10"`
 
+	// -- Latency SLOs --
+
+	// Max time a user facing (reveal and open) transaction should wait to be
+	// sent and sealed. While one waits longer, lower priority work is shed:
+	// minting transactions are not sent, new settlement and minting batches
+	// are halved and no new distributions are started. 0 disables.
+	UserFacingLatencySLO time.Duration `env:"FLOW_PDS_USER_FACING_LATENCY_SLO" envDefault:"60s"`
+	// How long to keep shedding work after the SLO was last breached
+	SLODegradationHold time.Duration `env:"FLOW_PDS_SLO_DEGRADATION_HOLD" envDefault:"1m"`
+
+	// -- Metrics --
+
+	// Max number of distributions labeled individually in metrics, the rest
+	// are labeled "other" to keep the number of time series in check
+	MetricsMaxDistributionLabels int `env:"FLOW_PDS_METRICS_MAX_DISTRIBUTION_LABELS" envDefault:"50"`
+	// How long a distribution keeps its label while not seen in metrics once
+	// all labels are taken, after that a new distribution can take its place
+	MetricsDistributionLabelIdle time.Duration `env:"FLOW_PDS_METRICS_DISTRIBUTION_LABEL_IDLE" envDefault:"1h"`
+
 	// -- Testing --
 
 	TestPackCount int `env:"TEST_PACK_COUNT" envDefault:"4"`
@@ -69,5 +427,26 @@ func ParseConfig(opt *ConfigOptions) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// validate checks settings env can not check on its own.
+func (cfg *Config) validate() error {
+	if cfg.JWTIssuerURL != "" {
+		if cfg.JWTAudience == "" {
+			return fmt.Errorf("JWT audience (FLOW_PDS_JWT_AUDIENCE) is required with a JWT issuer, tokens of the identity provider for other applications would be accepted")
+		}
+		if cfg.JWTIssuerClaim == "" && !cfg.JWTAnyIssuer {
+			return fmt.Errorf("JWT issuer claim (FLOW_PDS_JWT_ISSUER_CLAIM) is required with a JWT issuer, set FLOW_PDS_JWT_ANY_ISSUER to let tokens act for any issuer")
+		}
+		if cfg.JWTIssuerClaim != "" && cfg.JWTAnyIssuer {
+			return fmt.Errorf("JWT issuer claim (FLOW_PDS_JWT_ISSUER_CLAIM) and FLOW_PDS_JWT_ANY_ISSUER can not both be set")
+		}
+	}
+
+	return nil
+}
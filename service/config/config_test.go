@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestValidateJWT(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		cfg   Config
+		valid bool
+	}{
+		{"no JWT", Config{}, true},
+		{"claim", Config{JWTIssuerURL: "https://login.example.com", JWTAudience: "pds", JWTIssuerClaim: "flow_address"}, true},
+		{"any issuer", Config{JWTIssuerURL: "https://login.example.com", JWTAudience: "pds", JWTAnyIssuer: true}, true},
+		{"no audience", Config{JWTIssuerURL: "https://login.example.com", JWTIssuerClaim: "flow_address"}, false},
+		{"no claim", Config{JWTIssuerURL: "https://login.example.com", JWTAudience: "pds"}, false},
+		{"claim and any issuer", Config{JWTIssuerURL: "https://login.example.com", JWTAudience: "pds", JWTIssuerClaim: "flow_address", JWTAnyIssuer: true}, false},
+	} {
+		if err := c.cfg.validate(); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid %v, got %v", c.name, c.valid, err)
+		}
+	}
+}
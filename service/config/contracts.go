@@ -0,0 +1,28 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CollectibleContracts maps Flow networks to the collectible NFT contracts
+// (contract name to address) available on each network, e.g.
+//
+//	{"emulator": {"ExampleNFT": "01cf0e2f2f715450"}, "testnet": {"ExampleNFT": "f534d89914579e09"}}
+type CollectibleContracts map[string]map[string]string
+
+// UnmarshalText parses CollectibleContracts from JSON, allows setting it
+// from an environment variable.
+func (cc *CollectibleContracts) UnmarshalText(text []byte) error {
+	m := make(map[string]map[string]string)
+	if err := json.Unmarshal(text, &m); err != nil {
+		return fmt.Errorf("error while parsing collectible contracts: %w", err)
+	}
+	*cc = m
+	return nil
+}
+
+// Network returns the collectible contracts configured for 'network'.
+func (cc CollectibleContracts) Network(network string) map[string]string {
+	return cc[network]
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TrustedProxies are the networks of the proxies in front of the PDS, given
+// as IPs or CIDRs separated by commas, e.g. "10.0.0.0/8,192.0.2.10".
+type TrustedProxies []*net.IPNet
+
+// UnmarshalText parses TrustedProxies, allows setting it from an environment
+// variable.
+func (p *TrustedProxies) UnmarshalText(text []byte) error {
+	res := TrustedProxies{}
+	for _, s := range strings.Split(string(text), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy '%s'", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy '%s': %w", s, err)
+		}
+		res = append(res, network)
+	}
+	*p = res
+	return nil
+}
+
+// MarshalText returns the networks as they are set.
+func (p TrustedProxies) MarshalText() ([]byte, error) {
+	s := make([]string, len(p))
+	for i, n := range p {
+		s[i] = n.String()
+	}
+	return []byte(strings.Join(s, ",")), nil
+}
+
+// Contains returns true if 'ip' is the address of a trusted proxy.
+func (p TrustedProxies) Contains(ip net.IP) bool {
+	for _, n := range p {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
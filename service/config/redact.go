@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redacted returns the configuration as a map of field names to values, so
+// the configuration can be safely shown to operators. Numbers, booleans and
+// durations are shown as they are. Any other value (strings, lists, ...) is
+// replaced unless empty or its field is allowed with `redact:"false"`, so a
+// new secret is not shown by mistake.
+func (cfg Config) Redacted() map[string]interface{} {
+	res := make(map[string]interface{})
+
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			res[field.Name] = value.Interface().(time.Duration).String()
+		case field.Tag.Get("redact") != "false" && !shownKind(field.Type.Kind()) && !value.IsZero():
+			res[field.Name] = redactedValue
+		default:
+			res[field.Name] = value.Interface()
+		}
+	}
+
+	return res
+}
+
+// shownKind returns true for kinds of values which can not hold secrets.
+func shownKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
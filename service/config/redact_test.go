@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestRedacted(t *testing.T) {
+	cfg := Config{
+		AdminAddress:          "0x1",
+		AdminPrivateKey:       "secret",
+		DatabaseType:          "sqlite",
+		KeyRotationWebhookURL: "https://hooks.example.com/rotation?token=secret",
+		StalledWebhookURL:     "https://hooks.example.com/stalled?token=secret",
+		AccessAPIHosts:        []string{"access.example.com:9000"},
+		BatchProcessSize:      100,
+	}
+
+	r := cfg.Redacted()
+
+	if r["AdminPrivateKey"] != redactedValue {
+		t.Errorf("expected AdminPrivateKey to be redacted, got %v", r["AdminPrivateKey"])
+	}
+
+	if r["AdminAPIToken"] != "" {
+		t.Errorf("expected empty AdminAPIToken to be shown as empty, got %v", r["AdminAPIToken"])
+	}
+
+	// Redacted unless allowed
+	for _, name := range []string{"KeyRotationWebhookURL", "StalledWebhookURL"} {
+		if r[name] != redactedValue {
+			t.Errorf("expected %s to be redacted, got %v", name, r[name])
+		}
+	}
+
+	if hosts, ok := r["AccessAPIHosts"].([]string); !ok || len(hosts) != 1 {
+		t.Errorf("expected allowed AccessAPIHosts to be shown, got %v", r["AccessAPIHosts"])
+	}
+
+	if r["BatchProcessSize"] != 100 {
+		t.Errorf("expected numbers to be shown, got %v", r["BatchProcessSize"])
+	}
+
+	if r["AdminAddress"] != "0x1" || r["DatabaseType"] != "sqlite" {
+		t.Errorf("expected non-secret values to be shown, got %v", r)
+	}
+}
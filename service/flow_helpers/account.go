@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/onflow/flow-go-sdk"
-	"github.com/onflow/flow-go-sdk/client"
 	"github.com/onflow/flow-go-sdk/crypto"
 	"github.com/onflow/flow-go-sdk/crypto/cloudkms"
 	"github.com/trailofbits/go-mutexasserts"
@@ -73,6 +72,16 @@ func GetAccount(address flow.Address, privateKey, privateKeyType string, keyInde
 		return existing
 	}
 
+	new := NewAccount(address, privateKey, privateKeyType, keyIndexes)
+
+	accounts[address] = new
+
+	return new
+}
+
+// NewAccount initializes an Account outside of the application wide cache,
+// e.g. for another key set of an account already in the cache.
+func NewAccount(address flow.Address, privateKey, privateKeyType string, keyIndexes []int) *Account {
 	// Pick a random index to start from
 	rand.Seed(time.Now().UnixNano())
 	randomIndex := rand.Intn(len(keyIndexes))
@@ -84,23 +93,28 @@ func GetAccount(address flow.Address, privateKey, privateKeyType string, keyInde
 		pKeyIndexes[i] = &ProposalKeyIndex{index: idx}
 	}
 
-	new := &Account{
+	return &Account{
 		Address:           address,
 		PrivateKey:        privateKey,
 		PrivateKeyType:    privateKeyType,
 		PKeyIndexes:       pKeyIndexes,
 		nextKeyIndexIndex: randomIndex,
 	}
+}
 
-	accounts[address] = new
-
-	return new
+// KeyIndexes returns the indexes of the account keys used by 'a'.
+func (a *Account) KeyIndexes() []int {
+	res := make([]int, len(a.PKeyIndexes))
+	for i, k := range a.PKeyIndexes {
+		res[i] = k.index
+	}
+	return res
 }
 
-func (a *Account) GetProposalKey(ctx context.Context, flowClient *client.Client) (*flow.AccountKey, UnlockKeyFunc, error) {
+func (a *Account) GetProposalKey(ctx context.Context, flowClient FlowClient) (*flow.AccountKey, UnlockKeyFunc, error) {
 	account, err := flowClient.GetAccount(ctx, a.Address)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error in flow_helpers.Account.GetProposalKey: %w", err)
+		return nil, EmptyUnlockKey, fmt.Errorf("error in flow_helpers.Account.GetProposalKey: %w", err)
 	}
 
 	idx, unlock, err := a.PKeyIndexes.Next()
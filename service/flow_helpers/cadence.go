@@ -2,27 +2,90 @@ package flow_helpers
 
 import (
 	"bytes"
-	"io/ioutil"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
 
 	"text/template"
 
 	"github.com/caarlos0/env/v6"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	templatesMu sync.RWMutex
+	// Where templates are read from by default, the working directory unless
+	// set up otherwise (see SetupCadenceTemplates)
+	templates fs.FS = os.DirFS(".")
+	// Optional directory whose templates take precedence over 'templates'
+	templateOverrideDir string
 )
 
 type CadenceTemplateVars struct {
 	PDS                   string `env:"PDS_ADDRESS"`
 	IPackNFT              string `env:"PDS_ADDRESS"`
 	NonFungibleToken      string `env:"NON_FUNGIBLE_TOKEN_ADDRESS"`
+	FungibleToken         string `env:"FUNGIBLE_TOKEN_ADDRESS"`
 	PackNFTName           string
 	PackNFTAddress        string
 	CollectibleNFTName    string
 	CollectibleNFTAddress string
+	CollectibleNFTs       []CadenceContract // Collectible contracts of a pack, when it may hold several
+	TokenName             string            // Fungible token contract, e.g. of the amount in a pack
+	TokenAddress          string
+}
+
+// CadenceContract is a contract imported by a template.
+type CadenceContract struct {
+	Name       string
+	Address    string
+	Identifier string // e.g. 'A.01cf0e2f2f715450.ExampleNFT'
+}
+
+// SetupCadenceTemplates sets where ParseCadenceTemplate reads templates from.
+// A template found in 'overrideDir' (using the same relative path, e.g.
+// 'cadence-transactions/pds/settle.cdc') is used instead of the one in
+// 'embedded'. Overrides are disabled if 'overrideDir' is empty.
+func SetupCadenceTemplates(embedded fs.FS, overrideDir string) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+
+	templates = embedded
+	templateOverrideDir = overrideDir
+}
+
+func readCadenceTemplate(templatePath string) ([]byte, error) {
+	if filepath.IsAbs(templatePath) {
+		return os.ReadFile(templatePath)
+	}
+
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+
+	// fs.FS does not accept paths like "./cadence-transactions/..."
+	name := path.Clean(filepath.ToSlash(templatePath))
+
+	if templateOverrideDir != "" {
+		b, err := os.ReadFile(filepath.Join(templateOverrideDir, filepath.FromSlash(name)))
+		if err == nil {
+			log.WithFields(log.Fields{"template": name, "dir": templateOverrideDir}).Debug("Using overridden Cadence template")
+			return b, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return fs.ReadFile(templates, name)
 }
 
 func ParseCadenceTemplate(templatePath string, vars *CadenceTemplateVars) ([]byte, error) {
-	fb, err := ioutil.ReadFile(templatePath)
+	fb, err := readCadenceTemplate(templatePath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if vars == nil {
@@ -0,0 +1,43 @@
+package flow_helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseCadenceTemplateOverride(t *testing.T) {
+	embedded := fstest.MapFS{
+		"cadence-transactions/a.cdc": {Data: []byte("embedded a")},
+		"cadence-transactions/b.cdc": {Data: []byte("embedded b")},
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "cadence-transactions"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cadence-transactions", "a.cdc"), []byte("override a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetupCadenceTemplates(embedded, dir)
+	defer SetupCadenceTemplates(os.DirFS("."), "")
+
+	for _, c := range []struct{ path, expected string }{
+		{"./cadence-transactions/a.cdc", "override a"},
+		{"./cadence-transactions/b.cdc", "embedded b"},
+	} {
+		got, err := ParseCadenceTemplate(c.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != c.expected {
+			t.Errorf("expected %q for %s, got %q", c.expected, c.path, string(got))
+		}
+	}
+
+	if _, err := ParseCadenceTemplate("./cadence-transactions/missing.cdc", nil); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
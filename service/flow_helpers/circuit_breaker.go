@@ -0,0 +1,220 @@
+package flow_helpers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreaker instead of calling the
+// Access API while it is open. It is an Unavailable gRPC error so it is
+// handled like the access node being down.
+var ErrCircuitOpen = status.Error(codes.Unavailable, "access API circuit breaker open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker wraps an Access API client. After 'threshold' consecutive
+// calls failing with the access node being unavailable (or rate limiting us)
+// it opens: calls fail right away with ErrCircuitOpen. Once 'cooldown' has
+// passed a single probe call is let through (half-open), the breaker closes
+// again if it succeeds and stays open for another 'cooldown' if not.
+type CircuitBreaker struct {
+	client    FlowClient
+	clock     common.Clock
+	name      string // Used in logs and metrics
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func NewCircuitBreaker(client FlowClient, clock common.Clock, name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	metrics.SetCircuitOpen(name, false)
+	return &CircuitBreaker{client: client, clock: clock, name: name, threshold: threshold, cooldown: cooldown}
+}
+
+// withCircuitBreaker wraps 'c' in a CircuitBreaker configured by 'cfg', if
+// enabled (AccessAPIBreakerThreshold is not 0).
+func withCircuitBreaker(c FlowClient, cfg *config.Config, clock common.Clock, name string) FlowClient {
+	if cfg.AccessAPIBreakerThreshold <= 0 {
+		return c
+	}
+	return NewCircuitBreaker(c, clock, name, cfg.AccessAPIBreakerThreshold, cfg.AccessAPIBreakerCooldown)
+}
+
+// Available returns false if 'c' (or the current spork client of a
+// SporkClient) is a CircuitBreaker which is open and not yet ready for a
+// probe call.
+func Available(c FlowClient) bool {
+	if s, ok := c.(*SporkClient); ok {
+		c = s.current
+	}
+
+	b, ok := c.(*CircuitBreaker)
+	if !ok {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		return b.clock.Now().Sub(b.openedAt) >= b.cooldown
+	case circuitHalfOpen:
+		return false
+	}
+	return true
+}
+
+// allow returns ErrCircuitOpen if the call should not be made.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		log.WithFields(log.Fields{"client": b.name}).Debug("Access API circuit breaker half-open, probing")
+	case circuitHalfOpen:
+		// A probe is in flight
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
+
+// record updates the state of the breaker with the outcome of a call.
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	logger := log.WithFields(log.Fields{"client": b.name})
+
+	if err != nil && ctx.Err() != nil {
+		// Caller gave up, tells nothing about the access node
+		if b.state == circuitHalfOpen {
+			b.state = circuitOpen
+		}
+		return
+	}
+
+	// Any other error means the access node responded
+	if err != nil && isFailoverError(ctx, err) {
+		b.failures++
+		if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.threshold) {
+			if b.state == circuitClosed {
+				logger.WithFields(log.Fields{"error": err, "failures": b.failures}).Warn("Access API circuit breaker open")
+			}
+			b.state = circuitOpen
+			b.openedAt = b.clock.Now()
+			metrics.SetCircuitOpen(b.name, true)
+		}
+		return
+	}
+
+	if b.state != circuitClosed {
+		logger.Info("Access API circuit breaker closed, access node available again")
+		metrics.SetCircuitOpen(b.name, false)
+	}
+
+	b.state = circuitClosed
+	b.failures = 0
+}
+
+func (b *CircuitBreaker) do(ctx context.Context, f func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := f()
+	b.record(ctx, err)
+	return err
+}
+
+func (b *CircuitBreaker) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	return b.do(ctx, func() error {
+		return b.client.Ping(ctx, opts...)
+	})
+}
+
+func (b *CircuitBreaker) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (res *flow.BlockHeader, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.GetLatestBlockHeader(ctx, isSealed, opts...)
+		return
+	})
+	return
+}
+
+func (b *CircuitBreaker) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (res *flow.Account, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.GetAccount(ctx, address, opts...)
+		return
+	})
+	return
+}
+
+func (b *CircuitBreaker) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	return b.do(ctx, func() error {
+		return b.client.SendTransaction(ctx, tx, opts...)
+	})
+}
+
+func (b *CircuitBreaker) GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (res *flow.Transaction, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.GetTransaction(ctx, txID, opts...)
+		return
+	})
+	return
+}
+
+func (b *CircuitBreaker) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (res *flow.TransactionResult, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.GetTransactionResult(ctx, txID, opts...)
+		return
+	})
+	return
+}
+
+func (b *CircuitBreaker) GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) (res []client.BlockEvents, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.GetEventsForHeightRange(ctx, query, opts...)
+		return
+	})
+	return
+}
+
+func (b *CircuitBreaker) ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value, opts ...grpc.CallOption) (res cadence.Value, err error) {
+	err = b.do(ctx, func() (err error) {
+		res, err = b.client.ExecuteScriptAtLatestBlock(ctx, script, arguments, opts...)
+		return
+	})
+	return
+}
+
+// Close closes the underlying client.
+func (b *CircuitBreaker) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,77 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type pingClient struct {
+	FlowClient
+	err   error
+	calls int
+}
+
+func (c *pingClient) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	c.calls++
+	return c.err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	client := &pingClient{err: status.Error(codes.Unavailable, "connection refused")}
+	clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	b := NewCircuitBreaker(client, clock, "test", 3, 10*time.Second)
+	ctx := context.Background()
+
+	// Other errors mean the node responded
+	client.err = status.Error(codes.InvalidArgument, "invalid")
+	for i := 0; i < 5; i++ {
+		if err := b.Ping(ctx); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("expected the breaker to stay closed on non availability errors")
+		}
+	}
+
+	client.err = status.Error(codes.Unavailable, "connection refused")
+	for i := 0; i < 3; i++ {
+		if err := b.Ping(ctx); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected the breaker to be closed on call %d", i+1)
+		}
+	}
+
+	// Open, fails without calling the client
+	calls := client.calls
+	if err := b.Ping(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if client.calls != calls || Available(b) {
+		t.Fatal("expected the client not to be called while open")
+	}
+
+	// Half-open, the failed probe opens it again
+	clock.Advance(10 * time.Second)
+	if !Available(b) {
+		t.Fatal("expected a probe to be allowed after the cooldown")
+	}
+	if err := b.Ping(ctx); errors.Is(err, ErrCircuitOpen) || client.calls != calls+1 {
+		t.Fatal("expected the probe to call the client")
+	}
+	if err := b.Ping(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the breaker to open again after a failed probe, got %v", err)
+	}
+
+	// A successful probe closes it
+	clock.Advance(10 * time.Second)
+	client.err = nil
+	if err := b.Ping(ctx); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if err := b.Ping(ctx); err != nil || !Available(b) {
+		t.Fatalf("expected the breaker to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package flow_helpers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// FlowClient is the subset of the Flow Access API client used by the PDS.
+// It is satisfied by *client.Client and MultiClient.
+type FlowClient interface {
+	Ping(ctx context.Context, opts ...grpc.CallOption) error
+	GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error)
+	GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (*flow.Account, error)
+	SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error
+	GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.Transaction, error)
+	GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error)
+	GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) ([]client.BlockEvents, error)
+	ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value, opts ...grpc.CallOption) (cadence.Value, error)
+	Close() error
+}
+
+// NewAccessAPIClient returns a client for the Access API host(s) in 'cfg'.
+// If multiple hosts are configured, a MultiClient is returned which fails over
+// between them. The client is wrapped in a CircuitBreaker unless disabled,
+// and in a SporkClient if historical access nodes are configured.
+func NewAccessAPIClient(cfg *config.Config, clock common.Clock) (FlowClient, error) {
+	if len(cfg.AccessAPIHosts) == 0 {
+		return nil, fmt.Errorf("no Access API hosts configured")
+	}
+
+	var c FlowClient
+	if len(cfg.AccessAPIHosts) == 1 {
+		single, err := NewFlowClient(cfg.AccessAPIHosts[0], cfg)
+		if err != nil {
+			return nil, err
+		}
+		c = withCircuitBreaker(single, cfg, clock, "default")
+	} else {
+		m, err := NewMultiClient(cfg.AccessAPIHosts, cfg, clock)
+		if err != nil {
+			return nil, err
+		}
+		c = withCircuitBreaker(m, cfg, clock, "default")
+	}
+
+	if len(cfg.AccessAPIHistoricalHosts) == 0 {
+		return c, nil
+	}
+
+	historical, err := newHistoricalNodes(cfg, clock)
+	if err != nil {
+		return nil, err
+	}
+	return NewSporkClient(c, cfg.AccessAPISporkRootHeight, historical), nil
+}
+
+// NewFlowClient returns a Flow Access API client connected to 'host' using
+// the transport security settings in 'cfg'.
+func NewFlowClient(host string, cfg *config.Config) (*client.Client, error) {
+	opt, err := TransportDialOption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(host, opt)
+}
+
+// TransportDialOption returns the gRPC transport credentials option for
+// connecting to the Access API. Defaults to an insecure connection unless
+// 'AccessAPIUseTLS' is set.
+func TransportDialOption(cfg *config.Config) (grpc.DialOption, error) {
+	if !cfg.AccessAPIUseTLS {
+		return grpc.WithInsecure(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.AccessAPITLSServerName,
+	}
+
+	if cfg.AccessAPITLSCACertFile != "" {
+		pem, err := ioutil.ReadFile(cfg.AccessAPITLSCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading Access API CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in '%s'", cfg.AccessAPITLSCACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.AccessAPITLSCertFile != "" || cfg.AccessAPITLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.AccessAPITLSCertFile, cfg.AccessAPITLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while loading Access API client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
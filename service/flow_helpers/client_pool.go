@@ -0,0 +1,77 @@
+package flow_helpers
+
+import (
+	"sync"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+)
+
+// ClientPool manages Access API clients per host. The default client is
+// used whenever no specific host is requested.
+type ClientPool struct {
+	cfg           *config.Config
+	clock         common.Clock
+	defaultClient FlowClient
+	dial          func(host string) (FlowClient, error)
+	mu            sync.Mutex
+	clients       map[string]FlowClient
+}
+
+func NewClientPool(defaultClient FlowClient, cfg *config.Config, clock common.Clock) *ClientPool {
+	return &ClientPool{
+		cfg:           cfg,
+		clock:         clock,
+		defaultClient: defaultClient,
+		dial: func(host string) (FlowClient, error) {
+			return NewFlowClient(host, cfg)
+		},
+		clients: make(map[string]FlowClient),
+	}
+}
+
+// Get returns the client for 'host', connecting to it on first use. Clients
+// have their own circuit breaker (see CircuitBreaker).
+// An empty host returns the default client.
+func (p *ClientPool) Get(host string) (FlowClient, error) {
+	if host == "" {
+		return p.defaultClient, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, ok := p.clients[host]; ok {
+		return c, nil
+	}
+
+	c, err := p.dial(host)
+	if err != nil {
+		return nil, err
+	}
+
+	p.clients[host] = withCircuitBreaker(c, p.cfg, p.clock, host)
+
+	// Blocks of past sporks are served by the same historical nodes
+	if s, ok := p.defaultClient.(*SporkClient); ok {
+		p.clients[host] = s.withCurrent(p.clients[host])
+	}
+
+	return p.clients[host], nil
+}
+
+// Close closes all clients created by the pool. The default client is
+// owned by the caller and is left open.
+func (p *ClientPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for host, c := range p.clients {
+		if closeErr := c.Close(); closeErr != nil {
+			err = closeErr
+		}
+		delete(p.clients, host)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package flow_helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+)
+
+type closeClient struct {
+	FlowClient
+	host   string
+	closed bool
+}
+
+func (c *closeClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClientPool(t *testing.T) {
+	defaultClient := &closeClient{host: "default"}
+	p := NewClientPool(defaultClient, &config.Config{}, common.NewVirtualClock(time.Now()))
+
+	dialed := []*closeClient{}
+	p.dial = func(host string) (FlowClient, error) {
+		c := &closeClient{host: host}
+		dialed = append(dialed, c)
+		return c, nil
+	}
+
+	if c, err := p.Get(""); err != nil || c != defaultClient {
+		t.Fatalf("expected the default client without a host, got %v (%v)", c, err)
+	}
+
+	override, err := p.Get("access.example.com:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := override.(*closeClient); !ok || c.host != "access.example.com:9000" {
+		t.Fatalf("expected a client of the override host, got %v", override)
+	}
+
+	if c, err := p.Get("access.example.com:9000"); err != nil || c != override || len(dialed) != 1 {
+		t.Errorf("expected the client of a host to be reused, dialed %d times", len(dialed))
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !dialed[0].closed || defaultClient.closed {
+		t.Errorf("expected only the clients of the pool to be closed")
+	}
+
+	if _, err := p.Get("access.example.com:9000"); err != nil || len(dialed) != 2 {
+		t.Errorf("expected the host to be dialed again after closing, dialed %d times", len(dialed))
+	}
+}
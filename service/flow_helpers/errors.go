@@ -1,13 +1,60 @@
 package flow_helpers
 
 import (
+	"errors"
 	"strings"
 
 	fvm_errors "github.com/onflow/flow-go/fvm/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var InvalidProposalSeqNumberErrorString = fvm_errors.ErrCodeInvalidProposalSeqNumberError.String()
+var GasLimitExceededErrorString = fvm_errors.ErrCodeGasLimitExceededError.String()
 
 func IsInvalidProposalSeqNumberError(err error) bool {
 	return strings.Contains(err.Error(), InvalidProposalSeqNumberErrorString)
 }
+
+// IsGasLimitExceededError returns true if the error message of a transaction
+// result says it ran out of gas (computation limit).
+func IsGasLimitExceededError(message string) bool {
+	return strings.Contains(message, GasLimitExceededErrorString) ||
+		strings.Contains(strings.ToLower(message), "computation limit")
+}
+
+// IsRetryableSendError returns true if sending a transaction failed for a
+// reason which may go away when it is rebuilt and resent later, e.g. the
+// connection was reset, the reference block expired or the proposal key
+// sequence number did not match.
+func IsRetryableSendError(err error) bool {
+	if IsInvalidProposalSeqNumberError(err) {
+		return true
+	}
+
+	switch grpcCode(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "expired") ||
+		strings.Contains(msg, "sequence number")
+}
+
+// IsNotFoundError returns true if the Access API did not find what was asked
+// for, e.g. a transaction which never reached the network.
+func IsNotFoundError(err error) bool {
+	return err != nil && grpcCode(err) == codes.NotFound
+}
+
+// grpcCode returns the gRPC status code of a (wrapped) gRPC error, Unknown
+// for other errors.
+func grpcCode(err error) codes.Code {
+	var s interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &s) {
+		return s.GRPCStatus().Code()
+	}
+	return codes.Unknown
+}
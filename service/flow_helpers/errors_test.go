@@ -0,0 +1,48 @@
+package flow_helpers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryableSendError(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{status.Error(codes.Unavailable, "unavailable"), true},
+		{fmt.Errorf("wrapped: %w", status.Error(codes.ResourceExhausted, "rate limited")), true},
+		{errors.New("read tcp 127.0.0.1:3569: connection reset by peer"), true},
+		{status.Error(codes.InvalidArgument, "transaction is expired"), true},
+		{status.Error(codes.InvalidArgument, "invalid signature"), false},
+		{errors.New("cadence syntax error"), false},
+	}
+
+	for _, c := range cases {
+		if got := IsRetryableSendError(c.err); got != c.retryable {
+			t.Errorf("%v: expected retryable %t, got %t", c.err, c.retryable, got)
+		}
+	}
+}
+
+func TestIsNotFoundError(t *testing.T) {
+	if !IsNotFoundError(fmt.Errorf("wrapped: %w", status.Error(codes.NotFound, "not found"))) {
+		t.Error("expected a wrapped NotFound status to be a not found error")
+	}
+	if IsNotFoundError(status.Error(codes.Unavailable, "unavailable")) || IsNotFoundError(nil) {
+		t.Error("expected other errors not to be not found errors")
+	}
+}
+
+func TestIsGasLimitExceededError(t *testing.T) {
+	if !IsGasLimitExceededError("[Error Code: 1104] computation limited exceeded: 9999") {
+		t.Error("expected a gas limit exceeded error")
+	}
+	if IsGasLimitExceededError("[Error Code: 1101] cadence runtime error") {
+		t.Error("expected not a gas limit exceeded error")
+	}
+}
@@ -0,0 +1,239 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultiClient wraps multiple Access API clients. Reads are load balanced
+// between healthy nodes, transactions are sent to the first healthy node.
+// A node which is unavailable or rate limits us is marked unhealthy and the
+// call is retried on the next node. Unhealthy nodes are brought back by a
+// background health check (Ping).
+type MultiClient struct {
+	nodes []*accessNode
+	next  uint32 // Round robin counter for reads
+	clock common.Clock
+	quit  chan bool
+	once  sync.Once
+}
+
+type accessNode struct {
+	host    string
+	client  FlowClient
+	mu      sync.RWMutex
+	healthy bool
+}
+
+func (n *accessNode) isHealthy() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.healthy
+}
+
+func (n *accessNode) setHealthy(healthy bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.healthy = healthy
+}
+
+func NewMultiClient(hosts []string, cfg *config.Config, clock common.Clock) (*MultiClient, error) {
+	clients := make([]FlowClient, len(hosts))
+	for i, host := range hosts {
+		c, err := NewFlowClient(host, cfg)
+		if err != nil {
+			return nil, err
+		}
+		clients[i] = c
+	}
+
+	return newMultiClient(hosts, clients, cfg.AccessAPIHealthCheckInterval, clock), nil
+}
+
+// newMultiClient returns a MultiClient of 'clients', connected to 'hosts',
+// checking unhealthy nodes every 'interval'.
+func newMultiClient(hosts []string, clients []FlowClient, interval time.Duration, clock common.Clock) *MultiClient {
+	nodes := make([]*accessNode, len(hosts))
+	for i, host := range hosts {
+		nodes[i] = &accessNode{host: host, client: clients[i], healthy: true}
+	}
+
+	m := &MultiClient{nodes: nodes, clock: clock, quit: make(chan bool)}
+
+	go m.healthCheck(interval)
+
+	return m
+}
+
+func (m *MultiClient) healthCheck(interval time.Duration) {
+	ticker := m.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.Chan():
+			for _, n := range m.nodes {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := n.client.Ping(ctx)
+				cancel()
+
+				wasHealthy := n.isHealthy()
+				n.setHealthy(err == nil)
+
+				if err != nil && wasHealthy {
+					log.WithFields(log.Fields{"host": n.host, "error": err}).Warn("Access node health check failed")
+				} else if err == nil && !wasHealthy {
+					log.WithFields(log.Fields{"host": n.host}).Info("Access node healthy again")
+				}
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// candidates returns nodes in the order they should be tried, healthy ones
+// first. Reads start from a rotating index to spread the load.
+func (m *MultiClient) candidates(read bool) []*accessNode {
+	start := 0
+	if read {
+		start = int(atomic.AddUint32(&m.next, 1) % uint32(len(m.nodes)))
+	}
+
+	healthy := make([]*accessNode, 0, len(m.nodes))
+	unhealthy := make([]*accessNode, 0)
+	for i := range m.nodes {
+		n := m.nodes[(start+i)%len(m.nodes)]
+		if n.isHealthy() {
+			healthy = append(healthy, n)
+		} else {
+			unhealthy = append(unhealthy, n)
+		}
+	}
+
+	// Try unhealthy nodes as a last resort
+	return append(healthy, unhealthy...)
+}
+
+func (m *MultiClient) do(ctx context.Context, read bool, f func(c FlowClient) error) error {
+	var err error
+	for _, n := range m.candidates(read) {
+		if err = f(n.client); err == nil || !isFailoverError(ctx, err) {
+			return err
+		}
+
+		if n.isHealthy() {
+			log.WithFields(log.Fields{"host": n.host, "error": err}).Warn("Access node unavailable, failing over")
+		}
+
+		n.setHealthy(false)
+	}
+	return err
+}
+
+// isFailoverError returns true for errors which mean the node is unavailable
+// or is rate limiting us and the call should be retried on another node.
+func isFailoverError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		// Caller gave up, no point in trying other nodes
+		return false
+	}
+
+	var s interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &s) {
+		return false
+	}
+
+	switch s.GRPCStatus().Code() {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return true
+	}
+
+	return false
+}
+
+func (m *MultiClient) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	return m.do(ctx, true, func(c FlowClient) error {
+		return c.Ping(ctx, opts...)
+	})
+}
+
+func (m *MultiClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (res *flow.BlockHeader, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.GetLatestBlockHeader(ctx, isSealed, opts...)
+		return
+	})
+	return
+}
+
+func (m *MultiClient) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (res *flow.Account, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.GetAccount(ctx, address, opts...)
+		return
+	})
+	return
+}
+
+func (m *MultiClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	return m.do(ctx, false, func(c FlowClient) error {
+		return c.SendTransaction(ctx, tx, opts...)
+	})
+}
+
+func (m *MultiClient) GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (res *flow.Transaction, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.GetTransaction(ctx, txID, opts...)
+		return
+	})
+	return
+}
+
+func (m *MultiClient) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (res *flow.TransactionResult, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.GetTransactionResult(ctx, txID, opts...)
+		return
+	})
+	return
+}
+
+func (m *MultiClient) GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) (res []client.BlockEvents, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.GetEventsForHeightRange(ctx, query, opts...)
+		return
+	})
+	return
+}
+
+func (m *MultiClient) ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value, opts ...grpc.CallOption) (res cadence.Value, err error) {
+	err = m.do(ctx, true, func(c FlowClient) (err error) {
+		res, err = c.ExecuteScriptAtLatestBlock(ctx, script, arguments, opts...)
+		return
+	})
+	return
+}
+
+// Close stops the health check and closes all underlying clients.
+func (m *MultiClient) Close() error {
+	m.once.Do(func() { close(m.quit) })
+
+	var err error
+	for _, n := range m.nodes {
+		if closeErr := n.client.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
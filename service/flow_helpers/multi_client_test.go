@@ -0,0 +1,137 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nodeClient fails calls with 'err' while set and counts sent transactions.
+type nodeClient struct {
+	FlowClient
+	mu    sync.Mutex
+	err   error
+	sends int
+}
+
+func (c *nodeClient) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *nodeClient) sent() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sends
+}
+
+func (c *nodeClient) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *nodeClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.sends++
+	return nil
+}
+
+func (c *nodeClient) Close() error {
+	return nil
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	ctx := context.Background()
+
+	for _, code := range []codes.Code{codes.Unavailable, codes.ResourceExhausted} {
+		primary, secondary := &nodeClient{}, &nodeClient{}
+		clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+		m := newMultiClient([]string{"primary", "secondary"}, []FlowClient{primary, secondary}, time.Minute, clock)
+
+		if err := m.SendTransaction(ctx, flow.Transaction{}); err != nil || primary.sent() != 1 {
+			t.Fatalf("%s: expected transactions to be sent to the first node, got %v", code, err)
+		}
+
+		primary.setErr(status.Error(code, "node down"))
+
+		if err := m.SendTransaction(ctx, flow.Transaction{}); err != nil || secondary.sent() != 1 {
+			t.Fatalf("%s: expected to fail over to the second node, got %v", code, err)
+		}
+		if m.nodes[0].isHealthy() {
+			t.Errorf("%s: expected the first node to be marked unhealthy", code)
+		}
+
+		// Healthy nodes are tried first
+		if err := m.SendTransaction(ctx, flow.Transaction{}); err != nil || secondary.sent() != 2 {
+			t.Errorf("%s: expected to keep sending to the second node, got %v", code, err)
+		}
+
+		// Back once the health check passes
+		primary.setErr(nil)
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		waitFor(t, m.nodes[0].isHealthy)
+
+		if err := m.SendTransaction(ctx, flow.Transaction{}); err != nil || primary.sent() != 2 {
+			t.Errorf("%s: expected to send to the first node again, got %v", code, err)
+		}
+
+		m.Close()
+	}
+}
+
+func TestMultiClientNoFailover(t *testing.T) {
+	ctx := context.Background()
+
+	primary, secondary := &nodeClient{}, &nodeClient{}
+	m := newMultiClient([]string{"primary", "secondary"}, []FlowClient{primary, secondary}, time.Minute, common.NewVirtualClock(time.Now()))
+	defer m.Close()
+
+	// Errors of the request itself are returned as they are
+	invalid := status.Error(codes.InvalidArgument, "invalid transaction")
+	primary.setErr(invalid)
+	if err := m.SendTransaction(ctx, flow.Transaction{}); !errors.Is(err, invalid) || secondary.sent() != 0 {
+		t.Errorf("expected the error without failing over, got %v", err)
+	}
+	if !m.nodes[0].isHealthy() {
+		t.Error("expected the node to stay healthy")
+	}
+
+	// Unhealthy nodes are tried as a last resort
+	unavailable := status.Error(codes.Unavailable, "node down")
+	primary.setErr(unavailable)
+	secondary.setErr(unavailable)
+	if err := m.SendTransaction(ctx, flow.Transaction{}); !errors.Is(err, unavailable) {
+		t.Errorf("expected the last error once all nodes failed, got %v", err)
+	}
+	secondary.setErr(nil)
+	if err := m.SendTransaction(ctx, flow.Transaction{}); err != nil || secondary.sent() != 1 {
+		t.Errorf("expected an unhealthy node to be tried, got %v", err)
+	}
+}
+
+// waitFor waits (in real time) until 'f' returns true.
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout while waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,83 @@
+package flow_helpers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReferenceBlockCache caches the latest sealed block header used as the
+// reference block of sent transactions. A header older than half of 'ttl' is
+// still used but refreshed in the background, one older than 'ttl' is
+// fetched before returning. A 'ttl' of 0 disables caching.
+type ReferenceBlockCache struct {
+	client FlowClient
+	clock  common.Clock
+	ttl    time.Duration
+
+	mu         sync.Mutex
+	header     *flow.BlockHeader
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func NewReferenceBlockCache(client FlowClient, clock common.Clock, ttl time.Duration) *ReferenceBlockCache {
+	return &ReferenceBlockCache{client: client, clock: clock, ttl: ttl}
+}
+
+// Get returns the block header to reference.
+func (c *ReferenceBlockCache) Get(ctx context.Context) (*flow.BlockHeader, error) {
+	if c.ttl <= 0 {
+		return c.client.GetLatestBlockHeader(ctx, true)
+	}
+
+	c.mu.Lock()
+	header, age := c.header, c.clock.Now().Sub(c.fetchedAt)
+	if header != nil && age < c.ttl {
+		if age >= c.ttl/2 && !c.refreshing {
+			c.refreshing = true
+			go c.refresh()
+		}
+		c.mu.Unlock()
+		return header, nil
+	}
+	c.mu.Unlock()
+
+	return c.fetch(ctx)
+}
+
+func (c *ReferenceBlockCache) fetch(ctx context.Context) (*flow.BlockHeader, error) {
+	header, err := c.client.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A lagging access node may return an older block than the cached one
+	if c.header == nil || header.Height >= c.header.Height {
+		c.header, c.fetchedAt = header, c.clock.Now()
+	}
+
+	return header, nil
+}
+
+func (c *ReferenceBlockCache) refresh() {
+	defer func() {
+		c.mu.Lock()
+		c.refreshing = false
+		c.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.ttl)
+	defer cancel()
+
+	if _, err := c.fetch(ctx); err != nil {
+		log.WithFields(log.Fields{"error": err}).Debug("Error while refreshing reference block")
+	}
+}
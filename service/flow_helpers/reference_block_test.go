@@ -0,0 +1,78 @@
+package flow_helpers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+)
+
+type blockHeaderClient struct {
+	FlowClient
+	calls uint64
+}
+
+func (c *blockHeaderClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	height := atomic.AddUint64(&c.calls, 1)
+	return &flow.BlockHeader{Height: height}, nil
+}
+
+func TestReferenceBlockCache(t *testing.T) {
+	client := &blockHeaderClient{}
+	clock := common.NewVirtualClock(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewReferenceBlockCache(client, clock, 4*time.Second)
+	ctx := context.Background()
+
+	get := func() uint64 {
+		t.Helper()
+		header, err := cache.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return header.Height
+	}
+
+	if h := get(); h != 1 {
+		t.Fatalf("expected block 1, got %d", h)
+	}
+
+	// Fresh, no calls
+	clock.Advance(time.Second)
+	if h, calls := get(), atomic.LoadUint64(&client.calls); h != 1 || calls != 1 {
+		t.Fatalf("expected cached block 1, got %d (%d calls)", h, calls)
+	}
+
+	// Past half of the TTL the cached block is returned and refreshed
+	clock.Advance(2 * time.Second)
+	if h := get(); h != 1 {
+		t.Fatalf("expected cached block 1 while refreshing, got %d", h)
+	}
+	for i := 0; atomic.LoadUint64(&client.calls) < 2; i++ {
+		if i > 100 {
+			t.Fatal("expected the reference block to be refreshed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; get() != 2; i++ {
+		if i > 100 {
+			t.Fatal("expected the refreshed block to be cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Expired, fetched right away
+	clock.Advance(5 * time.Second)
+	if h := get(); h != 3 {
+		t.Fatalf("expected block 3, got %d", h)
+	}
+
+	// Disabled
+	uncached := NewReferenceBlockCache(client, clock, 0)
+	if header, err := uncached.Get(ctx); err != nil || header.Height != 4 {
+		t.Fatalf("expected block 4 without caching, got %v (%v)", header, err)
+	}
+}
@@ -0,0 +1,215 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	"google.golang.org/grpc"
+)
+
+var ErrHeightNotCovered = errors.New("no access node configured for block height")
+
+// HistoricalNode is an access node of a past spork, serving the blocks from
+// RootHeight up to the root height of the next spork.
+type HistoricalNode struct {
+	RootHeight uint64
+	Host       string
+	client     FlowClient
+}
+
+// ParseHistoricalHosts parses 'rootHeight=host' entries of historical
+// access nodes, sorted by root height. Root heights must be below
+// 'currentRootHeight', the root height of the current spork.
+func ParseHistoricalHosts(entries []string, currentRootHeight uint64) ([]HistoricalNode, error) {
+	nodes := make([]HistoricalNode, 0, len(entries))
+	seen := make(map[uint64]bool, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid historical access node '%s', expected 'rootHeight=host'", entry)
+		}
+
+		height, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root height of historical access node '%s': %w", entry, err)
+		}
+
+		if height >= currentRootHeight {
+			return nil, fmt.Errorf("root height of historical access node '%s' is not below the current spork root height %d", entry, currentRootHeight)
+		}
+
+		if seen[height] {
+			return nil, fmt.Errorf("duplicate root height %d in historical access nodes", height)
+		}
+		seen[height] = true
+
+		nodes = append(nodes, HistoricalNode{RootHeight: height, Host: parts[1]})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].RootHeight < nodes[j].RootHeight })
+
+	return nodes, nil
+}
+
+// SporkClient routes queries for blocks of past sporks to historical access
+// nodes, anything else goes to the client of the current spork. Event range
+// queries spanning sporks are split between the nodes. Transactions not
+// found on the current spork are looked up on the historical nodes, newest
+// first.
+type SporkClient struct {
+	current    FlowClient
+	rootHeight uint64           // Root height of the current spork
+	historical []HistoricalNode // By RootHeight, ascending
+	shared     bool             // Historical clients are owned by another SporkClient
+}
+
+// NewSporkClient returns a SporkClient using 'current' for blocks from
+// 'rootHeight' on and the clients of 'historical' for older blocks.
+func NewSporkClient(current FlowClient, rootHeight uint64, historical []HistoricalNode) *SporkClient {
+	return &SporkClient{current: current, rootHeight: rootHeight, historical: historical}
+}
+
+// newHistoricalNodes connects to the historical access nodes in 'cfg'.
+func newHistoricalNodes(cfg *config.Config, clock common.Clock) ([]HistoricalNode, error) {
+	nodes, err := ParseHistoricalHosts(cfg.AccessAPIHistoricalHosts, cfg.AccessAPISporkRootHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		c, err := NewFlowClient(nodes[i].Host, cfg)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i].client = withCircuitBreaker(c, cfg, clock, nodes[i].Host)
+	}
+
+	return nodes, nil
+}
+
+// withCurrent returns a SporkClient with the same historical nodes using
+// 'current' for the current spork.
+func (s *SporkClient) withCurrent(current FlowClient) *SporkClient {
+	return &SporkClient{current: current, rootHeight: s.rootHeight, historical: s.historical, shared: true}
+}
+
+// clientFor returns the client serving 'height' and the last height it
+// serves.
+func (s *SporkClient) clientFor(height uint64) (FlowClient, uint64, error) {
+	if height >= s.rootHeight {
+		return s.current, ^uint64(0), nil
+	}
+
+	for i := len(s.historical) - 1; i >= 0; i-- {
+		n := s.historical[i]
+		if height < n.RootHeight {
+			continue
+		}
+		end := s.rootHeight - 1
+		if i+1 < len(s.historical) {
+			end = s.historical[i+1].RootHeight - 1
+		}
+		return n.client, end, nil
+	}
+
+	return nil, 0, fmt.Errorf("%w %d", ErrHeightNotCovered, height)
+}
+
+func (s *SporkClient) GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) ([]client.BlockEvents, error) {
+	if query.StartHeight >= s.rootHeight {
+		return s.current.GetEventsForHeightRange(ctx, query, opts...)
+	}
+
+	res := []client.BlockEvents{}
+
+	for start := query.StartHeight; start <= query.EndHeight; {
+		c, end, err := s.clientFor(start)
+		if err != nil {
+			return nil, err
+		}
+		if end > query.EndHeight {
+			end = query.EndHeight
+		}
+
+		events, err := c.GetEventsForHeightRange(ctx, client.EventRangeQuery{
+			Type:        query.Type,
+			StartHeight: start,
+			EndHeight:   end,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, events...)
+
+		if end == query.EndHeight {
+			break
+		}
+		start = end + 1
+	}
+
+	return res, nil
+}
+
+func (s *SporkClient) GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.Transaction, error) {
+	tx, err := s.current.GetTransaction(ctx, txID, opts...)
+	if !IsNotFoundError(err) {
+		return tx, err
+	}
+
+	for i := len(s.historical) - 1; i >= 0; i-- {
+		if historicalTx, historicalErr := s.historical[i].client.GetTransaction(ctx, txID, opts...); historicalErr == nil {
+			return historicalTx, nil
+		}
+	}
+
+	return tx, err
+}
+
+func (s *SporkClient) Ping(ctx context.Context, opts ...grpc.CallOption) error {
+	return s.current.Ping(ctx, opts...)
+}
+
+func (s *SporkClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return s.current.GetLatestBlockHeader(ctx, isSealed, opts...)
+}
+
+func (s *SporkClient) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (*flow.Account, error) {
+	return s.current.GetAccount(ctx, address, opts...)
+}
+
+func (s *SporkClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	return s.current.SendTransaction(ctx, tx, opts...)
+}
+
+func (s *SporkClient) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error) {
+	return s.current.GetTransactionResult(ctx, txID, opts...)
+}
+
+func (s *SporkClient) ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value, opts ...grpc.CallOption) (cadence.Value, error) {
+	return s.current.ExecuteScriptAtLatestBlock(ctx, script, arguments, opts...)
+}
+
+// Close closes the current and the historical clients, unless the latter
+// are shared.
+func (s *SporkClient) Close() error {
+	err := s.current.Close()
+	if s.shared {
+		return err
+	}
+	for _, n := range s.historical {
+		if closeErr := n.client.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type rangeClient struct {
+	FlowClient
+	queries []client.EventRangeQuery
+	tx      *flow.Transaction
+}
+
+func (c *rangeClient) GetEventsForHeightRange(ctx context.Context, query client.EventRangeQuery, opts ...grpc.CallOption) ([]client.BlockEvents, error) {
+	c.queries = append(c.queries, query)
+	return []client.BlockEvents{{Height: query.StartHeight}}, nil
+}
+
+func (c *rangeClient) GetTransaction(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.Transaction, error) {
+	if c.tx == nil {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return c.tx, nil
+}
+
+func TestParseHistoricalHosts(t *testing.T) {
+	nodes, err := ParseHistoricalHosts([]string{"200=node-b:9000", "100=node-a:9000"}, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].RootHeight != 100 || nodes[0].Host != "node-a:9000" || nodes[1].RootHeight != 200 {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+
+	for _, entries := range [][]string{
+		{"node-a:9000"},
+		{"x=node-a:9000"},
+		{"100="},
+		{"300=node-a:9000"},
+		{"100=node-a:9000", "100=node-b:9000"},
+	} {
+		if _, err := ParseHistoricalHosts(entries, 300); err == nil {
+			t.Errorf("expected an error for %v", entries)
+		}
+	}
+}
+
+func TestSporkClientEventRouting(t *testing.T) {
+	a, b, current := &rangeClient{}, &rangeClient{}, &rangeClient{}
+	s := NewSporkClient(current, 300, []HistoricalNode{{RootHeight: 100, client: a}, {RootHeight: 200, client: b}})
+	ctx := context.Background()
+
+	// Current spork only
+	if _, err := s.GetEventsForHeightRange(ctx, client.EventRangeQuery{StartHeight: 300, EndHeight: 310}); err != nil {
+		t.Fatal(err)
+	}
+	if len(current.queries) != 1 || len(a.queries) != 0 || len(b.queries) != 0 {
+		t.Fatal("expected the query to go to the current spork")
+	}
+
+	// Spanning all sporks, split at the root heights
+	events, err := s.GetEventsForHeightRange(ctx, client.EventRangeQuery{StartHeight: 150, EndHeight: 305})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected events of 3 nodes, got %d", len(events))
+	}
+	if q := a.queries[0]; q.StartHeight != 150 || q.EndHeight != 199 {
+		t.Fatalf("unexpected query to first spork %+v", q)
+	}
+	if q := b.queries[0]; q.StartHeight != 200 || q.EndHeight != 299 {
+		t.Fatalf("unexpected query to second spork %+v", q)
+	}
+	if q := current.queries[1]; q.StartHeight != 300 || q.EndHeight != 305 {
+		t.Fatalf("unexpected query to current spork %+v", q)
+	}
+
+	// Before the first configured spork
+	if _, err := s.GetEventsForHeightRange(ctx, client.EventRangeQuery{StartHeight: 50, EndHeight: 120}); !errors.Is(err, ErrHeightNotCovered) {
+		t.Fatalf("expected ErrHeightNotCovered, got %v", err)
+	}
+}
+
+func TestSporkClientTransactionFallback(t *testing.T) {
+	tx := flow.NewTransaction()
+	a, b, current := &rangeClient{tx: tx}, &rangeClient{}, &rangeClient{}
+	s := NewSporkClient(current, 300, []HistoricalNode{{RootHeight: 100, client: a}, {RootHeight: 200, client: b}})
+
+	got, err := s.GetTransaction(context.Background(), flow.EmptyID)
+	if err != nil || got != tx {
+		t.Fatalf("expected the transaction from a historical node, got %v (%v)", got, err)
+	}
+
+	a.tx = nil
+	if _, err := s.GetTransaction(context.Background(), flow.EmptyID); !IsNotFoundError(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -2,14 +2,20 @@ package flow_helpers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/onflow/flow-go-sdk"
-	"github.com/onflow/flow-go-sdk/client"
 )
 
-func SignProposeAndPayAs(ctx context.Context, flowClient *client.Client, account *Account, tx *flow.Transaction) (UnlockKeyFunc, error) {
+// TransactionExpiry is the number of blocks after its reference block a
+// transaction expires if not yet included in a collection
+const TransactionExpiry = 600
+
+func SignProposeAndPayAs(ctx context.Context, flowClient FlowClient, account *Account, tx *flow.Transaction) (UnlockKeyFunc, error) {
 
 	signer, err := account.GetSigner()
 	if err != nil {
@@ -33,44 +39,105 @@ func SignProposeAndPayAs(ctx context.Context, flowClient *client.Client, account
 	return unlock, nil
 }
 
-// WaitForSeal blocks until
-// - an error occurs while fetching the transaction result
-// - the transaction gets an error status
-// - the transaction gets a "TransactionStatusSealed" or "TransactionStatusExpired" status
-// - timeout is reached
-func WaitForSeal(ctx context.Context, c *client.Client, id flow.Identifier, timeout time.Duration) (*flow.TransactionResult, error) {
-	var (
-		result *flow.TransactionResult
-		err    error
-	)
-
-	if timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
+var (
+	ErrTransactionExpired  = errors.New("transaction expired")
+	ErrTransactionReverted = errors.New("transaction reverted")
+	ErrWaitTimeout         = errors.New("timeout while waiting for transaction result")
+)
+
+// PollOptions configure how the result of a transaction is polled for.
+type PollOptions struct {
+	Interval    time.Duration // Delay before the second poll, grows by half for each poll after that
+	MaxInterval time.Duration // Max delay between polls, no backoff if not above Interval
+	Timeout     time.Duration // Max time to wait (measured using the clock), 0 means no limit
+	Jitter      float64       // Fraction (0-1) each delay is randomly varied by
+}
+
+// Delay returns how long to wait after the 'poll'th (1 = first) poll,
+// 'random' is in [0, 1) and varies the delay by Jitter.
+func (o PollOptions) Delay(poll int, random float64) time.Duration {
+	d := o.Interval
+	for i := 1; i < poll && d < o.MaxInterval; i++ {
+		d += d / 2
+	}
+	if d > o.MaxInterval && o.MaxInterval > o.Interval {
+		d = o.MaxInterval
 	}
+	if o.Jitter > 0 {
+		d += time.Duration(float64(d) * o.Jitter * (2*random - 1))
+	}
+	return d
+}
+
+// WaitForSeal polls for the result of transaction 'id' until it is sealed
+// and returns it. Fails with
+// - ErrTransactionReverted (wrapping the error) if execution failed
+// - ErrTransactionExpired if the transaction expired
+// - ErrWaitTimeout if not sealed within the timeout, along with the last result
+// - the error of 'ctx' if it is done
+// - the error of fetching the result
+func WaitForSeal(ctx context.Context, c FlowClient, clock common.Clock, id flow.Identifier, opts PollOptions) (*flow.TransactionResult, error) {
+	return waitForStatus(ctx, c, clock, id, opts, flow.TransactionStatusSealed)
+}
+
+// WaitForFinalize is like WaitForSeal but returns once the transaction is
+// finalized (or already sealed).
+func WaitForFinalize(ctx context.Context, c FlowClient, clock common.Clock, id flow.Identifier, opts PollOptions) (*flow.TransactionResult, error) {
+	return waitForStatus(ctx, c, clock, id, opts, flow.TransactionStatusFinalized)
+}
 
-	for {
-		result, err = c.GetTransactionResult(ctx, id)
+// waitForStatus polls for the result of transaction 'id' until it has reached
+// 'status', see WaitForSeal. Each poll is bounded by the time left until the
+// timeout, so a hanging Access API call does not outlast it.
+func waitForStatus(ctx context.Context, c FlowClient, clock common.Clock, id flow.Identifier, opts PollOptions, status flow.TransactionStatus) (*flow.TransactionResult, error) {
+	var result *flow.TransactionResult
+
+	deadline := clock.Now().Add(opts.Timeout)
+
+	for poll := 1; ; poll++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		left := deadline.Sub(clock.Now())
+		if opts.Timeout > 0 && left <= 0 {
+			return result, ErrWaitTimeout
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.Timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, left)
+		}
+		r, err := c.GetTransactionResult(callCtx, id)
+		timedOut := callCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if err != nil && timedOut {
+			return result, ErrWaitTimeout
+		}
 		if err != nil {
 			return nil, err
 		}
+		result = r
 
 		if result.Error != nil {
-			return result, result.Error
+			return result, fmt.Errorf("%w: %s", ErrTransactionReverted, result.Error)
 		}
 
-		switch result.Status {
-		default:
-			// Not an interesting state, exit switch and continue loop
-		case flow.TransactionStatusExpired:
-			// Expired, handle as an error
-			return result, fmt.Errorf("transaction expired")
-		case flow.TransactionStatusSealed:
-			// Sealed, all good
+		switch {
+		case result.Status == flow.TransactionStatusExpired:
+			return result, ErrTransactionExpired
+		case result.Status == flow.TransactionStatusSealed, result.Status == status:
 			return result, nil
 		}
 
-		time.Sleep(time.Second)
+		delay := opts.Delay(poll, rand.Float64())
+		if left = deadline.Sub(clock.Now()); opts.Timeout > 0 && delay > left {
+			delay = left
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-clock.After(delay):
+		}
 	}
 }
@@ -0,0 +1,122 @@
+package flow_helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+)
+
+type resultClient struct {
+	FlowClient
+	results []*flow.TransactionResult
+}
+
+func (c *resultClient) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error) {
+	r := c.results[0]
+	if len(c.results) > 1 {
+		c.results = c.results[1:]
+	}
+	return r, nil
+}
+
+// hangingClient blocks on results until the context of the call is done.
+type hangingClient struct {
+	FlowClient
+}
+
+func (hangingClient) GetTransactionResult(ctx context.Context, txID flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPollOptionsDelay(t *testing.T) {
+	o := PollOptions{Interval: time.Second, MaxInterval: 3 * time.Second}
+
+	for poll, expected := range map[int]time.Duration{
+		1: time.Second,
+		2: 1500 * time.Millisecond,
+		3: 2250 * time.Millisecond,
+		4: 3 * time.Second,
+		9: 3 * time.Second,
+	} {
+		if d := o.Delay(poll, 0.5); d != expected {
+			t.Errorf("poll %d: expected %s, got %s", poll, expected, d)
+		}
+	}
+
+	if d := (PollOptions{Interval: time.Second}).Delay(5, 0.5); d != time.Second {
+		t.Errorf("expected no backoff without a max interval, got %s", d)
+	}
+
+	o.Jitter = 0.5
+	if d := o.Delay(1, 0); d != 500*time.Millisecond {
+		t.Errorf("expected the delay lowered by the jitter, got %s", d)
+	}
+	if d := o.Delay(1, 0.99); d < 1400*time.Millisecond || d > 1500*time.Millisecond {
+		t.Errorf("expected the delay raised by the jitter, got %s", d)
+	}
+}
+
+func TestWaitForSeal(t *testing.T) {
+	ctx := context.Background()
+	opts := PollOptions{Interval: time.Millisecond, Timeout: time.Minute}
+	pending := &flow.TransactionResult{Status: flow.TransactionStatusPending}
+
+	for _, c := range []struct {
+		name     string
+		results  []*flow.TransactionResult
+		expected error
+	}{
+		{"sealed", []*flow.TransactionResult{pending, {Status: flow.TransactionStatusSealed}}, nil},
+		{"reverted", []*flow.TransactionResult{{Status: flow.TransactionStatusSealed, Error: errors.New("panic")}}, ErrTransactionReverted},
+		{"expired", []*flow.TransactionResult{pending, {Status: flow.TransactionStatusExpired}}, ErrTransactionExpired},
+	} {
+		result, err := WaitForSeal(ctx, &resultClient{results: c.results}, common.RealClock{}, flow.EmptyID, opts)
+		if !errors.Is(err, c.expected) || (c.expected == nil && err != nil) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, err)
+		}
+		if result == nil {
+			t.Errorf("%s: expected a result", c.name)
+		}
+	}
+
+	// Timeout, measured with the clock
+	clock := common.NewVirtualClock(time.Now())
+	done := make(chan error)
+	go func() {
+		_, err := WaitForSeal(ctx, &resultClient{results: []*flow.TransactionResult{pending}}, clock, flow.EmptyID, PollOptions{Interval: time.Second, Timeout: time.Minute})
+		done <- err
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Minute)
+	if err := <-done; !errors.Is(err, ErrWaitTimeout) {
+		t.Errorf("expected ErrWaitTimeout, got %v", err)
+	}
+
+	// A hanging call does not outlast the timeout
+	start := time.Now()
+	if _, err := WaitForSeal(ctx, hangingClient{}, common.RealClock{}, flow.EmptyID, PollOptions{Interval: time.Second, Timeout: 50 * time.Millisecond}); !errors.Is(err, ErrWaitTimeout) {
+		t.Errorf("expected ErrWaitTimeout from a hanging call, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected the hanging call to be cut off at the timeout, took %s", d)
+	}
+
+	// Context cancellation
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := WaitForSeal(cancelled, &resultClient{results: []*flow.TransactionResult{pending}}, common.RealClock{}, flow.EmptyID, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// Finalized is enough for WaitForFinalize
+	result, err := WaitForFinalize(ctx, &resultClient{results: []*flow.TransactionResult{pending, {Status: flow.TransactionStatusFinalized}}}, common.RealClock{}, flow.EmptyID, opts)
+	if err != nil || result.Status != flow.TransactionStatusFinalized {
+		t.Errorf("expected a finalized result, got %v (%v)", result, err)
+	}
+}
@@ -0,0 +1,572 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// MaxDepth is the max nesting of selection sets in a query, limiting the
+// work a single query can cause.
+const MaxDepth = 10
+
+// Request is a GraphQL request as POSTed in JSON.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response to a Request. Data is nil if the request could not be executed.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error of a request, Path is set for errors of a field.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Execute parses, validates and executes the query of 'req'. Fields are
+// resolved one after the other.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	v := &validator{doc: doc, variables: variables, definitions: map[string]*variableDefinition{}}
+	for _, d := range op.variables {
+		v.definitions[d.name] = d
+	}
+	if len(op.directives) > 0 {
+		v.errorf("directives on operations are not supported")
+	}
+	v.validate(s.Query, op.selections, 1, map[string]bool{})
+	if len(v.errors) > 0 {
+		return &Response{Errors: v.errors}
+	}
+
+	e := &executor{doc: doc, variables: variables}
+	data := e.executeSelections(ctx, s.Query, nil, op.selections, nil)
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (doc *document) operation(name string) (*operation, error) {
+	var op *operation
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with more than one operation")
+		}
+		op = doc.operations[0]
+	} else {
+		for _, o := range doc.operations {
+			if o.name == name {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, fmt.Errorf("unknown operation \"%s\"", name)
+		}
+	}
+
+	if op.kind != "query" {
+		return nil, fmt.Errorf("only queries are supported, got %s", op.kind)
+	}
+
+	return op, nil
+}
+
+// coerceVariables coerces the JSON decoded 'values' of the variables of 'op'.
+func coerceVariables(op *operation, values map[string]interface{}) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+
+	for _, d := range op.variables {
+		value, ok := values[d.name]
+		if !ok {
+			if d.hasDefault {
+				value = d.defaultValue
+			} else if d.typ.nonNull {
+				return nil, fmt.Errorf("variable \"$%s\" of type %s is required", d.name, d.typ)
+			} else {
+				continue
+			}
+		} else {
+			value = fromJSON(value)
+		}
+
+		coerced, err := coerceTypeRef(d.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable \"$%s\": %w", d.name, err)
+		}
+		res[d.name] = coerced
+	}
+
+	return res, nil
+}
+
+// fromJSON converts numbers in a JSON decoded value to int64 where they
+// are integral.
+func fromJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = fromJSON(item)
+		}
+		return res
+	}
+	return v
+}
+
+// coerceTypeRef checks the shape and nullability of a variable value 't',
+// scalars are coerced once used as an argument.
+func coerceTypeRef(t *typeRef, v interface{}) (interface{}, error) {
+	if v == nil {
+		if t.nonNull {
+			return nil, fmt.Errorf("expected a non-null %s", t)
+		}
+		return nil, nil
+	}
+
+	if t.elem == nil {
+		if _, ok := v.([]interface{}); ok {
+			return nil, fmt.Errorf("expected %s, got a list", t)
+		}
+		return v, nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+	res := make([]interface{}, len(list))
+	for i, item := range list {
+		c, err := coerceTypeRef(t.elem, item)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = c
+	}
+	return res, nil
+}
+
+// coerceInput coerces 'v' (with variables replaced) to argument type 't'.
+func coerceInput(t Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		if e, ok := v.(enum); ok {
+			return nil, fmt.Errorf("expected %s, got %s", t, e)
+		}
+		return t.ParseValue(v)
+
+	case List:
+		list, ok := v.([]interface{})
+		if !ok {
+			list = []interface{}{v}
+		}
+		res := make([]interface{}, len(list))
+		for i, item := range list {
+			c, err := coerceInput(t.Of, item)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = c
+		}
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("%s can not be used as an input type", t)
+}
+
+type validator struct {
+	doc         *document
+	variables   map[string]interface{}
+	definitions map[string]*variableDefinition
+	errors      []*Error
+}
+
+func (v *validator) errorf(format string, a ...interface{}) {
+	v.errors = append(v.errors, &Error{Message: fmt.Sprintf(format, a...)})
+}
+
+// validate checks 'selections' of 'object' against the schema and coerces
+// the arguments of their fields. 'fragments' holds the fragments being
+// spread, to detect cycles.
+func (v *validator) validate(object *Object, selections []selection, depth int, fragments map[string]bool) {
+	if depth > MaxDepth {
+		v.errorf("query is nested deeper than %d levels", MaxDepth)
+		return
+	}
+
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			v.validateDirectives(s.directives)
+			v.validateField(object, s, depth, fragments)
+
+		case *fragmentSpread:
+			v.validateDirectives(s.directives)
+			f, ok := v.doc.fragments[s.name]
+			if !ok {
+				v.errorf("unknown fragment \"%s\"", s.name)
+				continue
+			}
+			if fragments[s.name] {
+				v.errorf("fragment \"%s\" spreads itself", s.name)
+				continue
+			}
+			if f.typeCondition != object.Name {
+				v.errorf("fragment \"%s\" on %s can not be spread on %s", s.name, f.typeCondition, object.Name)
+				continue
+			}
+			if len(f.directives) > 0 {
+				v.errorf("directives on fragment definitions are not supported")
+			}
+			fragments[s.name] = true
+			v.validate(object, f.selections, depth, fragments)
+			delete(fragments, s.name)
+
+		case *inlineFragment:
+			v.validateDirectives(s.directives)
+			if s.typeCondition != "" && s.typeCondition != object.Name {
+				v.errorf("fragment on %s can not be spread on %s", s.typeCondition, object.Name)
+				continue
+			}
+			v.validate(object, s.selections, depth, fragments)
+		}
+	}
+}
+
+func (v *validator) validateField(object *Object, f *field, depth int, fragments map[string]bool) {
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || f.selections != nil {
+			v.errorf("field \"__typename\" takes no arguments or selections")
+		}
+		return
+	}
+	if f.name == "__schema" || f.name == "__type" {
+		v.errorf("introspection is not supported, see the schema definition instead")
+		return
+	}
+
+	def := object.field(f.name)
+	if def == nil {
+		v.errorf("cannot query field \"%s\" on type %s", f.name, object.Name)
+		return
+	}
+
+	f.args = map[string]interface{}{}
+	for _, a := range f.arguments {
+		argDef := def.arg(a.name)
+		if argDef == nil {
+			v.errorf("unknown argument \"%s\" of field %s.%s", a.name, object.Name, f.name)
+			continue
+		}
+		value, err := v.resolveVariables(a.value, argDef)
+		if err != nil {
+			v.errorf("argument \"%s\" of field %s.%s: %s", a.name, object.Name, f.name, err)
+			continue
+		}
+		if value == nil {
+			if _, ok := a.value.(variable); ok {
+				// Unset variables leave the argument out
+				continue
+			}
+		}
+		coerced, err := coerceInput(argDef.Type, value)
+		if err != nil {
+			v.errorf("argument \"%s\" of field %s.%s: %s", a.name, object.Name, f.name, err)
+			continue
+		}
+		f.args[a.name] = coerced
+	}
+	for _, argDef := range def.Args {
+		if _, ok := f.args[argDef.Name]; !ok && argDef.DefaultValue != nil {
+			f.args[argDef.Name] = argDef.DefaultValue
+		}
+		if argDef.Required && f.args[argDef.Name] == nil {
+			v.errorf("argument \"%s\" of field %s.%s is required", argDef.Name, object.Name, f.name)
+		}
+	}
+
+	switch t := namedType(def.Type).(type) {
+	case *Object:
+		if f.selections == nil {
+			v.errorf("field \"%s\" of type %s must have a selection of subfields", f.name, def.Type)
+			return
+		}
+		v.validate(t, f.selections, depth+1, fragments)
+	default:
+		if f.selections != nil {
+			v.errorf("field \"%s\" of type %s can not have a selection of subfields", f.name, def.Type)
+		}
+	}
+}
+
+// resolveVariables replaces the variables in 'value', checking they are
+// defined and their type matches 'arg'.
+func (v *validator) resolveVariables(value interface{}, arg *Argument) (interface{}, error) {
+	switch value := value.(type) {
+	case variable:
+		d, ok := v.definitions[string(value)]
+		if !ok {
+			return nil, fmt.Errorf("variable \"$%s\" is not defined", value)
+		}
+		if named := namedType(arg.Type); d.typ.named() != named.String() {
+			return nil, fmt.Errorf("variable \"$%s\" of type %s can not be used as %s", value, d.typ, arg.Type)
+		}
+		return v.variables[string(value)], nil
+
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			r, err := v.resolveVariables(item, arg)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = r
+		}
+		return res, nil
+
+	case map[string]interface{}:
+		return nil, fmt.Errorf("input objects are not supported")
+	}
+
+	return value, nil
+}
+
+func (t *typeRef) named() string {
+	for t.elem != nil {
+		t = t.elem
+	}
+	return t.name
+}
+
+func (v *validator) validateDirectives(directives []*directive) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf("unknown directive \"@%s\"", d.name)
+			continue
+		}
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			v.errorf("directive \"@%s\" takes a single argument \"if\"", d.name)
+			continue
+		}
+		value, err := v.resolveVariables(d.arguments[0].value, &Argument{Type: Boolean})
+		if err == nil {
+			_, err = Boolean.ParseValue(value)
+		}
+		if err != nil {
+			v.errorf("argument \"if\" of directive \"@%s\": %s", d.name, err)
+		}
+	}
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+// included evaluates the @skip and @include 'directives', which have been
+// validated.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		value := d.arguments[0].value
+		if name, ok := value.(variable); ok {
+			value = e.variables[string(name)]
+		}
+		if b, _ := value.(bool); b != (d.name == "include") {
+			return false
+		}
+	}
+	return true
+}
+
+// collectFields groups the fields of 'selections' by response key, in the
+// order the keys first appear.
+func (e *executor) collectFields(selections []selection, keys *[]string, fields map[string][]*field) {
+	for _, s := range selections {
+		switch s := s.(type) {
+		case *field:
+			if !e.included(s.directives) {
+				continue
+			}
+			key := s.responseKey()
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], s)
+
+		case *fragmentSpread:
+			if e.included(s.directives) {
+				e.collectFields(e.doc.fragments[s.name].selections, keys, fields)
+			}
+
+		case *inlineFragment:
+			if e.included(s.directives) {
+				e.collectFields(s.selections, keys, fields)
+			}
+		}
+	}
+}
+
+func (e *executor) executeSelections(ctx context.Context, object *Object, source interface{}, selections []selection, path []interface{}) *orderedMap {
+	keys := []string{}
+	fields := map[string][]*field{}
+	e.collectFields(selections, &keys, fields)
+
+	res := &orderedMap{values: map[string]interface{}{}}
+
+	for _, key := range keys {
+		nodes := fields[key]
+		f := nodes[0]
+		fieldPath := append(append([]interface{}{}, path...), key)
+
+		for _, n := range nodes[1:] {
+			if n.name != f.name {
+				e.fieldError(fieldPath, fmt.Errorf("fields \"%s\" and \"%s\" conflict, use different aliases", f.name, n.name))
+			}
+		}
+
+		if f.name == "__typename" {
+			res.set(key, object.Name)
+			continue
+		}
+
+		def := object.field(f.name)
+		value, err := def.Resolve(ctx, source, f.args)
+		if err != nil {
+			e.fieldError(fieldPath, err)
+			res.set(key, nil)
+			continue
+		}
+
+		res.set(key, e.completeValue(ctx, def.Type, nodes, value, fieldPath))
+	}
+
+	return res
+}
+
+func (e *executor) completeValue(ctx context.Context, t Type, nodes []*field, value interface{}, path []interface{}) interface{} {
+	r := reflect.ValueOf(value)
+	for r.Kind() == reflect.Ptr || r.Kind() == reflect.Interface {
+		if r.IsNil() {
+			return nil
+		}
+		r = r.Elem()
+	}
+	if !r.IsValid() {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		v, err := t.Serialize(r.Interface())
+		if err != nil {
+			e.fieldError(path, err)
+			return nil
+		}
+		return v
+
+	case *Object:
+		selections := []selection{}
+		for _, n := range nodes {
+			selections = append(selections, n.selections...)
+		}
+		return e.executeSelections(ctx, t, value, selections, path)
+
+	case List:
+		if r.Kind() != reflect.Slice && r.Kind() != reflect.Array {
+			e.fieldError(path, fmt.Errorf("expected a list, got %T", value))
+			return nil
+		}
+		res := make([]interface{}, r.Len())
+		for i := 0; i < r.Len(); i++ {
+			item := r.Index(i)
+			if item.CanAddr() && item.Kind() == reflect.Struct {
+				item = item.Addr()
+			}
+			res[i] = e.completeValue(ctx, t.Of, nodes, item.Interface(), append(append([]interface{}{}, path...), i))
+		}
+		return res
+	}
+
+	return nil
+}
+
+func (e *executor) fieldError(path []interface{}, err error) {
+	e.errors = append(e.errors, &Error{Message: err.Error(), Path: path})
+}
+
+// orderedMap is a JSON object keeping the order of its keys, responses list
+// fields in the order they were queried.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
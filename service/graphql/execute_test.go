@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	ID    string
+	N     int
+	Child *testItem
+}
+
+func testSchema() *Schema {
+	item := &Object{Name: "Item"}
+	item.Fields = []*Field{
+		{Name: "id", Type: ID, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).ID, nil
+		}},
+		{Name: "n", Type: Int, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).N, nil
+		}},
+		{Name: "child", Type: item, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).Child, nil
+		}},
+		{Name: "fail", Type: String, Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("failed")
+		}},
+	}
+
+	items := []testItem{{ID: "a", N: 1, Child: &testItem{ID: "c", N: 3}}, {ID: "b", N: 2}}
+
+	return &Schema{Query: &Object{Name: "Query", Fields: []*Field{
+		{
+			Name: "hello",
+			Type: String,
+			Args: []*Argument{{Name: "name", Type: String, DefaultValue: "world"}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return "hello " + args["name"].(string), nil
+			},
+		},
+		{
+			Name: "items",
+			Type: List{item},
+			Args: []*Argument{{Name: "limit", Type: Int}, {Name: "ids", Type: List{ID}}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				res := items
+				if limit, ok := args["limit"].(int); ok && limit < len(res) {
+					res = res[:limit]
+				}
+				return res, nil
+			},
+		},
+		{
+			Name: "item",
+			Type: item,
+			Args: []*Argument{{Name: "id", Type: ID, Required: true}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				for i := range items {
+					if items[i].ID == args["id"] {
+						return &items[i], nil
+					}
+				}
+				return nil, nil
+			},
+		},
+	}}}
+}
+
+func execute(t *testing.T, req Request) string {
+	t.Helper()
+	b, err := json.Marshal(testSchema().Execute(context.Background(), req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestExecute(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		req      Request
+		expected string
+	}{
+		{
+			"shorthand with default argument",
+			Request{Query: `{ hello }`},
+			`{"data":{"hello":"hello world"}}`,
+		},
+		{
+			"aliases and arguments",
+			Request{Query: `{ a: hello(name: "a") b: hello(name: "b") __typename }`},
+			`{"data":{"a":"hello a","b":"hello b","__typename":"Query"}}`,
+		},
+		{
+			"nested lists and objects in query order",
+			Request{Query: `query { items { n id child { id } } }`},
+			`{"data":{"items":[{"n":1,"id":"a","child":{"id":"c"}},{"n":2,"id":"b","child":null}]}}`,
+		},
+		{
+			"variables",
+			Request{
+				Query:     `query Items($limit: Int, $id: ID!) { items(limit: $limit) { id } item(id: $id) { n } }`,
+				Variables: map[string]interface{}{"limit": float64(1), "id": "b"},
+			},
+			`{"data":{"items":[{"id":"a"}],"item":{"n":2}}}`,
+		},
+		{
+			"fragments and directives",
+			Request{
+				Query: `query($skip: Boolean = true) { items { ...F ... on Item { n @skip(if: $skip) } } }
+					fragment F on Item { id child @include(if: false) { id } }`,
+			},
+			`{"data":{"items":[{"id":"a"},{"id":"b"}]}}`,
+		},
+		{
+			"operation name",
+			Request{Query: `query A { hello } query B { hello(name: "b") }`, OperationName: "B"},
+			`{"data":{"hello":"hello b"}}`,
+		},
+		{
+			"field error",
+			Request{Query: `{ item(id: "a") { id fail } }`},
+			`{"data":{"item":{"id":"a","fail":null}},"errors":[{"message":"failed","path":["item","fail"]}]}`,
+		},
+	} {
+		if res := execute(t, c.req); res != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, res)
+		}
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	for _, c := range []struct {
+		query     string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{`{ hello`, nil, "syntax error"},
+		{`mutation { hello }`, nil, "only queries are supported"},
+		{`query A { hello } query B { hello }`, nil, "operationName is required"},
+		{`{ unknown }`, nil, `cannot query field "unknown" on type Query`},
+		{`{ items }`, nil, "must have a selection of subfields"},
+		{`{ hello { id } }`, nil, "can not have a selection of subfields"},
+		{`{ item { id } }`, nil, `argument "id" of field Query.item is required`},
+		{`{ hello(other: "x") }`, nil, "unknown argument"},
+		{`{ hello(name: 1) }`, nil, "expected a string"},
+		{`{ items(limit: $limit) { id } }`, nil, `variable "$limit" is not defined`},
+		{`query($limit: String) { items(limit: $limit) { id } }`, nil, "can not be used as Int"},
+		{`query($id: ID!) { item(id: $id) { id } }`, nil, `variable "$id" of type ID! is required`},
+		{`{ items { ...F } } fragment F on Item { child { ...F } }`, nil, "spreads itself"},
+		{`{ ...F } fragment F on Item { id }`, nil, "can not be spread on Query"},
+		{`{ __schema { types { name } } }`, nil, "introspection is not supported"},
+		{`{ hello @deprecated }`, nil, "unknown directive"},
+		{`{ item(id: "a") { child { child { child { child { child { child { child { child { child { child { id } } } } } } } } } } } }`, nil, "nested deeper"},
+	} {
+		res := testSchema().Execute(context.Background(), Request{Query: c.query, Variables: c.variables})
+		if res.Data != nil {
+			t.Errorf("%s: expected no data, got %v", c.query, res.Data)
+		}
+		if len(res.Errors) == 0 || !strings.Contains(res.Errors[0].Message, c.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", c.query, c.expected, res.Errors)
+		}
+	}
+}
+
+func TestSchemaString(t *testing.T) {
+	sdl := testSchema().String()
+
+	for _, expected := range []string{
+		"schema {\n  query: Query\n}\n",
+		"type Query {\n  hello(name: String = \"world\"): String\n  items(limit: Int, ids: [ID]): [Item]\n  item(id: ID!): Item\n}\n",
+		"type Item {\n  id: ID\n  n: Int\n  child: Item\n  fail: String\n}\n",
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("expected schema to contain %q, got\n%s", expected, sdl)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const bom = "\uFEFF"
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "<EOF>"
+	case tokenString:
+		return strconv.Quote(t.value)
+	}
+	return t.value
+}
+
+// lex splits a GraphQL document into tokens, dropping whitespace, commas
+// and comments. Block strings are not supported.
+func lex(src string) ([]token, error) {
+	tokens := []token{}
+	pos := 0
+
+	for {
+		// Skip ignored tokens
+		for pos < len(src) {
+			c := src[pos]
+			if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+				pos++
+				continue
+			}
+			if c == '#' {
+				for pos < len(src) && src[pos] != '\n' && src[pos] != '\r' {
+					pos++
+				}
+				continue
+			}
+			if strings.HasPrefix(src[pos:], bom) {
+				pos += len(bom)
+				continue
+			}
+			break
+		}
+
+		if pos >= len(src) {
+			return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+		}
+
+		start := pos
+		c := src[pos]
+
+		switch {
+		case strings.HasPrefix(src[pos:], "..."):
+			tokens = append(tokens, token{tokenPunct, "...", start})
+			pos += 3
+
+		case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+			tokens = append(tokens, token{tokenPunct, string(c), start})
+			pos++
+
+		case c == '_' || isLetter(c):
+			for pos < len(src) && (src[pos] == '_' || isLetter(src[pos]) || isDigit(src[pos])) {
+				pos++
+			}
+			tokens = append(tokens, token{tokenName, src[start:pos], start})
+
+		case c == '-' || isDigit(c):
+			t, err := lexNumber(src, &pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+
+		case c == '"':
+			if strings.HasPrefix(src[pos:], `"""`) {
+				return nil, fmt.Errorf("syntax error at %d: block strings are not supported", pos)
+			}
+			s, err := lexString(src, &pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, s, start})
+
+		default:
+			r, _ := utf8.DecodeRuneInString(src[pos:])
+			return nil, fmt.Errorf("syntax error at %d: unexpected character %q", pos, r)
+		}
+	}
+}
+
+func lexNumber(src string, pos *int) (token, error) {
+	start := *pos
+	kind := tokenInt
+
+	if src[*pos] == '-' {
+		*pos++
+	}
+
+	digits := func() int {
+		n := 0
+		for *pos < len(src) && isDigit(src[*pos]) {
+			*pos++
+			n++
+		}
+		return n
+	}
+
+	if n := digits(); n == 0 || (n > 1 && src[*pos-n] == '0') {
+		return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+	}
+
+	if *pos < len(src) && src[*pos] == '.' {
+		kind = tokenFloat
+		*pos++
+		if digits() == 0 {
+			return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+		}
+	}
+
+	if *pos < len(src) && (src[*pos] == 'e' || src[*pos] == 'E') {
+		kind = tokenFloat
+		*pos++
+		if *pos < len(src) && (src[*pos] == '+' || src[*pos] == '-') {
+			*pos++
+		}
+		if digits() == 0 {
+			return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+		}
+	}
+
+	if *pos < len(src) && (src[*pos] == '_' || src[*pos] == '.' || isLetter(src[*pos])) {
+		return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+	}
+
+	return token{kind, src[start:*pos], start}, nil
+}
+
+func lexString(src string, pos *int) (string, error) {
+	start := *pos
+	*pos++ // Opening quote
+
+	var b strings.Builder
+	for *pos < len(src) {
+		c := src[*pos]
+		switch {
+		case c == '"':
+			*pos++
+			return b.String(), nil
+		case c == '\n' || c == '\r':
+			return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+		case c == '\\':
+			if *pos+1 >= len(src) {
+				return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+			}
+			e := src[*pos+1]
+			*pos += 2
+			switch e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if *pos+4 > len(src) {
+					return "", fmt.Errorf("syntax error at %d: invalid unicode escape", *pos)
+				}
+				r, err := strconv.ParseUint(src[*pos:*pos+4], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("syntax error at %d: invalid unicode escape", *pos)
+				}
+				b.WriteRune(rune(r))
+				*pos += 4
+			default:
+				return "", fmt.Errorf("syntax error at %d: invalid escape '\\%c'", *pos-2, e)
+			}
+		default:
+			b.WriteByte(c)
+			*pos++
+		}
+	}
+
+	return "", fmt.Errorf("syntax error at %d: unterminated string", start)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,509 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []*variableDefinition
+	directives []*directive
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	typ          *typeRef
+	defaultValue interface{} // Parsed value, nil if none
+	hasDefault   bool
+}
+
+// typeRef is a type of a variable definition, e.g. [String!]!
+type typeRef struct {
+	name    string   // Named type, empty for lists
+	elem    *typeRef // Element type of lists
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+}
+
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []*argument
+	directives []*directive
+	selections []selection
+
+	args map[string]interface{} // Coerced arguments, set by validate
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	typeCondition string // Optional
+	directives    []*directive
+	selections    []selection
+}
+
+type argument struct {
+	name  string
+	value interface{}
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+// Parsed values besides int64, float64, string, bool, nil, []interface{}
+// and map[string]interface{}
+type (
+	variable string
+	enum     string
+)
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+// parse parses a GraphQL document.
+func parse(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: map[string]*fragment{}}
+
+	for p.peek().kind != tokenEOF {
+		switch t := p.peek(); {
+		case p.peekPunct("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+
+		case t.kind == tokenName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+
+		case t.kind == tokenName && t.value == "fragment":
+			f, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("there can be only one fragment named \"%s\"", f.name)
+			}
+			doc.fragments[f.name] = f
+
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) skipPunct(value string) bool {
+	if p.peekPunct(value) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.skipPunct(value) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	if t := p.peek(); t.kind != tokenName {
+		return "", p.unexpected()
+	}
+	return p.advance().value, nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("syntax error at %d: unexpected %s", t.pos, t)
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.advance().value}
+
+	if p.peek().kind == tokenName {
+		op.name = p.advance().value
+	}
+
+	if p.skipPunct("(") {
+		for !p.skipPunct(")") {
+			v, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, v)
+		}
+	}
+
+	var err error
+	if op.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	if op.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*variableDefinition, error) {
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &variableDefinition{name: name, typ: typ}
+
+	if p.skipPunct("=") {
+		if v.defaultValue, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+		v.hasDefault = true
+	}
+
+	return v, nil
+}
+
+func (p *parser) parseType() (*typeRef, error) {
+	t := &typeRef{}
+
+	if p.skipPunct("[") {
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+		t.elem = elem
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+
+	t.nonNull = p.skipPunct("!")
+
+	return t, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	p.advance() // fragment
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("syntax error: fragment can not be named \"on\"")
+	}
+
+	if t := p.advance(); t.kind != tokenName || t.value != "on" {
+		return nil, fmt.Errorf("syntax error at %d: expected \"on\", got %s", t.pos, t)
+	}
+
+	f := &fragment{name: name}
+
+	if f.typeCondition, err = p.expectName(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if f.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	selections := []selection{}
+	for !p.skipPunct("}") {
+		s, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("syntax error: empty selection set")
+	}
+
+	return selections, nil
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	if p.skipPunct("...") {
+		if t := p.peek(); t.kind == tokenName && t.value != "on" {
+			s := &fragmentSpread{name: p.advance().value}
+			var err error
+			if s.directives, err = p.parseDirectives(); err != nil {
+				return nil, err
+			}
+			return s, nil
+		}
+
+		f := &inlineFragment{}
+		var err error
+		if t := p.peek(); t.kind == tokenName && t.value == "on" {
+			p.advance()
+			if f.typeCondition, err = p.expectName(); err != nil {
+				return nil, err
+			}
+		}
+		if f.directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	f := &field{}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipPunct(":") {
+		f.alias = name
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+
+	if f.arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (p *parser) parseArguments() ([]*argument, error) {
+	if !p.skipPunct("(") {
+		return nil, nil
+	}
+
+	arguments := []*argument{}
+	for !p.skipPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, &argument{name, value})
+	}
+
+	return arguments, nil
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+	for p.skipPunct("@") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &directive{name, arguments})
+	}
+	return directives, nil
+}
+
+// parseValue parses a value, without variables if 'constant'.
+func (p *parser) parseValue(constant bool) (interface{}, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenInt:
+		p.advance()
+		i, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: invalid int %s", t.pos, t.value)
+		}
+		return i, nil
+
+	case tokenFloat:
+		p.advance()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at %d: invalid float %s", t.pos, t.value)
+		}
+		return f, nil
+
+	case tokenString:
+		p.advance()
+		return t.value, nil
+
+	case tokenName:
+		p.advance()
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enum(t.value), nil
+
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			p.advance()
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variable(name), nil
+
+		case "[":
+			p.advance()
+			list := []interface{}{}
+			for !p.skipPunct("]") {
+				v, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, nil
+
+		case "{":
+			p.advance()
+			object := map[string]interface{}{}
+			for !p.skipPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				if object[name], err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+			}
+			return object, nil
+		}
+	}
+
+	return nil, p.unexpected()
+}
@@ -0,0 +1,290 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a field or argument: a *Scalar, an *Object or a List.
+type Type interface {
+	String() string
+}
+
+// ResolveFunc returns the value of a field of 'source' (nil for fields of
+// the query type) for the coerced 'args'.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Scalar is a leaf type. Serialize converts the resolved value to its JSON
+// representation (nil for null), ParseValue coerces an input value (int64,
+// float64, string or bool).
+type Scalar struct {
+	Name        string
+	Description string
+	Serialize   func(v interface{}) (interface{}, error)
+	ParseValue  func(v interface{}) (interface{}, error)
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+// Object is a type with fields, in the order they are listed in the schema.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// List is a list of 'Of'.
+type List struct {
+	Of Type
+}
+
+func (l List) String() string {
+	return "[" + l.Of.String() + "]"
+}
+
+// Field of an Object. Fields are nullable, a field which fails to resolve
+// is null in the response and its error is listed.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	Resolve     ResolveFunc
+}
+
+func (f *Field) arg(name string) *Argument {
+	for _, a := range f.Args {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Argument of a Field, its Type is a *Scalar or a List of them.
+type Argument struct {
+	Name         string
+	Description  string
+	Type         Type
+	Required     bool
+	DefaultValue interface{} // Used if not given, nil for none
+}
+
+// Schema of a read-only API, mutations and subscriptions are not supported.
+type Schema struct {
+	Query *Object
+}
+
+// Built-in scalars
+var (
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(fmt.Stringer); ok {
+				return s.String(), nil
+			}
+			return fmt.Sprint(v), nil
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected a string, got %v", v)
+		},
+	}
+
+	ID = &Scalar{
+		Name:      "ID",
+		Serialize: String.Serialize,
+		ParseValue: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case int64:
+				return strconv.FormatInt(v, 10), nil
+			}
+			return nil, fmt.Errorf("expected an ID, got %v", v)
+		},
+	}
+
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(v interface{}) (interface{}, error) {
+			r := reflect.ValueOf(v)
+			switch r.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return r.Int(), nil
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if r.Uint() > math.MaxInt64 {
+					return nil, fmt.Errorf("int %d out of range", r.Uint())
+				}
+				return int64(r.Uint()), nil
+			}
+			return nil, fmt.Errorf("expected an int, got %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if i, ok := v.(int64); ok && i >= math.MinInt32 && i <= math.MaxInt32 {
+				return int(i), nil
+			}
+			return nil, fmt.Errorf("expected a 32-bit int, got %v", v)
+		},
+	}
+
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(v interface{}) (interface{}, error) {
+			r := reflect.ValueOf(v)
+			switch r.Kind() {
+			case reflect.Float32, reflect.Float64:
+				return r.Float(), nil
+			}
+			return Int.Serialize(v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case float64:
+				return v, nil
+			case int64:
+				return float64(v), nil
+			}
+			return nil, fmt.Errorf("expected a float, got %v", v)
+		},
+	}
+
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a boolean, got %T", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a boolean, got %v", v)
+		},
+	}
+)
+
+var builtinScalars = map[string]bool{"String": true, "ID": true, "Int": true, "Float": true, "Boolean": true}
+
+// namedType returns the scalar or object type at the bottom of 't'.
+func namedType(t Type) Type {
+	for {
+		l, ok := t.(List)
+		if !ok {
+			return t
+		}
+		t = l.Of
+	}
+}
+
+// String returns the schema in the GraphQL schema definition language.
+func (s *Schema) String() string {
+	var b strings.Builder
+
+	seen := map[string]bool{}
+	objects := []*Object{s.Query}
+	scalars := []*Scalar{}
+	seen[s.Query.Name] = true
+
+	for i := 0; i < len(objects); i++ {
+		for _, f := range objects[i].Fields {
+			types := []Type{namedType(f.Type)}
+			for _, a := range f.Args {
+				types = append(types, namedType(a.Type))
+			}
+			for _, t := range types {
+				if seen[t.String()] {
+					continue
+				}
+				seen[t.String()] = true
+				switch t := t.(type) {
+				case *Object:
+					objects = append(objects, t)
+				case *Scalar:
+					if !builtinScalars[t.Name] {
+						scalars = append(scalars, t)
+					}
+				}
+			}
+		}
+	}
+
+	b.WriteString("schema {\n  query: " + s.Query.Name + "\n}\n")
+
+	for _, o := range objects {
+		b.WriteString("\n")
+		writeDescription(&b, "", o.Description)
+		b.WriteString("type " + o.Name + " {\n")
+		for _, f := range o.Fields {
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					args[i] = a.Name + ": " + a.Type.String()
+					if a.Required {
+						args[i] += "!"
+					}
+					if a.DefaultValue != nil {
+						args[i] += " = " + literal(a.DefaultValue)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, sc := range scalars {
+		b.WriteString("\n")
+		writeDescription(&b, "", sc.Description)
+		b.WriteString("scalar " + sc.Name + "\n")
+	}
+
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, description string) {
+	if description != "" {
+		b.WriteString(indent + strconv.Quote(description) + "\n")
+	}
+}
+
+// literal formats a coerced input value as a GraphQL literal.
+func literal(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = literal(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,292 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Messages of proto/flowpds/v1/pds.proto, field numbers must match it
+// (checked by TestMessagesMatchProto).
+
+type AddressLocation struct {
+	Name    string
+	Address string
+}
+
+func (m *AddressLocation) marshal(e *encoder) {
+	e.string(1, m.Name)
+	e.string(2, m.Address)
+}
+
+func (m *AddressLocation) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			m.Name = string(f.bytes)
+		case 2:
+			m.Address = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+type Bucket struct {
+	CollectibleReference  *AddressLocation
+	CollectibleCount      uint64
+	CollectibleCollection []uint64
+}
+
+func (m *Bucket) marshal(e *encoder) {
+	if m.CollectibleReference != nil {
+		e.message(1, m.CollectibleReference)
+	}
+	e.uint64(2, m.CollectibleCount)
+	e.packedUint64(3, m.CollectibleCollection)
+}
+
+func (m *Bucket) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			m.CollectibleReference = &AddressLocation{}
+			return m.CollectibleReference.unmarshal(f.bytes)
+		case 2:
+			m.CollectibleCount = f.varint
+		case 3:
+			ids, err := f.uint64s()
+			if err != nil {
+				return err
+			}
+			m.CollectibleCollection = append(m.CollectibleCollection, ids...)
+		}
+		return nil
+	})
+}
+
+type PackTemplate struct {
+	PackReference        *AddressLocation
+	PackCount            uint64
+	Buckets              []*Bucket
+	RevealNotBefore      *time.Time
+	TeaseNotBefore       *time.Time
+	CollectibleReference *AddressLocation
+}
+
+func (m *PackTemplate) marshal(e *encoder) {
+	if m.PackReference != nil {
+		e.message(1, m.PackReference)
+	}
+	e.uint64(2, m.PackCount)
+	for _, b := range m.Buckets {
+		e.message(3, b)
+	}
+	e.timestamp(4, m.RevealNotBefore)
+	e.timestamp(5, m.TeaseNotBefore)
+	if m.CollectibleReference != nil {
+		e.message(6, m.CollectibleReference)
+	}
+}
+
+func (m *PackTemplate) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) (err error) {
+		switch num {
+		case 1:
+			m.PackReference = &AddressLocation{}
+			return m.PackReference.unmarshal(f.bytes)
+		case 2:
+			m.PackCount = f.varint
+		case 3:
+			bucket := &Bucket{}
+			m.Buckets = append(m.Buckets, bucket)
+			return bucket.unmarshal(f.bytes)
+		case 4:
+			m.RevealNotBefore, err = f.time()
+		case 5:
+			m.TeaseNotBefore, err = f.time()
+		case 6:
+			m.CollectibleReference = &AddressLocation{}
+			return m.CollectibleReference.unmarshal(f.bytes)
+		}
+		return err
+	})
+}
+
+type CreateDistributionRequest struct {
+	DistFlowID       uint64
+	Issuer           string
+	PackTemplate     *PackTemplate
+	AccessAPIHost    string
+	RevealWebhookURL string
+	CollectionID     string
+}
+
+func (m *CreateDistributionRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			m.DistFlowID = f.varint
+		case 2:
+			m.Issuer = string(f.bytes)
+		case 3:
+			m.PackTemplate = &PackTemplate{}
+			return m.PackTemplate.unmarshal(f.bytes)
+		case 4:
+			m.AccessAPIHost = string(f.bytes)
+		case 5:
+			m.RevealWebhookURL = string(f.bytes)
+		case 6:
+			m.CollectionID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+type CreateDistributionResponse struct {
+	ID         string
+	DistFlowID uint64
+}
+
+func (m *CreateDistributionResponse) marshal(e *encoder) {
+	e.string(1, m.ID)
+	e.uint64(2, m.DistFlowID)
+}
+
+// IDRequest is GetDistributionRequest, AbortDistributionRequest,
+// WatchDistributionRequest and GetPackRequest, which only hold an ID.
+type IDRequest struct {
+	ID string
+}
+
+func (m *IDRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) error {
+		if num == 1 {
+			m.ID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+type Distribution struct {
+	ID            string
+	DistFlowID    uint64
+	Issuer        string
+	State         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	PackTemplate  *PackTemplate
+	AccessAPIHost string
+	CollectionID  string
+}
+
+func (m *Distribution) marshal(e *encoder) {
+	e.string(1, m.ID)
+	e.uint64(2, m.DistFlowID)
+	e.string(3, m.Issuer)
+	e.string(4, m.State)
+	e.timestamp(5, &m.CreatedAt)
+	e.timestamp(6, &m.UpdatedAt)
+	if m.PackTemplate != nil {
+		e.message(7, m.PackTemplate)
+	}
+	e.string(8, m.AccessAPIHost)
+	e.string(9, m.CollectionID)
+}
+
+type ListDistributionsRequest struct {
+	Limit        int32
+	Offset       int32
+	States       []string
+	Issuer       string
+	CreatedAfter *time.Time
+	Sort         string
+}
+
+func (m *ListDistributionsRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) (err error) {
+		switch num {
+		case 1:
+			m.Limit = int32(f.varint)
+		case 2:
+			m.Offset = int32(f.varint)
+		case 3:
+			m.States = append(m.States, string(f.bytes))
+		case 4:
+			m.Issuer = string(f.bytes)
+		case 5:
+			m.CreatedAfter, err = f.time()
+		case 6:
+			m.Sort = string(f.bytes)
+		}
+		return err
+	})
+}
+
+type ListDistributionsResponse struct {
+	Distributions []*Distribution
+	TotalCount    int64
+}
+
+func (m *ListDistributionsResponse) marshal(e *encoder) {
+	for _, d := range m.Distributions {
+		e.message(1, d)
+	}
+	e.int64(2, m.TotalCount)
+}
+
+type AbortDistributionResponse struct{}
+
+func (m *AbortDistributionResponse) marshal(e *encoder) {}
+
+type Pack struct {
+	ID             string
+	DistributionID string
+	FlowID         *uint64
+	State          string
+	CommitmentHash string
+}
+
+func (m *Pack) marshal(e *encoder) {
+	e.string(1, m.ID)
+	e.string(2, m.DistributionID)
+	if m.FlowID != nil {
+		e.optionalUint64(3, *m.FlowID)
+	}
+	e.string(4, m.State)
+	e.string(5, m.CommitmentHash)
+}
+
+type ListDistributionPacksRequest struct {
+	DistributionID string
+	States         []string
+	Limit          int32
+	Offset         int32
+}
+
+func (m *ListDistributionPacksRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			m.DistributionID = string(f.bytes)
+		case 2:
+			m.States = append(m.States, string(f.bytes))
+		case 3:
+			m.Limit = int32(f.varint)
+		case 4:
+			m.Offset = int32(f.varint)
+		}
+		return nil
+	})
+}
+
+type ListDistributionPacksResponse struct {
+	Packs      []*Pack
+	TotalCount int64
+}
+
+func (m *ListDistributionPacksResponse) marshal(e *encoder) {
+	for _, p := range m.Packs {
+		e.message(1, p)
+	}
+	e.int64(2, m.TotalCount)
+}
@@ -0,0 +1,400 @@
+package grpc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const protoFile = "../../proto/flowpds/v1/pds.proto"
+
+var (
+	protoMessageRegexp = regexp.MustCompile(`^message (\w+) \{(\})?$`)
+	protoFieldRegexp   = regexp.MustCompile(`^(repeated |optional )?([\w.]+) (\w+) = (\d+);$`)
+)
+
+var protoScalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+}
+
+// parseProtoFile builds the descriptor of the messages of pds.proto, which
+// only uses the subset of the language handled here.
+func parseProtoFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	f, err := os.Open(protoFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("flowpds/v1/pds.proto"),
+		Package:    proto.String("flowpds.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+	}
+
+	var msg *descriptorpb.DescriptorProto
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if m := protoMessageRegexp.FindStringSubmatch(line); m != nil {
+			msg = &descriptorpb.DescriptorProto{Name: proto.String(m[1])}
+			fdp.MessageType = append(fdp.MessageType, msg)
+			if m[2] != "" {
+				msg = nil
+			}
+			continue
+		}
+
+		if msg == nil {
+			continue
+		}
+
+		if line == "}" {
+			msg = nil
+			continue
+		}
+
+		m := protoFieldRegexp.FindStringSubmatch(line)
+		if m == nil {
+			if line != "" && !strings.HasPrefix(line, "//") {
+				t.Fatalf("can not parse line %q of message %s", line, msg.GetName())
+			}
+			continue
+		}
+
+		var number int32
+		fmt.Sscan(m[4], &number)
+
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(m[3]),
+			JsonName: proto.String(m[3]),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+
+		if typ, ok := protoScalarTypes[m[2]]; ok {
+			fd.Type = typ.Enum()
+		} else {
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			if strings.Contains(m[2], ".") {
+				fd.TypeName = proto.String("." + m[2])
+			} else {
+				fd.TypeName = proto.String(".flowpds.v1." + m[2])
+			}
+		}
+
+		switch m[1] {
+		case "repeated ":
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		case "optional ":
+			// proto3 optional fields are in a synthetic oneof
+			fd.Proto3Optional = proto.Bool(true)
+			fd.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + m[3])})
+		}
+
+		msg.Field = append(msg.Field, fd)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fd
+}
+
+// goFieldName matches proto field names to Go field names, e.g.
+// 'access_api_host' to 'AccessAPIHost'.
+func goFieldName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// filler sets distinct values, so fields mixed up with each other are caught.
+type filler struct {
+	n int
+}
+
+func (f *filler) next() int {
+	f.n++
+	return f.n
+}
+
+func (f *filler) time() time.Time {
+	return time.Unix(int64(1700000000+f.next()), int64(f.next())).UTC()
+}
+
+// fillGo sets every field of the Go message 'v'.
+func (f *filler) fillGo(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		f.fillGo(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(f.time()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f.fillGo(v.Field(i))
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 2, 2))
+		for i := 0; i < v.Len(); i++ {
+			f.fillGo(v.Index(i))
+		}
+	case reflect.String:
+		v.SetString(fmt.Sprintf("value-%d", f.next()))
+	case reflect.Int32, reflect.Int64:
+		v.SetInt(int64(f.next()))
+	case reflect.Uint64:
+		v.SetUint(uint64(f.next()))
+	default:
+		panic(fmt.Sprintf("can not fill %s", v.Type()))
+	}
+}
+
+// fillProto sets every field of the message 'm'.
+func (f *filler) fillProto(m protoreflect.Message) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for j := 0; j < 2; j++ {
+				if fd.Kind() == protoreflect.MessageKind {
+					f.fillProto(list.AppendMutable().Message())
+				} else {
+					list.Append(f.protoValue(fd))
+				}
+			}
+			continue
+		}
+		if fd.Kind() == protoreflect.MessageKind {
+			f.fillProto(m.Mutable(fd).Message())
+			continue
+		}
+		m.Set(fd, f.protoValue(fd))
+	}
+}
+
+func (f *filler) protoValue(fd protoreflect.FieldDescriptor) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(fmt.Sprintf("value-%d", f.next()))
+	case protoreflect.Uint64Kind:
+		return protoreflect.ValueOfUint64(uint64(f.next()))
+	case protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(int64(f.next()))
+	case protoreflect.Int32Kind:
+		return protoreflect.ValueOfInt32(int32(f.next()))
+	}
+	panic(fmt.Sprintf("can not fill %s", fd.Kind()))
+}
+
+// compareMessage checks that the Go message 'v' has exactly the fields of
+// 'm', holding the same values.
+func compareMessage(t *testing.T, path string, v reflect.Value, m protoreflect.Message) {
+	t.Helper()
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			t.Errorf("%s: not set", path)
+			return
+		}
+		v = v.Elem()
+	}
+
+	if len(m.GetUnknown()) > 0 {
+		t.Errorf("%s: fields unknown to %s (wrong number or type)", path, m.Descriptor().FullName())
+	}
+
+	if m.Descriptor().FullName() == "google.protobuf.Timestamp" {
+		fields := m.Descriptor().Fields()
+		got := time.Unix(m.Get(fields.ByName("seconds")).Int(), m.Get(fields.ByName("nanos")).Int()).UTC()
+		if want := v.Interface().(time.Time); !got.Equal(want) {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+		return
+	}
+
+	goFields := map[string]reflect.Value{}
+	for i := 0; i < v.NumField(); i++ {
+		goFields[goFieldName(v.Type().Field(i).Name)] = v.Field(i)
+	}
+
+	fields := m.Descriptor().Fields()
+	if fields.Len() != len(goFields) {
+		t.Errorf("%s: %s has %d fields, the Go message %d", path, m.Descriptor().FullName(), fields.Len(), len(goFields))
+	}
+
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := fmt.Sprintf("%s.%s", path, fd.Name())
+
+		gv, ok := goFields[goFieldName(string(fd.Name()))]
+		if !ok {
+			t.Errorf("%s: no such field in the Go message", name)
+			continue
+		}
+
+		if !m.Has(fd) {
+			t.Errorf("%s: not set", name)
+			continue
+		}
+
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			if list.Len() != gv.Len() {
+				t.Errorf("%s: expected %d items, got %d", name, gv.Len(), list.Len())
+				continue
+			}
+			for j := 0; j < list.Len(); j++ {
+				compareValue(t, fmt.Sprintf("%s[%d]", name, j), gv.Index(j), fd, list.Get(j))
+			}
+			continue
+		}
+
+		compareValue(t, name, gv, fd, m.Get(fd))
+	}
+}
+
+func compareValue(t *testing.T, path string, v reflect.Value, fd protoreflect.FieldDescriptor, pv protoreflect.Value) {
+	t.Helper()
+
+	if fd.Kind() == protoreflect.MessageKind {
+		compareMessage(t, path, v, pv.Message())
+		return
+	}
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	var got, want interface{}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		got, want = pv.String(), v.String()
+	case protoreflect.Uint64Kind:
+		got, want = pv.Uint(), v.Uint()
+	case protoreflect.Int64Kind, protoreflect.Int32Kind:
+		got, want = pv.Int(), v.Int()
+	default:
+		t.Errorf("%s: unexpected kind %s", path, fd.Kind())
+		return
+	}
+
+	if got != want {
+		t.Errorf("%s: expected %v, got %v", path, want, got)
+	}
+}
+
+// TestMessagesMatchProto checks the hand written messages against
+// proto/flowpds/v1/pds.proto: every field is encoded (responses) or decoded
+// (requests) with the number and type of the proto file.
+func TestMessagesMatchProto(t *testing.T) {
+	file := parseProtoFile(t)
+
+	responses := map[string]message{
+		"AddressLocation":               &AddressLocation{},
+		"Bucket":                        &Bucket{},
+		"PackTemplate":                  &PackTemplate{},
+		"CreateDistributionResponse":    &CreateDistributionResponse{},
+		"Distribution":                  &Distribution{},
+		"ListDistributionsResponse":     &ListDistributionsResponse{},
+		"AbortDistributionResponse":     &AbortDistributionResponse{},
+		"Pack":                          &Pack{},
+		"ListDistributionPacksResponse": &ListDistributionPacksResponse{},
+	}
+
+	requests := map[string]func() request{
+		"AddressLocation":              func() request { return &AddressLocation{} },
+		"Bucket":                       func() request { return &Bucket{} },
+		"PackTemplate":                 func() request { return &PackTemplate{} },
+		"CreateDistributionRequest":    func() request { return &CreateDistributionRequest{} },
+		"GetDistributionRequest":       func() request { return &IDRequest{} },
+		"ListDistributionsRequest":     func() request { return &ListDistributionsRequest{} },
+		"AbortDistributionRequest":     func() request { return &IDRequest{} },
+		"WatchDistributionRequest":     func() request { return &IDRequest{} },
+		"GetPackRequest":               func() request { return &IDRequest{} },
+		"ListDistributionPacksRequest": func() request { return &ListDistributionPacksRequest{} },
+	}
+
+	messages := file.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		name := string(messages.Get(i).Name())
+		if responses[name] == nil && requests[name] == nil {
+			t.Errorf("%s: no Go message", name)
+		}
+	}
+
+	for name, res := range responses {
+		desc := messages.ByName(protoreflect.Name(name))
+		if desc == nil {
+			t.Errorf("%s: not in the proto file", name)
+			continue
+		}
+
+		(&filler{}).fillGo(reflect.ValueOf(res).Elem())
+
+		b, err := (codec{}).Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := dynamicpb.NewMessage(desc)
+		if err := proto.Unmarshal(b, m); err != nil {
+			t.Errorf("%s: can not decode the encoded message: %s", name, err)
+			continue
+		}
+
+		compareMessage(t, name, reflect.ValueOf(res), m)
+	}
+
+	for name, newRequest := range requests {
+		desc := messages.ByName(protoreflect.Name(name))
+		if desc == nil {
+			t.Errorf("%s: not in the proto file", name)
+			continue
+		}
+
+		m := dynamicpb.NewMessage(desc)
+		(&filler{}).fillProto(m)
+
+		b, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := newRequest()
+		if err := (codec{}).Unmarshal(b, req); err != nil {
+			t.Errorf("%s: can not decode the message: %s", name, err)
+			continue
+		}
+
+		compareMessage(t, name, reflect.ValueOf(req), m)
+	}
+}
@@ -0,0 +1,459 @@
+// Package grpc serves the distribution lifecycle over gRPC, see
+// proto/flowpds/v1/pds.proto. It shares the app layer, authentication and
+// error semantics with the REST API in service/http.
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	serviceName = "flowpds.v1.DistributionService"
+
+	// How often a watched distribution is checked for state changes
+	watchPollInterval = 2 * time.Second
+)
+
+// TokenVerifier authenticates bearer tokens other than API keys and the
+// admin token (e.g. JWTs of an identity provider), returning the issuer a
+// token can act for, nil if any.
+type TokenVerifier interface {
+	Authenticate(ctx context.Context, token string) (*common.FlowAddress, error)
+}
+
+type Server struct {
+	Server *grpc.Server
+	cfg    *config.Config
+}
+
+// NewServer returns the gRPC server, 'tokens' is optional.
+func NewServer(cfg *config.Config, app *app.App, tokens TokenVerifier) *Server {
+	srv := grpc.NewServer(grpc.ForceServerCodec(codec{}))
+
+	// Handlers check their argument, any type is accepted as the server
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			unary("CreateDistribution", func() request { return &CreateDistributionRequest{} }, (*service).createDistribution),
+			unary("GetDistribution", func() request { return &IDRequest{} }, (*service).getDistribution),
+			unary("ListDistributions", func() request { return &ListDistributionsRequest{} }, (*service).listDistributions),
+			unary("AbortDistribution", func() request { return &IDRequest{} }, (*service).abortDistribution),
+			unary("GetPack", func() request { return &IDRequest{} }, (*service).getPack),
+			unary("ListDistributionPacks", func() request { return &ListDistributionPacksRequest{} }, (*service).listDistributionPacks),
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: "WatchDistribution", Handler: watchDistribution, ServerStreams: true},
+		},
+		Metadata: "flowpds/v1/pds.proto",
+	}, &service{cfg, app, tokens, logging.Logger(logging.HTTP)})
+
+	return &Server{srv, cfg}
+}
+
+// ListenAndServe serves in a goroutine until Stop is called.
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.GRPCPort))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		log.Infof("gRPC server listening on %s:%d", s.cfg.Host, s.cfg.GRPCPort)
+		if err := s.Server.Serve(lis); err != nil {
+			log.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops accepting calls and waits for running ones, cancelling
+// streams after 'timeout'.
+func (s *Server) Stop(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.Server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.Server.Stop()
+	}
+}
+
+type service struct {
+	cfg    *config.Config
+	app    *app.App
+	tokens TokenVerifier
+	logger *log.Logger
+}
+
+func unary(name string, newReq func() request, call func(s *service, ctx context.Context, req request) (message, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			s := srv.(*service)
+			req := newReq()
+			if err := dec(req); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			res, err := call(s, ctx, req)
+			if err != nil {
+				return nil, s.statusError(err)
+			}
+			return res, nil
+		},
+	}
+}
+
+func (s *service) createDistribution(ctx context.Context, r request) (message, error) {
+	req := r.(*CreateDistributionRequest)
+
+	ctx, authenticated, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appDist, err := req.ToApp()
+	if err != nil {
+		return nil, err
+	}
+
+	if authenticated != nil && *authenticated != appDist.Issuer {
+		return nil, app.ErrAPIKeyForbidden
+	}
+
+	if err := s.app.CreateDistribution(ctx, &appDist); err != nil {
+		return nil, err
+	}
+
+	return &CreateDistributionResponse{ID: appDist.ID.String(), DistFlowID: uint64(appDist.FlowID.Int64)}, nil
+}
+
+func (s *service) getDistribution(ctx context.Context, r request) (message, error) {
+	id, err := parseID(r.(*IDRequest).ID)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated, err := s.authenticateRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dist, err := s.app.GetDistribution(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if authenticated != nil && *authenticated != dist.Issuer {
+		return nil, app.ErrAPIKeyForbidden
+	}
+
+	res := DistributionFromApp(dist)
+	res.PackTemplate = PackTemplateFromApp(dist.PackTemplate)
+
+	return res, nil
+}
+
+func (s *service) listDistributions(ctx context.Context, r request) (message, error) {
+	req := r.(*ListDistributionsRequest)
+
+	authenticated, err := s.authenticateRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := app.DistributionFilter{CreatedAfter: req.CreatedAfter, Sort: req.Sort}
+	for _, state := range req.States {
+		filter.States = append(filter.States, common.DistributionState(state))
+	}
+	if req.Issuer != "" {
+		issuer, err := parseFlowAddress(req.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		filter.Issuer = &issuer
+	}
+	if authenticated != nil {
+		// Scoped like http.scopeDistributionFilter
+		if filter.Issuer != nil && *filter.Issuer != *authenticated {
+			return nil, app.ErrAPIKeyForbidden
+		}
+		filter.Issuer = authenticated
+	}
+
+	list, total, err := s.app.ListDistributions(ctx, filter, int(req.Limit), int(req.Offset))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListDistributionsResponse{Distributions: make([]*Distribution, len(list)), TotalCount: total}
+	for i := range list {
+		res.Distributions[i] = DistributionFromApp(&list[i])
+	}
+
+	return res, nil
+}
+
+func (s *service) abortDistribution(ctx context.Context, r request) (message, error) {
+	id, err := parseID(r.(*IDRequest).ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, authenticated, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeDistribution(ctx, authenticated, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.app.AbortDistribution(ctx, id, false); err != nil {
+		return nil, err
+	}
+
+	return &AbortDistributionResponse{}, nil
+}
+
+func (s *service) getPack(ctx context.Context, r request) (message, error) {
+	id, err := parseID(r.(*IDRequest).ID)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated, err := s.authenticateRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := s.app.GetPack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeDistribution(ctx, authenticated, pack.DistributionID); err != nil {
+		return nil, err
+	}
+
+	return PackFromApp(pack), nil
+}
+
+func (s *service) listDistributionPacks(ctx context.Context, r request) (message, error) {
+	req := r.(*ListDistributionPacksRequest)
+
+	id, err := parseID(req.DistributionID)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := app.ParsePackStates(req.States)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated, err := s.authenticateRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeDistribution(ctx, authenticated, id); err != nil {
+		return nil, err
+	}
+
+	list, total, err := s.app.ListDistributionPacks(ctx, id, states, int(req.Limit), int(req.Offset))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListDistributionPacksResponse{Packs: make([]*Pack, len(list)), TotalCount: total}
+	for i := range list {
+		res.Packs[i] = PackFromApp(&list[i])
+	}
+
+	return res, nil
+}
+
+// watchDistribution streams the distribution whenever its state changes
+// until it reaches a final state.
+func watchDistribution(srv interface{}, stream grpc.ServerStream) error {
+	s := srv.(*service)
+	ctx := stream.Context()
+
+	req := &IDRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, err := parseID(req.ID)
+	if err != nil {
+		return s.statusError(err)
+	}
+
+	authenticated, err := s.authenticateRead(ctx)
+	if err != nil {
+		return s.statusError(err)
+	}
+
+	if err := s.authorizeDistribution(ctx, authenticated, id); err != nil {
+		return s.statusError(err)
+	}
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	var sent common.DistributionState
+	for {
+		state, err := s.app.GetDistributionState(ctx, id)
+		if err != nil {
+			return s.statusError(err)
+		}
+
+		if state != sent {
+			dist, err := s.app.GetDistribution(ctx, id)
+			if err != nil {
+				return s.statusError(err)
+			}
+
+			res := DistributionFromApp(dist)
+			res.PackTemplate = PackTemplateFromApp(dist.PackTemplate)
+			if err := stream.SendMsg(res); err != nil {
+				return err
+			}
+			sent = dist.State
+		}
+
+		switch sent {
+		case common.DistributionStateComplete, common.DistributionStateInvalid, common.DistributionStateClosed, common.DistributionStateCancelled:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// authenticate checks the 'authorization' metadata like the mutating REST
+// endpoints do, see http.UseAPIKeyAuth, and returns the issuer the caller
+// can act for, nil if any, along with 'ctx' carrying the API key used (see
+// app.ContextWithAPIKey). Signed requests are only supported over REST.
+func (s *service) authenticate(ctx context.Context) (context.Context, *common.FlowAddress, error) {
+	return s.authenticateIf(ctx, s.cfg.APIKeysRequired)
+}
+
+// authenticateRead checks the 'authorization' metadata of reads like
+// http.UseIssuerIsolation does, only if issuer isolation is enabled, and
+// returns the issuer the caller can read for, nil if any.
+func (s *service) authenticateRead(ctx context.Context) (*common.FlowAddress, error) {
+	if !s.cfg.IssuerIsolation {
+		return nil, nil
+	}
+	_, issuer, err := s.authenticateIf(ctx, true)
+	return issuer, err
+}
+
+// authenticateIf is like authenticate, credentials being optional unless
+// 'required' is set or a TokenVerifier is configured.
+func (s *service) authenticateIf(ctx context.Context, required bool) (context.Context, *common.FlowAddress, error) {
+	if !required && s.tokens == nil {
+		return ctx, nil, nil
+	}
+
+	given := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			given = strings.TrimPrefix(v[0], "Bearer ")
+		}
+	}
+	if given == "" {
+		return ctx, nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	if s.cfg.AdminAPIToken != "" && subtle.ConstantTimeCompare([]byte(given), []byte(s.cfg.AdminAPIToken)) == 1 {
+		return ctx, nil, nil
+	}
+
+	// API keys never hold a '.'
+	if s.tokens != nil && strings.Count(given, ".") == 2 {
+		issuer, err := s.tokens.Authenticate(ctx, given)
+		if err != nil {
+			return ctx, nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return ctx, issuer, nil
+	}
+
+	key, err := s.app.AuthenticateAPIKey(ctx, given)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return app.ContextWithAPIKey(ctx, key), &key.Issuer, nil
+}
+
+// authorizeDistribution checks the caller authenticated for 'issuer' (nil
+// for any) can act for the distribution 'id'.
+func (s *service) authorizeDistribution(ctx context.Context, issuer *common.FlowAddress, id uuid.UUID) error {
+	if issuer == nil {
+		return nil
+	}
+
+	distIssuer, err := s.app.GetDistributionIssuer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *issuer != distIssuer {
+		return app.ErrAPIKeyForbidden
+	}
+
+	return nil
+}
+
+// statusError maps errors of the app layer to gRPC status codes like the
+// REST API maps them to HTTP status codes.
+func (s *service) statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	s.logger.Error(err)
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, app.ErrAPIKeyInvalid):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, app.ErrAPIKeyForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+}
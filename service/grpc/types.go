@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/google/uuid"
+)
+
+func (m *CreateDistributionRequest) ToApp() (app.Distribution, error) {
+	issuer, err := parseFlowAddress(m.Issuer)
+	if err != nil {
+		return app.Distribution{}, err
+	}
+
+	if m.PackTemplate == nil {
+		return app.Distribution{}, fmt.Errorf("pack template is required")
+	}
+
+	template, err := m.PackTemplate.ToApp()
+	if err != nil {
+		return app.Distribution{}, err
+	}
+
+	d := app.Distribution{
+		State:         common.DistributionStateInit,
+		FlowID:        common.FlowID{Int64: int64(m.DistFlowID), Valid: true},
+		Issuer:        issuer,
+		PackTemplate:  template,
+		AccessAPIHost: m.AccessAPIHost,
+
+		RevealWebhookURL: m.RevealWebhookURL,
+	}
+
+	if m.CollectionID != "" {
+		id, err := uuid.Parse(m.CollectionID)
+		if err != nil {
+			return app.Distribution{}, fmt.Errorf("invalid collection ID '%s'", m.CollectionID)
+		}
+		d.CollectionID = &id
+	}
+
+	return d, nil
+}
+
+func (pt *PackTemplate) ToApp() (app.PackTemplate, error) {
+	packRef, err := pt.PackReference.ToApp()
+	if err != nil {
+		return app.PackTemplate{}, err
+	}
+
+	buckets := make([]app.Bucket, len(pt.Buckets))
+	for i, b := range pt.Buckets {
+		ref := pt.CollectibleReference
+		if b.CollectibleReference != nil {
+			ref = b.CollectibleReference
+		}
+
+		collectibleRef, err := ref.ToApp()
+		if err != nil {
+			return app.PackTemplate{}, err
+		}
+
+		collection := make(common.FlowIDList, len(b.CollectibleCollection))
+		for j, id := range b.CollectibleCollection {
+			collection[j] = common.FlowID{Int64: int64(id), Valid: true}
+		}
+
+		buckets[i] = app.Bucket{
+			CollectibleReference:  collectibleRef,
+			CollectibleCount:      uint(b.CollectibleCount),
+			CollectibleCollection: collection,
+		}
+	}
+
+	return app.PackTemplate{
+		PackReference:   packRef,
+		PackCount:       uint(pt.PackCount),
+		Buckets:         buckets,
+		RevealNotBefore: pt.RevealNotBefore,
+		TeaseNotBefore:  pt.TeaseNotBefore,
+	}, nil
+}
+
+// ToApp returns an empty location if 'l' is nil, which validation of the
+// distribution refuses.
+func (l *AddressLocation) ToApp() (app.AddressLocation, error) {
+	if l == nil {
+		return app.AddressLocation{}, nil
+	}
+
+	address, err := parseFlowAddress(l.Address)
+	if err != nil {
+		return app.AddressLocation{}, err
+	}
+
+	return app.AddressLocation{Name: l.Name, Address: address}, nil
+}
+
+func DistributionFromApp(d *app.Distribution) *Distribution {
+	res := &Distribution{
+		ID:            d.ID.String(),
+		DistFlowID:    uint64(d.FlowID.Int64),
+		Issuer:        d.Issuer.String(),
+		State:         string(d.State),
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+		AccessAPIHost: d.AccessAPIHost,
+	}
+
+	if d.CollectionID != nil {
+		res.CollectionID = d.CollectionID.String()
+	}
+
+	return res
+}
+
+func PackTemplateFromApp(pt app.PackTemplate) *PackTemplate {
+	buckets := make([]*Bucket, len(pt.Buckets))
+	for i, b := range pt.Buckets {
+		collection := make([]uint64, len(b.CollectibleCollection))
+		for j, id := range b.CollectibleCollection {
+			collection[j] = uint64(id.Int64)
+		}
+
+		buckets[i] = &Bucket{
+			CollectibleReference:  addressLocationFromApp(b.CollectibleReference),
+			CollectibleCount:      uint64(b.CollectibleCount),
+			CollectibleCollection: collection,
+		}
+	}
+
+	return &PackTemplate{
+		PackReference:   addressLocationFromApp(pt.PackReference),
+		PackCount:       uint64(pt.PackCount),
+		Buckets:         buckets,
+		RevealNotBefore: pt.RevealNotBefore,
+		TeaseNotBefore:  pt.TeaseNotBefore,
+	}
+}
+
+func addressLocationFromApp(l app.AddressLocation) *AddressLocation {
+	return &AddressLocation{Name: l.Name, Address: l.Address.String()}
+}
+
+func PackFromApp(p *app.Pack) *Pack {
+	res := &Pack{
+		ID:             p.ID.String(),
+		DistributionID: p.DistributionID.String(),
+		State:          string(p.State),
+		CommitmentHash: p.CommitmentHash.String(),
+	}
+
+	if p.FlowID.Valid {
+		id := uint64(p.FlowID.Int64)
+		res.FlowID = &id
+	}
+
+	return res
+}
+
+func parseFlowAddress(s string) (common.FlowAddress, error) {
+	a := common.FlowAddress{}
+	if err := a.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return a, fmt.Errorf("invalid address '%s'", s)
+	}
+	return a, nil
+}
+
+func parseID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return id, fmt.Errorf("invalid ID '%s'", s)
+	}
+	return id, nil
+}
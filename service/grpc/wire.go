@@ -0,0 +1,195 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of proto/flowpds/v1/pds.proto are encoded by hand with
+// protowire rather than generated, they are few and only ever decoded
+// (requests) or encoded (responses) by the server.
+
+type message interface {
+	marshal(e *encoder)
+}
+
+type request interface {
+	unmarshal(b []byte) error
+}
+
+// codec encodes responses and decodes requests in the protobuf wire format.
+type codec struct{}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(message)
+	if !ok {
+		return nil, fmt.Errorf("can not marshal %T", v)
+	}
+	e := &encoder{}
+	m.marshal(e)
+	return e.b, nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	r, ok := v.(request)
+	if !ok {
+		return fmt.Errorf("can not unmarshal %T", v)
+	}
+	return r.unmarshal(data)
+}
+
+// encoder appends fields, leaving out those with default values like proto3.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) string(num protowire.Number, s string) {
+	if s == "" {
+		return
+	}
+	e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+	e.b = protowire.AppendString(e.b, s)
+}
+
+func (e *encoder) uint64(num protowire.Number, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.b = protowire.AppendTag(e.b, num, protowire.VarintType)
+	e.b = protowire.AppendVarint(e.b, v)
+}
+
+func (e *encoder) int64(num protowire.Number, v int64) {
+	e.uint64(num, uint64(v))
+}
+
+// optionalUint64 appends 'v' even if 0.
+func (e *encoder) optionalUint64(num protowire.Number, v uint64) {
+	e.b = protowire.AppendTag(e.b, num, protowire.VarintType)
+	e.b = protowire.AppendVarint(e.b, v)
+}
+
+func (e *encoder) packedUint64(num protowire.Number, vs []uint64) {
+	if len(vs) == 0 {
+		return
+	}
+	var b []byte
+	for _, v := range vs {
+		b = protowire.AppendVarint(b, v)
+	}
+	e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+	e.b = protowire.AppendBytes(e.b, b)
+}
+
+func (e *encoder) message(num protowire.Number, m message) {
+	inner := &encoder{}
+	m.marshal(inner)
+	e.b = protowire.AppendTag(e.b, num, protowire.BytesType)
+	e.b = protowire.AppendBytes(e.b, inner.b)
+}
+
+// timestamp appends 't' as a google.protobuf.Timestamp, nothing if nil.
+func (e *encoder) timestamp(num protowire.Number, t *time.Time) {
+	if t == nil {
+		return
+	}
+	e.message(num, timestamp(*t))
+}
+
+type timestamp time.Time
+
+func (t timestamp) marshal(e *encoder) {
+	e.int64(1, time.Time(t).Unix())
+	e.int64(2, int64(time.Time(t).Nanosecond()))
+}
+
+func (t *timestamp) unmarshal(b []byte) error {
+	var seconds, nanos uint64
+	err := decode(b, func(num protowire.Number, f field) error {
+		switch num {
+		case 1:
+			seconds = f.varint
+		case 2:
+			nanos = f.varint
+		}
+		return nil
+	})
+	*t = timestamp(time.Unix(int64(seconds), int64(int32(nanos))).UTC())
+	return err
+}
+
+// field is a decoded field, 'varint' is set for varints, 'bytes' for
+// length delimited fields.
+type field struct {
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+// decode calls 'f' for each field of the message 'b', skipping fixed size
+// and group fields which the API does not use.
+func decode(b []byte, f func(num protowire.Number, f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		fl := field{typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			fl.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			fl.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := f(num, fl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uint64s returns the values of a repeated varint field, which may be packed.
+func (f field) uint64s() ([]uint64, error) {
+	if f.typ == protowire.VarintType {
+		return []uint64{f.varint}, nil
+	}
+
+	var vs []uint64
+	b := f.bytes
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		vs = append(vs, v)
+		b = b[n:]
+	}
+	return vs, nil
+}
+
+func (f field) time() (*time.Time, error) {
+	t := timestamp{}
+	if err := t.unmarshal(f.bytes); err != nil {
+		return nil, err
+	}
+	res := time.Time(t)
+	return &res, nil
+}
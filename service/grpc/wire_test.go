@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestUnmarshalCreateDistributionRequest(t *testing.T) {
+	var bucket []byte
+	bucket = protowire.AppendTag(bucket, 2, protowire.VarintType)
+	bucket = protowire.AppendVarint(bucket, 2)
+	// Unpacked repeated field, accepted like packed ones
+	for _, id := range []uint64{5, 6} {
+		bucket = protowire.AppendTag(bucket, 3, protowire.VarintType)
+		bucket = protowire.AppendVarint(bucket, id)
+	}
+
+	var reveal []byte
+	reveal = protowire.AppendTag(reveal, 1, protowire.VarintType)
+	reveal = protowire.AppendVarint(reveal, 1700000000)
+
+	var template []byte
+	template = protowire.AppendTag(template, 2, protowire.VarintType)
+	template = protowire.AppendVarint(template, 3)
+	template = protowire.AppendTag(template, 3, protowire.BytesType)
+	template = protowire.AppendBytes(template, bucket)
+	template = protowire.AppendTag(template, 4, protowire.BytesType)
+	template = protowire.AppendBytes(template, reveal)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 42)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, "0x01cf0e2f2f715450")
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendBytes(b, template)
+	// Unknown fields are skipped
+	b = protowire.AppendTag(b, 99, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, 1)
+
+	req := &CreateDistributionRequest{}
+	if err := (codec{}).Unmarshal(b, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.DistFlowID != 42 || req.Issuer != "0x01cf0e2f2f715450" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+
+	pt := req.PackTemplate
+	if pt == nil || pt.PackCount != 3 || len(pt.Buckets) != 1 {
+		t.Fatalf("unexpected pack template %+v", pt)
+	}
+
+	if got := pt.Buckets[0].CollectibleCollection; len(got) != 2 || got[0] != 5 || got[1] != 6 {
+		t.Errorf("expected collection [5 6], got %v", got)
+	}
+
+	if pt.RevealNotBefore == nil || !pt.RevealNotBefore.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected reveal time %v", pt.RevealNotBefore)
+	}
+}
+
+func TestMarshalPackFlowID(t *testing.T) {
+	zero := uint64(0)
+
+	for _, c := range []struct {
+		flowID  *uint64
+		present bool
+	}{
+		{nil, false},
+		{&zero, true},
+	} {
+		b, err := (codec{}).Marshal(&Pack{ID: "id", FlowID: c.flowID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		present := false
+		if err := decode(b, func(num protowire.Number, f field) error {
+			if num == 3 {
+				present = true
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if present != c.present {
+			t.Errorf("flow ID %v: expected field present %v, got %v", c.flowID, c.present, present)
+		}
+	}
+}
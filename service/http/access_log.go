@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 100
+	redactedValue      = "[REDACTED]"
+)
+
+// Query parameters with a name containing any of these are redacted in
+// access logs
+var sensitiveParams = []string{"token", "key", "secret", "signature", "password", "auth"}
+
+// accessLogInfo is filled in by the middlewares and handlers a request
+// passes through, for its access log
+type accessLogInfo struct {
+	auth     string // How the request was authenticated, if at all
+	issuer   *common.FlowAddress
+	apiKeyID *uuid.UUID
+}
+
+type accessLogContextKey struct{}
+
+// UseLogging logs a structured access log entry for each request to
+// 'logger', with the request ID, latency, status and how the request was
+// authenticated. Query parameters holding secrets are redacted, headers and
+// bodies are never logged. The request ID is taken from the 'X-Request-ID'
+// header if valid, otherwise generated, and returned in the same header. It
+// is passed on in the request context, see logging.RequestID.
+func UseLogging(logger *log.Logger, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		rw.Header().Set(requestIDHeader, id)
+
+		info := &accessLogInfo{}
+		ctx := logging.ContextWithRequestID(r.Context(), id)
+		ctx = context.WithValue(ctx, accessLogContextKey{}, info)
+
+		m := httpsnoop.CaptureMetrics(h, rw, r.WithContext(ctx))
+
+		fields := log.Fields{
+			"requestID":  id,
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     m.Code,
+			"bytes":      m.Written,
+			"latencyMs":  float64(m.Duration.Microseconds()) / 1000,
+			"remoteAddr": r.RemoteAddr,
+			"userAgent":  r.UserAgent(),
+		}
+		if r.URL.RawQuery != "" {
+			fields["query"] = redactQuery(r.URL.Query())
+		}
+		if info.auth != "" {
+			fields["auth"] = info.auth
+		}
+		if info.issuer != nil {
+			fields["issuer"] = info.issuer.String()
+		}
+		if info.apiKeyID != nil {
+			fields["apiKeyID"] = info.apiKeyID.String()
+		}
+
+		entry := logger.WithFields(fields)
+		if m.Code >= http.StatusInternalServerError {
+			entry.Error("Request")
+		} else {
+			entry.Info("Request")
+		}
+	})
+}
+
+// logAuthenticated records how 'r' was authenticated for its access log.
+// 'issuer' and 'apiKeyID' are optional.
+func logAuthenticated(r *http.Request, auth string, issuer *common.FlowAddress, apiKeyID *uuid.UUID) {
+	if info, ok := r.Context().Value(accessLogContextKey{}).(*accessLogInfo); ok {
+		info.auth = auth
+		info.issuer = issuer
+		info.apiKeyID = apiKeyID
+	}
+}
+
+// validRequestID checks a request ID given by a client is safe to log and
+// return.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactQuery encodes 'query' with the values of sensitive parameters
+// replaced.
+func redactQuery(query url.Values) string {
+	redacted := make(url.Values, len(query))
+	for name, values := range query {
+		if sensitiveParam(name) {
+			values = []string{redactedValue}
+		}
+		redacted[name] = values
+	}
+	return redacted.Encode()
+}
+
+func sensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveParams {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestUseLogging(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	var requestID string
+	h := UseLogging(logger, UseAdminAuth("admin-token", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requestID = logging.RequestID(r.Context())
+		rw.WriteHeader(http.StatusTeapot)
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/system/config?apiKey=secret&limit=10", nil)
+	r.Header.Set("Authorization", "Bearer admin-token")
+	r.Header.Set(requestIDHeader, "req-1")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	if requestID != "req-1" || rw.Header().Get(requestIDHeader) != "req-1" {
+		t.Errorf("expected the given request ID to be passed on and returned, got %q and %q", requestID, rw.Header().Get(requestIDHeader))
+	}
+
+	entry := hook.LastEntry()
+	if entry == nil || entry.Level != log.InfoLevel {
+		t.Fatalf("expected an info access log entry, got %+v", entry)
+	}
+	if entry.Data["status"] != http.StatusTeapot || entry.Data["requestID"] != "req-1" || entry.Data["auth"] != authAdmin {
+		t.Errorf("unexpected access log fields %v", entry.Data)
+	}
+	if query := entry.Data["query"].(string); strings.Contains(query, "secret") || !strings.Contains(query, "limit=10") {
+		t.Errorf("expected the API key to be redacted from the query, got %s", query)
+	}
+
+	// Generated if not given or invalid
+	r = httptest.NewRequest(http.MethodGet, "/v1/system/config", nil)
+	r.Header.Set(requestIDHeader, "bad id\n")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+
+	if id := rw.Header().Get(requestIDHeader); id == "" || id == "bad id\n" {
+		t.Errorf("expected a generated request ID, got %q", id)
+	}
+	if entry := hook.LastEntry(); entry.Data["auth"] != nil || entry.Data["status"] != http.StatusUnauthorized {
+		t.Errorf("expected an unauthenticated request to be logged, got %v", entry.Data)
+	}
+}
@@ -0,0 +1,391 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/graphql"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Max number of distributions or packs a list field of the GraphQL API
+// returns at once
+const maxGraphQLLimit = 100
+
+var (
+	graphQLTime = &graphql.Scalar{
+		Name:        "Time",
+		Description: "RFC 3339 date-time",
+		Serialize: func(v interface{}) (interface{}, error) {
+			t, ok := v.(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("expected a time, got %T", v)
+			}
+			return t.UTC().Format(time.RFC3339Nano), nil
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected an RFC 3339 date-time, got %v", v)
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("expected an RFC 3339 date-time, got %s", s)
+			}
+			return t, nil
+		},
+	}
+
+	graphQLFlowID = &graphql.Scalar{
+		Name:        "FlowID",
+		Description: "ID of an NFT or distribution onchain, a 64-bit number",
+		Serialize: func(v interface{}) (interface{}, error) {
+			id, ok := v.(common.FlowID)
+			if !ok {
+				return nil, fmt.Errorf("expected a Flow ID, got %T", v)
+			}
+			if !id.Valid {
+				return nil, nil
+			}
+			return id.Int64, nil
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if i, ok := v.(int64); ok {
+				return common.FlowID{Int64: i, Valid: true}, nil
+			}
+			return nil, fmt.Errorf("expected a Flow ID, got %v", v)
+		},
+	}
+)
+
+// newGraphQLSchema returns the schema of the read-only GraphQL API over the
+// distributions, buckets, packs and collectibles of 'a'. Requests
+// authenticated for an issuer only see its distributions, like the REST API.
+func newGraphQLSchema(a *app.App) *graphql.Schema {
+	distribution := &graphql.Object{Name: "Distribution"}
+	bucket := &graphql.Object{Name: "Bucket", Description: "Slots of a pack filled from a collection of collectibles"}
+	pack := &graphql.Object{Name: "Pack"}
+	collectible := &graphql.Object{Name: "Collectible"}
+
+	listArgs := []*graphql.Argument{
+		{Name: "limit", Type: graphql.Int, DefaultValue: 20, Description: fmt.Sprintf("At most %d", maxGraphQLLimit)},
+		{Name: "offset", Type: graphql.Int, DefaultValue: 0},
+	}
+
+	distribution.Fields = []*graphql.Field{
+		graphQLField("id", graphql.ID, func(s interface{}) interface{} { return s.(*app.Distribution).ID }),
+		graphQLField("flowID", graphQLFlowID, func(s interface{}) interface{} { return s.(*app.Distribution).FlowID }),
+		graphQLField("issuer", graphql.String, func(s interface{}) interface{} { return s.(*app.Distribution).Issuer }),
+		graphQLField("state", graphql.String, func(s interface{}) interface{} { return s.(*app.Distribution).State }),
+		graphQLField("collectionID", graphql.ID, func(s interface{}) interface{} { return s.(*app.Distribution).CollectionID }),
+		graphQLField("packReference", graphql.String, func(s interface{}) interface{} { return s.(*app.Distribution).PackTemplate.PackReference }),
+		graphQLField("packCount", graphql.Int, func(s interface{}) interface{} { return s.(*app.Distribution).PackTemplate.PackCount }),
+		graphQLField("revealNotBefore", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).PackTemplate.RevealNotBefore }),
+		graphQLField("teaseNotBefore", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).PackTemplate.TeaseNotBefore }),
+		graphQLField("createdAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).CreatedAt }),
+		graphQLField("updatedAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).UpdatedAt }),
+		graphQLField("completedAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).CompletedAt }),
+		graphQLField("archivedAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).ArchivedAt }),
+		graphQLField("pausedAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).PausedAt }),
+		graphQLField("settlementStartAt", graphQLTime, func(s interface{}) interface{} { return s.(*app.Distribution).SettlementStartAt }),
+		{
+			Name: "buckets",
+			Type: graphql.List{Of: bucket},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				dist, err := a.GetDistributionWithBuckets(ctx, source.(*app.Distribution).ID)
+				if err != nil {
+					return nil, err
+				}
+				return dist.PackTemplate.Buckets, nil
+			},
+		},
+		{
+			Name:        "packs",
+			Description: "Packs in any of 'states' (all if not given), in the order they were created",
+			Type:        graphql.List{Of: pack},
+			Args:        append([]*graphql.Argument{{Name: "states", Type: graphql.List{Of: graphql.String}}}, listArgs...),
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				limit, err := graphQLLimit(args)
+				if err != nil {
+					return nil, err
+				}
+				states, err := app.ParsePackStates(graphQLStrings(args["states"]))
+				if err != nil {
+					return nil, err
+				}
+				list, _, err := a.ListDistributionPacks(ctx, source.(*app.Distribution).ID, states, limit, args["offset"].(int))
+				if err != nil {
+					return nil, err
+				}
+				return newGraphQLPacks(a, list), nil
+			},
+		},
+	}
+
+	bucket.Fields = []*graphql.Field{
+		graphQLField("collectibleReference", graphql.String, func(s interface{}) interface{} { return s.(*app.Bucket).CollectibleReference }),
+		graphQLField("collectibleCount", graphql.Int, func(s interface{}) interface{} { return s.(*app.Bucket).CollectibleCount }),
+		graphQLField("collectionSize", graphql.Int, func(s interface{}) interface{} { return len(s.(*app.Bucket).CollectibleCollection) }),
+	}
+
+	pack.Fields = []*graphql.Field{
+		graphQLField("id", graphql.ID, func(s interface{}) interface{} { return s.(*graphQLPack).ID }),
+		graphQLField("distributionID", graphql.ID, func(s interface{}) interface{} { return s.(*graphQLPack).DistributionID }),
+		graphQLField("flowID", graphQLFlowID, func(s interface{}) interface{} { return s.(*graphQLPack).FlowID }),
+		graphQLField("state", graphql.String, func(s interface{}) interface{} { return s.(*graphQLPack).State }),
+		graphQLField("commitmentHash", graphql.String, func(s interface{}) interface{} { return s.(*graphQLPack).CommitmentHash }),
+		graphQLField("createdAt", graphQLTime, func(s interface{}) interface{} { return s.(*graphQLPack).CreatedAt }),
+		graphQLField("updatedAt", graphQLTime, func(s interface{}) interface{} { return s.(*graphQLPack).UpdatedAt }),
+		{
+			Name: "distribution",
+			Type: distribution,
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				p := source.(*graphQLPack)
+				return p.loader.distribution(ctx, p.DistributionID)
+			},
+		},
+		{
+			Name:        "teaser",
+			Description: "Tier of each slot, once teased",
+			Type:        graphql.List{Of: graphql.String},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				p := source.(*graphQLPack)
+				reveal, err := p.loader.reveal(ctx, p.ID)
+				if err != nil {
+					return nil, err
+				}
+				return reveal.Teaser, nil
+			},
+		},
+		{
+			Name:        "collectibles",
+			Description: "Collectibles of the pack, once revealed",
+			Type:        graphql.List{Of: collectible},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				p := source.(*graphQLPack)
+				reveal, err := p.loader.reveal(ctx, p.ID)
+				if err != nil {
+					return nil, err
+				}
+				return reveal.Collectibles, nil
+			},
+		},
+	}
+
+	collectible.Fields = []*graphql.Field{
+		graphQLField("flowID", graphQLFlowID, func(s interface{}) interface{} { return s.(*app.Collectible).FlowID }),
+		graphQLField("contractReference", graphql.String, func(s interface{}) interface{} { return s.(*app.Collectible).ContractReference }),
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+		{
+			Name: "distribution",
+			Type: distribution,
+			Args: []*graphql.Argument{{Name: "id", Type: graphql.ID, Required: true}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := uuid.Parse(args["id"].(string))
+				if err != nil {
+					return nil, fmt.Errorf("invalid distribution ID '%s'", args["id"])
+				}
+				dist, err := a.GetDistributionWithBuckets(ctx, id)
+				if err != nil {
+					return graphQLNotFound(err)
+				}
+				if err := authorizeIssuerContext(ctx, dist.Issuer); err != nil {
+					return nil, err
+				}
+				return dist, nil
+			},
+		},
+		{
+			Name:        "distributions",
+			Description: "Distributions matching the filters, see GET /distributions",
+			Type:        graphql.List{Of: distribution},
+			Args: append([]*graphql.Argument{
+				{Name: "issuer", Type: graphql.String},
+				{Name: "states", Type: graphql.List{Of: graphql.String}},
+				{Name: "createdAfter", Type: graphQLTime},
+				{Name: "sort", Type: graphql.String},
+				{Name: "archived", Type: graphql.Boolean, Description: "Only archived distributions, otherwise they are left out"},
+			}, listArgs...),
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				limit, err := graphQLLimit(args)
+				if err != nil {
+					return nil, err
+				}
+
+				filter := app.DistributionFilter{}
+				if s, ok := args["issuer"].(string); ok {
+					issuer, err := parseFlowAddress(s)
+					if err != nil {
+						return nil, err
+					}
+					filter.Issuer = &issuer
+				}
+				for _, s := range graphQLStrings(args["states"]) {
+					filter.States = append(filter.States, common.DistributionState(s))
+				}
+				if t, ok := args["createdAfter"].(time.Time); ok {
+					filter.CreatedAfter = &t
+				}
+				if s, ok := args["sort"].(string); ok {
+					filter.Sort = s
+				}
+				if archived, ok := args["archived"].(bool); ok {
+					filter.Archived = archived
+				}
+
+				if err := scopeDistributionFilterContext(ctx, &filter); err != nil {
+					return nil, err
+				}
+
+				list, _, err := a.ListDistributions(ctx, filter, limit, args["offset"].(int))
+				return list, err
+			},
+		},
+		{
+			Name: "pack",
+			Type: pack,
+			Args: []*graphql.Argument{{Name: "id", Type: graphql.ID, Required: true}},
+			Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				id, err := uuid.Parse(args["id"].(string))
+				if err != nil {
+					return nil, fmt.Errorf("invalid pack ID '%s'", args["id"])
+				}
+				p, err := a.GetPack(ctx, id)
+				if err != nil {
+					return graphQLNotFound(err)
+				}
+				issuer, err := a.GetDistributionIssuer(ctx, p.DistributionID)
+				if err != nil {
+					return nil, err
+				}
+				if err := authorizeIssuerContext(ctx, issuer); err != nil {
+					return nil, err
+				}
+				return &newGraphQLPacks(a, []app.Pack{*p})[0], nil
+			},
+		},
+	}}
+
+	return &graphql.Schema{Query: query}
+}
+
+// graphQLField returns a field without arguments, resolved by 'value' from
+// its source.
+func graphQLField(name string, t graphql.Type, value func(source interface{}) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Name: name,
+		Type: t,
+		Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return value(source), nil
+		},
+	}
+}
+
+// graphQLPack is a pack resolved as part of a list, the fields of its
+// contents and distribution are loaded for the whole list by 'loader'.
+type graphQLPack struct {
+	*app.Pack
+	loader *graphQLPackLoader
+}
+
+// graphQLPackLoader loads what has been disclosed of the contents of a list
+// of packs and their distributions in one query each, once the first pack
+// of the list needs them, instead of one query per pack.
+type graphQLPackLoader struct {
+	a     *app.App
+	packs []*app.Pack
+
+	reveals       map[uuid.UUID]*app.PackReveal   // By pack ID, once loaded
+	distributions map[uuid.UUID]*app.Distribution // By ID, once loaded
+}
+
+// newGraphQLPacks returns 'list' sharing a loader.
+func newGraphQLPacks(a *app.App, list []app.Pack) []graphQLPack {
+	loader := &graphQLPackLoader{a: a, packs: make([]*app.Pack, len(list))}
+	res := make([]graphQLPack, len(list))
+	for i := range list {
+		loader.packs[i] = &list[i]
+		res[i] = graphQLPack{Pack: &list[i], loader: loader}
+	}
+	return res
+}
+
+// reveal returns what has been disclosed of the contents of pack 'id'.
+func (l *graphQLPackLoader) reveal(ctx context.Context, id uuid.UUID) (*app.PackReveal, error) {
+	if l.reveals == nil {
+		reveals, err := l.a.GetPackReveals(ctx, l.packs)
+		if err != nil {
+			return nil, err
+		}
+		l.reveals = reveals
+	}
+
+	reveal, ok := l.reveals[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return reveal, nil
+}
+
+// distribution returns distribution 'id' including its buckets.
+func (l *graphQLPackLoader) distribution(ctx context.Context, id uuid.UUID) (*app.Distribution, error) {
+	if l.distributions == nil {
+		ids := []uuid.UUID{}
+		seen := make(map[uuid.UUID]bool)
+		for _, p := range l.packs {
+			if !seen[p.DistributionID] {
+				seen[p.DistributionID] = true
+				ids = append(ids, p.DistributionID)
+			}
+		}
+
+		list, err := l.a.GetDistributionsWithBuckets(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		l.distributions = make(map[uuid.UUID]*app.Distribution, len(list))
+		for i := range list {
+			l.distributions[list[i].ID] = &list[i]
+		}
+	}
+
+	dist, ok := l.distributions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return dist, nil
+}
+
+// graphQLNotFound resolves unknown distributions and packs to null.
+func graphQLNotFound(err error) (interface{}, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+func graphQLLimit(args map[string]interface{}) (int, error) {
+	limit := args["limit"].(int)
+	if limit < 1 || limit > maxGraphQLLimit {
+		return 0, fmt.Errorf("limit has to be between 1 and %d, got %d", maxGraphQLLimit, limit)
+	}
+	return limit, nil
+}
+
+func graphQLStrings(v interface{}) []string {
+	list, _ := v.([]interface{})
+	res := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
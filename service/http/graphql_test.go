@@ -0,0 +1,172 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"gorm.io/gorm"
+)
+
+func TestHandleGraphQL(t *testing.T) {
+	schema := newGraphQLSchema(nil)
+
+	rw := httptest.NewRecorder()
+	HandleGetGraphQLSchema(schema).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/graphql/schema", nil))
+	for _, expected := range []string{
+		"distribution(id: ID!): Distribution\n",
+		"packs(states: [String], limit: Int = 20, offset: Int = 0): [Pack]\n",
+		"collectibles: [Collectible]\n",
+		"scalar FlowID\n",
+	} {
+		if !strings.Contains(rw.Body.String(), expected) {
+			t.Errorf("expected schema to contain %q, got\n%s", expected, rw.Body.String())
+		}
+	}
+
+	h := HandleGraphQL(nil, schema)
+
+	for _, c := range []struct {
+		name     string
+		r        *http.Request
+		status   int
+		expected string
+	}{
+		{
+			"post",
+			httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(`{"query":"{ __typename distribution(id: \"x\") { id } }"}`)),
+			http.StatusOK,
+			`{"data":{"__typename":"Query","distribution":null},"errors":[{"message":"invalid distribution ID 'x'","path":["distribution"]}]}`,
+		},
+		{
+			"get with variables",
+			httptest.NewRequest(http.MethodGet, "/v1/graphql?"+url.Values{
+				"query":     {`query($limit: Int) { distributions(limit: $limit) { id } }`},
+				"variables": {`{"limit":101}`},
+			}.Encode(), nil),
+			http.StatusOK,
+			`{"data":{"distributions":null},"errors":[{"message":"limit has to be between 1 and 100, got 101","path":["distributions"]}]}`,
+		},
+		{
+			"invalid query",
+			httptest.NewRequest(http.MethodPost, "/v1/graphql", strings.NewReader(`{"query":"{ packs }"}`)),
+			http.StatusOK,
+			`{"errors":[{"message":"cannot query field \"packs\" on type Query"}]}`,
+		},
+		{
+			"no query",
+			httptest.NewRequest(http.MethodGet, "/v1/graphql", nil),
+			http.StatusBadRequest,
+			"",
+		},
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, c.r)
+
+		if rw.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, rw.Code)
+			continue
+		}
+		if c.expected == "" {
+			continue
+		}
+
+		var expected, got interface{}
+		if err := json.Unmarshal([]byte(c.expected), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if mustMarshal(t, got) != mustMarshal(t, expected) {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, rw.Body.String())
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestGraphQLPackListQueries(t *testing.T) {
+	cfg, a, db := newIsolationTestApp(t)
+	h := NewRouter(cfg, a)
+
+	queries := 0
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+
+	// Returns the number of queries to list the contents and distribution
+	// of the packs of a distribution of 'count' packs
+	listPacks := func(count int) int {
+		dist := app.Distribution{Issuer: issuer, State: common.DistributionStateComplete}
+		if err := db.Create(&dist).Error; err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < count; i++ {
+			pack := app.Pack{
+				DistributionID: dist.ID,
+				FlowID:         common.FlowID{Int64: int64(i + 1), Valid: true},
+				State:          common.PackStateOpened,
+				Collectibles: app.Collectibles{
+					{FlowID: common.FlowID{Int64: int64(100 + i), Valid: true}, ContractReference: app.AddressLocation{Name: "ExampleNFT", Address: issuer}},
+				},
+			}
+			if err := db.Create(&pack).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		query := fmt.Sprintf(`{ distribution(id: "%s") { packs(limit: 100) { teaser collectibles { flowID } distribution { id } } } }`, dist.ID)
+		r := httptest.NewRequest(http.MethodGet, "/v1/graphql?"+url.Values{"query": {query}}.Encode(), nil)
+		r.Header.Set("Authorization", "Bearer admin-token")
+		rw := httptest.NewRecorder()
+
+		queries = 0
+		h.ServeHTTP(rw, r)
+
+		res := struct {
+			Data struct {
+				Distribution struct {
+					Packs []struct {
+						Collectibles []struct{ FlowID int64 }
+						Distribution struct{ ID string }
+					}
+				}
+			}
+			Errors []interface{}
+		}{}
+		if err := json.Unmarshal(rw.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Errors) > 0 || len(res.Data.Distribution.Packs) != count {
+			t.Fatalf("expected %d packs, got %s", count, rw.Body.String())
+		}
+		for _, p := range res.Data.Distribution.Packs {
+			if len(p.Collectibles) != 1 || p.Distribution.ID != dist.ID.String() {
+				t.Fatalf("expected the collectibles and distribution of each pack, got %s", rw.Body.String())
+			}
+		}
+
+		return queries
+	}
+
+	one, many := listPacks(1), listPacks(10)
+	if many != one {
+		t.Errorf("expected the number of queries not to grow with the packs listed, got %d for 1 pack and %d for 10", one, many)
+	}
+}
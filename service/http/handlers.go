@@ -1,14 +1,38 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/graphql"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	callbackTimestampHeader  = "X-PDS-Timestamp"
+	callbackSignatureHeader  = "X-PDS-Signature"
+	maxCallbackBodySize      = 1 << 20
+	totalCountHeader         = "X-Total-Count"
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	sseKeepAliveInterval     = 15 * time.Second
+	problemContentType       = "application/problem+json"
+	bulkCSVFormField         = "buckets"
 )
 
 // Set distribution capability
@@ -28,6 +52,11 @@ func HandleSetDistCap(logger *log.Logger, app *app.App) http.HandlerFunc {
 			return
 		}
 
+		if err := authorizeIssuer(r, reqData.Issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
 		if err := app.SetDistCap(r.Context(), reqData.Issuer); err != nil {
 			handleError(rw, logger, err)
 			return
@@ -47,24 +76,88 @@ func HandleCreateDistribution(logger *log.Logger, app *app.App) http.HandlerFunc
 			return
 		}
 
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
 		var reqDist ReqCreateDistribution
 
 		// Decode JSON
-		if err := json.NewDecoder(r.Body).Decode(&reqDist); err != nil {
+		if err := json.Unmarshal(body, &reqDist); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, reqDist.Issuer); err != nil {
 			handleError(rw, logger, err)
 			return
 		}
 
-		// Create new distribution
+		// Create new distribution, or return the one created by an earlier
+		// request with the same idempotency key
 		appDist := reqDist.ToApp()
-		if err := app.CreateDistribution(r.Context(), &appDist); err != nil {
+		created, replayed := &appDist, false
+		if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+			created, replayed, err = app.CreateDistributionIdempotent(r.Context(), &appDist, key, body)
+		} else {
+			err = app.CreateDistribution(r.Context(), &appDist)
+		}
+		if err != nil {
 			handleError(rw, logger, err)
 			return
 		}
 
 		res := ResCreateDistribution{
-			ID:     appDist.ID,
-			FlowID: appDist.FlowID,
+			ID:     created.ID,
+			FlowID: created.FlowID,
+		}
+
+		if replayed {
+			rw.Header().Set(idempotentReplayedHeader, "true")
+			handleJsonResponse(rw, http.StatusOK, res)
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Create distributions in bulk, from a JSON array or a multipart CSV of
+// bucket definitions
+func HandleCreateDistributions(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		distributions, err := readBulkDistributions(r)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		for _, d := range distributions {
+			if err := authorizeIssuer(r, d.Issuer); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		if err := app.CreateDistributions(r.Context(), distributions); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResCreateDistribution, len(distributions))
+		for i, d := range distributions {
+			res[i] = ResCreateDistribution{
+				ID:     d.ID,
+				FlowID: d.FlowID,
+			}
 		}
 
 		handleJsonResponse(rw, http.StatusCreated, res)
@@ -84,7 +177,18 @@ func HandleListDistributions(logger *log.Logger, app *app.App) http.HandlerFunc
 			offset = 0
 		}
 
-		list, err := app.ListDistributions(r.Context(), limit, offset)
+		filter, err := parseDistributionFilter(r)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := scopeDistributionFilter(r, &filter); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, total, err := app.ListDistributions(r.Context(), filter, limit, offset)
 		if err != nil {
 			handleError(rw, logger, err)
 			return
@@ -92,12 +196,123 @@ func HandleListDistributions(logger *log.Logger, app *app.App) http.HandlerFunc
 
 		res := ResDistributionListFromApp(list)
 
+		rw.Header().Set(totalCountHeader, strconv.FormatInt(total, 10))
 		handleJsonResponse(rw, http.StatusOK, res)
 	}
 }
 
-// Get distribution details
-func HandleGetDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+// List distributions (v2), a page after a cursor instead of an offset
+func HandleListDistributionsV2(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		filter, err := parseDistributionFilter(r)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := scopeDistributionFilter(r, &filter); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, next, err := app.ListDistributionsPage(r.Context(), filter, r.FormValue("cursor"), limit)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionPage{
+			Items:      ResDistributionListFromApp(list),
+			NextCursor: next,
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Create a collection
+func HandleCreateCollection(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCreateCollection
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, reqData.Issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		collection := reqData.ToApp()
+		if err := app.CreateCollection(r.Context(), &collection); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResCollectionFromApp(&collection)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List collections, optionally of an issuer
+func HandleListCollections(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		issuer := common.FlowAddress{}
+		if s := r.FormValue("issuer"); s != "" {
+			var err error
+			if issuer, err = parseFlowAddress(s); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		if err := scopeIssuer(r, &issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListCollections(r.Context(), issuer, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResCollection, len(list))
+		for i := range list {
+			res[i] = ResCollectionFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get collection details and roll-up stats
+func HandleGetCollection(logger *log.Logger, app *app.App) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 
@@ -107,20 +322,26 @@ func HandleGetDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
 			return
 		}
 
-		dist, err := app.GetDistribution(r.Context(), id)
+		if err := authorizeCollection(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		collection, stats, err := app.GetCollection(r.Context(), id)
 		if err != nil {
 			handleError(rw, logger, err)
 			return
 		}
 
-		res := ResGetDistributionFromApp(dist)
+		res := ResCollectionFromApp(collection)
+		res.Stats = ResCollectionStatsFromApp(stats)
 
 		handleJsonResponse(rw, http.StatusOK, res)
 	}
 }
 
-// Abort a distribution
-func HandleAbortDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+// List distributions of a collection
+func HandleListCollectionDistributions(logger *log.Logger, app *app.App) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 
@@ -130,17 +351,2450 @@ func HandleAbortDistribution(logger *log.Logger, app *app.App) http.HandlerFunc
 			return
 		}
 
-		if err := app.AbortDistribution(r.Context(), id); err != nil {
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		if err := authorizeCollection(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListCollectionDistributions(r.Context(), id, limit, offset)
+		if err != nil {
 			handleError(rw, logger, err)
 			return
 		}
 
-		handleJsonResponse(rw, http.StatusOK, "Ok")
+		res := ResDistributionListFromApp(list)
+
+		handleJsonResponse(rw, http.StatusOK, res)
 	}
 }
 
-func HandleHealthReady() http.HandlerFunc {
+// Create a reusable distribution template
+func HandleCreateDistributionTemplate(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqDistributionTemplate
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, reqData.Issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		template := reqData.ToApp()
+		if err := app.CreateDistributionTemplate(r.Context(), &template); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionTemplateFromApp(&template)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List distribution templates, optionally of an issuer
+func HandleListDistributionTemplates(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		issuer := common.FlowAddress{}
+		if s := r.FormValue("issuer"); s != "" {
+			var err error
+			if issuer, err = parseFlowAddress(s); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		if err := scopeIssuer(r, &issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListDistributionTemplates(r.Context(), issuer, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResDistributionTemplate, len(list))
+		for i := range list {
+			res[i] = ResDistributionTemplateFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get a distribution template
+func HandleGetDistributionTemplate(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		template, err := app.GetDistributionTemplate(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, template.Issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionTemplateFromApp(template)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Replace a distribution template
+func HandleUpdateDistributionTemplate(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqDistributionTemplate
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistributionTemplate(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		template := reqData.ToApp()
+		if err := app.UpdateDistributionTemplate(r.Context(), id, &template); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionTemplateFromApp(&template)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Delete a distribution template
+func HandleDeleteDistributionTemplate(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistributionTemplate(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := app.DeleteDistributionTemplate(r.Context(), id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+// List packs of a distribution
+func HandleListDistributionPacks(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		var states []common.PackState
+		if s := r.FormValue("state"); s != "" {
+			if states, err = parsePackStates(s); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		list, total, err := app.ListDistributionPacks(r.Context(), id, states, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPackListFromApp(list)
+
+		rw.Header().Set(totalCountHeader, strconv.FormatInt(total, 10))
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Stream the progress of a distribution as server-sent events
+func HandleDistributionEvents(logger *log.Logger, app *app.App, interval time.Duration) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		flusher, ok := rw.(http.Flusher)
+		if !ok {
+			handleError(rw, logger, fmt.Errorf("streaming is not supported"))
+			return
+		}
+
+		progress, err := app.GetDistributionProgress(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+		rw.WriteHeader(http.StatusOK)
+
+		end, err := writeDistributionEvents(rw, nil, progress)
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+		if end {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		keepAlive := time.NewTicker(sseKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-shutdownSignal(r.Context()):
+				return
+			case <-keepAlive.C:
+				// Comment lines keep idle connections from being closed by proxies
+				if _, err := io.WriteString(rw, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+				continue
+			case <-ticker.C:
+			}
+
+			next, err := app.GetDistributionProgress(r.Context(), id)
+			if err != nil {
+				logger.Error(err)
+				return
+			}
+
+			end, err := writeDistributionEvents(rw, progress, next)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+			if end {
+				return
+			}
+
+			progress = next
+		}
+	}
+}
+
+// Download the manifest of the packs of a complete distribution
+func HandleExportDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		includeSalt, err := strconv.ParseBool(r.FormValue("includeSalt"))
+		if err != nil {
+			includeSalt = false
+		}
+
+		export, err := app.ExportDistribution(r.Context(), id, r.FormValue("format"), includeSalt)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		rw.Header().Set("Content-Type", export.ContentType())
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName()))
+		rw.WriteHeader(http.StatusOK)
+
+		// The response has started, errors can only be logged
+		if err := export.Write(rw); err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// Get distribution details
+func HandleGetDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.GetDistribution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Update a distribution which has not started settling
+func HandleUpdateDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqUpdate ReqUpdateDistribution
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqUpdate); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.UpdateDistribution(r.Context(), id, reqUpdate.ToApp())
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Clone a distribution into a draft without collectibles
+func HandleCloneDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCloneDistribution
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.CloneDistribution(r.Context(), id, reqData.FlowID)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Reveal (and open) packs of a distribution on behalf of the issuer
+func HandleRevealPacks(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqRevealPacks
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.RevealPacks(r.Context(), id, reqData.PackIDs, reqData.Open)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResBulkRevealListFromApp(list)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Set when the packs of a distribution can be revealed
+func HandleScheduleReveals(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqScheduleReveals
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.ScheduleReveals(r.Context(), id, reqData.RevealNotBefore)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get pack details
+func HandleGetPack(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		pack, err := app.GetPack(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handlePackResponse(rw, r, logger, app, pack)
+	}
+}
+
+// Get the proof of a revealed pack, how its commitment hash is computed
+func HandleGetPackProof(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizePack(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		proof, err := app.GetPackProof(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPackProofFromApp(proof)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Revoke a minted, unopened pack
+func HandleRevokePack(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqRevokePack
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		revocation, err := app.RevokePack(r.Context(), id, reqData.Reason)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPackRevocationFromApp(revocation)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Mint a replacement for a revoked pack or a pack whose reveal failed
+func HandleReplacePack(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqReplacePack
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		replacement, err := app.ReplacePack(r.Context(), id, reqData.Reason)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPackReplacementFromApp(replacement)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Get the replacement of a pack
+func HandleGetPackReplacement(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		replacement, err := app.GetPackReplacement(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPackReplacementFromApp(replacement)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get pack details by its onchain commitment hash
+func HandleGetPackByCommitmentHash(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		hash, err := common.BinaryValueFromHexString(vars["hash"])
+		if err != nil {
+			handleError(rw, logger, fmt.Errorf("invalid commitment hash '%s': %w", vars["hash"], err))
+			return
+		}
+
+		pack, err := app.GetPackByCommitmentHash(r.Context(), hash)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handlePackResponse(rw, r, logger, app, pack)
+	}
+}
+
+// Get pack details by its PackNFT ID
+func HandleGetPackByFlowID(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := common.FlowIDFromString(vars["flowID"])
+		if err != nil || !id.Valid {
+			handleError(rw, logger, fmt.Errorf("invalid FlowID '%s'", vars["flowID"]))
+			return
+		}
+
+		packReference, err := parsePackReference(r.FormValue("packReference"))
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		pack, err := app.GetPackByFlowID(r.Context(), id, packReference)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handlePackResponse(rw, r, logger, app, pack)
+	}
+}
+
+// handlePackResponse writes the public details of 'pack', including its
+// contents once revealed
+func handlePackResponse(rw http.ResponseWriter, r *http.Request, logger *log.Logger, app *app.App, pack *app.Pack) {
+	issuer, err := app.GetDistributionIssuer(r.Context(), pack.DistributionID)
+	if err != nil {
+		handleError(rw, logger, err)
+		return
+	}
+
+	if err := authorizeIssuer(r, issuer); err != nil {
+		handleError(rw, logger, err)
+		return
+	}
+
+	branding, err := issuerBranding(r.Context(), app, issuer)
+	if err != nil {
+		handleError(rw, logger, err)
+		return
+	}
+
+	reveal, err := app.GetPackReveal(r.Context(), pack)
+	if err != nil {
+		handleError(rw, logger, err)
+		return
+	}
+
+	res := ResPackFromApp(pack, branding, reveal)
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Set the branding of an issuer
+// Reserve collectible IDs for a pack minting collectibles on open
+func HandleReserveCollectibleIDs(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqReserveCollectibleIDs
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		reservation, err := app.ReserveCollectibleIDs(r.Context(), id, reqData.CollectibleReference.ToApp(), reqData.Count)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResCollectibleIDReservationFromApp(reservation)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// List the collectible IDs reserved for a pack
+func HandleListCollectibleIDReservations(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListCollectibleIDReservations(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResCollectibleIDReservation, len(list))
+		for i := range list {
+			res[i] = ResCollectibleIDReservationFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+func HandleSetIssuerBranding(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqIssuerBranding
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding := reqData.ToApp(issuer)
+		if err := app.SetIssuerBranding(r.Context(), &branding); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerBrandingFromApp(&branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Register an issuer organization
+func HandleCreateIssuer(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCreateIssuer
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		issuer := reqData.ToApp()
+
+		if err := app.CreateIssuer(r.Context(), &issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerFromApp(&issuer)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List the registered issuers
+func HandleListIssuers(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		list, err := app.ListIssuers(r.Context(), limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResIssuer, len(list))
+		for i := range list {
+			res[i] = ResIssuerFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get a registered issuer
+func HandleGetIssuer(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		issuer, err := app.GetIssuer(r.Context(), address)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerFromApp(issuer)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Update the name and allowed collectible contracts of a registered issuer
+func HandleUpdateIssuer(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqUpdateIssuer
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		issuer, err := app.UpdateIssuer(r.Context(), address, reqData.Name, reqData.allowedContracts())
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerFromApp(issuer)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Create an API key for an issuer
+func HandleCreateAPIKey(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCreateAPIKey
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		key, plaintext, err := app.CreateAPIKey(r.Context(), issuer, reqData.Name, reqData.allowedContracts())
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResAPIKeyFromApp(key)
+		res.Key = plaintext
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List the API keys of an issuer
+func HandleListAPIKeys(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListAPIKeys(r.Context(), issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResAPIKey, len(list))
+		for i := range list {
+			res[i] = ResAPIKeyFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Revoke an API key of an issuer
+func HandleRevokeAPIKey(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		key, err := app.RevokeAPIKey(r.Context(), issuer, id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResAPIKeyFromApp(key)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Register a webhook of an issuer
+func HandleCreateIssuerWebhook(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCreateIssuerWebhook
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		w, err := app.CreateIssuerWebhook(r.Context(), issuer, authenticatedAPIKey(r), reqData.URL)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerWebhookFromApp(w)
+		res.Secret = w.Secret
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List the webhooks of an issuer
+func HandleListIssuerWebhooks(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		list, err := app.ListIssuerWebhooks(r.Context(), issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResIssuerWebhook, len(list))
+		for i := range list {
+			res[i] = ResIssuerWebhookFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Delete a webhook of an issuer
+func HandleDeleteIssuerWebhook(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := app.DeleteIssuerWebhook(r.Context(), issuer, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+// Generate a new callback secret for an issuer
+func HandleRotateIssuerCallbackSecret(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		secret, err := app.RotateIssuerCallbackSecret(r.Context(), issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerCallbackSecretFromApp(secret)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Receive a signed callback of an issuer
+func HandleReceiveIssuerCallback(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// The signature is over the raw body
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqIssuerCallback
+
+		// Decode JSON
+		if err := json.Unmarshal(body, &reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		callback := reqData.ToApp(issuer)
+		if err := app.ReceiveIssuerCallback(r.Context(), &callback, r.Header.Get(callbackTimestampHeader), r.Header.Get(callbackSignatureHeader), body); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerCallbackFromApp(&callback)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// List the received callbacks of an issuer
+func HandleListIssuerCallbacks(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		list, err := app.ListIssuerCallbacks(r.Context(), issuer, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := make([]ResIssuerCallback, len(list))
+		for i := range list {
+			res[i] = ResIssuerCallbackFromApp(&list[i])
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the branding of an issuer
+func HandleGetIssuerBranding(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := app.GetIssuerBranding(r.Context(), issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResIssuerBrandingFromApp(branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Opt an issuer in or out of the public stats
+func HandleSetPublicStatsOptIn(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		issuer, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeIssuer(r, issuer); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqPublicStatsOptIn
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := app.SetPublicStatsOptIn(r.Context(), issuer, reqData.OptIn); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+// Get the public (aggregate) stats
+func HandleGetPublicStats(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		stats, err := app.GetPublicStats(r.Context())
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResPublicStatsFromApp(stats)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Abort (cancel) a distribution, optionally returning the escrowed
+// collectibles of its cancelled packs to the issuer
+func HandleAbortDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		returnEscrow := false
+		if s := r.FormValue("returnEscrow"); s != "" {
+			returnEscrow, err = strconv.ParseBool(s)
+			if err != nil {
+				handleError(rw, logger, fmt.Errorf("invalid returnEscrow '%s'", s))
+				return
+			}
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := app.AbortDistribution(r.Context(), id, returnEscrow); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+// Retry the settlement or minting of a stuck distribution
+func HandleRetryDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		retry, err := app.RetryDistribution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionRetryFromApp(retry)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Pause the settlement and minting of a distribution
+func HandlePauseDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.PauseDistribution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Resume a paused distribution
+func HandleResumeDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.ResumeDistribution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Archive a completed distribution
+func HandleArchiveDistribution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		dist, err := app.ArchiveDistribution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		branding, err := issuerBranding(r.Context(), app, dist.Issuer)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGetDistributionFromApp(dist, branding)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Start verifying the onchain ownership of the packs in a distribution
+func HandleStartOwnershipVerification(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		verification, err := app.StartOwnershipVerification(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResOwnershipVerificationFromApp(verification)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Get the collectible ownership check run when setting up a distribution
+func HandleGetCollectibleOwnershipCheck(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		check, err := app.GetCollectibleOwnershipCheck(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResCollectibleOwnershipCheckFromApp(check)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the status and discrepancy report of an ownership verification
+func HandleGetOwnershipVerification(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		verificationID, err := uuid.Parse(vars["verificationID"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		verification, err := app.GetOwnershipVerification(r.Context(), id, verificationID)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResOwnershipVerificationFromApp(verification)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the collectibles of a distribution left in escrow which no pack needs
+func HandleGetEscrowSurplus(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		surplus, err := app.GetEscrowSurplus(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResEscrowSurplusFromApp(surplus)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Return the escrow surplus of a distribution to its issuer
+func HandleReturnEscrowSurplus(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		surplus, err := app.ReturnEscrowSurplus(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResEscrowSurplusFromApp(surplus)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the completion report of a closed distribution
+func HandleGetCompletionReport(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		report, err := app.GetCompletionReport(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResCompletionReportFromApp(report)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the fees paid for the transactions of a distribution
+func HandleGetDistributionCosts(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		costs, err := app.GetDistributionCosts(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionCostsFromApp(costs)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get an overview of the packs, slots, transactions and timing of a distribution
+func HandleGetDistributionSummary(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		summary, err := app.GetDistributionSummary(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionSummaryFromApp(summary)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Estimate when the settlement and minting of a distribution complete
+func HandleGetDistributionETA(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		eta, err := app.GetDistributionETA(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionETAFromApp(eta)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Disclose the resolve seed of a distribution and verify its packs against it
+func HandleGetDistributionResolution(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		resolution, err := app.GetDistributionResolution(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResDistributionResolutionFromApp(resolution)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Register intended recipients for minted packs of a distribution
+func HandleCreateGiftIntents(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		// Check body is not empty
+		if err := checkNonEmptyBody(r); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		var reqData ReqCreateGiftIntents
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		intents := reqData.ToApp()
+		if err := app.CreateGiftIntents(r.Context(), id, intents); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGiftIntentListFromApp(intents)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// List gift intents of a distribution
+func HandleListGiftIntents(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		list, err := app.ListGiftIntents(r.Context(), id, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGiftIntentListFromApp(list)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get a gift intent, including whether the transfer has been observed
+func HandleGetGiftIntent(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		if err := authorizeDistribution(r, app, id); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		giftIntentID, err := uuid.Parse(vars["giftIntentID"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		g, err := app.GetGiftIntent(r.Context(), id, giftIntentID)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResGiftIntentFromApp(g)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Get the effective configuration with secrets redacted
+// List the IDs of the collectibles of a contract held by an account
+func HandleListOwnedCollectibles(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		contract := AddressLocation{Name: r.FormValue("contractName")}
+		if contract.Name == "" {
+			handleError(rw, logger, fmt.Errorf("contractName required"))
+			return
+		}
+
+		if a := r.FormValue("contractAddress"); a != "" {
+			if contract.Address, err = parseFlowAddress(a); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		resolved, ids, err := app.ListOwnedCollectibleIDs(r.Context(), address, contract.Name, contract.Address, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResOwnedCollectibles{
+			Address:              address,
+			CollectibleReference: AddressLocation(resolved),
+			CollectibleIDs:       ids,
+		}
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// List the packs held by an account
+func HandleListOwnerPacks(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		address, err := parseFlowAddress(vars["address"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		var states []common.PackState
+		if s := r.FormValue("state"); s != "" {
+			if states, err = parsePackStates(s); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		list, total, err := app.ListOwnerPacks(r.Context(), address, states, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResOwnedPacksFromApp(list)
+
+		rw.Header().Set(totalCountHeader, strconv.FormatInt(total, 10))
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// List transactions which ran out of attempts
+func HandleListDeadLetterTransactions(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		list, err := app.ListDeadLetterTransactions(r.Context(), limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResTransactionListFromApp(list)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// List the transaction audit log of a distribution
+func HandleListTransactionAudit(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			limit = 0
+		}
+
+		offset, err := strconv.Atoi(r.FormValue("offset"))
+		if err != nil {
+			offset = 0
+		}
+
+		list, err := app.ListTransactionAudit(r.Context(), id, limit, offset)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResTransactionAttemptListFromApp(list)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Requeue a dead-letter transaction
+func HandleRequeueTransaction(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		t, err := app.RequeueTransaction(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResTransactionFromApp(t)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Force a new reveal transaction for a pack whose reveal transaction failed
+func HandleForceRevealPack(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		openRequest := false
+		if s := r.FormValue("openRequest"); s != "" {
+			openRequest, err = strconv.ParseBool(s)
+			if err != nil {
+				handleError(rw, logger, fmt.Errorf("invalid openRequest '%s'", s))
+				return
+			}
+		}
+
+		t, err := app.ForceRevealPack(r.Context(), id, openRequest)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResTransactionFromApp(t)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Force a new open transaction for a pack whose open transaction failed
+func HandleForceOpenPack(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		t, err := app.ForceOpenPack(r.Context(), id)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResTransactionFromApp(t)
+
+		handleJsonResponse(rw, http.StatusCreated, res)
+	}
+}
+
+// Freeze sending, revoke the admin keys and switch to the standby keys
+func HandleRotateAndFreeze(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var reqData ReqRotateAndFreeze
+
+		// Decode JSON
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		rotation, err := app.RotateAndFreeze(r.Context(), reqData.Reason)
+		if err != nil {
+			handleError(rw, logger, err)
+			return
+		}
+
+		res := ResKeyRotationFromApp(rotation)
+
+		handleJsonResponse(rw, http.StatusOK, res)
+	}
+}
+
+// Freeze sending transactions
+func HandleFreezeSending(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		app.FreezeSending()
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+// Unfreeze sending transactions
+func HandleUnfreezeSending(logger *log.Logger, app *app.App) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		app.UnfreezeSending()
+		handleJsonResponse(rw, http.StatusOK, "Ok")
+	}
+}
+
+func HandleGetSystemConfig(cfg *config.Config) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		handleJsonResponse(rw, http.StatusOK, cfg.Redacted())
+	}
+}
+
+func HandleHealthReady() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// Liveness probe, the process is serving requests
+func HandleHealthz() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(http.StatusOK)
+		fmt.Fprintln(rw, "ok")
+	}
+}
+
+// Readiness probe, the dependencies of the PDS are reachable
+func HandleReadyz(logger *log.Logger, app *app.App, timeout time.Duration) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		readiness := app.CheckReadiness(r.Context(), timeout)
+
+		status := http.StatusOK
+		if !readiness.Ready {
+			for _, c := range readiness.Checks {
+				if c.Error != "" {
+					logger.WithFields(log.Fields{"check": c.Name, "error": c.Error}).Warn("Readiness check failed")
+				}
+			}
+			status = http.StatusServiceUnavailable
+		}
+
+		handleJsonResponse(rw, status, ResReadinessFromApp(readiness))
+	}
+}
+
+// issuerBranding returns the branding of 'issuer', nil if it has none
+func issuerBranding(ctx context.Context, app *app.App, issuer common.FlowAddress) (*app.IssuerBranding, error) {
+	branding, err := app.GetIssuerBranding(ctx, issuer)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return branding, err
+}
+
+// parseFlowAddress parses a hex encoded Flow address, with or without 0x prefix
+func parseFlowAddress(s string) (common.FlowAddress, error) {
+	a := common.FlowAddress{}
+	if err := a.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return a, fmt.Errorf("invalid address '%s'", s)
+	}
+	return a, nil
+}
+
+// parseDistributionFilter reads the 'state' (comma separated), 'issuer',
+// 'createdAfter' (RFC 3339) and 'sort' query parameters of a distribution
+// listing.
+func parseDistributionFilter(r *http.Request) (app.DistributionFilter, error) {
+	filter := app.DistributionFilter{Sort: r.FormValue("sort")}
+
+	if s := r.FormValue("state"); s != "" {
+		for _, state := range strings.Split(s, ",") {
+			filter.States = append(filter.States, common.DistributionState(strings.TrimSpace(state)))
+		}
+	}
+
+	if s := r.FormValue("issuer"); s != "" {
+		issuer, err := parseFlowAddress(s)
+		if err != nil {
+			return filter, err
+		}
+		filter.Issuer = &issuer
+	}
+
+	if s := r.FormValue("createdAfter"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return filter, fmt.Errorf("invalid createdAfter '%s', expected RFC 3339", s)
+		}
+		filter.CreatedAfter = &t
+	}
+
+	if s := r.FormValue("archived"); s != "" {
+		archived, err := strconv.ParseBool(s)
+		if err != nil {
+			return filter, fmt.Errorf("invalid archived '%s'", s)
+		}
+		filter.Archived = archived
+	}
+
+	return filter, nil
+}
+
+// parsePackStates parses a comma separated list of pack states, see
+// app.ParsePackStates.
+func parsePackStates(s string) ([]common.PackState, error) {
+	return app.ParsePackStates(strings.Split(s, ","))
+}
+
+// readBulkDistributions reads the distributions of a bulk request, a
+// multipart form with a CSV file of bucket definitions or a JSON array.
+func readBulkDistributions(r *http.Request) ([]app.Distribution, error) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile(bulkCSVFormField)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading form file '%s': %w", bulkCSVFormField, err)
+		}
+		defer file.Close()
+		return app.ParseDistributionsCSV(file)
+	}
+
+	var reqDists []ReqCreateDistribution
+	if err := json.NewDecoder(r.Body).Decode(&reqDists); err != nil {
+		return nil, err
+	}
+
+	distributions := make([]app.Distribution, len(reqDists))
+	for i, d := range reqDists {
+		distributions[i] = d.ToApp()
+	}
+	return distributions, nil
+}
+
+// parsePackReference parses an optional pack contract reference,
+// e.g. 'A.0ae53cb6e3f42a79.PackNFT'
+func parsePackReference(s string) (*app.AddressLocation, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ref, err := app.AddressLocationFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// writeDistributionEvents writes the server-sent events for the progress of
+// a distribution going from 'prev' to 'next', returns true after the last
+// event of the stream
+func writeDistributionEvents(w io.Writer, prev, next *app.DistributionProgress) (bool, error) {
+	data, err := json.Marshal(ResDistributionProgressFromApp(next))
+	if err != nil {
+		return false, err
+	}
+
+	end := false
+	for _, event := range app.DistributionEvents(prev, *next) {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return false, err
+		}
+		end = end || event == app.DistributionEventEnd
+	}
+
+	return end, nil
+}
+
+// Query distributions and packs with GraphQL
+func HandleGraphQL(logger *log.Logger, schema *graphql.Schema) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+
+		if r.Method == http.MethodGet {
+			req.Query = r.FormValue("query")
+			req.OperationName = r.FormValue("operationName")
+			if v := r.FormValue("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					handleError(rw, logger, fmt.Errorf("invalid variables: %w", err))
+					return
+				}
+			}
+		} else {
+			// Check body is not empty
+			if err := checkNonEmptyBody(r); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+
+			// Decode JSON
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				handleError(rw, logger, err)
+				return
+			}
+		}
+
+		if req.Query == "" {
+			handleError(rw, logger, fmt.Errorf("query is required"))
+			return
+		}
+
+		handleJsonResponse(rw, http.StatusOK, schema.Execute(r.Context(), req))
+	}
+}
+
+// Get the GraphQL schema in the schema definition language
+func HandleGetGraphQLSchema(schema *graphql.Schema) http.HandlerFunc {
+	sdl := schema.String()
 	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
 		rw.WriteHeader(http.StatusOK)
+		fmt.Fprint(rw, sdl)
 	}
 }
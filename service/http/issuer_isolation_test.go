@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+type isolationFlowClient struct {
+	flow_helpers.FlowClient
+}
+
+func (isolationFlowClient) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (*flow.Account, error) {
+	return &flow.Account{Address: address, Keys: []*flow.AccountKey{{Index: 0}}}, nil
+}
+
+func (isolationFlowClient) Close() error {
+	return nil
+}
+
+// newIsolationTestApp returns an app on a temporary sqlite database, not
+// polling and without an Access API.
+func newIsolationTestApp(t *testing.T) (*config.Config, *app.App, *gorm.DB) {
+	t.Setenv("FLOW_PDS_ADMIN_ADDRESS", "f8d6e0586b0a20c7")
+	t.Setenv("PDS_ADDRESS", "f8d6e0586b0a20c7")
+	t.Setenv("FLOW_PDS_ADMIN_PRIVATE_KEY", "unused")
+	t.Setenv("NON_FUNGIBLE_TOKEN_ADDRESS", "f8d6e0586b0a20c7")
+
+	cfg, err := config.ParseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.DatabaseType = "sqlite"
+	cfg.DatabaseDSN = path.Join(t.TempDir(), "test.db")
+	cfg.IssuerIsolation = true
+	cfg.AdminAPIToken = "admin-token"
+
+	db, err := common.NewGormDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := transactions.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := app.New(cfg, db, isolationFlowClient{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(a.Close)
+
+	return cfg, a, db
+}
+
+func TestIssuerIsolationAcrossIssuers(t *testing.T) {
+	cfg, a, db := newIsolationTestApp(t)
+
+	issuer := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+	other := common.FlowAddressFromString("01cf0e2f2f715450")
+
+	_, key, err := a.CreateAPIKey(context.Background(), issuer, "dashboard", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resources of the other issuer
+	collection := app.Collection{Issuer: other, Name: "Other"}
+	if err := db.Create(&collection).Error; err != nil {
+		t.Fatal(err)
+	}
+	dist := app.Distribution{Issuer: other, State: common.DistributionStateComplete, CollectionID: &collection.ID}
+	if err := db.Create(&dist).Error; err != nil {
+		t.Fatal(err)
+	}
+	pack := app.Pack{
+		DistributionID: dist.ID,
+		FlowID:         common.FlowID{Int64: 1, Valid: true},
+		State:          common.PackStateOpened,
+		CommitmentHash: common.BinaryValue{0xab},
+		Collectibles: app.Collectibles{
+			{FlowID: common.FlowID{Int64: 10, Valid: true}, ContractReference: app.AddressLocation{Name: "ExampleNFT", Address: other}},
+		},
+	}
+	if err := db.Create(&pack).Error; err != nil {
+		t.Fatal(err)
+	}
+	template := app.DistributionTemplate{Issuer: other, Name: "Other"}
+	if err := db.Create(&template).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewRouter(cfg, a)
+
+	get := func(path, token string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	for _, p := range []string{
+		fmt.Sprintf("/v1/distributions/%s", dist.ID),
+		fmt.Sprintf("/v1/distributions/%s/packs", dist.ID),
+		fmt.Sprintf("/v1/packs/%s", pack.ID),
+		fmt.Sprintf("/v1/packs/%s/proof", pack.ID),
+		fmt.Sprintf("/v1/packs/by-commitment-hash/%s", pack.CommitmentHash),
+		fmt.Sprintf("/v1/collections/%s", collection.ID),
+		fmt.Sprintf("/v1/collections/%s/distributions", collection.ID),
+		fmt.Sprintf("/v1/collections?issuer=%s", other),
+		fmt.Sprintf("/v1/distributions?issuer=%s", other),
+		fmt.Sprintf("/v1/distribution-templates/%s", template.ID),
+		fmt.Sprintf("/v1/distribution-templates?issuer=%s", other),
+	} {
+		if code := get(p, ""); code != http.StatusUnauthorized {
+			t.Errorf("%s: expected an unauthenticated read to be refused, got %d", p, code)
+		}
+		if code := get(p, key); code != http.StatusForbidden && code != http.StatusNotFound {
+			t.Errorf("%s: expected another issuer's resource to be refused, got %d", p, code)
+		}
+	}
+
+	if code := get(fmt.Sprintf("/v1/packs/%s", pack.ID), "admin-token"); code != http.StatusOK {
+		t.Errorf("expected the admin token to read any pack, got %d", code)
+	}
+	if code := get("/v1/distribution-templates", key); code != http.StatusOK {
+		t.Errorf("expected the issuer to list its own templates, got %d", code)
+	}
+	if code := get("/v1/collections", key); code != http.StatusOK {
+		t.Errorf("expected the issuer to list its own collections, got %d", code)
+	}
+}
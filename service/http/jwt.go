@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	jwtLeeway          = time.Minute      // Allowed clock difference to the identity provider
+	jwksMinRefresh     = 30 * time.Second // Min time between fetches, successful or not
+	jwksRequestTimeout = 10 * time.Second
+)
+
+var errTokenInvalid = &app.Error{Code: app.ErrorCodeTokenInvalid, Err: errors.New("invalid token")}
+
+// JWTVerifier verifies JWTs issued by an OpenID Connect identity provider,
+// signed with RS256 or ES256 by a key of the JSON Web Key Set of the provider.
+// The key set is fetched on first use and cached, a token signed by an
+// unknown key refreshes it.
+type JWTVerifier struct {
+	issuer    string
+	audience  string
+	claim     string // Holds the Flow address of the issuer a token can act for
+	anyIssuer bool   // Tokens can act for any issuer, no claim is set
+	jwksURL   string // Found from the OpenID configuration of the issuer if empty
+	ttl       time.Duration
+	client    *http.Client
+	now       func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey // By key ID
+	fetchedAt   time.Time                   // Of the keys
+	attemptedAt time.Time                   // Of the last fetch, successful or not
+	fetchErr    error                       // Of the last fetch
+	fetching    chan struct{}               // Closed once the fetch in flight (if any) is done
+}
+
+// NewJWTVerifier returns a verifier for tokens of 'cfg.JWTIssuerURL', nil if
+// it is not set.
+func NewJWTVerifier(cfg *config.Config) *JWTVerifier {
+	if cfg.JWTIssuerURL == "" {
+		return nil
+	}
+	return &JWTVerifier{
+		issuer:    strings.TrimRight(cfg.JWTIssuerURL, "/"),
+		audience:  cfg.JWTAudience,
+		claim:     cfg.JWTIssuerClaim,
+		anyIssuer: cfg.JWTAnyIssuer,
+		jwksURL:   cfg.JWTJWKSURL,
+		ttl:       cfg.JWTJWKSCacheTTL,
+		client:    &http.Client{Timeout: jwksRequestTimeout},
+		now:       time.Now,
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature, issuer, audience and validity period of
+// 'token' and returns its claims.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", errTokenInvalid)
+	}
+
+	header := jwtHeader{}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", errTokenInvalid)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Authenticate verifies 'token' and returns the issuer it can act for, nil
+// if it can act for any issuer.
+func (v *JWTVerifier) Authenticate(ctx context.Context, token string) (*common.FlowAddress, error) {
+	claims, err := v.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.claim == "" {
+		if v.anyIssuer {
+			return nil, nil
+		}
+		// Refused at startup, see config.Config
+		return nil, fmt.Errorf("%w: no issuer claim configured", errTokenInvalid)
+	}
+
+	s, _ := claims[v.claim].(string)
+	issuer := common.FlowAddressFromString(s)
+	if s == "" || flow.Address(issuer) == flow.EmptyAddress {
+		return nil, fmt.Errorf("%w: no issuer in claim %q", errTokenInvalid, v.claim)
+	}
+
+	return &issuer, nil
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	if iss, _ := claims["iss"].(string); strings.TrimRight(iss, "/") != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", errTokenInvalid)
+	}
+
+	if v.audience == "" || !hasAudience(claims["aud"], v.audience) {
+		return fmt.Errorf("%w: unexpected audience", errTokenInvalid)
+	}
+
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: no expiry", errTokenInvalid)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return fmt.Errorf("%w: expired", errTokenInvalid)
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: not valid yet", errTokenInvalid)
+	}
+
+	return nil
+}
+
+// hasAudience returns true if 'aud' (a string or an array of strings) holds
+// 'audience'.
+func hasAudience(aud interface{}, audience string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == audience
+	case []interface{}:
+		for _, s := range a {
+			if s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: malformed", errTokenInvalid)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed", errTokenInvalid)
+	}
+	return nil
+}
+
+// verifyJWTSignature checks 'sig' is the signature of 'signed' by 'key'
+// with 'alg'. Only asymmetric algorithms are accepted.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "RS256":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match algorithm", errTokenInvalid)
+		}
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: invalid signature", errTokenInvalid)
+		}
+	case "ES256":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return fmt.Errorf("%w: key does not match algorithm", errTokenInvalid)
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return fmt.Errorf("%w: invalid signature", errTokenInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", errTokenInvalid, alg)
+	}
+
+	return nil
+}
+
+// key returns the key with ID 'kid', fetching the key set if it is not
+// cached or has expired. Concurrent callers share a single fetch, which is
+// made outside the lock and at most every jwksMinRefresh.
+func (v *JWTVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	for {
+		v.mu.Lock()
+		now := v.now()
+
+		cached, ok := v.keys[kid]
+		if ok && now.Sub(v.fetchedAt) < v.ttl {
+			v.mu.Unlock()
+			return cached, nil
+		}
+
+		// Wait for the fetch in flight and look again
+		if fetching := v.fetching; fetching != nil {
+			v.mu.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if v.attemptedAt.IsZero() || now.Sub(v.attemptedAt) >= jwksMinRefresh {
+			fetching := make(chan struct{})
+			v.fetching, v.attemptedAt = fetching, now
+			v.mu.Unlock()
+
+			// Not bound to 'ctx' as the result is shared with other callers
+			keys, err := v.fetchKeys(context.Background())
+			if err != nil {
+				log.WithFields(log.Fields{"error": err}).Warn("Error while fetching JWKS")
+			}
+
+			v.mu.Lock()
+			if err == nil {
+				v.keys, v.fetchedAt = keys, v.now()
+			}
+			v.fetchErr, v.fetching = err, nil
+			v.mu.Unlock()
+			close(fetching)
+			continue
+		}
+
+		fetchErr := v.fetchErr
+		v.mu.Unlock()
+
+		switch {
+		case ok:
+			// Keep using the expired key while the provider is unreachable
+			return cached, nil
+		case fetchErr != nil:
+			return nil, fmt.Errorf("error while fetching JWKS: %w", fetchErr)
+		}
+		return nil, fmt.Errorf("%w: unknown key %q", errTokenInvalid, kid)
+	}
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	} `json:"keys"`
+}
+
+func (v *JWTVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	jwksURL := v.jwksURL
+	if jwksURL == "" {
+		discovery := struct {
+			JWKSURI string `json:"jwks_uri"`
+		}{}
+		if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("no jwks_uri in the OpenID configuration of %s", v.issuer)
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	set := jwks{}
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+
+	return keys, nil
+}
+
+func (v *JWTVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s responded with status %d", url, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/config"
+)
+
+func TestJWTVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetches := 0
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]string{"jwks_uri": srv.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(rw http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	v := NewJWTVerifier(&config.Config{
+		JWTIssuerURL:    srv.URL,
+		JWTAudience:     "pds",
+		JWTIssuerClaim:  "flow_address",
+		JWTJWKSCacheTTL: time.Hour,
+	})
+	v.now = func() time.Time { return now }
+
+	sign := func(header, claims map[string]interface{}) string {
+		h, _ := json.Marshal(header)
+		c, _ := json.Marshal(claims)
+		signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+		digest := sha256.Sum256([]byte(signed))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	header := map[string]interface{}{"alg": "RS256", "kid": "k1"}
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":          srv.URL,
+			"aud":          []string{"other", "pds"},
+			"exp":          now.Add(time.Hour).Unix(),
+			"flow_address": "0x01",
+		}
+		for k, v := range changes {
+			c[k] = v
+		}
+		return c
+	}
+
+	issuer, err := v.Authenticate(context.Background(), sign(header, claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issuer == nil || issuer.String() != "0000000000000001" {
+		t.Fatalf("unexpected issuer %v", issuer)
+	}
+
+	for name, token := range map[string]string{
+		"expired":       sign(header, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet valid": sign(header, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"other issuer":  sign(header, claims(map[string]interface{}{"iss": "https://example.com"})),
+		"other aud":     sign(header, claims(map[string]interface{}{"aud": "other"})),
+		"no address":    sign(header, claims(map[string]interface{}{"flow_address": ""})),
+		"unknown key":   sign(map[string]interface{}{"alg": "RS256", "kid": "k2"}, claims(nil)),
+		"alg none":      sign(map[string]interface{}{"alg": "none", "kid": "k1"}, claims(nil)),
+		"malformed":     "not-a-token",
+	} {
+		if _, err := v.Authenticate(context.Background(), token); !errors.Is(err, errTokenInvalid) {
+			t.Errorf("%s: expected errTokenInvalid, got %v", name, err)
+		}
+	}
+
+	// Fetched once, the unknown key refreshes at most every jwksMinRefresh
+	if fetches != 1 {
+		t.Fatalf("expected 1 JWKS fetch, got %d", fetches)
+	}
+
+	// Tokens act for any issuer only if opted in
+	v.claim = ""
+	if _, err := v.Authenticate(context.Background(), sign(header, claims(nil))); !errors.Is(err, errTokenInvalid) {
+		t.Errorf("expected a token without issuer claim to be refused, got %v", err)
+	}
+	v.anyIssuer = true
+	if issuer, err := v.Authenticate(context.Background(), sign(header, claims(nil))); err != nil || issuer != nil {
+		t.Errorf("expected a token for any issuer, got %v %v", issuer, err)
+	}
+
+	v.audience = ""
+	if _, err := v.Authenticate(context.Background(), sign(header, claims(nil))); !errors.Is(err, errTokenInvalid) {
+		t.Errorf("expected tokens to be refused without an audience, got %v", err)
+	}
+}
+
+func TestJWTVerifierFetch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int32
+	failing := true
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		if failing {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	v := NewJWTVerifier(&config.Config{
+		JWTIssuerURL:    srv.URL,
+		JWTJWKSURL:      srv.URL,
+		JWTJWKSCacheTTL: time.Hour,
+	})
+	v.now = func() time.Time { return now }
+
+	// A failed fetch throttles retries as well
+	close(release)
+	for i := 0; i < 3; i++ {
+		if _, err := v.key(context.Background(), "k1"); err == nil || errors.Is(err, errTokenInvalid) {
+			t.Errorf("expected a fetch error, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("expected 1 JWKS fetch while the provider fails, got %d", n)
+	}
+
+	// Concurrent callers share a single fetch
+	failing = false
+	release = make(chan struct{})
+	now = now.Add(jwksMinRefresh)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.key(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+	for atomic.LoadInt32(&fetches) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected the key, got %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("expected a single JWKS fetch for concurrent callers, got %d", n-1)
+	}
+}
@@ -1,23 +1,53 @@
 package http
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
+	"github.com/google/uuid"
 	gorilla "github.com/gorilla/handlers"
+	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-func UseCors(h http.Handler) http.Handler {
-	return gorilla.CORS(gorilla.AllowedOrigins([]string{"*"}))(h)
-}
+// UseCors answers CORS requests from 'origins' ("*" for any) for 'methods'
+// with 'headers'. CORS is disabled if 'origins' is empty.
+func UseCors(origins, methods, headers []string, h http.Handler) http.Handler {
+	allowed := []string{}
+	for _, o := range origins {
+		if o = strings.TrimSpace(o); o != "" {
+			allowed = append(allowed, o)
+		}
+	}
+	if len(allowed) == 0 {
+		return h
+	}
 
-func UseLogging(out io.Writer, h http.Handler) http.Handler {
-	return gorilla.CombinedLoggingHandler(out, h)
+	return gorilla.CORS(
+		gorilla.AllowedOrigins(allowed),
+		gorilla.AllowedMethods(methods),
+		gorilla.AllowedHeaders(headers),
+		gorilla.ExposedHeaders([]string{totalCountHeader, "Retry-After", requestIDHeader}),
+	)(h)
 }
 
 func UseCompress(h http.Handler) http.Handler {
@@ -25,23 +55,443 @@ func UseCompress(h http.Handler) http.Handler {
 }
 
 func UseJson(h http.Handler) http.Handler {
-	// Only PUT, POST, and PATCH requests are considered.
-	return gorilla.ContentTypeHandler(h, "application/json")
+	// Only PUT, POST, and PATCH requests are considered. Multipart forms are
+	// for CSV uploads (/v1/distributions/bulk).
+	return gorilla.ContentTypeHandler(h, "application/json", "multipart/form-data")
+}
+
+// UseRateLimit refuses requests with '429 Too Many Requests' and a
+// 'Retry-After' header once a client exceeds the rate of 'limiter'. Clients
+// are told apart by their bearer token (API key, JWT accepted by 'jwt' or the
+// admin token) if it authenticates, otherwise by IP (see clientIP), so made
+// up tokens do not each get a limit of their own. Tokens are only checked
+// while the IP is within its limit, so made up tokens can not be used to
+// hammer the database or the identity provider either.
+func UseRateLimit(limiter *app.RateLimiter, trustForwardedFor bool, proxies config.TrustedProxies, adminToken string, a *app.App, jwt *JWTVerifier, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		refuse := func(kind string, wait time.Duration) {
+			metrics.CountRateLimited(kind)
+			rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			handleProblem(rw, http.StatusTooManyRequests, app.ErrorCodeRateLimited, "too many requests")
+		}
+
+		kind, client := metrics.RateLimitIP, clientIP(r, trustForwardedFor, proxies)
+		if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != "" {
+			if wait := limiter.Wait(kind + "/" + client); wait > 0 {
+				refuse(kind, wait)
+				return
+			}
+			if bearerTokenValid(r.Context(), token, adminToken, a, jwt) {
+				// Tokens are not kept in memory as is
+				sum := sha256.Sum256([]byte(token))
+				kind, client = metrics.RateLimitAPIKey, hex.EncodeToString(sum[:])
+			}
+		}
+
+		if wait := limiter.Allow(kind + "/" + client); wait > 0 {
+			refuse(kind, wait)
+			return
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// bearerTokenValid returns true if 'token' is the admin token, a JWT accepted
+// by 'jwt' (optional) or an API key which has not been revoked, like
+// UseAPIKeyAuth checks it.
+func bearerTokenValid(ctx context.Context, token, adminToken string, a *app.App, jwt *JWTVerifier) bool {
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+		return true
+	}
+
+	// API keys never hold a '.'
+	if jwt != nil && strings.Count(token, ".") == 2 {
+		_, err := jwt.Authenticate(ctx, token)
+		return err == nil
+	}
+
+	if a == nil {
+		return false
+	}
+	_, err := a.AuthenticateAPIKey(ctx, token)
+	return err == nil
+}
+
+// newRateLimiter returns the limiter of UseRateLimit configured by 'cfg'.
+func newRateLimiter(cfg *config.Config) *app.RateLimiter {
+	return app.NewRateLimiter(common.RealClock{}, cfg.RateLimit, cfg.RateLimitBurst)
+}
+
+// clientIP returns the IP a request came from. If 'trustForwardedFor' is set
+// it is the right-most 'X-Forwarded-For' hop not added by one of 'proxies',
+// the proxy the PDS is connected to being trusted as well. Hops left of it
+// are set by the client and can not be trusted.
+func clientIP(r *http.Request, trustForwardedFor bool, proxies config.TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !trustForwardedFor {
+		return host
+	}
+
+	hops := []string{}
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil || !proxies.Contains(ip) || i == 0 {
+			return hops[i]
+		}
+	}
+
+	return host
+}
+
+// UseAdminAuth only allows requests with an 'Authorization: Bearer <token>'
+// header matching 'token'. All requests are refused if 'token' is empty.
+func UseAdminAuth(token string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			handleProblem(rw, http.StatusForbidden, app.ErrorCodeAdminAPIDisabled, "admin API disabled")
+			return
+		}
+
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			handleProblem(rw, http.StatusUnauthorized, app.ErrorCodeUnauthorized, "unauthorized")
+			return
+		}
+
+		logAuthenticated(r, authAdmin, nil, nil)
+
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// Headers of requests signed with the shared secret of an issuer
+const (
+	requestIssuerHeader    = "X-PDS-Issuer"
+	requestTimestampHeader = callbackTimestampHeader
+	requestNonceHeader     = "X-PDS-Nonce"
+	requestSignatureHeader = callbackSignatureHeader
+)
+
+// issuerContextKey holds the issuer a request was authenticated as
+type issuerContextKey struct{}
+
+// How a request was authenticated, logged as 'auth'
+const (
+	authAdmin     = "admin"
+	authAPIKey    = "api_key"
+	authJWT       = "jwt"
+	authSignature = "signature"
+)
+
+// apiKeyContextKey holds the ID of the API key a request was authenticated
+// with, if any
+type apiKeyContextKey struct{}
+
+// UseAPIKeyAuth only allows requests with an 'Authorization: Bearer <key>'
+// header holding an API key which has not been revoked, a JWT accepted by
+// 'jwt' (optional) or the admin token. Requests with an 'X-PDS-Signature'
+// header are instead verified as signed by the issuer they name, see
+// app.SignRequest. The issuer of the key, token or signature is passed on in
+// the request context, see authorizeIssuer.
+// All requests are allowed if 'required' is false and 'jwt' is nil.
+func UseAPIKeyAuth(required bool, adminToken string, a *app.App, jwt *JWTVerifier, h http.Handler) http.Handler {
+	if !required && jwt == nil {
+		return h
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(requestSignatureHeader) != "" {
+			issuer, err := authenticateSignedRequest(r, a)
+			if err != nil {
+				handleError(rw, nil, err)
+				return
+			}
+
+			logAuthenticated(r, authSignature, &issuer, nil)
+
+			h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), issuerContextKey{}, issuer)))
+			return
+		}
+
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if given == "" {
+			handleProblem(rw, http.StatusUnauthorized, app.ErrorCodeUnauthorized, "unauthorized")
+			return
+		}
+
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(given), []byte(adminToken)) == 1 {
+			logAuthenticated(r, authAdmin, nil, nil)
+			h.ServeHTTP(rw, r)
+			return
+		}
+
+		// API keys never hold a '.'
+		if jwt != nil && strings.Count(given, ".") == 2 {
+			issuer, err := jwt.Authenticate(r.Context(), given)
+			if err != nil {
+				handleError(rw, nil, err)
+				return
+			}
+
+			logAuthenticated(r, authJWT, issuer, nil)
+
+			if issuer == nil {
+				h.ServeHTTP(rw, r)
+				return
+			}
+
+			h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), issuerContextKey{}, *issuer)))
+			return
+		}
+
+		key, err := a.AuthenticateAPIKey(r.Context(), given)
+		if err != nil {
+			handleError(rw, nil, err)
+			return
+		}
+
+		logAuthenticated(r, authAPIKey, &key.Issuer, &key.ID)
+
+		ctx := context.WithValue(r.Context(), issuerContextKey{}, key.Issuer)
+		ctx = context.WithValue(ctx, apiKeyContextKey{}, key.ID)
+		ctx = app.ContextWithAPIKey(ctx, key)
+		h.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// UseIssuerIsolation requires authentication like UseAPIKeyAuth on read
+// endpoints if 'isolation' is set. Handlers restrict their response to the
+// authenticated issuer, see authorizeDistribution and scopeDistributionFilter.
+func UseIssuerIsolation(isolation bool, adminToken string, a *app.App, jwt *JWTVerifier, h http.Handler) http.Handler {
+	if !isolation {
+		return h
+	}
+	return UseAPIKeyAuth(true, adminToken, a, jwt, h)
+}
+
+// authenticateSignedRequest verifies the signature of 'r' and returns the
+// issuer which signed it. The body is read and replaced for the handler.
+func authenticateSignedRequest(r *http.Request, a *app.App) (common.FlowAddress, error) {
+	issuer := common.FlowAddressFromString(r.Header.Get(requestIssuerHeader))
+	if flow.Address(issuer) == flow.EmptyAddress {
+		return issuer, fmt.Errorf("%w: invalid %s header", app.ErrCallbackUnauthorized, requestIssuerHeader)
+	}
+
+	body := []byte{}
+	if r.Body != nil {
+		b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize))
+		if err != nil {
+			return issuer, err
+		}
+		body = b
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err := a.AuthenticateSignedRequest(
+		r.Context(), issuer,
+		r.Header.Get(requestTimestampHeader),
+		r.Header.Get(requestNonceHeader),
+		r.Header.Get(requestSignatureHeader),
+		r.Method, r.URL.RequestURI(), body,
+	)
+
+	return issuer, err
+}
+
+// authorizeIssuer checks a request authenticated with an API key or signed
+// by an issuer acts for that issuer. Requests without one (admin token, or
+// keys not required) can act for any issuer.
+func authorizeIssuer(r *http.Request, issuer common.FlowAddress) error {
+	return authorizeIssuerContext(r.Context(), issuer)
+}
+
+// authorizeIssuerContext is like authorizeIssuer for the request context
+// 'ctx'.
+func authorizeIssuerContext(ctx context.Context, issuer common.FlowAddress) error {
+	authenticated, ok := ctx.Value(issuerContextKey{}).(common.FlowAddress)
+	if !ok || authenticated == issuer {
+		return nil
+	}
+	return app.ErrAPIKeyForbidden
+}
+
+// scopeDistributionFilter restricts 'filter' to the distributions of the
+// issuer 'r' was authenticated for, if any. Filtering by another issuer is
+// forbidden.
+func scopeDistributionFilter(r *http.Request, filter *app.DistributionFilter) error {
+	return scopeDistributionFilterContext(r.Context(), filter)
+}
+
+// scopeDistributionFilterContext is like scopeDistributionFilter for the
+// request context 'ctx'.
+func scopeDistributionFilterContext(ctx context.Context, filter *app.DistributionFilter) error {
+	authenticated, ok := ctx.Value(issuerContextKey{}).(common.FlowAddress)
+	if !ok {
+		return nil
+	}
+	if filter.Issuer != nil && *filter.Issuer != authenticated {
+		return app.ErrAPIKeyForbidden
+	}
+	filter.Issuer = &authenticated
+	return nil
+}
+
+// scopeIssuer is like scopeDistributionFilter for lists filtered by
+// 'issuer', the empty address for any.
+func scopeIssuer(r *http.Request, issuer *common.FlowAddress) error {
+	authenticated, ok := r.Context().Value(issuerContextKey{}).(common.FlowAddress)
+	if !ok {
+		return nil
+	}
+	if flow.Address(*issuer) != flow.EmptyAddress && *issuer != authenticated {
+		return app.ErrAPIKeyForbidden
+	}
+	*issuer = authenticated
+	return nil
+}
+
+// authenticatedAPIKey returns the ID of the API key 'r' was authenticated
+// with, nil if none.
+func authenticatedAPIKey(r *http.Request) *uuid.UUID {
+	if id, ok := r.Context().Value(apiKeyContextKey{}).(uuid.UUID); ok {
+		return &id
+	}
+	return nil
+}
+
+// authorizeDistribution is like authorizeIssuer for the issuer of a
+// distribution.
+func authorizeDistribution(r *http.Request, a *app.App, distributionID uuid.UUID) error {
+	if _, ok := r.Context().Value(issuerContextKey{}).(common.FlowAddress); !ok {
+		return nil
+	}
+
+	issuer, err := a.GetDistributionIssuer(r.Context(), distributionID)
+	if err != nil {
+		return err
+	}
+
+	return authorizeIssuer(r, issuer)
+}
+
+// authorizePack is like authorizeDistribution for the distribution of a
+// pack.
+func authorizePack(r *http.Request, a *app.App, packID uuid.UUID) error {
+	if _, ok := r.Context().Value(issuerContextKey{}).(common.FlowAddress); !ok {
+		return nil
+	}
+
+	pack, err := a.GetPack(r.Context(), packID)
+	if err != nil {
+		return err
+	}
+
+	return authorizeDistribution(r, a, pack.DistributionID)
+}
+
+// authorizeCollection is like authorizeDistribution for the issuer of a
+// collection.
+func authorizeCollection(r *http.Request, a *app.App, collectionID uuid.UUID) error {
+	if _, ok := r.Context().Value(issuerContextKey{}).(common.FlowAddress); !ok {
+		return nil
+	}
+
+	issuer, err := a.GetCollectionIssuer(r.Context(), collectionID)
+	if err != nil {
+		return err
+	}
+
+	return authorizeIssuer(r, issuer)
+}
+
+// authorizeDistributionTemplate is like authorizeDistribution for the issuer
+// of a distribution template.
+func authorizeDistributionTemplate(r *http.Request, a *app.App, templateID uuid.UUID) error {
+	if _, ok := r.Context().Value(issuerContextKey{}).(common.FlowAddress); !ok {
+		return nil
+	}
+
+	template, err := a.GetDistributionTemplate(r.Context(), templateID)
+	if err != nil {
+		return err
+	}
+
+	return authorizeIssuer(r, template.Issuer)
 }
 
 // handleError is a helper function for unified HTTP error handling.
+// Errors are returned as RFC 7807 problem details, see handleProblem.
 func handleError(rw http.ResponseWriter, logger *log.Logger, err error) {
 	if logger != nil {
 		logger.Error(err)
 	}
 
+	status := http.StatusBadRequest
+	switch {
 	// Check for "record not found" database error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		http.Error(rw, err.Error(), http.StatusNotFound)
-		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, app.ErrAPIKeyInvalid), errors.Is(err, errTokenInvalid):
+		status = http.StatusUnauthorized
+	case errors.Is(err, app.ErrAPIKeyForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, app.ErrCallbackUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, app.ErrCallbackReplayed), errors.Is(err, app.ErrRequestReplayed):
+		status = http.StatusConflict
+	case errors.Is(err, app.ErrIdempotencyKeyReused):
+		status = http.StatusUnprocessableEntity
+	}
+
+	code := app.ErrorCode(err)
+	if code == "" {
+		code = statusErrorCode(status)
+	}
+
+	handleProblem(rw, status, code, err.Error())
+}
+
+// handleProblem writes an 'application/problem+json' response (RFC 7807)
+// with the machine readable 'code' of the error, see app.ErrorCode.
+func handleProblem(rw http.ResponseWriter, status int, code, detail string) {
+	rw.Header().Set("Content-Type", problemContentType)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(status)
+	res := ResProblem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
 	}
+	if err := json.NewEncoder(rw).Encode(res); err != nil {
+		log.WithFields(log.Fields{"error": err}).Warn("error while encoding problem to JSON")
+	}
+}
 
-	http.Error(rw, err.Error(), http.StatusBadRequest)
+// statusErrorCode returns the code of errors with 'status' which have none.
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return app.ErrorCodeNotFound
+	case http.StatusUnauthorized:
+		return app.ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return app.ErrorCodeForbidden
+	}
+	return app.ErrorCodeInvalidRequest
 }
 
 // handleJsonResponse is a helper function for unified JSON response handling.
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"gorm.io/gorm"
+)
+
+func TestHandleErrorProblem(t *testing.T) {
+	for _, c := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{errors.New("empty body"), http.StatusBadRequest, app.ErrorCodeInvalidRequest},
+		{fmt.Errorf("get pack: %w", gorm.ErrRecordNotFound), http.StatusNotFound, app.ErrorCodeNotFound},
+		{app.ErrAPIKeyInvalid, http.StatusUnauthorized, app.ErrorCodeAPIKeyInvalid},
+		{fmt.Errorf("%w: expired", errTokenInvalid), http.StatusUnauthorized, app.ErrorCodeTokenInvalid},
+		{app.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, app.ErrorCodeIdempotencyKeyReused},
+		{&app.Error{Code: app.ErrorCodeInsufficientEscrow, Err: errors.New("collection too small")}, http.StatusBadRequest, app.ErrorCodeInsufficientEscrow},
+	} {
+		rw := httptest.NewRecorder()
+		handleError(rw, nil, c.err)
+
+		if rw.Code != c.status {
+			t.Errorf("%v: expected status %d, got %d", c.err, c.status, rw.Code)
+		}
+		if ct := rw.Header().Get("Content-Type"); ct != problemContentType {
+			t.Errorf("%v: expected content type %s, got %s", c.err, problemContentType, ct)
+		}
+
+		var res ResProblem
+		if err := json.Unmarshal(rw.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Code != c.code || res.Status != c.status || res.Detail != c.err.Error() || res.Title != http.StatusText(c.status) {
+			t.Errorf("%v: unexpected problem %+v", c.err, res)
+		}
+	}
+}
+
+func TestUseCors(t *testing.T) {
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	preflight := func(h http.Handler, origin, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodOptions, "/v1/distributions", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		r.Header.Set("Access-Control-Request-Headers", "Authorization")
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw
+	}
+
+	h := UseCors([]string{"https://dashboard.example.com"}, []string{"GET"}, []string{"Authorization"}, ok)
+
+	rw := preflight(h, "https://dashboard.example.com", http.MethodGet)
+	if rw.Code != http.StatusOK || rw.Header().Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" {
+		t.Errorf("expected preflight of allowed origin to be allowed, got %d %v", rw.Code, rw.Header())
+	}
+
+	if rw := preflight(h, "https://dashboard.example.com", http.MethodPost); rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected preflight of a method not allowed to be refused, got %d", rw.Code)
+	}
+
+	if rw := preflight(h, "https://other.example.com", http.MethodGet); rw.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected preflight of other origin not to be allowed, got %v", rw.Header())
+	}
+
+	disabled := UseCors([]string{""}, []string{"GET"}, nil, ok)
+	if rw := preflight(disabled, "https://dashboard.example.com", http.MethodGet); rw.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected CORS to be disabled, got %v", rw.Header())
+	}
+}
+
+func TestScopeDistributionFilter(t *testing.T) {
+	issuer := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+	other := common.FlowAddressFromString("01cf0e2f2f715450")
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/distributions", nil)
+
+	filter := app.DistributionFilter{}
+	if err := scopeDistributionFilter(r, &filter); err != nil || filter.Issuer != nil {
+		t.Errorf("expected unauthenticated filter to be left as is, got %v %v", filter.Issuer, err)
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), issuerContextKey{}, issuer))
+
+	if err := scopeDistributionFilter(r, &filter); err != nil || filter.Issuer == nil || *filter.Issuer != issuer {
+		t.Errorf("expected filter to be scoped to the authenticated issuer, got %v %v", filter.Issuer, err)
+	}
+
+	filter = app.DistributionFilter{Issuer: &other}
+	if err := scopeDistributionFilter(r, &filter); !errors.Is(err, app.ErrAPIKeyForbidden) {
+		t.Errorf("expected filtering by another issuer to be forbidden, got %v", err)
+	}
+}
+
+func TestUseIssuerIsolation(t *testing.T) {
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	get := func(h http.Handler, token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/distributions", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	if code := get(UseIssuerIsolation(false, "admin-token", nil, nil, ok), ""); code != http.StatusOK {
+		t.Errorf("expected reads without isolation to be public, got %d", code)
+	}
+
+	isolated := UseIssuerIsolation(true, "admin-token", nil, nil, ok)
+	if code := get(isolated, ""); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthenticated read to be refused, got %d", code)
+	}
+	if code := get(isolated, "admin-token"); code != http.StatusOK {
+		t.Errorf("expected admin token to be accepted, got %d", code)
+	}
+}
+
+func TestAuthenticateSignedRequestQuery(t *testing.T) {
+	_, a, _ := newIsolationTestApp(t)
+	issuer := common.FlowAddressFromString("f3fcd2c1a78f5eee")
+
+	secret, err := a.RotateIssuerCallbackSecret(context.Background(), issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := func(target, nonce string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		sig, err := app.SignRequest(secret.Secret, timestamp, nonce, http.MethodGet, r.URL.RequestURI(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set(requestIssuerHeader, issuer.String())
+		r.Header.Set(requestTimestampHeader, timestamp)
+		r.Header.Set(requestNonceHeader, nonce)
+		r.Header.Set(requestSignatureHeader, sig)
+		return r
+	}
+
+	if _, err := authenticateSignedRequest(signed("/v1/distributions?state=complete", "n1"), a); err != nil {
+		t.Fatalf("expected the signed request to be accepted, got %v", err)
+	}
+
+	r := signed("/v1/distributions?state=complete", "n2")
+	r.URL.RawQuery = "state=complete&issuer=01cf0e2f2f715450"
+	if _, err := authenticateSignedRequest(r, a); !errors.Is(err, app.ErrCallbackUnauthorized) {
+		t.Errorf("expected a request with a changed query to be refused, got %v", err)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := config.TrustedProxies{}
+	if err := proxies.UnmarshalText([]byte("10.0.0.0/8, 192.0.2.10")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		forwarded string
+		trust     bool
+		expected  string
+	}{
+		{"", false, "192.0.2.1"},
+		{"203.0.113.7", false, "192.0.2.1"},
+		{"", true, "192.0.2.1"},
+		{"203.0.113.7", true, "203.0.113.7"},
+		// Hops left of the one added by the proxy are set by the client
+		{"198.51.100.1, 203.0.113.7", true, "203.0.113.7"},
+		{"198.51.100.1, 203.0.113.7, 10.1.2.3, 192.0.2.10", true, "203.0.113.7"},
+		{"10.1.2.3, 192.0.2.10", true, "10.1.2.3"},
+		{"not-an-ip, 10.1.2.3", true, "not-an-ip"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/distributions", nil)
+		if c.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if ip := clientIP(r, c.trust, proxies); ip != c.expected {
+			t.Errorf("%q (trusted %v): expected %s, got %s", c.forwarded, c.trust, c.expected, ip)
+		}
+	}
+
+	if err := proxies.UnmarshalText([]byte("10.0.0.0/33")); err == nil {
+		t.Error("expected an invalid network to be refused")
+	}
+}
+
+func TestUseRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	h := UseRateLimit(app.NewRateLimiter(common.RealClock{}, 1, 1), false, nil, "admin-token", nil, nil, ok)
+
+	get := func(ip, token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/distributions", nil)
+		r.RemoteAddr = ip + ":1234"
+		r.Header.Set("Authorization", "Bearer "+token)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	if code := get("192.0.2.1", "made-up-1"); code != http.StatusOK {
+		t.Errorf("expected the first request to be allowed, got %d", code)
+	}
+	// Tokens which do not authenticate share the limit of their IP
+	if code := get("192.0.2.1", "made-up-2"); code != http.StatusTooManyRequests {
+		t.Errorf("expected another made up token to be limited by IP, got %d", code)
+	}
+	// Tokens are not checked while their IP is over its limit
+	if code := get("192.0.2.1", "admin-token"); code != http.StatusTooManyRequests {
+		t.Errorf("expected a token to be refused while its IP is limited, got %d", code)
+	}
+	if code := get("192.0.2.2", "admin-token"); code != http.StatusOK {
+		t.Errorf("expected the admin token to be allowed, got %d", code)
+	}
+	if code := get("192.0.2.2", "made-up-3"); code != http.StatusOK {
+		t.Errorf("expected the admin token to have its own limit, got %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+	"path/filepath"
+)
+
+// The API definition bundled into one JSON document by ./tools/clientgen,
+// regenerate with 'make clients' after changing ./reference or ./models.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// The Swagger UI assets are served by the PDS itself from a copy of
+// swagger-ui-dist (see 'make swagger-ui'), no third-party scripts are loaded.
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Flow PDS API</title>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <link rel="stylesheet" href="docs/assets/swagger-ui.css" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="docs/assets/swagger-ui-bundle.js"></script>
+    <script>
+      window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+    </script>
+  </body>
+</html>
+`
+
+func HandleGetOpenAPISpec() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write(openAPISpec)
+	}
+}
+
+func HandleSwaggerUI() http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte(swaggerUIPage))
+	}
+}
+
+// Files of swagger-ui-dist the page needs, nothing else in the directory is
+// served.
+var swaggerUIAssets = map[string]bool{
+	"swagger-ui.css":       true,
+	"swagger-ui-bundle.js": true,
+}
+
+func HandleSwaggerUIAssets(dir string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		name := filepath.Base(r.URL.Path)
+		if !swaggerUIAssets[name] {
+			http.NotFound(rw, r)
+			return
+		}
+		http.ServeFile(rw, r, filepath.Join(dir, name))
+	}
+}
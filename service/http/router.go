@@ -4,32 +4,166 @@ import (
 	"net/http"
 
 	"github.com/flow-hydraulics/flow-pds/service/app"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/flow-hydraulics/flow-pds/service/logging"
+	"github.com/flow-hydraulics/flow-pds/service/metrics"
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 )
 
-func NewRouter(app *app.App) http.Handler {
+// Versions of the REST API, each served under its own prefix (e.g. /v1).
+// v1 stays stable for existing issuers, breaking changes go to v2 only.
+const (
+	apiVersion1 = "v1"
+	apiVersion2 = "v2"
+)
+
+func NewRouter(cfg *config.Config, app *app.App) http.Handler {
 	r := mux.NewRouter()
 
-	requestLogger := log.New()
+	requestLogger := logging.Logger(logging.HTTP)
+
+	// Optional, accepted in place of API keys
+	jwt := NewJWTVerifier(cfg)
+
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+	r.HandleFunc("/openapi.json", HandleGetOpenAPISpec()).Methods(http.MethodGet)
+	if cfg.SwaggerUIDir != "" {
+		r.HandleFunc("/docs", HandleSwaggerUI()).Methods(http.MethodGet)
+		r.PathPrefix("/docs/assets/").Handler(HandleSwaggerUIAssets(cfg.SwaggerUIDir)).Methods(http.MethodGet)
+	}
+
+	// Not rate limited, probed by the orchestrator
+	r.HandleFunc("/healthz", HandleHealthz()).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", HandleReadyz(requestLogger, app, cfg.ReadinessCheckTimeout)).Methods(http.MethodGet)
 
-	// Catch the api version
-	rv := r.PathPrefix("/{apiVersion}").Subrouter()
+	// Shared by the versions, a client has one limit for all of them
+	var middlewares []mux.MiddlewareFunc
+	if cfg.RateLimit > 0 {
+		limiter := newRateLimiter(cfg)
+		middlewares = append(middlewares, func(h http.Handler) http.Handler {
+			return UseRateLimit(limiter, cfg.RateLimitTrustForwardedFor, cfg.RateLimitTrustedProxies, cfg.AdminAPIToken, app, jwt, h)
+		})
+	}
 
-	rv.HandleFunc("/health/ready", HandleHealthReady()).Methods(http.MethodGet)
+	for _, version := range []string{apiVersion1, apiVersion2} {
+		r.HandleFunc("/"+version+"/health/ready", HandleHealthReady()).Methods(http.MethodGet)
 
-	rv.HandleFunc("/set-dist-cap", HandleSetDistCap(requestLogger, app)).Methods(http.MethodPost)
+		rv := r.PathPrefix("/" + version).Subrouter()
+		rv.Use(middlewares...)
 
-	rv.HandleFunc("/distributions", HandleCreateDistribution(requestLogger, app)).Methods(http.MethodPost)
-	rv.HandleFunc("/distributions", HandleListDistributions(requestLogger, app)).Methods(http.MethodGet)
-	rv.HandleFunc("/distributions/{id}", HandleGetDistribution(requestLogger, app)).Methods(http.MethodGet)
-	rv.HandleFunc("/distributions/{id}/abort", HandleAbortDistribution(requestLogger, app)).Methods(http.MethodPost)
+		// Routes of v2 are matched first, replacing the v1 routes with the
+		// same path and method
+		if version == apiVersion2 {
+			handleV2Routes(rv, cfg, app, jwt, requestLogger)
+		}
+		handleV1Routes(rv, cfg, app, jwt, requestLogger)
+	}
 
 	// Use middleware
-	h := UseCors(r)
-	h = UseLogging(requestLogger.Writer(), h)
+	h := UseCors(cfg.CORSAllowedOrigins, cfg.CORSAllowedMethods, cfg.CORSAllowedHeaders, r)
+	h = UseLogging(requestLogger, h)
 	h = UseCompress(h)
 	h = UseJson(h)
 
 	return h
 }
+
+// handleV2Routes registers the routes of v2 which differ from v1.
+func handleV2Routes(rv *mux.Router, cfg *config.Config, app *app.App, jwt *JWTVerifier, requestLogger *log.Logger) {
+	rv.Handle("/distributions", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListDistributionsV2(requestLogger, app))).Methods(http.MethodGet)
+}
+
+// handleV1Routes registers the routes of v1.
+func handleV1Routes(rv *mux.Router, cfg *config.Config, app *app.App, jwt *JWTVerifier, requestLogger *log.Logger) {
+	rv.HandleFunc("/stats", HandleGetPublicStats(requestLogger, app)).Methods(http.MethodGet)
+
+	rv.Handle("/system/config", UseAdminAuth(cfg.AdminAPIToken, HandleGetSystemConfig(cfg))).Methods(http.MethodGet)
+	rv.Handle("/transactions/dead-letter", UseAdminAuth(cfg.AdminAPIToken, HandleListDeadLetterTransactions(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/transactions/{id}/requeue", UseAdminAuth(cfg.AdminAPIToken, HandleRequeueTransaction(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/transactions", UseAdminAuth(cfg.AdminAPIToken, HandleListTransactionAudit(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/keys/rotate-and-freeze", UseAdminAuth(cfg.AdminAPIToken, HandleRotateAndFreeze(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/sending/freeze", UseAdminAuth(cfg.AdminAPIToken, HandleFreezeSending(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/sending/unfreeze", UseAdminAuth(cfg.AdminAPIToken, HandleUnfreezeSending(requestLogger, app))).Methods(http.MethodPost)
+
+	rv.Handle("/set-dist-cap", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleSetDistCap(requestLogger, app))).Methods(http.MethodPost)
+
+	rv.HandleFunc("/accounts/{address}/collectibles", HandleListOwnedCollectibles(requestLogger, app)).Methods(http.MethodGet)
+	rv.HandleFunc("/owners/{address}/packs", HandleListOwnerPacks(requestLogger, app)).Methods(http.MethodGet)
+
+	rv.Handle("/issuers", UseAdminAuth(cfg.AdminAPIToken, HandleCreateIssuer(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/issuers", UseAdminAuth(cfg.AdminAPIToken, HandleListIssuers(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}", UseAdminAuth(cfg.AdminAPIToken, HandleGetIssuer(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}", UseAdminAuth(cfg.AdminAPIToken, HandleUpdateIssuer(requestLogger, app))).Methods(http.MethodPut)
+	rv.Handle("/issuers/{address}/branding", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleSetIssuerBranding(requestLogger, app))).Methods(http.MethodPut)
+	rv.HandleFunc("/issuers/{address}/branding", HandleGetIssuerBranding(requestLogger, app)).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}/api-keys", UseAdminAuth(cfg.AdminAPIToken, HandleCreateAPIKey(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/issuers/{address}/api-keys", UseAdminAuth(cfg.AdminAPIToken, HandleListAPIKeys(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}/api-keys/{id}/revoke", UseAdminAuth(cfg.AdminAPIToken, HandleRevokeAPIKey(requestLogger, app))).Methods(http.MethodPost)
+	// Webhooks receive all events of the issuer, always authenticated
+	rv.Handle("/issuers/{address}/webhooks", UseAPIKeyAuth(true, cfg.AdminAPIToken, app, jwt, HandleCreateIssuerWebhook(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/issuers/{address}/webhooks", UseAPIKeyAuth(true, cfg.AdminAPIToken, app, jwt, HandleListIssuerWebhooks(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}/webhooks/{id}", UseAPIKeyAuth(true, cfg.AdminAPIToken, app, jwt, HandleDeleteIssuerWebhook(requestLogger, app))).Methods(http.MethodDelete)
+	rv.Handle("/issuers/{address}/callback-secret", UseAdminAuth(cfg.AdminAPIToken, HandleRotateIssuerCallbackSecret(requestLogger, app))).Methods(http.MethodPost)
+	rv.HandleFunc("/issuers/{address}/callbacks", HandleReceiveIssuerCallback(requestLogger, app)).Methods(http.MethodPost)
+	rv.Handle("/issuers/{address}/callbacks", UseAdminAuth(cfg.AdminAPIToken, HandleListIssuerCallbacks(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/issuers/{address}/public-stats", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleSetPublicStatsOptIn(requestLogger, app))).Methods(http.MethodPut)
+
+	rv.Handle("/packs/by-commitment-hash/{hash}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetPackByCommitmentHash(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/packs/by-flow-id/{flowID}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetPackByFlowID(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/packs/{id}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetPack(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/packs/{id}/proof", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetPackProof(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/packs/{id}/collectible-ids", UseAdminAuth(cfg.AdminAPIToken, HandleReserveCollectibleIDs(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/packs/{id}/collectible-ids", UseAdminAuth(cfg.AdminAPIToken, HandleListCollectibleIDReservations(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/packs/{id}/force-reveal", UseAdminAuth(cfg.AdminAPIToken, HandleForceRevealPack(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/packs/{id}/force-open", UseAdminAuth(cfg.AdminAPIToken, HandleForceOpenPack(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/packs/{id}/revoke", UseAdminAuth(cfg.AdminAPIToken, HandleRevokePack(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/packs/{id}/replace", UseAdminAuth(cfg.AdminAPIToken, HandleReplacePack(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/packs/{id}/replacement", UseAdminAuth(cfg.AdminAPIToken, HandleGetPackReplacement(requestLogger, app))).Methods(http.MethodGet)
+
+	rv.Handle("/collections", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCreateCollection(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/collections", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListCollections(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/collections/{id}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetCollection(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/collections/{id}/distributions", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListCollectionDistributions(requestLogger, app))).Methods(http.MethodGet)
+
+	rv.Handle("/distribution-templates", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCreateDistributionTemplate(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distribution-templates", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListDistributionTemplates(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distribution-templates/{id}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistributionTemplate(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distribution-templates/{id}", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleUpdateDistributionTemplate(requestLogger, app))).Methods(http.MethodPut)
+	rv.Handle("/distribution-templates/{id}", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleDeleteDistributionTemplate(requestLogger, app))).Methods(http.MethodDelete)
+
+	rv.Handle("/distributions", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCreateDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/bulk", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCreateDistributions(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListDistributions(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistribution(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleUpdateDistribution(requestLogger, app))).Methods(http.MethodPatch)
+	rv.Handle("/distributions/{id}", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleAbortDistribution(requestLogger, app))).Methods(http.MethodDelete)
+	rv.Handle("/distributions/{id}/packs", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListDistributionPacks(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/events", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleDistributionEvents(requestLogger, app, cfg.DistributionEventsInterval))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/abort", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleAbortDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/retry", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleRetryDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/pause", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandlePauseDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/resume", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleResumeDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/archive", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleArchiveDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/clone", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCloneDistribution(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/packs/reveal", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleRevealPacks(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/reveal-schedule", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleScheduleReveals(requestLogger, app))).Methods(http.MethodPut)
+	rv.Handle("/distributions/{id}/collectible-ownership", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetCollectibleOwnershipCheck(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/ownership-verifications", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleStartOwnershipVerification(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/ownership-verifications/{verificationID}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetOwnershipVerification(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/escrow-surplus", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetEscrowSurplus(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/escrow-surplus/return", UseAdminAuth(cfg.AdminAPIToken, HandleReturnEscrowSurplus(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/report", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetCompletionReport(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/export", UseAPIKeyAuth(cfg.APIKeysRequired || cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleExportDistribution(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/costs", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistributionCosts(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/summary", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistributionSummary(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/eta", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistributionETA(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/resolution", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetDistributionResolution(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/gift-intents", UseAPIKeyAuth(cfg.APIKeysRequired, cfg.AdminAPIToken, app, jwt, HandleCreateGiftIntents(requestLogger, app))).Methods(http.MethodPost)
+	rv.Handle("/distributions/{id}/gift-intents", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleListGiftIntents(requestLogger, app))).Methods(http.MethodGet)
+	rv.Handle("/distributions/{id}/gift-intents/{giftIntentID}", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGetGiftIntent(requestLogger, app))).Methods(http.MethodGet)
+
+	graphQLSchema := newGraphQLSchema(app)
+	rv.Handle("/graphql", UseIssuerIsolation(cfg.IssuerIsolation, cfg.AdminAPIToken, app, jwt, HandleGraphQL(requestLogger, graphQLSchema))).Methods(http.MethodGet, http.MethodPost)
+	rv.HandleFunc("/graphql/schema", HandleGetGraphQLSchema(graphQLSchema)).Methods(http.MethodGet)
+}
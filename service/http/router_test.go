@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestRouterVersions(t *testing.T) {
+	h := NewRouter(&config.Config{}, nil)
+
+	for path, status := range map[string]int{
+		"/v1/health/ready":  http.StatusOK,
+		"/v2/health/ready":  http.StatusOK,
+		"/v3/health/ready":  http.StatusNotFound,
+		"/v3/distributions": http.StatusNotFound,
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		if rw.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, rw.Code)
+		}
+	}
+}
+
+func TestRouterSwaggerUI(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"swagger-ui.css", "swagger-ui-bundle.js", "index.html"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		return rw
+	}
+
+	if rw := get(NewRouter(&config.Config{}, nil), "/docs"); rw.Code != http.StatusNotFound {
+		t.Errorf("expected the docs not to be served without the assets, got %d", rw.Code)
+	}
+
+	h := NewRouter(&config.Config{SwaggerUIDir: dir}, nil)
+
+	rw := get(h, "/docs")
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected the docs to be served, got %d", rw.Code)
+	}
+	if strings.Contains(rw.Body.String(), "://") {
+		t.Error("expected the page to load no assets from other origins")
+	}
+
+	for path, status := range map[string]int{
+		"/docs/assets/swagger-ui.css":       http.StatusOK,
+		"/docs/assets/swagger-ui-bundle.js": http.StatusOK,
+		"/docs/assets/index.html":           http.StatusNotFound,
+		"/docs/assets/":                     http.StatusNotFound,
+	} {
+		if rw := get(h, path); rw.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, rw.Code)
+		}
+	}
+}
+
+func TestRouterOpenAPISpec(t *testing.T) {
+	spec := struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// Path parameters are named differently in the spec
+	params := regexp.MustCompile(`{[^}]+}`)
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for _, method := range methods {
+			if _, ok := operations[strings.ToLower(method)]; ok {
+				documented[method+" "+params.ReplaceAllString(path, "{}")] = true
+			}
+		}
+	}
+
+	rv := mux.NewRouter()
+	rv.HandleFunc("/health/ready", HandleHealthReady()).Methods(http.MethodGet)
+	handleV1Routes(rv, &config.Config{}, nil, nil, log.New())
+
+	routed := make(map[string]bool)
+	err := rv.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routed[method+" "+params.ReplaceAllString(path, "{}")] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for route := range routed {
+		if !documented[route] {
+			t.Errorf("%s: routed but not in the API definition", route)
+		}
+	}
+	for route := range documented {
+		if !routed[route] {
+			t.Errorf("%s: in the API definition but not routed", route)
+		}
+	}
+}
+
+func TestRouterIssuerWebhooksAuth(t *testing.T) {
+	// Authentication is not required for other endpoints
+	h := NewRouter(&config.Config{}, nil)
+
+	for _, c := range []struct{ method, path string }{
+		{http.MethodPost, "/v1/issuers/01cf0e2f2f715450/webhooks"},
+		{http.MethodGet, "/v1/issuers/01cf0e2f2f715450/webhooks"},
+		{http.MethodDelete, "/v1/issuers/01cf0e2f2f715450/webhooks/0d5d7e7c-5e8f-4d6c-9a57-2f6e8d0f1a01"},
+	} {
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		if rw.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status %d, got %d", c.method, c.path, http.StatusUnauthorized, rw.Code)
+		}
+	}
+}
@@ -2,10 +2,13 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/app"
@@ -20,7 +23,7 @@ type Server struct {
 
 func NewServer(cfg *config.Config, app *app.App) *Server {
 
-	r := NewRouter(app)
+	r := NewRouter(cfg, app)
 
 	// Server boilerplate
 	srv := &http.Server{
@@ -30,32 +33,45 @@ func NewServer(cfg *config.Config, app *app.App) *Server {
 		ReadTimeout:  15 * time.Minute,
 	}
 
+	// Shutdown does not wait for event streams to end on their own
+	shutdown := make(chan struct{})
+	srv.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), shutdownContextKey{}, (<-chan struct{})(shutdown))
+	}
+	srv.RegisterOnShutdown(func() { close(shutdown) })
+
 	return &Server{srv, cfg}
 }
 
+type shutdownContextKey struct{}
+
+// shutdownSignal returns a channel closed when the server serving the
+// request of 'ctx' is shutting down, nil if not served by a Server.
+func shutdownSignal(ctx context.Context) <-chan struct{} {
+	c, _ := ctx.Value(shutdownContextKey{}).(<-chan struct{})
+	return c
+}
+
+// ListenAndServe serves in a goroutine until Shutdown is called.
 func (s *Server) ListenAndServe() {
-	// Run our server in a goroutine so that it doesn't block.
 	go func() {
 		log.Infof("Server listening on %s:%d", s.cfg.Host, s.cfg.Port)
-		log.Error(s.Server.ListenAndServe())
+		if err := s.Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err)
+		}
 	}()
+}
 
-	// Trap interupt or sigterm and gracefully shutdown the server
-	c := make(chan os.Signal, 1)
-	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
-	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
-	signal.Notify(c, os.Interrupt)
-
-	// Block until we receive our signal.
-	sig := <-c
-
-	log.Infof("Got signal: %s. Shutting down...", sig)
-
-	// Create a deadline to wait for.
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
-	defer cancel()
+// Shutdown stops accepting requests and waits for running ones until 'ctx'
+// is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.Server.Shutdown(ctx)
+}
 
-	if err := s.Server.Shutdown(ctx); err != nil {
-		log.Fatalf("Error in server shutdown: %s", err)
-	}
+// WaitForSignal blocks until SIGINT (Ctrl+C) or SIGTERM is received.
+func WaitForSignal() os.Signal {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+	return <-c
 }
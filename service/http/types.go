@@ -1,11 +1,17 @@
 package http
 
 import (
+	"encoding/json"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/flow-hydraulics/flow-pds/service/app"
 	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/transactions"
 	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+	"gorm.io/datatypes"
 )
 
 type ReqSetDistCap struct {
@@ -13,27 +19,142 @@ type ReqSetDistCap struct {
 }
 
 type ReqCreateDistribution struct {
-	FlowID       common.FlowID      `json:"distFlowID"`
-	Issuer       common.FlowAddress `json:"issuer"`
-	PackTemplate ReqPackTemplate    `json:"packTemplate"`
+	FlowID        common.FlowID      `json:"distFlowID"`
+	Issuer        common.FlowAddress `json:"issuer"`
+	PackTemplate  ReqPackTemplate    `json:"packTemplate"`
+	AccessAPIHost string             `json:"accessAPIHost,omitempty"`
+
+	// Optional, receives the 'pack.teased' and 'pack.revealed' events
+	RevealWebhookURL string `json:"revealWebhookURL,omitempty"`
+
+	// Optional, the policies of the collection are used where left out
+	CollectionID *uuid.UUID `json:"collectionID,omitempty"`
+
+	// Optional, the template fills in what is left out, the buckets only give
+	// their collectibles
+	TemplateID *uuid.UUID `json:"templateID,omitempty"`
+
+	// Optional, the distribution stays 'scheduled' and is not set up before this
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+
+	// Optional, the packs are resolved from a seed anchored on-chain with a
+	// commit-reveal scheme
+	SeedCommitReveal bool `json:"seedCommitReveal,omitempty"`
+
+	// Optional, the commitment hash scheme of the packs, defaults to the
+	// current one
+	CommitmentHashVersion uint `json:"commitmentHashVersion,omitempty"`
+
+	// Optional, display metadata of the distribution
+	Metadata *ReqDistributionMetadata `json:"metadata,omitempty"`
+}
+
+type ReqDistributionMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURI    string `json:"imageURI,omitempty"`
 }
 
 type ReqPackTemplate struct {
-	PackReference AddressLocation `json:"packReference"`
-	PackCount     uint            `json:"packCount"`
-	Buckets       []ReqBucket     `json:"buckets"`
+	PackReference   AddressLocation `json:"packReference"`
+	PackCount       uint            `json:"packCount"`
+	Buckets         []ReqBucket     `json:"buckets"`
+	RevealNotBefore *time.Time      `json:"revealNotBefore,omitempty"`
+	// Optional, discloses the tier of each slot from then on
+	TeaseNotBefore *time.Time `json:"teaseNotBefore,omitempty"`
 
-	// This is here to provide compatibility between backend and onchain contracts.
-	// Backend handles CollectibleReferences per bucket but onchain contracts
-	// currently handle CollectibleReferences per distribution.
+	// Default CollectibleReference of buckets. Buckets may override it, a pack
+	// then holds collectibles of several contracts which are released to the
+	// matching collections of the owner when opened. The issuer has to share
+	// a withdraw capability for each contract when creating the distribution
+	// onchain (create_multi_collection_distribution.cdc).
 	CollectibleReference AddressLocation `json:"collectibleReference"`
+
+	// Optional, a fungible token amount in each pack. The issuer has to share
+	// a withdraw capability for the token when creating the distribution
+	// onchain (create_distribution_with_fungible_tokens.cdc).
+	FungibleToken *FungibleTokenAmount `json:"fungibleToken,omitempty"`
+}
+
+type FungibleTokenAmount struct {
+	TokenReference AddressLocation `json:"tokenReference"`
+	Amount         TokenAmount     `json:"amount"`
+	ReceiverPath   string          `json:"receiverPath"` // e.g. 'flowTokenReceiver'
+}
+
+// TokenAmount is an amount of a fungible token in 1e-8 units, a UFix64
+// decimal string (e.g. "5.0") in JSON.
+type TokenAmount uint64
+
+func (a TokenAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(app.FungibleTokenAmountString(uint64(a)))
+}
+
+func (a *TokenAmount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := app.FungibleTokenAmountFromString(s)
+	if err != nil {
+		return err
+	}
+	*a = TokenAmount(v)
+	return nil
 }
 
 type ReqBucket struct {
-	// NOTE: read about compatibility above
-	// CollectibleReference  AddressLocation   `json:"collectibleReference"`
+	// Optional, overrides the CollectibleReference of the pack template
+	// NOTE: read about multiple collectible contracts above
+	CollectibleReference  *AddressLocation  `json:"collectibleReference,omitempty"`
 	CollectibleCount      uint              `json:"collectibleCount"`
 	CollectibleCollection common.FlowIDList `json:"collectibleCollection"`
+	// Optional, collectibles of the collection by tier (e.g. rarity)
+	CollectibleTiers map[string]common.FlowIDList `json:"collectibleTiers,omitempty"`
+	// Optional, slots are filled by tier in proportion to these weights
+	TierWeights map[string]uint `json:"tierWeights,omitempty"`
+	// Optional, number of slots of each pack guaranteed to a tier
+	GuaranteedTiers map[string]uint `json:"guaranteedTiers,omitempty"`
+}
+
+// Fields left out are not changed
+type ReqUpdateDistribution struct {
+	PackTemplate      *ReqUpdatePackTemplate   `json:"packTemplate,omitempty"`
+	AccessAPIHost     *string                  `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL  *string                  `json:"revealWebhookURL,omitempty"`
+	SettlementStartAt *time.Time               `json:"settlementStartAt,omitempty"`
+	Metadata          *ReqDistributionMetadata `json:"metadata,omitempty"` // Replaces the metadata
+}
+
+type ReqUpdatePackTemplate struct {
+	PackReference   *AddressLocation `json:"packReference,omitempty"`
+	PackCount       *uint            `json:"packCount,omitempty"`
+	Buckets         []ReqBucket      `json:"buckets,omitempty"` // Replaces all buckets
+	RevealNotBefore *time.Time       `json:"revealNotBefore,omitempty"`
+	TeaseNotBefore  *time.Time       `json:"teaseNotBefore,omitempty"`
+
+	// Default CollectibleReference of 'Buckets'
+	CollectibleReference AddressLocation `json:"collectibleReference"`
+}
+
+type ReqCloneDistribution struct {
+	FlowID common.FlowID `json:"distFlowID"`
+}
+
+type ReqRevealPacks struct {
+	PackIDs []uuid.UUID `json:"packIDs"`
+	Open    bool        `json:"open"`
+}
+
+type ResBulkReveal struct {
+	PackID        uuid.UUID `json:"packID"`
+	TransactionID uuid.UUID `json:"transactionID"`
+	Action        string    `json:"action"`
+}
+
+// A null revealNotBefore opens the reveals at once.
+type ReqScheduleReveals struct {
+	RevealNotBefore *time.Time `json:"revealNotBefore"`
 }
 
 type ResCreateDistribution struct {
@@ -42,33 +163,589 @@ type ResCreateDistribution struct {
 }
 
 type ResGetDistribution struct {
+	ID             uuid.UUID                `json:"distID"`
+	FlowID         common.FlowID            `json:"distFlowID"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	UpdatedAt      time.Time                `json:"updatedAt"`
+	Issuer         common.FlowAddress       `json:"issuer"`
+	State          common.DistributionState `json:"state"`
+	PackTemplate   ResPackTemplate          `json:"packTemplate"`
+	AccessAPIHost  string                   `json:"accessAPIHost,omitempty"`
+	IssuerBranding *ResIssuerBranding       `json:"issuerBranding,omitempty"`
+	Metadata       *ResDistributionMetadata `json:"metadata,omitempty"`
+
+	RevealWebhookURL string                   `json:"revealWebhookURL,omitempty"`
+	TeasedAt         *time.Time               `json:"teasedAt,omitempty"`
+	CollectionID     *uuid.UUID               `json:"collectionID,omitempty"`
+	TemplateID       *uuid.UUID               `json:"templateID,omitempty"`
+	ArchivedAt       *time.Time               `json:"archivedAt,omitempty"`
+	PausedAt         *time.Time               `json:"pausedAt,omitempty"`
+	StalledState     common.DistributionState `json:"stalledState,omitempty"`
+
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+
+	SeedCommitReveal  bool   `json:"seedCommitReveal,omitempty"`
+	SeedAnchorHeight  uint64 `json:"seedAnchorHeight,omitempty"`
+	SeedAnchorBlockID string `json:"seedAnchorBlockID,omitempty"`
+
+	CommitmentHashVersion uint `json:"commitmentHashVersion"`
+}
+
+type ResDistributionMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURI    string `json:"imageURI,omitempty"`
+	OnChain     bool   `json:"onChain"`
+}
+
+type ResListDistribution struct {
 	ID           uuid.UUID                `json:"distID"`
 	FlowID       common.FlowID            `json:"distFlowID"`
 	CreatedAt    time.Time                `json:"createdAt"`
 	UpdatedAt    time.Time                `json:"updatedAt"`
 	Issuer       common.FlowAddress       `json:"issuer"`
 	State        common.DistributionState `json:"state"`
-	PackTemplate ResPackTemplate          `json:"packTemplate"`
+	CollectionID *uuid.UUID               `json:"collectionID,omitempty"`
+	ArchivedAt   *time.Time               `json:"archivedAt,omitempty"`
+	PausedAt     *time.Time               `json:"pausedAt,omitempty"`
+
+	SettlementStartAt *time.Time `json:"settlementStartAt,omitempty"`
+
+	Metadata *ResDistributionMetadata `json:"metadata,omitempty"`
 }
 
-type ResListDistribution struct {
-	ID        uuid.UUID                `json:"distID"`
-	FlowID    common.FlowID            `json:"distFlowID"`
-	CreatedAt time.Time                `json:"createdAt"`
-	UpdatedAt time.Time                `json:"updatedAt"`
-	Issuer    common.FlowAddress       `json:"issuer"`
-	State     common.DistributionState `json:"state"`
+// A page of distributions (v2)
+type ResDistributionPage struct {
+	Items      []ResListDistribution `json:"items"`
+	NextCursor string                `json:"nextCursor,omitempty"` // Empty after the last page
+}
+
+type ResListPack struct {
+	ID             uuid.UUID          `json:"packID"`
+	FlowID         common.FlowID      `json:"flowID"`
+	State          common.PackState   `json:"state"`
+	CommitmentHash common.BinaryValue `json:"commitmentHash"`
+}
+
+type ResOwnedPack struct {
+	ID             uuid.UUID          `json:"packID"`
+	DistributionID uuid.UUID          `json:"distID"`
+	PackReference  AddressLocation    `json:"packReference"`
+	FlowID         common.FlowID      `json:"flowID"`
+	State          common.PackState   `json:"state"`
+	CommitmentHash common.BinaryValue `json:"commitmentHash"`
 }
 
 type ResPackTemplate struct {
-	PackReference AddressLocation `json:"packReference"`
-	PackCount     uint            `json:"packCount"`
-	Buckets       []ResBucket     `json:"buckets"`
+	PackReference   AddressLocation `json:"packReference"`
+	PackCount       uint            `json:"packCount"`
+	Buckets         []ResBucket     `json:"buckets"`
+	RevealNotBefore *time.Time      `json:"revealNotBefore,omitempty"`
+	TeaseNotBefore  *time.Time      `json:"teaseNotBefore,omitempty"`
+
+	FungibleToken *FungibleTokenAmount `json:"fungibleToken,omitempty"`
 }
 
 type ResBucket struct {
+	CollectibleReference AddressLocation              `json:"collectibleReference"`
+	CollectibleCount     uint                         `json:"collectibleCount"`
+	CollectibleTiers     map[string]common.FlowIDList `json:"collectibleTiers,omitempty"`
+	TierWeights          map[string]uint              `json:"tierWeights,omitempty"`
+	GuaranteedTiers      map[string]uint              `json:"guaranteedTiers,omitempty"`
+}
+
+type ResPack struct {
+	ID             uuid.UUID          `json:"packID"`
+	DistributionID uuid.UUID          `json:"distID"`
+	FlowID         common.FlowID      `json:"flowID"`
+	State          common.PackState   `json:"state"`
+	CommitmentHash common.BinaryValue `json:"commitmentHash"`
+	RevokedAt      *time.Time         `json:"revokedAt,omitempty"`
+	ReplacesPackID *uuid.UUID         `json:"replacesPackID,omitempty"`
+	IssuerBranding *ResIssuerBranding `json:"issuerBranding,omitempty"`
+
+	// Tier of each slot, once teased
+	Teaser []string `json:"teaser,omitempty"`
+	// Collectibles of the pack, once revealed
+	Collectibles []string `json:"collectibles,omitempty"`
+}
+
+type ResPackProof struct {
+	ID                    uuid.UUID          `json:"packID"`
+	FlowID                common.FlowID      `json:"flowID"`
+	PackReference         AddressLocation    `json:"packReference"`
+	CommitmentHash        common.BinaryValue `json:"commitmentHash"`
+	CommitmentHashVersion uint               `json:"commitmentHashVersion"`
+	Algorithm             string             `json:"algorithm"`
+	Salt                  common.BinaryValue `json:"salt"`
+	Collectibles          []string           `json:"collectibles"`
+	Preimage              string             `json:"preimage"`
+	ComputedHash          common.BinaryValue `json:"computedHash"`
+	Verified              bool               `json:"verified"`
+}
+
+type ResEscrowSurplus struct {
+	DistributionID     uuid.UUID `json:"distID"`
+	Collectibles       []string  `json:"collectibles"`
+	QueuedTransactions int       `json:"queuedTransactions"`
+}
+
+type ReqRevokePack struct {
+	Reason string `json:"reason"`
+}
+
+type ResPackRevocation struct {
+	ID            uuid.UUID     `json:"packID"`
+	FlowID        common.FlowID `json:"flowID"`
+	RevokedAt     time.Time     `json:"revokedAt"`
+	Reason        string        `json:"reason"`
+	TransactionID *uuid.UUID    `json:"transactionID,omitempty"`
+}
+
+type ReqReplacePack struct {
+	Reason string `json:"reason"`
+}
+
+type ResPackReplacement struct {
+	DistributionID    uuid.UUID  `json:"distID"`
+	OriginalPackID    uuid.UUID  `json:"originalPackID"`
+	ReplacementPackID uuid.UUID  `json:"replacementPackID"`
+	Reason            string     `json:"reason"`
+	MintTransactionID uuid.UUID  `json:"mintTransactionID"`
+	Complete          bool       `json:"complete"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	CompletedAt       *time.Time `json:"completedAt,omitempty"`
+}
+
+type ReqDistributionTemplate struct {
+	Issuer      common.FlowAddress `json:"issuer"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+
+	PackReference *AddressLocation    `json:"packReference,omitempty"`
+	PackCount     uint                `json:"packCount,omitempty"`
+	Buckets       []ReqTemplateBucket `json:"buckets"`
+
+	// Default CollectibleReference of buckets
+	CollectibleReference *AddressLocation `json:"collectibleReference,omitempty"`
+
+	AccessAPIHost    string     `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string     `json:"revealWebhookURL,omitempty"`
+	CollectionID     *uuid.UUID `json:"collectionID,omitempty"`
+}
+
+type ReqTemplateBucket struct {
+	// Optional, overrides the CollectibleReference of the template
+	CollectibleReference *AddressLocation `json:"collectibleReference,omitempty"`
+	CollectibleCount     uint             `json:"collectibleCount"`
+}
+
+type ResDistributionTemplate struct {
+	ID          uuid.UUID          `json:"templateID"`
+	Issuer      common.FlowAddress `json:"issuer"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+
+	PackReference *AddressLocation    `json:"packReference,omitempty"`
+	PackCount     uint                `json:"packCount,omitempty"`
+	Buckets       []ResTemplateBucket `json:"buckets"`
+
+	AccessAPIHost    string     `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL string     `json:"revealWebhookURL,omitempty"`
+	CollectionID     *uuid.UUID `json:"collectionID,omitempty"`
+}
+
+type ResTemplateBucket struct {
+	CollectibleReference *AddressLocation `json:"collectibleReference,omitempty"`
+	CollectibleCount     uint             `json:"collectibleCount"`
+}
+
+type ReqCreateCollection struct {
+	Issuer      common.FlowAddress `json:"issuer"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+
+	// Shared policies, used by distributions of the collection which leave them out
+	PackReference        *AddressLocation `json:"packReference,omitempty"`
+	CollectibleReference *AddressLocation `json:"collectibleReference,omitempty"`
+	AccessAPIHost        string           `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL     string           `json:"revealWebhookURL,omitempty"`
+}
+
+type ResCollection struct {
+	ID          uuid.UUID          `json:"collectionID"`
+	Issuer      common.FlowAddress `json:"issuer"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+
+	PackReference        *AddressLocation `json:"packReference,omitempty"`
+	CollectibleReference *AddressLocation `json:"collectibleReference,omitempty"`
+	AccessAPIHost        string           `json:"accessAPIHost,omitempty"`
+	RevealWebhookURL     string           `json:"revealWebhookURL,omitempty"`
+
+	// Only included when getting a single collection
+	Stats *ResCollectionStats `json:"stats,omitempty"`
+}
+
+type ResCollectionStats struct {
+	DistributionCount    uint                              `json:"distributionCount"`
+	DistributionsByState map[common.DistributionState]uint `json:"distributionsByState"`
+	PackCount            uint                              `json:"packCount"`
+	SealedCount          uint                              `json:"sealedCount"`
+	RevealedCount        uint                              `json:"revealedCount"`
+	OpenedCount          uint                              `json:"openedCount"`
+}
+
+type ReqCreateIssuer struct {
+	Address common.FlowAddress `json:"address"`
+	ReqUpdateIssuer
+}
+
+type ReqUpdateIssuer struct {
+	Name                        string            `json:"name"`
+	AllowedCollectibleContracts []AddressLocation `json:"allowedCollectibleContracts"`
+}
+
+type ResIssuer struct {
+	Address                     common.FlowAddress `json:"address"`
+	Name                        string             `json:"name"`
+	AllowedCollectibleContracts []AddressLocation  `json:"allowedCollectibleContracts"`
+	CreatedAt                   time.Time          `json:"createdAt"`
+	UpdatedAt                   time.Time          `json:"updatedAt"`
+}
+
+type ReqCreateAPIKey struct {
+	Name                        string            `json:"name"`
+	AllowedCollectibleContracts []AddressLocation `json:"allowedCollectibleContracts,omitempty"`
+}
+
+type ResAPIKey struct {
+	ID        uuid.UUID          `json:"id"`
+	Issuer    common.FlowAddress `json:"issuer"`
+	Name      string             `json:"name"`
+	Prefix    string             `json:"prefix"`
+	CreatedAt time.Time          `json:"createdAt"`
+	RevokedAt *time.Time         `json:"revokedAt,omitempty"`
+
+	AllowedCollectibleContracts []AddressLocation `json:"allowedCollectibleContracts,omitempty"`
+
+	// Only returned when created
+	Key string `json:"key,omitempty"`
+}
+
+type ReqCreateIssuerWebhook struct {
+	URL string `json:"url"`
+}
+
+type ResIssuerWebhook struct {
+	ID        uuid.UUID          `json:"id"`
+	Issuer    common.FlowAddress `json:"issuer"`
+	URL       string             `json:"url"`
+	APIKeyID  *uuid.UUID         `json:"apiKeyID,omitempty"`
+	CreatedAt time.Time          `json:"createdAt"`
+
+	// Only returned when created
+	Secret string `json:"secret,omitempty"`
+}
+
+type ReqIssuerBranding struct {
+	DisplayName string `json:"displayName"`
+	LogoURI     string `json:"logoURI,omitempty"`
+	SupportURL  string `json:"supportURL,omitempty"`
+}
+
+type ResIssuerCallbackSecret struct {
+	Issuer    common.FlowAddress `json:"issuer"`
+	Secret    string             `json:"secret"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
+
+type ReqIssuerCallback struct {
+	CallbackID string          `json:"callbackID"`
+	Event      string          `json:"event"`
+	PackID     *uuid.UUID      `json:"packID,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+type ResIssuerCallback struct {
+	ID         uuid.UUID          `json:"id"`
+	Issuer     common.FlowAddress `json:"issuer"`
+	CallbackID string             `json:"callbackID"`
+	Event      string             `json:"event"`
+	PackID     *uuid.UUID         `json:"packID,omitempty"`
+	Data       json.RawMessage    `json:"data,omitempty"`
+	SignedAt   time.Time          `json:"signedAt"`
+	CreatedAt  time.Time          `json:"createdAt"`
+}
+
+type ReqReserveCollectibleIDs struct {
 	CollectibleReference AddressLocation `json:"collectibleReference"`
-	CollectibleCount     uint            `json:"collectibleCount"`
+	Count                int             `json:"count"`
+}
+
+type ResCollectibleIDReservation struct {
+	PackID               uuid.UUID       `json:"packID"`
+	CollectibleReference AddressLocation `json:"collectibleReference"`
+	CollectibleIDs       []common.FlowID `json:"collectibleIDs"`
+	CreatedAt            time.Time       `json:"createdAt"`
+}
+
+type ResIssuerBranding struct {
+	Issuer      common.FlowAddress `json:"issuer"`
+	DisplayName string             `json:"displayName"`
+	LogoURI     string             `json:"logoURI,omitempty"`
+	SupportURL  string             `json:"supportURL,omitempty"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+type ReqPublicStatsOptIn struct {
+	OptIn bool `json:"optIn"`
+}
+
+type ResPublicStats struct {
+	PacksMinted            *uint     `json:"packsMinted,omitempty"`
+	DistributionsCompleted *uint     `json:"distributionsCompleted,omitempty"`
+	UpdatedAt              time.Time `json:"updatedAt"`
+}
+
+type ResDistributionRetry struct {
+	State                 common.DistributionState `json:"state"`
+	CheckpointBlock       uint64                   `json:"checkpointBlock"`
+	CancelledTransactions int64                    `json:"cancelledTransactions"`
+	RequeuedCount         int64                    `json:"requeuedCount"`
+	QueuedTransactions    int                      `json:"queuedTransactions"`
+	Unstalled             bool                     `json:"unstalled,omitempty"`
+}
+
+// ResProblem is an RFC 7807 problem details response.
+type ResProblem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"` // Machine readable, e.g. 'distribution_invalid_bucket'
+}
+
+type ResDistributionProgress struct {
+	State        common.DistributionState `json:"state"`
+	SettledCount uint                     `json:"settledCount"`
+	SettleTotal  uint                     `json:"settleTotal"`
+	MintedCount  uint                     `json:"mintedCount"`
+	PackCount    uint                     `json:"packCount"`
+}
+
+type ResDistributionETA struct {
+	DistributionID      uuid.UUID                `json:"distID"`
+	State               common.DistributionState `json:"state"`
+	SettledCount        uint                     `json:"settledCount"`
+	SettleTotal         uint                     `json:"settleTotal"`
+	MintedCount         uint                     `json:"mintedCount"`
+	PackCount           uint                     `json:"packCount"`
+	SettleBatches       uint                     `json:"settleBatches"`
+	MintBatches         uint                     `json:"mintBatches"`
+	SealLatencyMs       int64                    `json:"sealLatencyMs"`
+	SealLatencyObserved bool                     `json:"sealLatencyObserved"`
+	EstimatedSettledAt  *time.Time               `json:"estimatedSettledAt,omitempty"`
+	EstimatedMintedAt   *time.Time               `json:"estimatedMintedAt,omitempty"`
+}
+
+type ResDistributionSummary struct {
+	DistributionID      uuid.UUID                        `json:"distID"`
+	State               common.DistributionState         `json:"state"`
+	SettledCount        uint                             `json:"settledCount"`
+	SettleTotal         uint                             `json:"settleTotal"`
+	SettledPercent      float64                          `json:"settledPercent"`
+	MintedCount         uint                             `json:"mintedCount"`
+	PackCount           uint                             `json:"packCount"`
+	MintedPercent       float64                          `json:"mintedPercent"`
+	PacksByState        map[common.PackState]uint        `json:"packsByState"`
+	Slots               []ResSlotSummary                 `json:"slots"`
+	TransactionsByState map[common.TransactionState]uint `json:"transactionsByState"`
+	ErrorCount          uint                             `json:"errorCount"`
+	CreatedAt           time.Time                        `json:"createdAt"`
+	SettlingStartedAt   *time.Time                       `json:"settlingStartedAt,omitempty"`
+	SettledAt           *time.Time                       `json:"settledAt,omitempty"`
+	MintingStartedAt    *time.Time                       `json:"mintingStartedAt,omitempty"`
+	MintedAt            *time.Time                       `json:"mintedAt,omitempty"`
+}
+
+type ResDistributionResolution struct {
+	DistributionID   uuid.UUID `json:"distID"`
+	Seed             string    `json:"seed"`
+	AlgorithmVersion uint      `json:"algorithmVersion"`
+	PackCount        uint      `json:"packCount"`
+	MatchingCount    uint      `json:"matchingCount"`
+	Verified         bool      `json:"verified"`
+
+	// Commit-reveal distributions only
+	SeedSecret        common.BinaryValue `json:"seedSecret,omitempty"`
+	SeedAnchorHeight  uint64             `json:"seedAnchorHeight,omitempty"`
+	SeedAnchorBlockID string             `json:"seedAnchorBlockID,omitempty"`
+}
+
+type ResSlotSummary struct {
+	CollectibleReference AddressLocation `json:"collectibleReference"`
+	SlotCount            uint            `json:"slotCount"`
+	AvailableCount       uint            `json:"availableCount"`
+	FillRate             float64         `json:"fillRate"`
+}
+
+type ResReadiness struct {
+	Ready  bool                `json:"ready"`
+	Checks []ResReadinessCheck `json:"checks"`
+}
+
+type ResReadinessCheck struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+type ResOwnershipVerification struct {
+	ID               uuid.UUID                         `json:"verificationID"`
+	DistributionID   uuid.UUID                         `json:"distID"`
+	CreatedAt        time.Time                         `json:"createdAt"`
+	UpdatedAt        time.Time                         `json:"updatedAt"`
+	State            common.OwnershipVerificationState `json:"state"`
+	CheckedCount     uint                              `json:"checkedCount"`
+	DiscrepancyCount int                               `json:"discrepancyCount"`
+	Discrepancies    []ResOwnershipDiscrepancy         `json:"discrepancies"`
+}
+
+type ResOwnershipDiscrepancy struct {
+	PackID        uuid.UUID          `json:"packID"`
+	PackFlowID    common.FlowID      `json:"packFlowID"`
+	ExpectedOwner common.FlowAddress `json:"expectedOwner"`
+	Reason        string             `json:"reason"`
+}
+
+type ResCollectibleOwnershipCheck struct {
+	DistributionID uuid.UUID               `json:"distID"`
+	CheckedAt      time.Time               `json:"checkedAt"`
+	Issuer         common.FlowAddress      `json:"issuer"`
+	Passed         bool                    `json:"passed"`
+	CheckedCount   uint                    `json:"checkedCount"`
+	MissingCount   int                     `json:"missingCount"`
+	Missing        []ResMissingCollectible `json:"missing"`
+}
+
+type ResMissingCollectible struct {
+	BucketIndex int           `json:"bucketIndex"`
+	FlowID      common.FlowID `json:"flowID"`
+	Collectible string        `json:"collectible"`
+}
+
+type ReqCreateGiftIntents struct {
+	Gifts      []ReqGiftIntent `json:"gifts"`
+	ExpiresAt  *time.Time      `json:"expiresAt,omitempty"`
+	RemindAt   *time.Time      `json:"remindAt,omitempty"`
+	WebhookURL string          `json:"webhookURL,omitempty"`
+}
+
+type ReqGiftIntent struct {
+	PackFlowID common.FlowID      `json:"packFlowID"`
+	Recipient  common.FlowAddress `json:"recipient"`
+}
+
+type ResGiftIntent struct {
+	ID                    uuid.UUID              `json:"giftIntentID"`
+	DistributionID        uuid.UUID              `json:"distID"`
+	CreatedAt             time.Time              `json:"createdAt"`
+	UpdatedAt             time.Time              `json:"updatedAt"`
+	PackFlowID            common.FlowID          `json:"packFlowID"`
+	Recipient             common.FlowAddress     `json:"recipient"`
+	State                 common.GiftIntentState `json:"state"`
+	ExpiresAt             *time.Time             `json:"expiresAt,omitempty"`
+	RemindAt              *time.Time             `json:"remindAt,omitempty"`
+	WebhookURL            string                 `json:"webhookURL,omitempty"`
+	TransferredAt         *time.Time             `json:"transferredAt,omitempty"`
+	TransferTransactionID string                 `json:"transferTransactionID,omitempty"`
+}
+
+type ResCompletionReport struct {
+	DistributionID           uuid.UUID `json:"distID"`
+	ClosedAt                 time.Time `json:"closedAt"`
+	Reason                   string    `json:"reason"`
+	PackCount                uint      `json:"packCount"`
+	SealedCount              uint      `json:"sealedCount"`
+	RevealedCount            uint      `json:"revealedCount"`
+	OpenedCount              uint      `json:"openedCount"`
+	RevokedCount             uint      `json:"revokedCount"`
+	UnopenedCollectibleCount uint      `json:"unopenedCollectibleCount"`
+	TransactionCount         uint      `json:"transactionCount"`
+	FailedTransactionCount   uint      `json:"failedTransactionCount"`
+	CloseTransactionID       uuid.UUID `json:"closeTransactionID"`
+}
+
+type ResDistributionCosts struct {
+	DistributionID uuid.UUID            `json:"distID"`
+	Fees           string               `json:"fees"` // FLOW, UFix64
+	Attempts       uint                 `json:"attempts"`
+	ByName         []ResTransactionCost `json:"byName"`
+}
+
+type ResTransactionCost struct {
+	Name     string `json:"name"`
+	Fees     string `json:"fees"` // FLOW, UFix64
+	Attempts uint   `json:"attempts"`
+}
+
+type ReqRotateAndFreeze struct {
+	Reason string `json:"reason"`
+}
+
+type ResKeyRotation struct {
+	ID                uuid.UUID               `json:"keyRotationID"`
+	CreatedAt         time.Time               `json:"createdAt"`
+	UpdatedAt         time.Time               `json:"updatedAt"`
+	State             common.KeyRotationState `json:"state"`
+	Reason            string                  `json:"reason,omitempty"`
+	RevokedKeyIndexes json.RawMessage         `json:"revokedKeyIndexes"`
+	StandbyKeyIndexes json.RawMessage         `json:"standbyKeyIndexes"`
+	TransactionID     string                  `json:"flowTransactionID,omitempty"`
+	Error             string                  `json:"error,omitempty"`
+}
+
+type ResTransaction struct {
+	ID                uuid.UUID               `json:"transactionID"`
+	CreatedAt         time.Time               `json:"createdAt"`
+	UpdatedAt         time.Time               `json:"updatedAt"`
+	Name              string                  `json:"name"`
+	State             common.TransactionState `json:"state"`
+	Priority          transactions.Priority   `json:"priority"`
+	JobVersion        uint                    `json:"jobVersion"`
+	Error             string                  `json:"error,omitempty"`
+	RetryCount        uint                    `json:"retryCount"`
+	FlowTransactionID string                  `json:"flowTransactionID,omitempty"`
+	DistributionID    *uuid.UUID              `json:"distID,omitempty"`
+	PackID            *uuid.UUID              `json:"packID,omitempty"`
+	Arguments         []json.RawMessage       `json:"arguments"` // JSON-Cadence
+}
+
+type ResTransactionAttempt struct {
+	ID                   uuid.UUID               `json:"attemptID"`
+	TransactionID        uuid.UUID               `json:"transactionID"`
+	CreatedAt            time.Time               `json:"createdAt"`
+	UpdatedAt            time.Time               `json:"updatedAt"`
+	Attempt              uint                    `json:"attempt"`
+	Name                 string                  `json:"name"`
+	FlowTransactionID    string                  `json:"flowTransactionID"`
+	ScriptHash           string                  `json:"scriptHash"`
+	Arguments            []json.RawMessage       `json:"arguments"` // JSON-Cadence
+	ProposerKeyIndex     int                     `json:"proposerKeyIndex"`
+	ReferenceBlockHeight uint64                  `json:"referenceBlockHeight"`
+	RLP                  string                  `json:"rlp"`
+	State                common.TransactionState `json:"state"`
+	Error                string                  `json:"error,omitempty"`
+}
+
+type ResOwnedCollectibles struct {
+	Address              common.FlowAddress `json:"address"`
+	CollectibleReference AddressLocation    `json:"collectibleReference"`
+	CollectibleIDs       []common.FlowID    `json:"collectibleIDs"`
 }
 
 type AddressLocation struct {
@@ -76,15 +753,452 @@ type AddressLocation struct {
 	Address common.FlowAddress `json:"address"`
 }
 
-func ResGetDistributionFromApp(d *app.Distribution) ResGetDistribution {
+func ResGetDistributionFromApp(d *app.Distribution, branding *app.IssuerBranding) ResGetDistribution {
 	return ResGetDistribution{
-		ID:           d.ID,
-		FlowID:       d.FlowID,
-		CreatedAt:    d.CreatedAt,
-		UpdatedAt:    d.UpdatedAt,
-		Issuer:       d.Issuer,
-		State:        d.State,
-		PackTemplate: ResPackTemplateFromApp(d.PackTemplate),
+		ID:             d.ID,
+		FlowID:         d.FlowID,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		Issuer:         d.Issuer,
+		State:          d.State,
+		PackTemplate:   ResPackTemplateFromApp(d.PackTemplate),
+		AccessAPIHost:  d.AccessAPIHost,
+		IssuerBranding: ResIssuerBrandingFromApp(branding),
+		Metadata:       ResDistributionMetadataFromApp(d.Metadata),
+
+		RevealWebhookURL: d.RevealWebhookURL,
+		TeasedAt:         d.TeasedAt,
+		CollectionID:     d.CollectionID,
+		TemplateID:       d.TemplateID,
+		ArchivedAt:       d.ArchivedAt,
+		PausedAt:         d.PausedAt,
+		StalledState:     d.StalledState,
+
+		SettlementStartAt: d.SettlementStartAt,
+
+		SeedCommitReveal:  d.SeedCommitReveal,
+		SeedAnchorHeight:  d.SeedAnchorHeight,
+		SeedAnchorBlockID: d.SeedAnchorBlockID,
+
+		CommitmentHashVersion: commitmentHashVersion(d.CommitmentHashVersion),
+	}
+}
+
+// Distributions without metadata leave it out
+func ResDistributionMetadataFromApp(m app.DistributionMetadata) *ResDistributionMetadata {
+	if m.IsEmpty() {
+		return nil
+	}
+	return &ResDistributionMetadata{
+		Title:       m.Title,
+		Description: m.Description,
+		ImageURI:    m.ImageURI,
+		OnChain:     m.OnChain,
+	}
+}
+
+func (m *ReqDistributionMetadata) ToApp() *app.DistributionMetadata {
+	if m == nil {
+		return nil
+	}
+	return &app.DistributionMetadata{
+		Title:       m.Title,
+		Description: m.Description,
+		ImageURI:    m.ImageURI,
+	}
+}
+
+// Distributions created before versions were recorded use version 1
+func commitmentHashVersion(version uint) uint {
+	if version == 0 {
+		return 1
+	}
+	return version
+}
+
+func ResPackFromApp(p *app.Pack, branding *app.IssuerBranding, reveal *app.PackReveal) ResPack {
+	res := ResPack{
+		ID:             p.ID,
+		DistributionID: p.DistributionID,
+		FlowID:         p.FlowID,
+		State:          p.State,
+		CommitmentHash: p.CommitmentHash,
+		RevokedAt:      p.RevokedAt,
+		ReplacesPackID: p.ReplacesPackID,
+		IssuerBranding: ResIssuerBrandingFromApp(branding),
+	}
+	if reveal != nil {
+		res.Teaser = reveal.Teaser
+		for _, c := range reveal.Collectibles {
+			res.Collectibles = append(res.Collectibles, c.String())
+		}
+	}
+	return res
+}
+
+func (c ReqCreateCollection) ToApp() app.Collection {
+	collection := app.Collection{
+		Issuer:           c.Issuer,
+		Name:             c.Name,
+		Description:      c.Description,
+		AccessAPIHost:    c.AccessAPIHost,
+		RevealWebhookURL: c.RevealWebhookURL,
+	}
+	if c.PackReference != nil {
+		collection.PackReference = c.PackReference.ToApp()
+	}
+	if c.CollectibleReference != nil {
+		collection.CollectibleReference = c.CollectibleReference.ToApp()
+	}
+	return collection
+}
+
+func (t ReqDistributionTemplate) ToApp() app.DistributionTemplate {
+	template := app.DistributionTemplate{
+		Issuer:           t.Issuer,
+		Name:             t.Name,
+		Description:      t.Description,
+		PackCount:        t.PackCount,
+		Buckets:          make(app.TemplateBuckets, len(t.Buckets)),
+		AccessAPIHost:    t.AccessAPIHost,
+		RevealWebhookURL: t.RevealWebhookURL,
+		CollectionID:     t.CollectionID,
+	}
+	if t.PackReference != nil {
+		template.PackReference = t.PackReference.ToApp()
+	}
+	for i, b := range t.Buckets {
+		ref := t.CollectibleReference
+		if b.CollectibleReference != nil {
+			ref = b.CollectibleReference
+		}
+		template.Buckets[i].CollectibleCount = b.CollectibleCount
+		if ref != nil {
+			template.Buckets[i].CollectibleReference = ref.ToApp()
+		}
+	}
+	return template
+}
+
+func ResDistributionTemplateFromApp(t *app.DistributionTemplate) ResDistributionTemplate {
+	buckets := make([]ResTemplateBucket, len(t.Buckets))
+	for i, b := range t.Buckets {
+		buckets[i] = ResTemplateBucket{
+			CollectibleReference: optionalAddressLocation(b.CollectibleReference),
+			CollectibleCount:     b.CollectibleCount,
+		}
+	}
+	return ResDistributionTemplate{
+		ID:               t.ID,
+		Issuer:           t.Issuer,
+		Name:             t.Name,
+		Description:      t.Description,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+		PackReference:    optionalAddressLocation(t.PackReference),
+		PackCount:        t.PackCount,
+		Buckets:          buckets,
+		AccessAPIHost:    t.AccessAPIHost,
+		RevealWebhookURL: t.RevealWebhookURL,
+		CollectionID:     t.CollectionID,
+	}
+}
+
+func ResCollectionFromApp(c *app.Collection) ResCollection {
+	return ResCollection{
+		ID:                   c.ID,
+		Issuer:               c.Issuer,
+		Name:                 c.Name,
+		Description:          c.Description,
+		CreatedAt:            c.CreatedAt,
+		UpdatedAt:            c.UpdatedAt,
+		PackReference:        optionalAddressLocation(c.PackReference),
+		CollectibleReference: optionalAddressLocation(c.CollectibleReference),
+		AccessAPIHost:        c.AccessAPIHost,
+		RevealWebhookURL:     c.RevealWebhookURL,
+	}
+}
+
+func ResCollectionStatsFromApp(s app.CollectionStats) *ResCollectionStats {
+	byState := s.DistributionsByState
+	if byState == nil {
+		byState = map[common.DistributionState]uint{}
+	}
+	return &ResCollectionStats{
+		DistributionCount:    s.DistributionCount,
+		DistributionsByState: byState,
+		PackCount:            s.PackCount,
+		SealedCount:          s.SealedCount,
+		RevealedCount:        s.RevealedCount,
+		OpenedCount:          s.OpenedCount,
+	}
+}
+
+// optionalAddressLocation returns nil for a reference left out
+func optionalAddressLocation(al app.AddressLocation) *AddressLocation {
+	if al.Name == "" {
+		return nil
+	}
+	res := AddressLocation(al)
+	return &res
+}
+
+func (i ReqCreateIssuer) ToApp() app.Issuer {
+	return app.Issuer{
+		Address:          i.Address,
+		Name:             i.Name,
+		AllowedContracts: i.ReqUpdateIssuer.allowedContracts(),
+	}
+}
+
+func (i ReqUpdateIssuer) allowedContracts() app.ContractList {
+	return contractListToApp(i.AllowedCollectibleContracts)
+}
+
+func (k ReqCreateAPIKey) allowedContracts() app.ContractList {
+	return contractListToApp(k.AllowedCollectibleContracts)
+}
+
+func contractListToApp(refs []AddressLocation) app.ContractList {
+	list := make(app.ContractList, len(refs))
+	for n, ref := range refs {
+		list[n] = app.AddressLocation(ref)
+	}
+	return list
+}
+
+func contractListFromApp(list app.ContractList) []AddressLocation {
+	refs := make([]AddressLocation, len(list))
+	for n, ref := range list {
+		refs[n] = AddressLocation(ref)
+	}
+	return refs
+}
+
+func ResIssuerFromApp(i *app.Issuer) ResIssuer {
+	return ResIssuer{
+		Address:                     i.Address,
+		Name:                        i.Name,
+		AllowedCollectibleContracts: contractListFromApp(i.AllowedContracts),
+		CreatedAt:                   i.CreatedAt,
+		UpdatedAt:                   i.UpdatedAt,
+	}
+}
+
+func ResAPIKeyFromApp(k *app.APIKey) ResAPIKey {
+	return ResAPIKey{
+		ID:        k.ID,
+		Issuer:    k.Issuer,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		CreatedAt: k.CreatedAt,
+		RevokedAt: k.RevokedAt,
+
+		AllowedCollectibleContracts: contractListFromApp(k.AllowedContracts),
+	}
+}
+
+func ResIssuerWebhookFromApp(w *app.IssuerWebhook) ResIssuerWebhook {
+	return ResIssuerWebhook{
+		ID:        w.ID,
+		Issuer:    w.Issuer,
+		URL:       w.URL,
+		APIKeyID:  w.APIKeyID,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func (b ReqIssuerBranding) ToApp(issuer common.FlowAddress) app.IssuerBranding {
+	return app.IssuerBranding{
+		Issuer:      issuer,
+		DisplayName: b.DisplayName,
+		LogoURI:     b.LogoURI,
+		SupportURL:  b.SupportURL,
+	}
+}
+
+func ResIssuerCallbackSecretFromApp(s *app.IssuerCallbackSecret) ResIssuerCallbackSecret {
+	return ResIssuerCallbackSecret{
+		Issuer:    s.Issuer,
+		Secret:    s.Secret,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func (c ReqIssuerCallback) ToApp(issuer common.FlowAddress) app.IssuerCallback {
+	return app.IssuerCallback{
+		Issuer:     issuer,
+		CallbackID: c.CallbackID,
+		Event:      c.Event,
+		PackID:     c.PackID,
+		Data:       datatypes.JSON(c.Data),
+	}
+}
+
+func (al AddressLocation) ToApp() app.AddressLocation {
+	return app.AddressLocation(al)
+}
+
+func ResCollectibleIDReservationFromApp(r *app.IDReservation) ResCollectibleIDReservation {
+	return ResCollectibleIDReservation{
+		PackID:               r.PackID,
+		CollectibleReference: AddressLocation(r.ContractReference),
+		CollectibleIDs:       r.IDs(),
+		CreatedAt:            r.CreatedAt,
+	}
+}
+
+func ResIssuerCallbackFromApp(c *app.IssuerCallback) ResIssuerCallback {
+	res := ResIssuerCallback{
+		ID:         c.ID,
+		Issuer:     c.Issuer,
+		CallbackID: c.CallbackID,
+		Event:      c.Event,
+		PackID:     c.PackID,
+		SignedAt:   c.SignedAt,
+		CreatedAt:  c.CreatedAt,
+	}
+	if len(c.Data) > 0 {
+		res.Data = json.RawMessage(c.Data)
+	}
+	return res
+}
+
+func ResPublicStatsFromApp(s app.PublicStats) ResPublicStats {
+	return ResPublicStats{
+		PacksMinted:            s.PacksMinted,
+		DistributionsCompleted: s.DistributionsCompleted,
+		UpdatedAt:              s.UpdatedAt,
+	}
+}
+
+func ResDistributionRetryFromApp(r *app.DistributionRetry) ResDistributionRetry {
+	return ResDistributionRetry{
+		State:                 r.State,
+		CheckpointBlock:       r.CheckpointBlock,
+		CancelledTransactions: r.CancelledTransactions,
+		RequeuedCount:         r.RequeuedCount,
+		QueuedTransactions:    r.QueuedTransactions,
+		Unstalled:             r.Unstalled,
+	}
+}
+
+func ResDistributionProgressFromApp(p *app.DistributionProgress) ResDistributionProgress {
+	return ResDistributionProgress{
+		State:        p.State,
+		SettledCount: p.SettledCount,
+		SettleTotal:  p.SettleTotal,
+		MintedCount:  p.MintedCount,
+		PackCount:    p.PackCount,
+	}
+}
+
+// The seed is a string as it may not fit the integers of JSON parsers.
+func ResDistributionResolutionFromApp(r *app.Resolution) ResDistributionResolution {
+	return ResDistributionResolution{
+		DistributionID:   r.DistributionID,
+		Seed:             strconv.FormatInt(r.Seed, 10),
+		AlgorithmVersion: r.AlgorithmVersion,
+		PackCount:        r.PackCount,
+		MatchingCount:    r.MatchingCount,
+		Verified:         r.Verified,
+
+		SeedSecret:        r.SeedSecret,
+		SeedAnchorHeight:  r.SeedAnchorHeight,
+		SeedAnchorBlockID: r.SeedAnchorBlockID,
+	}
+}
+
+func ResBulkRevealListFromApp(list []app.BulkRevealResult) []ResBulkReveal {
+	res := make([]ResBulkReveal, len(list))
+	for i, r := range list {
+		res[i] = ResBulkReveal{
+			PackID:        r.PackID,
+			TransactionID: r.TransactionID,
+			Action:        r.Action,
+		}
+	}
+	return res
+}
+
+func ResDistributionETAFromApp(e *app.DistributionETA) ResDistributionETA {
+	return ResDistributionETA{
+		DistributionID:      e.DistributionID,
+		State:               e.Progress.State,
+		SettledCount:        e.Progress.SettledCount,
+		SettleTotal:         e.Progress.SettleTotal,
+		MintedCount:         e.Progress.MintedCount,
+		PackCount:           e.Progress.PackCount,
+		SettleBatches:       e.SettleBatches,
+		MintBatches:         e.MintBatches,
+		SealLatencyMs:       e.SealLatency.Milliseconds(),
+		SealLatencyObserved: e.SealLatencyObserved,
+		EstimatedSettledAt:  e.SettledAt,
+		EstimatedMintedAt:   e.MintedAt,
+	}
+}
+
+func ResDistributionSummaryFromApp(s *app.DistributionSummary) ResDistributionSummary {
+	packs := s.PacksByState
+	if packs == nil {
+		packs = map[common.PackState]uint{}
+	}
+
+	txs := s.TransactionsByState
+	if txs == nil {
+		txs = map[common.TransactionState]uint{}
+	}
+
+	slots := make([]ResSlotSummary, len(s.Slots))
+	for i, slot := range s.Slots {
+		slots[i] = ResSlotSummary{
+			CollectibleReference: AddressLocation(slot.CollectibleReference),
+			SlotCount:            slot.SlotCount,
+			AvailableCount:       slot.AvailableCount,
+			FillRate:             slot.FillRate,
+		}
+	}
+
+	return ResDistributionSummary{
+		DistributionID:      s.DistributionID,
+		State:               s.Progress.State,
+		SettledCount:        s.Progress.SettledCount,
+		SettleTotal:         s.Progress.SettleTotal,
+		SettledPercent:      s.SettledPercent,
+		MintedCount:         s.Progress.MintedCount,
+		PackCount:           s.Progress.PackCount,
+		MintedPercent:       s.MintedPercent,
+		PacksByState:        packs,
+		Slots:               slots,
+		TransactionsByState: txs,
+		ErrorCount:          s.ErrorCount,
+		CreatedAt:           s.CreatedAt,
+		SettlingStartedAt:   s.SettlingStartedAt,
+		SettledAt:           s.SettledAt,
+		MintingStartedAt:    s.MintingStartedAt,
+		MintedAt:            s.MintedAt,
+	}
+}
+
+func ResReadinessFromApp(r app.Readiness) ResReadiness {
+	checks := make([]ResReadinessCheck, len(r.Checks))
+	for i, c := range r.Checks {
+		checks[i] = ResReadinessCheck{Name: c.Name, Error: c.Error}
+	}
+	return ResReadiness{Ready: r.Ready, Checks: checks}
+}
+
+// ResIssuerBrandingFromApp returns nil for a nil branding
+func ResIssuerBrandingFromApp(b *app.IssuerBranding) *ResIssuerBranding {
+	if b == nil {
+		return nil
+	}
+	return &ResIssuerBranding{
+		Issuer:      b.Issuer,
+		DisplayName: b.DisplayName,
+		LogoURI:     b.LogoURI,
+		SupportURL:  b.SupportURL,
+		UpdatedAt:   b.UpdatedAt,
 	}
 }
 
@@ -92,23 +1206,68 @@ func ResDistributionListFromApp(dd []app.Distribution) []ResListDistribution {
 	res := make([]ResListDistribution, len(dd))
 	for i, d := range dd {
 		res[i] = ResListDistribution{
-			ID:        d.ID,
-			FlowID:    d.FlowID,
-			CreatedAt: d.CreatedAt,
-			UpdatedAt: d.UpdatedAt,
-			Issuer:    d.Issuer,
-			State:     d.State,
+			ID:           d.ID,
+			FlowID:       d.FlowID,
+			CreatedAt:    d.CreatedAt,
+			UpdatedAt:    d.UpdatedAt,
+			Issuer:       d.Issuer,
+			State:        d.State,
+			CollectionID: d.CollectionID,
+			ArchivedAt:   d.ArchivedAt,
+			PausedAt:     d.PausedAt,
+
+			SettlementStartAt: d.SettlementStartAt,
+
+			Metadata: ResDistributionMetadataFromApp(d.Metadata),
+		}
+	}
+	return res
+}
+
+func ResPackListFromApp(pp []app.Pack) []ResListPack {
+	res := make([]ResListPack, len(pp))
+	for i, p := range pp {
+		res[i] = ResListPack{
+			ID:             p.ID,
+			FlowID:         p.FlowID,
+			State:          p.State,
+			CommitmentHash: p.CommitmentHash,
+		}
+	}
+	return res
+}
+
+func ResOwnedPacksFromApp(pp []app.Pack) []ResOwnedPack {
+	res := make([]ResOwnedPack, len(pp))
+	for i, p := range pp {
+		res[i] = ResOwnedPack{
+			ID:             p.ID,
+			DistributionID: p.DistributionID,
+			PackReference:  AddressLocation(p.ContractReference),
+			FlowID:         p.FlowID,
+			State:          p.State,
+			CommitmentHash: p.CommitmentHash,
 		}
 	}
 	return res
 }
 
 func ResPackTemplateFromApp(pt app.PackTemplate) ResPackTemplate {
-	return ResPackTemplate{
-		PackReference: AddressLocation(pt.PackReference),
-		PackCount:     pt.PackCount,
-		Buckets:       ResBucketsFromApp(pt),
+	res := ResPackTemplate{
+		PackReference:   AddressLocation(pt.PackReference),
+		PackCount:       pt.PackCount,
+		Buckets:         ResBucketsFromApp(pt),
+		RevealNotBefore: pt.RevealNotBefore,
+		TeaseNotBefore:  pt.TeaseNotBefore,
 	}
+	if !pt.FungibleToken.IsZero() {
+		res.FungibleToken = &FungibleTokenAmount{
+			TokenReference: AddressLocation(pt.FungibleToken.ContractReference),
+			Amount:         TokenAmount(pt.FungibleToken.Amount),
+			ReceiverPath:   pt.FungibleTokenReceiverPath,
+		}
+	}
+	return res
 }
 
 func ResBucketsFromApp(pt app.PackTemplate) []ResBucket {
@@ -117,35 +1276,378 @@ func ResBucketsFromApp(pt app.PackTemplate) []ResBucket {
 		buckets[i] = ResBucket{
 			CollectibleReference: AddressLocation(b.CollectibleReference),
 			CollectibleCount:     b.CollectibleCount,
+			CollectibleTiers:     collectibleTiersByTier(b.CollectibleTiers),
+			TierWeights:          b.TierWeights,
+			GuaranteedTiers:      b.GuaranteedTiers,
 		}
 	}
 	return buckets
 }
 
+// collectibleTiersByTier groups the collectibles of 'tiers' by tier
+func collectibleTiersByTier(tiers app.CollectibleTiers) map[string]common.FlowIDList {
+	if len(tiers) == 0 {
+		return nil
+	}
+	res := make(map[string]common.FlowIDList)
+	for id, tier := range tiers {
+		res[tier] = append(res[tier], common.FlowID{Int64: id, Valid: true})
+	}
+	for _, ids := range res {
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Int64 < ids[j].Int64 })
+	}
+	return res
+}
+
+func ResOwnershipVerificationFromApp(v *app.OwnershipVerification) ResOwnershipVerification {
+	discrepancies := make([]ResOwnershipDiscrepancy, len(v.Discrepancies))
+	for i, d := range v.Discrepancies {
+		discrepancies[i] = ResOwnershipDiscrepancy{
+			PackID:        d.PackID,
+			PackFlowID:    d.PackFlowID,
+			ExpectedOwner: d.ExpectedOwner,
+			Reason:        d.Reason,
+		}
+	}
+	return ResOwnershipVerification{
+		ID:               v.ID,
+		DistributionID:   v.DistributionID,
+		CreatedAt:        v.CreatedAt,
+		UpdatedAt:        v.UpdatedAt,
+		State:            v.State,
+		CheckedCount:     v.CheckedCount,
+		DiscrepancyCount: len(discrepancies),
+		Discrepancies:    discrepancies,
+	}
+}
+
+func ResCollectibleOwnershipCheckFromApp(c *app.CollectibleOwnershipCheck) ResCollectibleOwnershipCheck {
+	missing := make([]ResMissingCollectible, len(c.Missing))
+	for i, m := range c.Missing {
+		missing[i] = ResMissingCollectible{
+			BucketIndex: m.BucketIndex,
+			FlowID:      m.FlowID,
+			Collectible: app.Collectible{FlowID: m.FlowID, ContractReference: m.ContractReference}.String(),
+		}
+	}
+	return ResCollectibleOwnershipCheck{
+		DistributionID: c.DistributionID,
+		CheckedAt:      c.CreatedAt,
+		Issuer:         c.Issuer,
+		Passed:         c.Passed(),
+		CheckedCount:   c.CheckedCount,
+		MissingCount:   len(missing),
+		Missing:        missing,
+	}
+}
+
+func ResPackProofFromApp(p *app.PackProof) ResPackProof {
+	collectibles := make([]string, len(p.Collectibles))
+	for i, c := range p.Collectibles {
+		collectibles[i] = c.String()
+	}
+
+	return ResPackProof{
+		ID:                    p.PackID,
+		FlowID:                p.FlowID,
+		PackReference:         AddressLocation(p.ContractReference),
+		CommitmentHash:        p.CommitmentHash,
+		CommitmentHashVersion: p.CommitmentHashVersion,
+		Algorithm:             p.Algorithm,
+		Salt:                  p.Salt,
+		Collectibles:          collectibles,
+		Preimage:              p.Preimage,
+		ComputedHash:          p.ComputedHash,
+		Verified:              p.Verified,
+	}
+}
+
+func ResPackRevocationFromApp(r *app.PackRevocation) ResPackRevocation {
+	return ResPackRevocation{
+		ID:            r.PackID,
+		FlowID:        r.FlowID,
+		RevokedAt:     r.RevokedAt,
+		Reason:        r.Reason,
+		TransactionID: r.TransactionID,
+	}
+}
+
+func ResPackReplacementFromApp(r *app.PackReplacement) ResPackReplacement {
+	return ResPackReplacement{
+		DistributionID:    r.DistributionID,
+		OriginalPackID:    r.OriginalPackID,
+		ReplacementPackID: r.ReplacementPackID,
+		Reason:            r.Reason,
+		MintTransactionID: r.MintTransactionID,
+		Complete:          r.Complete,
+		CreatedAt:         r.CreatedAt,
+		CompletedAt:       r.CompletedAt,
+	}
+}
+
+func ResEscrowSurplusFromApp(s *app.EscrowSurplus) ResEscrowSurplus {
+	collectibles := make([]string, len(s.Collectibles))
+	for i, c := range s.Collectibles {
+		collectibles[i] = c.String()
+	}
+
+	return ResEscrowSurplus{
+		DistributionID:     s.DistributionID,
+		Collectibles:       collectibles,
+		QueuedTransactions: s.QueuedTransactions,
+	}
+}
+
 func (d ReqCreateDistribution) ToApp() app.Distribution {
-	return app.Distribution{
-		State:        common.DistributionStateInit,
-		FlowID:       d.FlowID,
-		Issuer:       d.Issuer,
-		PackTemplate: d.PackTemplate.ToApp(),
+	res := app.Distribution{
+		State:         common.DistributionStateInit,
+		FlowID:        d.FlowID,
+		Issuer:        d.Issuer,
+		PackTemplate:  d.PackTemplate.ToApp(),
+		AccessAPIHost: d.AccessAPIHost,
+
+		RevealWebhookURL: d.RevealWebhookURL,
+		CollectionID:     d.CollectionID,
+		TemplateID:       d.TemplateID,
+
+		SettlementStartAt: d.SettlementStartAt,
+
+		SeedCommitReveal: d.SeedCommitReveal,
+
+		CommitmentHashVersion: d.CommitmentHashVersion,
+	}
+
+	if m := d.Metadata.ToApp(); m != nil {
+		res.Metadata = *m
 	}
+
+	return res
 }
 
 func (pt ReqPackTemplate) ToApp() app.PackTemplate {
 	buckets := make([]app.Bucket, len(pt.Buckets))
 	for i, b := range pt.Buckets {
-		// ref := b.CollectibleReference
 		ref := pt.CollectibleReference
+		if b.CollectibleReference != nil {
+			ref = *b.CollectibleReference
+		}
 
 		buckets[i] = app.Bucket{
 			CollectibleReference:  app.AddressLocation(ref),
 			CollectibleCount:      b.CollectibleCount,
 			CollectibleCollection: b.CollectibleCollection,
+			TierWeights:           b.TierWeights,
+			GuaranteedTiers:       b.GuaranteedTiers,
+		}
+
+		if len(b.CollectibleTiers) > 0 {
+			tiers := make(app.CollectibleTiers)
+			for tier, ids := range b.CollectibleTiers {
+				for _, id := range ids {
+					tiers[id.Int64] = tier
+				}
+			}
+			buckets[i].CollectibleTiers = tiers
 		}
 	}
-	return app.PackTemplate{
-		PackReference: app.AddressLocation(pt.PackReference),
-		PackCount:     pt.PackCount,
-		Buckets:       buckets,
+	res := app.PackTemplate{
+		PackReference:   app.AddressLocation(pt.PackReference),
+		PackCount:       pt.PackCount,
+		Buckets:         buckets,
+		RevealNotBefore: pt.RevealNotBefore,
+		TeaseNotBefore:  pt.TeaseNotBefore,
 	}
+	if ft := pt.FungibleToken; ft != nil {
+		res.FungibleToken = app.FungibleTokenAmount{
+			ContractReference: app.AddressLocation(ft.TokenReference),
+			Amount:            uint64(ft.Amount),
+		}
+		res.FungibleTokenReceiverPath = ft.ReceiverPath
+	}
+	return res
+}
+
+func (d ReqUpdateDistribution) ToApp() app.DistributionUpdate {
+	res := app.DistributionUpdate{
+		AccessAPIHost:     d.AccessAPIHost,
+		RevealWebhookURL:  d.RevealWebhookURL,
+		SettlementStartAt: d.SettlementStartAt,
+		Metadata:          d.Metadata.ToApp(),
+	}
+
+	if pt := d.PackTemplate; pt != nil {
+		if pt.PackReference != nil {
+			ref := app.AddressLocation(*pt.PackReference)
+			res.PackReference = &ref
+		}
+		res.PackCount = pt.PackCount
+		res.RevealNotBefore = pt.RevealNotBefore
+		res.TeaseNotBefore = pt.TeaseNotBefore
+
+		if pt.Buckets != nil {
+			res.Buckets = ReqPackTemplate{
+				Buckets:              pt.Buckets,
+				CollectibleReference: pt.CollectibleReference,
+			}.ToApp().Buckets
+		}
+	}
+
+	return res
+}
+
+func (r ReqCreateGiftIntents) ToApp() []app.GiftIntent {
+	res := make([]app.GiftIntent, len(r.Gifts))
+	for i, g := range r.Gifts {
+		res[i] = app.GiftIntent{
+			PackFlowID: g.PackFlowID,
+			Recipient:  g.Recipient,
+			ExpiresAt:  r.ExpiresAt,
+			RemindAt:   r.RemindAt,
+			WebhookURL: r.WebhookURL,
+		}
+	}
+	return res
+}
+
+func ResGiftIntentFromApp(g *app.GiftIntent) ResGiftIntent {
+	return ResGiftIntent{
+		ID:                    g.ID,
+		DistributionID:        g.DistributionID,
+		CreatedAt:             g.CreatedAt,
+		UpdatedAt:             g.UpdatedAt,
+		PackFlowID:            g.PackFlowID,
+		Recipient:             g.Recipient,
+		State:                 g.State,
+		ExpiresAt:             g.ExpiresAt,
+		RemindAt:              g.RemindAt,
+		WebhookURL:            g.WebhookURL,
+		TransferredAt:         g.TransferredAt,
+		TransferTransactionID: g.TransferTransactionID,
+	}
+}
+
+func ResGiftIntentListFromApp(gg []app.GiftIntent) []ResGiftIntent {
+	res := make([]ResGiftIntent, len(gg))
+	for i := range gg {
+		res[i] = ResGiftIntentFromApp(&gg[i])
+	}
+	return res
+}
+
+func ResCompletionReportFromApp(r *app.CompletionReport) ResCompletionReport {
+	return ResCompletionReport{
+		DistributionID:           r.DistributionID,
+		ClosedAt:                 r.CreatedAt,
+		Reason:                   r.Reason,
+		PackCount:                r.PackCount,
+		SealedCount:              r.SealedCount,
+		RevealedCount:            r.RevealedCount,
+		OpenedCount:              r.OpenedCount,
+		RevokedCount:             r.RevokedCount,
+		UnopenedCollectibleCount: r.UnopenedCollectibleCount,
+		TransactionCount:         r.TransactionCount,
+		FailedTransactionCount:   r.FailedTransactionCount,
+		CloseTransactionID:       r.CloseTransactionID,
+	}
+}
+
+func ResDistributionCostsFromApp(c *app.DistributionCosts) ResDistributionCosts {
+	byName := make([]ResTransactionCost, len(c.ByName))
+	for i, s := range c.ByName {
+		byName[i] = ResTransactionCost{
+			Name:     s.Name,
+			Fees:     cadence.UFix64(s.Fees).String(),
+			Attempts: s.Attempts,
+		}
+	}
+	return ResDistributionCosts{
+		DistributionID: c.DistributionID,
+		Fees:           cadence.UFix64(c.Fees).String(),
+		Attempts:       c.Attempts,
+		ByName:         byName,
+	}
+}
+
+func ResKeyRotationFromApp(r *app.KeyRotation) ResKeyRotation {
+	return ResKeyRotation{
+		ID:                r.ID,
+		CreatedAt:         r.CreatedAt,
+		UpdatedAt:         r.UpdatedAt,
+		State:             r.State,
+		Reason:            r.Reason,
+		RevokedKeyIndexes: json.RawMessage(r.RevokedKeyIndexes),
+		StandbyKeyIndexes: json.RawMessage(r.StandbyKeyIndexes),
+		TransactionID:     r.TransactionID,
+		Error:             r.Error,
+	}
+}
+
+func ResTransactionFromApp(t *transactions.StorableTransaction) ResTransaction {
+	res := ResTransaction{
+		ID:                t.ID,
+		CreatedAt:         t.CreatedAt,
+		UpdatedAt:         t.UpdatedAt,
+		Name:              t.Name,
+		State:             t.State,
+		Priority:          t.Priority,
+		JobVersion:        t.JobVersion,
+		Error:             t.Error,
+		RetryCount:        t.RetryCount,
+		FlowTransactionID: t.TransactionID,
+	}
+
+	if t.DistributionID != uuid.Nil {
+		res.DistributionID = &t.DistributionID
+	}
+	if t.PackID != uuid.Nil {
+		res.PackID = &t.PackID
+	}
+
+	res.Arguments = argumentsFromApp(t.Arguments)
+
+	return res
+}
+
+// argumentsFromApp returns stored transaction arguments, a list of
+// JSON-Cadence encoded values
+func argumentsFromApp(arguments []byte) []json.RawMessage {
+	res := []json.RawMessage{}
+	args := [][]byte{}
+	if err := json.Unmarshal(arguments, &args); err == nil {
+		for _, a := range args {
+			res = append(res, json.RawMessage(a))
+		}
+	}
+	return res
+}
+
+func ResTransactionAttemptListFromApp(aa []transactions.TransactionAttempt) []ResTransactionAttempt {
+	res := make([]ResTransactionAttempt, len(aa))
+	for i, a := range aa {
+		res[i] = ResTransactionAttempt{
+			ID:                   a.ID,
+			TransactionID:        a.StorableTransactionID,
+			CreatedAt:            a.CreatedAt,
+			UpdatedAt:            a.UpdatedAt,
+			Attempt:              a.Attempt,
+			Name:                 a.Name,
+			FlowTransactionID:    a.TransactionID,
+			ScriptHash:           a.ScriptHash,
+			Arguments:            argumentsFromApp(a.Arguments),
+			ProposerKeyIndex:     a.ProposerKeyIndex,
+			ReferenceBlockHeight: a.ReferenceBlockHeight,
+			RLP:                  a.RLP,
+			State:                a.State,
+			Error:                a.Error,
+		}
+	}
+	return res
+}
+
+func ResTransactionListFromApp(tt []transactions.StorableTransaction) []ResTransaction {
+	res := make([]ResTransaction, len(tt))
+	for i := range tt {
+		res[i] = ResTransactionFromApp(&tt[i])
+	}
+	return res
 }
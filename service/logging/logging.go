@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Subsystems which can have their log level configured separately
+const (
+	HTTP       = "http"
+	Settlement = "settlement"
+	Minting    = "minting"
+	Events     = "events"
+)
+
+var loggers = map[string]*log.Logger{
+	HTTP:       newLogger(),
+	Settlement: newLogger(),
+	Minting:    newLogger(),
+	Events:     newLogger(),
+}
+
+func newLogger() *log.Logger {
+	l := log.New()
+	l.SetOutput(os.Stderr)
+	l.SetFormatter(textFormatter())
+	return l
+}
+
+func textFormatter() log.Formatter {
+	return &log.TextFormatter{
+		DisableColors: true,
+		FullTimestamp: true,
+	}
+}
+
+// Logger returns the logger of 'subsystem'. Loggers for unknown subsystems
+// fall back to the standard logger.
+func Logger(subsystem string) *log.Logger {
+	if l, ok := loggers[subsystem]; ok {
+		return l
+	}
+	return log.StandardLogger()
+}
+
+// Setup configures the standard logger and the subsystem loggers according
+// to 'cfg'. Subsystems without a level override use the global level.
+// Should be called once at startup, before the loggers are used.
+func Setup(cfg *config.Config) error {
+	formatter, err := parseFormat(cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+
+	level, err := log.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	log.SetFormatter(formatter)
+	log.SetLevel(level)
+
+	overrides := map[string]string{
+		HTTP:       cfg.LogLevelHTTP,
+		Settlement: cfg.LogLevelSettlement,
+		Minting:    cfg.LogLevelMinting,
+		Events:     cfg.LogLevelEvents,
+	}
+
+	for subsystem, l := range loggers {
+		subsystemLevel := level
+		if o := overrides[subsystem]; o != "" {
+			if subsystemLevel, err = log.ParseLevel(o); err != nil {
+				return fmt.Errorf("invalid log level for subsystem '%s': %w", subsystem, err)
+			}
+		}
+		l.SetFormatter(formatter)
+		l.SetLevel(subsystemLevel)
+	}
+
+	return nil
+}
+
+func parseFormat(format string) (log.Formatter, error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+		return textFormatter(), nil
+	case "json":
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format '%s', expected 'text' or 'json'", format)
+	}
+}
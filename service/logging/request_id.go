@@ -0,0 +1,18 @@
+package logging
+
+import "context"
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID returns a copy of 'ctx' holding the ID of the API
+// request it serves, see RequestID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID returns the ID of the API request 'ctx' serves, empty if none.
+// Logged as 'requestID' to correlate the logs of a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// CardinalityGuard limits the number of distinct values of a label.
+// At most 'max' distinct values get their own label value at a time. Once
+// full, a new value takes the place of the least recently seen one if that
+// was not seen for 'idle', otherwise it is labeled "other". Finished
+// distributions so make room for new ones instead of keeping their labels
+// for the life of the process.
+type CardinalityGuard struct {
+	max   int
+	idle  time.Duration
+	clock common.Clock
+	mu    sync.Mutex
+	seen  map[string]time.Time // Last time each tracked value was seen
+}
+
+func NewCardinalityGuard(max int, idle time.Duration, clock common.Clock) *CardinalityGuard {
+	return &CardinalityGuard{max: max, idle: idle, clock: clock, seen: make(map[string]time.Time)}
+}
+
+// Label returns 'value' if it is (or can be) tracked, "other" otherwise.
+// If tracking 'value' evicted an idle value, that value is returned as
+// 'evicted' so its series can be deleted.
+func (g *CardinalityGuard) Label(value string) (label string, evicted string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+
+	if _, ok := g.seen[value]; ok {
+		g.seen[value] = now
+		return value, ""
+	}
+
+	if g.max <= 0 {
+		return labelOther, ""
+	}
+
+	if len(g.seen) >= g.max {
+		var oldest time.Time
+		for v, t := range g.seen {
+			if evicted == "" || t.Before(oldest) {
+				evicted, oldest = v, t
+			}
+		}
+
+		if now.Sub(oldest) < g.idle {
+			return labelOther, ""
+		}
+
+		delete(g.seen, evicted)
+	}
+
+	g.seen[value] = now
+
+	return value, evicted
+}
+
+var flowErrorCodeRegexp = regexp.MustCompile(`\[Error Code: (\d+)\]`)
+
+// ErrorCode returns a low cardinality code for 'err' to be used as a label.
+func ErrorCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "not_found"
+	}
+
+	var s interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &s) {
+		return "grpc_" + strings.ToLower(s.GRPCStatus().Code().String())
+	}
+
+	return FlowErrorCode(err.Error())
+}
+
+// FlowErrorCode returns the code of a Flow (cadence) error message,
+// e.g. "flow_1007" for an invalid proposal key sequence number.
+func FlowErrorCode(message string) string {
+	if message == "" {
+		return "ok"
+	}
+	if m := flowErrorCodeRegexp.FindStringSubmatch(message); m != nil {
+		return "flow_" + m[1]
+	}
+	return "error"
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCardinalityGuard(t *testing.T) {
+	clock := common.NewVirtualClock(time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC))
+	g := NewCardinalityGuard(2, time.Hour, clock)
+
+	check := func(value, label, evicted string) {
+		t.Helper()
+		if l, e := g.Label(value); l != label || e != evicted {
+			t.Errorf("expected label for %q to be %q evicting %q, got %q evicting %q", value, label, evicted, l, e)
+		}
+	}
+
+	check("a", "a", "")
+	check("b", "b", "")
+	check("c", labelOther, "")
+	check("a", "a", "")
+	check("d", labelOther, "")
+
+	// "b" was seen least recently, it gives way once idle
+	clock.Advance(30 * time.Minute)
+	check("a", "a", "")
+	clock.Advance(30 * time.Minute)
+	check("c", "c", "b")
+	check("b", labelOther, "")
+	check("a", "a", "")
+
+	none := NewCardinalityGuard(0, time.Hour, clock)
+	if l, e := none.Label("a"); l != labelOther || e != "" {
+		t.Errorf("expected all values to be labeled %q, got %q evicting %q", labelOther, l, e)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected string
+	}{
+		{nil, "ok"},
+		{errors.New("something"), "error"},
+		{fmt.Errorf("wrapped: %w", status.Error(codes.Unavailable, "unavailable")), "grpc_unavailable"},
+		{errors.New("[Error Code: 1007] invalid proposal key"), "flow_1007"},
+	} {
+		if got := ErrorCode(c.err); got != c.expected {
+			t.Errorf("expected error code for %v to be %q, got %q", c.err, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	"github.com/flow-hydraulics/flow-pds/service/config"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Operation types
+const (
+	OperationSetup           = "setup"
+	OperationSettle          = "settle"
+	OperationMint            = "mint"
+	OperationEvents          = "events"
+	OperationTeardown        = "teardown"
+	OperationSendTransaction = "send_transaction"
+	OperationTransaction     = "transaction" // Final result of a transaction
+)
+
+// Distribution tiers, based on the number of packs in a distribution
+const (
+	TierNone   = "none" // Operation is not related to a distribution
+	TierSmall  = "small"
+	TierMedium = "medium"
+	TierLarge  = "large"
+)
+
+// Send rate limits
+const (
+	ThrottleGlobal  = "global"
+	ThrottleAccount = "account"
+)
+
+// Actions taken on transactions exceeding the size limits
+const (
+	OversizedSplit  = "split"
+	OversizedFailed = "failed"
+)
+
+// Clients of rate limited requests
+const (
+	RateLimitAPIKey = "api_key"
+	RateLimitIP     = "ip"
+)
+
+// Latency SLOs
+const (
+	SLOUserFacingLatency = "user_facing_latency"
+)
+
+const labelOther = "other"
+
+var (
+	operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flow_pds",
+		Name:      "operations_total",
+		Help:      "Number of operations handled.",
+	}, []string{"operation", "distribution", "tier", "error_code"})
+
+	operationDurations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flow_pds",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "tier"})
+
+	batchSizes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flow_pds",
+		Name:      "batch_size",
+		Help:      "Current size of new settlement and minting batches.",
+	}, []string{"operation"})
+
+	sendThrottles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flow_pds",
+		Name:      "send_throttled_total",
+		Help:      "Number of transaction sends delayed by a rate limit.",
+	}, []string{"limit", "account"})
+
+	sendThrottleDurations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flow_pds",
+		Name:      "send_throttled_seconds_total",
+		Help:      "Time transaction sends were delayed by a rate limit.",
+	}, []string{"limit", "account"})
+
+	oversizedTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flow_pds",
+		Name:      "oversized_transactions_total",
+		Help:      "Number of transactions exceeding the size limits, split into smaller batches or failed.",
+	}, []string{"name", "action"})
+
+	circuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flow_pds",
+		Name:      "access_api_circuit_open",
+		Help:      "1 while the circuit breaker of an Access API client is open.",
+	}, []string{"client"})
+
+	sloLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flow_pds",
+		Name:      "slo_latency_seconds",
+		Help:      "Latency last checked against a latency SLO.",
+	}, []string{"slo"})
+
+	sloBreached = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "flow_pds",
+		Name:      "slo_breached",
+		Help:      "1 while a latency SLO is breached and lower priority work is shed.",
+	}, []string{"slo"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "flow_pds",
+		Name:      "http_rate_limited_total",
+		Help:      "Number of HTTP requests refused by the rate limit.",
+	}, []string{"client"})
+
+	// Distributions which get their own label value, the rest are labeled "other"
+	distributions = NewCardinalityGuard(50, time.Hour, common.RealClock{})
+
+	// Label values of the operation series of distributions labeled
+	// individually, to delete them once a distribution is evicted
+	operationSeriesMu sync.Mutex
+	operationSeries   = map[string]map[[4]string]bool{}
+)
+
+func init() {
+	prometheus.MustRegister(operations, operationDurations, batchSizes, sendThrottles, sendThrottleDurations, oversizedTransactions, circuitOpen, sloLatency, sloBreached, rateLimited)
+}
+
+// Setup configures metrics according to 'cfg'.
+// Should be called once at startup.
+func Setup(cfg *config.Config) {
+	distributions = NewCardinalityGuard(cfg.MetricsMaxDistributionLabels, cfg.MetricsDistributionLabelIdle, common.RealClock{})
+}
+
+// Handler returns the HTTP handler exposing the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Distribution describes the distribution an operation relates to.
+type Distribution struct {
+	ID        uuid.UUID
+	PackCount uint
+}
+
+// Tier returns the tier of the distribution.
+func (d Distribution) Tier() string {
+	switch {
+	case d.ID == uuid.Nil:
+		return TierNone
+	case d.PackCount < 1000:
+		return TierSmall
+	case d.PackCount < 100000:
+		return TierMedium
+	default:
+		return TierLarge
+	}
+}
+
+// incOperation counts an operation regarding 'dist'. The series of a
+// distribution evicted from the labels are deleted.
+func incOperation(operation string, dist Distribution, errorCode string) {
+	operationSeriesMu.Lock()
+	defer operationSeriesMu.Unlock()
+
+	label := ""
+	if dist.ID != uuid.Nil {
+		var evicted string
+		label, evicted = distributions.Label(dist.ID.String())
+		for values := range operationSeries[evicted] {
+			operations.DeleteLabelValues(values[:]...)
+		}
+		delete(operationSeries, evicted)
+	}
+
+	values := [4]string{operation, label, dist.Tier(), errorCode}
+	if label != "" && label != labelOther {
+		if operationSeries[label] == nil {
+			operationSeries[label] = map[[4]string]bool{}
+		}
+		operationSeries[label][values] = true
+	}
+
+	operations.WithLabelValues(values[:]...).Inc()
+}
+
+// ObserveOperation records an operation regarding 'dist' which started at
+// 'start' and resulted in 'err'.
+func ObserveOperation(operation string, dist Distribution, start time.Time, err error) {
+	incOperation(operation, dist, ErrorCode(err))
+	operationDurations.WithLabelValues(operation, dist.Tier()).Observe(time.Since(start).Seconds())
+}
+
+// CountOperation records an operation regarding 'dist' without a duration.
+func CountOperation(operation string, dist Distribution, errorCode string) {
+	incOperation(operation, dist, errorCode)
+}
+
+// SetBatchSize records the current batch size of 'operation'.
+func SetBatchSize(operation string, size int) {
+	batchSizes.WithLabelValues(operation).Set(float64(size))
+}
+
+// ObserveSendThrottle records a transaction send delayed by 'wait' because of
+// 'limit', 'account' is the throttled account for per account limits.
+func ObserveSendThrottle(limit, account string, wait time.Duration) {
+	sendThrottles.WithLabelValues(limit, account).Inc()
+	sendThrottleDurations.WithLabelValues(limit, account).Add(wait.Seconds())
+}
+
+// CountOversizedTransaction records a transaction exceeding the size limits,
+// 'name' being the name of the transaction and 'action' what was done with it.
+func CountOversizedTransaction(name, action string) {
+	oversizedTransactions.WithLabelValues(name, action).Inc()
+}
+
+// SetCircuitOpen records whether the circuit breaker of Access API 'client'
+// is open.
+func SetCircuitOpen(client string, open bool) {
+	v := 0.0
+	if open {
+		v = 1
+	}
+	circuitOpen.WithLabelValues(client).Set(v)
+}
+
+// SetSLOLatency records the latency last checked against 'slo'.
+func SetSLOLatency(slo string, latency time.Duration) {
+	sloLatency.WithLabelValues(slo).Set(latency.Seconds())
+}
+
+// SetSLOBreached records whether 'slo' is breached.
+func SetSLOBreached(slo string, breached bool) {
+	v := 0.0
+	if breached {
+		v = 1
+	}
+	sloBreached.WithLabelValues(slo).Set(v)
+}
+
+// CountRateLimited records a request refused by the rate limit, 'client' is
+// what the request was keyed by (RateLimitAPIKey or RateLimitIP).
+func CountRateLimited(client string) {
+	rateLimited.WithLabelValues(client).Inc()
+}
@@ -1,19 +1,32 @@
 package transactions
 
 import (
+	"encoding/hex"
+	"time"
+
 	"github.com/flow-hydraulics/flow-pds/service/common"
 	"github.com/google/uuid"
+	"github.com/onflow/flow-go-sdk"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&StorableTransaction{}); err != nil {
+	if err := db.AutoMigrate(&StorableTransaction{}, &TransactionAttempt{}); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (TransactionAttempt) TableName() string {
+	return "transaction_attempts"
+}
+
+func (a *TransactionAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return nil
+}
+
 func (StorableTransaction) TableName() string {
 	return "transactions"
 }
@@ -33,20 +46,267 @@ func GetTransaction(db *gorm.DB, id uuid.UUID) (*StorableTransaction, error) {
 	return &t, db.First(&t, id).Error
 }
 
-func GetNextSendable(db *gorm.DB) (*StorableTransaction, error) {
+// GetNextSendable returns the least recently updated transaction of the
+// 'priority' lane which is sendable (state is init or retry, not held) at
+// 'now'.
+func GetNextSendable(db *gorm.DB, now time.Time, priority Priority) (*StorableTransaction, error) {
 	t := StorableTransaction{}
 	err := db.Order("updated_at asc").
 		Clauses(clause.Locking{Strength: "UPDATE SKIP LOCKED"}).
-		Where(map[string]interface{}{"state": common.TransactionStateInit}).
-		Or(map[string]interface{}{"state": common.TransactionStateRetry}).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry}).
+		Where("held = ?", false).
+		Where(&StorableTransaction{Priority: priority}).
+		Where("job_version = ?", JobVersion).
+		Where("send_not_before IS NULL OR send_not_before <= ?", now).
+		First(&t).Error
+	return &t, err
+}
+
+// GetOldestPending returns the longest waiting transaction of the 'priority'
+// lane which is due at 'now' and not yet complete (init, retry or sent).
+// Held transactions are not waiting to be sent.
+func GetOldestPending(db *gorm.DB, now time.Time, priority Priority) (*StorableTransaction, error) {
+	t := StorableTransaction{}
+	err := db.Order("created_at asc").
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry, common.TransactionStateSent}).
+		Where("held = ?", false).
+		Where(&StorableTransaction{Priority: priority}).
+		Where("send_not_before IS NULL OR send_not_before <= ?", now).
 		First(&t).Error
 	return &t, err
 }
 
+// ListDeadLetter lists dead-letter transactions, most recently updated first.
+func ListDeadLetter(db *gorm.DB, limit, offset int) ([]StorableTransaction, error) {
+	list := []StorableTransaction{}
+	return list, db.
+		Where(&StorableTransaction{State: common.TransactionStateDeadLetter}).
+		Order("updated_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&list).Error
+}
+
+// ListSentIDs returns the IDs of all transactions in 'sent' state, least
+// recently updated first. Transactions of a newer JobVersion are left out.
+func ListSentIDs(db *gorm.DB) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	return ids, db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{State: common.TransactionStateSent}).
+		Where("job_version <= ?", JobVersion).
+		Order("updated_at asc").
+		Pluck("id", &ids).Error
+}
+
 func GetNextSent(db *gorm.DB) (*StorableTransaction, error) {
 	t := StorableTransaction{}
 	err := db.Order("updated_at asc").
 		Where(map[string]interface{}{"state": common.TransactionStateSent}).
+		Where("job_version <= ?", JobVersion).
 		First(&t).Error
 	return &t, err
 }
+
+// ListOutdatedJobs returns transactions of an older JobVersion which are
+// still to be sent (init or retry), at most 'limit'.
+func ListOutdatedJobs(db *gorm.DB, limit int) ([]StorableTransaction, error) {
+	list := []StorableTransaction{}
+	return list, db.
+		Where("job_version < ?", JobVersion).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry}).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&list).Error
+}
+
+// CountNewerJobs returns the number of unfinished transactions of a newer
+// JobVersion, created by a newer version of the PDS.
+func CountNewerJobs(db *gorm.DB) (int64, error) {
+	var count int64
+	return count, db.Model(&StorableTransaction{}).
+		Where("job_version > ?", JobVersion).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry, common.TransactionStateSent, common.TransactionStateDeadLetter}).
+		Count(&count).Error
+}
+
+// InsertAttempt records the current send of 't' as 'tx', call before it is
+// sent.
+func (t *StorableTransaction) InsertAttempt(db *gorm.DB, tx *flow.Transaction) error {
+	a := TransactionAttempt{
+		StorableTransactionID: t.ID,
+		Attempt:               t.RetryCount + 1,
+		TransactionID:         t.TransactionID,
+		ReferenceBlockHeight:  t.ReferenceBlockHeight,
+		ProposerKeyIndex:      t.ProposerKeyIndex,
+		State:                 common.TransactionStateSent,
+		DistributionID:        t.DistributionID,
+		Name:                  t.Name,
+		ScriptHash:            ScriptHash(t.Script),
+		Arguments:             t.Arguments,
+		RLP:                   hex.EncodeToString(tx.Encode()),
+	}
+	return db.Omit(clause.Associations).Create(&a).Error
+}
+
+// UpdateAttempt records the outcome (state, error and fees of 't') of the
+// current send of 't'.
+func (t *StorableTransaction) UpdateAttempt(db *gorm.DB) error {
+	updates := map[string]interface{}{"state": t.State, "error": t.Error}
+	if t.fees != nil {
+		updates["fees"] = t.fees.Amount
+		updates["inclusion_effort"] = t.fees.InclusionEffort
+		updates["execution_effort"] = t.fees.ExecutionEffort
+	}
+	return db.Model(&TransactionAttempt{}).
+		Where("storable_transaction_id = ? AND transaction_id = ?", t.ID, t.TransactionID).
+		Updates(updates).Error
+}
+
+// FeeSummary is the fees paid for the transactions of one name.
+type FeeSummary struct {
+	Name     string
+	Attempts uint
+	Fees     uint64
+}
+
+// SumFeesByName returns the fees paid for the transactions of a
+// distribution per transaction name.
+func SumFeesByName(db *gorm.DB, distributionID uuid.UUID) ([]FeeSummary, error) {
+	list := []FeeSummary{}
+	return list, db.Model(&TransactionAttempt{}).
+		Select("name, count(*) as attempts, coalesce(sum(fees), 0) as fees").
+		Where(&TransactionAttempt{DistributionID: distributionID}).
+		Group("name").
+		Order("name asc").
+		Scan(&list).Error
+}
+
+// ListAttemptsByDistribution lists the transaction attempts of a
+// distribution, oldest first.
+func ListAttemptsByDistribution(db *gorm.DB, distributionID uuid.UUID, limit, offset int) ([]TransactionAttempt, error) {
+	list := []TransactionAttempt{}
+	return list, db.
+		Where(&TransactionAttempt{DistributionID: distributionID}).
+		Order("created_at asc").
+		Limit(limit).
+		Offset(offset).
+		Find(&list).Error
+}
+
+// CountPending returns the number of transactions named 'name' (any name if
+// empty) of a distribution which are still to be sent or waiting for a result.
+func CountPending(db *gorm.DB, distributionID uuid.UUID, name string) (int64, error) {
+	var count int64
+	return count, db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID, Name: name}).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry, common.TransactionStateSent}).
+		Count(&count).Error
+}
+
+// CountInStates returns the number of transactions named 'name' of a
+// distribution which are in any of 'states'.
+func CountInStates(db *gorm.DB, distributionID uuid.UUID, name string, states []common.TransactionState) (int64, error) {
+	var count int64
+	return count, db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID, Name: name}).
+		Where("state IN ?", states).
+		Count(&count).Error
+}
+
+// CountPendingForPack returns the number of transactions named 'name' of a
+// pack which are still to be sent or waiting for a result.
+func CountPendingForPack(db *gorm.DB, packID uuid.UUID, name string) (int64, error) {
+	var count int64
+	return count, db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{PackID: packID, Name: name}).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry, common.TransactionStateSent}).
+		Count(&count).Error
+}
+
+// CancelUnsent cancels the transactions named 'names' of a distribution which
+// have not been sent (init, retry or dead-letter). Sent transactions can not
+// be cancelled. Returns the number of cancelled transactions.
+func CancelUnsent(db *gorm.DB, distributionID uuid.UUID, names []string) (int64, error) {
+	res := db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID}).
+		Where("name IN ?", names).
+		Where("state IN ?", []common.TransactionState{common.TransactionStateInit, common.TransactionStateRetry, common.TransactionStateDeadLetter}).
+		Update("state", common.TransactionStateCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// Hold the transactions named 'names' of a distribution which have not been
+// sent yet (init), so they are not sent until released. Returns the number of
+// held transactions.
+func Hold(db *gorm.DB, distributionID uuid.UUID, names []string) (int64, error) {
+	res := db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID, State: common.TransactionStateInit}).
+		Where("name IN ?", names).
+		Update("held", true)
+	return res.RowsAffected, res.Error
+}
+
+// Reschedule sets when the transactions named 'names' of a distribution
+// which have not been sent yet are sent, nil sends them at once. Retried
+// transactions keep the time of their next attempt. Returns the number of
+// rescheduled transactions.
+func Reschedule(db *gorm.DB, distributionID uuid.UUID, names []string, notBefore *time.Time) (int64, error) {
+	res := db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID, State: common.TransactionStateInit}).
+		Where("name IN ?", names).
+		Update("send_not_before", notBefore)
+	return res.RowsAffected, res.Error
+}
+
+// Release the held transactions of a distribution, so they are sent again.
+// Returns the number of released transactions.
+func Release(db *gorm.DB, distributionID uuid.UUID) (int64, error) {
+	res := db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID}).
+		Where("held = ?", true).
+		Update("held", false)
+	return res.RowsAffected, res.Error
+}
+
+// CancelDeadLetter cancels the dead-letter transactions named 'name' of a
+// distribution. Returns the number of cancelled transactions.
+func CancelDeadLetter(db *gorm.DB, distributionID uuid.UUID, name string) (int64, error) {
+	res := db.Model(&StorableTransaction{}).
+		Where(&StorableTransaction{DistributionID: distributionID, Name: name, State: common.TransactionStateDeadLetter}).
+		Update("state", common.TransactionStateCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// CountByState returns the number of transactions of a distribution per state.
+func CountByState(db *gorm.DB, distributionID uuid.UUID) (map[common.TransactionState]uint, error) {
+	rows := []struct {
+		State common.TransactionState
+		Count uint
+	}{}
+	err := db.Model(&StorableTransaction{}).
+		Select("state, count(*) as count").
+		Where(&StorableTransaction{DistributionID: distributionID}).
+		Group("state").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[common.TransactionState]uint, len(rows))
+	for _, r := range rows {
+		res[r.State] = r.Count
+	}
+	return res, nil
+}
+
+// ListRecentAttempts lists the latest 'limit' attempts in 'state' of a
+// distribution which are named any of 'names', newest first.
+func ListRecentAttempts(db *gorm.DB, distributionID uuid.UUID, names []string, state common.TransactionState, limit int) ([]TransactionAttempt, error) {
+	list := []TransactionAttempt{}
+	return list, db.
+		Select("id", "created_at", "updated_at", "name", "state").
+		Where(&TransactionAttempt{DistributionID: distributionID, State: state}).
+		Where("name IN ?", names).
+		Order("updated_at desc").
+		Limit(limit).
+		Find(&list).Error
+}
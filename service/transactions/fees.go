@@ -0,0 +1,52 @@
+package transactions
+
+import (
+	"strings"
+
+	"github.com/flow-hydraulics/flow-pds/service/flow_helpers"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Fees paid for a transaction, in the smallest unit of FLOW (UFix64, 1e-8).
+type Fees struct {
+	Amount          uint64
+	InclusionEffort uint64
+	ExecutionEffort uint64
+}
+
+// FeesFromEvents returns the fees of a transaction from the events of its
+// result: 'FlowFees.FeesDeducted' (amount and effort) or on older networks
+// 'FlowFees.TokensDeposited' (amount only). Returns false if neither is
+// found, e.g. on the emulator with fees disabled.
+func FeesFromEvents(events []flow.Event) (Fees, bool) {
+	var deposited *Fees
+
+	for _, e := range events {
+		switch {
+		case strings.HasSuffix(e.Type, ".FlowFees.FeesDeducted"):
+			values := flow_helpers.EventValuesToMap(e)
+			return Fees{
+				Amount:          ufix64Value(values["amount"]),
+				InclusionEffort: ufix64Value(values["inclusionEffort"]),
+				ExecutionEffort: ufix64Value(values["executionEffort"]),
+			}, true
+		case strings.HasSuffix(e.Type, ".FlowFees.TokensDeposited") && deposited == nil:
+			values := flow_helpers.EventValuesToMap(e)
+			deposited = &Fees{Amount: ufix64Value(values["amount"])}
+		}
+	}
+
+	if deposited != nil {
+		return *deposited, true
+	}
+
+	return Fees{}, false
+}
+
+func ufix64Value(v cadence.Value) uint64 {
+	if f, ok := v.(cadence.UFix64); ok {
+		return uint64(f)
+	}
+	return 0
+}
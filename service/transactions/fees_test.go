@@ -0,0 +1,38 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func feeEvent(typ string, fields map[string]uint64, order ...string) flow.Event {
+	eventType := &cadence.EventType{}
+	values := []cadence.Value{}
+	for _, name := range order {
+		eventType.Fields = append(eventType.Fields, cadence.Field{Identifier: name, Type: cadence.UFix64Type{}})
+		values = append(values, cadence.UFix64(fields[name]))
+	}
+	return flow.Event{Type: typ, Value: cadence.NewEvent(values).WithType(eventType)}
+}
+
+func TestFeesFromEvents(t *testing.T) {
+	deducted := feeEvent("A.f919ee77447b7497.FlowFees.FeesDeducted",
+		map[string]uint64{"amount": 1000, "inclusionEffort": 100000000, "executionEffort": 42},
+		"amount", "inclusionEffort", "executionEffort")
+	deposited := feeEvent("A.f919ee77447b7497.FlowFees.TokensDeposited", map[string]uint64{"amount": 10}, "amount")
+	other := feeEvent("A.1654653399040a61.FlowToken.TokensDeposited", map[string]uint64{"amount": 99}, "amount")
+
+	if fees, ok := FeesFromEvents([]flow.Event{other, deposited, deducted}); !ok || fees != (Fees{Amount: 1000, InclusionEffort: 100000000, ExecutionEffort: 42}) {
+		t.Fatalf("expected the deducted fees, got %+v (%t)", fees, ok)
+	}
+
+	if fees, ok := FeesFromEvents([]flow.Event{other, deposited}); !ok || fees != (Fees{Amount: 10}) {
+		t.Fatalf("expected the deposited fees, got %+v (%t)", fees, ok)
+	}
+
+	if _, ok := FeesFromEvents([]flow.Event{other}); ok {
+		t.Fatal("expected no fees")
+	}
+}
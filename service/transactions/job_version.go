@@ -0,0 +1,52 @@
+package transactions
+
+import "fmt"
+
+// JobVersion is the version of the format of stored transactions (their
+// script, arguments and how they are handled once sent). Increment it when
+// that changes and add a migration from the previous version to
+// jobMigrations, or leave the migration out if transactions of the previous
+// version can not be migrated and have to be re-planned.
+// Transactions of other versions are not sent.
+const JobVersion uint = 2
+
+// JobMigration migrates a stored transaction to the next version.
+type JobMigration func(t *StorableTransaction) error
+
+// jobMigrations by the version they migrate from.
+var jobMigrations = map[uint]JobMigration{
+	// Stored before transactions were versioned, same format as version 1
+	0: func(t *StorableTransaction) error { return nil },
+	// Reveal transactions of version 2 leave the salt out, it is filled in
+	// when sending. The app clears the salt stored by version 1.
+	1: func(t *StorableTransaction) error { return nil },
+}
+
+// MigrateJob migrates 't' to JobVersion. Returns false if there is no
+// migration path, 't' needs to be re-planned then. Transactions of a newer
+// version are never migrated.
+func (t *StorableTransaction) MigrateJob() (bool, error) {
+	return t.migrateJob(jobMigrations, JobVersion)
+}
+
+func (t *StorableTransaction) migrateJob(migrations map[uint]JobMigration, to uint) (bool, error) {
+	if t.JobVersion > to {
+		return false, nil
+	}
+
+	// Check the whole path first so 't' is left as is if it can not be migrated
+	for v := t.JobVersion; v < to; v++ {
+		if _, ok := migrations[v]; !ok {
+			return false, nil
+		}
+	}
+
+	for t.JobVersion < to {
+		if err := migrations[t.JobVersion](t); err != nil {
+			return false, fmt.Errorf("error while migrating transaction from job version %d: %w", t.JobVersion, err)
+		}
+		t.JobVersion++
+	}
+
+	return true, nil
+}
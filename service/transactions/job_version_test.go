@@ -0,0 +1,57 @@
+package transactions
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrateJob(t *testing.T) {
+	calls := []uint{}
+	migrations := map[uint]JobMigration{}
+	for _, v := range []uint{0, 1, 3} {
+		v := v
+		migrations[v] = func(t *StorableTransaction) error {
+			calls = append(calls, v)
+			return nil
+		}
+	}
+
+	// Migrates step by step
+	tx := StorableTransaction{JobVersion: 0}
+	if ok, err := tx.migrateJob(migrations, 2); !ok || err != nil {
+		t.Fatalf("expected migration, got %t, %v", ok, err)
+	}
+	if tx.JobVersion != 2 || len(calls) != 2 || calls[0] != 0 || calls[1] != 1 {
+		t.Fatalf("expected version 2 after migrations 0 and 1, got %d after %v", tx.JobVersion, calls)
+	}
+
+	// Current version, nothing to do
+	calls = calls[:0]
+	if ok, _ := tx.migrateJob(migrations, 2); !ok || len(calls) != 0 {
+		t.Fatalf("expected no migrations, got %t, %v", ok, calls)
+	}
+
+	// No path from 2 to 4, left as is
+	if ok, _ := tx.migrateJob(migrations, 4); ok || tx.JobVersion != 2 || len(calls) != 0 {
+		t.Fatalf("expected no migration path, got version %d after %v", tx.JobVersion, calls)
+	}
+
+	// Newer version
+	tx = StorableTransaction{JobVersion: 5}
+	if ok, _ := tx.migrateJob(migrations, 4); ok || tx.JobVersion != 5 {
+		t.Fatalf("expected newer version not to be migrated, got version %d", tx.JobVersion)
+	}
+
+	// Failing migration
+	migrations[1] = func(t *StorableTransaction) error { return errors.New("broken") }
+	tx = StorableTransaction{JobVersion: 1}
+	if ok, err := tx.migrateJob(migrations, 2); ok || err == nil {
+		t.Fatal("expected migration to fail")
+	}
+
+	// Current migrations cover all older versions
+	tx = StorableTransaction{JobVersion: 0}
+	if ok, err := tx.MigrateJob(); !ok || err != nil || tx.JobVersion != JobVersion {
+		t.Fatalf("expected migration to %d, got %d, %v", JobVersion, tx.JobVersion, err)
+	}
+}
@@ -0,0 +1,73 @@
+package transactions
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy configures how expired and failed (retryable) transactions
+// are retried.
+type RetryPolicy struct {
+	MaxAttempts uint          // Including the first send, 0 means no limit
+	Backoff     time.Duration // Delay before the first retry, doubled for each retry after that
+	MaxBackoff  time.Duration // Max delay between retries, 0 means no limit
+}
+
+// Delay returns how long to wait before the 'retry'th (1 = first) retry.
+func (p RetryPolicy) Delay(retry uint) time.Duration {
+	d := p.Backoff
+	for i := uint(1); i < retry; i++ {
+		if (p.MaxBackoff > 0 && d >= p.MaxBackoff) || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// Retry sets the transaction to be rebuilt (fresh reference block and
+// proposal key) and resent once the backoff delay has passed at 'now'.
+// The transaction is moved to the dead-letter state instead if it is out of
+// attempts.
+func (t *StorableTransaction) Retry(reason string, now time.Time, p RetryPolicy) {
+	t.Error = reason
+
+	if p.MaxAttempts > 0 && t.RetryCount+1 >= p.MaxAttempts {
+		t.State = common.TransactionStateDeadLetter
+		t.Error = fmt.Sprintf("giving up after %d attempts: %s", t.RetryCount+1, reason)
+		t.logger().WithFields(log.Fields{"error": t.Error}).Error("Transaction out of attempts, moved to dead-letter")
+		return
+	}
+
+	t.RetryCount++
+	t.State = common.TransactionStateRetry
+
+	sendNotBefore := now.Add(p.Delay(t.RetryCount))
+	t.SendNotBefore = &sendNotBefore
+}
+
+// Requeue resets a dead-letter transaction to be sent again as if it was
+// new, attempts start over.
+func (t *StorableTransaction) Requeue() error {
+	if t.State != common.TransactionStateDeadLetter {
+		return fmt.Errorf("only dead-letter transactions can be requeued, state is '%s'", t.State)
+	}
+
+	// Older versions are migrated (or re-planned) once requeued
+	if t.JobVersion > JobVersion {
+		return fmt.Errorf("transaction was created by a newer job version %d, this version sends %d", t.JobVersion, JobVersion)
+	}
+
+	t.State = common.TransactionStateInit
+	t.RetryCount = 0
+	t.SendNotBefore = nil
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package transactions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flow-hydraulics/flow-pds/service/common"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, e := range expected {
+		if d := p.Delay(uint(i + 1)); d != e {
+			t.Errorf("retry %d: expected delay %s, got %s", i+1, e, d)
+		}
+	}
+
+	if d := (RetryPolicy{Backoff: time.Second}).Delay(100); d <= 0 {
+		t.Errorf("expected a positive delay without max backoff, got %s", d)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	now := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	p := RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute}
+
+	tx := &StorableTransaction{State: common.TransactionStateSent}
+
+	tx.Retry("expired", now, p)
+	if tx.State != common.TransactionStateRetry || tx.RetryCount != 1 {
+		t.Fatalf("expected first retry, got state %s retry count %d", tx.State, tx.RetryCount)
+	}
+	if !tx.SendNotBefore.Equal(now.Add(time.Second)) {
+		t.Errorf("expected send not before %s, got %s", now.Add(time.Second), tx.SendNotBefore)
+	}
+
+	tx.Retry("expired", now, p)
+	if tx.State != common.TransactionStateRetry || tx.RetryCount != 2 {
+		t.Fatalf("expected second retry, got state %s retry count %d", tx.State, tx.RetryCount)
+	}
+	if !tx.SendNotBefore.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("expected send not before %s, got %s", now.Add(2*time.Second), tx.SendNotBefore)
+	}
+
+	tx.Retry("expired", now, p)
+	if tx.State != common.TransactionStateDeadLetter {
+		t.Fatalf("expected dead-letter after max attempts, got %s", tx.State)
+	}
+
+	if err := tx.Requeue(); err != nil {
+		t.Fatal(err)
+	}
+	if tx.State != common.TransactionStateInit || tx.RetryCount != 0 || tx.SendNotBefore != nil {
+		t.Errorf("expected requeued transaction to start over, got state %s retry count %d", tx.State, tx.RetryCount)
+	}
+
+	if err := tx.Requeue(); err == nil {
+		t.Error("expected an error when requeueing a transaction which is not in dead-letter state")
+	}
+}
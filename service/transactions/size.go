@@ -0,0 +1,109 @@
+package transactions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// envelopeOverhead is a generous estimate of what the reference block,
+// proposal key, payer, authorizers and signatures add to the encoded size of
+// a transaction.
+const envelopeOverhead = 1000
+
+// SizeLimits are the network limits a transaction is checked against before
+// it is sent. Zero values mean no limit.
+type SizeLimits struct {
+	MaxByteSize       int // Encoded size of the signed transaction
+	MaxArgumentValues int // Number of argument values, items of arrays and dictionaries included
+}
+
+// TooLargeError is returned by CheckSize for a transaction exceeding the
+// size limits.
+type TooLargeError struct {
+	ByteSize       int
+	ArgumentValues int
+	Limits         SizeLimits
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf(
+		"transaction exceeds size limits: %d bytes (max %d), %d argument values (max %d)",
+		e.ByteSize, e.Limits.MaxByteSize, e.ArgumentValues, e.Limits.MaxArgumentValues,
+	)
+}
+
+// Ratio returns how much the transaction would need to shrink to fit the
+// limits, e.g. 0.5 if it is twice the size allowed.
+func (e *TooLargeError) Ratio() float64 {
+	ratio := 1.0
+	if e.Limits.MaxByteSize > 0 && e.ByteSize > e.Limits.MaxByteSize {
+		ratio = float64(e.Limits.MaxByteSize) / float64(e.ByteSize)
+	}
+	if e.Limits.MaxArgumentValues > 0 && e.ArgumentValues > e.Limits.MaxArgumentValues {
+		if r := float64(e.Limits.MaxArgumentValues) / float64(e.ArgumentValues); r < ratio {
+			ratio = r
+		}
+	}
+	return ratio
+}
+
+// CheckSize returns a TooLargeError if the encoded transaction or its number
+// of argument values exceed 'limits'.
+func (t *StorableTransaction) CheckSize(limits SizeLimits) error {
+	rawArgs := [][]byte{}
+	if err := json.Unmarshal(t.Arguments, &rawArgs); err != nil {
+		return err
+	}
+
+	tx := flow.NewTransaction().SetScript([]byte(t.Script))
+	for _, a := range rawArgs {
+		tx.AddRawArgument(a)
+	}
+
+	args, err := t.ArgumentsAsCadence()
+	if err != nil {
+		return err
+	}
+
+	values := 0
+	for _, a := range args {
+		values += countValues(a)
+	}
+
+	size := len(tx.Encode()) + envelopeOverhead
+
+	if (limits.MaxByteSize > 0 && size > limits.MaxByteSize) ||
+		(limits.MaxArgumentValues > 0 && values > limits.MaxArgumentValues) {
+		return &TooLargeError{ByteSize: size, ArgumentValues: values, Limits: limits}
+	}
+
+	return nil
+}
+
+// countValues returns the number of values in 'v', counting each item of
+// arrays and dictionaries (but not the containers themselves).
+func countValues(v cadence.Value) int {
+	switch v := v.(type) {
+	case cadence.Array:
+		n := 0
+		for _, item := range v.Values {
+			n += countValues(item)
+		}
+		return n
+	case cadence.Dictionary:
+		n := 0
+		for _, pair := range v.Pairs {
+			n += countValues(pair.Key) + countValues(pair.Value)
+		}
+		return n
+	case cadence.Optional:
+		if v.Value == nil {
+			return 1
+		}
+		return countValues(v.Value)
+	}
+	return 1
+}
@@ -0,0 +1,42 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+)
+
+func TestCheckSize(t *testing.T) {
+	ids := make([]cadence.Value, 100)
+	for i := range ids {
+		ids[i] = cadence.UInt64(i)
+	}
+
+	tx, err := NewTransaction("settle", []byte("transaction {}"), []cadence.Value{cadence.UInt64(7), cadence.NewArray(ids)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.CheckSize(SizeLimits{}); err != nil {
+		t.Fatalf("expected no error without limits, got %s", err)
+	}
+
+	err = tx.CheckSize(SizeLimits{MaxArgumentValues: 50})
+	tooLarge, ok := err.(*TooLargeError)
+	if !ok {
+		t.Fatalf("expected a TooLargeError, got %v", err)
+	}
+	if tooLarge.ArgumentValues != 101 {
+		t.Errorf("expected 101 argument values, got %d", tooLarge.ArgumentValues)
+	}
+	if r := tooLarge.Ratio(); r > 0.5 || r < 0.49 {
+		t.Errorf("expected to shrink to about half, got %f", r)
+	}
+
+	if err := tx.CheckSize(SizeLimits{MaxByteSize: 1000}); err == nil {
+		t.Error("expected the encoded size to exceed 1000 bytes")
+	}
+	if err := tx.CheckSize(SizeLimits{MaxByteSize: 1_500_000, MaxArgumentValues: 101}); err != nil {
+		t.Errorf("expected the transaction to fit, got %s", err)
+	}
+}
@@ -2,6 +2,8 @@ package transactions
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -12,50 +14,71 @@ import (
 	"github.com/onflow/cadence"
 	c_json "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go-sdk"
-	"github.com/onflow/flow-go-sdk/client"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// Priority is the send lane of a transaction, see Priorities.
+type Priority string
+
+const (
+	PriorityUserFacing Priority = "user-facing" // Reveals and opens requested by end users
+	PrioritySettlement Priority = "settlement"  // Setup, settlement and distribution state changes
+	PriorityMinting    Priority = "minting"     // Minting of packs
+)
+
+// Priorities lists the send lanes from the highest priority to the lowest.
+var Priorities = []Priority{PriorityUserFacing, PrioritySettlement, PriorityMinting}
+
 // StorableTransaction represents a Flow transaction.
-// It stores the script and arguments of a transaction.
+// It stores the script and arguments of a transaction. All transactions the
+// PDS sends go through the 'transactions' table, state changes:
+// init -> sent -> complete (sealed) or failed, sent -> retry -> sent,
+// retry -> dead-letter (out of attempts) -> init (requeued),
+// init, retry or dead-letter -> cancelled (distribution aborted or retried).
+// Held transactions (init) are not sent while their distribution is paused.
 type StorableTransaction struct {
 	gorm.Model
 	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
 
 	State         common.TransactionState `gorm:"column:state;not null;default:null;index"`
 	Error         string                  `gorm:"column:error"`
-	RetryCount    uint                    `gorm:"column:retry_count"` // TODO increment this
+	RetryCount    uint                    `gorm:"column:retry_count"` // Number of times the transaction has been set to be retried
 	TransactionID string                  `gorm:"column:transaction_id"`
 
+	ReferenceBlockHeight uint64     `gorm:"column:reference_block_height"` // Height of the reference block of the latest sent transaction
+	ProposerKeyIndex     int        `gorm:"column:proposer_key_index"`     // Proposal key of the latest sent transaction
+	SendNotBefore        *time.Time `gorm:"column:send_not_before;index"`  // Optional, the transaction is not sent before this
+	Priority             Priority   `gorm:"column:priority;not null;default:settlement;index"`
+	JobVersion           uint       `gorm:"column:job_version;not null;default:0;index"` // Version of the code (format) which created the transaction, see JobVersion
+	Held                 bool       `gorm:"column:held;not null;default:false;index"`    // Not sent while set, its distribution is paused
+
 	Name      string         `gorm:"column:name"` // Just a way to identify a transaction
 	Script    string         `gorm:"column:script"`
 	Arguments datatypes.JSON `gorm:"column:arguments"`
 
 	DistributionID uuid.UUID `gorm:"column:distribution_id;index"` // NOTE: Not a proper foreign key
+	PackID         uuid.UUID `gorm:"column:pack_id;index"`         // Optional, NOTE: Not a proper foreign key
+	BatchSize      int       `gorm:"column:batch_size"`            // Optional, number of items in a batch transaction
+
+	fees          *Fees           // Fees of the current attempt, once executed
+	sendArguments []cadence.Value // Arguments of the current attempt if set, see SetSendArguments
 }
 
 func NewTransaction(name string, script []byte, arguments []cadence.Value) (*StorableTransaction, error) {
-	argsBytes := make([][]byte, len(arguments))
-	for i, a := range arguments {
-		b, err := c_json.Encode(a)
-		if err != nil {
-			return nil, err
-		}
-		argsBytes[i] = b
-	}
-
-	argsJSON, err := json.Marshal(argsBytes)
+	argsJSON, err := encodeArguments(arguments)
 	if err != nil {
 		return nil, err
 	}
 
 	transaction := StorableTransaction{
-		State:     common.TransactionStateInit,
-		Name:      name,
-		Script:    string(script),
-		Arguments: argsJSON,
+		State:      common.TransactionStateInit,
+		Priority:   PrioritySettlement,
+		JobVersion: JobVersion,
+		Name:       name,
+		Script:     string(script),
+		Arguments:  argsJSON,
 	}
 
 	return &transaction, nil
@@ -72,6 +95,86 @@ func NewTransactionWithDistributionID(name string, script []byte, arguments []ca
 	return transaction, nil
 }
 
+// Split returns new transactions with the same script and arguments as 't'
+// except for the array argument at 'argIndex', which is split into chunks of
+// at most 'size' items, one per transaction.
+func (t *StorableTransaction) Split(argIndex, size int) ([]*StorableTransaction, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid batch size %d", size)
+	}
+
+	args, err := t.ArgumentsAsCadence()
+	if err != nil {
+		return nil, err
+	}
+
+	if argIndex < 0 || argIndex >= len(args) {
+		return nil, fmt.Errorf("transaction has no argument at index %d", argIndex)
+	}
+
+	arr, ok := args[argIndex].(cadence.Array)
+	if !ok {
+		return nil, fmt.Errorf("argument at index %d is not an array", argIndex)
+	}
+
+	res := []*StorableTransaction{}
+	for start := 0; start < len(arr.Values); start += size {
+		end := start + size
+		if end > len(arr.Values) {
+			end = len(arr.Values)
+		}
+
+		chunkArgs := make([]cadence.Value, len(args))
+		copy(chunkArgs, args)
+		chunkArgs[argIndex] = cadence.NewArray(arr.Values[start:end])
+
+		chunk, err := NewTransactionWithDistributionID(t.Name, []byte(t.Script), chunkArgs, t.DistributionID)
+		if err != nil {
+			return nil, err
+		}
+
+		chunk.PackID = t.PackID
+		chunk.Priority = t.Priority
+		chunk.BatchSize = end - start
+
+		res = append(res, chunk)
+	}
+
+	return res, nil
+}
+
+func encodeArguments(arguments []cadence.Value) (datatypes.JSON, error) {
+	argsBytes := make([][]byte, len(arguments))
+	for i, a := range arguments {
+		b, err := c_json.Encode(a)
+		if err != nil {
+			return nil, err
+		}
+		argsBytes[i] = b
+	}
+
+	return json.Marshal(argsBytes)
+}
+
+// SetArguments replaces the stored arguments of 't'.
+func (t *StorableTransaction) SetArguments(arguments []cadence.Value) error {
+	argsJSON, err := encodeArguments(arguments)
+	if err != nil {
+		return err
+	}
+
+	t.Arguments = argsJSON
+
+	return nil
+}
+
+// SetSendArguments sets the arguments 't' is prepared with instead of the
+// stored ones, e.g. to fill in secrets which must not be stored. They are
+// never saved.
+func (t *StorableTransaction) SetSendArguments(arguments []cadence.Value) {
+	t.sendArguments = arguments
+}
+
 func (t *StorableTransaction) ArgumentsAsCadence() ([]cadence.Value, error) {
 	bytes := [][]byte{}
 	if err := json.Unmarshal(t.Arguments, &bytes); err != nil {
@@ -90,11 +193,16 @@ func (t *StorableTransaction) ArgumentsAsCadence() ([]cadence.Value, error) {
 	return argsCadence, nil
 }
 
-// Prepare parses the transaction into a sendable state.
-func (t *StorableTransaction) Prepare(ctx context.Context, flowClient *client.Client, account *flow_helpers.Account, gasLimit uint64) (*flow.Transaction, flow_helpers.UnlockKeyFunc, error) {
-	args, err := t.ArgumentsAsCadence()
-	if err != nil {
-		return nil, nil, err
+// Prepare parses the transaction into a sendable state, referencing the
+// block from 'refBlocks'.
+func (t *StorableTransaction) Prepare(ctx context.Context, flowClient flow_helpers.FlowClient, refBlocks *flow_helpers.ReferenceBlockCache, account *flow_helpers.Account, gasLimit uint64) (*flow.Transaction, flow_helpers.UnlockKeyFunc, error) {
+	args := t.sendArguments
+	if args == nil {
+		var err error
+		args, err = t.ArgumentsAsCadence()
+		if err != nil {
+			return nil, flow_helpers.EmptyUnlockKey, err
+		}
 	}
 
 	tx := flow.NewTransaction().
@@ -103,83 +211,155 @@ func (t *StorableTransaction) Prepare(ctx context.Context, flowClient *client.Cl
 
 	for _, a := range args {
 		if err := tx.AddArgument(a); err != nil {
-			return nil, nil, err
+			return nil, flow_helpers.EmptyUnlockKey, err
 		}
 	}
 
-	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	referenceBlock, err := refBlocks.Get(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, flow_helpers.EmptyUnlockKey, err
 	}
 
-	tx.SetReferenceBlockID(latestBlockHeader.ID)
+	tx.SetReferenceBlockID(referenceBlock.ID)
+	t.ReferenceBlockHeight = referenceBlock.Height
 
 	unlock, err := flow_helpers.SignProposeAndPayAs(ctx, flowClient, account, tx)
 	if err != nil {
 		return nil, unlock, err
 	}
 
+	t.ProposerKeyIndex = tx.ProposalKey.KeyIndex
+
 	return tx, unlock, nil
 }
 
 // HandleResult checks the results of a transaction onchain and updates the
 // StorableTransaction accordingly.
-func (t *StorableTransaction) HandleResult(ctx context.Context, flowClient *client.Client) error {
-	logger := log.WithFields(log.Fields{
-		"name":           t.Name,
-		"transactionID":  t.TransactionID,
-		"distributionID": t.DistributionID,
-	})
-
+// A transaction which has not been executed 'expiryMargin' blocks after its
+// reference block expired is set to be retried.
+func (t *StorableTransaction) HandleResult(ctx context.Context, flowClient flow_helpers.FlowClient, expiryMargin uint64, policy RetryPolicy, now time.Time) error {
 	result, err := flowClient.GetTransactionResult(ctx, flow.HexToID(t.TransactionID))
+	if flow_helpers.IsNotFoundError(err) {
+		// Never reached the network (e.g. the service stopped while sending),
+		// handle as pending so it is retried once its reference block expires
+		result, err = &flow.TransactionResult{Status: flow.TransactionStatusUnknown}, nil
+	}
 	if err != nil {
 		return err
 	}
 
+	switch result.Status {
+	case flow.TransactionStatusUnknown, flow.TransactionStatusPending:
+		if result.Error != nil {
+			break
+		}
+		expired, err := t.isExpired(ctx, flowClient, expiryMargin)
+		if err != nil {
+			return err
+		}
+		if expired {
+			t.logger().Info("Transaction expired, retrying")
+			t.Retry("transaction expired", now, policy)
+		}
+		return nil
+	}
+
+	t.SetResult(result, now, policy)
+
+	return nil
+}
+
+// SetResult updates the state of the transaction according to 'result',
+// retrying it with 'policy' if it expired or failed for a retryable reason.
+// Pending results are ignored.
+func (t *StorableTransaction) SetResult(result *flow.TransactionResult, now time.Time, policy RetryPolicy) {
+	logger := t.logger()
+
 	t.Error = ""
 
+	// Executed transactions pay fees, failed ones too
+	if fees, ok := FeesFromEvents(result.Events); ok {
+		t.fees = &fees
+	}
+
 	if result.Error != nil {
 		loggerWithError := logger.WithFields(log.Fields{"error": result.Error.Error()})
 
-		t.Error = result.Error.Error()
-
 		if flow_helpers.IsInvalidProposalSeqNumberError(result.Error) {
-			t.State = common.TransactionStateRetry
+			t.Retry(result.Error.Error(), now, policy)
 			// These can be quite numerous so using trace log level here
 			loggerWithError.Trace("Invalid sequence number, retrying later")
 		} else {
 			t.State = common.TransactionStateFailed
+			t.Error = result.Error.Error()
 			loggerWithError.Warn("Error in transaction")
 		}
-		return nil
+		return
 	}
 
 	switch result.Status {
 	case flow.TransactionStatusExpired:
-		// TODO (latenssi): will we ever get here? if status == expired is result.Error set?
-		t.State = common.TransactionStateRetry
+		logger.Info("Transaction expired, retrying")
+		t.Retry("transaction expired", now, policy)
 	case flow.TransactionStatusSealed:
 		logger.Debug("Transaction sealed")
 		t.State = common.TransactionStateComplete
 	}
+}
 
-	return nil
+func (t *StorableTransaction) logger() *log.Entry {
+	return log.WithFields(log.Fields{
+		"name":           t.Name,
+		"transactionID":  t.TransactionID,
+		"distributionID": t.DistributionID,
+		"retryCount":     t.RetryCount,
+	})
 }
 
-func (t *StorableTransaction) WaitForFinalize(ctx context.Context, flowClient *client.Client) (*flow.TransactionResult, error) {
-	for ctx.Err() == nil {
-		result, err := flowClient.GetTransactionResult(ctx, flow.HexToID(t.TransactionID))
-		if err != nil {
-			return nil, fmt.Errorf("error getting transaction result: %w", err)
-		}
-		if result.Status == flow.TransactionStatusFinalized || result.Status == flow.TransactionStatusSealed {
-			return result, result.Error
-		}
+// isExpired returns true if the reference block of the transaction expired
+// more than 'expiryMargin' blocks ago.
+func (t *StorableTransaction) isExpired(ctx context.Context, flowClient flow_helpers.FlowClient, expiryMargin uint64) (bool, error) {
+	if t.ReferenceBlockHeight == 0 {
+		// Sent before reference block heights were stored
+		return false, nil
+	}
 
-		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && deadline.Before(time.Now()) {
-			return nil, fmt.Errorf("error getting transaction result within timeout")
-		}
-		time.Sleep(time.Duration(100) * time.Millisecond)
+	latestBlockHeader, err := flowClient.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return false, err
 	}
-	return nil, ctx.Err()
+
+	return latestBlockHeader.Height > t.ReferenceBlockHeight+flow_helpers.TransactionExpiry+expiryMargin, nil
+}
+
+// TransactionAttempt records a single send of a StorableTransaction and
+// how it ended. Attempts are the audit log of what the PDS executed onchain,
+// they store the exact script, arguments and signed transaction sent.
+type TransactionAttempt struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"column:id;primary_key;type:uuid;"`
+
+	StorableTransactionID uuid.UUID               `gorm:"column:storable_transaction_id;index"`
+	Attempt               uint                    `gorm:"column:attempt"`        // 1 for the first send
+	TransactionID         string                  `gorm:"column:transaction_id"` // Flow transaction ID
+	ReferenceBlockHeight  uint64                  `gorm:"column:reference_block_height"`
+	ProposerKeyIndex      int                     `gorm:"column:proposer_key_index"`
+	State                 common.TransactionState `gorm:"column:state"` // 'sent' until the outcome is known, 'retry' if the transaction was retried
+	Error                 string                  `gorm:"column:error"`
+
+	DistributionID uuid.UUID      `gorm:"column:distribution_id;index"` // NOTE: Not a proper foreign key
+	Name           string         `gorm:"column:name"`
+	ScriptHash     string         `gorm:"column:script_hash"` // Hex encoded SHA-256 of the script
+	Arguments      datatypes.JSON `gorm:"column:arguments"`
+	RLP            string         `gorm:"column:rlp"` // Hex encoded RLP of the signed transaction
+
+	Fees            uint64 `gorm:"column:fees"` // FLOW paid (UFix64), 0 until executed
+	InclusionEffort uint64 `gorm:"column:inclusion_effort"`
+	ExecutionEffort uint64 `gorm:"column:execution_effort"`
+}
+
+// ScriptHash returns the hex encoded SHA-256 hash of a transaction script.
+func ScriptHash(script string) string {
+	hash := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(hash[:])
 }
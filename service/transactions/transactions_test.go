@@ -0,0 +1,70 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/onflow/cadence"
+)
+
+func TestSplit(t *testing.T) {
+	ids := make([]cadence.Value, 5)
+	for i := range ids {
+		ids[i] = cadence.UInt64(i + 1)
+	}
+
+	distID := uuid.New()
+	tx, err := NewTransactionWithDistributionID("settle", []byte("transaction {}"), []cadence.Value{cadence.UInt64(7), cadence.NewArray(ids)}, distID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	split, err := tx.Split(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(split) != 3 {
+		t.Fatalf("expected 3 transactions, got %d", len(split))
+	}
+
+	next := uint64(1)
+	for i, s := range split {
+		if s.Name != tx.Name || s.Script != tx.Script || s.DistributionID != distID {
+			t.Errorf("transaction %d: expected name, script and distribution to be kept", i)
+		}
+
+		args, err := s.ArgumentsAsCadence()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if args[0] != cadence.UInt64(7) {
+			t.Errorf("transaction %d: expected the first argument to be kept, got %v", i, args[0])
+		}
+
+		arr := args[1].(cadence.Array)
+		if len(arr.Values) != s.BatchSize {
+			t.Errorf("transaction %d: expected batch size %d, got %d", i, len(arr.Values), s.BatchSize)
+		}
+		for _, v := range arr.Values {
+			if v != cadence.UInt64(next) {
+				t.Errorf("transaction %d: expected %d, got %v", i, next, v)
+			}
+			next++
+		}
+	}
+
+	if _, err := tx.Split(0, 2); err == nil {
+		t.Error("expected an error when splitting a non array argument")
+	}
+}
+
+func TestScriptHash(t *testing.T) {
+	if h := ScriptHash(""); h != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatalf("unexpected hash of an empty script %s", h)
+	}
+	if ScriptHash("transaction {}") == ScriptHash("transaction { }") {
+		t.Fatal("expected different scripts to hash differently")
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/client"
-	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
@@ -62,7 +61,7 @@ func getTestCfg(t *testing.T, b *testing.B) *config.Config {
 
 func getTestApp(cfg *config.Config, poll bool) (*app.App, func()) {
 
-	flowClient, err := client.New(cfg.AccessAPIHost, grpc.WithInsecure())
+	flowClient, err := flow_helpers.NewAccessAPIClient(cfg, common.RealClock{})
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle returns the API definition at 'path' as a single JSON document, for
+// tools that do not follow references to other files (e.g. Swagger UI or
+// client generators of issuers). Model files become components/schemas
+// named after the file, their references point there.
+func Bundle(path string) ([]byte, error) {
+	b := &bundler{
+		loader:  &loader{files: make(map[string]*yaml.Node)},
+		schemas: make(map[string]*yaml.Node),
+		sources: make(map[string]string),
+	}
+
+	doc, err := b.file(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewrite a copy, the loader caches the parsed files
+	root, err := b.rewrite(path, copyNode(doc))
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := componentSchemas(root)
+	for _, name := range b.order {
+		setMapping(schemas, name, b.schemas[name])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeJSON(buf, root); err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+
+	return out.Bytes(), nil
+}
+
+type bundler struct {
+	*loader
+	schemas map[string]*yaml.Node
+	sources map[string]string // Model file of each schema name
+	order   []string
+}
+
+// rewrite replaces references to model files in 'n' (of 'file') with
+// references to component schemas, bundling the models.
+func (b *bundler) rewrite(file string, n *yaml.Node) (*yaml.Node, error) {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "$ref" && v.Kind == yaml.ScalarNode && !strings.HasPrefix(v.Value, "#/") {
+				name, err := b.model(filepath.Join(filepath.Dir(file), v.Value))
+				if err != nil {
+					return nil, err
+				}
+				v.Value = "#/components/schemas/" + name
+				continue
+			}
+			if _, err := b.rewrite(file, v); err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	}
+
+	for _, c := range n.Content {
+		if _, err := b.rewrite(file, c); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// model bundles the model file at 'path' and returns its schema name.
+func (b *bundler) model(path string) (string, error) {
+	name := refName(path, "")
+
+	if src, ok := b.sources[name]; ok {
+		if src != path {
+			return "", fmt.Errorf("model files %s and %s have the same name", src, path)
+		}
+		return name, nil
+	}
+	b.sources[name] = path
+
+	n, err := b.file(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Registered before rewriting so recursive models terminate
+	b.order = append(b.order, name)
+	if b.schemas[name], err = b.rewrite(path, copyNode(n)); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// componentSchemas returns the components/schemas mapping of 'doc', adding
+// it if missing.
+func componentSchemas(doc *yaml.Node) *yaml.Node {
+	components := getMapping(doc, "components")
+	if components == nil {
+		components = &yaml.Node{Kind: yaml.MappingNode}
+		setMapping(doc, "components", components)
+	}
+	schemas := getMapping(components, "schemas")
+	if schemas == nil || schemas.Kind != yaml.MappingNode {
+		schemas = &yaml.Node{Kind: yaml.MappingNode}
+		setMapping(components, "schemas", schemas)
+	}
+	return schemas
+}
+
+func getMapping(n *yaml.Node, key string) *yaml.Node {
+	for _, p := range mappingPairs(n) {
+		if p.key == key {
+			return p.value
+		}
+	}
+	return nil
+}
+
+func setMapping(n *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			n.Content[i+1] = value
+			return
+		}
+	}
+	n.Kind, n.Style = yaml.MappingNode, 0 // e.g. 'schemas: {}'
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+// writeJSON writes 'n' as compact JSON, keeping the order of mappings.
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, n.Content[0])
+	case yaml.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			writeJSONString(buf, n.Content[i].Value)
+			buf.WriteString(":")
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null":
+			buf.WriteString("null")
+		case "!!bool":
+			v := false
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			buf.WriteString(strconv.FormatBool(v))
+		case "!!int", "!!float":
+			var v interface{}
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			buf.Write(b)
+		default:
+			writeJSONString(buf, n.Value)
+		}
+	case yaml.AliasNode:
+		return writeJSON(buf, n.Alias)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // Can not fail for a string, Indent drops the newline
+}
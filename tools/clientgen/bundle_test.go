@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleResolvesModelReferences(t *testing.T) {
+	spec, err := Bundle(filepath.Join("..", "..", defaultOptions().spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Components.Schemas["Flow-Address"]; !ok {
+		t.Error("expected the Flow-Address model in components/schemas")
+	}
+
+	// Every reference must point into the document
+	for _, line := range strings.Split(string(spec), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `"$ref"`) {
+			continue
+		}
+		ref := strings.Trim(strings.TrimPrefix(line, `"$ref": `), `",`)
+		if !strings.HasPrefix(ref, "#/") {
+			t.Errorf("unresolved reference %s", ref)
+			continue
+		}
+		if name := strings.TrimPrefix(ref, "#/components/schemas/"); name != ref {
+			if _, ok := doc.Components.Schemas[name]; !ok {
+				t.Errorf("reference to missing schema %s", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestClientsInSync fails if the committed clients or the bundled API
+// definition differ from what the current API definition generates, run
+// 'make clients' to update them.
+func TestClientsInSync(t *testing.T) {
+	opts := defaultOptions()
+	root := filepath.Join("..", "..")
+	opts.spec = filepath.Join(root, opts.spec)
+
+	goCode, tsCode, spec, err := generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		path     string
+		expected []byte
+	}{
+		{filepath.Join(root, opts.goOut), goCode},
+		{filepath.Join(root, opts.tsOut), tsCode},
+		{filepath.Join(root, opts.spOut), spec},
+	} {
+		committed, err := ioutil.ReadFile(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(committed, c.expected) {
+			t.Errorf("%s is out of sync with the API definition, run 'make clients'", c.path)
+		}
+	}
+}
+
+func TestEventStreamsAreNotGenerated(t *testing.T) {
+	api, err := Load(filepath.Join("..", "..", defaultOptions().spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range api.Operations {
+		if op.ID == "stream-distribution-events" {
+			t.Error("expected no client method for the distribution event stream")
+		}
+	}
+
+	found := false
+	for _, s := range api.Types {
+		found = found || s.Name == "DistributionProgress"
+	}
+	if !found {
+		t.Error("expected the type of the distribution events to be generated")
+	}
+}
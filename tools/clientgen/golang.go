@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// GenerateGo generates the types and operations of the Go client, the
+// package also contains the hand written client.go.
+func GenerateGo(api *API, pkg string) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "// Code generated by tools/clientgen from the %s definition. DO NOT EDIT.\n\n", api.Title)
+	fmt.Fprintf(b, "package %s\n\n", pkg)
+
+	imports := []string{"context", "net/http", "net/url"}
+	if usesGoType(api, "time.Time") {
+		imports = append(imports, "time")
+	}
+	if usesStrconv(api) {
+		imports = append(imports, "strconv")
+	}
+	b.WriteString("import (\n")
+	for _, i := range imports {
+		fmt.Fprintf(b, "\t%q\n", i)
+	}
+	b.WriteString(")\n\n")
+
+	for _, t := range api.Types {
+		if t.Description != "" {
+			goComment(b, "", t.Name+" "+t.Description)
+		}
+		if t.Type == "object" {
+			fmt.Fprintf(b, "type %s struct {\n", t.Name)
+			for _, p := range t.Properties {
+				if p.Description != "" {
+					goComment(b, "\t", p.Description)
+				}
+				tag := p.Name
+				if !p.Required {
+					tag += ",omitempty"
+				}
+				fmt.Fprintf(b, "\t%s %s `json:%q`%s\n", pascalCase(p.Name), goFieldType(p), tag, goEnumComment(p.Schema))
+			}
+			b.WriteString("}\n\n")
+		} else {
+			fmt.Fprintf(b, "type %s %s\n\n", t.Name, goPrimitive(t))
+		}
+	}
+
+	for _, op := range api.Operations {
+		goOperation(b, op)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func goOperation(b *bytes.Buffer, op Operation) {
+	name := pascalCase(op.ID)
+
+	// Required query parameters are arguments, optional ones go to a struct
+	var required, query []Parameter
+	for _, p := range queryParameters(op) {
+		if p.Required {
+			required = append(required, p)
+		} else {
+			query = append(query, p)
+		}
+	}
+
+	if len(query) > 0 {
+		goComment(b, "", fmt.Sprintf("%sParams are the optional query parameters of %s.", name, name))
+		fmt.Fprintf(b, "type %sParams struct {\n", name)
+		for _, p := range query {
+			if p.Description != "" {
+				goComment(b, "\t", p.Description)
+			}
+			fmt.Fprintf(b, "\t%s *%s\n", pascalCase(p.Name), goType(p.Schema))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			args = append(args, fmt.Sprintf("%s %s", goArgName(p.Name), goType(p.Schema)))
+		}
+	}
+	for _, p := range required {
+		args = append(args, fmt.Sprintf("%s %s", goArgName(p.Name), goType(p.Schema)))
+	}
+	if len(query) > 0 {
+		args = append(args, fmt.Sprintf("params *%sParams", name))
+	}
+	if op.Body != nil {
+		args = append(args, "body "+goType(op.Body))
+	}
+
+	results := "error"
+	if op.Response != nil {
+		results = fmt.Sprintf("(%s, error)", goType(op.Response))
+	}
+
+	doc := name + " " + op.Summary
+	if d := strings.TrimSpace(op.Description); d != "" {
+		// Terminate the paragraph so gofmt does not turn it into a heading
+		if !strings.ContainsAny(d[len(d)-1:], ".!?:") {
+			d += "."
+		}
+		doc += "\n\n" + d
+	}
+	goComment(b, "", fmt.Sprintf("%s\n\n%s %s", doc, op.Method, op.Path))
+	fmt.Fprintf(b, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), results)
+
+	// Path
+	path := fmt.Sprintf("%q", op.Path)
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			path = strings.Replace(path, "{"+p.Name+"}", `" + url.PathEscape(`+goToString(goArgName(p.Name), p.Schema)+`) + "`, 1)
+		}
+	}
+	path = strings.ReplaceAll(path, ` + ""`, "")
+	fmt.Fprintf(b, "\tpath := %s\n", path)
+
+	// Query
+	b.WriteString("\tquery := url.Values{}\n")
+	for _, p := range required {
+		fmt.Fprintf(b, "\tquery.Set(%q, %s)\n", p.Name, goToString(goArgName(p.Name), p.Schema))
+	}
+	if len(query) > 0 {
+		b.WriteString("\tif params != nil {\n")
+		for _, p := range query {
+			f := "params." + pascalCase(p.Name)
+			fmt.Fprintf(b, "\t\tif %s != nil {\n\t\t\tquery.Set(%q, %s)\n\t\t}\n", f, p.Name, goToString("*"+f, p.Schema))
+		}
+		b.WriteString("\t}\n")
+	}
+
+	bodyArg := "nil"
+	if op.Body != nil {
+		bodyArg = "body"
+	}
+
+	method := "http.Method" + strings.Title(strings.ToLower(op.Method))
+
+	if op.Response != nil {
+		fmt.Fprintf(b, "\tvar res %s\n", goType(op.Response))
+		fmt.Fprintf(b, "\terr := c.do(ctx, %s, path, query, %s, &res, %t)\n", method, bodyArg, op.Admin)
+		b.WriteString("\treturn res, err\n")
+	} else {
+		fmt.Fprintf(b, "\treturn c.do(ctx, %s, path, query, %s, nil, %t)\n", method, bodyArg, op.Admin)
+	}
+	b.WriteString("}\n\n")
+}
+
+func goType(s *Schema) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Type == "array" {
+		return "[]" + goType(s.Items)
+	}
+	if s.Map {
+		return "map[string]interface{}"
+	}
+	return goPrimitive(s)
+}
+
+func goPrimitive(s *Schema) string {
+	switch s.Type {
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time"
+		}
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "interface{}"
+}
+
+// goFieldType returns the type of a struct field, optional objects and
+// timestamps are pointers so they can be left out.
+func goFieldType(p Property) string {
+	t := goType(p.Schema)
+	if !p.Required && ((p.Schema.Type == "object" && !p.Schema.Map) || t == "time.Time") {
+		return "*" + t
+	}
+	return t
+}
+
+func goToString(v string, s *Schema) string {
+	switch s.Type {
+	case "integer":
+		return fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", v)
+	case "number":
+		return fmt.Sprintf("strconv.FormatFloat(float64(%s), 'f', -1, 64)", v)
+	case "boolean":
+		return fmt.Sprintf("strconv.FormatBool(bool(%s))", v)
+	case "string":
+		if s.Format == "date-time" {
+			return fmt.Sprintf("(%s).Format(time.RFC3339)", v)
+		}
+	}
+	return fmt.Sprintf("string(%s)", v)
+}
+
+func goEnumComment(s *Schema) string {
+	if len(s.Enum) == 0 {
+		return ""
+	}
+	return " // One of: " + strings.Join(s.Enum, ", ")
+}
+
+func goComment(b *bytes.Buffer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+		} else {
+			fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimSpace(line))
+		}
+	}
+}
+
+func usesGoType(api *API, t string) bool {
+	for _, s := range api.Types {
+		if goPrimitive(s) == t && s.Type != "object" {
+			return true
+		}
+		for _, p := range s.Properties {
+			if strings.TrimPrefix(goFieldType(p), "*") == t || goType(p.Schema) == "[]"+t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func usesStrconv(api *API) bool {
+	for _, op := range api.Operations {
+		for _, p := range queryParameters(op) {
+			if p.Schema.Type != "string" {
+				return true
+			}
+		}
+	}
+	for _, op := range api.Operations {
+		for _, p := range op.Parameters {
+			if p.In == "path" && p.Schema.Type != "string" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goArgName returns the argument name of parameter 'name', suffixed if it
+// clashes with a variable of the generated methods.
+func goArgName(name string) string {
+	arg := camelCase(name)
+	switch arg {
+	case "c", "ctx", "path", "query", "params", "body", "res", "err":
+		return arg + "Param"
+	}
+	return arg
+}
+
+func queryParameters(op Operation) []Parameter {
+	res := []Parameter{}
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+// Command clientgen generates the Go and TypeScript API clients in ./client
+// and the bundled JSON API definition served by the PDS from the API
+// definition in ./reference. Run from the repository root:
+//
+//	go run ./tools/clientgen
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+)
+
+type options struct {
+	spec  string
+	goOut string
+	goPkg string
+	tsOut string
+	spOut string // Bundled API definition
+}
+
+func defaultOptions() options {
+	return options{
+		spec:  "reference/Flow-PDS-API.yaml",
+		goOut: "client/client_gen.go",
+		goPkg: "client",
+		tsOut: "client/ts/src/client.ts",
+		spOut: "service/http/openapi.json",
+	}
+}
+
+func main() {
+	opts := defaultOptions()
+
+	flag.StringVar(&opts.spec, "spec", opts.spec, "API definition")
+	flag.StringVar(&opts.goOut, "go", opts.goOut, "Go client output file")
+	flag.StringVar(&opts.goPkg, "go-package", opts.goPkg, "Go client package name")
+	flag.StringVar(&opts.tsOut, "ts", opts.tsOut, "TypeScript client output file")
+	flag.StringVar(&opts.spOut, "openapi", opts.spOut, "Bundled JSON API definition output file")
+	flag.Parse()
+
+	goCode, tsCode, spec, err := generate(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(opts.goOut, goCode, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(opts.tsOut, tsCode, 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(opts.spOut, spec, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(opts options) ([]byte, []byte, []byte, error) {
+	api, err := Load(opts.spec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	goCode, err := GenerateGo(api, opts.goPkg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	spec, err := Bundle(opts.spec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return goCode, GenerateTypeScript(api), spec, nil
+}
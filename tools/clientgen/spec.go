@@ -0,0 +1,492 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Only the parts of OpenAPI 3 used by the PDS API definition are supported.
+
+// Schema is a resolved schema, named if it comes from a model file,
+// a component or an inline object.
+type Schema struct {
+	Name        string
+	Description string
+	Type        string // string, integer, number, boolean, array, object
+	Format      string
+	Enum        []string
+	Items       *Schema
+	Properties  []Property
+	Map         bool // Object without properties (free form)
+}
+
+type Property struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+type Parameter struct {
+	Name        string
+	In          string // path or query
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+type Operation struct {
+	ID          string // operationId
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Admin       bool // Requires the admin token
+	Parameters  []Parameter
+	Body        *Schema
+	Response    *Schema // First 2xx JSON response, nil if none
+	Stream      bool    // Responds with server-sent events
+}
+
+type API struct {
+	Title      string
+	Operations []Operation
+	Types      []*Schema // Named object and alias types, sorted by name
+}
+
+type rawSchema struct {
+	Ref                  string      `yaml:"$ref"`
+	Type                 string      `yaml:"type"`
+	Format               string      `yaml:"format"`
+	Title                string      `yaml:"title"`
+	Description          string      `yaml:"description"`
+	Enum                 []string    `yaml:"enum"`
+	Items                yaml.Node   `yaml:"items"`
+	Properties           yaml.Node   `yaml:"properties"`
+	Required             []string    `yaml:"required"`
+	AdditionalProperties interface{} `yaml:"additionalProperties"`
+}
+
+type rawParameter struct {
+	Name        string    `yaml:"name"`
+	In          string    `yaml:"in"`
+	Description string    `yaml:"description"`
+	Required    bool      `yaml:"required"`
+	Schema      yaml.Node `yaml:"schema"`
+}
+
+type rawMediaTypes map[string]struct {
+	Schema yaml.Node `yaml:"schema"`
+}
+
+type rawResponse struct {
+	Ref     string        `yaml:"$ref"`
+	Content rawMediaTypes `yaml:"content"`
+}
+
+type rawOperation struct {
+	OperationID string                `yaml:"operationId"`
+	Summary     string                `yaml:"summary"`
+	Description string                `yaml:"description"`
+	Security    []map[string][]string `yaml:"security"`
+	Parameters  []rawParameter        `yaml:"parameters"`
+	RequestBody *struct {
+		Content rawMediaTypes `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses yaml.Node `yaml:"responses"`
+}
+
+var httpMethods = map[string]bool{"get": true, "post": true, "put": true, "patch": true, "delete": true}
+
+type loader struct {
+	root      string // Path of the API definition
+	doc       *yaml.Node
+	files     map[string]*yaml.Node
+	named     map[string]*Schema
+	resolving map[string]bool
+}
+
+// Load reads the API definition at 'path' resolving references to model files.
+func Load(path string) (*API, error) {
+	l := &loader{
+		root:      path,
+		files:     make(map[string]*yaml.Node),
+		named:     make(map[string]*Schema),
+		resolving: make(map[string]bool),
+	}
+
+	doc, err := l.file(path)
+	if err != nil {
+		return nil, err
+	}
+	l.doc = doc
+
+	var raw struct {
+		Info struct {
+			Title string `yaml:"title"`
+		} `yaml:"info"`
+		Paths yaml.Node `yaml:"paths"`
+	}
+	if err := doc.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	api := &API{Title: raw.Info.Title}
+
+	for _, p := range mappingPairs(&raw.Paths) {
+		path := p.key
+		var pathParams []rawParameter
+
+		for _, m := range mappingPairs(p.value) {
+			if m.key == "parameters" {
+				if err := m.value.Decode(&pathParams); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for _, m := range mappingPairs(p.value) {
+			if !httpMethods[m.key] {
+				continue
+			}
+
+			var rop rawOperation
+			if err := m.value.Decode(&rop); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", m.key, path, err)
+			}
+
+			op, err := l.operation(path, m.key, rop, pathParams)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", m.key, path, err)
+			}
+
+			// Event streams are consumed with an EventSource, only the
+			// types of their events are generated
+			if op.Stream {
+				continue
+			}
+
+			api.Operations = append(api.Operations, op)
+		}
+	}
+
+	for _, s := range l.named {
+		api.Types = append(api.Types, s)
+	}
+	sort.Slice(api.Types, func(i, j int) bool { return api.Types[i].Name < api.Types[j].Name })
+
+	return api, nil
+}
+
+func (l *loader) operation(path, method string, rop rawOperation, pathParams []rawParameter) (Operation, error) {
+	op := Operation{
+		ID:          rop.OperationID,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Summary:     rop.Summary,
+		Description: rop.Description,
+	}
+
+	for _, s := range rop.Security {
+		if _, ok := s["adminToken"]; ok {
+			op.Admin = true
+		}
+	}
+
+	name := pascalCase(op.ID)
+
+	for _, rp := range append(pathParams, rop.Parameters...) {
+		s, err := l.schema(l.root, &rp.Schema, name+pascalCase(rp.Name))
+		if err != nil {
+			return op, err
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        rp.Name,
+			In:          rp.In,
+			Description: rp.Description,
+			Required:    rp.Required || rp.In == "path",
+			Schema:      s,
+		})
+	}
+
+	if rop.RequestBody != nil {
+		if mt, ok := rop.RequestBody.Content["application/json"]; ok {
+			s, err := l.schema(l.root, &mt.Schema, name+"Request")
+			if err != nil {
+				return op, err
+			}
+			op.Body = s
+		}
+	}
+
+	for _, r := range mappingPairs(&rop.Responses) {
+		if !strings.HasPrefix(r.key, "2") {
+			continue
+		}
+
+		var res rawResponse
+		if err := r.value.Decode(&res); err != nil {
+			return op, err
+		}
+
+		responseName := name + "Response"
+		if ref := res.Ref; ref != "" {
+			node, err := l.pointer(l.doc, ref)
+			if err != nil {
+				return op, err
+			}
+			res = rawResponse{}
+			if err := node.Decode(&res); err != nil {
+				return op, err
+			}
+			responseName = pascalCase(refName(ref, r.key))
+		}
+
+		if mt, ok := res.Content["application/json"]; ok {
+			s, err := l.schema(l.root, &mt.Schema, responseName)
+			if err != nil {
+				return op, err
+			}
+			op.Response = s
+		}
+
+		if mt, ok := res.Content["text/event-stream"]; ok {
+			s, err := l.schema(l.root, &mt.Schema, responseName)
+			if err != nil {
+				return op, err
+			}
+			op.Response, op.Stream = s, true
+		}
+
+		break
+	}
+
+	return op, nil
+}
+
+// schema resolves the schema in 'node' found in 'file'. Inline objects are
+// named 'name'.
+func (l *loader) schema(file string, node *yaml.Node, name string) (*Schema, error) {
+	var raw rawSchema
+	if err := node.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	if raw.Ref != "" {
+		return l.ref(file, raw.Ref)
+	}
+
+	s := &Schema{
+		Description: strings.TrimSpace(raw.Description),
+		Type:        raw.Type,
+		Format:      raw.Format,
+		Enum:        raw.Enum,
+	}
+
+	switch raw.Type {
+	case "array":
+		if raw.Items.Kind == 0 {
+			return nil, fmt.Errorf("array without items in %s", file)
+		}
+		items, err := l.schema(file, &raw.Items, name+"Item")
+		if err != nil {
+			return nil, err
+		}
+		s.Items = items
+	case "":
+		if raw.Properties.Kind == 0 && raw.AdditionalProperties == nil {
+			// Any value, e.g. items: {}
+			return s, nil
+		}
+		fallthrough
+	case "object":
+		s.Type = "object"
+		props := mappingPairs(&raw.Properties)
+		if len(props) == 0 {
+			s.Map = true
+			return s, nil
+		}
+
+		required := make(map[string]bool)
+		for _, r := range raw.Required {
+			required[r] = true
+		}
+
+		for _, p := range props {
+			ps, err := l.schema(file, p.value, name+pascalCase(p.key))
+			if err != nil {
+				return nil, err
+			}
+
+			var desc struct {
+				Description string `yaml:"description"`
+			}
+			_ = p.value.Decode(&desc)
+
+			s.Properties = append(s.Properties, Property{
+				Name:        p.key,
+				Description: strings.TrimSpace(desc.Description),
+				Required:    required[p.key],
+				Schema:      ps,
+			})
+		}
+
+		s.Name = name
+		l.named[name] = s
+	}
+
+	return s, nil
+}
+
+// ref resolves a reference to a model file or a component.
+func (l *loader) ref(file, ref string) (*Schema, error) {
+	var target string
+	var node *yaml.Node
+
+	if strings.HasPrefix(ref, "#/") {
+		target = file + ref
+		n, err := l.file(file)
+		if err != nil {
+			return nil, err
+		}
+		if node, err = l.pointer(n, ref); err != nil {
+			return nil, err
+		}
+	} else {
+		target = filepath.Join(filepath.Dir(file), ref)
+		n, err := l.file(target)
+		if err != nil {
+			return nil, err
+		}
+		node = n
+		file = target
+	}
+
+	name := pascalCase(refName(ref, ""))
+	if s, ok := l.named[name]; ok {
+		return s, nil
+	}
+
+	if l.resolving[target] {
+		return nil, fmt.Errorf("recursive reference %s", target)
+	}
+	l.resolving[target] = true
+	defer delete(l.resolving, target)
+
+	s, err := l.schema(file, node, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Named primitive types (e.g. Flow-Address)
+	if s.Name == "" && s.Type != "array" {
+		s.Name = name
+		l.named[name] = s
+	}
+
+	return s, nil
+}
+
+func (l *loader) file(path string) (*yaml.Node, error) {
+	if n, ok := l.files[path]; ok {
+		return n, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	n := &doc
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	l.files[path] = n
+
+	return n, nil
+}
+
+// pointer resolves a local JSON pointer, e.g. '#/components/responses/Ok'.
+func (l *loader) pointer(n *yaml.Node, ref string) (*yaml.Node, error) {
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		var next *yaml.Node
+		for _, p := range mappingPairs(n) {
+			if p.key == part {
+				next = p.value
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("reference %s not found", ref)
+		}
+		n = next
+	}
+	return n, nil
+}
+
+type pair struct {
+	key   string
+	value *yaml.Node
+}
+
+// mappingPairs returns the key value pairs of a mapping node in order.
+func mappingPairs(n *yaml.Node) []pair {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	res := make([]pair, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		res = append(res, pair{n.Content[i].Value, n.Content[i+1]})
+	}
+	return res
+}
+
+// refName returns the name of the referenced schema, e.g. 'Flow-Address'
+// for '../models/Flow-Address.yaml'.
+func refName(ref, fallback string) string {
+	if ref == "" {
+		return fallback
+	}
+	base := ref[strings.LastIndex(ref, "/")+1:]
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// pascalCase converts e.g. 'get-distribution-by-id' to 'GetDistributionById'.
+func pascalCase(s string) string {
+	b := strings.Builder{}
+	upper := true
+	for _, r := range s {
+		if r == '-' || r == '_' || r == ' ' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// camelCase converts e.g. 'Distribution-Id' to 'distributionId'.
+func camelCase(s string) string {
+	p := []rune(pascalCase(s))
+	if len(p) > 0 {
+		p[0] = unicode.ToLower(p[0])
+	}
+	return string(p)
+}
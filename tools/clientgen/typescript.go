@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// GenerateTypeScript generates the TypeScript client, a single module with
+// no dependencies besides a global (or given) fetch.
+func GenerateTypeScript(api *API) []byte {
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "// Code generated by tools/clientgen from the %s definition. DO NOT EDIT.\n\n", api.Title)
+
+	for _, t := range api.Types {
+		if t.Description != "" {
+			tsComment(b, "", t.Description)
+		}
+		if t.Type == "object" {
+			fmt.Fprintf(b, "export interface %s {\n", t.Name)
+			for _, p := range t.Properties {
+				if p.Description != "" {
+					tsComment(b, "  ", p.Description)
+				}
+				optional := "?"
+				if p.Required {
+					optional = ""
+				}
+				fmt.Fprintf(b, "  %s%s: %s;\n", p.Name, optional, tsType(p.Schema))
+			}
+			b.WriteString("}\n\n")
+		} else {
+			fmt.Fprintf(b, "export type %s = %s;\n\n", t.Name, tsPrimitive(t))
+		}
+	}
+
+	b.WriteString(tsRuntime)
+
+	b.WriteString("export class Client {\n")
+	b.WriteString("  private readonly api: Api;\n\n")
+	b.WriteString("  constructor(baseUrl: string, options: ClientOptions = {}) {\n")
+	b.WriteString("    this.api = new Api(baseUrl, options);\n")
+	b.WriteString("  }\n")
+
+	for _, op := range api.Operations {
+		tsOperation(b, op)
+	}
+
+	b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+func tsOperation(b *bytes.Buffer, op Operation) {
+	name := camelCase(op.ID)
+	query := queryParameters(op)
+
+	args := []string{}
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			args = append(args, fmt.Sprintf("%s: %s", camelCase(p.Name), tsType(p.Schema)))
+		}
+	}
+	if op.Body != nil {
+		args = append(args, "body: "+tsType(op.Body))
+	}
+	if len(query) > 0 {
+		fields := []string{}
+		required := false
+		for _, p := range query {
+			if p.Required {
+				required = true
+				fields = append(fields, fmt.Sprintf("%s: %s", p.Name, tsType(p.Schema)))
+			} else {
+				fields = append(fields, fmt.Sprintf("%s?: %s", p.Name, tsType(p.Schema)))
+			}
+		}
+		if required {
+			args = append(args, fmt.Sprintf("params: { %s }", strings.Join(fields, "; ")))
+		} else {
+			args = append(args, fmt.Sprintf("params: { %s } = {}", strings.Join(fields, "; ")))
+		}
+	}
+
+	result := "void"
+	if op.Response != nil {
+		result = tsType(op.Response)
+	}
+
+	doc := op.Summary
+	if op.Description != "" {
+		doc += "\n\n" + op.Description
+	}
+
+	b.WriteString("\n")
+	tsComment(b, "  ", fmt.Sprintf("%s\n\n%s %s", doc, op.Method, op.Path))
+	fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), result)
+
+	path := op.Path
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			path = strings.Replace(path, "{"+p.Name+"}", "${encodeURIComponent(String("+camelCase(p.Name)+"))}", 1)
+		}
+	}
+
+	queryArg := "{}"
+	if len(query) > 0 {
+		queryArg = "params"
+	}
+	bodyArg := "undefined"
+	if op.Body != nil {
+		bodyArg = "body"
+	}
+
+	fmt.Fprintf(b, "    return this.api.request<%s>(%q, `%s`, %s, %s, %t);\n", result, op.Method, path, queryArg, bodyArg, op.Admin)
+	b.WriteString("  }\n")
+}
+
+func tsType(s *Schema) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.Type == "array" {
+		t := tsType(s.Items)
+		if strings.Contains(t, " ") {
+			return "Array<" + t + ">"
+		}
+		return t + "[]"
+	}
+	if s.Map {
+		return "Record<string, unknown>"
+	}
+	return tsPrimitive(s)
+}
+
+func tsPrimitive(s *Schema) string {
+	switch s.Type {
+	case "string":
+		if len(s.Enum) > 0 {
+			return "'" + strings.Join(s.Enum, "' | '") + "'"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	}
+	return "unknown"
+}
+
+func tsComment(b *bytes.Buffer, indent, text string) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.TrimSpace(lines[0]))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(b, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+const tsRuntime = `export interface ClientOptions {
+  /** Bearer token for the admin endpoints */
+  adminToken?: string;
+  /** API key of an issuer, sent with the other requests */
+  apiKey?: string;
+  /** Defaults to the global fetch */
+  fetch?: typeof fetch;
+  /** Extra headers sent with every request */
+  headers?: Record<string, string>;
+}
+
+/** Error for any non 2xx response */
+export class ApiError extends Error {
+  /** Machine readable code of the problem details (RFC 7807), e.g. 'insufficient_escrow' */
+  readonly code?: string;
+  readonly detail?: string;
+
+  constructor(readonly status: number, readonly body: string) {
+    super(` + "`request failed with status ${status}: ${body}`" + `);
+    this.name = 'ApiError';
+    try {
+      const problem = JSON.parse(body);
+      this.code = problem.code;
+      this.detail = problem.detail;
+    } catch {
+      // Not problem details
+    }
+  }
+}
+
+class Api {
+  private readonly baseUrl: string;
+
+  constructor(baseUrl: string, private readonly options: ClientOptions) {
+    this.baseUrl = baseUrl.replace(/\/+$/, '');
+  }
+
+  async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, string | number | boolean | undefined>,
+    body: unknown,
+    admin: boolean,
+  ): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query)) {
+      if (value !== undefined) {
+        params.set(key, String(value));
+      }
+    }
+    const qs = params.toString();
+
+    const headers: Record<string, string> = { Accept: 'application/json, application/problem+json', ...this.options.headers };
+    if (body !== undefined) {
+      headers['Content-Type'] = 'application/json';
+    }
+    if (admin && this.options.adminToken) {
+      headers.Authorization = ` + "`Bearer ${this.options.adminToken}`" + `;
+    } else if (this.options.apiKey) {
+      headers.Authorization = ` + "`Bearer ${this.options.apiKey}`" + `;
+    }
+
+    const doFetch = this.options.fetch ?? fetch;
+    const res = await doFetch(this.baseUrl + path + (qs ? '?' + qs : ''), {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+
+    const text = await res.text();
+    if (!res.ok) {
+      throw new ApiError(res.status, text);
+    }
+
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+}
+
+`